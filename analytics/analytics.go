@@ -0,0 +1,290 @@
+// Package analytics rolls the run events published by the events package up into daily usage
+// statistics per graph and tenant: runs by status, node errors, tokens and latency percentiles.
+//
+// An Aggregator is an events.Publisher: register it with an events.Emitter, or feed it the
+// events consumed from a message bus. It sums events in memory and periodically merges the sums
+// into a Store, such as the MemoryStore or the SQLite store of the sqlite subpackage, which
+// NewHandler serves as JSON:
+//
+//	aggregator := analytics.NewAggregator(store)
+//	go aggregator.Run(ctx, time.Minute)
+//	emitter := events.NewEmitter[State](aggregator, events.WithMetadata(map[string]string{
+//		events.MetadataGraph: "support",
+//	}))
+//
+// Runs are attributed to the graph and tenant of the events.MetadataGraph and
+// events.MetadataTenant metadata of their events, and to the day, in UTC, they finished. Every
+// invocation counts as a run, including those resuming an interrupted one. Events are counted
+// as received: consumers of at-least-once buses count redelivered events again.
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// DayFormat is the layout of the days of Stats.
+const DayFormat = time.DateOnly
+
+// Day returns the day of t, in UTC, in DayFormat.
+func Day(t time.Time) string {
+	return t.UTC().Format(DayFormat)
+}
+
+// Key identifies the stats of a graph and tenant on a day.
+type Key struct {
+	// Day is the day, in DayFormat.
+	Day string `json:"day,omitempty"`
+
+	// Graph is the name of the graph, empty for the runs without graph.
+	Graph string `json:"graph,omitempty"`
+
+	// Tenant is the tenant, empty for the runs without tenant.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Stats are the statistics of the runs of a graph for a tenant on a day.
+type Stats struct {
+	Key
+
+	// Runs is the number of finished runs.
+	Runs int64 `json:"runs"`
+
+	// Completed is the number of runs that reached END.
+	Completed int64 `json:"completed"`
+
+	// Interrupted is the number of runs that paused at an interrupt.
+	Interrupted int64 `json:"interrupted"`
+
+	// Failed is the number of runs that failed.
+	Failed int64 `json:"failed"`
+
+	// NodeErrors is the number of node failures, including those retried or handled.
+	NodeErrors int64 `json:"node_errors"`
+
+	// Usage is the usage of the metered runs.
+	Usage graph.Usage `json:"usage"`
+
+	// Latency is the distribution of the durations of the runs.
+	Latency Latency `json:"latency"`
+}
+
+// Merge adds the counts of other to s, whatever their keys.
+func (s *Stats) Merge(other Stats) {
+	s.Runs += other.Runs
+	s.Completed += other.Completed
+	s.Interrupted += other.Interrupted
+	s.Failed += other.Failed
+	s.NodeErrors += other.NodeErrors
+	s.Usage = s.Usage.Add(other.Usage)
+	s.Latency.Merge(other.Latency)
+}
+
+// ErrorRate returns the share of failed runs, or 0 without runs.
+func (s Stats) ErrorRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(s.Runs)
+}
+
+const (
+	// latencyBase is the upper bound of the first bucket of Latency.
+	latencyBase = time.Millisecond
+
+	// latencyBucketsPerDoubling is the number of buckets of Latency per doubling of the
+	// duration, bounding the error of percentiles to about 19%.
+	latencyBucketsPerDoubling = 4
+
+	// latencyBuckets is the number of buckets of Latency, whose last bucket holds the
+	// durations over a day.
+	latencyBuckets = 108
+)
+
+// Latency is a histogram of durations with exponential buckets, which is small enough to
+// store and can be merged across runs and days, at the cost of approximate percentiles.
+type Latency struct {
+	// Count is the number of durations.
+	Count int64 `json:"count"`
+
+	// Sum is the sum of the durations.
+	Sum time.Duration `json:"sum_ns"`
+
+	// Max is the longest duration.
+	Max time.Duration `json:"max_ns"`
+
+	// Buckets counts the durations by bucket; trailing empty buckets are omitted.
+	Buckets []int64 `json:"buckets,omitempty"`
+}
+
+// Observe adds a duration.
+func (l *Latency) Observe(d time.Duration) {
+	i := latencyBucket(d)
+	if len(l.Buckets) <= i {
+		l.Buckets = slices.Grow(l.Buckets, i+1-len(l.Buckets))[:i+1]
+	}
+	l.Buckets[i]++
+	l.Count++
+	l.Sum += d
+	l.Max = max(l.Max, d)
+}
+
+// Merge adds the durations of other.
+func (l *Latency) Merge(other Latency) {
+	if len(l.Buckets) < len(other.Buckets) {
+		l.Buckets = slices.Grow(l.Buckets, len(other.Buckets)-len(l.Buckets))[:len(other.Buckets)]
+	}
+	for i, n := range other.Buckets {
+		l.Buckets[i] += n
+	}
+	l.Count += other.Count
+	l.Sum += other.Sum
+	l.Max = max(l.Max, other.Max)
+}
+
+// Mean returns the mean duration, or 0 without durations.
+func (l Latency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Sum / time.Duration(l.Count)
+}
+
+// Quantile returns an upper bound of the q-quantile of the durations, e.g. 0.99 for the 99th
+// percentile, or 0 without durations.
+func (l Latency) Quantile(q float64) time.Duration {
+	rank := int64(math.Ceil(q * float64(l.Count)))
+	var seen int64
+	for i, n := range l.Buckets {
+		seen += n
+		if seen >= max(rank, 1) {
+			return min(latencyBound(i), l.Max)
+		}
+	}
+	return l.Max
+}
+
+// latencyBucket returns the index of the bucket of d.
+func latencyBucket(d time.Duration) int {
+	if d <= latencyBase {
+		return 0
+	}
+	i := int(math.Ceil(latencyBucketsPerDoubling * math.Log2(float64(d)/float64(latencyBase))))
+	return min(i, latencyBuckets-1)
+}
+
+// latencyBound returns the upper bound of the bucket with index i.
+func latencyBound(i int) time.Duration {
+	if i == latencyBuckets-1 {
+		return math.MaxInt64
+	}
+	return time.Duration(float64(latencyBase) * math.Exp2(float64(i)/latencyBucketsPerDoubling))
+}
+
+// Aggregator sums run events into Stats and merges them into a Store. It is safe for
+// concurrent use.
+type Aggregator struct {
+	store Store
+
+	mu      sync.Mutex
+	pending map[Key]*Stats
+}
+
+var _ events.Publisher = (*Aggregator)(nil)
+
+// NewAggregator returns an aggregator merging stats into store.
+func NewAggregator(store Store) *Aggregator {
+	return &Aggregator{store: store, pending: make(map[Key]*Stats)}
+}
+
+// Publish adds an event to the pending stats. Only events.KindRunFinished and
+// events.KindNodeFailed events are counted. It never fails.
+func (a *Aggregator) Publish(_ context.Context, e events.Event) error {
+	if e.Kind != events.KindRunFinished && e.Kind != events.KindNodeFailed {
+		return nil
+	}
+	key := Key{Day: Day(e.Time), Graph: e.Metadata[events.MetadataGraph], Tenant: e.Metadata[events.MetadataTenant]}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats, ok := a.pending[key]
+	if !ok {
+		stats = &Stats{Key: key}
+		a.pending[key] = stats
+	}
+	if e.Kind == events.KindNodeFailed {
+		stats.NodeErrors++
+		return nil
+	}
+
+	stats.Runs++
+	switch e.Status {
+	case events.StatusCompleted:
+		stats.Completed++
+	case events.StatusInterrupted:
+		stats.Interrupted++
+	case events.StatusFailed:
+		stats.Failed++
+	}
+	if e.Usage != nil {
+		stats.Usage = stats.Usage.Add(*e.Usage)
+	}
+	stats.Latency.Observe(e.Duration)
+	return nil
+}
+
+// Flush merges the pending stats into the store. On failure they are kept pending, to be
+// merged by the next flush.
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[Key]*Stats)
+	a.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	stats := make([]Stats, 0, len(pending))
+	for _, s := range pending {
+		stats = append(stats, *s)
+	}
+	if err := a.store.Add(ctx, stats); err != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for key, s := range pending {
+			if newer, ok := a.pending[key]; ok {
+				s.Merge(*newer)
+			}
+			a.pending[key] = s
+		}
+		return err
+	}
+	return nil
+}
+
+// Run flushes the pending stats every interval until ctx is done, then flushes them one last
+// time. Failed flushes are logged and retried at the next interval; Run returns the error of
+// the last one.
+func (a *Aggregator) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				slog.WarnContext(ctx, "flushing usage stats failed", "error", err)
+			}
+		case <-ctx.Done():
+			return a.Flush(context.WithoutCancel(ctx))
+		}
+	}
+}
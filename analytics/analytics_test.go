@@ -0,0 +1,181 @@
+package analytics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/analytics"
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+
+	var empty analytics.Latency
+	assert.Zero(t, empty.Mean())
+	assert.Zero(t, empty.Quantile(0.5))
+
+	var l analytics.Latency
+	for i := 1; i <= 100; i++ {
+		l.Observe(time.Duration(i) * 10 * time.Millisecond)
+	}
+	assert.EqualValues(t, 100, l.Count)
+	assert.Equal(t, 505*time.Millisecond, l.Mean())
+	assert.Equal(t, time.Second, l.Max)
+
+	testCases := []struct {
+		q        float64
+		expected time.Duration
+	}{
+		{q: 0, expected: 10 * time.Millisecond},
+		{q: 0.5, expected: 500 * time.Millisecond},
+		{q: 0.9, expected: 900 * time.Millisecond},
+		{q: 0.99, expected: 990 * time.Millisecond},
+		{q: 1, expected: time.Second},
+	}
+	for _, tc := range testCases {
+		got := l.Quantile(tc.q)
+		assert.GreaterOrEqual(t, got, tc.expected, "q=%v", tc.q)
+		assert.LessOrEqual(t, float64(got), 1.2*float64(tc.expected), "q=%v", tc.q)
+	}
+
+	// Merged histograms are the histograms of all the durations.
+	var a, b analytics.Latency
+	for i := 1; i <= 100; i++ {
+		if i%2 == 0 {
+			a.Observe(time.Duration(i) * 10 * time.Millisecond)
+		} else {
+			b.Observe(time.Duration(i) * 10 * time.Millisecond)
+		}
+	}
+	a.Merge(b)
+	assert.Equal(t, l, a)
+
+	// Durations out of range land in the first and last buckets.
+	var extremes analytics.Latency
+	extremes.Observe(0)
+	extremes.Observe(48 * time.Hour)
+	assert.Equal(t, time.Millisecond, extremes.Quantile(0.5))
+	assert.Equal(t, 48*time.Hour, extremes.Quantile(1))
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+
+	store := &analytics.MemoryStore{}
+	aggregator := analytics.NewAggregator(store)
+	ctx := context.Background()
+
+	day := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	metadata := map[string]string{events.MetadataGraph: "support", events.MetadataTenant: "acme"}
+	for _, e := range []events.Event{
+		{Kind: events.KindRunStarted, Time: day, Metadata: metadata},
+		{Kind: events.KindNodeCompleted, Time: day, Metadata: metadata},
+		{Kind: events.KindNodeFailed, Time: day, Metadata: metadata},
+		{Kind: events.KindRunFinished, Status: events.StatusFailed, Duration: time.Second, Time: day, Metadata: metadata},
+		{
+			Kind: events.KindRunFinished, Status: events.StatusCompleted, Duration: 3 * time.Second,
+			Usage: &graph.Usage{Calls: 2, InputTokens: 100, OutputTokens: 10}, Time: day, Metadata: metadata,
+		},
+		{Kind: events.KindRunFinished, Status: events.StatusInterrupted, Time: day.Add(2 * time.Hour), Metadata: metadata},
+		{Kind: events.KindRunFinished, Status: events.StatusCompleted, Time: day},
+	} {
+		require.NoError(t, aggregator.Publish(ctx, e))
+	}
+	require.NoError(t, aggregator.Flush(ctx))
+	require.NoError(t, aggregator.Flush(ctx))
+
+	stats, err := store.Query(ctx, analytics.Query{})
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+
+	assert.Equal(t, analytics.Key{Day: "2024-05-01"}, stats[0].Key)
+	assert.EqualValues(t, 1, stats[0].Runs)
+
+	acme := stats[1]
+	assert.Equal(t, analytics.Key{Day: "2024-05-01", Graph: "support", Tenant: "acme"}, acme.Key)
+	assert.EqualValues(t, 2, acme.Runs)
+	assert.EqualValues(t, 1, acme.Completed)
+	assert.EqualValues(t, 1, acme.Failed)
+	assert.EqualValues(t, 1, acme.NodeErrors)
+	assert.InDelta(t, 0.5, acme.ErrorRate(), 1e-9)
+	assert.Equal(t, graph.Usage{Calls: 2, InputTokens: 100, OutputTokens: 10}, acme.Usage)
+	assert.Equal(t, 2*time.Second, acme.Latency.Mean())
+	assert.Equal(t, 3*time.Second, acme.Latency.Quantile(0.99))
+
+	assert.Equal(t, "2024-05-02", stats[2].Day)
+	assert.EqualValues(t, 1, stats[2].Interrupted)
+}
+
+// failingStore fails to add stats while failing is set.
+type failingStore struct {
+	analytics.MemoryStore
+	failing bool
+}
+
+func (f *failingStore) Add(ctx context.Context, stats []analytics.Stats) error {
+	if f.failing {
+		return errors.New("unavailable")
+	}
+	return f.MemoryStore.Add(ctx, stats)
+}
+
+func TestAggregatorRetries(t *testing.T) {
+	t.Parallel()
+
+	store := &failingStore{failing: true}
+	aggregator := analytics.NewAggregator(store)
+	ctx := context.Background()
+	finished := events.Event{Kind: events.KindRunFinished, Status: events.StatusCompleted, Time: time.Now()}
+
+	require.NoError(t, aggregator.Publish(ctx, finished))
+	require.Error(t, aggregator.Flush(ctx))
+	require.NoError(t, aggregator.Publish(ctx, finished))
+	require.Error(t, aggregator.Flush(ctx))
+
+	store.failing = false
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, aggregator.Run(runCtx, time.Hour))
+
+	stats, err := store.Query(ctx, analytics.Query{})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.EqualValues(t, 2, stats[0].Runs, "failed flushes are retried")
+}
+
+func TestAggregatorEmitter(t *testing.T) {
+	t.Parallel()
+
+	store := &analytics.MemoryStore{}
+	aggregator := analytics.NewAggregator(store)
+
+	g := graph.NewMessageGraph[int]("a")
+	g.AddNode("a", func(ctx context.Context, state int) (int, error) {
+		graph.RecordUsage(ctx, graph.Usage{Calls: 1, InputTokens: 7})
+		return state, nil
+	})
+	g.AddEdge("a", graph.END)
+	emitter := events.NewEmitter[int](aggregator, events.WithMetadata(map[string]string{events.MetadataGraph: "g"}))
+	runnable, err := g.Compile(graph.WithCallbacks[int](emitter))
+	require.NoError(t, err)
+
+	ctx := graph.WithUsageMeter(context.Background(), &graph.UsageMeter{})
+	for _, tenant := range []string{"a", "b", "a"} {
+		_, err := runnable.Invoke(events.WithRunMetadata(ctx, map[string]string{events.MetadataTenant: tenant}), 0)
+		require.NoError(t, err)
+	}
+	require.NoError(t, aggregator.Flush(ctx))
+
+	stats, err := store.Query(ctx, analytics.Query{Graph: "g", Tenant: "a"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.EqualValues(t, 2, stats[0].Completed)
+	assert.Equal(t, graph.Usage{Calls: 2, InputTokens: 14}, stats[0].Usage)
+}
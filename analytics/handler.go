@@ -0,0 +1,183 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Summary is the JSON representation of Stats served by NewHandler, with the latency
+// distribution reduced to its mean and percentiles.
+type Summary struct {
+	Key
+
+	// Runs is the number of finished runs.
+	Runs int64 `json:"runs"`
+
+	// Completed is the number of runs that reached END.
+	Completed int64 `json:"completed"`
+
+	// Interrupted is the number of runs that paused at an interrupt.
+	Interrupted int64 `json:"interrupted"`
+
+	// Failed is the number of runs that failed.
+	Failed int64 `json:"failed"`
+
+	// ErrorRate is the share of failed runs.
+	ErrorRate float64 `json:"error_rate"`
+
+	// NodeErrors is the number of node failures.
+	NodeErrors int64 `json:"node_errors"`
+
+	// Usage is the usage of the metered runs.
+	Usage graph.Usage `json:"usage"`
+
+	// LatencyMean is the mean duration of the runs.
+	LatencyMean time.Duration `json:"latency_mean_ns"`
+
+	// LatencyP50 is the median duration of the runs.
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+
+	// LatencyP90 is the 90th percentile of the durations of the runs.
+	LatencyP90 time.Duration `json:"latency_p90_ns"`
+
+	// LatencyP99 is the 99th percentile of the durations of the runs.
+	LatencyP99 time.Duration `json:"latency_p99_ns"`
+}
+
+// Summary returns the summary of s.
+func (s Stats) Summary() Summary {
+	return Summary{
+		Key:         s.Key,
+		Runs:        s.Runs,
+		Completed:   s.Completed,
+		Interrupted: s.Interrupted,
+		Failed:      s.Failed,
+		ErrorRate:   s.ErrorRate(),
+		NodeErrors:  s.NodeErrors,
+		Usage:       s.Usage,
+		LatencyMean: s.Latency.Mean(),
+		LatencyP50:  s.Latency.Quantile(0.5),
+		LatencyP90:  s.Latency.Quantile(0.9),
+		LatencyP99:  s.Latency.Quantile(0.99),
+	}
+}
+
+// Rollup merges the stats whose keys map to the same key with by, e.g. to sum the stats of a
+// graph over days and tenants, and returns them ordered by key.
+func Rollup(stats []Stats, by func(Key) Key) []Stats {
+	merged := make(map[Key]*Stats)
+	var keys []Key
+	for _, s := range stats {
+		key := by(s.Key)
+		m, ok := merged[key]
+		if !ok {
+			m = &Stats{Key: key}
+			merged[key] = m
+			keys = append(keys, key)
+		}
+		m.Merge(s)
+	}
+	slices.SortFunc(keys, Compare)
+
+	rolled := make([]Stats, len(keys))
+	for i, key := range keys {
+		rolled[i] = *merged[key]
+	}
+	return rolled
+}
+
+// NewHandler returns a handler serving the stats of the store to GET requests, as a JSON array
+// of Summary. The query parameters select and group them:
+//
+//   - from and to bound the days, inclusive, in DayFormat;
+//   - graph and tenant select a graph and a tenant;
+//   - group lists, comma-separated, the fields of the key the stats are grouped by, among day,
+//     graph and tenant; by default stats are reported per day, graph and tenant, and with an
+//     empty group they are summed into one.
+func NewHandler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		params := r.URL.Query()
+		q := Query{Graph: params.Get("graph"), Tenant: params.Get("tenant")}
+		var err error
+		if q.From, err = parseDay(params.Get("from")); err != nil {
+			http.Error(w, fmt.Sprintf("from: %v", err), http.StatusBadRequest)
+			return
+		}
+		if q.To, err = parseDay(params.Get("to")); err != nil {
+			http.Error(w, fmt.Sprintf("to: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		stats, err := store.Query(r.Context(), q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if params.Has("group") {
+			by, err := groupBy(params.Get("group"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			stats = Rollup(stats, by)
+		}
+
+		summaries := make([]Summary, len(stats))
+		for i, s := range stats {
+			summaries[i] = s.Summary()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(summaries)
+	})
+}
+
+// parseDay parses a day in DayFormat, or returns the zero time if empty.
+func parseDay(day string) (time.Time, error) {
+	if day == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(DayFormat, day)
+}
+
+// groupBy returns the function keeping the fields of a key listed in group.
+func groupBy(group string) (func(Key) Key, error) {
+	var day, graph, tenant bool
+	for _, field := range strings.Split(group, ",") {
+		switch strings.TrimSpace(field) {
+		case "":
+		case "day":
+			day = true
+		case "graph":
+			graph = true
+		case "tenant":
+			tenant = true
+		default:
+			return nil, fmt.Errorf("unknown group field %q", field)
+		}
+	}
+	return func(key Key) Key {
+		var grouped Key
+		if day {
+			grouped.Day = key.Day
+		}
+		if graph {
+			grouped.Graph = key.Graph
+		}
+		if tenant {
+			grouped.Tenant = key.Tenant
+		}
+		return grouped
+	}, nil
+}
@@ -0,0 +1,130 @@
+package analytics_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/analytics"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	store := &analytics.MemoryStore{}
+	stats := func(day, g, tenant string, runs, failed int64, latency time.Duration) analytics.Stats {
+		s := analytics.Stats{
+			Key:  analytics.Key{Day: day, Graph: g, Tenant: tenant},
+			Runs: runs, Completed: runs - failed, Failed: failed,
+			Usage: graph.Usage{Calls: int(runs), InputTokens: int(10 * runs)},
+		}
+		for range runs {
+			s.Latency.Observe(latency)
+		}
+		return s
+	}
+	require.NoError(t, store.Add(context.Background(), []analytics.Stats{
+		stats("2024-05-01", "g", "a", 2, 1, time.Second),
+		stats("2024-05-01", "g", "b", 2, 0, time.Second),
+		stats("2024-05-02", "g", "a", 4, 0, time.Second),
+		stats("2024-05-02", "h", "a", 1, 1, time.Second),
+	}))
+	handler := analytics.NewHandler(store)
+
+	testCases := []struct {
+		name     string
+		target   string
+		status   int
+		expected []analytics.Key
+		runs     []int64
+	}{
+		{
+			name:   "all",
+			target: "/",
+			status: http.StatusOK,
+			expected: []analytics.Key{
+				{Day: "2024-05-01", Graph: "g", Tenant: "a"},
+				{Day: "2024-05-01", Graph: "g", Tenant: "b"},
+				{Day: "2024-05-02", Graph: "g", Tenant: "a"},
+				{Day: "2024-05-02", Graph: "h", Tenant: "a"},
+			},
+			runs: []int64{2, 2, 4, 1},
+		},
+		{
+			name:     "filtered",
+			target:   "/?from=2024-05-02&to=2024-05-02&graph=g",
+			status:   http.StatusOK,
+			expected: []analytics.Key{{Day: "2024-05-02", Graph: "g", Tenant: "a"}},
+			runs:     []int64{4},
+		},
+		{
+			name:     "grouped by graph",
+			target:   "/?group=graph",
+			status:   http.StatusOK,
+			expected: []analytics.Key{{Graph: "g"}, {Graph: "h"}},
+			runs:     []int64{8, 1},
+		},
+		{
+			name:     "grouped by day and tenant",
+			target:   "/?group=day,tenant&graph=g",
+			status:   http.StatusOK,
+			expected: []analytics.Key{{Day: "2024-05-01", Tenant: "a"}, {Day: "2024-05-01", Tenant: "b"}, {Day: "2024-05-02", Tenant: "a"}},
+			runs:     []int64{2, 2, 4},
+		},
+		{
+			name:     "total",
+			target:   "/?group=",
+			status:   http.StatusOK,
+			expected: []analytics.Key{{}},
+			runs:     []int64{9},
+		},
+		{name: "invalid day", target: "/?from=yesterday", status: http.StatusBadRequest},
+		{name: "invalid group", target: "/?group=node", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			if tc.status != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var summaries []analytics.Summary
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+			var keys []analytics.Key
+			var runs []int64
+			for _, s := range summaries {
+				keys = append(keys, s.Key)
+				runs = append(runs, s.Runs)
+				assert.Equal(t, s.Runs*10, int64(s.Usage.InputTokens))
+				assert.Equal(t, time.Second, s.LatencyP99)
+			}
+			assert.Equal(t, tc.expected, keys)
+			assert.Equal(t, tc.runs, runs)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?group=", nil))
+	var total []analytics.Summary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &total))
+	assert.InDelta(t, 2.0/9, total[0].ErrorRate, 1e-9)
+	assert.Equal(t, time.Second, total[0].LatencyMean)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodGet, rec.Header().Get("Allow"))
+}
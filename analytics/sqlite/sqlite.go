@@ -0,0 +1,172 @@
+//go:build !js && !wasip1
+
+// Package sqlite implements analytics.Store on a SQLite database, so usage stats survive
+// restarts without running a database server.
+//
+// The stats are stored in one table, created when the store is opened, with a row per day,
+// graph and tenant holding the stats as JSON. The driver is the pure Go modernc.org/sqlite, so
+// cgo is not required, but it does not support WebAssembly: the package is not built for js and
+// wasip1.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	// Registers the "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+
+	"github.com/cesto93/langgraphgo/analytics"
+)
+
+// DefaultTable is the name of the table of stats when none is configured.
+const DefaultTable = "usage_stats"
+
+// Store is an analytics.Store on SQLite.
+type Store struct {
+	db    *sql.DB
+	table string
+
+	// owned is set when the database was opened by Open, and is closed by Close.
+	owned bool
+}
+
+var _ analytics.Store = (*Store)(nil)
+
+// Open opens, or creates, the database file at path and its table of stats. The database is
+// opened in WAL mode and waits for locks instead of failing when several processes share it.
+func Open(ctx context.Context, path string) (*Store, error) {
+	dsn := "file:" + path + "?" + url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)"}}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	s, err := New(ctx, db, DefaultTable)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New returns a store keeping stats in the table of db, DefaultTable if empty, which is created
+// if it does not exist. The database is not closed by Close.
+func New(ctx context.Context, db *sql.DB, table string) (*Store, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	s := &Store{db: db, table: table}
+	if _, err := db.ExecContext(ctx, s.query(`
+CREATE TABLE IF NOT EXISTS %s (
+	day    TEXT NOT NULL,
+	graph  TEXT NOT NULL,
+	tenant TEXT NOT NULL,
+	stats  TEXT NOT NULL,
+	PRIMARY KEY (day, graph, tenant)
+)`)); err != nil {
+		return nil, fmt.Errorf("creating table %s: %w", table, err)
+	}
+	return s, nil
+}
+
+// Close closes the database if it was opened by Open.
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// query returns the query with the quoted name of the table in place of %s.
+func (s *Store) query(q string) string {
+	return fmt.Sprintf(q, `"`+s.table+`"`)
+}
+
+// Add merges stats into the stored stats of their keys, in a transaction.
+func (s *Store) Add(ctx context.Context, stats []analytics.Stats) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("adding stats: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, added := range stats {
+		// Inserting the row first takes the write lock before reading it, so concurrent writers
+		// wait for each other instead of failing to upgrade their read locks.
+		if _, err := tx.ExecContext(ctx, s.query(`
+INSERT INTO %s (day, graph, tenant, stats) VALUES (?, ?, ?, '{}') ON CONFLICT (day, graph, tenant) DO NOTHING`),
+			added.Day, added.Graph, added.Tenant); err != nil {
+			return fmt.Errorf("writing stats %s/%s/%s: %w", added.Day, added.Graph, added.Tenant, err)
+		}
+
+		var encoded string
+		if err := tx.QueryRowContext(ctx, s.query(`SELECT stats FROM %s WHERE day = ? AND graph = ? AND tenant = ?`),
+			added.Day, added.Graph, added.Tenant).Scan(&encoded); err != nil {
+			return fmt.Errorf("reading stats %s/%s/%s: %w", added.Day, added.Graph, added.Tenant, err)
+		}
+		var merged analytics.Stats
+		if err := json.Unmarshal([]byte(encoded), &merged); err != nil {
+			return fmt.Errorf("decoding stats %s/%s/%s: %w", added.Day, added.Graph, added.Tenant, err)
+		}
+		merged.Merge(added)
+
+		data, err := json.Marshal(merged)
+		if err != nil {
+			return fmt.Errorf("encoding stats %s/%s/%s: %w", added.Day, added.Graph, added.Tenant, err)
+		}
+		if _, err := tx.ExecContext(ctx, s.query(`UPDATE %s SET stats = ? WHERE day = ? AND graph = ? AND tenant = ?`),
+			string(data), added.Day, added.Graph, added.Tenant); err != nil {
+			return fmt.Errorf("writing stats %s/%s/%s: %w", added.Day, added.Graph, added.Tenant, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("adding stats: %w", err)
+	}
+	return nil
+}
+
+// Query returns the stored stats matching q, ordered by day, graph and tenant.
+func (s *Store) Query(ctx context.Context, q analytics.Query) ([]analytics.Stats, error) {
+	where, args := "1 = 1", []any{}
+	if !q.From.IsZero() {
+		where, args = where+" AND day >= ?", append(args, analytics.Day(q.From))
+	}
+	if !q.To.IsZero() {
+		where, args = where+" AND day <= ?", append(args, analytics.Day(q.To))
+	}
+	if q.Graph != "" {
+		where, args = where+" AND graph = ?", append(args, q.Graph)
+	}
+	if q.Tenant != "" {
+		where, args = where+" AND tenant = ?", append(args, q.Tenant)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT day, graph, tenant, stats FROM %s WHERE `+where+` ORDER BY day, graph, tenant`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []analytics.Stats
+	for rows.Next() {
+		var key analytics.Key
+		var encoded string
+		if err := rows.Scan(&key.Day, &key.Graph, &key.Tenant, &encoded); err != nil {
+			return nil, fmt.Errorf("querying stats: %w", err)
+		}
+		var st analytics.Stats
+		if err := json.Unmarshal([]byte(encoded), &st); err != nil {
+			return nil, fmt.Errorf("decoding stats %s/%s/%s: %w", key.Day, key.Graph, key.Tenant, err)
+		}
+		st.Key = key
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,87 @@
+//go:build !js && !wasip1
+
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/analytics"
+	"github.com/cesto93/langgraphgo/analytics/sqlite"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "stats.db")
+	store, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+
+	added := analytics.Stats{
+		Key:  analytics.Key{Day: "2024-05-01", Graph: "g", Tenant: "a"},
+		Runs: 1, Completed: 1, Usage: graph.Usage{Calls: 1, InputTokens: 10},
+	}
+	added.Latency.Observe(time.Second)
+	require.NoError(t, store.Add(ctx, []analytics.Stats{
+		added,
+		{Key: analytics.Key{Day: "2024-05-02", Graph: "g"}, Runs: 1, Failed: 1},
+		{Key: analytics.Key{Day: "2024-05-01", Graph: "h", Tenant: "a"}, Runs: 5},
+	}))
+
+	// Stores sharing the file merge their stats.
+	other, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for _, s := range []*sqlite.Store{store, other, store, other} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Add(ctx, []analytics.Stats{added}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, other.Close())
+
+	stats, err := store.Query(ctx, analytics.Query{})
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, analytics.Key{Day: "2024-05-01", Graph: "g", Tenant: "a"}, stats[0].Key)
+	assert.Equal(t, analytics.Key{Day: "2024-05-01", Graph: "h", Tenant: "a"}, stats[1].Key)
+	assert.Equal(t, analytics.Key{Day: "2024-05-02", Graph: "g"}, stats[2].Key)
+
+	merged := stats[0]
+	assert.EqualValues(t, 5, merged.Runs)
+	assert.Equal(t, graph.Usage{Calls: 5, InputTokens: 50}, merged.Usage)
+	assert.EqualValues(t, 5, merged.Latency.Count)
+	assert.Equal(t, time.Second, merged.Latency.Quantile(0.5))
+	require.NoError(t, store.Close())
+
+	// Stats survive reopening, and are queried like those of the memory store.
+	store, err = sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, q := range []analytics.Query{
+		{From: day, To: day},
+		{Graph: "g"},
+		{Tenant: "a"},
+		{From: day.AddDate(0, 0, 1), Graph: "g", Tenant: ""},
+	} {
+		var memory analytics.MemoryStore
+		require.NoError(t, memory.Add(ctx, stats))
+		expected, err := memory.Query(ctx, q)
+		require.NoError(t, err)
+		got, err := store.Query(ctx, q)
+		require.NoError(t, err)
+		assert.Equal(t, expected, got, "%+v", q)
+	}
+}
@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store persists Stats.
+type Store interface {
+	// Add merges stats into the stored stats of their keys.
+	Add(ctx context.Context, stats []Stats) error
+
+	// Query returns the stored stats matching q, ordered by day, graph and tenant.
+	Query(ctx context.Context, q Query) ([]Stats, error)
+}
+
+// Query selects stats.
+type Query struct {
+	// From is the first day of the stats; the zero time does not bound them.
+	From time.Time
+
+	// To is the last day of the stats; the zero time does not bound them.
+	To time.Time
+
+	// Graph selects the stats of a graph; empty selects every graph.
+	Graph string
+
+	// Tenant selects the stats of a tenant; empty selects every tenant.
+	Tenant string
+}
+
+// Match reports whether q selects the stats of key.
+func (q Query) Match(key Key) bool {
+	return (q.From.IsZero() || key.Day >= Day(q.From)) &&
+		(q.To.IsZero() || key.Day <= Day(q.To)) &&
+		(q.Graph == "" || key.Graph == q.Graph) &&
+		(q.Tenant == "" || key.Tenant == q.Tenant)
+}
+
+// Compare orders keys by day, graph and tenant.
+func Compare(a, b Key) int {
+	return cmp.Or(cmp.Compare(a.Day, b.Day), cmp.Compare(a.Graph, b.Graph), cmp.Compare(a.Tenant, b.Tenant))
+}
+
+// MemoryStore is a Store in memory, for tests and single-process applications. The zero value
+// is ready to use and it is safe for concurrent use.
+type MemoryStore struct {
+	mu    sync.Mutex
+	stats map[Key]Stats
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// Add merges stats into the stored stats of their keys.
+func (m *MemoryStore) Add(_ context.Context, stats []Stats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stats == nil {
+		m.stats = make(map[Key]Stats)
+	}
+	for _, s := range stats {
+		stored := m.stats[s.Key]
+		stored.Key = s.Key
+		stored.Merge(s)
+		m.stats[s.Key] = stored
+	}
+	return nil
+}
+
+// Query returns the stored stats matching q, ordered by day, graph and tenant.
+func (m *MemoryStore) Query(_ context.Context, q Query) ([]Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stats []Stats
+	for key, s := range m.stats {
+		if q.Match(key) {
+			s.Latency.Buckets = slices.Clone(s.Latency.Buckets)
+			stats = append(stats, s)
+		}
+	}
+	slices.SortFunc(stats, func(a, b Stats) int { return Compare(a.Key, b.Key) })
+	return stats, nil
+}
@@ -0,0 +1,75 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/analytics"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := &analytics.MemoryStore{}
+	ctx := context.Background()
+	require.NoError(t, store.Add(ctx, []analytics.Stats{
+		{Key: analytics.Key{Day: "2024-05-02", Graph: "g", Tenant: "b"}, Runs: 1},
+		{Key: analytics.Key{Day: "2024-05-01", Graph: "g", Tenant: "a"}, Runs: 2},
+		{Key: analytics.Key{Day: "2024-05-03", Graph: "h"}, Runs: 3},
+	}))
+	require.NoError(t, store.Add(ctx, []analytics.Stats{
+		{Key: analytics.Key{Day: "2024-05-01", Graph: "g", Tenant: "a"}, Runs: 4},
+	}))
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	testCases := []struct {
+		name     string
+		query    analytics.Query
+		expected []analytics.Key
+	}{
+		{
+			name: "all",
+			expected: []analytics.Key{
+				{Day: "2024-05-01", Graph: "g", Tenant: "a"},
+				{Day: "2024-05-02", Graph: "g", Tenant: "b"},
+				{Day: "2024-05-03", Graph: "h"},
+			},
+		},
+		{
+			name:     "days",
+			query:    analytics.Query{From: day(2), To: day(2)},
+			expected: []analytics.Key{{Day: "2024-05-02", Graph: "g", Tenant: "b"}},
+		},
+		{
+			name:     "graph and tenant",
+			query:    analytics.Query{Graph: "g", Tenant: "a"},
+			expected: []analytics.Key{{Day: "2024-05-01", Graph: "g", Tenant: "a"}},
+		},
+		{
+			name:  "none",
+			query: analytics.Query{Graph: "missing"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stats, err := store.Query(ctx, tc.query)
+			require.NoError(t, err)
+			var keys []analytics.Key
+			for _, s := range stats {
+				keys = append(keys, s.Key)
+			}
+			assert.Equal(t, tc.expected, keys)
+		})
+	}
+
+	stats, err := store.Query(ctx, analytics.Query{Tenant: "a"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, stats[0].Runs, "added stats are merged")
+}
@@ -0,0 +1,361 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/config"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+var (
+	// ErrUnknownCheckpointerType is returned when a manifest declares a checkpointer of an
+	// unsupported type.
+	ErrUnknownCheckpointerType = config.ErrUnknownCheckpointerType
+
+	// ErrUnknownGraph is returned when invoking a graph the application does not declare.
+	ErrUnknownGraph = errors.New("unknown graph")
+)
+
+// ShutdownTimeout bounds how long Run waits for in-flight HTTP requests when its context is done.
+const ShutdownTimeout = 10 * time.Second
+
+// App is a set of compiled graphs wired to their checkpointers, routes and schedules.
+// All the graphs of an App share the state type T.
+type App[T any] struct {
+	manifest      *Manifest
+	graphs        map[string]*graph.Runnable[T]
+	checkpointers map[string]checkpoint.Checkpointer
+	inputs        []T
+}
+
+// Open reads the manifest at path and builds the application it declares.
+// Spec paths are resolved relative to the directory of the manifest.
+func Open[T any](path string, r *spec.Registry[T]) (*App[T], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(m, os.DirFS(filepath.Dir(path)), r)
+}
+
+// New builds the application declared by the manifest, reading graph specs from fsys and
+// building their nodes with the registry.
+func New[T any](m *Manifest, fsys fs.FS, r *spec.Registry[T]) (*App[T], error) {
+	a := &App[T]{
+		manifest:      m,
+		graphs:        make(map[string]*graph.Runnable[T], len(m.Graphs)),
+		checkpointers: make(map[string]checkpoint.Checkpointer, len(m.Checkpointers)),
+	}
+
+	for _, name := range sortedKeys(m.Checkpointers) {
+		cp, err := config.NewCheckpointer(config.CheckpointerConfig(m.Checkpointers[name]))
+		if err != nil {
+			return nil, fmt.Errorf("checkpointer %s: %w", name, err)
+		}
+		a.checkpointers[name] = cp
+	}
+
+	snapshot, err := readSnapshot(fsys, m.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(m.Graphs) {
+		g := m.Graphs[name]
+		data, err := fs.ReadFile(fsys, g.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("graph %s: %w", name, err)
+		}
+
+		opts := []graph.CompileOption{graph.WithInterruptBefore(g.InterruptBefore...), graph.WithInterruptAfter(g.InterruptAfter...)}
+		if cp, ok := a.checkpointers[g.Checkpointer]; ok {
+			opts = append(opts, graph.WithCheckpointer(cp))
+		}
+		plan, planned := snapshot.Graphs[name]
+		if planned {
+			opts = append(opts, graph.WithPlan(plan))
+		}
+		runnable, err := spec.LoadWithParams(data, r, spec.Values(g.Params), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("graph %s: %w", name, err)
+		}
+		if planned && runnable.Plan().Fingerprint != plan.Fingerprint {
+			slog.Warn("graph changed since the snapshot, validated", "graph", name, "snapshot", m.Snapshot)
+		}
+		a.graphs[name] = runnable
+	}
+
+	for i, s := range m.Schedules {
+		var input T
+		if !s.Input.IsZero() {
+			if err := s.Input.Decode(&input); err != nil {
+				return nil, fmt.Errorf("schedules[%d]: decoding input: %w", i, err)
+			}
+		}
+		a.inputs = append(a.inputs, input)
+	}
+
+	return a, nil
+}
+
+// Graph returns the compiled graph with the given name.
+func (a *App[T]) Graph(name string) (*graph.Runnable[T], bool) {
+	g, ok := a.graphs[name]
+	return g, ok
+}
+
+// Schema returns the schema of the state of the graph with the given name.
+func (a *App[T]) Schema(name string) (graph.StateSchema, bool) {
+	g, ok := a.graphs[name]
+	if !ok {
+		return graph.StateSchema{}, false
+	}
+	return g.Schema(), true
+}
+
+// Checkpointer returns the checkpointer with the given name.
+func (a *App[T]) Checkpointer(name string) (checkpoint.Checkpointer, bool) {
+	cp, ok := a.checkpointers[name]
+	return cp, ok
+}
+
+// GraphCheckpointer returns the checkpointer the threads of the named graph are saved to.
+func (a *App[T]) GraphCheckpointer(name string) (checkpoint.Checkpointer, bool) {
+	g, ok := a.manifest.Graphs[name]
+	if !ok {
+		return nil, false
+	}
+	cp, ok := a.checkpointers[g.Checkpointer]
+	return cp, ok
+}
+
+// Invoke runs the named graph on state. When threadID is not empty and the graph has a
+// checkpointer, the thread is saved after every step, at interrupts and once complete; see
+// graph.Runnable.Invoke. When execution pauses at an interrupt, Invoke returns the state reached
+// and an error matching graph.ErrInterrupted, and Resume continues the thread.
+func (a *App[T]) Invoke(ctx context.Context, name, threadID string, state T) (T, error) {
+	g, ok := a.graphs[name]
+	if !ok {
+		return state, fmt.Errorf("%w: %s", ErrUnknownGraph, name)
+	}
+	if threadID != "" {
+		ctx = graph.WithThreadID(ctx, threadID)
+	}
+	return g.Invoke(ctx, state)
+}
+
+// Resume continues the thread of the named graph paused at an interrupt with state, the state
+// returned with the interrupt, possibly edited. It returns an error matching
+// graph.ErrNotInterrupted if the thread is not paused, and graph.ErrNoCheckpointer if the graph
+// has no checkpointer.
+func (a *App[T]) Resume(ctx context.Context, name, threadID string, state T) (T, error) {
+	g, ok := a.graphs[name]
+	if !ok {
+		return state, fmt.Errorf("%w: %s", ErrUnknownGraph, name)
+	}
+	interrupt, _, err := g.Pending(ctx, threadID)
+	if err != nil {
+		return state, err
+	}
+	return g.Resume(ctx, interrupt, state)
+}
+
+// Handler returns the HTTP handler serving the routes of the manifest. Each route accepts a
+// POST whose body is the JSON-encoded input state and responds with the JSON-encoded output
+// state. The optional thread_id query parameter selects the thread the run is saved to, and with
+// the resume=true query parameter, the input state resumes the thread paused at an interrupt.
+// Runs paused at an interrupt respond with status 202 Accepted and the state reached; resuming
+// a thread that is not paused responds with status 409 Conflict.
+// Responses carry a Server-Timing header with the duration of every node execution. Strict
+// manifests reject the input states not matching the state type exactly, naming the
+// offending values.
+//
+// The handler also documents the state of the graphs under SchemaPath: a GET of SchemaPath
+// responds with the JSON Schema of the state of every graph, by name, and a GET of
+// SchemaPath/{graph} with the JSON Schema of the state of one graph, or its Markdown
+// documentation with the format=markdown query parameter.
+func (a *App[T]) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+SchemaPath, func(w http.ResponseWriter, _ *http.Request) {
+		schemas := make(map[string]any, len(a.graphs))
+		for name, g := range a.graphs {
+			schemas[name] = g.Schema().JSON
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(schemas)
+	})
+	mux.HandleFunc("GET "+SchemaPath+"/{graph}", func(w http.ResponseWriter, r *http.Request) {
+		schema, ok := a.Schema(r.PathValue("graph"))
+		if !ok {
+			http.Error(w, fmt.Sprintf("%v: %s", ErrUnknownGraph, r.PathValue("graph")), http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = io.WriteString(w, schema.Markdown())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(schema.JSON)
+	})
+
+	for _, route := range a.manifest.Routes {
+		name := route.Graph
+		mux.HandleFunc(route.Path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			state, err := a.decode(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			ctx, profile := graph.WithProfiling(r.Context())
+			run := a.Invoke
+			if r.URL.Query().Get("resume") == "true" {
+				run = a.Resume
+			}
+			out, err := run(ctx, name, r.URL.Query().Get("thread_id"), state)
+			w.Header().Set("Server-Timing", serverTiming(profile))
+			status := http.StatusOK
+			switch {
+			case errors.Is(err, graph.ErrInterrupted):
+				status = http.StatusAccepted
+			case errors.Is(err, graph.ErrNotInterrupted) || errors.Is(err, checkpoint.ErrNotFound):
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(out)
+		})
+	}
+	return mux
+}
+
+// decode decodes an input state, strictly if the manifest is strict.
+func (a *App[T]) decode(body io.Reader) (T, error) {
+	var state T
+	if !a.manifest.Strict {
+		if err := json.NewDecoder(body).Decode(&state); err != nil {
+			return state, fmt.Errorf("decoding state: %w", err)
+		}
+		return state, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return state, fmt.Errorf("reading state: %w", err)
+	}
+	return checkpoint.DecodeStrict[T](data)
+}
+
+// serverTiming formats the node executions of the profile as a Server-Timing header: one
+// "node" metric per execution, described by the node name, and a "total" metric.
+func serverTiming(p *graph.Profile) string {
+	var metrics []string
+	for _, entry := range p.Entries() {
+		if entry.Kind == graph.SpanNode {
+			metrics = append(metrics, fmt.Sprintf("node;desc=%s;dur=%s", strconv.Quote(entry.Name), milliseconds(entry.Duration)))
+		}
+	}
+	metrics = append(metrics, "total;dur="+milliseconds(p.Total()))
+	return strings.Join(metrics, ", ")
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// Run serves the routes of the manifest and runs its schedules until ctx is done, then waits
+// up to ShutdownTimeout for in-flight requests. Failed scheduled runs are logged and do not
+// stop the application. The HTTP server is only started when the manifest declares routes.
+func (a *App[T]) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, s := range a.manifest.Schedules {
+		wg.Add(1)
+		go func(s ScheduleSpec, input T) {
+			defer wg.Done()
+			a.schedule(ctx, s, input)
+		}(s, a.inputs[i])
+	}
+	defer wg.Wait()
+
+	if len(a.manifest.Routes) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	addr := a.manifest.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           a.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serving %s: %w", addr, err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.WithoutCancel(ctx), ShutdownTimeout)
+	defer cancelShutdown()
+	return server.Shutdown(shutdownCtx)
+}
+
+func (a *App[T]) schedule(ctx context.Context, s ScheduleSpec, input T) {
+	ticker := time.NewTicker(time.Duration(s.Every))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := a.Invoke(ctx, s.Graph, "", input); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "scheduled run failed", "app", a.manifest.Name, "schedule", s.Name, "graph", s.Graph, "error", err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package app_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cesto93/langgraphgo/app"
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const greetSpec = `
+params:
+  greeting:
+    default: hello
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    config:
+      text: ${greeting}
+edges:
+  - from: greet
+    to: END
+`
+
+func testRegistry(runs *atomic.Int32) *spec.Registry[[]string] {
+	r := spec.NewRegistry[[]string]()
+	r.Register("append", func(node spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		text, _ := node.Config["text"].(string)
+		return func(_ context.Context, state []string) ([]string, error) {
+			runs.Add(1)
+			return append(state, text), nil
+		}, nil
+	})
+	return r
+}
+
+func testApp(t *testing.T, manifest string, runs *atomic.Int32) *app.App[[]string] {
+	t.Helper()
+
+	m, err := app.ParseManifest([]byte(manifest))
+	require.NoError(t, err)
+
+	a, err := app.New(m, fstest.MapFS{"greet.yaml": {Data: []byte(greetSpec)}}, testRegistry(runs))
+	require.NoError(t, err)
+	return a
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	a := testApp(t, `
+checkpointers:
+  shared:
+    type: memory
+graphs:
+  english:
+    spec: greet.yaml
+    checkpointer: shared
+  italian:
+    spec: greet.yaml
+    params:
+      greeting: ciao
+routes:
+  - path: /english
+    graph: english
+  - path: /italian
+    graph: italian
+`, new(atomic.Int32))
+	server := httptest.NewServer(a.Handler())
+	defer server.Close()
+
+	post := func(path, body string) (int, string) {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	status, body := post("/italian", `["hi"]`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `["hi","ciao"]`, body)
+
+	timed, err := http.Post(server.URL+"/italian", "application/json", strings.NewReader(`[]`))
+	require.NoError(t, err)
+	timed.Body.Close()
+	assert.Regexp(t, `^node;desc="greet";dur=[0-9.]+, total;dur=[0-9.]+$`, timed.Header.Get("Server-Timing"))
+
+	for range 2 {
+		status, body = post("/english?thread_id=t1", `[]`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `["hello"]`, body)
+	}
+
+	status, _ = post("/english", `{`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Get(server.URL + "/english")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	cp, ok := a.Checkpointer("shared")
+	require.True(t, ok)
+	checkpoints, err := cp.List(context.Background(), "t1")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+
+	state, err := checkpoint.Decode[[]string](checkpoints[1].State)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, state)
+	assert.Equal(t, 1, checkpoints[1].Step)
+}
+
+func TestHandlerStrict(t *testing.T) {
+	t.Parallel()
+
+	a := testApp(t, `
+strict: true
+graphs:
+  english:
+    spec: greet.yaml
+routes:
+  - path: /english
+    graph: english
+`, new(atomic.Int32))
+	server := httptest.NewServer(a.Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/english", "application/json", strings.NewReader(`["hi", 1, {}]`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, string(body), "$[1]: number, expected string")
+	assert.Contains(t, string(body), "$[2]: object, expected string")
+
+	resp, err = http.Post(server.URL+"/english", "application/json", strings.NewReader(`["hi"]`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandlerSchema(t *testing.T) {
+	t.Parallel()
+
+	a := testApp(t, `
+graphs:
+  english:
+    spec: greet.yaml
+`, new(atomic.Int32))
+	server := httptest.NewServer(a.Handler())
+	defer server.Close()
+
+	get := func(path string) (int, string, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), strings.TrimSpace(string(out))
+	}
+
+	status, contentType, body := get("/schema")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{"english": {
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "[]string",
+		"type": "array",
+		"items": {"type": "string"}
+	}}`, body)
+
+	status, _, body = get("/schema/english?format=markdown")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "# State `[]string`", body)
+
+	status, _, _ = get("/schema/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestInvokeConcurrent(t *testing.T) {
+	t.Parallel()
+
+	a := testApp(t, `
+checkpointers:
+  shared:
+    type: memory
+graphs:
+  english:
+    spec: greet.yaml
+    checkpointer: shared
+`, new(atomic.Int32))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.Invoke(context.Background(), "english", "t1", nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	cp, _ := a.Checkpointer("shared")
+	checkpoints, err := cp.List(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Len(t, checkpoints, 20, "no invocation overwrote another")
+}
+
+func TestHandlerInterrupt(t *testing.T) {
+	t.Parallel()
+
+	runs := new(atomic.Int32)
+	a := testApp(t, `
+checkpointers:
+  shared:
+    type: memory
+graphs:
+  english:
+    spec: greet.yaml
+    checkpointer: shared
+    interrupt_before: [greet]
+routes:
+  - path: /english
+    graph: english
+`, runs)
+	server := httptest.NewServer(a.Handler())
+	defer server.Close()
+
+	post := func(path, body string) (int, string) {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	status, body := post("/english?thread_id=t1", `["hi"]`)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, `["hi"]`, body)
+	assert.Equal(t, int32(0), runs.Load())
+
+	g, _ := a.Graph("english")
+	interrupt, _, err := g.Pending(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "greet", interrupt.Node)
+
+	status, body = post("/english?thread_id=t1&resume=true", `["hi","edited"]`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `["hi","edited","hello"]`, body)
+
+	status, _ = post("/english?thread_id=t1&resume=true", `[]`)
+	assert.Equal(t, http.StatusConflict, status)
+}
+
+func TestInvokeUnknownGraph(t *testing.T) {
+	t.Parallel()
+
+	a := testApp(t, "graphs: {}\n", new(atomic.Int32))
+	_, err := a.Invoke(context.Background(), "missing", "", nil)
+	require.ErrorIs(t, err, app.ErrUnknownGraph)
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	m, err := app.ParseManifest([]byte("checkpointers:\n  db:\n    type: postgres\n"))
+	require.NoError(t, err)
+	_, err = app.New(m, fstest.MapFS{}, testRegistry(new(atomic.Int32)))
+	require.ErrorIs(t, err, app.ErrUnknownCheckpointerType)
+
+	m, err = app.ParseManifest([]byte("graphs:\n  a:\n    spec: missing.yaml\n"))
+	require.NoError(t, err)
+	_, err = app.New(m, fstest.MapFS{}, testRegistry(new(atomic.Int32)))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greet.yaml"), []byte(greetSpec), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(`
+addr: 127.0.0.1:0
+graphs:
+  greet:
+    spec: greet.yaml
+routes:
+  - path: /greet
+    graph: greet
+schedules:
+  - name: frequent
+    graph: greet
+    every: 1ms
+    input: [tick]
+`), 0o600))
+
+	var runs atomic.Int32
+	a, err := app.Open(filepath.Join(dir, "app.yaml"), testRegistry(&runs))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, 5*time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
@@ -0,0 +1,204 @@
+// Package app bundles several graphs declared as specs, the checkpointers they share, the HTTP
+// routes serving them and the schedules running them into a single application:
+//
+//	name: support
+//	addr: ":8080"
+//	checkpointers:
+//	  default:
+//	    type: memory
+//	graphs:
+//	  triage:
+//	    spec: graphs/triage.yaml
+//	    params:
+//	      model: small
+//	    checkpointer: default
+//	    interrupt_before: [escalate]
+//	  digest:
+//	    spec: graphs/digest.yaml
+//	routes:
+//	  - path: /triage
+//	    graph: triage
+//	schedules:
+//	  - name: nightly-digest
+//	    graph: digest
+//	    every: 24h
+//	    input: []
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+// ErrInvalidManifest is returned when a manifest is malformed or references undeclared items.
+var ErrInvalidManifest = errors.New("invalid application manifest")
+
+// DefaultAddr is the address the HTTP server listens on when the manifest sets none.
+const DefaultAddr = ":8080"
+
+// SchemaPath is the URL path under which the HTTP server documents the state of the graphs;
+// routes cannot use it.
+const SchemaPath = "/schema"
+
+// Manifest declares an application.
+type Manifest struct {
+	// Name identifies the application.
+	Name string `yaml:"name"`
+
+	// Addr is the address the HTTP server listens on. DefaultAddr is used when empty.
+	Addr string `yaml:"addr"`
+
+	// Strict makes the routes reject input states with fields the state type does not declare
+	// or values of another type, naming them, instead of silently dropping them.
+	Strict bool `yaml:"strict"`
+
+	// Checkpointers declares the checkpointers shared by the graphs, by name.
+	Checkpointers map[string]CheckpointerSpec `yaml:"checkpointers"`
+
+	// Graphs declares the graphs of the application, by name.
+	Graphs map[string]GraphSpec `yaml:"graphs"`
+
+	// Routes exposes graphs over HTTP.
+	Routes []RouteSpec `yaml:"routes"`
+
+	// Schedules runs graphs periodically.
+	Schedules []ScheduleSpec `yaml:"schedules"`
+
+	// Snapshot is the path of the snapshot of the plans of the graphs written by
+	// App.WriteSnapshot, relative to the manifest. Graphs whose plan it holds and still matches
+	// are compiled without validation, to start faster. A missing snapshot is ignored.
+	Snapshot string `yaml:"snapshot"`
+}
+
+// CheckpointerSpec declares a checkpointer.
+type CheckpointerSpec struct {
+	// Type selects the checkpointer implementation. The only built-in type is "memory".
+	Type string `yaml:"type"`
+
+	// DSN locates the storage of implementations backed by a database.
+	DSN string `yaml:"dsn"`
+}
+
+// GraphSpec declares a graph of the application.
+type GraphSpec struct {
+	// Spec is the path of the graph spec, relative to the manifest.
+	Spec string `yaml:"spec"`
+
+	// Params are the values of the parameters of the spec.
+	Params map[string]string `yaml:"params"`
+
+	// Checkpointer is the name of the checkpointer persisting the threads of the graph.
+	Checkpointer string `yaml:"checkpointer"`
+
+	// InterruptBefore and InterruptAfter are the nodes execution pauses before and after; see
+	// graph.WithInterruptBefore and graph.WithInterruptAfter.
+	InterruptBefore []string `yaml:"interrupt_before"`
+	InterruptAfter  []string `yaml:"interrupt_after"`
+}
+
+// RouteSpec declares an HTTP route invoking a graph.
+type RouteSpec struct {
+	// Path is the URL path of the route.
+	Path string `yaml:"path"`
+
+	// Graph is the name of the graph invoked.
+	Graph string `yaml:"graph"`
+}
+
+// ScheduleSpec declares a graph invoked periodically.
+type ScheduleSpec struct {
+	// Name identifies the schedule in logs.
+	Name string `yaml:"name"`
+
+	// Graph is the name of the graph invoked.
+	Graph string `yaml:"graph"`
+
+	// Every is the interval between two runs.
+	Every spec.Duration `yaml:"every"`
+
+	// Input is the initial state of every run, decoded into the state type of the application.
+	Input yaml.Node `yaml:"input"`
+}
+
+// ParseManifest decodes a manifest and checks that it only references declared graphs and
+// checkpointers. All the problems found are reported together.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%w:\n%w", ErrInvalidManifest, err)
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	var errs []error
+
+	for _, name := range sortedKeys(m.Checkpointers) {
+		if m.Checkpointers[name].Type == "" {
+			errs = append(errs, fmt.Errorf("checkpointer %s: type is missing", name))
+		}
+	}
+
+	for _, name := range sortedKeys(m.Graphs) {
+		g := m.Graphs[name]
+		if err := graph.ValidateName(name); err != nil {
+			errs = append(errs, fmt.Errorf("graph %q: %w", name, err))
+		}
+		if g.Spec == "" {
+			errs = append(errs, fmt.Errorf("graph %s: spec is missing", name))
+		}
+		if _, ok := m.Checkpointers[g.Checkpointer]; g.Checkpointer != "" && !ok {
+			errs = append(errs, fmt.Errorf("graph %s: unknown checkpointer %q", name, g.Checkpointer))
+		}
+	}
+
+	paths := make(map[string]bool)
+	for i, r := range m.Routes {
+		switch {
+		case !strings.HasPrefix(r.Path, "/"):
+			errs = append(errs, fmt.Errorf("routes[%d]: path %q must start with /", i, r.Path))
+		case paths[r.Path]:
+			errs = append(errs, fmt.Errorf("routes[%d]: duplicate path %q", i, r.Path))
+		case r.Path == SchemaPath || strings.HasPrefix(r.Path, SchemaPath+"/"):
+			errs = append(errs, fmt.Errorf("routes[%d]: path %q is reserved for the schemas", i, r.Path))
+		}
+		paths[r.Path] = true
+		if _, ok := m.Graphs[r.Graph]; !ok {
+			errs = append(errs, fmt.Errorf("routes[%d]: unknown graph %q", i, r.Graph))
+		}
+	}
+
+	for i, s := range m.Schedules {
+		if _, ok := m.Graphs[s.Graph]; !ok {
+			errs = append(errs, fmt.Errorf("schedules[%d]: unknown graph %q", i, s.Graph))
+		}
+		if s.Every <= 0 {
+			errs = append(errs, fmt.Errorf("schedules[%d]: every must be positive", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
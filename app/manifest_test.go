@@ -0,0 +1,132 @@
+package app_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/app"
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	t.Parallel()
+
+	m, err := app.ParseManifest([]byte(`
+name: support
+checkpointers:
+  default:
+    type: memory
+graphs:
+  triage:
+    spec: triage.yaml
+    params:
+      model: small
+    checkpointer: default
+routes:
+  - path: /triage
+    graph: triage
+schedules:
+  - name: nightly
+    graph: triage
+    every: 24h
+    input: [start]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "support", m.Name)
+	assert.Equal(t, app.GraphSpec{Spec: "triage.yaml", Params: map[string]string{"model": "small"}, Checkpointer: "default"}, m.Graphs["triage"])
+	assert.Equal(t, []app.RouteSpec{{Path: "/triage", Graph: "triage"}}, m.Routes)
+	require.Len(t, m.Schedules, 1)
+	assert.Equal(t, spec.Duration(24*time.Hour), m.Schedules[0].Every)
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		manifest string
+		errors   []string
+	}{
+		{
+			name:     "unknown field",
+			manifest: "graphz: {}\n",
+			errors:   []string{"field graphz not found"},
+		},
+		{
+			name: "dangling references",
+			manifest: `
+checkpointers:
+  broken: {}
+graphs:
+  a:
+    checkpointer: missing
+routes:
+  - path: a
+    graph: b
+  - path: a
+    graph: a
+schedules:
+  - graph: c
+`,
+			errors: []string{
+				"checkpointer broken: type is missing",
+				"graph a: spec is missing",
+				`graph a: unknown checkpointer "missing"`,
+				`routes[0]: path "a" must start with /`,
+				`routes[0]: unknown graph "b"`,
+				`routes[1]: path "a" must start with /`,
+				`schedules[0]: unknown graph "c"`,
+				"schedules[0]: every must be positive",
+			},
+		},
+		{
+			name: "reserved paths",
+			manifest: `
+graphs:
+  a:
+    spec: a.yaml
+routes:
+  - path: /schema
+    graph: a
+  - path: /schema/a
+    graph: a
+  - path: /schemas
+    graph: a
+`,
+			errors: []string{
+				`routes[0]: path "/schema" is reserved for the schemas`,
+				`routes[1]: path "/schema/a" is reserved for the schemas`,
+			},
+		},
+		{
+			name: "graph names",
+			manifest: `
+graphs:
+  support/v2:
+    spec: a.yaml
+  END:
+    spec: a.yaml
+`,
+			errors: []string{
+				`graph "END": node name is reserved: END`,
+				`graph "support/v2": invalid name: "support/v2" holds '/'`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := app.ParseManifest([]byte(tc.manifest))
+			require.ErrorIs(t, err, app.ErrInvalidManifest)
+			for _, msg := range tc.errors {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
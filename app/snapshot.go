@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrInvalidSnapshot is returned when the snapshot of a manifest cannot be decoded.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Snapshot holds the plans of the compiled graphs of an application, so that serverless
+// deployments can skip their validation at cold start: write it at build time with
+// WriteSnapshot and reference it from the snapshot field of the manifest.
+//
+// Plans are checked against the graphs built from the specs and the node functions registered
+// in code: a graph changed since the snapshot was written is validated as without snapshot.
+// Write snapshots with the binary loading them, e.g. with a build step running it: the names
+// of closures depend on how it was compiled, and other binaries would only be validated.
+type Snapshot struct {
+	// Version is the version of the format of the plans, graph.PlanVersion.
+	Version int `json:"version"`
+
+	// Graphs are the plans of the graphs, by name.
+	Graphs map[string]graph.Plan `json:"graphs"`
+}
+
+// Snapshot returns the snapshot of the plans of the graphs of the application.
+func (a *App[T]) Snapshot() Snapshot {
+	s := Snapshot{Version: graph.PlanVersion, Graphs: make(map[string]graph.Plan, len(a.graphs))}
+	for name, g := range a.graphs {
+		s.Graphs[name] = g.Plan()
+	}
+	return s
+}
+
+// WriteSnapshot writes the snapshot of the plans of the graphs of the application to w as JSON.
+func (a *App[T]) WriteSnapshot(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a.Snapshot())
+}
+
+// readSnapshot reads the snapshot at path in fsys. Without path or file, or when the snapshot
+// was written by another version, it returns an empty snapshot.
+func readSnapshot(fsys fs.FS, path string) (Snapshot, error) {
+	if path == "" {
+		return Snapshot{}, nil
+	}
+	data, err := fs.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, nil
+	}
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot: %w", err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %s: %w", ErrInvalidSnapshot, path, err)
+	}
+	if s.Version != graph.PlanVersion {
+		return Snapshot{}, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,108 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/app"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// snapshotRegistry is shared by the applications of the test: the names of the node functions,
+// which plans check, depend on where their closures are created.
+var snapshotRegistry = testRegistry(new(atomic.Int32))
+
+const snapshotManifest = `
+snapshot: snapshot.json
+graphs:
+  greet:
+    spec: greet.yaml
+`
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	m, err := app.ParseManifest([]byte(snapshotManifest))
+	require.NoError(t, err)
+	a, err := app.New(m, fstest.MapFS{"greet.yaml": {Data: []byte(greetSpec)}}, snapshotRegistry)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, a.WriteSnapshot(&buf))
+
+	var snapshot app.Snapshot
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &snapshot))
+	assert.Equal(t, graph.PlanVersion, snapshot.Version)
+	require.Contains(t, snapshot.Graphs, "greet")
+	assert.Equal(t, []string{"greet"}, snapshot.Graphs["greet"].Nodes)
+
+	// Plans are marked cyclic to tell whether they were trusted.
+	plan := snapshot.Graphs["greet"]
+	plan.Cyclic = true
+	stale := plan
+	stale.Fingerprint = "stale"
+
+	testCases := []struct {
+		name     string
+		snapshot *app.Snapshot
+		data     string
+		cyclic   bool
+		err      error
+	}{
+		{
+			name:     "matching",
+			snapshot: &app.Snapshot{Version: graph.PlanVersion, Graphs: map[string]graph.Plan{"greet": plan}},
+			cyclic:   true,
+		},
+		{
+			name:     "stale",
+			snapshot: &app.Snapshot{Version: graph.PlanVersion, Graphs: map[string]graph.Plan{"greet": stale}},
+		},
+		{
+			name:     "other version",
+			snapshot: &app.Snapshot{Version: graph.PlanVersion + 1, Graphs: map[string]graph.Plan{"greet": plan}},
+		},
+		{
+			name: "missing",
+		},
+		{
+			name: "invalid",
+			data: "{",
+			err:  app.ErrInvalidSnapshot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fsys := fstest.MapFS{"greet.yaml": {Data: []byte(greetSpec)}}
+			switch {
+			case tc.snapshot != nil:
+				data, err := json.Marshal(tc.snapshot)
+				require.NoError(t, err)
+				fsys["snapshot.json"] = &fstest.MapFile{Data: data}
+			case tc.data != "":
+				fsys["snapshot.json"] = &fstest.MapFile{Data: []byte(tc.data)}
+			}
+
+			m, err := app.ParseManifest([]byte(snapshotManifest))
+			require.NoError(t, err)
+			a, err := app.New(m, fsys, snapshotRegistry)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			g, ok := a.Graph("greet")
+			require.True(t, ok)
+			assert.Equal(t, tc.cyclic, g.Plan().Cyclic)
+		})
+	}
+}
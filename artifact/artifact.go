@@ -0,0 +1,171 @@
+// Package artifact stores the rich results of tools, such as tables, files and images, apart
+// from the message history: messages carry a textual summary and a reference to the artifact,
+// which downstream nodes and user interfaces resolve from the Store.
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when an artifact does not exist.
+var ErrNotFound = errors.New("artifact not found")
+
+// Kind is the kind of an artifact.
+type Kind string
+
+const (
+	// KindTable is tabular data, held in Artifact.Table.
+	KindTable Kind = "table"
+
+	// KindFile is a file, held in Artifact.Data.
+	KindFile Kind = "file"
+
+	// KindImage is an image, held in Artifact.Data.
+	KindImage Kind = "image"
+)
+
+// Table is tabular data.
+type Table struct {
+	// Columns are the names of the columns.
+	Columns []string `json:"columns"`
+
+	// Rows are the rows, with a value per column.
+	Rows [][]string `json:"rows"`
+}
+
+// Artifact is a rich result of a tool.
+type Artifact struct {
+	// ID identifies the artifact in its store; Put sets it when empty.
+	ID string `json:"id"`
+
+	// Kind is the kind of the artifact.
+	Kind Kind `json:"kind"`
+
+	// Name is a human-readable name, such as a file name.
+	Name string `json:"name,omitempty"`
+
+	// MIMEType is the media type of Data.
+	MIMEType string `json:"mime_type,omitempty"`
+
+	// Data is the content of files and images.
+	Data []byte `json:"data,omitempty"`
+
+	// Table is the content of tables.
+	Table *Table `json:"table,omitempty"`
+
+	// Summary describes the artifact in the messages referencing it; Describe is used when
+	// empty.
+	Summary string `json:"summary,omitempty"`
+
+	// CreatedAt is the time the artifact was stored; Put sets it when zero.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Describe returns the summary of the artifact, or a description of its kind, name and size
+// when it has none.
+func (a Artifact) Describe() string {
+	if a.Summary != "" {
+		return a.Summary
+	}
+	name := a.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	switch {
+	case a.Kind == KindTable && a.Table != nil:
+		return fmt.Sprintf("table %s: %d rows, columns %s", name, len(a.Table.Rows), strings.Join(a.Table.Columns, ", "))
+	case a.MIMEType != "":
+		return fmt.Sprintf("%s %s (%s, %d bytes)", a.Kind, name, a.MIMEType, len(a.Data))
+	default:
+		return fmt.Sprintf("%s %s (%d bytes)", a.Kind, name, len(a.Data))
+	}
+}
+
+// Store stores artifacts. Implementations must be safe for concurrent use.
+type Store interface {
+	// Put stores an artifact and returns its ID, generated when empty.
+	Put(ctx context.Context, a Artifact) (string, error)
+
+	// Get returns the artifact with the given ID.
+	Get(ctx context.Context, id string) (Artifact, error)
+}
+
+// Memory is an in-memory Store, useful for tests and single-process applications.
+type Memory struct {
+	mu        sync.RWMutex
+	next      int
+	artifacts map[string]Artifact
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory creates a new in-memory store.
+func NewMemory() *Memory {
+	return &Memory{artifacts: make(map[string]Artifact)}
+}
+
+// Put stores an artifact, replacing any artifact with the same ID.
+func (m *Memory) Put(_ context.Context, a Artifact) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a.ID == "" {
+		m.next++
+		a.ID = fmt.Sprintf("art-%d", m.next)
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	m.artifacts[a.ID] = a
+	return a.ID, nil
+}
+
+// Get returns the artifact with the given ID.
+func (m *Memory) Get(_ context.Context, id string) (Artifact, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.artifacts[id]
+	if !ok {
+		return Artifact{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return a, nil
+}
+
+type storeKey struct{}
+
+// WithStore returns a context making the tools of prebuilt agents store their artifacts in s.
+func WithStore(ctx context.Context, s Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// StoreFromContext returns the store of the context, or nil.
+func StoreFromContext(ctx context.Context) Store {
+	s, _ := ctx.Value(storeKey{}).(Store)
+	return s
+}
+
+// refScheme prefixes the references to artifacts in messages.
+const refScheme = "artifact://"
+
+var refPattern = regexp.MustCompile(`artifact://([A-Za-z0-9._~-]+)`)
+
+// Ref returns the reference to the artifact with the given ID, as written in messages.
+func Ref(id string) string {
+	return refScheme + id
+}
+
+// Refs returns the IDs of the artifacts referenced in text, in order.
+func Refs(text string) []string {
+	var ids []string
+	for _, match := range refPattern.FindAllStringSubmatch(text, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
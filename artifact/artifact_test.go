@@ -0,0 +1,74 @@
+package artifact_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/artifact"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := artifact.NewMemory()
+
+	id, err := store.Put(ctx, artifact.Artifact{Kind: artifact.KindFile, Name: "a.txt", Data: []byte("a")})
+	require.NoError(t, err)
+	assert.Equal(t, "art-1", id)
+
+	named, err := store.Put(ctx, artifact.Artifact{ID: "report", Kind: artifact.KindFile})
+	require.NoError(t, err)
+	assert.Equal(t, "report", named)
+
+	a, err := store.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), a.Data)
+	assert.WithinDuration(t, time.Now(), a.CreatedAt, time.Minute)
+
+	_, err = store.Get(ctx, "missing")
+	require.ErrorIs(t, err, artifact.ErrNotFound)
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		artifact artifact.Artifact
+		expected string
+	}{
+		{
+			artifact: artifact.Artifact{Kind: artifact.KindTable, Name: "sales", Table: &artifact.Table{Columns: []string{"a", "b"}, Rows: [][]string{{"1", "2"}}}},
+			expected: "table sales: 1 rows, columns a, b",
+		},
+		{
+			artifact: artifact.Artifact{Kind: artifact.KindImage, Name: "cat.png", MIMEType: "image/png", Data: []byte("png")},
+			expected: "image cat.png (image/png, 3 bytes)",
+		},
+		{
+			artifact: artifact.Artifact{Kind: artifact.KindFile},
+			expected: "file unnamed (0 bytes)",
+		},
+		{
+			artifact: artifact.Artifact{Kind: artifact.KindFile, Summary: "the logs"},
+			expected: "the logs",
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.artifact.Describe())
+	}
+}
+
+func TestRefs(t *testing.T) {
+	t.Parallel()
+
+	text := "[table: " + artifact.Ref("art-1") + "]\n[chart: " + artifact.Ref("art-2") + "]"
+	assert.Equal(t, []string{"art-1", "art-2"}, artifact.Refs(text))
+	assert.Empty(t, artifact.Refs("no artifact"))
+	assert.Nil(t, artifact.StoreFromContext(context.Background()))
+}
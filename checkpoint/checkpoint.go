@@ -0,0 +1,117 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when a thread or checkpoint does not exist.
+var ErrNotFound = errors.New("checkpoint not found")
+
+// Kind tells how the state of a checkpoint is encoded.
+type Kind string
+
+const (
+	// KindFull is a checkpoint holding the complete encoded state.
+	KindFull Kind = "full"
+
+	// KindDelta is a checkpoint holding only the changes since the previous checkpoint.
+	KindDelta Kind = "delta"
+)
+
+// Checkpoint is a persisted snapshot of the state of a thread after a step.
+type Checkpoint struct {
+	// ThreadID identifies the thread the checkpoint belongs to.
+	ThreadID string
+
+	// ID identifies the checkpoint within its thread.
+	ID string
+
+	// Step is the index of the step that produced the checkpoint.
+	Step int
+
+	// Node is the name of the node that produced the checkpoint.
+	Node string
+
+	// Kind tells how State is encoded.
+	Kind Kind
+
+	// Version is the format version State was written with; see FormatVersion.
+	Version int
+
+	// State is the encoded state.
+	State []byte
+
+	// Metadata holds arbitrary key-value pairs attached to the checkpoint.
+	Metadata map[string]string
+
+	// CreatedAt is the time the checkpoint was created.
+	CreatedAt time.Time
+}
+
+// Checkpointer persists checkpoints of graph threads.
+// Implementations must be safe for concurrent use.
+type Checkpointer interface {
+	// Put stores a checkpoint, replacing any checkpoint of the thread with the same ID.
+	Put(ctx context.Context, cp Checkpoint) error
+
+	// Get returns the checkpoint of the thread with the given ID.
+	Get(ctx context.Context, threadID, id string) (Checkpoint, error)
+
+	// Latest returns the checkpoint of the thread with the highest step.
+	Latest(ctx context.Context, threadID string) (Checkpoint, error)
+
+	// List returns the checkpoints of the thread ordered by step.
+	List(ctx context.Context, threadID string) ([]Checkpoint, error)
+
+	// Delete permanently removes all the checkpoints of the thread.
+	// Deleting a thread without checkpoints is not an error.
+	Delete(ctx context.Context, threadID string) error
+}
+
+// ThreadLister is implemented by checkpointers able to enumerate their threads, which
+// maintenance tasks such as retention need.
+type ThreadLister interface {
+	// Threads returns the IDs of the threads holding checkpoints, in lexical order.
+	Threads(ctx context.Context) ([]string, error)
+}
+
+// Appender is implemented by checkpointers able to number the checkpoints of a thread
+// themselves, so that concurrent writers never give two checkpoints the same step.
+type Appender interface {
+	// Append stores cp as the next checkpoint of its thread and returns it with its Step set
+	// to one past the highest step of the thread, or 0 for a new thread, and its ID to the
+	// StepID of its step. The step is read and the checkpoint written atomically.
+	Append(ctx context.Context, cp Checkpoint) (Checkpoint, error)
+}
+
+// Append stores cp as the next checkpoint of its thread, as Appender.Append does, and returns
+// it with its step and ID set. Checkpointers not implementing Appender are read with Latest
+// then written with Put, so concurrent appends to a thread of such checkpointers may be given
+// the same step, the last one replacing the others.
+func Append(ctx context.Context, c Checkpointer, cp Checkpoint) (Checkpoint, error) {
+	if appender, ok := c.(Appender); ok {
+		return appender.Append(ctx, cp)
+	}
+
+	cp.Step = 0
+	latest, err := c.Latest(ctx, cp.ThreadID)
+	switch {
+	case err == nil:
+		cp.Step = latest.Step + 1
+	case !errors.Is(err, ErrNotFound):
+		return Checkpoint{}, err
+	}
+	cp.ID = StepID(cp.Step)
+	return cp, c.Put(ctx, cp)
+}
+
+// MetadataTenant is the metadata key holding the tenant a checkpoint belongs to.
+const MetadataTenant = "tenant"
+
+// StepID returns the checkpoint ID used for a step. IDs of increasing steps sort lexically.
+func StepID(step int) string {
+	return fmt.Sprintf("%010d", step)
+}
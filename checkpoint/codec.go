@@ -0,0 +1,133 @@
+// Package checkpoint provides the encoding and storage primitives used to persist graph state.
+package checkpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrDeltaMismatch is returned when a delta does not apply to the given base state.
+var ErrDeltaMismatch = errors.New("delta does not apply to base state")
+
+// maxPooledBufferSize is the capacity above which buffers are not returned to the pool,
+// so that one huge state does not pin memory forever.
+const maxPooledBufferSize = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	buf, _ := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// encodeWith encodes using a pooled buffer and returns a copy of the encoded bytes.
+func encodeWith(fn func(buf *bytes.Buffer) error) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := fn(buf); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// Encode encodes a state as JSON using pooled buffers.
+func Encode(v any) ([]byte, error) {
+	return encodeWith(func(buf *bytes.Buffer) error {
+		return encodeJSON(buf, v)
+	})
+}
+
+// Decode decodes a state encoded with Encode.
+func Decode[T any](data []byte) (T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decoding state: %w", err)
+	}
+	return v, nil
+}
+
+func encodeJSON(buf *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	// Drop the newline written by Encoder.Encode.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// delta is the wire format of an incremental encoding of an append-only slice.
+type delta struct {
+	// Offset is the number of elements of the base state the delta applies after.
+	Offset int `json:"offset"`
+
+	// Items are the elements appended after Offset.
+	Items json.RawMessage `json:"items"`
+}
+
+// EncodeDelta encodes only the elements of items after offset, typically the number of
+// elements already persisted by the previous checkpoint. Only the new elements are serialized,
+// so the cost of a step does not grow with the length of the history.
+// The elements before offset must be unchanged since they were persisted.
+func EncodeDelta[E any](items []E, offset int) ([]byte, error) {
+	if offset < 0 || offset > len(items) {
+		return nil, fmt.Errorf("%w: offset %d outside of %d items", ErrDeltaMismatch, offset, len(items))
+	}
+
+	return encodeWith(func(buf *bytes.Buffer) error {
+		fmt.Fprintf(buf, `{"offset":%d,"items":`, offset)
+		if err := encodeJSON(buf, items[offset:]); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	})
+}
+
+// ApplyDelta decodes a delta produced by EncodeDelta and applies it to base.
+// The base is never modified; elements of base after the delta offset are discarded.
+func ApplyDelta[E any](base []E, data []byte) ([]E, error) {
+	offset, items, err := decodeDelta[E](data)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(base) {
+		return nil, fmt.Errorf("%w: offset %d beyond %d items", ErrDeltaMismatch, offset, len(base))
+	}
+
+	merged := make([]E, offset, offset+len(items))
+	copy(merged, base[:offset])
+	return append(merged, items...), nil
+}
+
+// decodeDelta decodes a delta produced by EncodeDelta into its offset and items.
+func decodeDelta[E any](data []byte) (int, []E, error) {
+	var d delta
+	if err := json.Unmarshal(data, &d); err != nil {
+		return 0, nil, fmt.Errorf("decoding delta: %w", err)
+	}
+	if d.Offset < 0 {
+		return 0, nil, fmt.Errorf("%w: negative offset %d", ErrDeltaMismatch, d.Offset)
+	}
+
+	var items []E
+	if err := json.Unmarshal(d.Items, &items); err != nil {
+		return 0, nil, fmt.Errorf("decoding delta items: %w", err)
+	}
+	return d.Offset, items, nil
+}
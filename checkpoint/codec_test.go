@@ -0,0 +1,91 @@
+package checkpoint_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	state := []message{{Role: "human", Content: "<hi> & bye"}}
+	data, err := checkpoint.Encode(state)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"role":"human","content":"<hi> & bye"}]`, string(data))
+
+	decoded, err := checkpoint.Decode[[]message](data)
+	require.NoError(t, err)
+	assert.Equal(t, state, decoded)
+
+	_, err = checkpoint.Decode[[]message]([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestDelta(t *testing.T) {
+	t.Parallel()
+
+	history := []message{{"human", "q1"}, {"ai", "a1"}}
+	base := append([]message(nil), history...)
+
+	history = append(history, message{"human", "q2"}, message{"ai", "a2"})
+	data, err := checkpoint.EncodeDelta(history, len(base))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "q1", "persisted messages must not be serialized again")
+
+	restored, err := checkpoint.ApplyDelta(base, data)
+	require.NoError(t, err)
+	assert.Equal(t, history, restored)
+	assert.Len(t, base, 2, "the base must not be modified")
+
+	_, err = checkpoint.ApplyDelta(base[:1], data)
+	require.ErrorIs(t, err, checkpoint.ErrDeltaMismatch)
+
+	_, err = checkpoint.EncodeDelta(history, len(history)+1)
+	require.ErrorIs(t, err, checkpoint.ErrDeltaMismatch)
+}
+
+func TestDeltaRewind(t *testing.T) {
+	t.Parallel()
+
+	base := []message{{"human", "q1"}, {"ai", "a1"}, {"human", "q2"}}
+	data, err := checkpoint.EncodeDelta([]message{{"human", "q1"}, {"ai", "a1-edited"}}, 1)
+	require.NoError(t, err)
+
+	restored, err := checkpoint.ApplyDelta(base, data)
+	require.NoError(t, err)
+	assert.Equal(t, []message{{"human", "q1"}, {"ai", "a1-edited"}}, restored)
+}
+
+func BenchmarkEncodeHistory(b *testing.B) {
+	history := make([]message, 10_000)
+	for i := range history {
+		history[i] = message{Role: "human", Content: fmt.Sprintf("message %d", i)}
+	}
+
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := checkpoint.Encode(history); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("delta", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := checkpoint.EncodeDelta(history, len(history)-2); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package checkpoint_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// FuzzApplyDelta checks that corrupted deltas are rejected with an error instead of a panic
+// and that valid deltas round-trip.
+func FuzzApplyDelta(f *testing.F) {
+	f.Add([]byte(`{"offset":1,"items":["b"]}`), "a,b,c")
+	f.Add([]byte(`{"offset":-1,"items":[]}`), "a")
+	f.Add([]byte(`{"offset":9,"items":null}`), "")
+	f.Add([]byte(`{"items":[1,2]}`), "a")
+	f.Add([]byte(`not json`), "a")
+
+	f.Fuzz(func(t *testing.T, data []byte, joined string) {
+		if !utf8.ValidString(joined) {
+			// JSON replaces invalid UTF-8, so such states cannot round-trip.
+			t.Skip()
+		}
+		base := strings.Split(joined, ",")
+
+		state, err := checkpoint.ApplyDelta(base, data)
+		if err != nil {
+			return
+		}
+		// Whatever was accepted must encode and apply back to the same state.
+		for offset := range len(state) + 1 {
+			encoded, err := checkpoint.EncodeDelta(state, offset)
+			if err != nil {
+				t.Fatalf("encoding accepted state: %v", err)
+			}
+			restored, err := checkpoint.ApplyDelta(state[:offset], encoded)
+			if err != nil {
+				t.Fatalf("applying own delta: %v", err)
+			}
+			if strings.Join(restored, ",") != strings.Join(state, ",") || len(restored) != len(state) {
+				t.Fatalf("round trip changed state: %q != %q", restored, state)
+			}
+		}
+	})
+}
+
+// FuzzHistoryLoad stores arbitrary checkpoint payloads and checks that loading them never panics.
+func FuzzHistoryLoad(f *testing.F) {
+	f.Add([]byte(`["a"]`), []byte(`{"offset":1,"items":["b"]}`), false, 1)
+	f.Add([]byte(`["a"]`), []byte(`{"offset":5,"items":["b"]}`), false, 1)
+	f.Add([]byte(`{"offset":0,"items":[]}`), []byte(`[]`), true, 0)
+
+	f.Fuzz(func(t *testing.T, first, second []byte, firstIsDelta bool, k int) {
+		ctx := context.Background()
+		store := checkpoint.NewMemory()
+
+		kind := checkpoint.KindFull
+		if firstIsDelta {
+			kind = checkpoint.KindDelta
+		}
+		_ = store.Put(ctx, checkpoint.Checkpoint{ThreadID: "t", ID: checkpoint.StepID(0), Kind: kind, State: first})
+		_ = store.Put(ctx, checkpoint.Checkpoint{ThreadID: "t", ID: checkpoint.StepID(1), Step: 1, Kind: checkpoint.KindDelta, State: second})
+
+		history := checkpoint.NewHistory[string](store, 10)
+		state, err := history.Load(ctx, "t", "")
+		if tail, tailErr := history.LoadTail(ctx, "t", k); tailErr == nil && err == nil {
+			if len(tail.Items) > len(state) {
+				t.Fatalf("tail %q longer than state %q", tail.Items, state)
+			}
+		}
+	})
+}
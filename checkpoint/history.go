@@ -0,0 +1,260 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultSnapshotInterval is the number of steps between full snapshots used by NewHistory
+// when no positive interval is given.
+const DefaultSnapshotInterval = 10
+
+var (
+	// ErrBrokenChain is returned when a delta checkpoint cannot be traced back to a full snapshot.
+	ErrBrokenChain = errors.New("delta chain has no full snapshot")
+
+	// ErrUnknownKind is returned when reading a checkpoint of a kind other than KindFull and
+	// KindDelta.
+	ErrUnknownKind = errors.New("unknown checkpoint kind")
+)
+
+// MetadataBase is the metadata key of the delta checkpoints naming the checkpoint of their
+// thread they apply to, when it is not the checkpoint preceding them, e.g. because several
+// invocations save to the thread at the same time.
+const MetadataBase = "base"
+
+// History persists an append-only slice state, such as a message history, as per-step deltas
+// plus a full snapshot every few steps. Loading a checkpoint applies the deltas written since
+// the nearest preceding snapshot.
+//
+// Elements already persisted must not be modified in place. A shrinking state is detected and
+// written as a full snapshot.
+type History[E any] struct {
+	checkpointer  Checkpointer
+	snapshotEvery int
+
+	mu      sync.Mutex
+	cursors map[string]historyCursor
+}
+
+// historyCursor tracks what has been persisted for a thread.
+type historyCursor struct {
+	// length is the number of elements persisted so far.
+	length int
+
+	// deltas is the number of deltas written since the last full snapshot.
+	deltas int
+}
+
+// NewHistory creates a History writing to checkpointer with a full snapshot every snapshotEvery steps.
+func NewHistory[E any](checkpointer Checkpointer, snapshotEvery int) *History[E] {
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotInterval
+	}
+	return &History[E]{
+		checkpointer:  checkpointer,
+		snapshotEvery: snapshotEvery,
+		cursors:       make(map[string]historyCursor),
+	}
+}
+
+// Save persists the state of the thread after the given step and returns the stored checkpoint.
+// Saves of the same thread must not run concurrently.
+func (h *History[E]) Save(ctx context.Context, threadID string, step int, node string, state []E) (Checkpoint, error) {
+	cursor, ok, err := h.cursor(ctx, threadID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	cp := Checkpoint{
+		ThreadID:  threadID,
+		ID:        StepID(step),
+		Step:      step,
+		Node:      node,
+		Version:   FormatVersion,
+		CreatedAt: time.Now(),
+	}
+
+	full := !ok || cursor.deltas+1 >= h.snapshotEvery || len(state) < cursor.length
+	if full {
+		cp.Kind = KindFull
+		cp.State, err = Encode(state)
+		cursor.deltas = 0
+	} else {
+		cp.Kind = KindDelta
+		cp.State, err = EncodeDelta(state, cursor.length)
+		cursor.deltas++
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	if err := h.checkpointer.Put(ctx, cp); err != nil {
+		return Checkpoint{}, fmt.Errorf("writing checkpoint: %w", err)
+	}
+
+	cursor.length = len(state)
+	h.mu.Lock()
+	h.cursors[threadID] = cursor
+	h.mu.Unlock()
+
+	return cp, nil
+}
+
+// cursor returns the persisted position of the thread, rebuilding it from storage when the
+// thread was written by another History instance or process.
+func (h *History[E]) cursor(ctx context.Context, threadID string) (historyCursor, bool, error) {
+	h.mu.Lock()
+	cursor, ok := h.cursors[threadID]
+	h.mu.Unlock()
+	if ok {
+		return cursor, true, nil
+	}
+
+	checkpoints, err := h.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return historyCursor{}, false, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	if len(checkpoints) == 0 {
+		return historyCursor{}, false, nil
+	}
+
+	state, err := h.rebuild(checkpoints, len(checkpoints)-1)
+	if err != nil {
+		return historyCursor{}, false, err
+	}
+
+	cursor.length = len(state)
+	for i := len(checkpoints) - 1; i >= 0 && checkpoints[i].Kind == KindDelta; i-- {
+		cursor.deltas++
+	}
+	return cursor, true, nil
+}
+
+// Load reconstructs the state of the thread at the checkpoint with the given ID,
+// or at the latest checkpoint if id is empty.
+func (h *History[E]) Load(ctx context.Context, threadID, id string) ([]E, error) {
+	checkpoints, err := h.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, threadID)
+	}
+
+	target := len(checkpoints) - 1
+	if id != "" {
+		target = -1
+		for i, cp := range checkpoints {
+			if cp.ID == id {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, threadID, id)
+		}
+	}
+
+	return h.rebuild(checkpoints, target)
+}
+
+// Delete permanently removes the checkpoints of the thread and forgets its cursor, so the next
+// Save starts the thread over with a full snapshot.
+func (h *History[E]) Delete(ctx context.Context, threadID string) error {
+	h.mu.Lock()
+	delete(h.cursors, threadID)
+	h.mu.Unlock()
+
+	if err := h.checkpointer.Delete(ctx, threadID); err != nil {
+		return fmt.Errorf("deleting thread %s: %w", threadID, err)
+	}
+	return nil
+}
+
+// Resolve returns the checkpoint with its complete state, as a KindFull checkpoint: the state
+// of a delta checkpoint, whose state is a JSON array, is rebuilt from the checkpoints of the
+// thread it applies to. It returns ErrUnknownKind for checkpoints of other kinds, whose state
+// cannot be read.
+func Resolve(ctx context.Context, c Checkpointer, cp Checkpoint) (Checkpoint, error) {
+	switch cp.Kind {
+	case KindFull:
+		return cp, nil
+	case KindDelta:
+	default:
+		return cp, fmt.Errorf("%w: %q of checkpoint %s", ErrUnknownKind, cp.Kind, cp.ID)
+	}
+
+	checkpoints, err := c.List(ctx, cp.ThreadID)
+	if err != nil {
+		return cp, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	target := slices.IndexFunc(checkpoints, func(other Checkpoint) bool { return other.ID == cp.ID })
+	if target < 0 {
+		return cp, fmt.Errorf("%w: %s/%s", ErrNotFound, cp.ThreadID, cp.ID)
+	}
+	items, err := (&History[json.RawMessage]{}).rebuild(checkpoints, target)
+	if err != nil {
+		return cp, err
+	}
+	if cp.State, err = Encode(items); err != nil {
+		return cp, err
+	}
+	cp.Kind = KindFull
+	return cp, nil
+}
+
+// rebuild decodes the full snapshot the deltas up to target apply to and applies them. Deltas
+// apply to the checkpoint named by their MetadataBase, or else to the checkpoint preceding them.
+func (h *History[E]) rebuild(checkpoints []Checkpoint, target int) ([]E, error) {
+	checkpoints, err := upgradeAll(checkpoints[:target+1])
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []int{target}
+	for i := target; checkpoints[i].Kind != KindFull; chain = append(chain, i) {
+		if checkpoints[i].Kind != KindDelta {
+			return nil, fmt.Errorf("%w: %q of checkpoint %s", ErrUnknownKind, checkpoints[i].Kind, checkpoints[i].ID)
+		}
+		if base, ok := checkpoints[i].Metadata[MetadataBase]; ok {
+			i = slices.IndexFunc(checkpoints[:i], func(cp Checkpoint) bool { return cp.ID == base })
+		} else {
+			i--
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("%w: checkpoint %s", ErrBrokenChain, checkpoints[target].ID)
+		}
+	}
+
+	base := checkpoints[chain[len(chain)-1]]
+	state, err := Decode[[]E](base.State)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", base.ID, err)
+	}
+	for j := len(chain) - 2; j >= 0; j-- {
+		cp := checkpoints[chain[j]]
+		state, err = ApplyDelta(state, cp.State)
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+		}
+	}
+	return state, nil
+}
+
+// upgradeAll returns checkpoints upgraded to FormatVersion.
+func upgradeAll(checkpoints []Checkpoint) ([]Checkpoint, error) {
+	upgraded := make([]Checkpoint, len(checkpoints))
+	for i, cp := range checkpoints {
+		var err error
+		if upgraded[i], err = Upgrade(cp); err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+		}
+	}
+	return upgraded, nil
+}
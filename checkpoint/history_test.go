@@ -0,0 +1,168 @@
+package checkpoint_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[message](store, 3)
+
+	var state []message
+	var states [][]message
+	for step := range 7 {
+		state = append(state, message{Role: "human", Content: fmt.Sprintf("m%d", step)})
+		states = append(states, append([]message(nil), state...))
+
+		_, err := history.Save(ctx, "thread", step, "node", state)
+		require.NoError(t, err)
+	}
+
+	list, err := store.List(ctx, "thread")
+	require.NoError(t, err)
+
+	kinds := make([]checkpoint.Kind, len(list))
+	for i, cp := range list {
+		kinds[i] = cp.Kind
+	}
+	assert.Equal(t, []checkpoint.Kind{
+		checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindDelta,
+		checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindDelta,
+		checkpoint.KindFull,
+	}, kinds)
+
+	for step, expected := range states {
+		loaded, err := history.Load(ctx, "thread", checkpoint.StepID(step))
+		require.NoError(t, err)
+		assert.Equal(t, expected, loaded, "step %d", step)
+	}
+
+	latest, err := history.Load(ctx, "thread", "")
+	require.NoError(t, err)
+	assert.Equal(t, state, latest)
+
+	_, err = history.Load(ctx, "thread", "missing")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestHistoryResumesFromStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+
+	state := []message{{"human", "q1"}}
+	_, err := checkpoint.NewHistory[message](store, 10).Save(ctx, "thread", 0, "node", state)
+	require.NoError(t, err)
+
+	// A new instance, e.g. after a restart, continues the delta chain.
+	history := checkpoint.NewHistory[message](store, 10)
+	state = append(state, message{"ai", "a1"})
+	cp, err := history.Save(ctx, "thread", 1, "node", state)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.KindDelta, cp.Kind)
+
+	// A shrinking state is written as a full snapshot.
+	cp, err = history.Save(ctx, "thread", 2, "node", state[:1])
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.KindFull, cp.Kind)
+
+	loaded, err := history.Load(ctx, "thread", checkpoint.StepID(1))
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
+
+func TestHistoryBrokenChain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	require.NoError(t, store.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: checkpoint.StepID(0), Kind: checkpoint.KindDelta,
+		State: []byte(`{"offset":0,"items":[]}`),
+	}))
+
+	_, err := checkpoint.NewHistory[message](store, 3).Load(ctx, "thread", "")
+	assert.ErrorIs(t, err, checkpoint.ErrBrokenChain)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	for _, cp := range []checkpoint.Checkpoint{
+		{ID: "0", Kind: checkpoint.KindFull, State: []byte(`["a"]`)},
+		{ID: "1", Kind: checkpoint.KindFull, State: []byte(`["x"]`)},
+		// Applies to 0, saved by the same invocation, rather than to 1.
+		{ID: "2", Kind: checkpoint.KindDelta, State: []byte(`{"offset":1,"items":["b"]}`), Metadata: map[string]string{checkpoint.MetadataBase: "0"}},
+		{ID: "3", Kind: checkpoint.KindDelta, State: []byte(`{"offset":2,"items":["y"]}`)},
+		{ID: "4", Kind: checkpoint.KindDelta, State: []byte(`{"offset":2,"items":["c"]}`), Metadata: map[string]string{checkpoint.MetadataBase: "2"}},
+		{ID: "5", Kind: "compressed", State: []byte(`["a"]`)},
+		{ID: "6", Kind: checkpoint.KindDelta, State: []byte(`{"offset":0,"items":[]}`), Metadata: map[string]string{checkpoint.MetadataBase: "missing"}},
+	} {
+		cp.ThreadID, cp.Version = "thread", checkpoint.FormatVersion
+		require.NoError(t, store.Put(ctx, cp))
+	}
+
+	testCases := []struct {
+		id       string
+		expected string
+		err      error
+	}{
+		{id: "0", expected: `["a"]`},
+		{id: "2", expected: `["a","b"]`},
+		{id: "3", expected: `["a","b","y"]`},
+		{id: "4", expected: `["a","b","c"]`},
+		{id: "5", err: checkpoint.ErrUnknownKind},
+		{id: "6", err: checkpoint.ErrBrokenChain},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			t.Parallel()
+
+			cp, err := store.Get(ctx, "thread", tc.id)
+			require.NoError(t, err)
+			resolved, err := checkpoint.Resolve(ctx, store, cp)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, checkpoint.KindFull, resolved.Kind)
+			assert.JSONEq(t, tc.expected, string(resolved.State))
+		})
+	}
+}
+
+func TestHistoryDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[message](store, 10)
+
+	state := []message{{Role: "human", Content: "hi"}, {Role: "ai", Content: "hello"}}
+	_, err := history.Save(ctx, "thread", 0, "chat", state[:1])
+	require.NoError(t, err)
+	_, err = history.Save(ctx, "thread", 1, "chat", state)
+	require.NoError(t, err)
+
+	require.NoError(t, history.Delete(ctx, "thread"))
+	_, err = history.Load(ctx, "thread", "")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	saved, err := history.Save(ctx, "thread", 0, "chat", state[:1])
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.KindFull, saved.Kind, "a deleted thread starts over with a snapshot")
+}
@@ -0,0 +1,118 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Memory is an in-memory Checkpointer, useful for tests and single-process applications.
+type Memory struct {
+	mu      sync.RWMutex
+	threads map[string][]Checkpoint
+}
+
+var (
+	_ Checkpointer = (*Memory)(nil)
+	_ ThreadLister = (*Memory)(nil)
+	_ Appender     = (*Memory)(nil)
+)
+
+// NewMemory creates a new in-memory checkpointer.
+func NewMemory() *Memory {
+	return &Memory{
+		threads: make(map[string][]Checkpoint),
+	}
+}
+
+// Put stores a checkpoint.
+func (m *Memory) Put(_ context.Context, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checkpoints := m.threads[cp.ThreadID]
+	for i := range checkpoints {
+		if checkpoints[i].ID == cp.ID {
+			checkpoints[i] = cp
+			return nil
+		}
+	}
+
+	checkpoints = append(checkpoints, cp)
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Step < checkpoints[j].Step
+	})
+	m.threads[cp.ThreadID] = checkpoints
+	return nil
+}
+
+// Append stores cp as the next checkpoint of its thread, numbered under the lock of the
+// checkpointer.
+func (m *Memory) Append(_ context.Context, cp Checkpoint) (Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp.Step = 0
+	if checkpoints := m.threads[cp.ThreadID]; len(checkpoints) > 0 {
+		cp.Step = checkpoints[len(checkpoints)-1].Step + 1
+	}
+	cp.ID = StepID(cp.Step)
+	m.threads[cp.ThreadID] = append(m.threads[cp.ThreadID], cp)
+	return cp, nil
+}
+
+// Get returns the checkpoint of the thread with the given ID.
+func (m *Memory) Get(_ context.Context, threadID, id string) (Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, cp := range m.threads[threadID] {
+		if cp.ID == id {
+			return cp, nil
+		}
+	}
+	return Checkpoint{}, fmt.Errorf("%w: %s/%s", ErrNotFound, threadID, id)
+}
+
+// Latest returns the checkpoint of the thread with the highest step.
+func (m *Memory) Latest(_ context.Context, threadID string) (Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	checkpoints := m.threads[threadID]
+	if len(checkpoints) == 0 {
+		return Checkpoint{}, fmt.Errorf("%w: %s", ErrNotFound, threadID)
+	}
+	return checkpoints[len(checkpoints)-1], nil
+}
+
+// List returns the checkpoints of the thread ordered by step.
+func (m *Memory) List(_ context.Context, threadID string) ([]Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]Checkpoint(nil), m.threads[threadID]...), nil
+}
+
+// Delete removes all the checkpoints of the thread.
+func (m *Memory) Delete(_ context.Context, threadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.threads, threadID)
+	return nil
+}
+
+// Threads returns the IDs of the threads holding checkpoints, in lexical order.
+func (m *Memory) Threads(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	threads := make([]string, 0, len(m.threads))
+	for id := range m.threads {
+		threads = append(threads, id)
+	}
+	sort.Strings(threads)
+	return threads, nil
+}
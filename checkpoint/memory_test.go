@@ -0,0 +1,122 @@
+package checkpoint_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := checkpoint.NewMemory()
+
+	_, err := m.Latest(ctx, "thread")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	require.NoError(t, m.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2}))
+	require.NoError(t, m.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "a", Step: 1}))
+	require.NoError(t, m.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2, Node: "replaced"}))
+
+	list, err := m.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].ID)
+
+	latest, err := m.Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", latest.Node)
+
+	got, err := m.Get(ctx, "thread", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Step)
+
+	_, err = m.Get(ctx, "thread", "missing")
+	assert.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestStepID(t *testing.T) {
+	t.Parallel()
+
+	assert.Less(t, checkpoint.StepID(9), checkpoint.StepID(10))
+}
+
+func TestMemoryDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := checkpoint.NewMemory()
+	require.NoError(t, m.Put(ctx, checkpoint.Checkpoint{ThreadID: "a", ID: "1"}))
+	require.NoError(t, m.Put(ctx, checkpoint.Checkpoint{ThreadID: "b", ID: "1"}))
+
+	require.NoError(t, m.Delete(ctx, "a"))
+	require.NoError(t, m.Delete(ctx, "unknown"))
+
+	_, err := m.Latest(ctx, "a")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+	_, err = m.Latest(ctx, "b")
+	require.NoError(t, err)
+}
+
+// plain hides the Append method of the checkpointer it wraps.
+type plain struct {
+	checkpoint.Checkpointer
+}
+
+func TestAppend(t *testing.T) {
+	t.Parallel()
+
+	for name, c := range map[string]checkpoint.Checkpointer{
+		"appender":     checkpoint.NewMemory(),
+		"not appender": plain{checkpoint.NewMemory()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "custom", Step: 4}))
+			for _, expected := range []int{5, 6} {
+				cp, err := checkpoint.Append(ctx, c, checkpoint.Checkpoint{ThreadID: "thread", Node: "node"})
+				require.NoError(t, err)
+				assert.Equal(t, checkpoint.Checkpoint{ThreadID: "thread", ID: checkpoint.StepID(expected), Step: expected, Node: "node"}, cp)
+			}
+
+			cp, err := checkpoint.Append(ctx, c, checkpoint.Checkpoint{ThreadID: "new"})
+			require.NoError(t, err)
+			assert.Equal(t, 0, cp.Step)
+			latest, err := c.Latest(ctx, "thread")
+			require.NoError(t, err)
+			assert.Equal(t, checkpoint.StepID(6), latest.ID)
+		})
+	}
+}
+
+func TestMemoryAppendConcurrent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := checkpoint.NewMemory()
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Append(ctx, checkpoint.Checkpoint{ThreadID: "thread"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	list, err := m.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 50)
+	for i, cp := range list {
+		assert.Equal(t, i, cp.Step)
+		assert.Equal(t, checkpoint.StepID(i), cp.ID)
+	}
+}
@@ -0,0 +1,313 @@
+// Package postgres implements checkpoint.Checkpointer on PostgreSQL, so several server instances
+// can share the state of graph threads.
+//
+// The checkpoints are stored in one table, with a row per checkpoint keyed by thread and ID. The
+// table is created and upgraded by versioned migrations, applied when the checkpointer is
+// created under an advisory lock, so instances starting together do not race. Every write is a
+// single statement, so concurrent writers never observe or leave partial changes, and appends
+// to a thread hold an advisory lock of the thread, so concurrent appends get distinct steps.
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// DefaultTable is the name of the table of checkpoints when none is configured.
+const DefaultTable = "checkpoints"
+
+// migrations create and upgrade the table of checkpoints, in order: the table is at the version
+// of the number of migrations applied. Names are in place of %[1]s for the table and %[2]s for
+// its index.
+var migrations = []string{
+	`CREATE TABLE %[1]s (
+	thread_id  TEXT        NOT NULL,
+	id         TEXT        NOT NULL,
+	seq        BIGSERIAL   NOT NULL,
+	step       BIGINT      NOT NULL,
+	node       TEXT        NOT NULL,
+	kind       TEXT        NOT NULL,
+	version    INTEGER     NOT NULL,
+	state      BYTEA,
+	metadata   JSONB,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (thread_id, id)
+);
+CREATE INDEX %[2]s ON %[1]s (thread_id, step, seq);`,
+}
+
+// Checkpointer is a checkpoint.Checkpointer on PostgreSQL.
+type Checkpointer struct {
+	pool  *pgxpool.Pool
+	table pgx.Identifier
+
+	// owned is set when the pool was created by Open, and is closed by Close.
+	owned bool
+}
+
+var (
+	_ checkpoint.Checkpointer = (*Checkpointer)(nil)
+	_ checkpoint.ThreadLister = (*Checkpointer)(nil)
+	_ checkpoint.Appender     = (*Checkpointer)(nil)
+)
+
+// Open connects a pool to the database of connString, a URL or DSN as accepted by
+// pgxpool.ParseConfig, whose pool_max_conns and related parameters configure the pool, and
+// migrates DefaultTable.
+func Open(ctx context.Context, connString string) (*Checkpointer, error) {
+	pool, err := pgxpool.New(ctx, connString)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+	c, err := New(ctx, pool, DefaultTable)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	c.owned = true
+	return c, nil
+}
+
+// New returns a checkpointer storing checkpoints in the table of the pool, DefaultTable if
+// empty, optionally qualified by a schema as in "schema.table". The table is migrated to the
+// latest version. The pool is not closed by Close.
+func New(ctx context.Context, pool *pgxpool.Pool, table string) (*Checkpointer, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	c := &Checkpointer{pool: pool, table: strings.Split(table, ".")}
+	if err := c.migrate(ctx); err != nil {
+		return nil, fmt.Errorf("migrating table %s: %w", table, err)
+	}
+	return c, nil
+}
+
+// Close closes the pool if it was created by Open.
+func (c *Checkpointer) Close() {
+	if c.owned {
+		c.pool.Close()
+	}
+}
+
+// suffixed returns the name of the table followed by suffix, unqualified.
+func (c *Checkpointer) suffixed(suffix string) string {
+	return c.table[len(c.table)-1] + suffix
+}
+
+// query returns the query with the quoted name of the table in place of %[1]s, and of its
+// index in place of %[2]s.
+func (c *Checkpointer) query(q string) string {
+	return fmt.Sprintf(q, c.table.Sanitize(), pgx.Identifier{c.suffixed("_thread_step")}.Sanitize())
+}
+
+// migrate applies the migrations not applied yet to the table, recording its version in a
+// table of its own. The transaction holds an advisory lock on the table, so concurrent
+// migrations wait for each other.
+func (c *Checkpointer) migrate(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		versions := append(slices.Clone(c.table[:len(c.table)-1]), c.suffixed("_migrations")).Sanitize()
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, versions); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+versions+` (version INTEGER NOT NULL)`); err != nil {
+			return err
+		}
+
+		var version int
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+versions).Scan(&version); err != nil {
+			return err
+		}
+		if version > len(migrations) {
+			return fmt.Errorf("table at version %d, newest supported is %d", version, len(migrations))
+		}
+		for i := version; i < len(migrations); i++ {
+			if _, err := tx.Exec(ctx, c.query(migrations[i])); err != nil {
+				return fmt.Errorf("applying migration %d: %w", i+1, err)
+			}
+		}
+		if version < len(migrations) {
+			if _, err := tx.Exec(ctx, `INSERT INTO `+versions+` (version) VALUES ($1)`, len(migrations)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// columns are the columns of the table, in the order scan reads them.
+const columns = "thread_id, id, step, node, kind, version, state, metadata, created_at"
+
+// Put stores a checkpoint, replacing any checkpoint of the thread with the same ID.
+func (c *Checkpointer) Put(ctx context.Context, cp checkpoint.Checkpoint) error {
+	metadata, err := encodeMetadata(cp.Metadata)
+	if err != nil {
+		return err
+	}
+
+	// Upserting keeps the sequence number, and so the order, of replaced checkpoints.
+	_, err = c.pool.Exec(ctx, c.query(`
+INSERT INTO %[1]s (`+columns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (thread_id, id) DO UPDATE SET
+	step = excluded.step, node = excluded.node, kind = excluded.kind, version = excluded.version,
+	state = excluded.state, metadata = excluded.metadata, created_at = excluded.created_at`),
+		cp.ThreadID, cp.ID, cp.Step, cp.Node, string(cp.Kind), cp.Version, cp.State, metadata, cp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("putting checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+	}
+	return nil
+}
+
+// Append stores cp as the next checkpoint of its thread. The transaction reading the step and
+// inserting the row holds an advisory lock of the thread, so concurrent appends wait for each
+// other.
+func (c *Checkpointer) Append(ctx context.Context, cp checkpoint.Checkpoint) (checkpoint.Checkpoint, error) {
+	metadata, err := encodeMetadata(cp.Metadata)
+	if err != nil {
+		return checkpoint.Checkpoint{}, err
+	}
+
+	err = pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, c.table.Sanitize(), cp.ThreadID); err != nil {
+			return err
+		}
+		// The format of the IDs is the format of checkpoint.StepID.
+		return tx.QueryRow(ctx, c.query(`
+INSERT INTO %[1]s (`+columns+`)
+SELECT $1::text, lpad(n.step::text, 10, '0'), n.step, $2::text, $3::text, $4::integer, $5::bytea, $6::jsonb, $7::timestamptz
+FROM (SELECT COALESCE(MAX(step) + 1, 0) AS step FROM %[1]s WHERE thread_id = $1) AS n
+RETURNING step`),
+			cp.ThreadID, cp.Node, string(cp.Kind), cp.Version, cp.State, metadata, cp.CreatedAt).Scan(&cp.Step)
+	})
+	if err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("appending checkpoint to thread %s: %w", cp.ThreadID, err)
+	}
+	cp.ID = checkpoint.StepID(cp.Step)
+	return cp, nil
+}
+
+// Get returns the checkpoint of the thread with the given ID.
+func (c *Checkpointer) Get(ctx context.Context, threadID, id string) (checkpoint.Checkpoint, error) {
+	row := c.pool.QueryRow(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = $1 AND id = $2`), threadID, id)
+	cp, err := scan(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return cp, fmt.Errorf("%w: %s/%s", checkpoint.ErrNotFound, threadID, id)
+	}
+	return cp, err
+}
+
+// Latest returns the checkpoint of the thread with the highest step.
+func (c *Checkpointer) Latest(ctx context.Context, threadID string) (checkpoint.Checkpoint, error) {
+	row := c.pool.QueryRow(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = $1 ORDER BY step DESC, seq DESC LIMIT 1`), threadID)
+	cp, err := scan(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return cp, fmt.Errorf("%w: %s", checkpoint.ErrNotFound, threadID)
+	}
+	return cp, err
+}
+
+// List returns the checkpoints of the thread ordered by step.
+func (c *Checkpointer) List(ctx context.Context, threadID string) ([]checkpoint.Checkpoint, error) {
+	rows, err := c.pool.Query(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = $1 ORDER BY step, seq`), threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+	}
+	defer rows.Close()
+
+	var checkpoints []checkpoint.Checkpoint
+	for rows.Next() {
+		cp, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all the checkpoints of the thread.
+func (c *Checkpointer) Delete(ctx context.Context, threadID string) error {
+	if _, err := c.pool.Exec(ctx, c.query(`DELETE FROM %[1]s WHERE thread_id = $1`), threadID); err != nil {
+		return fmt.Errorf("deleting thread %s: %w", threadID, err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads holding checkpoints, in lexical order.
+func (c *Checkpointer) Threads(ctx context.Context) ([]string, error) {
+	// The C collation orders by bytes, whatever the collation of the database.
+	rows, err := c.pool.Query(ctx, c.query(`SELECT DISTINCT thread_id COLLATE "C" AS thread_id FROM %[1]s ORDER BY 1`))
+	if err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	threads, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	return threads, nil
+}
+
+// Prune deletes the checkpoints of the thread older than its last keep ones, and returns how
+// many were deleted. Delta checkpoints still need the full checkpoint they apply to, so the
+// checkpoints since the last full one among the deleted ones are kept.
+func (c *Checkpointer) Prune(ctx context.Context, threadID string, keep int) (int, error) {
+	if keep < 1 {
+		keep = 1
+	}
+	tag, err := c.pool.Exec(ctx, c.query(`
+DELETE FROM %[1]s WHERE thread_id = $1 AND step < (
+	SELECT MAX(step) FROM %[1]s WHERE thread_id = $1 AND kind != $3 AND step <= (
+		SELECT step FROM %[1]s WHERE thread_id = $1 ORDER BY step DESC, seq DESC LIMIT 1 OFFSET $2
+	)
+)`), threadID, keep-1, string(checkpoint.KindDelta))
+	if err != nil {
+		return 0, fmt.Errorf("pruning thread %s: %w", threadID, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// encodeMetadata returns the value of the metadata column for the metadata.
+func encodeMetadata(metadata map[string]string) ([]byte, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encoding metadata: %w", err)
+	}
+	return encoded, nil
+}
+
+// scan reads a checkpoint from a row of columns.
+func scan(row pgx.Row) (checkpoint.Checkpoint, error) {
+	var (
+		cp       checkpoint.Checkpoint
+		kind     string
+		metadata []byte
+	)
+	err := row.Scan(&cp.ThreadID, &cp.ID, &cp.Step, &cp.Node, &kind, &cp.Version, &cp.State, &metadata, &cp.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return cp, err
+		}
+		return cp, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	cp.Kind = checkpoint.Kind(kind)
+	if metadata != nil {
+		if err := json.Unmarshal(metadata, &cp.Metadata); err != nil {
+			return cp, fmt.Errorf("decoding metadata of checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+		}
+	}
+	return cp, nil
+}
@@ -0,0 +1,142 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/checkpoint/postgres"
+)
+
+// TestCheckpointer runs against the database at POSTGRES_URL, and is skipped when it is not
+// set.
+func TestCheckpointer(t *testing.T) {
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, url)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Isolate the tables of every run of the test.
+	table := "checkpoints_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	defer func() {
+		for _, name := range []string{table, table + "_migrations"} {
+			_, _ = pool.Exec(context.Background(), "DROP TABLE IF EXISTS "+pgx.Identifier{name}.Sanitize())
+		}
+	}()
+
+	// Instances starting together migrate the table once.
+	var wg sync.WaitGroup
+	instances := make([]*postgres.Checkpointer, 4)
+	errs := make([]error, len(instances))
+	for i := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instances[i], errs[i] = postgres.New(ctx, pool, table)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	c := instances[0]
+
+	_, err = c.Latest(ctx, "thread")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 1000, time.UTC)
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: "a", Step: 1, Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2, Node: "replaced"}))
+
+	list, err := c.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].ID)
+
+	latest, err := instances[1].Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", latest.Node)
+	assert.Nil(t, latest.Metadata)
+
+	got, err := c.Get(ctx, "thread", "a")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k": "v"}, got.Metadata)
+	assert.Equal(t, []byte(`{"n":1}`), got.State)
+	assert.Equal(t, checkpoint.KindFull, got.Kind)
+	assert.True(t, createdAt.Equal(got.CreatedAt))
+
+	_, err = c.Get(ctx, "thread", "missing")
+	assert.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	// Concurrent writers of a thread all land.
+	for i, instance := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = instance.Put(ctx, checkpoint.Checkpoint{ThreadID: "concurrent", ID: checkpoint.StepID(i), Step: i})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	list, err = c.List(ctx, "concurrent")
+	require.NoError(t, err)
+	assert.Len(t, list, len(instances))
+
+	// Concurrent appends to a thread get distinct steps, following the existing ones.
+	for _, instance := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := instance.Append(ctx, checkpoint.Checkpoint{ThreadID: "concurrent", Metadata: map[string]string{"k": "v"}})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	list, err = c.List(ctx, "concurrent")
+	require.NoError(t, err)
+	require.Len(t, list, 2*len(instances))
+	for i, cp := range list {
+		assert.Equal(t, checkpoint.StepID(i), cp.ID)
+	}
+
+	// Pruning keeps the full checkpoint the kept deltas apply to.
+	for step, kind := range []checkpoint.Kind{checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindDelta} {
+		require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "pruned", ID: checkpoint.StepID(step), Step: step, Kind: kind}))
+	}
+	deleted, err := c.Prune(ctx, "pruned", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	require.NoError(t, c.Delete(ctx, "pruned"))
+	require.NoError(t, c.Delete(ctx, "unknown"))
+
+	threads, err := c.Threads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"concurrent", "thread"}, threads)
+
+	_, err = postgres.New(ctx, pool, fmt.Sprintf("missing_schema_%d.checkpoints", time.Now().UnixNano()))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cesto93/langgraphgo/redact"
+)
+
+// redacting is a Checkpointer masking secrets before they reach the wrapped checkpointer.
+type redacting struct {
+	next     Checkpointer
+	redactor *redact.Redactor
+}
+
+// WithRedaction returns a Checkpointer masking the secrets of states and metadata with r before
+// storing them in cp. Checkpoints read back through it have the secrets masked by r restored,
+// so the process that wrote them sees the original values while the storage never does.
+func WithRedaction(cp Checkpointer, r *redact.Redactor) Checkpointer {
+	return &redacting{next: cp, redactor: r}
+}
+
+// Put masks the secrets of the checkpoint and stores it.
+func (c *redacting) Put(ctx context.Context, cp Checkpoint) error {
+	state, err := c.redactor.JSON(cp.State)
+	if err != nil {
+		return fmt.Errorf("redacting state: %w", err)
+	}
+	cp.State = state
+	cp.Metadata = mapValues(cp.Metadata, c.redactor.String)
+	return c.next.Put(ctx, cp)
+}
+
+// Append masks the secrets of the checkpoint and stores it as the next checkpoint of its
+// thread, atomically if the wrapped checkpointer implements Appender.
+func (c *redacting) Append(ctx context.Context, cp Checkpoint) (Checkpoint, error) {
+	redacted := cp
+	state, err := c.redactor.JSON(cp.State)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("redacting state: %w", err)
+	}
+	redacted.State = state
+	redacted.Metadata = mapValues(cp.Metadata, c.redactor.String)
+	stored, err := Append(ctx, c.next, redacted)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	cp.ID, cp.Step = stored.ID, stored.Step
+	return cp, nil
+}
+
+// Get returns a checkpoint with its secrets restored.
+func (c *redacting) Get(ctx context.Context, threadID, id string) (Checkpoint, error) {
+	cp, err := c.next.Get(ctx, threadID, id)
+	if err != nil {
+		return cp, err
+	}
+	return c.restore(cp)
+}
+
+// Latest returns the latest checkpoint of a thread with its secrets restored.
+func (c *redacting) Latest(ctx context.Context, threadID string) (Checkpoint, error) {
+	cp, err := c.next.Latest(ctx, threadID)
+	if err != nil {
+		return cp, err
+	}
+	return c.restore(cp)
+}
+
+// List returns the checkpoints of a thread with their secrets restored.
+func (c *redacting) List(ctx context.Context, threadID string) ([]Checkpoint, error) {
+	checkpoints, err := c.next.List(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checkpoints {
+		if checkpoints[i], err = c.restore(checkpoints[i]); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all the checkpoints of a thread.
+func (c *redacting) Delete(ctx context.Context, threadID string) error {
+	return c.next.Delete(ctx, threadID)
+}
+
+// Threads lists the threads of the wrapped checkpointer, if it implements ThreadLister.
+func (c *redacting) Threads(ctx context.Context) ([]string, error) {
+	lister, ok := c.next.(ThreadLister)
+	if !ok {
+		return nil, fmt.Errorf("%T does not list threads", c.next)
+	}
+	return lister.Threads(ctx)
+}
+
+func (c *redacting) restore(cp Checkpoint) (Checkpoint, error) {
+	state, err := c.redactor.Restore(cp.State)
+	if err != nil {
+		return cp, fmt.Errorf("restoring state: %w", err)
+	}
+	cp.State = state
+	cp.Metadata = mapValues(cp.Metadata, c.redactor.RestoreString)
+	return cp, nil
+}
+
+// mapValues returns a copy of m with fn applied to its values.
+func mapValues(m map[string]string, fn func(string) string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fn(v)
+	}
+	return out
+}
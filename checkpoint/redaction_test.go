@@ -0,0 +1,104 @@
+package checkpoint_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	APIKey  string `json:"api_key,omitempty"`
+}
+
+func TestWithRedaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := checkpoint.NewMemory()
+	r := redact.New([]string{"api_key"}, regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-\d{4}\b`))
+	history := checkpoint.NewHistory[secretMessage](checkpoint.WithRedaction(storage, r), 2)
+
+	state := []secretMessage{{Role: "system", Content: "hi", APIKey: "sk-live"}}
+	_, err := history.Save(ctx, "t1", 0, "setup", state)
+	require.NoError(t, err)
+
+	state = append(state, secretMessage{Role: "human", Content: "card 1234-5678-9012-3456"})
+	saved, err := history.Save(ctx, "t1", 1, "chat", state)
+	require.NoError(t, err)
+
+	stored, err := storage.List(ctx, "t1")
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	for _, cp := range stored {
+		assert.NotContains(t, string(cp.State), "sk-live")
+		assert.NotContains(t, string(cp.State), "1234-5678-9012-3456")
+	}
+
+	loaded, err := history.Load(ctx, "t1", saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
+
+func TestWithRedactionMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := checkpoint.NewMemory()
+	cp := checkpoint.WithRedaction(storage, redact.New(nil, regexp.MustCompile(`secret-\w+`)))
+
+	require.NoError(t, cp.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "t1",
+		ID:       checkpoint.StepID(0),
+		State:    []byte(`"secret-state"`),
+		Metadata: map[string]string{"note": "secret-meta"},
+	}))
+
+	raw, err := storage.Latest(ctx, "t1")
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw.State), "secret-state")
+	assert.NotContains(t, raw.Metadata["note"], "secret-meta")
+
+	restored, err := cp.Get(ctx, "t1", checkpoint.StepID(0))
+	require.NoError(t, err)
+	assert.Equal(t, `"secret-state"`, string(restored.State))
+	assert.Equal(t, "secret-meta", restored.Metadata["note"])
+
+	_, err = cp.Get(ctx, "t1", "missing")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	err = cp.Put(ctx, checkpoint.Checkpoint{ThreadID: "t1", ID: "bad", State: []byte("{")})
+	require.Error(t, err)
+}
+
+func TestWithRedactionAppend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := checkpoint.NewMemory()
+	cp := checkpoint.WithRedaction(storage, redact.New(nil, regexp.MustCompile(`secret-\w+`)))
+
+	for step := range 2 {
+		appended, err := checkpoint.Append(ctx, cp, checkpoint.Checkpoint{
+			ThreadID: "t1",
+			State:    []byte(`"secret-state"`),
+			Metadata: map[string]string{"note": "secret-meta"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, step, appended.Step)
+		assert.Equal(t, `"secret-state"`, string(appended.State))
+		assert.Equal(t, "secret-meta", appended.Metadata["note"])
+	}
+
+	raw, err := storage.Latest(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.StepID(1), raw.ID)
+	assert.NotContains(t, string(raw.State), "secret-state")
+	assert.NotContains(t, raw.Metadata["note"], "secret-meta")
+}
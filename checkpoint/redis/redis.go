@@ -0,0 +1,398 @@
+// Package redis implements checkpoint.Checkpointer on Redis, for low-latency session state in
+// chat deployments.
+//
+// The checkpoints of a thread are stored under keys sharing the hash tag of the thread, so they
+// live on one node of a cluster: a hash of the checkpoints by ID, a hash of their sequence
+// numbers, a sorted set ordering them by step and a counter. Writes are Lua scripts, so
+// concurrent writers never observe or leave partial changes. Threads expire after a TTL since
+// their last write, and writes can be announced on a pub/sub channel.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// DefaultPrefix prefixes the keys of the checkpointer when no prefix is configured.
+const DefaultPrefix = "langgraph"
+
+// Config configures a Checkpointer.
+type Config struct {
+	// Prefix prefixes the keys and the channel of the checkpointer; DefaultPrefix is used when
+	// empty.
+	Prefix string
+
+	// TTL is how long a thread is kept after its last write; threads are kept until deleted
+	// when zero.
+	TTL time.Duration
+
+	// Notify publishes a Notification on the channel of the checkpointer for every checkpoint
+	// written, which Subscribe receives.
+	Notify bool
+}
+
+// Notification announces a checkpoint written to the checkpointer.
+type Notification struct {
+	// ThreadID identifies the thread of the checkpoint.
+	ThreadID string `json:"thread_id"`
+
+	// ID identifies the checkpoint within its thread.
+	ID string `json:"id"`
+
+	// Step is the step of the checkpoint.
+	Step int `json:"step"`
+
+	// Node is the node that produced the checkpoint.
+	Node string `json:"node"`
+}
+
+// Checkpointer is a checkpoint.Checkpointer on Redis.
+type Checkpointer struct {
+	client goredis.UniversalClient
+	cfg    Config
+}
+
+var (
+	_ checkpoint.Checkpointer = (*Checkpointer)(nil)
+	_ checkpoint.ThreadLister = (*Checkpointer)(nil)
+	_ checkpoint.Appender     = (*Checkpointer)(nil)
+)
+
+// New returns a checkpointer storing checkpoints with the client.
+func New(client goredis.UniversalClient, cfg Config) *Checkpointer {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	return &Checkpointer{client: client, cfg: cfg}
+}
+
+// record is the encoding of a checkpoint in the hash of its thread.
+type record struct {
+	ID        string            `json:"id"`
+	Step      int               `json:"step"`
+	Node      string            `json:"node"`
+	Kind      checkpoint.Kind   `json:"kind"`
+	Version   int               `json:"version"`
+	State     []byte            `json:"state"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// threadKeys returns the keys of a thread: the hash of its checkpoints, the hash of their
+// sequence numbers, the sorted set ordering them and the counter of the sequence.
+func (c *Checkpointer) threadKeys(threadID string) []string {
+	base := c.cfg.Prefix + ":{" + threadID + "}:"
+	return []string{base + "checkpoints", base + "seqs", base + "order", base + "seq"}
+}
+
+// threadsKey returns the key of the sorted set of the threads, scored by their expiry.
+func (c *Checkpointer) threadsKey() string {
+	return c.cfg.Prefix + ":threads"
+}
+
+// channel returns the channel notifications are published to.
+func (c *Checkpointer) channel() string {
+	return c.cfg.Prefix + ":notifications"
+}
+
+// The members of the sorted sets ordering the checkpoints of a thread are the sequence number
+// of the checkpoint, padded so that members of equal steps sort in insertion order, followed by
+// its ID.
+var (
+	// putScript stores a checkpoint, keeping the sequence number of the checkpoint it replaces.
+	// ARGV are the ID, the step, the record and the TTL in milliseconds.
+	putScript = goredis.NewScript(`
+local seq = redis.call('HGET', KEYS[2], ARGV[1])
+if not seq then
+	seq = redis.call('INCR', KEYS[4])
+	redis.call('HSET', KEYS[2], ARGV[1], seq)
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[2], string.format('%020d:%s', tonumber(seq), ARGV[1]))
+if tonumber(ARGV[4]) > 0 then
+	for i = 1, 4 do
+		redis.call('PEXPIRE', KEYS[i], ARGV[4])
+	end
+end
+return 1`)
+
+	// appendScript stores a checkpoint as the next one of the thread and returns its step.
+	// ARGV are the record, without ID and step, and the TTL in milliseconds. The format of the
+	// IDs is the format of checkpoint.StepID.
+	appendScript = goredis.NewScript(`
+local last = redis.call('ZREVRANGE', KEYS[3], 0, 0, 'WITHSCORES')
+local step = 0
+if #last > 0 then
+	step = tonumber(last[2]) + 1
+end
+local id = string.format('%010d', step)
+local record = cjson.decode(ARGV[1])
+record['id'] = id
+record['step'] = step
+local seq = redis.call('INCR', KEYS[4])
+redis.call('HSET', KEYS[2], id, seq)
+redis.call('HSET', KEYS[1], id, cjson.encode(record))
+redis.call('ZADD', KEYS[3], step, string.format('%020d:%s', seq, id))
+if tonumber(ARGV[2]) > 0 then
+	for i = 1, 4 do
+		redis.call('PEXPIRE', KEYS[i], ARGV[2])
+	end
+end
+return step`)
+
+	// latestScript returns the record of the last checkpoint.
+	latestScript = goredis.NewScript(`
+local members = redis.call('ZREVRANGE', KEYS[3], 0, 0)
+if #members == 0 then
+	return false
+end
+return redis.call('HGET', KEYS[1], string.sub(members[1], 22))`)
+
+	// listScript returns the records of the checkpoints in order.
+	listScript = goredis.NewScript(`
+local members = redis.call('ZRANGE', KEYS[3], 0, -1)
+local records = {}
+for i, member in ipairs(members) do
+	records[i] = redis.call('HGET', KEYS[1], string.sub(member, 22))
+end
+return records`)
+
+	// pruneScript deletes the checkpoints of the given IDs, unless the thread changed since it
+	// was read: ARGV[1] is the ID of its last checkpoint and ARGV[2] their number then.
+	pruneScript = goredis.NewScript(`
+local members = redis.call('ZREVRANGE', KEYS[3], 0, 0)
+if #members == 0 or string.sub(members[1], 22) ~= ARGV[1] or redis.call('ZCARD', KEYS[3]) ~= tonumber(ARGV[2]) then
+	return -1
+end
+local deleted = 0
+for i = 3, #ARGV do
+	local seq = redis.call('HGET', KEYS[2], ARGV[i])
+	if seq then
+		redis.call('ZREM', KEYS[3], string.format('%020d:%s', tonumber(seq), ARGV[i]))
+		redis.call('HDEL', KEYS[1], ARGV[i])
+		redis.call('HDEL', KEYS[2], ARGV[i])
+		deleted = deleted + 1
+	end
+end
+return deleted`)
+)
+
+// Put stores a checkpoint, replacing any checkpoint of the thread with the same ID, and
+// extends the TTL of the thread.
+func (c *Checkpointer) Put(ctx context.Context, cp checkpoint.Checkpoint) error {
+	encoded, err := json.Marshal(record{
+		ID: cp.ID, Step: cp.Step, Node: cp.Node, Kind: cp.Kind, Version: cp.Version,
+		State: cp.State, Metadata: cp.Metadata, CreatedAt: cp.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+	}
+
+	if err := putScript.Run(ctx, c.client, c.threadKeys(cp.ThreadID), cp.ID, cp.Step, encoded, c.cfg.TTL.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("putting checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+	}
+	return c.written(ctx, cp)
+}
+
+// Append stores cp as the next checkpoint of its thread, numbered by a script, and extends the
+// TTL of the thread.
+func (c *Checkpointer) Append(ctx context.Context, cp checkpoint.Checkpoint) (checkpoint.Checkpoint, error) {
+	encoded, err := json.Marshal(record{
+		Node: cp.Node, Kind: cp.Kind, Version: cp.Version,
+		State: cp.State, Metadata: cp.Metadata, CreatedAt: cp.CreatedAt,
+	})
+	if err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("encoding checkpoint of thread %s: %w", cp.ThreadID, err)
+	}
+
+	cp.Step, err = appendScript.Run(ctx, c.client, c.threadKeys(cp.ThreadID), encoded, c.cfg.TTL.Milliseconds()).Int()
+	if err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("appending checkpoint to thread %s: %w", cp.ThreadID, err)
+	}
+	cp.ID = checkpoint.StepID(cp.Step)
+	return cp, c.written(ctx, cp)
+}
+
+// written indexes the thread of a checkpoint just written and announces the checkpoint.
+func (c *Checkpointer) written(ctx context.Context, cp checkpoint.Checkpoint) error {
+	// The threads are indexed apart from their keys, which may live on another node.
+	expiry := math.Inf(1)
+	if c.cfg.TTL > 0 {
+		expiry = float64(time.Now().Add(c.cfg.TTL).UnixMilli())
+	}
+	if err := c.client.ZAdd(ctx, c.threadsKey(), goredis.Z{Score: expiry, Member: cp.ThreadID}).Err(); err != nil {
+		return fmt.Errorf("indexing thread %s: %w", cp.ThreadID, err)
+	}
+
+	if c.cfg.Notify {
+		notification, err := json.Marshal(Notification{ThreadID: cp.ThreadID, ID: cp.ID, Step: cp.Step, Node: cp.Node})
+		if err != nil {
+			return fmt.Errorf("encoding notification: %w", err)
+		}
+		if err := c.client.Publish(ctx, c.channel(), notification).Err(); err != nil {
+			return fmt.Errorf("notifying checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the checkpoint of the thread with the given ID.
+func (c *Checkpointer) Get(ctx context.Context, threadID, id string) (checkpoint.Checkpoint, error) {
+	encoded, err := c.client.HGet(ctx, c.threadKeys(threadID)[0], id).Result()
+	if errors.Is(err, goredis.Nil) {
+		return checkpoint.Checkpoint{}, fmt.Errorf("%w: %s/%s", checkpoint.ErrNotFound, threadID, id)
+	}
+	if err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("getting checkpoint %s/%s: %w", threadID, id, err)
+	}
+	return decode(threadID, encoded)
+}
+
+// Latest returns the checkpoint of the thread with the highest step.
+func (c *Checkpointer) Latest(ctx context.Context, threadID string) (checkpoint.Checkpoint, error) {
+	encoded, err := latestScript.Run(ctx, c.client, c.threadKeys(threadID)).Text()
+	if errors.Is(err, goredis.Nil) {
+		return checkpoint.Checkpoint{}, fmt.Errorf("%w: %s", checkpoint.ErrNotFound, threadID)
+	}
+	if err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("getting latest checkpoint of thread %s: %w", threadID, err)
+	}
+	return decode(threadID, encoded)
+}
+
+// List returns the checkpoints of the thread ordered by step.
+func (c *Checkpointer) List(ctx context.Context, threadID string) ([]checkpoint.Checkpoint, error) {
+	records, err := listScript.Run(ctx, c.client, c.threadKeys(threadID)).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+	}
+
+	var checkpoints []checkpoint.Checkpoint
+	for _, encoded := range records {
+		cp, err := decode(threadID, encoded)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all the checkpoints of the thread.
+func (c *Checkpointer) Delete(ctx context.Context, threadID string) error {
+	if err := c.client.Del(ctx, c.threadKeys(threadID)...).Err(); err != nil {
+		return fmt.Errorf("deleting thread %s: %w", threadID, err)
+	}
+	if err := c.client.ZRem(ctx, c.threadsKey(), threadID).Err(); err != nil {
+		return fmt.Errorf("deleting thread %s: %w", threadID, err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads holding checkpoints, in lexical order. Expired
+// threads are removed from the index of the threads.
+func (c *Checkpointer) Threads(ctx context.Context) ([]string, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := c.client.ZRemRangeByScore(ctx, c.threadsKey(), "-inf", "("+now).Err(); err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	threads, err := c.client.ZRange(ctx, c.threadsKey(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	slices.Sort(threads)
+	return threads, nil
+}
+
+// Prune deletes the checkpoints of the thread older than its last keep ones, and returns how
+// many were deleted. Delta checkpoints still need the full checkpoint they apply to, so the
+// checkpoints since the last full one among the deleted ones are kept. Pruning is retried when
+// the thread is written concurrently.
+func (c *Checkpointer) Prune(ctx context.Context, threadID string, keep int) (int, error) {
+	keep = max(keep, 1)
+	for {
+		checkpoints, err := c.List(ctx, threadID)
+		if err != nil {
+			return 0, fmt.Errorf("pruning thread %s: %w", threadID, err)
+		}
+		if len(checkpoints) <= keep {
+			return 0, nil
+		}
+
+		anchor := len(checkpoints) - keep
+		for anchor > 0 && checkpoints[anchor].Kind == checkpoint.KindDelta {
+			anchor--
+		}
+		args := []any{checkpoints[len(checkpoints)-1].ID, len(checkpoints)}
+		for _, cp := range checkpoints[:anchor] {
+			args = append(args, cp.ID)
+		}
+		deleted, err := pruneScript.Run(ctx, c.client, c.threadKeys(threadID), args...).Int()
+		if err != nil {
+			return 0, fmt.Errorf("pruning thread %s: %w", threadID, err)
+		}
+		if deleted >= 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the notifications of the checkpoints written with
+// Config.Notify, by any checkpointer with the same prefix, until ctx is done.
+func (c *Checkpointer) Subscribe(ctx context.Context) (<-chan Notification, error) {
+	sub := c.client.Subscribe(ctx, c.channel())
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("subscribing to %s: %w", c.channel(), err)
+	}
+
+	notifications := make(chan Notification)
+	go func() {
+		defer close(notifications)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var n Notification
+				if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+					continue
+				}
+				select {
+				case notifications <- n:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return notifications, nil
+}
+
+// decode decodes the record of a checkpoint of the thread.
+func decode(threadID, encoded string) (checkpoint.Checkpoint, error) {
+	var r record
+	if err := json.Unmarshal([]byte(encoded), &r); err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("decoding checkpoint of thread %s: %w", threadID, err)
+	}
+	return checkpoint.Checkpoint{
+		ThreadID: threadID, ID: r.ID, Step: r.Step, Node: r.Node, Kind: r.Kind, Version: r.Version,
+		State: r.State, Metadata: r.Metadata, CreatedAt: r.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package redis_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/checkpoint/redis"
+)
+
+// TestCheckpointer runs against the Redis server at REDIS_URL, and is skipped when it is not
+// set.
+func TestCheckpointer(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not set")
+	}
+
+	opts, err := goredis.ParseURL(url)
+	require.NoError(t, err)
+	client := goredis.NewClient(opts)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Isolate the keys of every run of the test.
+	prefix := "test-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	defer func() {
+		keys, _ := client.Keys(context.Background(), prefix+":*").Result()
+		if len(keys) > 0 {
+			client.Del(context.Background(), keys...)
+		}
+	}()
+
+	c := redis.New(client, redis.Config{Prefix: prefix, Notify: true})
+	notifications, err := c.Subscribe(ctx)
+	require.NoError(t, err)
+
+	_, err = c.Latest(ctx, "thread")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 42, time.UTC)
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: "a", Step: 1, Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2, Node: "replaced"}))
+
+	for _, expected := range []redis.Notification{
+		{ThreadID: "thread", ID: "b", Step: 2},
+		{ThreadID: "thread", ID: "a", Step: 1, Node: "start"},
+		{ThreadID: "thread", ID: "b", Step: 2, Node: "replaced"},
+	} {
+		select {
+		case n := <-notifications:
+			assert.Equal(t, expected, n)
+		case <-ctx.Done():
+			t.Fatal("notification not received")
+		}
+	}
+
+	list, err := c.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].ID)
+
+	latest, err := c.Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", latest.Node)
+
+	got, err := c.Get(ctx, "thread", "a")
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: "a", Step: 1, Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	}, got)
+
+	_, err = c.Get(ctx, "thread", "missing")
+	assert.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	// Checkpoints of equal steps keep their insertion order.
+	for _, id := range []string{"x", "m", "a"} {
+		require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "same", ID: id, Step: 1}))
+	}
+	latest, err = c.Latest(ctx, "same")
+	require.NoError(t, err)
+	assert.Equal(t, "a", latest.ID)
+
+	// Concurrent writers of a thread all land.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "concurrent", ID: checkpoint.StepID(i), Step: i}))
+		}()
+	}
+	wg.Wait()
+	list, err = c.List(ctx, "concurrent")
+	require.NoError(t, err)
+	assert.Len(t, list, 8)
+
+	// Concurrent appends to a thread get distinct steps, following the existing ones.
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Append(ctx, checkpoint.Checkpoint{ThreadID: "concurrent", Metadata: map[string]string{"k": "v"}})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	list, err = c.List(ctx, "concurrent")
+	require.NoError(t, err)
+	require.Len(t, list, 16)
+	for i, cp := range list {
+		assert.Equal(t, checkpoint.StepID(i), cp.ID)
+		assert.Equal(t, i, cp.Step)
+	}
+	assert.Equal(t, map[string]string{"k": "v"}, list[15].Metadata)
+
+	// Pruning keeps the full checkpoint the kept deltas apply to.
+	for step, kind := range []checkpoint.Kind{checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindDelta} {
+		require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "pruned", ID: checkpoint.StepID(step), Step: step, Kind: kind}))
+	}
+	deleted, err := c.Prune(ctx, "pruned", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	list, err = c.List(ctx, "pruned")
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, checkpoint.KindFull, list[0].Kind)
+
+	require.NoError(t, c.Delete(ctx, "pruned"))
+	require.NoError(t, c.Delete(ctx, "unknown"))
+	_, err = c.Latest(ctx, "pruned")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	threads, err := c.Threads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"concurrent", "same", "thread"}, threads)
+
+	// Threads expire after their TTL.
+	expiring := redis.New(client, redis.Config{Prefix: prefix, TTL: 100 * time.Millisecond})
+	require.NoError(t, expiring.Put(ctx, checkpoint.Checkpoint{ThreadID: "session", ID: "1"}))
+	threads, err = expiring.Threads(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, threads, "session")
+
+	require.Eventually(t, func() bool {
+		_, err := expiring.Latest(ctx, "session")
+		return err != nil
+	}, 5*time.Second, 50*time.Millisecond)
+	threads, err = expiring.Threads(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, threads, "session")
+}
@@ -0,0 +1,283 @@
+//go:build !js && !wasip1
+
+// Package sqlite implements checkpoint.Checkpointer on a single-file SQLite database, for local
+// applications and tests that need checkpoints to survive restarts without running a database
+// server.
+//
+// The checkpoints are stored in one table, created when the checkpointer is opened, with a row
+// per checkpoint keyed by thread and ID. The driver is the pure Go modernc.org/sqlite, so cgo is
+// not required, but it does not support WebAssembly: the package is not built for js and wasip1.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	// Registers the "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// DefaultTable is the name of the table of checkpoints when none is configured.
+const DefaultTable = "checkpoints"
+
+// Checkpointer is a checkpoint.Checkpointer on SQLite.
+type Checkpointer struct {
+	db    *sql.DB
+	table string
+
+	// owned is set when the database was opened by Open, and is closed by Close.
+	owned bool
+}
+
+var (
+	_ checkpoint.Checkpointer = (*Checkpointer)(nil)
+	_ checkpoint.ThreadLister = (*Checkpointer)(nil)
+	_ checkpoint.Appender     = (*Checkpointer)(nil)
+)
+
+// Open opens, or creates, the database file at path and its table of checkpoints. The database
+// is opened in WAL mode, so readers do not block the writer, and waits for locks instead of
+// failing when several processes share it.
+func Open(ctx context.Context, path string) (*Checkpointer, error) {
+	dsn := "file:" + path + "?" + url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)"}}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	c, err := New(ctx, db, DefaultTable)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	c.owned = true
+	return c, nil
+}
+
+// New returns a checkpointer storing checkpoints in the table of db, DefaultTable if empty,
+// which is created if it does not exist. The database is not closed by Close.
+func New(ctx context.Context, db *sql.DB, table string) (*Checkpointer, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	c := &Checkpointer{db: db, table: table}
+	if _, err := db.ExecContext(ctx, c.query(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+	thread_id  TEXT    NOT NULL,
+	id         TEXT    NOT NULL,
+	step       INTEGER NOT NULL,
+	node       TEXT    NOT NULL,
+	kind       TEXT    NOT NULL,
+	version    INTEGER NOT NULL,
+	state      BLOB,
+	metadata   TEXT,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (thread_id, id)
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (thread_id, step);`)); err != nil {
+		return nil, fmt.Errorf("creating table %s: %w", table, err)
+	}
+	return c, nil
+}
+
+// Close closes the database if it was opened by Open.
+func (c *Checkpointer) Close() error {
+	if !c.owned {
+		return nil
+	}
+	return c.db.Close()
+}
+
+// query returns the query with the quoted names of the table in place of %[1]s, and of its
+// index in place of %[2]s.
+func (c *Checkpointer) query(q string) string {
+	return fmt.Sprintf(q, `"`+c.table+`"`, `"`+c.table+`_thread_step"`)
+}
+
+// columns are the columns of the table, in the order scan reads them.
+const columns = "thread_id, id, step, node, kind, version, state, metadata, created_at"
+
+// Put stores a checkpoint, replacing any checkpoint of the thread with the same ID.
+func (c *Checkpointer) Put(ctx context.Context, cp checkpoint.Checkpoint) error {
+	metadata, err := encodeMetadata(cp.Metadata)
+	if err != nil {
+		return err
+	}
+
+	// Upserting keeps the row, and so the order, of replaced checkpoints.
+	_, err = c.db.ExecContext(ctx, c.query(`
+INSERT INTO %[1]s (`+columns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (thread_id, id) DO UPDATE SET
+	step = excluded.step, node = excluded.node, kind = excluded.kind, version = excluded.version,
+	state = excluded.state, metadata = excluded.metadata, created_at = excluded.created_at`),
+		cp.ThreadID, cp.ID, cp.Step, cp.Node, string(cp.Kind), cp.Version, cp.State, metadata, cp.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("putting checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+	}
+	return nil
+}
+
+// Append stores cp as the next checkpoint of its thread. The step is read and the row inserted
+// by one statement, which SQLite runs under the lock of the database.
+func (c *Checkpointer) Append(ctx context.Context, cp checkpoint.Checkpoint) (checkpoint.Checkpoint, error) {
+	metadata, err := encodeMetadata(cp.Metadata)
+	if err != nil {
+		return checkpoint.Checkpoint{}, err
+	}
+
+	// The format of the IDs is the format of checkpoint.StepID.
+	row := c.db.QueryRowContext(ctx, c.query(`
+INSERT INTO %[1]s (`+columns+`)
+SELECT ?, printf('%%010d', n.step), n.step, ?, ?, ?, ?, ?, ?
+FROM (SELECT COALESCE(MAX(step) + 1, 0) AS step FROM %[1]s WHERE thread_id = ?) AS n
+RETURNING step`),
+		cp.ThreadID, cp.Node, string(cp.Kind), cp.Version, cp.State, metadata, cp.CreatedAt.UnixNano(), cp.ThreadID)
+	if err := row.Scan(&cp.Step); err != nil {
+		return checkpoint.Checkpoint{}, fmt.Errorf("appending checkpoint to thread %s: %w", cp.ThreadID, err)
+	}
+	cp.ID = checkpoint.StepID(cp.Step)
+	return cp, nil
+}
+
+// Get returns the checkpoint of the thread with the given ID.
+func (c *Checkpointer) Get(ctx context.Context, threadID, id string) (checkpoint.Checkpoint, error) {
+	row := c.db.QueryRowContext(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = ? AND id = ?`), threadID, id)
+	cp, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, fmt.Errorf("%w: %s/%s", checkpoint.ErrNotFound, threadID, id)
+	}
+	return cp, err
+}
+
+// Latest returns the checkpoint of the thread with the highest step.
+func (c *Checkpointer) Latest(ctx context.Context, threadID string) (checkpoint.Checkpoint, error) {
+	row := c.db.QueryRowContext(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = ? ORDER BY step DESC, rowid DESC LIMIT 1`), threadID)
+	cp, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cp, fmt.Errorf("%w: %s", checkpoint.ErrNotFound, threadID)
+	}
+	return cp, err
+}
+
+// List returns the checkpoints of the thread ordered by step.
+func (c *Checkpointer) List(ctx context.Context, threadID string) ([]checkpoint.Checkpoint, error) {
+	rows, err := c.db.QueryContext(ctx, c.query(`SELECT `+columns+` FROM %[1]s WHERE thread_id = ? ORDER BY step, rowid`), threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+	}
+	defer rows.Close()
+
+	var checkpoints []checkpoint.Checkpoint
+	for rows.Next() {
+		cp, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all the checkpoints of the thread.
+func (c *Checkpointer) Delete(ctx context.Context, threadID string) error {
+	if _, err := c.db.ExecContext(ctx, c.query(`DELETE FROM %[1]s WHERE thread_id = ?`), threadID); err != nil {
+		return fmt.Errorf("deleting thread %s: %w", threadID, err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads holding checkpoints, in lexical order.
+func (c *Checkpointer) Threads(ctx context.Context) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, c.query(`SELECT DISTINCT thread_id FROM %[1]s ORDER BY thread_id`))
+	if err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []string
+	for rows.Next() {
+		var threadID string
+		if err := rows.Scan(&threadID); err != nil {
+			return nil, fmt.Errorf("listing threads: %w", err)
+		}
+		threads = append(threads, threadID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing threads: %w", err)
+	}
+	return threads, nil
+}
+
+// Prune deletes the checkpoints of the thread older than its last keep ones, and returns how
+// many were deleted. Delta checkpoints still need the full checkpoint they apply to, so the
+// checkpoints since the last full one among the deleted ones are kept.
+func (c *Checkpointer) Prune(ctx context.Context, threadID string, keep int) (int, error) {
+	if keep < 1 {
+		keep = 1
+	}
+	res, err := c.db.ExecContext(ctx, c.query(`
+DELETE FROM %[1]s WHERE thread_id = ?1 AND step < (
+	SELECT MAX(step) FROM %[1]s WHERE thread_id = ?1 AND kind != ?3 AND step <= (
+		SELECT step FROM %[1]s WHERE thread_id = ?1 ORDER BY step DESC, rowid DESC LIMIT 1 OFFSET ?2 - 1
+	)
+)`), threadID, keep, string(checkpoint.KindDelta))
+	if err != nil {
+		return 0, fmt.Errorf("pruning thread %s: %w", threadID, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("pruning thread %s: %w", threadID, err)
+	}
+	return int(deleted), nil
+}
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// encodeMetadata returns the value of the metadata column for the metadata.
+func encodeMetadata(metadata map[string]string) (sql.NullString, error) {
+	if metadata == nil {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encoding metadata: %w", err)
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// scan reads a checkpoint from a row of columns.
+func scan(row scanner) (checkpoint.Checkpoint, error) {
+	var (
+		cp        checkpoint.Checkpoint
+		kind      string
+		metadata  sql.NullString
+		createdAt int64
+	)
+	err := row.Scan(&cp.ThreadID, &cp.ID, &cp.Step, &cp.Node, &kind, &cp.Version, &cp.State, &metadata, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cp, err
+		}
+		return cp, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	cp.Kind = checkpoint.Kind(kind)
+	cp.CreatedAt = time.Unix(0, createdAt)
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &cp.Metadata); err != nil {
+			return cp, fmt.Errorf("decoding metadata of checkpoint %s/%s: %w", cp.ThreadID, cp.ID, err)
+		}
+	}
+	return cp, nil
+}
@@ -0,0 +1,240 @@
+//go:build !js && !wasip1
+
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/checkpoint/sqlite"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func open(t *testing.T) (*sqlite.Checkpointer, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "checkpoints.db")
+	c, err := sqlite.Open(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c, path
+}
+
+func TestCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, _ := open(t)
+
+	_, err := c.Latest(ctx, "thread")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 42, time.UTC)
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: "a", Step: 1, Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	}))
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "b", Step: 2, Node: "replaced"}))
+
+	list, err := c.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].ID)
+
+	latest, err := c.Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", latest.Node)
+	assert.Nil(t, latest.Metadata)
+
+	got, err := c.Get(ctx, "thread", "a")
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: "a", Step: 1, Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	}, checkpoint.Checkpoint{
+		ThreadID: got.ThreadID, ID: got.ID, Step: got.Step, Node: got.Node, Kind: got.Kind, Version: got.Version,
+		State: got.State, Metadata: got.Metadata, CreatedAt: got.CreatedAt.UTC(),
+	})
+
+	_, err = c.Get(ctx, "thread", "missing")
+	assert.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestCheckpointerSameStep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, _ := open(t)
+	for _, id := range []string{"x", "a", "m"} {
+		require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: id, Step: 1}))
+	}
+
+	list, err := c.List(ctx, "thread")
+	require.NoError(t, err)
+	ids := make([]string, len(list))
+	for i, cp := range list {
+		ids[i] = cp.ID
+	}
+	assert.Equal(t, []string{"x", "a", "m"}, ids)
+
+	latest, err := c.Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "m", latest.ID)
+}
+
+func TestCheckpointerAppend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, _ := open(t)
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 42, time.UTC)
+	cp, err := c.Append(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", Node: "start", Kind: checkpoint.KindFull, Version: 3,
+		State: []byte(`{"n":1}`), Metadata: map[string]string{"k": "v"}, CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+	got, err := c.Get(ctx, "thread", checkpoint.StepID(0))
+	require.NoError(t, err)
+	got.CreatedAt = got.CreatedAt.UTC()
+	assert.Equal(t, cp, got)
+
+	// Concurrent appends, from several connections, get distinct steps.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Append(ctx, checkpoint.Checkpoint{ThreadID: "thread"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	list, err := c.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, list, 21)
+	for i, cp := range list {
+		assert.Equal(t, i, cp.Step)
+		assert.Equal(t, checkpoint.StepID(i), cp.ID)
+	}
+}
+
+func TestCheckpointerDeleteAndThreads(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, _ := open(t)
+	for _, thread := range []string{"c", "a", "b"} {
+		require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: thread, ID: "1"}))
+	}
+
+	require.NoError(t, c.Delete(ctx, "b"))
+	require.NoError(t, c.Delete(ctx, "unknown"))
+
+	_, err := c.Latest(ctx, "b")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	threads, err := c.Threads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, threads)
+}
+
+func TestCheckpointerPrune(t *testing.T) {
+	t.Parallel()
+
+	full, delta := checkpoint.KindFull, checkpoint.KindDelta
+
+	tests := []struct {
+		name    string
+		kinds   []checkpoint.Kind
+		keep    int
+		deleted int
+	}{
+		{name: "full", kinds: []checkpoint.Kind{full, full, full, full}, keep: 2, deleted: 2},
+		{name: "keep all", kinds: []checkpoint.Kind{full, full}, keep: 5, deleted: 0},
+		{name: "keep one at least", kinds: []checkpoint.Kind{full, full}, keep: 0, deleted: 1},
+		{name: "delta chain", kinds: []checkpoint.Kind{full, delta, full, delta, delta, delta}, keep: 2, deleted: 2},
+		{name: "no full kept", kinds: []checkpoint.Kind{full, delta, delta, delta}, keep: 1, deleted: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			c, _ := open(t)
+			require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "other", ID: "1"}))
+			for step, kind := range tt.kinds {
+				require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: checkpoint.StepID(step), Step: step, Kind: kind}))
+			}
+
+			deleted, err := c.Prune(ctx, "thread", tt.keep)
+			require.NoError(t, err)
+			assert.Equal(t, tt.deleted, deleted)
+
+			list, err := c.List(ctx, "thread")
+			require.NoError(t, err)
+			require.Len(t, list, len(tt.kinds)-tt.deleted)
+			assert.NotEqual(t, checkpoint.KindDelta, list[0].Kind)
+
+			other, err := c.List(ctx, "other")
+			require.NoError(t, err)
+			assert.Len(t, other, 1)
+		})
+	}
+}
+
+func TestCheckpointerReopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, path := open(t)
+	require.NoError(t, c.Put(ctx, checkpoint.Checkpoint{ThreadID: "thread", ID: "1", State: []byte("state")}))
+	require.NoError(t, c.Close())
+
+	reopened, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.Latest(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("state"), got.State)
+}
+
+func TestCheckpointerGraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[int]("double")
+	g.AddNode("double", func(_ context.Context, n int) (int, error) { return n * 2, nil })
+	g.AddNode("increment", func(_ context.Context, n int) (int, error) { return n + 1, nil })
+	g.AddEdge("double", "increment")
+	g.AddEdge("increment", graph.END)
+
+	c, _ := open(t)
+	runnable, err := g.Compile(graph.WithInterruptBefore("increment"), graph.WithCheckpointer(c))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = runnable.Invoke(graph.WithThreadID(ctx, "thread"), 3)
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+
+	interrupt, state, err := runnable.Pending(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, 6, state)
+
+	got, err := runnable.Resume(graph.WithThreadID(ctx, "thread"), interrupt, state)
+	require.NoError(t, err)
+	assert.Equal(t, 7, got)
+
+	snapshot, err := runnable.GetState(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, 7, snapshot.State)
+	assert.Empty(t, snapshot.Next)
+}
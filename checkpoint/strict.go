@@ -0,0 +1,221 @@
+package checkpoint
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownField is returned by DecodeStrict when the encoded state has a field its type
+	// does not declare.
+	ErrUnknownField = errors.New("unknown state field")
+
+	// ErrTypeMismatch is returned by DecodeStrict when a value of the encoded state does not
+	// fit the type of its field.
+	ErrTypeMismatch = errors.New("state type mismatch")
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// DecodeStrict is like Decode but rejects the states whose encoding does not match the type
+// exactly, instead of dropping what does not fit: fields the type does not declare
+// (ErrUnknownField) and values of another JSON type, or numbers out of the range of their
+// field (ErrTypeMismatch). Every problem is reported, joined, with the path of its value, such
+// as $.messages[2].role.
+func DecodeStrict[T any](data []byte) (T, error) {
+	var v T
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return v, fmt.Errorf("decoding state: %w", err)
+	}
+	if dec.More() {
+		return v, errors.New("decoding state: data after the state")
+	}
+
+	if err := errors.Join(check(reflect.TypeFor[T](), raw, "$")...); err != nil {
+		return v, fmt.Errorf("decoding state: %w", err)
+	}
+
+	// Types decoding themselves are only checked by decoding them.
+	dec = json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return v, fmt.Errorf("decoding state: %w", err)
+	}
+	return v, nil
+}
+
+// check returns the problems of the decoded JSON value v, at path, as a value of type t.
+func check(t reflect.Type, v any, path string) []error {
+	if v == nil {
+		// Null leaves any value unchanged.
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	if t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return expect[string](v, path, "string")
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.Bool:
+		return expect[bool](v, path, "boolean")
+	case reflect.String:
+		return expect[string](v, path, "string")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := v.(json.Number); ok {
+			if _, err := strconv.ParseInt(n.String(), 10, t.Bits()); err != nil {
+				return []error{fmt.Errorf("%w: %s: %s does not fit %s", ErrTypeMismatch, path, n, t)}
+			}
+		}
+		return expect[json.Number](v, path, "number")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := v.(json.Number); ok {
+			if _, err := strconv.ParseUint(n.String(), 10, t.Bits()); err != nil {
+				return []error{fmt.Errorf("%w: %s: %s does not fit %s", ErrTypeMismatch, path, n, t)}
+			}
+		}
+		return expect[json.Number](v, path, "number")
+	case reflect.Float32, reflect.Float64:
+		return expect[json.Number](v, path, "number")
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return expect[string](v, path, "string")
+		}
+		items, ok := v.([]any)
+		if !ok {
+			return mismatch(v, path, "array")
+		}
+		var errs []error
+		for i, item := range items {
+			errs = append(errs, check(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case reflect.Map:
+		entries, ok := v.(map[string]any)
+		if !ok {
+			return mismatch(v, path, "object")
+		}
+		var errs []error
+		for _, key := range sortedKeys(entries) {
+			errs = append(errs, check(t.Elem(), entries[key], path+"."+key)...)
+		}
+		return errs
+	case reflect.Struct:
+		entries, ok := v.(map[string]any)
+		if !ok {
+			return mismatch(v, path, "object")
+		}
+		fields := jsonFields(t)
+		var errs []error
+		for _, key := range sortedKeys(entries) {
+			field, ok := fields[key]
+			if !ok {
+				// Like encoding/json, match the names of the fields case-insensitively.
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+			if !ok {
+				errs = append(errs, fmt.Errorf("%w: %s.%s", ErrUnknownField, path, key))
+				continue
+			}
+			errs = append(errs, check(field, entries[key], path+"."+key)...)
+		}
+		return errs
+	default:
+		return nil
+	}
+}
+
+// expect returns a mismatch unless v, at path, is a T, of the given JSON type.
+func expect[T any](v any, path, jsonType string) []error {
+	if _, ok := v.(T); ok {
+		return nil
+	}
+	return mismatch(v, path, jsonType)
+}
+
+// mismatch returns the mismatch of v, at path, expected of the given JSON type.
+func mismatch(v any, path, jsonType string) []error {
+	var actual string
+	switch v.(type) {
+	case bool:
+		actual = "boolean"
+	case json.Number:
+		actual = "number"
+	case string:
+		actual = "string"
+	case []any:
+		actual = "array"
+	default:
+		actual = "object"
+	}
+	return []error{fmt.Errorf("%w: %s: %s, expected %s", ErrTypeMismatch, path, actual, jsonType)}
+}
+
+// jsonFields returns the types of the fields of the JSON encoding of a struct, by name, with
+// the fields of embedded structs promoted like encoding/json does.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		embedded := f.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if f.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			for promoted, typ := range jsonFields(embedded) {
+				// Fields of the outer struct win over the promoted ones.
+				if _, ok := fields[promoted]; !ok {
+					fields[promoted] = typ
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
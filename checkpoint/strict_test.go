@@ -0,0 +1,111 @@
+package checkpoint_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+type Envelope struct {
+	Trace string `json:"trace"`
+}
+
+type strictState struct {
+	Envelope
+	Messages []message          `json:"messages"`
+	Count    int8               `json:"count"`
+	Scores   map[string]float64 `json:"scores"`
+	At       time.Time          `json:"at"`
+	Raw      []byte             `json:"raw"`
+	Extra    any                `json:"extra"`
+	Ignored  string             `json:"-"`
+}
+
+func TestDecodeStrict(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		data   string
+		errors []string
+	}{
+		{
+			name: "valid",
+			data: `{"trace":"t","messages":[{"role":"human","content":"hi"}],"count":3,"scores":{"a":1.5},
+				"at":"2024-05-01T12:00:00Z","raw":"aGk=","extra":{"any":[1]},"COUNT":4}`,
+		},
+		{
+			name: "nulls",
+			data: `{"messages":null,"count":null,"scores":{"a":null}}`,
+		},
+		{
+			name: "unknown fields",
+			data: `{"messages":[{"role":"human","name":"bob"}],"Ignored":"x","other":1}`,
+			errors: []string{
+				"unknown state field: $.Ignored",
+				"unknown state field: $.messages[0].name",
+				"unknown state field: $.other",
+			},
+		},
+		{
+			name: "type mismatches",
+			data: `{"trace":1,"messages":{},"count":300,"scores":{"a":"high"},"raw":[1]}`,
+			errors: []string{
+				"state type mismatch: $.count: 300 does not fit int8",
+				"state type mismatch: $.messages: object, expected array",
+				"state type mismatch: $.raw: array, expected string",
+				"state type mismatch: $.scores.a: string, expected number",
+				"state type mismatch: $.trace: number, expected string",
+			},
+		},
+		{
+			name:   "fraction",
+			data:   `{"count":1.5}`,
+			errors: []string{"state type mismatch: $.count: 1.5 does not fit int8"},
+		},
+		{
+			name:   "root",
+			data:   `[]`,
+			errors: []string{"state type mismatch: $: array, expected object"},
+		},
+		{
+			name:   "self-decoding type",
+			data:   `{"at":"yesterday"}`,
+			errors: []string{"decoding state: parsing time"},
+		},
+		{
+			name:   "trailing data",
+			data:   `{} {}`,
+			errors: []string{"data after the state"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			lenient, err := checkpoint.Decode[strictState]([]byte(tc.data))
+			strict, strictErr := checkpoint.DecodeStrict[strictState]([]byte(tc.data))
+			if len(tc.errors) == 0 {
+				require.NoError(t, err)
+				require.NoError(t, strictErr)
+				assert.Equal(t, lenient, strict)
+				return
+			}
+
+			require.Error(t, strictErr)
+			for _, msg := range tc.errors {
+				assert.Contains(t, strictErr.Error(), msg)
+			}
+		})
+	}
+
+	_, err := checkpoint.DecodeStrict[strictState]([]byte(`{"other":1}`))
+	assert.ErrorIs(t, err, checkpoint.ErrUnknownField)
+	_, err = checkpoint.DecodeStrict[strictState]([]byte(`{"count":"1"}`))
+	assert.ErrorIs(t, err, checkpoint.ErrTypeMismatch)
+}
@@ -0,0 +1,140 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tail holds the last elements of a persisted state. The older elements are only fetched
+// from storage when requested, which keeps resuming long threads cheap.
+type Tail[E any] struct {
+	// Items are the last elements of the state, oldest first.
+	Items []E
+
+	// Offset is the index of Items[0] in the full state.
+	Offset int
+
+	// CheckpointID is the checkpoint the tail was loaded from.
+	CheckpointID string
+
+	load func(ctx context.Context) ([]E, error)
+}
+
+// Complete reports whether Items holds the whole state.
+func (t *Tail[E]) Complete() bool {
+	return t.Offset == 0
+}
+
+// Older fetches the elements preceding Items.
+func (t *Tail[E]) Older(ctx context.Context) ([]E, error) {
+	if t.Complete() {
+		return nil, nil
+	}
+
+	all, err := t.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return all[:t.Offset], nil
+}
+
+// All returns the full state, fetching the older elements if needed.
+func (t *Tail[E]) All(ctx context.Context) ([]E, error) {
+	older, err := t.Older(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(older, t.Items...), nil
+}
+
+// LoadTail loads the last k elements of the latest state of the thread.
+// Only the checkpoints holding those elements are decoded: deltas older than needed and
+// the snapshot they build on are left untouched unless the tail reaches into them.
+func (h *History[E]) LoadTail(ctx context.Context, threadID string, k int) (*Tail[E], error) {
+	checkpoints, err := h.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, threadID)
+	}
+	if checkpoints, err = upgradeAll(checkpoints); err != nil {
+		return nil, err
+	}
+
+	target := len(checkpoints) - 1
+	length, err := lengthAt[E](checkpoints[target])
+	if err != nil {
+		return nil, err
+	}
+
+	lo := max(length-max(k, 0), 0)
+	items, err := rangeAt[E](checkpoints, target, lo, length)
+	if err != nil {
+		return nil, err
+	}
+
+	id := checkpoints[target].ID
+	return &Tail[E]{
+		Items:        items,
+		Offset:       lo,
+		CheckpointID: id,
+		load: func(ctx context.Context) ([]E, error) {
+			return h.Load(ctx, threadID, id)
+		},
+	}, nil
+}
+
+// lengthAt returns the number of elements of the state stored at the checkpoint.
+func lengthAt[E any](cp Checkpoint) (int, error) {
+	if cp.Kind == KindFull {
+		state, err := Decode[[]E](cp.State)
+		return len(state), err
+	}
+
+	offset, items, err := decodeDelta[E](cp.State)
+	return offset + len(items), err
+}
+
+// rangeAt returns the elements [lo, hi) of the state at checkpoints[i], walking back the
+// delta chain only as far as needed.
+func rangeAt[E any](checkpoints []Checkpoint, i, lo, hi int) ([]E, error) {
+	if lo >= hi {
+		return nil, nil
+	}
+
+	cp := checkpoints[i]
+	if cp.Kind == KindFull {
+		state, err := Decode[[]E](cp.State)
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+		}
+		if hi > len(state) {
+			return nil, fmt.Errorf("%w: checkpoint %s has %d items, %d requested", ErrDeltaMismatch, cp.ID, len(state), hi)
+		}
+		return state[lo:hi], nil
+	}
+
+	offset, items, err := decodeDelta[E](cp.State)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+	}
+	if hi > offset+len(items) {
+		return nil, fmt.Errorf("%w: checkpoint %s has %d items, %d requested", ErrDeltaMismatch, cp.ID, offset+len(items), hi)
+	}
+
+	var out []E
+	if lo < offset {
+		if i == 0 {
+			return nil, fmt.Errorf("%w: checkpoint %s", ErrBrokenChain, cp.ID)
+		}
+		out, err = rangeAt[E](checkpoints, i-1, lo, min(hi, offset))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hi > offset {
+		out = append(out, items[max(lo-offset, 0):hi-offset]...)
+	}
+	return out, nil
+}
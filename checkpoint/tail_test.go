@@ -0,0 +1,74 @@
+package checkpoint_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTail(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[message](store, 4)
+
+	var state []message
+	for step := range 10 {
+		state = append(state, message{Role: "human", Content: fmt.Sprintf("m%d", step)})
+		if step%3 == 0 {
+			state = append(state, message{Role: "ai", Content: fmt.Sprintf("a%d", step)})
+		}
+		_, err := history.Save(ctx, "thread", step, "node", state)
+		require.NoError(t, err)
+	}
+
+	for _, k := range []int{0, 1, 3, 7, len(state), len(state) + 5} {
+		tail, err := history.LoadTail(ctx, "thread", k)
+		require.NoError(t, err)
+
+		n := min(k, len(state))
+		assert.Equal(t, state[len(state)-n:], append([]message{}, tail.Items...), "k=%d", k)
+		assert.Equal(t, len(state)-n, tail.Offset)
+		assert.Equal(t, n == len(state), tail.Complete())
+
+		all, err := tail.All(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, state, all)
+	}
+
+	_, err := history.LoadTail(ctx, "missing", 3)
+	assert.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestLoadTailSkipsOlderCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[message](store, 100)
+
+	state := []message{{"human", "q1"}}
+	_, err := history.Save(ctx, "thread", 0, "node", state)
+	require.NoError(t, err)
+	state = append(state, message{"ai", "a1"})
+	_, err = history.Save(ctx, "thread", 1, "node", state)
+	require.NoError(t, err)
+
+	// Corrupt the snapshot: loading the last message must not need it.
+	first, err := store.Get(ctx, "thread", checkpoint.StepID(0))
+	require.NoError(t, err)
+	first.State = []byte("corrupted")
+	require.NoError(t, store.Put(ctx, first))
+
+	tail, err := history.LoadTail(ctx, "thread", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []message{{"ai", "a1"}}, tail.Items)
+
+	_, err = tail.Older(ctx)
+	assert.Error(t, err)
+}
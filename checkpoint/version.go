@@ -0,0 +1,43 @@
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FormatVersion is the version of the checkpoint format written by this package.
+// Checkpoints written with an older version are upgraded when read.
+const FormatVersion = 1
+
+// ErrUnsupportedVersion is returned when reading a checkpoint with a format version this package
+// cannot decode, typically one written by a newer release.
+var ErrUnsupportedVersion = errors.New("unsupported checkpoint format version")
+
+// upgraders convert a checkpoint from the format version they are indexed by to the next one.
+var upgraders = map[int]func(Checkpoint) Checkpoint{
+	// Version 0 covers checkpoints written before versions were stamped. Their states are
+	// encoded like version 1, but they may lack a kind, in which case they hold a full state.
+	0: func(cp Checkpoint) Checkpoint {
+		if cp.Kind == "" {
+			cp.Kind = KindFull
+		}
+		return cp
+	},
+}
+
+// Upgrade converts a checkpoint written with any supported format version to FormatVersion.
+func Upgrade(cp Checkpoint) (Checkpoint, error) {
+	if cp.Version > FormatVersion {
+		return cp, fmt.Errorf("%w: %d, newest supported is %d", ErrUnsupportedVersion, cp.Version, FormatVersion)
+	}
+
+	for cp.Version < FormatVersion {
+		upgrade, ok := upgraders[cp.Version]
+		if !ok {
+			return cp, fmt.Errorf("%w: %d", ErrUnsupportedVersion, cp.Version)
+		}
+		cp = upgrade(cp)
+		cp.Version++
+	}
+	return cp, nil
+}
@@ -0,0 +1,86 @@
+package checkpoint_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrade(t *testing.T) {
+	t.Parallel()
+
+	legacy := checkpoint.Checkpoint{ID: "a", State: []byte(`[]`)}
+	upgraded, err := checkpoint.Upgrade(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.FormatVersion, upgraded.Version)
+	assert.Equal(t, checkpoint.KindFull, upgraded.Kind, "unversioned checkpoints without a kind hold full states")
+
+	current := checkpoint.Checkpoint{ID: "b", Kind: checkpoint.KindDelta, Version: checkpoint.FormatVersion}
+	upgraded, err = checkpoint.Upgrade(current)
+	require.NoError(t, err)
+	assert.Equal(t, current, upgraded)
+
+	_, err = checkpoint.Upgrade(checkpoint.Checkpoint{ID: "c", Version: checkpoint.FormatVersion + 1})
+	require.ErrorIs(t, err, checkpoint.ErrUnsupportedVersion)
+
+	_, err = checkpoint.Upgrade(checkpoint.Checkpoint{ID: "d", Version: -1})
+	require.ErrorIs(t, err, checkpoint.ErrUnsupportedVersion)
+}
+
+func TestHistoryReadsLegacyThreads(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+
+	// A thread written before checkpoints were versioned: a full state without kind, then a delta.
+	require.NoError(t, store.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "legacy", ID: checkpoint.StepID(0), Step: 0,
+		State: []byte(`[{"role":"human","content":"hi"}]`),
+	}))
+	require.NoError(t, store.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "legacy", ID: checkpoint.StepID(1), Step: 1, Kind: checkpoint.KindDelta,
+		State: []byte(`{"offset":1,"items":[{"role":"ai","content":"hello"}]}`),
+	}))
+
+	history := checkpoint.NewHistory[message](store, 10)
+	expected := []message{{Role: "human", Content: "hi"}, {Role: "ai", Content: "hello"}}
+
+	state, err := history.Load(ctx, "legacy", "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, state)
+
+	tail, err := history.LoadTail(ctx, "legacy", 2)
+	require.NoError(t, err)
+	assert.Equal(t, expected, tail.Items)
+
+	view, err := checkpoint.NewObserver(store, checkpoint.Projection{}).Get(ctx, "legacy", checkpoint.StepID(0))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"role":"human","content":"hi"}]`, string(view.State))
+
+	expected = append(expected, message{Role: "human", Content: "bye"})
+	saved, err := history.Save(ctx, "legacy", 2, "chat", expected)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.FormatVersion, saved.Version, "new checkpoints are stamped")
+
+	state, err = history.Load(ctx, "legacy", "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, state)
+}
+
+func TestHistoryRejectsNewerVersions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	require.NoError(t, store.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "future", ID: checkpoint.StepID(0), Kind: checkpoint.KindFull,
+		Version: checkpoint.FormatVersion + 1, State: []byte(`[]`),
+	}))
+
+	_, err := checkpoint.NewHistory[message](store, 10).Load(ctx, "future", "")
+	require.ErrorIs(t, err, checkpoint.ErrUnsupportedVersion)
+}
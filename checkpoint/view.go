@@ -0,0 +1,234 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cesto93/langgraphgo/redact"
+)
+
+// View is the read-only projection of a checkpoint handed to observers such as UIs and
+// analytics. Its state is always complete, even for delta checkpoints, and only holds the
+// fields allowed by the Projection it was made with.
+type View struct {
+	// ThreadID identifies the thread the checkpoint belongs to.
+	ThreadID string `json:"thread_id"`
+
+	// ID identifies the checkpoint within its thread.
+	ID string `json:"id"`
+
+	// Step is the index of the step that produced the checkpoint.
+	Step int `json:"step"`
+
+	// Node is the name of the node that produced the checkpoint.
+	Node string `json:"node"`
+
+	// State is the projected JSON state.
+	State json.RawMessage `json:"state"`
+
+	// CreatedAt is the time the checkpoint was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Projection selects the parts of a JSON state visible to observers. Paths are dotted object
+// keys, such as "scratch" or "messages.tool_calls"; a path crossing an array applies to each
+// of its elements, and a state that is an array applies its paths to each element.
+type Projection struct {
+	// Include lists the paths kept. An empty Include keeps everything.
+	Include []string
+
+	// Exclude lists the paths removed from what Include kept.
+	Exclude []string
+
+	// Redactor, if set, masks the secrets of the projected state.
+	Redactor *redact.Redactor
+}
+
+// pathTree is a set of dotted paths. A nil child marks the end of a path.
+type pathTree map[string]pathTree
+
+func newPathTree(paths []string) pathTree {
+	tree := pathTree{}
+	for _, p := range paths {
+		node := tree
+		keys := strings.Split(p, ".")
+		for i, key := range keys {
+			child, seen := node[key]
+			if seen && child == nil {
+				break
+			}
+			if i == len(keys)-1 {
+				node[key] = nil
+				break
+			}
+			if child == nil {
+				child = pathTree{}
+				node[key] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// Apply returns the projection of a JSON state.
+func (p Projection) Apply(state []byte) ([]byte, error) {
+	if len(p.Include) == 0 && len(p.Exclude) == 0 && p.Redactor == nil {
+		return state, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(state))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+
+	if len(p.Include) > 0 {
+		v = include(v, newPathTree(p.Include))
+	}
+	if len(p.Exclude) > 0 {
+		exclude(v, newPathTree(p.Exclude))
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	if p.Redactor != nil {
+		return p.Redactor.JSON(out)
+	}
+	return out, nil
+}
+
+func include(v any, tree pathTree) any {
+	switch v := v.(type) {
+	case map[string]any:
+		kept := make(map[string]any, len(tree))
+		for key, child := range tree {
+			item, ok := v[key]
+			if !ok {
+				continue
+			}
+			if child == nil {
+				kept[key] = item
+			} else {
+				kept[key] = include(item, child)
+			}
+		}
+		return kept
+	case []any:
+		for i, item := range v {
+			v[i] = include(item, tree)
+		}
+	}
+	return v
+}
+
+func exclude(v any, tree pathTree) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range tree {
+			if child == nil {
+				delete(v, key)
+			} else if item, ok := v[key]; ok {
+				exclude(item, child)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			exclude(item, tree)
+		}
+	}
+}
+
+// Observer gives read-only access to the checkpoints of a Checkpointer through a Projection.
+// It never writes to the checkpointer and hands out copies, so observers cannot alter the
+// execution. An Observer is safe for concurrent use.
+type Observer struct {
+	checkpointer Checkpointer
+	projection   Projection
+}
+
+// NewObserver creates an Observer reading cp through p.
+func NewObserver(cp Checkpointer, p Projection) *Observer {
+	return &Observer{checkpointer: cp, projection: p}
+}
+
+// Get returns the view of the checkpoint of the thread with the given ID.
+func (o *Observer) Get(ctx context.Context, threadID, id string) (View, error) {
+	checkpoints, err := o.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return View{}, err
+	}
+	for i, cp := range checkpoints {
+		if cp.ID == id {
+			return o.view(checkpoints, i)
+		}
+	}
+	return View{}, fmt.Errorf("%w: %s/%s", ErrNotFound, threadID, id)
+}
+
+// Latest returns the view of the latest checkpoint of the thread.
+func (o *Observer) Latest(ctx context.Context, threadID string) (View, error) {
+	checkpoints, err := o.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return View{}, err
+	}
+	if len(checkpoints) == 0 {
+		return View{}, fmt.Errorf("%w: %s", ErrNotFound, threadID)
+	}
+	return o.view(checkpoints, len(checkpoints)-1)
+}
+
+// List returns the views of the checkpoints of the thread ordered by step.
+func (o *Observer) List(ctx context.Context, threadID string) ([]View, error) {
+	checkpoints, err := o.checkpointer.List(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]View, len(checkpoints))
+	for i := range checkpoints {
+		if views[i], err = o.view(checkpoints, i); err != nil {
+			return nil, err
+		}
+	}
+	return views, nil
+}
+
+// view projects checkpoints[i], rebuilding its complete state if it is a delta.
+func (o *Observer) view(checkpoints []Checkpoint, i int) (View, error) {
+	cp, err := Upgrade(checkpoints[i])
+	if err != nil {
+		return View{}, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+	}
+
+	state := cp.State
+	if cp.Kind == KindDelta {
+		items, err := (&History[json.RawMessage]{}).rebuild(checkpoints, i)
+		if err != nil {
+			return View{}, err
+		}
+		if state, err = Encode(items); err != nil {
+			return View{}, err
+		}
+	}
+
+	projected, err := o.projection.Apply(state)
+	if err != nil {
+		return View{}, fmt.Errorf("checkpoint %s: %w", cp.ID, err)
+	}
+	return View{
+		ThreadID:  cp.ThreadID,
+		ID:        cp.ID,
+		Step:      cp.Step,
+		Node:      cp.Node,
+		State:     bytes.Clone(projected),
+		CreatedAt: cp.CreatedAt,
+	}, nil
+}
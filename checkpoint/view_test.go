@@ -0,0 +1,114 @@
+package checkpoint_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectionApply(t *testing.T) {
+	t.Parallel()
+
+	state := []byte(`{"messages":[{"role":"ai","content":"hi","tool_calls":[1]},{"role":"human","content":"yo"}],` +
+		`"scratch":{"plan":"x"},"user":{"name":"ada","email":"ada@example.com"}}`)
+
+	testCases := []struct {
+		name       string
+		projection checkpoint.Projection
+		expected   string
+	}{
+		{
+			name:       "no projection",
+			projection: checkpoint.Projection{},
+			expected:   string(state),
+		},
+		{
+			name:       "include",
+			projection: checkpoint.Projection{Include: []string{"messages.content", "user.name", "missing"}},
+			expected:   `{"messages":[{"content":"hi"},{"content":"yo"}],"user":{"name":"ada"}}`,
+		},
+		{
+			name:       "exclude",
+			projection: checkpoint.Projection{Exclude: []string{"scratch", "messages.tool_calls", "user.email"}},
+			expected:   `{"messages":[{"role":"ai","content":"hi"},{"role":"human","content":"yo"}],"user":{"name":"ada"}}`,
+		},
+		{
+			name: "include then exclude",
+			projection: checkpoint.Projection{
+				Include: []string{"user.email", "user"},
+				Exclude: []string{"user.email"},
+			},
+			expected: `{"user":{"name":"ada"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := tc.projection.Apply(state)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(out))
+		})
+	}
+}
+
+func TestProjectionRedacts(t *testing.T) {
+	t.Parallel()
+
+	p := checkpoint.Projection{Redactor: redact.New(nil, regexp.MustCompile(`\S+@example\.com`))}
+	out, err := p.Apply([]byte(`["write to ada@example.com"]`))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "ada@example.com")
+
+	_, err = p.Apply([]byte(`[`))
+	require.Error(t, err)
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[message](storage, 10)
+
+	var state []message
+	for i, content := range []string{"a", "b", "c"} {
+		state = append(state, message{Role: "human", Content: content})
+		_, err := history.Save(ctx, "t1", i, "chat", state)
+		require.NoError(t, err)
+	}
+
+	observer := checkpoint.NewObserver(storage, checkpoint.Projection{Include: []string{"content"}})
+
+	views, err := observer.List(ctx, "t1")
+	require.NoError(t, err)
+	require.Len(t, views, 3)
+	assert.JSONEq(t, `[{"content":"a"},{"content":"b"}]`, string(views[1].State), "delta states are complete")
+	assert.Equal(t, 1, views[1].Step)
+	assert.Equal(t, "chat", views[1].Node)
+
+	latest, err := observer.Latest(ctx, "t1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"content":"a"},{"content":"b"},{"content":"c"}]`, string(latest.State))
+
+	first, err := observer.Get(ctx, "t1", checkpoint.StepID(0))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"content":"a"}]`, string(first.State))
+
+	first.State[0] = '!'
+	again, err := observer.Get(ctx, "t1", checkpoint.StepID(0))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"content":"a"}]`, string(again.State), "views are copies")
+
+	_, err = observer.Get(ctx, "t1", "missing")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+	_, err = observer.Latest(ctx, "unknown")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
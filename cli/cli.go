@@ -0,0 +1,208 @@
+// Package cli implements the langgraphgo command, running a graph from the command line on the
+// JSON-encoded state read from stdin and printing the events of the run as newline-delimited
+// JSON, one serve.Event per line, to smoke-test graphs in scripts and CI pipelines:
+//
+//	echo '{"question": "hi"}' | langgraphgo -spec support.yaml -param model=gpt-4o
+//
+// The graph is either loaded from a YAML spec with -spec, its nodes built with a registry, or
+// selected with -graph among the graphs registered in the binary. The stock binary of
+// cmd/langgraphgo only knows a few generic node types: applications build their own binary
+// with their registry and graphs, calling Main:
+//
+//	func main() {
+//		cli.Main(cli.Options[State]{Registry: registry, Graphs: map[string]*graph.Runnable[State]{"support": support}})
+//	}
+//
+// The exit status tells how the run ended: ExitCompleted, ExitInterrupted or ExitFailed, or
+// ExitUsage for invalid arguments.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve"
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+// Exit statuses of Run.
+const (
+	// ExitCompleted is the exit status of runs that completed.
+	ExitCompleted = 0
+
+	// ExitFailed is the exit status of runs that failed, or could not start.
+	ExitFailed = 1
+
+	// ExitUsage is the exit status of invalid arguments.
+	ExitUsage = 2
+
+	// ExitInterrupted is the exit status of runs paused at an interrupt.
+	ExitInterrupted = 3
+)
+
+// Options configures the command.
+type Options[T any] struct {
+	// Registry builds the nodes of the specs given with -spec; -spec is not supported if nil.
+	Registry *spec.Registry[T]
+
+	// Graphs are the graphs selected with -graph, by name.
+	Graphs map[string]*graph.Runnable[T]
+}
+
+// Main runs the command with the arguments of the process and exits with its status. The run is
+// cancelled on interrupt signals.
+func Main[T any](opts Options[T]) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	status := Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, opts)
+	stop()
+	os.Exit(status)
+}
+
+// Run runs the command with the arguments, without the name of the program, and returns its
+// exit status. Events are written to stdout, and errors and usage to stderr.
+func Run[T any](ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, opts Options[T]) int {
+	flags := flag.NewFlagSet("langgraphgo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	specPath := flags.String("spec", "", "run the graph of the YAML spec at `path`")
+	name := flags.String("graph", "", "run the registered graph with the `name`")
+	params := make(map[string]string)
+	flags.Func("param", "set the spec parameter `name=value`; repeatable", func(param string) error {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return fmt.Errorf("%q is not name=value", param)
+		}
+		params[name] = value
+		return nil
+	})
+	strict := flags.Bool("strict", false, "reject input states with unknown fields")
+	activity := flags.Bool("activity", false, "print the activities of the run")
+	final := flags.Bool("final", false, "print only the final state instead of the events")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: langgraphgo (-spec path | -graph name) [flags] < input.json")
+		fmt.Fprintln(stderr, "\nRuns a graph on the JSON state read from stdin and prints its events as newline-delimited JSON.")
+		if len(opts.Graphs) > 0 {
+			fmt.Fprintf(stderr, "\nGraphs: %s\n", strings.Join(graphNames(opts.Graphs), ", "))
+		}
+		fmt.Fprintln(stderr, "\nFlags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCompleted
+		}
+		return ExitUsage
+	}
+	if flags.NArg() > 0 || (*specPath == "") == (*name == "") {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	runnable, err := load(*specPath, *name, params, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "langgraphgo:", err)
+		return ExitUsage
+	}
+	state, err := decode[T](stdin, *strict)
+	if err != nil {
+		fmt.Fprintln(stderr, "langgraphgo:", err)
+		return ExitFailed
+	}
+
+	if *activity {
+		ctx = graph.WithActivity(ctx)
+	}
+	events, err := runnable.Stream(ctx, state)
+	if err != nil {
+		fmt.Fprintln(stderr, "langgraphgo:", err)
+		return ExitFailed
+	}
+	enc := json.NewEncoder(stdout)
+	var end graph.StreamEvent[T]
+	for event := range events {
+		if event.Kind == graph.EventEnd {
+			end = event
+		}
+		if *final {
+			continue
+		}
+		if err := enc.Encode(serve.NewEvent(event)); err != nil {
+			fmt.Fprintln(stderr, "langgraphgo:", err)
+			return ExitFailed
+		}
+	}
+
+	status := ExitCompleted
+	switch {
+	case errors.Is(end.Err, graph.ErrInterrupted):
+		status = ExitInterrupted
+	case end.Err != nil:
+		fmt.Fprintln(stderr, "langgraphgo:", end.Err)
+		return ExitFailed
+	}
+	if *final {
+		if err := enc.Encode(end.State); err != nil {
+			fmt.Fprintln(stderr, "langgraphgo:", err)
+			return ExitFailed
+		}
+	}
+	return status
+}
+
+// load returns the graph of the spec at specPath, or the registered graph with the name.
+func load[T any](specPath, name string, params map[string]string, opts Options[T]) (*graph.Runnable[T], error) {
+	if name != "" {
+		runnable, ok := opts.Graphs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown graph %s", name)
+		}
+		return runnable, nil
+	}
+
+	if opts.Registry == nil {
+		return nil, errors.New("-spec is not supported: no node registry")
+	}
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+	return spec.LoadWithParams(data, opts.Registry, spec.Values(params))
+}
+
+// decode decodes the state read from r; empty input is the zero state.
+func decode[T any](r io.Reader, strict bool) (T, error) {
+	var state T
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return state, fmt.Errorf("reading input: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return state, nil
+	}
+	if strict {
+		return checkpoint.DecodeStrict[T](data)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("decoding input: %w", err)
+	}
+	return state, nil
+}
+
+func graphNames[T any](graphs map[string]*graph.Runnable[T]) []string {
+	names := make([]string, 0, len(graphs))
+	for name := range graphs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
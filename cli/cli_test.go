@@ -0,0 +1,204 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/cli"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+type State struct {
+	Messages []string `json:"messages"`
+}
+
+func appendNode(message string) func(context.Context, State) (State, error) {
+	return func(_ context.Context, state State) (State, error) {
+		return State{Messages: append(state.Messages, message)}, nil
+	}
+}
+
+// options returns options with a registry of the node type "append", appending its config
+// message, and "fail", and the graph "review" pausing before its node "send".
+func options(t *testing.T) cli.Options[State] {
+	t.Helper()
+
+	registry := spec.NewRegistry[State]()
+	registry.Register("append", func(node spec.NodeSpec) (func(context.Context, State) (State, error), error) {
+		message, _ := node.Config["message"].(string)
+		return appendNode(message), nil
+	})
+	registry.Register("fail", func(spec.NodeSpec) (func(context.Context, State) (State, error), error) {
+		return func(_ context.Context, state State) (State, error) {
+			return state, errors.New("node failed")
+		}, nil
+	})
+
+	g := graph.NewMessageGraph[State]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("send", appendNode("send"))
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	review, err := g.Compile(graph.WithInterruptBefore("send"))
+	require.NoError(t, err)
+
+	return cli.Options[State]{Registry: registry, Graphs: map[string]*graph.Runnable[State]{"review": review}}
+}
+
+func writeSpec(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "graph.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	greet := writeSpec(t, `
+params:
+  greeting:
+    required: true
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    config:
+      message: ${greeting}
+edges:
+  - from: greet
+    to: END
+`)
+	broken := writeSpec(t, `
+entry_point: broken
+nodes:
+  - name: broken
+    type: fail
+edges:
+  - from: broken
+    to: END
+`)
+
+	testCases := []struct {
+		name   string
+		args   []string
+		input  string
+		status int
+		stdout []string
+		stderr string
+	}{
+		{
+			name:   "spec",
+			args:   []string{"-spec", greet, "-param", "greeting=hello"},
+			input:  `{"messages": ["hi"]}`,
+			status: cli.ExitCompleted,
+			stdout: []string{
+				`{"kind":"node","step":0,"node":"greet","state":{"messages":["hi","hello"]}}`,
+				`{"kind":"route","step":0,"node":"greet","next":["END"]}`,
+				`{"kind":"end","step":0,"state":{"messages":["hi","hello"]},"reason":"completed"}`,
+			},
+		},
+		{
+			name:   "final",
+			args:   []string{"-spec", greet, "-param", "greeting=hello", "-final"},
+			status: cli.ExitCompleted,
+			stdout: []string{`{"messages":["hello"]}`},
+		},
+		{
+			name:   "interrupted",
+			args:   []string{"-graph", "review", "-final"},
+			input:  `{"messages": ["hi"]}`,
+			status: cli.ExitInterrupted,
+			stdout: []string{`{"messages":["hi","draft"]}`},
+		},
+		{
+			name:   "failed",
+			args:   []string{"-spec", broken, "-final"},
+			status: cli.ExitFailed,
+			stderr: "error in node broken: node failed",
+		},
+		{
+			name:   "invalid input",
+			args:   []string{"-graph", "review"},
+			input:  `{"messages": `,
+			status: cli.ExitFailed,
+			stderr: "decoding input",
+		},
+		{
+			name:   "strict",
+			args:   []string{"-graph", "review", "-strict"},
+			input:  `{"extra": 1}`,
+			status: cli.ExitFailed,
+			stderr: "extra",
+		},
+		{
+			name:   "missing param",
+			args:   []string{"-spec", greet},
+			status: cli.ExitUsage,
+			stderr: "greeting",
+		},
+		{
+			name:   "unknown graph",
+			args:   []string{"-graph", "missing"},
+			status: cli.ExitUsage,
+			stderr: "unknown graph missing",
+		},
+		{
+			name:   "no graph",
+			status: cli.ExitUsage,
+			stderr: "Graphs: review",
+		},
+		{
+			name:   "spec and graph",
+			args:   []string{"-spec", greet, "-graph", "review"},
+			status: cli.ExitUsage,
+			stderr: "Usage",
+		},
+		{
+			name:   "invalid param",
+			args:   []string{"-graph", "review", "-param", "greeting"},
+			status: cli.ExitUsage,
+			stderr: `"greeting" is not name=value`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			status := cli.Run(context.Background(), tc.args, strings.NewReader(tc.input), &stdout, &stderr, options(t))
+			assert.Equal(t, tc.status, status, stderr.String())
+			assert.Contains(t, stderr.String(), tc.stderr)
+
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(tc.stdout) == 0 {
+				assert.Empty(t, strings.TrimSpace(stdout.String()))
+				return
+			}
+			require.Len(t, lines, len(tc.stdout))
+			for i, expected := range tc.stdout {
+				assert.JSONEq(t, expected, lines[i])
+			}
+		})
+	}
+}
+
+func TestRunWithoutRegistry(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	status := cli.Run(context.Background(), []string{"-spec", "graph.yaml"}, strings.NewReader(""), &stdout, &stderr, cli.Options[State]{})
+	assert.Equal(t, cli.ExitUsage, status)
+	assert.Contains(t, stderr.String(), "no node registry")
+}
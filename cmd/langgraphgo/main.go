@@ -0,0 +1,44 @@
+// Command langgraphgo runs the graphs of YAML specs on JSON objects read from stdin and prints
+// the events of the runs; see package cli. It knows the generic node types:
+//
+//   - noop returns the state unchanged;
+//   - set returns the state with the keys of its config set to their values.
+//
+// Graphs with other node types are run by binaries built with their registry and cli.Main.
+package main
+
+import (
+	"context"
+	"maps"
+
+	"github.com/cesto93/langgraphgo/cli"
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+// State is the state of the graphs: a JSON object.
+type State map[string]any
+
+func main() {
+	cli.Main(cli.Options[State]{Registry: registry()})
+}
+
+// registry returns the registry of the generic node types.
+func registry() *spec.Registry[State] {
+	r := spec.NewRegistry[State]()
+	r.Register("noop", func(spec.NodeSpec) (func(context.Context, State) (State, error), error) {
+		return func(_ context.Context, state State) (State, error) {
+			return state, nil
+		}, nil
+	})
+	r.Register("set", func(node spec.NodeSpec) (func(context.Context, State) (State, error), error) {
+		return func(_ context.Context, state State) (State, error) {
+			state = maps.Clone(state)
+			if state == nil {
+				state = make(State, len(node.Config))
+			}
+			maps.Copy(state, node.Config)
+			return state, nil
+		}, nil
+	})
+	return r
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/cli"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "graph.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+entry_point: start
+nodes:
+  - name: start
+    type: noop
+  - name: label
+    type: set
+    config:
+      status: done
+      count: 2
+edges:
+  - from: start
+    to: label
+  - from: label
+    to: END
+`), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	status := cli.Run(context.Background(), []string{"-spec", path, "-final"}, strings.NewReader(`{"id": "a1"}`), &stdout, &stderr, cli.Options[State]{Registry: registry()})
+	require.Equal(t, cli.ExitCompleted, status, stderr.String())
+	assert.JSONEq(t, `{"id": "a1", "status": "done", "count": 2}`, stdout.String())
+}
@@ -0,0 +1,318 @@
+// Package compare compares two runs of a graph, e.g. before and after a prompt change: it
+// aligns their steps, diffs the states they produced and locates the first divergence, to
+// debug behavior regressions.
+//
+//	before, _ := observer.List(ctx, "thread-before")
+//	after, _ := observer.List(ctx, "thread-after")
+//	report, err := compare.Runs(compare.Views(before), compare.Views(after))
+//	fmt.Print(report)
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// Step is a step of a run: a node and the JSON state it produced.
+type Step struct {
+	// Node is the name of the node executed.
+	Node string `json:"node"`
+
+	// State is the JSON state the node produced.
+	State json.RawMessage `json:"state"`
+}
+
+// Views returns the steps of a run from the views of its checkpoints, ordered by step.
+func Views(views []checkpoint.View) []Step {
+	steps := make([]Step, len(views))
+	for i, v := range views {
+		steps[i] = Step{Node: v.Node, State: v.State}
+	}
+	return steps
+}
+
+// Change is a difference between two JSON states.
+type Change struct {
+	// Path locates the value, such as $.messages[2].content; $ is the whole state.
+	Path string `json:"path"`
+
+	// Before is the value in the first state, nil if it was added.
+	Before json.RawMessage `json:"before,omitempty"`
+
+	// After is the value in the second state, nil if it was removed.
+	After json.RawMessage `json:"after,omitempty"`
+}
+
+// String formats the change as "path: before -> after".
+func (c Change) String() string {
+	format := func(v json.RawMessage) string {
+		if v == nil {
+			return "(none)"
+		}
+		return string(v)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, format(c.Before), format(c.After))
+}
+
+// Kind tells how a step of one run relates to the other run.
+type Kind string
+
+const (
+	// KindSame marks steps of both runs executing the same node and producing the same state.
+	KindSame Kind = "same"
+
+	// KindChanged marks steps of both runs executing the same node and producing different
+	// states.
+	KindChanged Kind = "changed"
+
+	// KindRemoved marks steps only found in the first run.
+	KindRemoved Kind = "removed"
+
+	// KindAdded marks steps only found in the second run.
+	KindAdded Kind = "added"
+)
+
+// StepDiff is a pair of aligned steps.
+type StepDiff struct {
+	// Kind tells how the steps relate.
+	Kind Kind `json:"kind"`
+
+	// Node is the node executed.
+	Node string `json:"node"`
+
+	// Before is the index of the step in the first run, -1 for added steps.
+	Before int `json:"before"`
+
+	// After is the index of the step in the second run, -1 for removed steps.
+	After int `json:"after"`
+
+	// Changes are the differences between the states of changed steps.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Report is the comparison of two runs.
+type Report struct {
+	// Steps are the aligned steps of both runs, in execution order.
+	Steps []StepDiff `json:"steps"`
+
+	// Divergence is the index in Steps of the first step that is not KindSame, -1 if the runs
+	// executed the same steps with the same states.
+	Divergence int `json:"divergence"`
+
+	// Output are the differences between the final states of the runs.
+	Output []Change `json:"output,omitempty"`
+}
+
+// Equal reports whether the runs executed the same steps with the same states.
+func (r Report) Equal() bool {
+	return r.Divergence < 0
+}
+
+// String summarizes the report: the divergence point, one line per step marked = (same),
+// ~ (changed), - (removed) or + (added), and the changes of the output.
+func (r Report) String() string {
+	var b strings.Builder
+	if r.Equal() {
+		b.WriteString("runs are identical\n")
+	} else {
+		d := r.Steps[r.Divergence]
+		fmt.Fprintf(&b, "runs diverge at step %d (%s %s)\n", r.Divergence, d.Kind, d.Node)
+	}
+
+	marks := map[Kind]string{KindSame: "=", KindChanged: "~", KindRemoved: "-", KindAdded: "+"}
+	for _, step := range r.Steps {
+		fmt.Fprintf(&b, "%s %s", marks[step.Kind], step.Node)
+		if len(step.Changes) > 0 {
+			fmt.Fprintf(&b, " (%d changes)", len(step.Changes))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Output) > 0 {
+		fmt.Fprintf(&b, "output: %d changes\n", len(r.Output))
+		for _, change := range r.Output {
+			fmt.Fprintf(&b, "  %s\n", change)
+		}
+	}
+	return b.String()
+}
+
+// Runs compares two runs. Steps are aligned on the longest common sequence of nodes, so a step
+// inserted or skipped by one run does not shift the comparison of the following ones.
+func Runs(before, after []Step) (Report, error) {
+	report := Report{Divergence: -1}
+
+	for _, pair := range align(before, after) {
+		diff := StepDiff{Before: pair[0], After: pair[1]}
+		switch {
+		case pair[1] < 0:
+			diff.Kind, diff.Node = KindRemoved, before[pair[0]].Node
+		case pair[0] < 0:
+			diff.Kind, diff.Node = KindAdded, after[pair[1]].Node
+		default:
+			changes, err := States(before[pair[0]].State, after[pair[1]].State)
+			if err != nil {
+				return Report{}, fmt.Errorf("step %s: %w", before[pair[0]].Node, err)
+			}
+			diff.Kind, diff.Node, diff.Changes = KindSame, before[pair[0]].Node, changes
+			if len(changes) > 0 {
+				diff.Kind = KindChanged
+			}
+		}
+		if diff.Kind != KindSame && report.Divergence < 0 {
+			report.Divergence = len(report.Steps)
+		}
+		report.Steps = append(report.Steps, diff)
+	}
+
+	var last [2]json.RawMessage
+	if len(before) > 0 {
+		last[0] = before[len(before)-1].State
+	}
+	if len(after) > 0 {
+		last[1] = after[len(after)-1].State
+	}
+	if last[0] != nil || last[1] != nil {
+		output, err := States(orNull(last[0]), orNull(last[1]))
+		if err != nil {
+			return Report{}, fmt.Errorf("output: %w", err)
+		}
+		report.Output = output
+	}
+	return report, nil
+}
+
+func orNull(state json.RawMessage) json.RawMessage {
+	if state == nil {
+		return json.RawMessage("null")
+	}
+	return state
+}
+
+// align pairs the indexes of the steps of both runs along their longest common sequence of
+// nodes. Unpaired steps have -1 as the other index; removed steps come before added ones.
+func align(before, after []Step) [][2]int {
+	// lcs[i][j] is the length of the longest common sequence of before[i:] and after[j:].
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i].Node == after[j].Node {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i].Node == after[j].Node:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case j == len(after) || (i < len(before) && lcs[i+1][j] >= lcs[i][j+1]):
+			pairs = append(pairs, [2]int{i, -1})
+			i++
+		default:
+			pairs = append(pairs, [2]int{-1, j})
+			j++
+		}
+	}
+	return pairs
+}
+
+// States returns the differences between two JSON states, ordered by path. Objects are
+// compared key by key and arrays index by index.
+func States(before, after json.RawMessage) ([]Change, error) {
+	a, err := decode(before)
+	if err != nil {
+		return nil, fmt.Errorf("first state: %w", err)
+	}
+	b, err := decode(after)
+	if err != nil {
+		return nil, fmt.Errorf("second state: %w", err)
+	}
+
+	var changes []Change
+	diff("$", a, b, &changes)
+	return changes, nil
+}
+
+func decode(state json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(state))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	return v, nil
+}
+
+// missing marks values absent from one of the states.
+type missing struct{}
+
+func diff(path string, a, b any, changes *[]Change) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			keys := make([]string, 0, len(a)+len(b))
+			for key := range a {
+				keys = append(keys, key)
+			}
+			for key := range b {
+				if _, ok := a[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				diff(path+"."+key, valueOf(a, key), valueOf(b, key), changes)
+			}
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				diff(fmt.Sprintf("%s[%d]", path, i), index(a, i), index(b, i), changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Before: encode(a), After: encode(b)})
+	}
+}
+
+func valueOf(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return missing{}
+}
+
+func index(s []any, i int) any {
+	if i < len(s) {
+		return s[i]
+	}
+	return missing{}
+}
+
+func encode(v any) json.RawMessage {
+	if _, ok := v.(missing); ok {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,140 @@
+package compare_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/compare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func step(node, state string) compare.Step {
+	return compare.Step{Node: node, State: json.RawMessage(state)}
+}
+
+func TestRuns(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		before     []compare.Step
+		after      []compare.Step
+		steps      []compare.StepDiff
+		divergence int
+		output     []compare.Change
+	}{
+		{
+			name:       "identical",
+			before:     []compare.Step{step("a", `{"n":1}`), step("b", `{"n":2}`)},
+			after:      []compare.Step{step("a", `{"n":1}`), step("b", `{"n":2}`)},
+			steps:      []compare.StepDiff{{Kind: compare.KindSame, Node: "a", Before: 0, After: 0}, {Kind: compare.KindSame, Node: "b", Before: 1, After: 1}},
+			divergence: -1,
+		},
+		{
+			name:   "changed state",
+			before: []compare.Step{step("a", `{"n":1}`), step("b", `{"answer":"yes","n":2}`)},
+			after:  []compare.Step{step("a", `{"n":1}`), step("b", `{"answer":"no","n":2,"extra":true}`)},
+			steps: []compare.StepDiff{
+				{Kind: compare.KindSame, Node: "a", Before: 0, After: 0},
+				{Kind: compare.KindChanged, Node: "b", Before: 1, After: 1, Changes: []compare.Change{
+					{Path: "$.answer", Before: json.RawMessage(`"yes"`), After: json.RawMessage(`"no"`)},
+					{Path: "$.extra", After: json.RawMessage(`true`)},
+				}},
+			},
+			divergence: 1,
+			output: []compare.Change{
+				{Path: "$.answer", Before: json.RawMessage(`"yes"`), After: json.RawMessage(`"no"`)},
+				{Path: "$.extra", After: json.RawMessage(`true`)},
+			},
+		},
+		{
+			name:   "inserted and skipped steps",
+			before: []compare.Step{step("agent", `["q"]`), step("answer", `["q","a"]`)},
+			after:  []compare.Step{step("agent", `["q"]`), step("tools", `["q","t"]`), step("answer", `["q","t","a"]`)},
+			steps: []compare.StepDiff{
+				{Kind: compare.KindSame, Node: "agent", Before: 0, After: 0},
+				{Kind: compare.KindAdded, Node: "tools", Before: -1, After: 1},
+				{Kind: compare.KindChanged, Node: "answer", Before: 1, After: 2, Changes: []compare.Change{
+					{Path: "$[1]", Before: json.RawMessage(`"a"`), After: json.RawMessage(`"t"`)},
+					{Path: "$[2]", After: json.RawMessage(`"a"`)},
+				}},
+			},
+			divergence: 1,
+			output: []compare.Change{
+				{Path: "$[1]", Before: json.RawMessage(`"a"`), After: json.RawMessage(`"t"`)},
+				{Path: "$[2]", After: json.RawMessage(`"a"`)},
+			},
+		},
+		{
+			name:   "different route",
+			before: []compare.Step{step("a", `1`), step("b", `2`)},
+			after:  []compare.Step{step("a", `1`), step("c", `2`)},
+			steps: []compare.StepDiff{
+				{Kind: compare.KindSame, Node: "a", Before: 0, After: 0},
+				{Kind: compare.KindRemoved, Node: "b", Before: 1, After: -1},
+				{Kind: compare.KindAdded, Node: "c", Before: -1, After: 1},
+			},
+			divergence: 1,
+		},
+		{
+			name:   "empty run",
+			before: []compare.Step{step("a", `{"x":[1]}`)},
+			steps: []compare.StepDiff{
+				{Kind: compare.KindRemoved, Node: "a", Before: 0, After: -1},
+			},
+			divergence: 0,
+			output:     []compare.Change{{Path: "$", Before: json.RawMessage(`{"x":[1]}`), After: json.RawMessage(`null`)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			report, err := compare.Runs(tc.before, tc.after)
+			require.NoError(t, err)
+			assert.Equal(t, tc.steps, report.Steps)
+			assert.Equal(t, tc.divergence, report.Divergence)
+			assert.Equal(t, tc.output, report.Output)
+			assert.Equal(t, tc.divergence < 0, report.Equal())
+		})
+	}
+}
+
+func TestRunsInvalidState(t *testing.T) {
+	t.Parallel()
+
+	_, err := compare.Runs([]compare.Step{step("a", `{`)}, []compare.Step{step("a", `{}`)})
+	assert.ErrorContains(t, err, "step a: first state")
+}
+
+func TestReportString(t *testing.T) {
+	t.Parallel()
+
+	report, err := compare.Runs(
+		[]compare.Step{step("agent", `{"n":1}`), step("answer", `{"n":2}`)},
+		[]compare.Step{step("agent", `{"n":1}`), step("tools", `{"n":2}`), step("answer", `{"n":3}`)},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `runs diverge at step 1 (added tools)
+= agent
++ tools
+~ answer (1 changes)
+output: 1 changes
+  $.n: 2 -> 3
+`, report.String())
+
+	report, err = compare.Runs([]compare.Step{step("a", `1`)}, []compare.Step{step("a", `1`)})
+	require.NoError(t, err)
+	assert.Equal(t, "runs are identical\n= a\n", report.String())
+}
+
+func TestViews(t *testing.T) {
+	t.Parallel()
+
+	steps := compare.Views([]checkpoint.View{{Node: "a", Step: 0, State: json.RawMessage(`1`)}})
+	assert.Equal(t, []compare.Step{step("a", `1`)}, steps)
+}
@@ -0,0 +1,239 @@
+// Package config loads the deployment configuration of services built on the graph package,
+// such as the checkpointer, telemetry and model credentials, from a YAML file and environment
+// variables, so every service is configured the same way.
+//
+// Environment variables override the file and are named after the fields, upper-cased and
+// prefixed, e.g. with DefaultPrefix:
+//
+//	LANGGRAPH_CONFIG_FILE=/etc/agent.yaml
+//	LANGGRAPH_CHECKPOINTER_TYPE=memory
+//	LANGGRAPH_CHECKPOINTER_DSN=...
+//	LANGGRAPH_TELEMETRY_SERVICE_NAME=support
+//	LANGGRAPH_TELEMETRY_PROFILING=true
+//	LANGGRAPH_MODEL_DEFAULT_PROVIDER=openai
+//	LANGGRAPH_MODEL_DEFAULT_MODEL=gpt-4o
+//	LANGGRAPH_MODEL_DEFAULT_API_KEY=...
+//	LANGGRAPH_MODEL_DEFAULT_BASE_URL=...
+//	LANGGRAPH_LOCALIZATION_WORKING_LANGUAGE=en
+//	LANGGRAPH_LOCALIZATION_LANGUAGES=fr,de,ja
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/localize"
+)
+
+// DefaultPrefix is the prefix of the environment variables read by Load when none is given.
+const DefaultPrefix = "LANGGRAPH_"
+
+var (
+	// ErrInvalidConfig is returned when a configuration file or variable cannot be parsed.
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrUnknownCheckpointerType is returned when a checkpointer of an unsupported type is configured.
+	ErrUnknownCheckpointerType = errors.New("unknown checkpointer type")
+)
+
+// Config is the deployment configuration of a service.
+type Config struct {
+	// Checkpointer configures where graph state is persisted.
+	Checkpointer CheckpointerConfig `yaml:"checkpointer"`
+
+	// Telemetry configures the observability of graph invocations.
+	Telemetry TelemetryConfig `yaml:"telemetry"`
+
+	// Models configures the models used by the service, by name.
+	Models map[string]ModelConfig `yaml:"models"`
+
+	// Localization configures the translation of the inputs and outputs of graphs; see
+	// localize.New.
+	Localization localize.Config `yaml:"localization"`
+}
+
+// CheckpointerConfig configures a checkpointer.
+type CheckpointerConfig struct {
+	// Type selects the implementation. The only built-in type is "memory", which is also the default.
+	Type string `yaml:"type"`
+
+	// DSN locates the storage of implementations backed by a database.
+	DSN string `yaml:"dsn"`
+}
+
+// TelemetryConfig configures the observability of graph invocations.
+type TelemetryConfig struct {
+	// ServiceName identifies the service in logs and traces.
+	ServiceName string `yaml:"service_name"`
+
+	// Profiling enables a graph.Profile on the contexts returned by Config.Context.
+	Profiling bool `yaml:"profiling"`
+}
+
+// ModelConfig configures the access to a model.
+type ModelConfig struct {
+	// Provider names the model provider, e.g. "openai".
+	Provider string `yaml:"provider"`
+
+	// Model is the name of the model at the provider.
+	Model string `yaml:"model"`
+
+	// APIKey is the credential used to call the provider.
+	APIKey string `yaml:"api_key"`
+
+	// BaseURL overrides the endpoint of the provider.
+	BaseURL string `yaml:"base_url"`
+}
+
+// Load reads the file named by the prefix+CONFIG_FILE environment variable, if set, and applies
+// the environment variables with the given prefix on top of it. DefaultPrefix is used when
+// prefix is empty.
+func Load(prefix string) (*Config, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	c := &Config{}
+	if path := os.Getenv(prefix + "CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if c, err = Parse(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Override(prefix, os.Environ()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Parse decodes a YAML configuration file.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return &c, nil
+}
+
+// Override applies the variables of environ, in "KEY=value" form, whose key starts with prefix.
+// Variables that do not name a configuration field are ignored.
+func (c *Config) Override(prefix string, environ []string) error {
+	var errs []error
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		if err := c.set(strings.TrimPrefix(key, prefix), value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
+
+// modelFields maps the suffixes of model variables to the fields they set.
+var modelFields = []struct {
+	suffix string
+	field  func(m *ModelConfig) *string
+}{
+	{"_PROVIDER", func(m *ModelConfig) *string { return &m.Provider }},
+	{"_MODEL", func(m *ModelConfig) *string { return &m.Model }},
+	{"_API_KEY", func(m *ModelConfig) *string { return &m.APIKey }},
+	{"_BASE_URL", func(m *ModelConfig) *string { return &m.BaseURL }},
+}
+
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "CHECKPOINTER_TYPE":
+		c.Checkpointer.Type = value
+	case "CHECKPOINTER_DSN":
+		c.Checkpointer.DSN = value
+	case "TELEMETRY_SERVICE_NAME":
+		c.Telemetry.ServiceName = value
+	case "TELEMETRY_PROFILING":
+		profiling, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.Telemetry.Profiling = profiling
+	case "LOCALIZATION_WORKING_LANGUAGE":
+		c.Localization.WorkingLanguage = value
+	case "LOCALIZATION_LANGUAGES":
+		c.Localization.Languages = nil
+		for _, language := range strings.Split(value, ",") {
+			if language = strings.TrimSpace(language); language != "" {
+				c.Localization.Languages = append(c.Localization.Languages, language)
+			}
+		}
+	default:
+		rest, ok := strings.CutPrefix(key, "MODEL_")
+		if !ok {
+			return nil
+		}
+		for _, f := range modelFields {
+			name, ok := strings.CutSuffix(rest, f.suffix)
+			if !ok || name == "" {
+				continue
+			}
+
+			name = strings.ToLower(name)
+			if c.Models == nil {
+				c.Models = make(map[string]ModelConfig)
+			}
+			m := c.Models[name]
+			*f.field(&m) = value
+			c.Models[name] = m
+			return nil
+		}
+	}
+	return nil
+}
+
+// Model returns the configuration of the named model.
+func (c *Config) Model(name string) (ModelConfig, bool) {
+	m, ok := c.Models[name]
+	return m, ok
+}
+
+// NewCheckpointer creates the checkpointer.
+func (c *Config) NewCheckpointer() (checkpoint.Checkpointer, error) {
+	return NewCheckpointer(c.Checkpointer)
+}
+
+// NewCheckpointer creates a checkpointer from its configuration.
+func NewCheckpointer(cfg CheckpointerConfig) (checkpoint.Checkpointer, error) {
+	switch cfg.Type {
+	case "", "memory":
+		return checkpoint.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCheckpointerType, cfg.Type)
+	}
+}
+
+// Context prepares the context of a graph invocation according to the telemetry configuration.
+// The returned profile is nil unless profiling is enabled.
+func (c *Config) Context(ctx context.Context) (context.Context, *graph.Profile) {
+	if !c.Telemetry.Profiling {
+		return ctx, nil
+	}
+	return graph.WithProfiling(ctx)
+}
@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/config"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/localize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configFile = `
+checkpointer:
+  type: memory
+telemetry:
+  service_name: support
+models:
+  default:
+    provider: openai
+    model: gpt-4o
+localization:
+  working_language: en
+`
+
+func TestOverride(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.Parse([]byte(configFile))
+	require.NoError(t, err)
+
+	err = c.Override("APP_", []string{
+		"APP_TELEMETRY_PROFILING=true",
+		"APP_MODEL_DEFAULT_API_KEY=secret",
+		"APP_MODEL_FAST_MODEL=gpt-4o-mini",
+		"APP_MODEL_FAST_BASE_URL=http://localhost:8000",
+		"APP_LOCALIZATION_LANGUAGES=fr, de,",
+		"APP_UNRELATED=ignored",
+		"OTHER_CHECKPOINTER_TYPE=sqlite",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Config{
+		Checkpointer: config.CheckpointerConfig{Type: "memory"},
+		Telemetry:    config.TelemetryConfig{ServiceName: "support", Profiling: true},
+		Models: map[string]config.ModelConfig{
+			"default": {Provider: "openai", Model: "gpt-4o", APIKey: "secret"},
+			"fast":    {Model: "gpt-4o-mini", BaseURL: "http://localhost:8000"},
+		},
+		Localization: localize.Config{WorkingLanguage: "en", Languages: []string{"fr", "de"}},
+	}, *c)
+
+	m, ok := c.Model("fast")
+	assert.True(t, ok)
+	assert.Equal(t, "gpt-4o-mini", m.Model)
+	_, ok = c.Model("missing")
+	assert.False(t, ok)
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.Parse([]byte("checkpoint: {}\n"))
+	require.ErrorIs(t, err, config.ErrInvalidConfig)
+
+	err = (&config.Config{}).Override("APP_", []string{"APP_TELEMETRY_PROFILING=maybe"})
+	require.ErrorIs(t, err, config.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "APP_TELEMETRY_PROFILING")
+
+	_, err = (&config.Config{Checkpointer: config.CheckpointerConfig{Type: "postgres"}}).NewCheckpointer()
+	require.ErrorIs(t, err, config.ErrUnknownCheckpointerType)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(configFile), 0o600))
+	t.Setenv("LANGGRAPH_CONFIG_FILE", path)
+	t.Setenv("LANGGRAPH_TELEMETRY_SERVICE_NAME", "billing")
+
+	c, err := config.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", c.Telemetry.ServiceName)
+	assert.Equal(t, "openai", c.Models["default"].Provider)
+
+	cp, err := c.NewCheckpointer()
+	require.NoError(t, err)
+	assert.IsType(t, &checkpoint.Memory{}, cp)
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[int]("step")
+	g.AddNode("step", func(_ context.Context, state int) (int, error) { return state + 1, nil })
+	g.AddEdge("step", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx, profile := (&config.Config{}).Context(context.Background())
+	assert.Nil(t, profile)
+	assert.Equal(t, context.Background(), ctx)
+
+	c := &config.Config{Telemetry: config.TelemetryConfig{Profiling: true}}
+	ctx, profile = c.Context(context.Background())
+	require.NotNil(t, profile)
+
+	_, err = runnable.Invoke(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, profile.Entries(), 1)
+}
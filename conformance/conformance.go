@@ -0,0 +1,172 @@
+// Package conformance checks that graphs behave like their Python LangGraph equivalents.
+//
+// A fixture holds the JSON export of a compiled Python graph (graph.get_graph().to_json())
+// and the order in which Python executed its nodes. Run rebuilds the topology with the graph
+// package, using nodes that record their own name, and Check compares the executed nodes step
+// by step. Fixtures are recorded with record.py in this directory.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Names of the virtual start and end nodes in Python exports.
+const (
+	pythonStart = "__start__"
+	pythonEnd   = "__end__"
+)
+
+var (
+	// ErrUnsupported is returned for exports using features the graph package does not implement.
+	ErrUnsupported = errors.New("unsupported topology")
+
+	// ErrMismatch is returned when Go and Python execute different nodes.
+	ErrMismatch = errors.New("execution differs from python")
+)
+
+// Fixture is a Python graph and its recorded execution.
+type Fixture struct {
+	// Name identifies the fixture.
+	Name string `json:"name"`
+
+	// Graph is the JSON export of the compiled Python graph.
+	Graph Export `json:"graph"`
+
+	// Trace lists the nodes executed by Python, in order.
+	Trace []string `json:"trace"`
+}
+
+// Export is the JSON export of a Python LangGraph graph.
+type Export struct {
+	// Nodes are the nodes of the graph, including __start__ and __end__.
+	Nodes []ExportNode `json:"nodes"`
+
+	// Edges are the edges of the graph.
+	Edges []ExportEdge `json:"edges"`
+}
+
+// ExportNode is a node of an Export.
+type ExportNode struct {
+	// ID is the name of the node.
+	ID string `json:"id"`
+
+	// Type is "schema" for the virtual start and end nodes and "runnable" otherwise.
+	Type string `json:"type"`
+}
+
+// ExportEdge is an edge of an Export.
+type ExportEdge struct {
+	// Source is the ID of the node the edge starts from.
+	Source string `json:"source"`
+
+	// Target is the ID of the node the edge points to.
+	Target string `json:"target"`
+
+	// Conditional reports whether the edge is one of the outcomes of a routing function.
+	Conditional bool `json:"conditional"`
+}
+
+// Load reads a fixture file.
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decoding fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Build builds the topology of the export with nodes appending their name to the state.
+func Build(e Export) (*graph.MessageGraph[[]string], error) {
+	var entryPoint string
+	for _, edge := range e.Edges {
+		if edge.Conditional {
+			return nil, fmt.Errorf("%w: conditional edge %s -> %s", ErrUnsupported, edge.Source, edge.Target)
+		}
+		if edge.Source == pythonStart {
+			if entryPoint != "" {
+				return nil, fmt.Errorf("%w: several entry points", ErrUnsupported)
+			}
+			entryPoint = edge.Target
+		}
+	}
+
+	g := graph.NewMessageGraph[[]string](entryPoint)
+	for _, node := range e.Nodes {
+		if node.ID == pythonStart || node.ID == pythonEnd {
+			continue
+		}
+		name := node.ID
+		g.AddNode(name, func(_ context.Context, state []string) ([]string, error) {
+			return graph.AppendMessages(state, name), nil
+		})
+	}
+
+	outgoing := make(map[string]bool)
+	for _, edge := range e.Edges {
+		if edge.Source == pythonStart {
+			continue
+		}
+		if outgoing[edge.Source] {
+			return nil, fmt.Errorf("%w: fan-out from %s", ErrUnsupported, edge.Source)
+		}
+		outgoing[edge.Source] = true
+
+		target := edge.Target
+		if target == pythonEnd {
+			target = graph.END
+		}
+		g.AddEdge(edge.Source, target)
+	}
+	return g, nil
+}
+
+// Run executes the topology of the fixture and returns the nodes executed, in order.
+func (f *Fixture) Run(ctx context.Context) ([]string, error) {
+	g, err := Build(f.Graph)
+	if err != nil {
+		return nil, err
+	}
+
+	runnable, err := g.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return runnable.Invoke(ctx, nil)
+}
+
+// Check runs the fixture and compares the executed nodes with the Python trace.
+func (f *Fixture) Check(ctx context.Context) error {
+	trace, err := f.Run(ctx)
+	if err != nil {
+		return err
+	}
+	return Compare(f.Trace, trace)
+}
+
+// Compare reports the first step at which the Go trace diverges from the Python trace.
+func Compare(python, golang []string) error {
+	for step := 0; step < max(len(python), len(golang)); step++ {
+		want, got := "<end>", "<end>"
+		if step < len(python) {
+			want = python[step]
+		}
+		if step < len(golang) {
+			got = golang[step]
+		}
+		if want != got {
+			return fmt.Errorf("%w: step %d: python ran %s, go ran %s", ErrMismatch, step, want, got)
+		}
+	}
+	return nil
+}
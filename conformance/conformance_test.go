@@ -0,0 +1,78 @@
+package conformance_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			t.Parallel()
+
+			f, err := conformance.Load(path)
+			require.NoError(t, err)
+
+			err = f.Check(context.Background())
+			if errors.Is(err, conformance.ErrUnsupported) {
+				t.Skip(err)
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		python []string
+		golang []string
+		err    string
+	}{
+		{name: "equal", python: []string{"a", "b"}, golang: []string{"a", "b"}},
+		{name: "different node", python: []string{"a", "b"}, golang: []string{"a", "c"}, err: "step 1: python ran b, go ran c"},
+		{name: "go stops early", python: []string{"a", "b"}, golang: []string{"a"}, err: "step 1: python ran b, go ran <end>"},
+		{name: "go runs longer", python: []string{"a"}, golang: []string{"a", "a"}, err: "step 1: python ran <end>, go ran a"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := conformance.Compare(tc.python, tc.golang)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, conformance.ErrMismatch)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestBuildUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := conformance.Build(conformance.Export{Edges: []conformance.ExportEdge{
+		{Source: "__start__", Target: "a"},
+		{Source: "a", Target: "b"},
+		{Source: "a", Target: "c"},
+	}})
+	require.ErrorIs(t, err, conformance.ErrUnsupported)
+}
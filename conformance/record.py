@@ -0,0 +1,78 @@
+"""Records conformance fixtures from Python LangGraph.
+
+Every topology is compiled and invoked with nodes that append their name to
+the state; the graph export and the resulting trace are written to
+testdata/<name>.json.
+
+Usage: python record.py [OUTPUT_DIR]
+"""
+
+import json
+import operator
+import pathlib
+import sys
+from typing import Annotated
+
+from langgraph.graph import END, START, StateGraph
+from typing_extensions import TypedDict
+
+
+class State(TypedDict):
+    trace: Annotated[list[str], operator.add]
+
+
+def record(name):
+    def run(state):
+        return {"trace": [name]}
+
+    return run
+
+
+def chain(*names):
+    g = StateGraph(State)
+    for name in names:
+        g.add_node(name, record(name))
+    g.add_edge(START, names[0])
+    for source, target in zip(names, names[1:]):
+        g.add_edge(source, target)
+    g.add_edge(names[-1], END)
+    return g.compile()
+
+
+def react():
+    g = StateGraph(State)
+    g.add_node("agent", record("agent"))
+    g.add_node("tools", record("tools"))
+    g.add_edge(START, "agent")
+    g.add_conditional_edges(
+        "agent",
+        lambda state: "tools" if state["trace"].count("agent") < 2 else END,
+        ["tools", END],
+    )
+    g.add_edge("tools", "agent")
+    return g.compile()
+
+
+TOPOLOGIES = {
+    "single": lambda: chain("agent"),
+    "linear": lambda: chain("retrieve", "grade", "generate"),
+    "declared_out_of_order": lambda: chain("c", "a", "b"),
+    "react": react,
+}
+
+
+def main():
+    out = pathlib.Path(sys.argv[1] if len(sys.argv) > 1 else "testdata")
+    out.mkdir(parents=True, exist_ok=True)
+    for name, build in TOPOLOGIES.items():
+        app = build()
+        fixture = {
+            "name": name,
+            "graph": app.get_graph().to_json(),
+            "trace": app.invoke({"trace": []})["trace"],
+        }
+        (out / f"{name}.json").write_text(json.dumps(fixture, indent=2) + "\n")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,266 @@
+// Package events publishes the lifecycle of graph invocations to message buses, so analytics
+// and downstream systems can follow runs without reading checkpoints: an Emitter registered as
+// graph callbacks turns every run start, node completion and run end into an Event handed to a
+// Publisher, such as the Kafka, NATS and webhook publishers of the subpackages.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Kind classifies events.
+type Kind string
+
+const (
+	// KindRunStarted is published when an invocation starts or resumes.
+	KindRunStarted Kind = "run.started"
+
+	// KindNodeCompleted is published when a node succeeded.
+	KindNodeCompleted Kind = "node.completed"
+
+	// KindNodeFailed is published when a node failed, with its error.
+	KindNodeFailed Kind = "node.failed"
+
+	// KindRunFinished is published when an invocation ends, with its status.
+	KindRunFinished Kind = "run.finished"
+)
+
+const (
+	// MetadataGraph is the metadata key holding the name of the graph of a run.
+	MetadataGraph = "graph"
+
+	// MetadataTenant is the metadata key holding the tenant a run is made for.
+	MetadataTenant = checkpoint.MetadataTenant
+)
+
+// Status is the outcome of a finished run.
+type Status string
+
+const (
+	// StatusCompleted is the status of the runs that reached END.
+	StatusCompleted Status = "completed"
+
+	// StatusInterrupted is the status of the runs that paused at an interrupt.
+	StatusInterrupted Status = "interrupted"
+
+	// StatusFailed is the status of the runs that failed.
+	StatusFailed Status = "failed"
+)
+
+// Event is an event of the lifecycle of an invocation.
+type Event struct {
+	// ID identifies the event, so consumers can discard the duplicates of redeliveries.
+	ID string `json:"id"`
+
+	// Kind classifies the event.
+	Kind Kind `json:"kind"`
+
+	// RunID identifies the invocation; see graph.RunID.
+	RunID string `json:"run_id"`
+
+	// ThreadID is the thread of the invocation, if any; see graph.WithThreadID.
+	ThreadID string `json:"thread_id,omitempty"`
+
+	// Node is the node of node events.
+	Node string `json:"node,omitempty"`
+
+	// Status is the outcome of run.finished events.
+	Status Status `json:"status,omitempty"`
+
+	// Reason is why the run ended, for run.finished events; finer than Status.
+	Reason graph.Reason `json:"reason,omitempty"`
+
+	// Error is the error of node.failed events and of failed runs.
+	Error string `json:"error,omitempty"`
+
+	// Duration is the duration of the run of run.finished events.
+	Duration time.Duration `json:"duration_ns,omitempty"`
+
+	// Usage is the usage recorded during the run of run.finished events, when the run was
+	// metered; see graph.WithUsageMeter.
+	Usage *graph.Usage `json:"usage,omitempty"`
+
+	// Time is the time of the event.
+	Time time.Time `json:"time"`
+
+	// Metadata are the metadata set with WithMetadata and WithRunMetadata, e.g. MetadataGraph
+	// and MetadataTenant.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Publisher publishes events. Implementations must be safe for concurrent use.
+type Publisher interface {
+	// Publish publishes an event.
+	Publish(ctx context.Context, e Event) error
+}
+
+// PublisherFunc adapts a function to Publisher.
+type PublisherFunc func(ctx context.Context, e Event) error
+
+// Publish calls f.
+func (f PublisherFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Option configures an Emitter.
+type Option func(*options)
+
+type options struct {
+	metadata map[string]string
+	onError  func(ctx context.Context, e Event, err error)
+}
+
+// WithMetadata attaches metadata to every event, e.g. the name and version of the graph or the
+// deployment environment.
+func WithMetadata(metadata map[string]string) Option {
+	return func(o *options) { o.metadata = maps.Clone(metadata) }
+}
+
+// WithErrorHandler sets the function called when an event cannot be published. By default
+// failures are logged: they never fail the run.
+func WithErrorHandler(onError func(ctx context.Context, e Event, err error)) Option {
+	return func(o *options) { o.onError = onError }
+}
+
+type runMetadataKey struct{}
+
+// WithRunMetadata returns a context attaching metadata to the events of the invocations made
+// with it, in addition to those set with WithMetadata, e.g. the tenant of a request.
+func WithRunMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	if parent, _ := ctx.Value(runMetadataKey{}).(map[string]string); parent != nil {
+		merged := maps.Clone(parent)
+		maps.Copy(merged, metadata)
+		metadata = merged
+	}
+	return context.WithValue(ctx, runMetadataKey{}, maps.Clone(metadata))
+}
+
+// run is a run in progress.
+type run struct {
+	start time.Time
+	usage graph.Usage
+}
+
+// Emitter is graph.Callbacks publishing the events of the invocations it is notified of. Events
+// are published synchronously, in the order of the callbacks: use a fast publisher, or one
+// buffering events, for latency-sensitive graphs.
+type Emitter[T any] struct {
+	publisher Publisher
+	opts      options
+
+	mu   sync.Mutex
+	runs map[string]run
+}
+
+var _ graph.Callbacks[any] = (*Emitter[any])(nil)
+
+// NewEmitter returns an Emitter publishing events with p. Register it with graph.WithCallbacks
+// or graph.WithInvokeCallbacks.
+func NewEmitter[T any](p Publisher, opts ...Option) *Emitter[T] {
+	o := options{onError: logError}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Emitter[T]{publisher: p, opts: o, runs: make(map[string]run)}
+}
+
+// OnGraphStart publishes a KindRunStarted event.
+func (e *Emitter[T]) OnGraphStart(ctx context.Context, _ T) {
+	event := e.event(ctx, KindRunStarted)
+	started := run{start: event.Time}
+	if meter := graph.UsageMeterFrom(ctx); meter != nil {
+		started.usage = meter.Total()
+	}
+	e.mu.Lock()
+	e.runs[event.RunID] = started
+	e.mu.Unlock()
+	e.publish(ctx, event)
+}
+
+// OnNodeStart does nothing.
+func (e *Emitter[T]) OnNodeStart(context.Context, string, T) {}
+
+// OnNodeEnd publishes a KindNodeCompleted event.
+func (e *Emitter[T]) OnNodeEnd(ctx context.Context, node string, _ T) {
+	event := e.event(ctx, KindNodeCompleted)
+	event.Node = node
+	e.publish(ctx, event)
+}
+
+// OnNodeError publishes a KindNodeFailed event.
+func (e *Emitter[T]) OnNodeError(ctx context.Context, node string, err error) {
+	event := e.event(ctx, KindNodeFailed)
+	event.Node, event.Error = node, err.Error()
+	e.publish(ctx, event)
+}
+
+// OnGraphEnd publishes a KindRunFinished event.
+func (e *Emitter[T]) OnGraphEnd(ctx context.Context, _ T, err error) {
+	event := e.event(ctx, KindRunFinished)
+	event.Reason = graph.ReasonOf(err)
+	switch {
+	case err == nil:
+		event.Status = StatusCompleted
+	case errors.Is(err, graph.ErrInterrupted):
+		event.Status = StatusInterrupted
+	default:
+		event.Status, event.Error = StatusFailed, err.Error()
+	}
+
+	e.mu.Lock()
+	started, ok := e.runs[event.RunID]
+	delete(e.runs, event.RunID)
+	e.mu.Unlock()
+	if ok {
+		event.Duration = event.Time.Sub(started.start)
+		if meter := graph.UsageMeterFrom(ctx); meter != nil {
+			usage := meter.Total().Sub(started.usage)
+			event.Usage = &usage
+		}
+	}
+
+	// The run is over, but its end is published even if it ended because ctx is done.
+	e.publish(context.WithoutCancel(ctx), event)
+}
+
+// event returns a new event of the invocation of ctx.
+func (e *Emitter[T]) event(ctx context.Context, kind Kind) Event {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	metadata := e.opts.metadata
+	if run, _ := ctx.Value(runMetadataKey{}).(map[string]string); run != nil {
+		metadata = maps.Clone(metadata)
+		if metadata == nil {
+			metadata = make(map[string]string, len(run))
+		}
+		maps.Copy(metadata, run)
+	}
+	return Event{
+		ID:       hex.EncodeToString(id[:]),
+		Kind:     kind,
+		RunID:    graph.RunID(ctx),
+		ThreadID: graph.ThreadID(ctx),
+		Time:     time.Now(),
+		Metadata: metadata,
+	}
+}
+
+func (e *Emitter[T]) publish(ctx context.Context, event Event) {
+	if err := e.publisher.Publish(ctx, event); err != nil {
+		e.opts.onError(ctx, event, err)
+	}
+}
+
+func logError(ctx context.Context, e Event, err error) {
+	slog.ErrorContext(ctx, "publishing event failed", "kind", e.Kind, "run", e.RunID, "node", e.Node, "error", err)
+}
@@ -0,0 +1,177 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// recorder records the events published with it.
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) Publish(_ context.Context, e events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func newRunnable(t *testing.T, callbacks graph.Callbacks[[]string], opts ...graph.CompileOption) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", func(_ context.Context, state []string) ([]string, error) {
+		return graph.AppendMessages(state, "a"), nil
+	})
+	g.AddNode("b", func(_ context.Context, state []string) ([]string, error) {
+		if len(state) > 1 {
+			return state, errors.New("boom")
+		}
+		return graph.AppendMessages(state, "b"), nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", graph.END)
+
+	runnable, err := g.Compile(append(opts, graph.WithCallbacks(callbacks))...)
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestEmitter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		input  []string
+		opts   []graph.CompileOption
+		kinds  []events.Kind
+		status events.Status
+		reason graph.Reason
+		err    string
+	}{
+		{
+			name:   "completed",
+			kinds:  []events.Kind{events.KindRunStarted, events.KindNodeCompleted, events.KindNodeCompleted, events.KindRunFinished},
+			status: events.StatusCompleted,
+			reason: graph.ReasonCompleted,
+		},
+		{
+			name:   "failed",
+			input:  []string{"x"},
+			kinds:  []events.Kind{events.KindRunStarted, events.KindNodeCompleted, events.KindNodeFailed, events.KindRunFinished},
+			status: events.StatusFailed,
+			reason: graph.ReasonNodeError,
+			err:    "boom",
+		},
+		{
+			name:   "interrupted",
+			opts:   []graph.CompileOption{graph.WithInterruptBefore("b")},
+			kinds:  []events.Kind{events.KindRunStarted, events.KindNodeCompleted, events.KindRunFinished},
+			status: events.StatusInterrupted,
+			reason: graph.ReasonInterrupted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			publisher := &recorder{}
+			emitter := events.NewEmitter[[]string](publisher, events.WithMetadata(map[string]string{"env": "test"}))
+			runnable := newRunnable(t, emitter, tc.opts...)
+
+			ctx := graph.WithThreadID(graph.WithRunID(context.Background(), "run"), "thread")
+			_, _ = runnable.Invoke(ctx, tc.input)
+
+			var kinds []events.Kind
+			ids := make(map[string]bool)
+			for _, e := range publisher.events {
+				kinds = append(kinds, e.Kind)
+				ids[e.ID] = true
+				assert.Equal(t, "run", e.RunID)
+				assert.Equal(t, "thread", e.ThreadID)
+				assert.Equal(t, map[string]string{"env": "test"}, e.Metadata)
+				assert.False(t, e.Time.IsZero())
+			}
+			require.Equal(t, tc.kinds, kinds)
+			assert.Len(t, ids, len(kinds), "events have unique IDs")
+			assert.Equal(t, "a", publisher.events[1].Node)
+
+			finished := publisher.events[len(publisher.events)-1]
+			assert.Equal(t, tc.status, finished.Status)
+			assert.Equal(t, tc.reason, finished.Reason)
+			assert.Contains(t, finished.Error, tc.err)
+			assert.Positive(t, finished.Duration)
+			if tc.err != "" {
+				failed := publisher.events[2]
+				assert.Equal(t, "b", failed.Node)
+				assert.Contains(t, failed.Error, tc.err)
+			}
+		})
+	}
+}
+
+func TestEmitterErrors(t *testing.T) {
+	t.Parallel()
+
+	var failed []events.Kind
+	publisher := events.PublisherFunc(func(context.Context, events.Event) error {
+		return errors.New("unavailable")
+	})
+	emitter := events.NewEmitter[[]string](publisher, events.WithErrorHandler(func(_ context.Context, e events.Event, err error) {
+		assert.EqualError(t, err, "unavailable")
+		failed = append(failed, e.Kind)
+	}))
+
+	state, err := newRunnable(t, emitter).Invoke(context.Background(), nil)
+	require.NoError(t, err, "publishing failures do not fail runs")
+	assert.Equal(t, []string{"a", "b"}, state)
+	assert.Len(t, failed, 4)
+}
+
+func TestEmitterRunMetadataAndUsage(t *testing.T) {
+	t.Parallel()
+
+	publisher := &recorder{}
+	emitter := events.NewEmitter[int](publisher, events.WithMetadata(map[string]string{events.MetadataGraph: "g", "env": "test"}))
+
+	g := graph.NewMessageGraph[int]("a")
+	g.AddNode("a", func(ctx context.Context, state int) (int, error) {
+		graph.RecordUsage(ctx, graph.Usage{Calls: 1, InputTokens: 10, OutputTokens: 2})
+		return state, nil
+	})
+	g.AddEdge("a", graph.END)
+	runnable, err := g.Compile(graph.WithCallbacks[int](emitter))
+	require.NoError(t, err)
+
+	// The meter already holds the usage of an earlier run.
+	var meter graph.UsageMeter
+	ctx := graph.WithUsageMeter(context.Background(), &meter)
+	graph.RecordUsage(ctx, graph.Usage{Calls: 5})
+	ctx = events.WithRunMetadata(ctx, map[string]string{events.MetadataTenant: "acme", "env": "prod"})
+	ctx = events.WithRunMetadata(ctx, map[string]string{"request": "r1"})
+	_, err = runnable.Invoke(ctx, 0)
+	require.NoError(t, err)
+
+	finished := publisher.events[len(publisher.events)-1]
+	assert.Equal(t, map[string]string{"graph": "g", "tenant": "acme", "env": "prod", "request": "r1"}, finished.Metadata)
+	assert.Equal(t, &graph.Usage{Calls: 1, InputTokens: 10, OutputTokens: 2}, finished.Usage)
+	assert.Nil(t, publisher.events[0].Usage)
+
+	// Runs without meter report no usage.
+	_, err = runnable.Invoke(context.Background(), 0)
+	require.NoError(t, err)
+	finished = publisher.events[len(publisher.events)-1]
+	assert.Nil(t, finished.Usage)
+	assert.Equal(t, map[string]string{"graph": "g", "env": "test"}, finished.Metadata)
+}
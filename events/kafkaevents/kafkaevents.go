@@ -0,0 +1,81 @@
+// Package kafkaevents implements events.Publisher on Kafka.
+//
+// Events are produced as JSON documents to a topic, keyed by thread, or by run for the runs
+// without a thread, so the events of a thread are consumed in order. Topics are not created by
+// the publisher.
+package kafkaevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cesto93/langgraphgo/events"
+)
+
+// DefaultTopic is the topic of events when none is configured.
+const DefaultTopic = "langgraph.events"
+
+// headerKind holds the kind of an event, so consumers can filter events without decoding them.
+const headerKind = "kind"
+
+// Config configures a Publisher.
+type Config struct {
+	// Brokers are the seed brokers of the cluster.
+	Brokers []string
+
+	// Topic is the topic of events; DefaultTopic is used when empty.
+	Topic string
+
+	// Options are additional client options, e.g. for TLS or SASL.
+	Options []kgo.Opt
+}
+
+// Publisher is an events.Publisher on Kafka. Publish waits for the event to be acknowledged by
+// the cluster.
+type Publisher struct {
+	client *kgo.Client
+}
+
+var _ events.Publisher = (*Publisher)(nil)
+
+// New returns a publisher producing to the topic of the configuration.
+func New(cfg Config) (*Publisher, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = DefaultTopic
+	}
+	opts := append([]kgo.Opt{kgo.SeedBrokers(cfg.Brokers...), kgo.DefaultProduceTopic(cfg.Topic)}, cfg.Options...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating producer: %w", err)
+	}
+	return &Publisher{client: client}, nil
+}
+
+// Close closes the client of the publisher.
+func (p *Publisher) Close() {
+	p.client.Close()
+}
+
+// Publish produces an event.
+func (p *Publisher) Publish(ctx context.Context, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event %s: %w", e.ID, err)
+	}
+	key := e.ThreadID
+	if key == "" {
+		key = e.RunID
+	}
+	record := &kgo.Record{
+		Key:     []byte(key),
+		Value:   data,
+		Headers: []kgo.RecordHeader{{Key: headerKind, Value: []byte(e.Kind)}},
+	}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("producing event %s: %w", e.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package kafkaevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/events/kafkaevents"
+)
+
+// TestPublisher runs against the Kafka cluster at the comma-separated KAFKA_BROKERS, which
+// must create topics automatically, and is skipped when it is not set.
+func TestPublisher(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Isolate the topic of every run of the test.
+	topic := "test-events-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	seeds := strings.Split(brokers, ",")
+	p, err := kafkaevents.New(kafkaevents.Config{
+		Brokers: seeds,
+		Topic:   topic,
+		Options: []kgo.Opt{kgo.AllowAutoTopicCreation()},
+	})
+	require.NoError(t, err)
+	defer p.Close()
+
+	published := []events.Event{
+		{ID: "1", Kind: events.KindRunStarted, RunID: "run", ThreadID: "thread", Time: time.Now().UTC()},
+		{ID: "2", Kind: events.KindNodeCompleted, RunID: "run", ThreadID: "thread", Node: "a", Time: time.Now().UTC()},
+		{ID: "3", Kind: events.KindRunFinished, RunID: "other", Status: events.StatusCompleted, Time: time.Now().UTC()},
+	}
+	for _, e := range published {
+		require.NoError(t, p.Publish(ctx, e))
+	}
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(seeds...), kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	var received []events.Event
+	keys := make(map[string]string)
+	for len(received) < len(published) {
+		fetches := consumer.PollFetches(ctx)
+		require.NoError(t, fetches.Err())
+		fetches.EachRecord(func(r *kgo.Record) {
+			var e events.Event
+			require.NoError(t, json.Unmarshal(r.Value, &e))
+			received = append(received, e)
+			keys[e.ID] = string(r.Key)
+			assert.Equal(t, string(e.Kind), string(r.Headers[0].Value))
+		})
+	}
+	assert.ElementsMatch(t, published, received)
+	assert.Equal(t, map[string]string{"1": "thread", "2": "thread", "3": "other"}, keys)
+}
@@ -0,0 +1,90 @@
+// Package natsevents implements events.Publisher on NATS JetStream.
+//
+// Events are published as JSON documents to a subject per kind, the subject prefix followed by
+// the kind, e.g. langgraph.events.run.finished, so consumers can subscribe to some kinds only.
+// Events carry their ID as message ID, so the stream drops the duplicates published within its
+// duplicate window.
+package natsevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/cesto93/langgraphgo/events"
+)
+
+const (
+	// DefaultStream is the stream of events when none is configured.
+	DefaultStream = "EVENTS"
+
+	// DefaultSubject is the subject prefix of events when none is configured.
+	DefaultSubject = "langgraph.events"
+
+	// headerKind holds the kind of an event.
+	headerKind = "Kind"
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// Stream is the stream capturing the events; DefaultStream is used when empty.
+	Stream string
+
+	// Subject is the subject prefix of events; DefaultSubject is used when empty.
+	Subject string
+
+	// Replicas is the number of replicas of the stream; 1 is used when not positive.
+	Replicas int
+}
+
+// Publisher is an events.Publisher on NATS JetStream. Publish waits for the event to be stored
+// by the stream.
+type Publisher struct {
+	js  jetstream.JetStream
+	cfg Config
+}
+
+var _ events.Publisher = (*Publisher)(nil)
+
+// New creates or updates the stream of the configuration and returns a publisher using it.
+func New(ctx context.Context, js jetstream.JetStream, cfg Config) (*Publisher, error) {
+	if cfg.Stream == "" {
+		cfg.Stream = DefaultStream
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultSubject
+	}
+	cfg.Replicas = max(cfg.Replicas, 1)
+
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: []string{cfg.Subject + ".>"},
+		Replicas: cfg.Replicas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating stream %s: %w", cfg.Stream, err)
+	}
+	return &Publisher{js: js, cfg: cfg}, nil
+}
+
+// Subject returns the subject of the events of a kind.
+func (p *Publisher) Subject(kind events.Kind) string {
+	return p.cfg.Subject + "." + string(kind)
+}
+
+// Publish publishes an event.
+func (p *Publisher) Publish(ctx context.Context, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event %s: %w", e.ID, err)
+	}
+	msg := &nats.Msg{Subject: p.Subject(e.Kind), Data: data, Header: nats.Header{}}
+	msg.Header.Set(headerKind, string(e.Kind))
+	if _, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(e.ID)); err != nil {
+		return fmt.Errorf("publishing event %s: %w", e.ID, err)
+	}
+	return nil
+}
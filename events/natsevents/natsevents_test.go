@@ -0,0 +1,68 @@
+package natsevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/events/natsevents"
+)
+
+// TestPublisher runs against the JetStream-enabled NATS server at NATS_URL, and is skipped
+// when it is not set.
+func TestPublisher(t *testing.T) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		t.Skip("NATS_URL not set")
+	}
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Isolate the stream of every run of the test.
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	stream := "TEST_EVENTS_" + suffix
+	p, err := natsevents.New(ctx, js, natsevents.Config{Stream: stream, Subject: "test.events." + suffix})
+	require.NoError(t, err)
+	defer func() { _ = js.DeleteStream(context.Background(), stream) }()
+
+	assert.Equal(t, "test.events."+suffix+".run.finished", p.Subject(events.KindRunFinished))
+
+	started := events.Event{ID: "1", Kind: events.KindRunStarted, RunID: "run", Time: time.Now().UTC()}
+	finished := events.Event{ID: "2", Kind: events.KindRunFinished, RunID: "run", Status: events.StatusCompleted, Time: time.Now().UTC()}
+	require.NoError(t, p.Publish(ctx, started))
+	require.NoError(t, p.Publish(ctx, finished))
+	// Redeliveries of an event are dropped.
+	require.NoError(t, p.Publish(ctx, finished))
+
+	info, err := js.Stream(ctx, stream)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, info.CachedInfo().State.Msgs)
+
+	// Consumers can follow some kinds only.
+	consumer, err := js.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{p.Subject(events.KindRunFinished)},
+	})
+	require.NoError(t, err)
+	msg, err := consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+	require.NoError(t, err)
+	var got events.Event
+	require.NoError(t, json.Unmarshal(msg.Data(), &got))
+	assert.Equal(t, finished, got)
+	assert.Equal(t, "run.finished", msg.Headers().Get("Kind"))
+}
@@ -0,0 +1,116 @@
+// Package webhook implements events.Publisher with HTTP webhooks: every event is posted as a
+// JSON document to a URL. When a secret is configured, requests are signed with the
+// HMAC-SHA256 of their body, hex-encoded in the SignatureHeader as sha256=<signature>, so
+// receivers can authenticate them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cesto93/langgraphgo/events"
+)
+
+const (
+	// DefaultTimeout bounds the delivery of an event when no timeout is configured.
+	DefaultTimeout = 10 * time.Second
+
+	// SignatureHeader holds the signature of the signed requests.
+	SignatureHeader = "X-Signature-256"
+
+	// EventHeader holds the kind of the event of a request.
+	EventHeader = "X-Event-Kind"
+)
+
+// ErrStatus is returned when the webhook answers with a status other than 2xx.
+var ErrStatus = errors.New("webhook failed")
+
+// Config configures a Publisher.
+type Config struct {
+	// URL is the URL events are posted to.
+	URL string
+
+	// Secret, if set, is the key signing the requests.
+	Secret []byte
+
+	// Header holds additional headers of the requests, e.g. for authorization.
+	Header http.Header
+
+	// Timeout bounds the delivery of an event; DefaultTimeout is used when zero.
+	Timeout time.Duration
+
+	// HTTPClient posts the events; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+}
+
+// Publisher is an events.Publisher posting events to a webhook.
+type Publisher struct {
+	cfg Config
+}
+
+var _ events.Publisher = (*Publisher)(nil)
+
+// New returns a publisher posting to the webhook of the configuration.
+func New(cfg Config) *Publisher {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &Publisher{cfg: cfg}
+}
+
+// Publish posts an event.
+func (p *Publisher) Publish(ctx context.Context, e events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event %s: %w", e.ID, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range p.cfg.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(e.Kind))
+	if len(p.cfg.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(p.cfg.Secret, body))
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting event %s: %w", e.ID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: posting event %s: %s", ErrStatus, e.ID, resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a body with a secret, as set in the SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature, the value of the SignatureHeader of a request, is the
+// signature of its body with a secret.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
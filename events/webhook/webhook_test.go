@@ -0,0 +1,79 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/events/webhook"
+)
+
+func TestPublisher(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	event := events.Event{
+		ID: "1", Kind: events.KindRunFinished, RunID: "run", Status: events.StatusCompleted,
+		Duration: time.Second, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name   string
+		secret []byte
+		status int
+		err    string
+	}{
+		{name: "signed", secret: secret, status: http.StatusNoContent},
+		{name: "unsigned", status: http.StatusOK},
+		{name: "failed", status: http.StatusBadGateway, err: "webhook failed: posting event 1: 502 Bad Gateway"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "run.finished", r.Header.Get(webhook.EventHeader))
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+				signature := r.Header.Get(webhook.SignatureHeader)
+				if tc.secret == nil {
+					assert.Empty(t, signature)
+				} else {
+					assert.True(t, webhook.Verify(secret, body, signature))
+					assert.False(t, webhook.Verify([]byte("other"), body, signature))
+				}
+
+				var got events.Event
+				assert.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, event, got)
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			p := webhook.New(webhook.Config{
+				URL:    server.URL,
+				Secret: tc.secret,
+				Header: http.Header{"Authorization": {"Bearer token"}},
+			})
+			err := p.Publish(context.Background(), event)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, webhook.ErrStatus)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
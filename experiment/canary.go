@@ -0,0 +1,298 @@
+// Package experiment routes traffic between variants of a node to roll out changes safely.
+//
+// A Canary sends a fraction of the executions of a node to a new implementation, scores its
+// results online and, when the scores degrade, automatically reverts all the traffic to the
+// control implementation and fires an alert webhook:
+//
+//	canary := experiment.NewCanary(answerV1, answerV2, experiment.Options[State]{
+//		Fraction:         0.1,
+//		Scorers:          []graph.Scorer[State]{groundedness},
+//		MaxRejectionRate: 0.2,
+//		Webhook:          "https://alerts.example.com/hooks/canary",
+//	})
+//	g.AddNode("answer", canary.Node())
+package experiment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Default values of Options.
+const (
+	// DefaultWindow is the number of recent canary executions scored.
+	DefaultWindow = 100
+
+	// DefaultMinSamples is the number of canary executions scored before a rollback is possible.
+	DefaultMinSamples = 20
+
+	// WebhookTimeout bounds the delivery of the alert webhook.
+	WebhookTimeout = 10 * time.Second
+)
+
+// Variant identifies the implementation that executed a node.
+type Variant string
+
+const (
+	// VariantControl is the current implementation.
+	VariantControl Variant = "control"
+
+	// VariantCanary is the implementation being rolled out.
+	VariantCanary Variant = "canary"
+)
+
+// Options configures a Canary.
+type Options[T any] struct {
+	// Name identifies the canary in scores and alerts.
+	Name string
+
+	// Fraction is the share of executions routed to the canary, between 0 and 1.
+	Fraction float64
+
+	// Scorers score the states returned by the canary. A state rejected by a scorer counts as
+	// a failure, as does an error of the canary.
+	Scorers []graph.Scorer[T]
+
+	// Window is the number of recent canary executions considered; DefaultWindow is used when
+	// not positive.
+	Window int
+
+	// MinSamples is the number of canary executions needed before rolling back;
+	// DefaultMinSamples is used when not positive.
+	MinSamples int
+
+	// MaxRejectionRate is the share of failed canary executions in the window above which the
+	// canary is rolled back.
+	MaxRejectionRate float64
+
+	// Webhook, if set, is the URL the Rollback is posted to as JSON.
+	Webhook string
+
+	// HTTPClient posts the webhook; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+
+	// OnRollback, if set, is called when the canary is rolled back.
+	OnRollback func(Rollback)
+
+	// Rand returns numbers in [0, 1) deciding the routing; math/rand is used when nil.
+	Rand func() float64
+}
+
+// Rollback describes the rollback of a canary.
+type Rollback struct {
+	// Canary is the name of the canary.
+	Canary string `json:"canary"`
+
+	// RejectionRate is the share of failed canary executions in the window.
+	RejectionRate float64 `json:"rejection_rate"`
+
+	// Samples is the number of canary executions in the window.
+	Samples int `json:"samples"`
+
+	// Reasons counts the failures by scorer name, or "error" for canary errors.
+	Reasons map[string]int `json:"reasons"`
+
+	// At is the time of the rollback.
+	At time.Time `json:"at"`
+}
+
+// Canary routes the executions of a node between a control and a canary implementation.
+// It is safe for concurrent use.
+type Canary[T any] struct {
+	control, canary func(ctx context.Context, state T) (T, error)
+	opts            Options[T]
+
+	mu         sync.Mutex
+	outcomes   []outcome
+	next       int
+	rolledBack bool
+	counts     map[Variant]int
+}
+
+// outcome is the result of a canary execution: the scorer rejecting it, "error" for failed
+// executions, or empty for successful ones.
+type outcome string
+
+// NewCanary creates a Canary routing between control and canary.
+func NewCanary[T any](control, canary func(ctx context.Context, state T) (T, error), opts Options[T]) *Canary[T] {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = DefaultMinSamples
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.Float64
+	}
+	return &Canary[T]{
+		control: control,
+		canary:  canary,
+		opts:    opts,
+		counts:  make(map[Variant]int),
+	}
+}
+
+// Node returns the node function routing executions to the variants. When the canary fails,
+// the execution is retried with the control implementation, so failures are not visible to
+// users; states rejected by scorers are returned as is.
+func (c *Canary[T]) Node() func(ctx context.Context, state T) (T, error) {
+	return func(ctx context.Context, state T) (T, error) {
+		if c.pick() == VariantControl {
+			return c.control(ctx, state)
+		}
+
+		out, err := c.canary(ctx, graph.Clip(state))
+		if err != nil {
+			c.observe(ctx, "error")
+			return c.control(ctx, state)
+		}
+		c.observe(ctx, c.score(ctx, out))
+		return out, nil
+	}
+}
+
+// pick decides the variant of an execution.
+func (c *Canary[T]) pick() Variant {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	variant := VariantControl
+	if !c.rolledBack && c.opts.Rand() < c.opts.Fraction {
+		variant = VariantCanary
+	}
+	c.counts[variant]++
+	return variant
+}
+
+// score returns the name of the first scorer rejecting the state, or empty if none does.
+// Scorer errors are ignored.
+func (c *Canary[T]) score(ctx context.Context, state T) outcome {
+	for _, scorer := range c.opts.Scorers {
+		value, err := scorer.Score(ctx, graph.ScoreInput[T]{Node: c.opts.Name, State: state})
+		if err == nil && scorer.Accept != nil && !scorer.Accept(value) {
+			return outcome(scorer.Name)
+		}
+	}
+	return ""
+}
+
+// observe records the outcome of a canary execution and rolls back if the window degraded.
+func (c *Canary[T]) observe(ctx context.Context, o outcome) {
+	c.mu.Lock()
+	if c.rolledBack {
+		c.mu.Unlock()
+		return
+	}
+	if len(c.outcomes) < c.opts.Window {
+		c.outcomes = append(c.outcomes, o)
+	} else {
+		c.outcomes[c.next] = o
+		c.next = (c.next + 1) % c.opts.Window
+	}
+
+	reasons := make(map[string]int)
+	failures := 0
+	for _, o := range c.outcomes {
+		if o != "" {
+			reasons[string(o)]++
+			failures++
+		}
+	}
+	rate := float64(failures) / float64(len(c.outcomes))
+	if len(c.outcomes) < c.opts.MinSamples || rate <= c.opts.MaxRejectionRate {
+		c.mu.Unlock()
+		return
+	}
+	c.rolledBack = true
+	c.mu.Unlock()
+
+	rollback := Rollback{
+		Canary:        c.opts.Name,
+		RejectionRate: rate,
+		Samples:       len(c.outcomes),
+		Reasons:       reasons,
+		At:            time.Now().UTC(),
+	}
+	if c.opts.OnRollback != nil {
+		c.opts.OnRollback(rollback)
+	}
+	if c.opts.Webhook != "" {
+		go c.alert(context.WithoutCancel(ctx), rollback)
+	}
+}
+
+// alert posts the rollback to the webhook. Failures are logged, as there is no caller left to
+// report them to.
+func (c *Canary[T]) alert(ctx context.Context, rollback Rollback) {
+	if err := c.post(ctx, rollback); err != nil {
+		slog.Error("canary alert webhook failed", "canary", c.opts.Name, "error", err)
+	}
+}
+
+func (c *Canary[T]) post(ctx context.Context, rollback Rollback) error {
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(rollback)
+	if err != nil {
+		return fmt.Errorf("encoding rollback: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// RolledBack reports whether the canary was rolled back.
+func (c *Canary[T]) RolledBack() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rolledBack
+}
+
+// Counts returns how many executions were routed to each variant.
+func (c *Canary[T]) Counts() map[Variant]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[Variant]int, len(c.counts))
+	for variant, count := range c.counts {
+		counts[variant] = count
+	}
+	return counts
+}
+
+// Reset resumes routing to the canary after a rollback, with an empty window, e.g. once a
+// fixed version was deployed.
+func (c *Canary[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rolledBack = false
+	c.outcomes = nil
+	c.next = 0
+}
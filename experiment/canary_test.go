@@ -0,0 +1,135 @@
+package experiment_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/experiment"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func variant(name string) func(context.Context, []string) ([]string, error) {
+	return func(_ context.Context, state []string) ([]string, error) {
+		return append(state, name), nil
+	}
+}
+
+// quality rejects the states produced by the canary.
+var quality = graph.Scorer[[]string]{
+	Name: "quality",
+	Score: func(_ context.Context, in graph.ScoreInput[[]string]) (float64, error) {
+		if in.State[len(in.State)-1] == "canary" {
+			return 0, nil
+		}
+		return 1, nil
+	},
+	Accept: graph.AtLeast(0.5),
+}
+
+func TestCanaryRollback(t *testing.T) {
+	t.Parallel()
+
+	alerts := make(chan experiment.Rollback, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rollback experiment.Rollback
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&rollback))
+		alerts <- rollback
+	}))
+	defer server.Close()
+
+	var rollbacks []experiment.Rollback
+	canary := experiment.NewCanary(variant("control"), variant("canary"), experiment.Options[[]string]{
+		Name:             "answer",
+		Fraction:         0.5,
+		Scorers:          []graph.Scorer[[]string]{quality},
+		MinSamples:       3,
+		MaxRejectionRate: 0.5,
+		Webhook:          server.URL,
+		OnRollback:       func(r experiment.Rollback) { rollbacks = append(rollbacks, r) },
+		Rand:             func() float64 { return 0 },
+	})
+	node := canary.Node()
+
+	for i := 0; i < 3; i++ {
+		out, err := node(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"canary"}, out)
+	}
+	assert.True(t, canary.RolledBack())
+
+	out, err := node(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"control"}, out, "traffic reverts to control")
+	assert.Equal(t, map[experiment.Variant]int{experiment.VariantCanary: 3, experiment.VariantControl: 1}, canary.Counts())
+
+	require.Len(t, rollbacks, 1)
+	assert.Equal(t, "answer", rollbacks[0].Canary)
+	assert.Equal(t, 1.0, rollbacks[0].RejectionRate)
+	assert.Equal(t, 3, rollbacks[0].Samples)
+	assert.Equal(t, map[string]int{"quality": 3}, rollbacks[0].Reasons)
+
+	select {
+	case alert := <-alerts:
+		assert.Equal(t, rollbacks[0].Reasons, alert.Reasons)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+
+	canary.Reset()
+	out, err = node(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"canary"}, out)
+	assert.False(t, canary.RolledBack())
+}
+
+func TestCanaryErrorFallsBackToControl(t *testing.T) {
+	t.Parallel()
+
+	failing := func(context.Context, []string) ([]string, error) { return nil, errors.New("boom") }
+	canary := experiment.NewCanary(variant("control"), failing, experiment.Options[[]string]{
+		Fraction:         1,
+		MinSamples:       2,
+		MaxRejectionRate: 0.9,
+	})
+	node := canary.Node()
+
+	out, err := node(context.Background(), []string{"input"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"input", "control"}, out)
+	assert.False(t, canary.RolledBack(), "not enough samples")
+
+	_, err = node(context.Background(), nil)
+	require.NoError(t, err)
+	assert.True(t, canary.RolledBack())
+}
+
+func TestCanaryHealthy(t *testing.T) {
+	t.Parallel()
+
+	draws := []float64{0.05, 0.5, 0.05, 0.9}
+	canary := experiment.NewCanary(variant("control"), variant("canary-ok"), experiment.Options[[]string]{
+		Fraction:         0.1,
+		Scorers:          []graph.Scorer[[]string]{quality},
+		MinSamples:       1,
+		MaxRejectionRate: 0,
+		Rand: func() float64 {
+			d := draws[0]
+			draws = draws[1:]
+			return d
+		},
+	})
+	node := canary.Node()
+	for range 4 {
+		_, err := node(context.Background(), nil)
+		require.NoError(t, err)
+	}
+	assert.False(t, canary.RolledBack())
+	assert.Equal(t, map[experiment.Variant]int{experiment.VariantCanary: 2, experiment.VariantControl: 2}, canary.Counts())
+}
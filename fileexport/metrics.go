@@ -0,0 +1,69 @@
+package fileexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/cesto93/langgraphgo/analytics"
+)
+
+// MetricsKind is the kind of the files written by MetricsStore.
+const MetricsKind = "metrics"
+
+// Snapshot is the record of the statistics flushed at once in the metrics files.
+type Snapshot struct {
+	// At is the time the statistics were flushed.
+	At time.Time `json:"at"`
+
+	// Stats are the statistics counted since the previous flush, to be added to the totals.
+	Stats []analytics.Stats `json:"stats"`
+}
+
+// MetricsStore is an analytics.Store appending the statistics it is given to the metrics files
+// of a directory, as a Snapshot per call to Add.
+type MetricsStore struct {
+	dir string
+	w   *Writer
+}
+
+var _ analytics.Store = (*MetricsStore)(nil)
+
+// NewMetricsStore returns a MetricsStore writing to dir.
+func NewMetricsStore(dir string, opts Options) (*MetricsStore, error) {
+	w, err := NewWriter(dir, MetricsKind, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricsStore{dir: dir, w: w}, nil
+}
+
+// Add writes the statistics as a snapshot.
+func (m *MetricsStore) Add(_ context.Context, stats []analytics.Stats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return m.w.Write(Snapshot{At: time.Now().UTC(), Stats: stats})
+}
+
+// Query returns the statistics matching q summed over the snapshots of the files, which only
+// cover the period of the files kept.
+func (m *MetricsStore) Query(ctx context.Context, q analytics.Query) ([]analytics.Stats, error) {
+	var store analytics.MemoryStore
+	if err := LoadStats(ctx, m.dir, &store); err != nil {
+		return nil, err
+	}
+	return store.Query(ctx, q)
+}
+
+// Close closes the current file.
+func (m *MetricsStore) Close() error {
+	return m.w.Close()
+}
+
+// LoadStats adds the statistics of the snapshots of the metrics files of dir to store, e.g. an
+// analytics.MemoryStore served by analytics.NewHandler on a machine outside the deployment.
+func LoadStats(ctx context.Context, dir string, store analytics.Store) error {
+	return Read(dir, MetricsKind, func(s Snapshot) error {
+		return store.Add(ctx, s.Stats)
+	})
+}
@@ -0,0 +1,46 @@
+package fileexport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/analytics"
+	"github.com/cesto93/langgraphgo/fileexport"
+)
+
+func TestMetricsStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := fileexport.NewMetricsStore(dir, fileexport.Options{})
+	require.NoError(t, err)
+
+	support := analytics.Key{Day: "2024-05-01", Graph: "support"}
+	billing := analytics.Key{Day: "2024-05-01", Graph: "billing"}
+	var latency analytics.Latency
+	latency.Observe(time.Second)
+	require.NoError(t, store.Add(ctx, []analytics.Stats{
+		{Key: support, Runs: 2, Completed: 1, Failed: 1, Latency: latency},
+		{Key: billing, Runs: 1, Completed: 1},
+	}))
+	require.NoError(t, store.Add(ctx, nil))
+	require.NoError(t, store.Add(ctx, []analytics.Stats{{Key: support, Runs: 1, Interrupted: 1}}))
+
+	stats, err := store.Query(ctx, analytics.Query{Graph: "support"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, analytics.Stats{Key: support, Runs: 3, Completed: 1, Interrupted: 1, Failed: 1, Latency: latency}, stats[0])
+	require.NoError(t, store.Close())
+
+	// The files are loaded elsewhere, e.g. to be served by analytics.NewHandler.
+	var loaded analytics.MemoryStore
+	require.NoError(t, fileexport.LoadStats(ctx, dir, &loaded))
+	all, err := loaded.Query(ctx, analytics.Query{})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
@@ -0,0 +1,140 @@
+package fileexport
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracesKind is the kind of the files written by SpanExporter.
+const TracesKind = "traces"
+
+// Span is the record of a span in the traces files.
+type Span struct {
+	// TraceID is the hex-encoded ID of the trace of the span.
+	TraceID string `json:"trace_id"`
+
+	// SpanID is the hex-encoded ID of the span.
+	SpanID string `json:"span_id"`
+
+	// ParentSpanID is the hex-encoded ID of the parent of the span; empty for root spans.
+	ParentSpanID string `json:"parent_span_id,omitempty"`
+
+	// Name is the name of the span, e.g. "graph.invoke" or the name of a node.
+	Name string `json:"name"`
+
+	// Start is the time the span started.
+	Start time.Time `json:"start"`
+
+	// End is the time the span ended.
+	End time.Time `json:"end"`
+
+	// Status is the status code of the span, "Error" or "Ok"; empty if unset.
+	Status string `json:"status,omitempty"`
+
+	// StatusMessage describes the error of the span.
+	StatusMessage string `json:"status_message,omitempty"`
+
+	// Attributes are the attributes of the span.
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	// Events are the events of the span.
+	Events []SpanEvent `json:"events,omitempty"`
+}
+
+// Duration returns the duration of the span.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SpanEvent is the record of an event of a span.
+type SpanEvent struct {
+	// Name is the name of the event, e.g. "interrupted".
+	Name string `json:"name"`
+
+	// Time is the time of the event.
+	Time time.Time `json:"time"`
+
+	// Attributes are the attributes of the event.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// SpanExporter is an OpenTelemetry span exporter writing the spans to the traces files of a
+// directory. Register it with a batching span processor.
+type SpanExporter struct {
+	w *Writer
+}
+
+var _ sdktrace.SpanExporter = (*SpanExporter)(nil)
+
+// NewSpanExporter returns a SpanExporter writing to dir.
+func NewSpanExporter(dir string, opts Options) (*SpanExporter, error) {
+	w, err := NewWriter(dir, TracesKind, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SpanExporter{w: w}, nil
+}
+
+// ExportSpans writes the spans.
+func (e *SpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	records := make([]any, len(spans))
+	for i, s := range spans {
+		records[i] = spanOf(s)
+	}
+	return e.w.Write(records...)
+}
+
+// Shutdown closes the current file.
+func (e *SpanExporter) Shutdown(context.Context) error {
+	return e.w.Close()
+}
+
+// ReadSpans returns the spans of the traces files of dir, in the order they were exported.
+func ReadSpans(dir string) ([]Span, error) {
+	var spans []Span
+	err := Read(dir, TracesKind, func(s Span) error {
+		spans = append(spans, s)
+		return nil
+	})
+	return spans, err
+}
+
+func spanOf(s sdktrace.ReadOnlySpan) Span {
+	span := Span{
+		TraceID:    s.SpanContext().TraceID().String(),
+		SpanID:     s.SpanContext().SpanID().String(),
+		Name:       s.Name(),
+		Start:      s.StartTime(),
+		End:        s.EndTime(),
+		Attributes: attributesOf(s.Attributes()),
+	}
+	if s.Parent().HasSpanID() {
+		span.ParentSpanID = s.Parent().SpanID().String()
+	}
+	if status := s.Status(); status.Code != codes.Unset {
+		span.Status, span.StatusMessage = status.Code.String(), status.Description
+	}
+	for _, event := range s.Events() {
+		span.Events = append(span.Events, SpanEvent{
+			Name:       event.Name,
+			Time:       event.Time,
+			Attributes: attributesOf(event.Attributes),
+		})
+	}
+	return span
+}
+
+func attributesOf(attrs []attribute.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
@@ -0,0 +1,48 @@
+package fileexport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/cesto93/langgraphgo/fileexport"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestSpanExporter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	exporter, err := fileexport.NewSpanExporter(dir, fileexport.Options{})
+	require.NoError(t, err)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	g := graph.NewMessageGraph[[]string]("search")
+	g.AddNode("search", func(_ context.Context, state []string) ([]string, error) {
+		return state, errors.New("search API down")
+	})
+	g.SetFinishPoint("search")
+	runnable, err := g.Compile(graph.WithTracerProvider(tp))
+	require.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.Error(t, err)
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	spans, err := fileexport.ReadSpans(dir)
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+	node, invoke := spans[0], spans[1]
+	assert.Equal(t, "search", node.Name)
+	assert.Equal(t, "graph.invoke", invoke.Name)
+	assert.Equal(t, invoke.TraceID, node.TraceID)
+	assert.Equal(t, invoke.SpanID, node.ParentSpanID)
+	assert.Empty(t, invoke.ParentSpanID)
+	assert.Equal(t, "Error", node.Status)
+	assert.Contains(t, node.StatusMessage, "search API down")
+	assert.Equal(t, "search", node.Attributes[string(graph.AttributeNode)])
+	assert.GreaterOrEqual(t, invoke.Duration(), node.Duration())
+}
@@ -0,0 +1,225 @@
+// Package fileexport exports traces and metrics to local JSON Lines files, for deployments
+// without access to an OTLP collector or a Prometheus server, such as air-gapped ones. The files
+// are rotated by size and can be copied out and loaded later with ReadSpans and LoadStats.
+//
+// A SpanExporter writes the spans of an OpenTelemetry tracer provider, and a MetricsStore
+// is an analytics.Store writing the statistics an analytics.Aggregator flushes:
+//
+//	spans, err := fileexport.NewSpanExporter("/var/lib/agent/telemetry", fileexport.Options{})
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spans))
+//	runnable, err := g.Compile(graph.WithTracerProvider(tp))
+//
+//	metrics, err := fileexport.NewMetricsStore("/var/lib/agent/telemetry", fileexport.Options{})
+//	aggregator := analytics.NewAggregator(metrics)
+//	go aggregator.Run(ctx, time.Minute)
+package fileexport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the size, in bytes, of a file beyond which it is rotated.
+	DefaultMaxSize = 64 << 20
+
+	// DefaultMaxFiles is the number of files of a kind kept.
+	DefaultMaxFiles = 10
+
+	// fileTime is the layout of the times in file names, which sort them chronologically.
+	fileTime = "20060102T150405.000000000Z"
+)
+
+var (
+	// ErrClosed is returned when writing to a closed exporter.
+	ErrClosed = errors.New("exporter closed")
+
+	// ErrCorruptFile is returned when reading a line of a file that is not a valid record.
+	ErrCorruptFile = errors.New("corrupt telemetry file")
+)
+
+// Options configures the files written by the exporters.
+type Options struct {
+	// MaxSize is the size, in bytes, beyond which a file is rotated; DefaultMaxSize if 0.
+	MaxSize int64
+
+	// MaxFiles is the number of files kept, including the one being written: the oldest are
+	// removed on rotation. DefaultMaxFiles if 0; negative keeps every file.
+	MaxFiles int
+}
+
+// Writer appends records to the JSON Lines files of a kind in a directory, named after the kind
+// and the time they were created, e.g. traces-20240501T120000.000000000Z.jsonl. It starts a
+// new file when the current one would exceed the maximum size. It is safe for concurrent use.
+type Writer struct {
+	dir  string
+	kind string
+	opts Options
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// NewWriter returns a Writer of the files of kind in dir, creating dir if needed.
+func NewWriter(dir, kind string, opts Options) (*Writer, error) {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	if opts.MaxFiles == 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating telemetry directory: %w", err)
+	}
+	return &Writer{dir: dir, kind: kind, opts: opts}, nil
+}
+
+// Write appends the records, one JSON document per line. Records are never split across
+// files.
+func (w *Writer) Write(records ...any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding %s record: %w", w.kind, err)
+		}
+		line = append(line, '\n')
+		if w.file != nil && w.size+int64(len(line)) > w.opts.MaxSize {
+			if err := w.rotate(); err != nil {
+				return err
+			}
+		}
+		if w.file == nil {
+			if err := w.open(); err != nil {
+				return err
+			}
+		}
+		n, err := w.file.Write(line)
+		w.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("writing %s record: %w", w.kind, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the current file. Writing afterwards fails with ErrClosed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open creates a new file and removes the oldest ones beyond the maximum number of files.
+func (w *Writer) open() error {
+	name := filepath.Join(w.dir, fmt.Sprintf("%s-%s.jsonl", w.kind, time.Now().UTC().Format(fileTime)))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("creating %s file: %w", w.kind, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("creating %s file: %w", w.kind, err)
+	}
+	w.file, w.size = file, info.Size()
+
+	if w.opts.MaxFiles < 0 {
+		return nil
+	}
+	files, err := Files(w.dir, w.kind)
+	if err != nil {
+		return err
+	}
+	for _, old := range files[:max(len(files)-w.opts.MaxFiles, 0)] {
+		if err := os.Remove(old); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing rotated %s file: %w", w.kind, err)
+		}
+	}
+	return nil
+}
+
+// rotate closes the current file, so the next record is written to a new one.
+func (w *Writer) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("rotating %s file: %w", w.kind, err)
+	}
+	return nil
+}
+
+// Files returns the paths of the files of kind in dir, oldest first.
+func Files(dir, kind string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, kind+"-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// Read decodes the records of the files of kind in dir, oldest first, and calls fn with every
+// one. The last line of a file is skipped when incomplete, as when the process writing it
+// crashed or is still writing it; other invalid lines fail with ErrCorruptFile.
+func Read[R any](dir, kind string, fn func(R) error) error {
+	files, err := Files(dir, kind)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := readFile(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile[R any](name string, fn func(R) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// The line is empty or incomplete.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		var record R
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("%w: %s:%d: %w", ErrCorruptFile, name, n, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package fileexport_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/fileexport"
+)
+
+type record struct {
+	N       int    `json:"n"`
+	Padding string `json:"padding"`
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	// Every record is 30 bytes long, so every file holds three records.
+	padding := strings.Repeat("x", 9)
+	testCases := []struct {
+		name     string
+		maxFiles int
+		files    int
+		first    int
+	}{
+		{name: "default", maxFiles: 0, files: 4, first: 0},
+		{name: "two files", maxFiles: 2, files: 2, first: 6},
+		{name: "every file", maxFiles: -1, files: 4, first: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "telemetry")
+			w, err := fileexport.NewWriter(dir, "test", fileexport.Options{MaxSize: 90, MaxFiles: tc.maxFiles})
+			require.NoError(t, err)
+			for n := range 10 {
+				require.NoError(t, w.Write(record{N: n, Padding: padding}))
+			}
+			require.NoError(t, w.Close())
+			require.ErrorIs(t, w.Write(record{}), fileexport.ErrClosed)
+
+			files, err := fileexport.Files(dir, "test")
+			require.NoError(t, err)
+			assert.Len(t, files, tc.files)
+
+			var read []int
+			require.NoError(t, fileexport.Read(dir, "test", func(r record) error {
+				read = append(read, r.N)
+				return nil
+			}))
+			var expected []int
+			for n := tc.first; n < 10; n++ {
+				expected = append(expected, n)
+			}
+			assert.Equal(t, expected, read)
+		})
+	}
+}
+
+func TestReadIncomplete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "test-20240501T120000.000000000Z.jsonl")
+	require.NoError(t, os.WriteFile(name, []byte("{\"n\": 1}\n{\"n\": 2}\n{\"n\": "), 0o600))
+
+	var read []int
+	require.NoError(t, fileexport.Read(dir, "test", func(r record) error {
+		read = append(read, r.N)
+		return nil
+	}))
+	assert.Equal(t, []int{1, 2}, read)
+
+	require.NoError(t, os.WriteFile(name, []byte("{\"n\": 1}\nnot json\n"), 0o600))
+	err := fileexport.Read(dir, "test", func(record) error { return nil })
+	require.ErrorIs(t, err, fileexport.ErrCorruptFile)
+	assert.ErrorContains(t, err, ".jsonl:2")
+}
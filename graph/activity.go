@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+// Activity is what a run is busy with, reported by EventActivity events so chat frontends can
+// render indicators such as "thinking…" or "searching the web…" without conventions of their
+// own.
+type Activity string
+
+const (
+	// ActivityThinking is reported when a node starts.
+	ActivityThinking Activity = "thinking"
+
+	// ActivityToolRunning is reported by nodes calling a tool, with the name of the tool; see
+	// StreamActivity.
+	ActivityToolRunning Activity = "tool_running"
+
+	// ActivityAwaitingInput is reported when a run pauses at an interrupt, with the node of the
+	// interrupt, before EventEnd.
+	ActivityAwaitingInput Activity = "awaiting_input"
+)
+
+type activityKey struct{}
+
+// WithActivity returns a context making the streams of the invocations with it emit
+// EventActivity events: ActivityThinking when a node starts, the activities nodes report with
+// StreamActivity, and ActivityAwaitingInput when the run pauses. Without it, streams have no
+// activity events.
+func WithActivity(ctx context.Context) context.Context {
+	return context.WithValue(ctx, activityKey{}, true)
+}
+
+func activityEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(activityKey{}).(bool)
+	return enabled
+}
+
+type activitySendKey struct{}
+
+// StreamActivity reports an activity of the node running with ctx to the stream of the
+// invocation as an EventActivity event, e.g. ActivityToolRunning with the name of the tool
+// before calling it. It does nothing unless the invocation is streamed with WithActivity, and
+// returns the error of the context of the stream once it is done.
+func StreamActivity(ctx context.Context, activity Activity, tool string) error {
+	send, _ := ctx.Value(activitySendKey{}).(func(Activity, string) error)
+	if send == nil {
+		return nil
+	}
+	return send(activity, tool)
+}
+
+// withActivity returns the context of a node making StreamActivity emit activity events to the
+// stream, if any emits them, after the chunks held by flush, and reports that the node is
+// thinking.
+func withActivity[T any](ctx context.Context, s *stream[T], index int, node, branch string, flush func() error) context.Context {
+	if s == nil || !s.activity {
+		return ctx
+	}
+	send := func(activity Activity, tool string) error {
+		if err := flush(); err != nil {
+			return err
+		}
+		event := StreamEvent[T]{Kind: EventActivity, Step: index, Node: node, Branch: branch, Activity: activity, Tool: tool}
+		select {
+		case s.events <- event:
+			return nil
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+	_ = send(ActivityThinking, "")
+	return context.WithValue(ctx, activitySendKey{}, send)
+}
+
+// awaitingInput emits the ActivityAwaitingInput event of a run that ended with err, if it
+// paused at an interrupt.
+func (s *stream[T]) awaitingInput(err error) {
+	var interrupt *Interrupt
+	if !s.activity || !errors.As(err, &interrupt) {
+		return
+	}
+	s.emit(StreamEvent[T]{Kind: EventActivity, Step: interrupt.Step, Node: interrupt.Node, Activity: ActivityAwaitingInput})
+}
@@ -0,0 +1,76 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestStreamActivity(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("search")
+	g.AddNode("search", func(ctx context.Context, state []string) ([]string, error) {
+		if err := graph.StreamActivity(ctx, graph.ActivityToolRunning, "web"); err != nil {
+			return state, err
+		}
+		return graph.AppendMessages(state, "search"), nil
+	})
+	g.AddNode("send", appendNode("send"))
+	g.AddEdge("search", "send")
+	g.SetFinishPoint("send")
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()), graph.WithInterruptBefore("send"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		expected []graph.StreamEvent[[]string]
+	}{
+		{
+			name: "without activity",
+			ctx:  context.Background(),
+			expected: []graph.StreamEvent[[]string]{
+				{Kind: graph.EventNode, Node: "search", State: []string{"search"}},
+				{Kind: graph.EventRoute, Node: "search", Edges: []graph.Edge{{From: "search", To: "send"}}},
+			},
+		},
+		{
+			name: "with activity",
+			ctx:  graph.WithActivity(context.Background()),
+			expected: []graph.StreamEvent[[]string]{
+				{Kind: graph.EventActivity, Node: "search", Activity: graph.ActivityThinking},
+				{Kind: graph.EventActivity, Node: "search", Activity: graph.ActivityToolRunning, Tool: "web"},
+				{Kind: graph.EventNode, Node: "search", State: []string{"search"}},
+				{Kind: graph.EventRoute, Node: "search", Edges: []graph.Edge{{From: "search", To: "send"}}},
+				{Kind: graph.EventActivity, Step: 1, Node: "send", Activity: graph.ActivityAwaitingInput},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			events, err := runnable.Stream(graph.WithThreadID(tc.ctx, tc.name), nil)
+			require.NoError(t, err)
+			all := collect(t, events)
+			require.NotEmpty(t, all)
+			end := all[len(all)-1]
+			assert.Equal(t, graph.EventEnd, end.Kind)
+			require.ErrorIs(t, end.Err, graph.ErrInterrupted)
+			assert.Equal(t, tc.expected, all[:len(all)-1])
+		})
+	}
+}
+
+func TestStreamActivityWithoutStream(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, graph.StreamActivity(graph.WithActivity(context.Background()), graph.ActivityToolRunning, "web"))
+}
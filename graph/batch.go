@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// BatchOption configures Batch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	context     func(ctx context.Context, i int) context.Context
+}
+
+// WithBatchConcurrency sets the number of inputs Batch runs at once; runtime.GOMAXPROCS(0) by
+// default, and one when not positive.
+func WithBatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithBatchContext sets the function deriving the context of the invocation of every input from
+// the context of Batch, given the index of the input, e.g. to run every input in its own
+// thread with WithThreadID or with its own run ID with WithRunID.
+func WithBatchContext(f func(ctx context.Context, i int) context.Context) BatchOption {
+	return func(o *batchOptions) {
+		o.context = f
+	}
+}
+
+// Batch invokes the graph on every input as Invoke does, running several inputs concurrently,
+// e.g. to evaluate a graph offline on a dataset or to process records in bulk. The invocations
+// share the compiled graph. It returns the output and the error of every input at its index:
+// errors.Join(errs...) is nil when every invocation succeeded. Once ctx is done, the inputs not
+// started yet are not run and fail with the error of ctx.
+func (r *Runnable[T]) Batch(ctx context.Context, inputs []T, opts ...BatchOption) ([]T, []error) {
+	o := batchOptions{concurrency: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	outputs := make([]T, len(inputs))
+	errs := make([]error, len(inputs))
+	slots := make(chan struct{}, o.concurrency)
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			outputs[i], errs[i] = input, err
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, input T) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			runCtx := ctx
+			if o.context != nil {
+				runCtx = o.context(ctx, i)
+			}
+			outputs[i], errs[i] = r.Invoke(runCtx, input)
+		}(i, input)
+	}
+	wg.Wait()
+	return outputs, errs
+}
@@ -0,0 +1,100 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	g := graph.NewMessageGraph[int]("square")
+	g.AddNode("square", func(_ context.Context, n int) (int, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if current <= p || peak.CompareAndSwap(p, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if n < 0 {
+			return n, errors.New("negative")
+		}
+		return n * n, nil
+	})
+	g.SetFinishPoint("square")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	inputs := []int{1, 2, -3, 4, 5, 6, 7, 8}
+	outputs, errs := runnable.Batch(context.Background(), inputs, graph.WithBatchConcurrency(3))
+	assert.Equal(t, []int{1, 4, -3, 16, 25, 36, 49, 64}, outputs)
+	require.Len(t, errs, len(inputs))
+	for i, err := range errs {
+		if i == 2 {
+			assert.EqualError(t, err, "error in node square: negative")
+			continue
+		}
+		assert.NoError(t, err)
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+
+	outputs, errs = runnable.Batch(context.Background(), nil)
+	assert.Empty(t, outputs)
+	assert.NoError(t, errors.Join(errs...))
+}
+
+func TestBatchContext(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", appendNode("a"))
+	g.SetFinishPoint("a")
+	cp := checkpoint.NewMemory()
+	runnable, err := g.Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+
+	outputs, errs := runnable.Batch(context.Background(), [][]string{{"x"}, {"y"}}, graph.WithBatchContext(func(ctx context.Context, i int) context.Context {
+		return graph.WithThreadID(ctx, fmt.Sprintf("t%d", i))
+	}))
+	require.NoError(t, errors.Join(errs...))
+	assert.Equal(t, [][]string{{"x", "a"}, {"y", "a"}}, outputs)
+	for i, expected := range outputs {
+		snapshot, err := runnable.GetState(context.Background(), fmt.Sprintf("t%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, expected, snapshot.State)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := graph.NewMessageGraph[int]("cancel")
+	g.AddNode("cancel", func(_ context.Context, n int) (int, error) {
+		cancel()
+		return n, nil
+	})
+	g.SetFinishPoint("cancel")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	outputs, errs := runnable.Batch(ctx, []int{1, 2, 3}, graph.WithBatchConcurrency(1))
+	assert.Equal(t, []int{1, 2, 3}, outputs)
+	require.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], context.Canceled)
+	assert.ErrorIs(t, errs[2], context.Canceled)
+}
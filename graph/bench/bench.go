@@ -0,0 +1,200 @@
+// Package bench provides representative graphs and helpers to benchmark the graph engine.
+// The scenarios can be used by downstream users as a performance regression harness:
+//
+//	func BenchmarkEngine(b *testing.B) {
+//		for _, s := range bench.Scenarios() {
+//			b.Run(s.Name, func(b *testing.B) { bench.Run(b, s) })
+//		}
+//	}
+package bench
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// State is the state type used by the benchmark graphs.
+type State []string
+
+// Scenario is a compiled benchmark graph.
+type Scenario struct {
+	// Name identifies the scenario in benchmark output.
+	Name string
+
+	// Runnable is the compiled graph.
+	Runnable *graph.Runnable[State]
+
+	// Steps is the number of node executions performed by a single invocation.
+	Steps int
+}
+
+// step is a cheap node function appending one message to the state.
+func step(name string) func(ctx context.Context, state State) (State, error) {
+	return func(_ context.Context, state State) (State, error) {
+		return append(state, name), nil
+	}
+}
+
+// Linear returns a scenario executing n nodes in sequence.
+func Linear(n int) (Scenario, error) {
+	g := graph.NewMessageGraph[State]("node-0")
+	for i := range n {
+		name := fmt.Sprintf("node-%d", i)
+		g.AddNode(name, step(name))
+		next := graph.END
+		if i < n-1 {
+			next = fmt.Sprintf("node-%d", i+1)
+		}
+		g.AddEdge(name, next)
+	}
+
+	r, err := g.Compile()
+	return Scenario{Name: fmt.Sprintf("linear-%d", n), Runnable: r, Steps: n}, err
+}
+
+// AgentLoop returns a scenario alternating an agent node and a tools node for the given
+// number of iterations before a final agent answer.
+// The loop is unrolled to measure static edges only; see Router for a loop with a router.
+func AgentLoop(iterations int) (Scenario, error) {
+	g := graph.NewMessageGraph[State]("agent-0")
+	for i := range iterations {
+		agent, tools := fmt.Sprintf("agent-%d", i), fmt.Sprintf("tools-%d", i)
+		g.AddNode(agent, step("agent"))
+		g.AddNode(tools, step("tools"))
+		g.AddEdge(agent, tools)
+		g.AddEdge(tools, fmt.Sprintf("agent-%d", i+1))
+	}
+	final := fmt.Sprintf("agent-%d", iterations)
+	g.AddNode(final, step("answer"))
+	g.AddEdge(final, graph.END)
+
+	r, err := g.Compile()
+	return Scenario{Name: fmt.Sprintf("agent-loop-%d", iterations), Runnable: r, Steps: 2*iterations + 1}, err
+}
+
+// Router returns a scenario looping the given number of iterations between an agent node and
+// one of the given number of tool nodes, picked by a router among its declared routes, before
+// a final agent answer: the dispatch of agents choosing among many tools.
+func Router(tools, iterations int) (Scenario, error) {
+	names := make([]string, tools)
+	g := graph.NewMessageGraph[State]("agent")
+	g.AddNode("agent", step("agent"))
+	for i := range names {
+		names[i] = fmt.Sprintf("tool-%d", i)
+		g.AddNode(names[i], step("tool"))
+		g.AddEdge(names[i], "agent")
+	}
+	g.AddConditionalEdge("agent", func(_ context.Context, state State) (string, error) {
+		if len(state) >= 2*iterations {
+			return graph.END, nil
+		}
+		// The tools are picked from the last, the most expensive to look up by scanning.
+		return names[tools-1-len(state)/2%tools], nil
+	}, append(names, graph.END)...)
+
+	r, err := g.Compile()
+	return Scenario{Name: fmt.Sprintf("router-%d", tools), Runnable: r, Steps: 2*iterations + 1}, err
+}
+
+// WideFanOut returns a scenario running width branches concurrently and concatenating their results.
+func WideFanOut(width int) (Scenario, error) {
+	branches := make([]graph.Branch[State], width)
+	for i := range branches {
+		name := fmt.Sprintf("branch-%d", i)
+		branches[i] = graph.Branch[State]{
+			Name: name,
+			Function: func(_ context.Context, _ State) (State, error) {
+				return State{name}, nil
+			},
+		}
+	}
+
+	join := func(_ context.Context, state State, results []graph.BranchResult[State]) (State, error) {
+		merged := make(State, len(state), len(state)+len(results)+1)
+		copy(merged, state)
+		for _, r := range results {
+			merged = append(merged, r.State...)
+		}
+		return append(merged, "join"), nil
+	}
+
+	g := graph.NewMessageGraph[State]("fanout")
+	g.AddNode("fanout", graph.FanOut(graph.FailFast, join, branches...))
+	g.AddEdge("fanout", graph.END)
+
+	r, err := g.Compile()
+	return Scenario{Name: fmt.Sprintf("fan-out-%d", width), Runnable: r, Steps: width + 1}, err
+}
+
+// DeepRecursion returns a scenario where each graph invokes a nested graph from its only node,
+// depth levels deep.
+func DeepRecursion(depth int) (Scenario, error) {
+	var inner *graph.Runnable[State]
+	for level := range depth {
+		name := fmt.Sprintf("level-%d", level)
+		fn := step(name)
+		if inner != nil {
+			nested := inner
+			fn = func(ctx context.Context, state State) (State, error) {
+				return nested.Invoke(ctx, append(state, name))
+			}
+		}
+
+		g := graph.NewMessageGraph[State](name)
+		g.AddNode(name, fn)
+		g.AddEdge(name, graph.END)
+
+		r, err := g.Compile()
+		if err != nil {
+			return Scenario{}, err
+		}
+		inner = r
+	}
+
+	return Scenario{Name: fmt.Sprintf("recursion-%d", depth), Runnable: inner, Steps: depth}, nil
+}
+
+// Scenarios returns the standard set of benchmark scenarios.
+// It panics if a scenario fails to compile, which indicates a bug in the engine.
+func Scenarios() []Scenario {
+	builders := []func() (Scenario, error){
+		func() (Scenario, error) { return Linear(10) },
+		func() (Scenario, error) { return Linear(100) },
+		func() (Scenario, error) { return AgentLoop(10) },
+		func() (Scenario, error) { return Router(500, 10) },
+		func() (Scenario, error) { return WideFanOut(32) },
+		func() (Scenario, error) { return DeepRecursion(32) },
+	}
+
+	scenarios := make([]Scenario, 0, len(builders))
+	for _, build := range builders {
+		s, err := build()
+		if err != nil {
+			panic(fmt.Sprintf("bench: compiling scenario: %v", err))
+		}
+		scenarios = append(scenarios, s)
+	}
+	return scenarios
+}
+
+// Run benchmarks the scenario, reporting allocations and executed steps per second.
+func Run(b *testing.B, s Scenario) {
+	b.Helper()
+	b.ReportAllocs()
+
+	ctx := context.Background()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := s.Runnable.Invoke(ctx, nil); err != nil {
+			b.Fatalf("invoking %s: %v", s.Name, err)
+		}
+	}
+	b.StopTimer()
+
+	if elapsed := b.Elapsed().Seconds(); elapsed > 0 {
+		b.ReportMetric(float64(s.Steps*b.N)/elapsed, "steps/s")
+	}
+}
@@ -0,0 +1,32 @@
+package bench_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph/bench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarios(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range bench.Scenarios() {
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := s.Runnable.Invoke(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Len(t, output, s.Steps)
+		})
+	}
+}
+
+func BenchmarkScenarios(b *testing.B) {
+	for _, s := range bench.Scenarios() {
+		b.Run(s.Name, func(b *testing.B) {
+			bench.Run(b, s)
+		})
+	}
+}
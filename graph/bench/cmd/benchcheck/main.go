@@ -0,0 +1,54 @@
+// Command benchcheck compares two outputs of go test -bench and exits with status 1 when a
+// metric of the current run regressed against the baseline by more than the threshold:
+//
+//	benchcheck -threshold=10 old.txt bench_output.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cesto93/langgraphgo/graph/bench"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 10, "regression `percent` above which the check fails")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: benchcheck [-threshold percent] base current")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	regressions, err := compare(flag.Arg(0), flag.Arg(1), *threshold/100)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "benchcheck:", err)
+		os.Exit(2)
+	}
+	for _, r := range regressions {
+		fmt.Println(r)
+	}
+	if len(regressions) > 0 {
+		fmt.Fprintf(os.Stderr, "benchcheck: %d metrics regressed by more than %g%%\n", len(regressions), *threshold)
+		os.Exit(1)
+	}
+}
+
+// compare compares the outputs in the files base and current.
+func compare(base, current string, threshold float64) ([]bench.Regression, error) {
+	b, err := os.Open(base)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+	c, err := os.Open(current)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return bench.Compare(b, c, threshold)
+}
@@ -0,0 +1,116 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Regression is a metric of a benchmark that got worse than in the baseline by more than the
+// threshold of Compare.
+type Regression struct {
+	// Benchmark is the full name of the benchmark, e.g. BenchmarkScenarios/linear-10-8.
+	Benchmark string
+
+	// Unit is the unit of the metric, e.g. ns/op.
+	Unit string
+
+	// Base and Current are the medians of the metric in the baseline and in the current run.
+	Base, Current float64
+}
+
+// Change returns the relative change of the metric, positive when it got worse.
+func (r Regression) Change() float64 {
+	if rate(r.Unit) {
+		return (r.Base - r.Current) / r.Base
+	}
+	return (r.Current - r.Base) / r.Base
+}
+
+// String formats the regression for reports.
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %g -> %g (%+.1f%%)", r.Benchmark, r.Unit, r.Base, r.Current, 100*r.Change())
+}
+
+// Compare reads the outputs of go test -bench of a baseline and of the current run, and returns
+// the metrics whose median got worse by more than threshold, a fraction of the baseline: 0.1
+// reports regressions above 10%. Metrics measured per second are better when higher, all the
+// others when lower. Benchmarks and metrics missing from either output are ignored. The
+// regressions are sorted by benchmark and unit.
+func Compare(base, current io.Reader, threshold float64) ([]Regression, error) {
+	before, err := parseResults(base)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	after, err := parseResults(current)
+	if err != nil {
+		return nil, fmt.Errorf("reading current run: %w", err)
+	}
+
+	var regressions []Regression
+	for key, values := range after {
+		baseValues, ok := before[key]
+		if !ok {
+			continue
+		}
+		r := Regression{Benchmark: key.benchmark, Unit: key.unit, Base: median(baseValues), Current: median(values)}
+		if r.Base != 0 && r.Change() > threshold {
+			regressions = append(regressions, r)
+		}
+	}
+	slices.SortFunc(regressions, func(a, b Regression) int {
+		if c := strings.Compare(a.Benchmark, b.Benchmark); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Unit, b.Unit)
+	})
+	return regressions, nil
+}
+
+// metric identifies a metric of a benchmark.
+type metric struct {
+	benchmark, unit string
+}
+
+// parseResults returns the values of the metrics of every result line of a go test -bench output.
+func parseResults(r io.Reader) (map[metric][]float64, error) {
+	results := make(map[metric][]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Result lines are the name, the iterations, and pairs of a value and its unit.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s of %s: %w", fields[i+1], fields[0], err)
+			}
+			key := metric{benchmark: fields[0], unit: fields[i+1]}
+			results[key] = append(results[key], value)
+		}
+	}
+	return results, scanner.Err()
+}
+
+// median returns the median of values, which must not be empty.
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	if n := len(sorted); n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+// rate reports whether the unit is a rate, better when higher.
+func rate(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
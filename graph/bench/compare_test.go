@@ -0,0 +1,85 @@
+package bench_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph/bench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseline = `goos: linux
+goarch: amd64
+pkg: github.com/cesto93/langgraphgo/graph/bench
+BenchmarkScenarios/linear-10-8   	  100000	     10000 ns/op	   1000000 steps/s	    2048 B/op	      20 allocs/op
+BenchmarkScenarios/linear-10-8   	  100000	     10400 ns/op	    961538 steps/s	    2048 B/op	      20 allocs/op
+BenchmarkScenarios/linear-10-8   	  100000	      9800 ns/op	   1020408 steps/s	    2048 B/op	      20 allocs/op
+BenchmarkScenarios/router-500-8  	   10000	    100000 ns/op	    210000 steps/s	    4096 B/op	      40 allocs/op
+PASS
+ok  	github.com/cesto93/langgraphgo/graph/bench	12.345s
+`
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		current   string
+		threshold float64
+		expected  []string
+	}{
+		{
+			name:      "unchanged",
+			current:   baseline,
+			threshold: 0.1,
+		},
+		{
+			name: "within threshold",
+			current: `BenchmarkScenarios/linear-10-8   	  100000	     10900 ns/op	    917431 steps/s	    2048 B/op	      20 allocs/op
+BenchmarkScenarios/router-500-8  	   10000	     90000 ns/op	    233333 steps/s	    4096 B/op	      40 allocs/op
+`,
+			threshold: 0.1,
+		},
+		{
+			name: "regressed",
+			current: `BenchmarkScenarios/linear-10-8   	  100000	     12000 ns/op	    833333 steps/s	    2048 B/op	      30 allocs/op
+BenchmarkScenarios/linear-10-8   	  100000	     12200 ns/op	    819672 steps/s	    2048 B/op	      30 allocs/op
+BenchmarkScenarios/router-500-8  	   10000	    100000 ns/op	    210000 steps/s	    4096 B/op	      40 allocs/op
+`,
+			threshold: 0.1,
+			expected: []string{
+				"BenchmarkScenarios/linear-10-8 allocs/op: 20 -> 30 (+50.0%)",
+				"BenchmarkScenarios/linear-10-8 ns/op: 10000 -> 12100 (+21.0%)",
+				"BenchmarkScenarios/linear-10-8 steps/s: 1e+06 -> 826502.5 (+17.3%)",
+			},
+		},
+		{
+			name: "new benchmark",
+			current: `BenchmarkScenarios/fan-out-32-8   	    1000	   1000000 ns/op
+`,
+			threshold: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			regressions, err := bench.Compare(strings.NewReader(baseline), strings.NewReader(tc.current), tc.threshold)
+			require.NoError(t, err)
+			var reports []string
+			for _, r := range regressions {
+				reports = append(reports, r.String())
+			}
+			assert.Equal(t, tc.expected, reports)
+		})
+	}
+}
+
+func TestCompareInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := bench.Compare(strings.NewReader(baseline), strings.NewReader("BenchmarkBroken-8 10 fast ns/op\n"), 0.1)
+	require.ErrorContains(t, err, "reading current run: parsing ns/op of BenchmarkBroken-8")
+}
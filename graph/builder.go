@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxNameLength is the maximum length, in bytes, of node names.
+const MaxNameLength = 128
+
+var (
+	// ErrEmptyName is returned by the Try methods when a node or edge names no node.
+	ErrEmptyName = errors.New("node name is empty")
+
+	// ErrReservedName is returned by TryAddNode when the node is named START or END.
+	ErrReservedName = errors.New("node name is reserved")
+
+	// ErrInvalidName is returned by ValidateName for names that are not identifiers.
+	ErrInvalidName = errors.New("invalid name")
+
+	// ErrDuplicateNode is returned by TryAddNode when the graph already has a node with the name.
+	ErrDuplicateNode = errors.New("node already exists")
+
+	// ErrInvalidEdge is returned by TryAddEdge for edges leaving END or leading to START.
+	ErrInvalidEdge = errors.New("invalid edge")
+
+	// ErrInvalidEntryPoint is returned by TrySetEntryPoint and TryAddEdge when the entry point
+	// would be START or END.
+	ErrInvalidEntryPoint = errors.New("invalid entry point")
+)
+
+// ValidateName checks that name can name a node, or another identifier such as the name of a
+// graph served. It returns ErrEmptyName for an empty name,
+// ErrReservedName for START and END, and ErrInvalidName for names longer than MaxNameLength,
+// holding other characters than letters, digits, '_', '-' and '.', or made of dots only. Such
+// names are safe in the identifiers of Mermaid and DOT renderings and in the URL paths of
+// servers, e.g. of serve.NewPatchHandler.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return ErrEmptyName
+	case name == START || name == END:
+		return fmt.Errorf("%w: %s", ErrReservedName, name)
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("%w: %.16q... is longer than %d bytes", ErrInvalidName, name, MaxNameLength)
+	case strings.Trim(name, ".") == "":
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	for _, c := range name {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("_-.", c) {
+			return fmt.Errorf("%w: %q holds %q", ErrInvalidName, name, c)
+		}
+	}
+	return nil
+}
+
+// TryAddNode is like AddNodeWithOptions but returns an error instead of accepting a node that
+// is empty (ErrEmptyName), reserved (ErrReservedName), not an identifier (ErrInvalidName; see
+// ValidateName) or already added (ErrDuplicateNode), or has no function (ErrNilNodeFunction).
+// The graph is left unchanged on error.
+func (g *MessageGraph[T]) TryAddNode(name string, fn func(ctx context.Context, state T) (T, error), opts ...NodeOption) error {
+	if err := g.checkNode(name, fn == nil); err != nil {
+		return err
+	}
+	g.AddNodeWithOptions(name, fn, opts...)
+	return nil
+}
+
+// TryAddNode is like MessageGraph.TryAddNode for the nodes of the state graph, whose functions
+// return the update of the state.
+func (g *StateGraph[S]) TryAddNode(name string, fn func(ctx context.Context, state S) (S, error), opts ...NodeOption) error {
+	if err := g.checkNode(name, fn == nil); err != nil {
+		return err
+	}
+	g.AddNodeWithOptions(name, fn, opts...)
+	return nil
+}
+
+// TryAddEdge is like AddEdge but returns an error instead of accepting an edge from or to an
+// empty name (ErrEmptyName) or a name that is not an identifier (ErrInvalidName), leaving END
+// or leading to START (ErrInvalidEdge), or from START to END (ErrInvalidEntryPoint). The nodes may be added after the edge: Compile reports the
+// edges to missing nodes. The graph is left unchanged on error.
+func (g *MessageGraph[T]) TryAddEdge(from, to string) error {
+	switch {
+	case from == "" || to == "":
+		return fmt.Errorf("edge from %q to %q: %w", from, to, ErrEmptyName)
+	case from == END || to == START:
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidEdge, from, to)
+	case from == START:
+		return g.TrySetEntryPoint(to)
+	}
+	for _, name := range []string{from, to} {
+		if err := ValidateName(name); err != nil && !errors.Is(err, ErrReservedName) {
+			return fmt.Errorf("edge from %q to %q: %w", from, to, err)
+		}
+	}
+	g.AddEdge(from, to)
+	return nil
+}
+
+// TrySetEntryPoint is like SetEntryPoint but returns an error instead of accepting an empty
+// entry point (ErrEmptyName), START or END (ErrInvalidEntryPoint), or a name that is not an
+// identifier (ErrInvalidName). The node may be added
+// after: Compile reports an entry point that is not a node. The graph is left unchanged on
+// error.
+func (g *MessageGraph[T]) TrySetEntryPoint(name string) error {
+	switch name {
+	case "":
+		return fmt.Errorf("entry point: %w", ErrEmptyName)
+	case START, END:
+		return fmt.Errorf("%w: %s", ErrInvalidEntryPoint, name)
+	}
+	if err := ValidateName(name); err != nil {
+		return fmt.Errorf("entry point: %w", err)
+	}
+	g.SetEntryPoint(name)
+	return nil
+}
+
+// checkNode checks a node can be added with the name.
+func (g *MessageGraph[T]) checkNode(name string, nilFunction bool) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if nilFunction {
+		return fmt.Errorf("%w: %s", ErrNilNodeFunction, name)
+	}
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateNode, name)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestTryAddNode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		node     string
+		nilNode  bool
+		expected error
+	}{
+		{name: "valid", node: "review"},
+		{name: "empty", node: "", expected: graph.ErrEmptyName},
+		{name: "end", node: graph.END, expected: graph.ErrReservedName},
+		{name: "start", node: graph.START, expected: graph.ErrReservedName},
+		{name: "invalid", node: "review/1", expected: graph.ErrInvalidName},
+		{name: "duplicate", node: "draft", expected: graph.ErrDuplicateNode},
+		{name: "nil function", node: "review", nilNode: true, expected: graph.ErrNilNodeFunction},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewMessageGraph[[]string]("draft")
+			require.NoError(t, g.TryAddNode("draft", appendNode("draft")))
+			fn := appendNode(tc.node)
+			if tc.nilNode {
+				fn = nil
+			}
+			err := g.TryAddNode(tc.node, fn)
+			require.ErrorIs(t, err, tc.expected)
+			if tc.expected != nil {
+				assert.Equal(t, []string{"draft"}, g.Topology().Nodes)
+			}
+		})
+	}
+}
+
+func TestTryAddEdge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		from, to string
+		expected error
+	}{
+		{name: "valid", from: "draft", to: graph.END},
+		{name: "start", from: graph.START, to: "draft"},
+		{name: "empty from", from: "", to: "draft", expected: graph.ErrEmptyName},
+		{name: "empty to", from: "draft", to: "", expected: graph.ErrEmptyName},
+		{name: "from end", from: graph.END, to: "draft", expected: graph.ErrInvalidEdge},
+		{name: "to start", from: "draft", to: graph.START, expected: graph.ErrInvalidEdge},
+		{name: "start to end", from: graph.START, to: graph.END, expected: graph.ErrInvalidEntryPoint},
+		{name: "invalid from", from: "draft step", to: "draft", expected: graph.ErrInvalidName},
+		{name: "invalid to", from: "draft", to: "a->b", expected: graph.ErrInvalidName},
+		{name: "invalid entry point", from: graph.START, to: "..", expected: graph.ErrInvalidName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewMessageGraph[[]string]("")
+			require.NoError(t, g.TryAddNode("draft", appendNode("draft")))
+			require.ErrorIs(t, g.TryAddEdge(tc.from, tc.to), tc.expected)
+			if tc.expected != nil {
+				assert.Empty(t, g.Topology().Edges)
+				assert.Empty(t, g.Topology().EntryPoint)
+			}
+		})
+	}
+}
+
+func TestTrySetEntryPoint(t *testing.T) {
+	t.Parallel()
+
+	type State struct {
+		Notes []string `reducer:"append"`
+	}
+	g := graph.NewStateGraph[State]("")
+	require.ErrorIs(t, g.TrySetEntryPoint(""), graph.ErrEmptyName)
+	require.ErrorIs(t, g.TrySetEntryPoint(graph.START), graph.ErrInvalidEntryPoint)
+	require.ErrorIs(t, g.TrySetEntryPoint(graph.END), graph.ErrInvalidEntryPoint)
+	require.ErrorIs(t, g.TrySetEntryPoint("a\"b"), graph.ErrInvalidName)
+
+	// Nodes of state graphs added with TryAddNode return updates.
+	require.NoError(t, g.TrySetEntryPoint("note"))
+	require.NoError(t, g.TryAddNode("note", func(context.Context, State) (State, error) {
+		return State{Notes: []string{"noted"}}, nil
+	}))
+	require.NoError(t, g.TryAddEdge("note", graph.END))
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	out, err := runnable.Invoke(context.Background(), State{Notes: []string{"first"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "noted"}, out.Notes)
+}
+
+func TestValidateName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		expected error
+	}{
+		{name: "search"},
+		{name: "call_tool-2.v1"},
+		{name: "résumé"},
+		{name: strings.Repeat("a", graph.MaxNameLength)},
+		{name: "", expected: graph.ErrEmptyName},
+		{name: graph.START, expected: graph.ErrReservedName},
+		{name: graph.END, expected: graph.ErrReservedName},
+		{name: strings.Repeat("a", graph.MaxNameLength+1), expected: graph.ErrInvalidName},
+		{name: ".", expected: graph.ErrInvalidName},
+		{name: "..", expected: graph.ErrInvalidName},
+		{name: "a/b", expected: graph.ErrInvalidName},
+		{name: "a b", expected: graph.ErrInvalidName},
+		{name: "a;b", expected: graph.ErrInvalidName},
+		{name: "a\nb", expected: graph.ErrInvalidName},
+		{name: "a{b}", expected: graph.ErrInvalidName},
+		{name: "a?b", expected: graph.ErrInvalidName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ErrorIs(t, graph.ValidateName(tc.name), tc.expected)
+		})
+	}
+}
+
+func TestCompileValidatesNames(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("review step", appendNode("review"))
+	g.AddNode(graph.START, appendNode("start"))
+	g.AddEdge("draft", "review step")
+	g.AddEdge("review step", graph.END)
+	g.AddEdge(graph.START, "draft")
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrInvalidName)
+	assert.ErrorContains(t, err, `"review step" holds ' '`)
+	require.ErrorIs(t, err, graph.ErrReservedName)
+}
@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ErrCallbacksType is returned by Compile when callbacks registered with WithCallbacks are not
+// for the state type of the graph.
+var ErrCallbacksType = errors.New("callbacks of another state type")
+
+// Callbacks are notified of the lifecycle of the invocations of a graph with state type T,
+// e.g. to log, measure or trace them without modifying the functions of the nodes. Nodes
+// executed in parallel notify them concurrently, so implementations must be safe for
+// concurrent use. Embed NopCallbacks to handle only some of the events.
+type Callbacks[T any] interface {
+	// OnGraphStart is called when an invocation starts, or resumes, with its input state.
+	OnGraphStart(ctx context.Context, state T)
+
+	// OnNodeStart is called before a node executes, with the state it receives.
+	OnNodeStart(ctx context.Context, node string, state T)
+
+	// OnNodeEnd is called after a node succeeded, with the state it returned.
+	OnNodeEnd(ctx context.Context, node string, state T)
+
+	// OnNodeError is called after a node failed, with its error.
+	OnNodeError(ctx context.Context, node string, err error)
+
+	// OnGraphEnd is called when an invocation ends, with the state reached and its error, if
+	// any, which is an *Interrupt when it paused.
+	OnGraphEnd(ctx context.Context, state T, err error)
+}
+
+// NopCallbacks implements Callbacks with methods doing nothing.
+type NopCallbacks[T any] struct{}
+
+// OnGraphStart does nothing.
+func (NopCallbacks[T]) OnGraphStart(context.Context, T) {}
+
+// OnNodeStart does nothing.
+func (NopCallbacks[T]) OnNodeStart(context.Context, string, T) {}
+
+// OnNodeEnd does nothing.
+func (NopCallbacks[T]) OnNodeEnd(context.Context, string, T) {}
+
+// OnNodeError does nothing.
+func (NopCallbacks[T]) OnNodeError(context.Context, string, error) {}
+
+// OnGraphEnd does nothing.
+func (NopCallbacks[T]) OnGraphEnd(context.Context, T, error) {}
+
+// WithCallbacks registers callbacks notified of every invocation of the Runnable. Compile fails
+// if T is not the state type of the graph.
+func WithCallbacks[T any](callbacks ...Callbacks[T]) CompileOption {
+	return func(o *compileOptions) {
+		o.callbacks = append(o.callbacks, callbacksOf[T]{callbacks: callbacks})
+	}
+}
+
+type callbacksKey struct{}
+
+// WithInvokeCallbacks returns a context registering callbacks notified of the invocations made
+// with it, in addition to the callbacks registered at Compile. The callbacks only apply to
+// invocations of graphs with state type T, including the graphs invoked by their nodes.
+func WithInvokeCallbacks[T any](ctx context.Context, callbacks ...Callbacks[T]) context.Context {
+	registered := slices.Concat(callbacksFromContext[T](ctx), callbacks)
+	return context.WithValue(ctx, callbacksKey{}, callbacksOf[T]{callbacks: registered})
+}
+
+func callbacksFromContext[T any](ctx context.Context) []Callbacks[T] {
+	c, _ := ctx.Value(callbacksKey{}).(callbacksOf[T])
+	return c.callbacks
+}
+
+// callbacksOf holds callbacks of a state type, letting compile options hold them untyped.
+type callbacksOf[T any] struct {
+	callbacks []Callbacks[T]
+}
+
+// stateType returns the state type of the callbacks.
+func (callbacksOf[T]) stateType() reflect.Type { return reflect.TypeFor[T]() }
+
+// typedCallbacks is implemented by callbacksOf.
+type typedCallbacks interface {
+	stateType() reflect.Type
+}
+
+// compileCallbacks returns the callbacks registered with WithCallbacks, checking they apply to
+// the state type of the graph.
+func compileCallbacks[T any](registered []typedCallbacks) ([]Callbacks[T], error) {
+	var callbacks []Callbacks[T]
+	for _, c := range registered {
+		typed, ok := c.(callbacksOf[T])
+		if !ok {
+			return nil, fmt.Errorf("%w: callbacks of state %s on graph of state %s", ErrCallbacksType, c.stateType(), reflect.TypeFor[T]())
+		}
+		callbacks = append(callbacks, typed.callbacks...)
+	}
+	return callbacks, nil
+}
+
+// notifier notifies the callbacks of an invocation.
+type notifier[T any] []Callbacks[T]
+
+// callbacks returns the callbacks notified of an invocation made with ctx.
+func (r *Runnable[T]) callbacks(ctx context.Context) notifier[T] {
+	return slices.Concat(r.compiledCallbacks, callbacksFromContext[T](ctx))
+}
+
+func (n notifier[T]) graphStart(ctx context.Context, state T) {
+	for _, c := range n {
+		c.OnGraphStart(ctx, state)
+	}
+}
+
+func (n notifier[T]) nodeStart(ctx context.Context, node string, state T) {
+	for _, c := range n {
+		c.OnNodeStart(ctx, node, state)
+	}
+}
+
+func (n notifier[T]) nodeEnd(ctx context.Context, node string, state T, err error) {
+	for _, c := range n {
+		if err != nil {
+			c.OnNodeError(ctx, node, err)
+		} else {
+			c.OnNodeEnd(ctx, node, state)
+		}
+	}
+}
+
+func (n notifier[T]) graphEnd(ctx context.Context, state T, err error) {
+	for _, c := range n {
+		c.OnGraphEnd(ctx, state, err)
+	}
+}
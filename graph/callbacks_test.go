@@ -0,0 +1,122 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// recorder records the events it is notified of.
+type recorder struct {
+	name string
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, r.name+" "+fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) OnGraphStart(_ context.Context, state []string) {
+	r.record("graph start %v", state)
+}
+
+func (r *recorder) OnNodeStart(_ context.Context, node string, state []string) {
+	r.record("start %s %v", node, state)
+}
+
+func (r *recorder) OnNodeEnd(_ context.Context, node string, state []string) {
+	r.record("end %s %v", node, state)
+}
+
+func (r *recorder) OnNodeError(_ context.Context, node string, err error) {
+	r.record("error %s %v", node, err)
+}
+
+func (r *recorder) OnGraphEnd(_ context.Context, state []string, err error) {
+	r.record("graph end %v %v", state, err)
+}
+
+// nodeErrors only handles node errors.
+type nodeErrors struct {
+	graph.NopCallbacks[[]string]
+	errs []error
+}
+
+func (n *nodeErrors) OnNodeError(_ context.Context, _ string, err error) {
+	n.errs = append(n.errs, err)
+}
+
+func TestCallbacks(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	compiled, invoked, errs := &recorder{name: "compiled"}, &recorder{name: "invoked"}, &nodeErrors{}
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", func(_ context.Context, state []string) ([]string, error) {
+		return graph.AppendMessages(state, "a"), nil
+	})
+	g.AddNode("b", func(_ context.Context, state []string) ([]string, error) {
+		if len(state) > 2 {
+			return state, boom
+		}
+		return graph.AppendMessages(state, "b"), nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", graph.END)
+
+	runnable, err := g.Compile(graph.WithCallbacks[[]string](compiled, errs))
+	require.NoError(t, err)
+
+	ctx := graph.WithInvokeCallbacks[[]string](context.Background(), invoked)
+	_, err = runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), []string{"x", "y"})
+	require.ErrorIs(t, err, boom)
+
+	assert.Equal(t, []string{
+		"compiled graph start []",
+		"compiled start a []",
+		"compiled end a [a]",
+		"compiled start b [a]",
+		"compiled end b [a b]",
+		"compiled graph end [a b] <nil>",
+		"compiled graph start [x y]",
+		"compiled start a [x y]",
+		"compiled end a [x y a]",
+		"compiled start b [x y a]",
+		"compiled error b boom",
+		"compiled graph end [x y a] error in node b: boom",
+	}, compiled.events)
+	assert.Equal(t, []string{
+		"invoked graph start []",
+		"invoked start a []",
+		"invoked end a [a]",
+		"invoked start b [a]",
+		"invoked end b [a b]",
+		"invoked graph end [a b] <nil>",
+	}, invoked.events)
+	assert.Equal(t, []error{boom}, errs.errs)
+}
+
+func TestCallbacksType(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", func(_ context.Context, state []string) ([]string, error) { return state, nil })
+	g.AddEdge("a", graph.END)
+
+	_, err := g.Compile(graph.WithCallbacks[string](graph.NopCallbacks[string]{}))
+	require.ErrorIs(t, err, graph.ErrCallbacksType)
+}
@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultCallBytes is the size recorded prompts and responses are cut to when the limits of
+// RecorderOptions are not positive.
+const DefaultCallBytes = 256 << 10
+
+// ModelCall is a model request and its response, as recorded by a CallRecorder.
+type ModelCall struct {
+	// ID identifies the call; node spans of the profile link to it through ProfileEntry.CallID.
+	ID string `json:"id"`
+
+	// Node is the node that made the call.
+	Node string `json:"node,omitempty"`
+
+	// Model names the model called.
+	Model string `json:"model"`
+
+	// Prompt is the rendered prompt, cut to RecorderOptions.MaxPromptBytes.
+	Prompt string `json:"prompt"`
+
+	// PromptSize is the size in bytes of the prompt before it was cut.
+	PromptSize int `json:"prompt_size"`
+
+	// Response is the raw response, cut to RecorderOptions.MaxResponseBytes.
+	Response string `json:"response"`
+
+	// ResponseSize is the size in bytes of the response before it was cut.
+	ResponseSize int `json:"response_size"`
+
+	// Err is the error returned by the call, if any.
+	Err string `json:"error,omitempty"`
+
+	// Start is the time the call started.
+	Start time.Time `json:"start"`
+
+	// Duration is the duration of the call.
+	Duration time.Duration `json:"duration"`
+}
+
+// RecorderOptions configures what a CallRecorder keeps.
+type RecorderOptions struct {
+	// MaxPromptBytes bounds the size of recorded prompts; DefaultCallBytes is used when not positive.
+	MaxPromptBytes int
+
+	// MaxResponseBytes bounds the size of recorded responses; DefaultCallBytes is used when not positive.
+	MaxResponseBytes int
+
+	// MaxCalls is the number of calls kept, the oldest being dropped first. Zero keeps all.
+	MaxCalls int
+
+	// MaxAge is how long calls are kept. Zero keeps them forever.
+	MaxAge time.Duration
+
+	// Redact, if set, rewrites prompts and responses before they are stored, e.g. to mask secrets
+	// with a redact.Redactor.
+	Redact func(string) string
+}
+
+// CallRecorder keeps the prompts and responses of the model calls made by nodes, for debugging
+// generation issues. Recording is opt-in: nodes report calls with RecordModelCall, which does
+// nothing unless the context carries a recorder. It is safe for concurrent use.
+type CallRecorder struct {
+	opts RecorderOptions
+
+	mu    sync.Mutex
+	next  int
+	calls []ModelCall
+}
+
+// NewCallRecorder creates a recorder with the given options.
+func NewCallRecorder(opts RecorderOptions) *CallRecorder {
+	if opts.MaxPromptBytes <= 0 {
+		opts.MaxPromptBytes = DefaultCallBytes
+	}
+	if opts.MaxResponseBytes <= 0 {
+		opts.MaxResponseBytes = DefaultCallBytes
+	}
+	return &CallRecorder{opts: opts}
+}
+
+type callRecorderKey struct{}
+
+// WithCallRecorder returns a context making RecordModelCall record into r.
+func WithCallRecorder(ctx context.Context, r *CallRecorder) context.Context {
+	return context.WithValue(ctx, callRecorderKey{}, r)
+}
+
+// RecordModelCall reports the start of a call to model with the rendered prompt and returns the
+// function reporting its outcome. The call is timed as a SpanModel span of the profile, if any,
+// and stored by the recorder of the context, if any.
+//
+//	done := graph.RecordModelCall(ctx, "gpt-4o", prompt)
+//	resp, err := client.Complete(ctx, prompt)
+//	done(resp.Text, err)
+func RecordModelCall(ctx context.Context, model, prompt string) func(response string, err error) {
+	r, _ := ctx.Value(callRecorderKey{}).(*CallRecorder)
+	p := profileFromContext(ctx)
+	if r == nil && p == nil {
+		return func(string, error) {}
+	}
+
+	node := currentNodeName(ctx)
+	start := time.Now()
+	return func(response string, err error) {
+		entry := ProfileEntry{Kind: SpanModel, Name: model, Node: node, Start: start}
+		if r != nil {
+			call := ModelCall{Node: node, Model: model, Start: start, Duration: time.Since(start)}
+			if err != nil {
+				call.Err = err.Error()
+			}
+			entry.CallID = r.add(call, prompt, response)
+		}
+		p.record(entry)
+	}
+}
+
+func (r *CallRecorder) add(call ModelCall, prompt, response string) string {
+	if r.opts.Redact != nil {
+		prompt, response = r.opts.Redact(prompt), r.opts.Redact(response)
+		call.Err = r.opts.Redact(call.Err)
+	}
+	call.Prompt, call.PromptSize = truncate(prompt, r.opts.MaxPromptBytes), len(prompt)
+	call.Response, call.ResponseSize = truncate(response, r.opts.MaxResponseBytes), len(response)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	call.ID = fmt.Sprintf("call-%d", r.next)
+	r.calls = append(r.calls, call)
+	r.expire(time.Now())
+	return call.ID
+}
+
+// expire drops the calls beyond the retention policy. The lock must be held.
+func (r *CallRecorder) expire(now time.Time) {
+	drop := 0
+	if r.opts.MaxCalls > 0 && len(r.calls) > r.opts.MaxCalls {
+		drop = len(r.calls) - r.opts.MaxCalls
+	}
+	if r.opts.MaxAge > 0 {
+		for drop < len(r.calls) && now.Sub(r.calls[drop].Start) > r.opts.MaxAge {
+			drop++
+		}
+	}
+	if drop > 0 {
+		r.calls = append([]ModelCall(nil), r.calls[drop:]...)
+	}
+}
+
+// Prune drops the calls started before the given time and returns how many were dropped.
+func (r *CallRecorder) Prune(before time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.calls[:0]
+	for _, call := range r.calls {
+		if !call.Start.Before(before) {
+			kept = append(kept, call)
+		}
+	}
+	dropped := len(r.calls) - len(kept)
+	clear(r.calls[len(kept):])
+	r.calls = kept
+	return dropped
+}
+
+// Calls returns the calls kept, oldest first.
+func (r *CallRecorder) Calls() []ModelCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(time.Now())
+	return append([]ModelCall(nil), r.calls...)
+}
+
+// Call returns the call with the given ID, if it is still kept.
+func (r *CallRecorder) Call(id string) (ModelCall, bool) {
+	for _, call := range r.Calls() {
+		if call.ID == id {
+			return call, true
+		}
+	}
+	return ModelCall{}, false
+}
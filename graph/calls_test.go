@@ -0,0 +1,93 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func modelGraph(t *testing.T) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", func(ctx context.Context, state []string) ([]string, error) {
+		prompt := strings.Join(state, "\n")
+		done := graph.RecordModelCall(ctx, "gpt", prompt)
+		response := "answer to " + prompt
+		done(response, nil)
+		return append(state, response), nil
+	})
+	g.AddEdge("agent", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestCallRecorder(t *testing.T) {
+	t.Parallel()
+
+	recorder := graph.NewCallRecorder(graph.RecorderOptions{
+		MaxPromptBytes:   5,
+		MaxResponseBytes: 8,
+		Redact:           func(s string) string { return strings.ReplaceAll(s, "secret", "******") },
+	})
+	ctx, profile := graph.WithProfiling(graph.WithCallRecorder(context.Background(), recorder))
+
+	_, err := modelGraph(t).Invoke(ctx, []string{"a secret"})
+	require.NoError(t, err)
+
+	calls := recorder.Calls()
+	require.Len(t, calls, 1)
+	call := calls[0]
+	assert.Equal(t, "agent", call.Node)
+	assert.Equal(t, "gpt", call.Model)
+	assert.Equal(t, "a ***", call.Prompt)
+	assert.Equal(t, 8, call.PromptSize)
+	assert.Equal(t, "answer t", call.Response)
+	assert.Equal(t, 18, call.ResponseSize)
+
+	entries := profile.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, graph.SpanModel, entries[0].Kind)
+	assert.Equal(t, call.ID, entries[0].CallID, "the trace links to the call")
+
+	linked, ok := recorder.Call(entries[0].CallID)
+	assert.True(t, ok)
+	assert.Equal(t, call, linked)
+}
+
+func TestCallRecorderRetention(t *testing.T) {
+	t.Parallel()
+
+	recorder := graph.NewCallRecorder(graph.RecorderOptions{MaxCalls: 2})
+	ctx := graph.WithCallRecorder(context.Background(), recorder)
+	for _, prompt := range []string{"a", "b", "c"} {
+		graph.RecordModelCall(ctx, "gpt", prompt)("", errors.New("failed "+prompt))
+	}
+
+	calls := recorder.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, []string{"b", "c"}, []string{calls[0].Prompt, calls[1].Prompt})
+	assert.Equal(t, "failed c", calls[1].Err)
+	_, ok := recorder.Call("call-1")
+	assert.False(t, ok)
+
+	aging := graph.NewCallRecorder(graph.RecorderOptions{MaxAge: time.Millisecond})
+	graph.RecordModelCall(graph.WithCallRecorder(context.Background(), aging), "gpt", "old")("", nil)
+	assert.Eventually(t, func() bool { return len(aging.Calls()) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestRecordModelCallDisabled(t *testing.T) {
+	t.Parallel()
+
+	out, err := modelGraph(t).Invoke(context.Background(), []string{"q"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"q", "answer to q"}, out)
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"unicode/utf8"
+)
+
+// DefaultCaptureBytes is the size captured inputs are cut to when CaptureOptions.MaxBytes is not positive.
+const DefaultCaptureBytes = 64 << 10
+
+// CaptureOptions configures the capture of node inputs into a profile.
+type CaptureOptions struct {
+	// MaxBytes bounds the size of a captured input; longer inputs are cut.
+	// DefaultCaptureBytes is used when it is not positive.
+	MaxBytes int
+
+	// SampleRate is the fraction of node executions whose input is captured.
+	// Every execution is captured when it is not in the (0, 1) range.
+	SampleRate float64
+
+	// Redact, if set, rewrites the encoded input before it is stored, e.g. to mask secrets
+	// with a redact.Redactor.
+	Redact func(input []byte) ([]byte, error)
+}
+
+// capturedInput is an input attached to a node span.
+type capturedInput struct {
+	data string
+	size int
+}
+
+// CaptureInputs makes the profile record the state each node receives along with the node
+// span, so the exact input of a misbehaving node can be inspected afterwards.
+// It must be called before the profiled invocation starts.
+func (p *Profile) CaptureInputs(opts CaptureOptions) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultCaptureBytes
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.capture = &opts
+}
+
+func (p *Profile) captureInput(state any) capturedInput {
+	if p == nil {
+		return capturedInput{}
+	}
+
+	p.mu.Lock()
+	opts := p.capture
+	p.mu.Unlock()
+	if opts == nil || (opts.SampleRate > 0 && opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate) {
+		return capturedInput{}
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil && opts.Redact != nil {
+		data, err = opts.Redact(data)
+	}
+	if err != nil {
+		// Keep something to look at rather than nothing, unless it could leak secrets.
+		if opts.Redact != nil {
+			return capturedInput{data: fmt.Sprintf("capturing input: %v", err)}
+		}
+		data = []byte(fmt.Sprintf("%+v", state))
+	}
+
+	return capturedInput{data: truncate(string(data), opts.MaxBytes), size: len(data)}
+}
+
+// truncate cuts s to at most maxBytes without splitting a rune.
+func truncate(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
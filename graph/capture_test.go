@@ -0,0 +1,125 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureGraph(t *testing.T, steps int) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("n0")
+	for i := range steps {
+		name, next := "n"+string(rune('0'+i)), "n"+string(rune('1'+i))
+		if i == steps-1 {
+			next = graph.END
+		}
+		g.AddNode(name, func(_ context.Context, state []string) ([]string, error) {
+			return append(state, name), nil
+		})
+		g.AddEdge(name, next)
+	}
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestCaptureInputs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		opts     graph.CaptureOptions
+		expected []string
+		sizes    []int
+	}{
+		{
+			name:     "full",
+			opts:     graph.CaptureOptions{},
+			expected: []string{`["héllo"]`, `["héllo","n0"]`},
+			sizes:    []int{10, 15},
+		},
+		{
+			name:     "cut on a rune boundary",
+			opts:     graph.CaptureOptions{MaxBytes: 4},
+			expected: []string{`["h`, `["h`},
+			sizes:    []int{10, 15},
+		},
+		{
+			name: "redacted",
+			opts: graph.CaptureOptions{Redact: func(input []byte) ([]byte, error) {
+				return bytes.ReplaceAll(input, []byte("héllo"), []byte("***")), nil
+			}},
+			expected: []string{`["***"]`, `["***","n0"]`},
+			sizes:    []int{7, 12},
+		},
+		{
+			name: "redaction failure",
+			opts: graph.CaptureOptions{Redact: func([]byte) ([]byte, error) {
+				return nil, errors.New("boom")
+			}},
+			expected: []string{"capturing input: boom", "capturing input: boom"},
+			sizes:    []int{0, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, profile := graph.WithProfiling(context.Background())
+			profile.CaptureInputs(tc.opts)
+			_, err := captureGraph(t, 2).Invoke(ctx, []string{"héllo"})
+			require.NoError(t, err)
+
+			entries := profile.Entries()
+			require.Len(t, entries, 2)
+			for i, e := range entries {
+				assert.Equal(t, tc.expected[i], e.Input)
+				assert.Equal(t, tc.sizes[i], e.InputSize)
+			}
+		})
+	}
+}
+
+func TestCaptureInputsSampling(t *testing.T) {
+	t.Parallel()
+
+	ctx, profile := graph.WithProfiling(context.Background())
+	profile.CaptureInputs(graph.CaptureOptions{SampleRate: 0.5})
+	for range 20 {
+		_, err := captureGraph(t, 5).Invoke(ctx, nil)
+		require.NoError(t, err)
+	}
+
+	captured := 0
+	for _, e := range profile.Entries() {
+		if e.Input != "" {
+			captured++
+		}
+	}
+	assert.Greater(t, captured, 0)
+	assert.Less(t, captured, 100)
+}
+
+func TestCaptureInputsDisabled(t *testing.T) {
+	t.Parallel()
+
+	ctx, profile := graph.WithProfiling(context.Background())
+	_, err := captureGraph(t, 1).Invoke(ctx, []string{"secret"})
+	require.NoError(t, err)
+
+	for _, e := range profile.Entries() {
+		assert.Empty(t, e.Input)
+	}
+	assert.False(t, strings.Contains(profile.Text(), "secret"))
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCommandNotResumable is returned by UpdateState when the node the update is attributed to
+// is a command node, whose next node is only known by executing it.
+var ErrCommandNotResumable = errors.New("command nodes cannot be resumed")
+
+// Command is the result of a command node: the state it returns and the node to execute next,
+// so that the node decides where execution goes in the same place it updates the state.
+type Command[T any] struct {
+	// Update is the state the node returns; for state graphs, the update reduced into the state.
+	Update T
+
+	// Goto is the node to execute next, or END.
+	Goto string
+}
+
+type commandKey struct{}
+
+// commandTarget receives the Goto of the command returned by a command node.
+type commandTarget struct {
+	next string
+}
+
+// AddCommandNode adds a node whose function returns both the state and the node to execute
+// next, instead of leaving through edges. Like AddConditionalEdge, it replaces the edges added
+// before from the node, and is replaced by the edges added after.
+//
+// The routes are the nodes the commands may go to. They are optional but let Topology, Lint and
+// the renderers know the possible transitions, and invocations fail with ErrUndeclaredRoute
+// when a command goes to another node. Commands without Goto fail with ErrNoOutgoingEdge.
+func (g *MessageGraph[T]) AddCommandNode(name string, fn func(ctx context.Context, state T) (Command[T], error), routes ...string) {
+	g.AddCommandNodeWithOptions(name, fn, routes)
+}
+
+// AddCommandNodeWithOptions is like AddCommandNode but configures the node with options, such
+// as its resource hints, its retry policy or its timeout.
+func (g *MessageGraph[T]) AddCommandNodeWithOptions(name string, fn func(ctx context.Context, state T) (Command[T], error), routes []string, opts ...NodeOption) {
+	var node func(ctx context.Context, state T) (T, error)
+	if fn != nil {
+		node = func(ctx context.Context, state T) (T, error) {
+			command, err := fn(ctx, state)
+			if err != nil {
+				return state, err
+			}
+			if target, ok := ctx.Value(commandKey{}).(*commandTarget); ok {
+				target.next = command.Goto
+			}
+			return command.Update, nil
+		}
+	}
+	g.AddNodeWithOptions(name, node, opts...)
+	delete(g.edges, name)
+	g.conditionalEdges[name] = conditionalEdge[T]{
+		command: true,
+		routes:  append([]string(nil), routes...),
+	}
+}
+
+// AddCommandNode adds a command node to the state graph, whose commands hold the update of the
+// state. See MessageGraph.AddCommandNode.
+func (g *StateGraph[S]) AddCommandNode(name string, fn func(ctx context.Context, state S) (Command[S], error), routes ...string) {
+	g.AddCommandNodeWithOptions(name, fn, routes)
+}
+
+// AddCommandNodeWithOptions is like AddCommandNode but configures the node with options, such as
+// its resource hints, its retry policy or its timeout.
+func (g *StateGraph[S]) AddCommandNodeWithOptions(name string, fn func(ctx context.Context, state S) (Command[S], error), routes []string, opts ...NodeOption) {
+	if fn == nil {
+		g.MessageGraph.AddCommandNodeWithOptions(name, nil, routes, opts...)
+		return
+	}
+	g.MessageGraph.AddCommandNodeWithOptions(name, func(ctx context.Context, state S) (Command[S], error) {
+		command, err := fn(ctx, state)
+		if err != nil {
+			return Command[S]{Update: state}, err
+		}
+		return Command[S]{Update: g.Reduce(state, command.Update), Goto: command.Goto}, nil
+	}, routes, opts...)
+}
+
+// withCommand returns a context receiving the Goto of the command returned by a command node.
+func withCommand(ctx context.Context, target *commandTarget) context.Context {
+	return context.WithValue(ctx, commandKey{}, target)
+}
+
+// goTo checks the Goto of the command returned by a command node goes to a declared route, if
+// any, and returns it.
+func (c conditionalEdge[T]) goTo(node string, target *commandTarget) (string, error) {
+	if target.next == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoOutgoingEdge, node)
+	}
+	if !c.declares(target.next) {
+		return "", fmt.Errorf("%w: %q", ErrUndeclaredRoute, target.next)
+	}
+	return target.next, nil
+}
@@ -0,0 +1,131 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// commandGraph drafts until the state holds three messages, deciding in the command of the
+// draft node whether to draft again or to review, or to go to next once done.
+func commandGraph(next string, routes ...string) *graph.MessageGraph[[]string] {
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddCommandNode("draft", func(_ context.Context, state []string) (graph.Command[[]string], error) {
+		state = graph.AppendMessages(state, "draft")
+		if len(state) < 3 {
+			return graph.Command[[]string]{Update: state, Goto: "draft"}, nil
+		}
+		return graph.Command[[]string]{Update: state, Goto: next}, nil
+	}, routes...)
+	g.AddNode("review", appendNode("review"))
+	g.AddEdge("review", graph.END)
+	return g
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		next     string
+		routes   []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "goto",
+			next:     "review",
+			routes:   []string{"draft", "review"},
+			expected: []string{"draft", "draft", "draft", "review"},
+		},
+		{
+			name:     "end",
+			next:     graph.END,
+			routes:   []string{"draft", "review", graph.END},
+			expected: []string{"draft", "draft", "draft"},
+		},
+		{
+			name:     "undeclared routes",
+			next:     "review",
+			expected: []string{"draft", "draft", "draft", "review"},
+		},
+		{
+			name:   "undeclared route",
+			next:   graph.END,
+			routes: []string{"draft", "review"},
+			err:    graph.ErrUndeclaredRoute,
+		},
+		{
+			name:   "no goto",
+			routes: []string{"draft", "review"},
+			err:    graph.ErrNoOutgoingEdge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, err := commandGraph(tc.next, tc.routes...).Compile()
+			require.NoError(t, err)
+
+			out, err := runnable.Invoke(context.Background(), nil)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestCommandTopology(t *testing.T) {
+	t.Parallel()
+
+	g := commandGraph("review", "draft", "review")
+	topology := g.Topology()
+	assert.Equal(t, []string{"draft"}, topology.Routers)
+	assert.Equal(t, []string{"draft", "review"}, topology.Successors("draft"))
+
+	// Command nodes replace the edges added before, and are replaced by those added after.
+	g.AddEdge("draft", "review")
+	assert.Empty(t, g.Topology().Routers)
+
+	g = graph.NewMessageGraph[[]string]("draft")
+	g.AddCommandNode("draft", nil)
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrNilNodeFunction)
+	require.NotErrorIs(t, err, graph.ErrNilRouter)
+}
+
+func TestCommandStateGraph(t *testing.T) {
+	t.Parallel()
+
+	type State struct {
+		Topic string
+		Notes []string `reducer:"append"`
+	}
+	g := graph.NewStateGraph[State]("note")
+	g.AddCommandNode("note", func(_ context.Context, state State) (graph.Command[State], error) {
+		if len(state.Notes) == 2 {
+			return graph.Command[State]{Goto: graph.END}, nil
+		}
+		return graph.Command[State]{Update: State{Notes: []string{"noted"}}, Goto: "note"}, nil
+	}, "note", graph.END)
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	out, err := runnable.Invoke(graph.WithThreadID(ctx, "t1"), State{Topic: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, State{Topic: "go", Notes: []string{"noted", "noted"}}, out)
+
+	_, err = runnable.UpdateState(ctx, "t1", State{}, "note")
+	require.ErrorIs(t, err, graph.ErrCommandNotResumable)
+}
@@ -0,0 +1,169 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests exercise the concurrency contract of the package and are meant to run with -race.
+
+const concurrentInvocations = 32
+
+func TestConcurrentInvoke(t *testing.T) {
+	t.Parallel()
+
+	stats := &graph.RoutingStats{}
+	handlers := map[string]func(context.Context, []string) ([]string, error){
+		"even": func(_ context.Context, state []string) ([]string, error) {
+			return graph.AppendMessages(state, "even"), nil
+		},
+		"odd": func(_ context.Context, state []string) ([]string, error) {
+			return graph.AppendMessages(state, "odd"), nil
+		},
+	}
+	router := func(_ context.Context, state []string) (string, error) {
+		if len(state[0])%2 == 0 {
+			return "even", nil
+		}
+		return "odd", nil
+	}
+
+	appendBranch := func(name string) graph.Branch[[]string] {
+		return graph.Branch[[]string]{
+			Name: name,
+			Function: func(_ context.Context, state []string) ([]string, error) {
+				return graph.AppendMessages(state, name), nil
+			},
+		}
+	}
+
+	g := graph.NewMessageGraph[[]string]("fanout")
+	g.AddPrefetch("docs", func(_ context.Context, state []string) (any, error) {
+		return "docs for " + state[0], nil
+	})
+	g.AddNode("fanout", graph.FanOut(graph.FailFast, graph.Concatenate[string](),
+		appendBranch("left"), appendBranch("right")))
+	g.AddNode("route", graph.Speculate(router, handlers, stats))
+	g.AddNode("answer", func(ctx context.Context, state []string) ([]string, error) {
+		defer graph.ProfileSpan(ctx, graph.SpanModel, "model")()
+		docs, err := graph.PrefetchedAs[string](ctx, "docs")
+		if err != nil {
+			return nil, err
+		}
+		return graph.AppendMessages(state, docs), nil
+	})
+	g.AddEdge("fanout", "route")
+	g.AddEdge("route", "answer")
+	g.AddEdge("answer", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	outputs := make([][]string, concurrentInvocations)
+	errs := make([]error, concurrentInvocations)
+	for i := range concurrentInvocations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, _ := graph.WithProfiling(context.Background())
+			input := make([]string, 1, 16)
+			input[0] = fmt.Sprintf("q%d", i)
+			outputs[i], errs[i] = runnable.Invoke(ctx, input)
+		}()
+	}
+	wg.Wait()
+
+	for i := range concurrentInvocations {
+		require.NoError(t, errs[i])
+		question := fmt.Sprintf("q%d", i)
+		parity := "even"
+		if len(question)%2 != 0 {
+			parity = "odd"
+		}
+		assert.Equal(t, []string{question, "left", "right", parity, "docs for " + question}, outputs[i])
+	}
+
+	counts := stats.Counts()
+	assert.Equal(t, concurrentInvocations, counts["even"]+counts["odd"])
+}
+
+func TestConcurrentCheckpointing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	history := checkpoint.NewHistory[string](store, 3)
+
+	var wg sync.WaitGroup
+	for i := range concurrentInvocations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			thread := fmt.Sprintf("thread-%d", i)
+			var state []string
+			for step := range 5 {
+				state = graph.AppendMessages(state, fmt.Sprintf("m%d", step))
+				_, err := history.Save(ctx, thread, step, "node", state)
+				assert.NoError(t, err)
+				_, err = history.LoadTail(ctx, thread, 2)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range concurrentInvocations {
+		state, err := history.Load(ctx, fmt.Sprintf("thread-%d", i), "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"m0", "m1", "m2", "m3", "m4"}, state)
+	}
+}
+
+func TestCompileSnapshotsGraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", appendNode("a"))
+	g.AddNode("b", appendNode("b"))
+	g.AddLabeledEdge("a", "b", "next", "")
+	g.SetFinishPoint("b")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// Modifying the graph while the Runnable is invoked neither races nor changes its runs.
+	var wg sync.WaitGroup
+	for i := range concurrentInvocations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := runnable.Invoke(context.Background(), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a", "b"}, out, "invocation %d", i)
+		}()
+	}
+	g.AddNode("a", appendNode("replaced"))
+	g.AddNode("c", appendNode("c"))
+	g.AddLabeledEdge("a", "b", "renamed", "")
+	g.AddEdge("c", "a")
+	g.SetEntryPoint("c")
+	wg.Wait()
+
+	out, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, out)
+	assert.Equal(t, "next", runnable.Topology().Edges[0].Label)
+
+	recompiled, err := g.Compile()
+	require.NoError(t, err)
+	out, err = recompiled.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "replaced", "b"}, out)
+}
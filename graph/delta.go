@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// ErrStateNotSlice is returned by Compile with WithDeltaCheckpoints when the state is not a
+// slice.
+var ErrStateNotSlice = errors.New("state is not a slice")
+
+// WithDeltaCheckpoints makes invocations save their checkpoints to the checkpointer set with
+// WithCheckpointer as deltas, holding only the elements appended to the state since the
+// previous checkpoint of the invocation, with a full snapshot every snapshotEvery checkpoints,
+// or checkpoint.DefaultSnapshotInterval if not positive. Threads saved after every step then
+// grow with the length of their state, rather than with its square.
+//
+// The state must be a slice, such as a message history, whose elements are only appended to:
+// the elements already saved must not be changed, and a state that shrank is saved in full.
+// The first checkpoint of every invocation is a full snapshot. GetState, Pending, UpdateState and
+// InvokeFrom rebuild the complete state of delta checkpoints; see checkpoint.Resolve. They fail
+// with checkpoint.ErrBrokenChain if the checkpoints a delta applies to were pruned.
+func WithDeltaCheckpoints(snapshotEvery int) CompileOption {
+	return func(o *compileOptions) {
+		o.deltas = true
+		o.snapshotEvery = snapshotEvery
+		if o.snapshotEvery <= 0 {
+			o.snapshotEvery = checkpoint.DefaultSnapshotInterval
+		}
+	}
+}
+
+type deltaChainKey struct{}
+
+// deltaChain tracks the checkpoints saved by an invocation, which the next delta applies to.
+type deltaChain struct {
+	// owner is the Runnable of the invocation.
+	owner any
+
+	mu sync.Mutex
+
+	// base is the ID of the last checkpoint saved, empty until the first one.
+	base string
+
+	// length is the length of the state saved in base.
+	length int
+
+	// deltas is the number of deltas saved since the last full snapshot.
+	deltas int
+}
+
+// withDeltaChain returns a context in which the outermost invocation on a thread saves delta
+// checkpoints, if enabled with WithDeltaCheckpoints.
+func (r *Runnable[T]) withDeltaChain(ctx context.Context) context.Context {
+	if r.snapshotEvery == 0 || currentNodeName(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, deltaChainKey{}, &deltaChain{owner: r})
+}
+
+// deltaChain returns the chain of the invocation of the context, or nil if it saves full
+// checkpoints only, e.g. because it is invoked by a node.
+func (r *Runnable[T]) deltaChain(ctx context.Context) *deltaChain {
+	chain, _ := ctx.Value(deltaChainKey{}).(*deltaChain)
+	if chain == nil || chain.owner != any(r) || currentNodeName(ctx) != "" {
+		return nil
+	}
+	return chain
+}
+
+// encodeCheckpoint encodes the state into cp, as a delta of the last checkpoint of chain unless
+// chain is nil or a full snapshot is due. It returns the length of the state.
+func (r *Runnable[T]) encodeCheckpoint(cp *checkpoint.Checkpoint, chain *deltaChain, state T) (int, error) {
+	if chain == nil {
+		var err error
+		cp.Kind = checkpoint.KindFull
+		cp.State, err = checkpoint.Encode(state)
+		return 0, err
+	}
+
+	v := reflect.ValueOf(state)
+	length := v.Len()
+	if chain.base == "" || chain.deltas+1 >= r.snapshotEvery || length < chain.length {
+		var err error
+		cp.Kind = checkpoint.KindFull
+		cp.State, err = checkpoint.Encode(state)
+		return length, err
+	}
+
+	// Pointers to the elements encode like the whole slice does, methods with pointer
+	// receivers included.
+	items := make([]any, length)
+	for i := chain.length; i < length; i++ {
+		items[i] = v.Index(i).Addr().Interface()
+	}
+	data, err := checkpoint.EncodeDelta(items, chain.length)
+	if err != nil {
+		return length, err
+	}
+	cp.Kind, cp.State = checkpoint.KindDelta, data
+	cp.Metadata = maps.Clone(cp.Metadata)
+	if cp.Metadata == nil {
+		cp.Metadata = make(map[string]string, 1)
+	}
+	cp.Metadata[checkpoint.MetadataBase] = chain.base
+	return length, nil
+}
+
+// saved records in chain the checkpoint saved, with a state of the given length.
+func (c *deltaChain) saved(cp checkpoint.Checkpoint, length int) {
+	if c == nil {
+		return
+	}
+	c.base, c.length = cp.ID, length
+	if cp.Kind == checkpoint.KindFull {
+		c.deltas = 0
+	} else {
+		c.deltas++
+	}
+}
+
+// decodeCheckpoint decodes the complete state of a checkpoint read from the checkpointer, timed
+// as a SpanSerialization span of the profile of the context, if any.
+func (r *Runnable[T]) decodeCheckpoint(ctx context.Context, cp checkpoint.Checkpoint) (T, error) {
+	resolved, err := checkpoint.Resolve(ctx, r.checkpointer, cp)
+	if err != nil {
+		var state T
+		return state, fmt.Errorf("reading checkpoint %s of thread %s: %w", cp.ID, cp.ThreadID, err)
+	}
+	defer ProfileSpan(ctx, SpanSerialization, cp.Node)()
+	return r.decode(resolved.State)
+}
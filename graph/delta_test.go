@@ -0,0 +1,127 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// deltaGraph returns a graph appending to the state in a chain of nodes, saving delta
+// checkpoints to store.
+func deltaGraph(t *testing.T, store checkpoint.Checkpointer, nodes int) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("node-0")
+	for i := range nodes {
+		name := fmt.Sprintf("node-%d", i)
+		g.AddNode(name, appendNode(name))
+		if i > 0 {
+			g.AddEdge(fmt.Sprintf("node-%d", i-1), name)
+		}
+	}
+	g.SetFinishPoint(fmt.Sprintf("node-%d", nodes-1))
+	runnable, err := g.Compile(graph.WithCheckpointer(store), graph.WithDeltaCheckpoints(3))
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestDeltaCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	runnable := deltaGraph(t, store, 5)
+
+	threadCtx := graph.WithThreadID(ctx, "thread")
+	out, err := runnable.Invoke(threadCtx, []string{"question"})
+	require.NoError(t, err)
+
+	checkpoints, err := store.List(ctx, "thread")
+	require.NoError(t, err)
+	var kinds []checkpoint.Kind
+	for _, cp := range checkpoints {
+		kinds = append(kinds, cp.Kind)
+	}
+	assert.Equal(t, []checkpoint.Kind{
+		checkpoint.KindFull, checkpoint.KindDelta, checkpoint.KindDelta,
+		checkpoint.KindFull, checkpoint.KindDelta,
+	}, kinds)
+	assert.JSONEq(t, `{"offset":3,"items":["node-2"]}`, string(checkpoints[2].State))
+
+	snapshot, err := runnable.GetState(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, out, snapshot.State)
+
+	// Replaying from a delta rebuilds its state.
+	replayed, err := runnable.InvokeFrom(ctx, "thread", checkpoints[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, out, replayed)
+}
+
+func TestDeltaCheckpointsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	runnable := deltaGraph(t, store, 8)
+
+	// The checkpoints of the invocations interleave in the thread.
+	threadCtx := graph.WithThreadID(ctx, "thread")
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := runnable.Invoke(threadCtx, []string{fmt.Sprintf("question-%d", i)})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	checkpoints, err := store.List(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 4*8)
+	for _, cp := range checkpoints {
+		resolved, err := checkpoint.Resolve(ctx, store, cp)
+		require.NoError(t, err)
+		state, err := checkpoint.Decode[[]string](resolved.State)
+		require.NoError(t, err)
+
+		// Every state is the question of its invocation followed by the nodes executed.
+		require.NotEmpty(t, state)
+		assert.Regexp(t, `^question-\d$`, state[0])
+		for i, node := range state[1:] {
+			assert.Equal(t, fmt.Sprintf("node-%d", i), node, "checkpoint %s", cp.ID)
+		}
+	}
+}
+
+func TestDeltaCheckpointsErrors(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Messages []string
+	}
+	s := graph.NewMessageGraph[state]("a")
+	s.AddNode("a", func(_ context.Context, st state) (state, error) { return st, nil })
+	s.SetFinishPoint("a")
+	_, err := s.Compile(graph.WithDeltaCheckpoints(0))
+	require.ErrorIs(t, err, graph.ErrStateNotSlice)
+
+	// Checkpoints of unknown kinds are not misread as full states.
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	require.NoError(t, store.Put(ctx, checkpoint.Checkpoint{
+		ThreadID: "thread", ID: checkpoint.StepID(0), Kind: "compressed", Version: checkpoint.FormatVersion,
+		State: []byte(`["question"]`),
+	}))
+	_, err = deltaGraph(t, store, 1).GetState(ctx, "thread")
+	require.ErrorIs(t, err, checkpoint.ErrUnknownKind)
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// ErrSideEffectNotConfirmed is returned when a run would execute a side-effecting node again
+// without confirmation; see WithSideEffects.
+var ErrSideEffectNotConfirmed = errors.New("re-executing side effects not confirmed")
+
+// metadataRunID is the checkpoint metadata key holding the run ID of the runs that saved a
+// side-effect interrupt.
+const metadataRunID = "run_id"
+
+// WithSideEffects marks a node as side-effecting, such as sending an email or charging a card,
+// so the runtime does not execute it again by itself after it may have run, unlike idempotent
+// nodes, which is the default:
+//
+//   - its retry policy retries it only when it fails with an error marked with RetrySafe;
+//   - in invocations on a thread of a checkpointer, a checkpoint is saved before every step
+//     executing it, as an Interrupt before the step whose SideEffect is set. The thread stays
+//     paused at it if the run fails or crashes before the next checkpoint, so GetState and
+//     Pending report the node that may have run. Resuming that interrupt, or invoking the
+//     thread again with the same run ID, as workers redelivering a run do, fails with
+//     ErrSideEffectNotConfirmed unless the context was created by ConfirmSideEffects.
+func WithSideEffects() NodeOption {
+	return func(o *nodeOptions) {
+		o.sideEffects = true
+	}
+}
+
+type confirmSideEffectsKey struct{}
+
+// ConfirmSideEffects returns a context allowing the runs with it to execute side-effecting
+// nodes again, e.g. once an operator checked the email of an interrupted run was not sent.
+func ConfirmSideEffects(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmSideEffectsKey{}, true)
+}
+
+func sideEffectsConfirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(confirmSideEffectsKey{}).(bool)
+	return confirmed
+}
+
+// retrySafeError is an error of a side-effecting node that may be retried.
+type retrySafeError struct {
+	err error
+}
+
+func (e *retrySafeError) Error() string { return e.err.Error() }
+
+func (e *retrySafeError) Unwrap() error { return e.err }
+
+// RetrySafe marks the error of a side-effecting node as safe to retry, because the node failed
+// before its side effect, e.g. when the mail server refused the connection. It returns nil
+// for a nil error.
+func RetrySafe(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retrySafeError{err: err}
+}
+
+// retryable reports whether the node may be retried after failing with err.
+func (n Node[T]) retryable(err error) bool {
+	var safe *retrySafeError
+	return !n.SideEffects || errors.As(err, &safe)
+}
+
+// markSideEffects saves the interrupt before the step with the given index, reached from the
+// "from" nodes, when it executes side-effecting nodes in the outermost invocation on a thread,
+// and reports whether it did.
+func (r *Runnable[T]) markSideEffects(ctx context.Context, index int, nodes, from []string, state T) (bool, error) {
+	threadID := threadIDFromContext(ctx)
+	if r.checkpointer == nil || threadID == "" || currentNodeName(ctx) != "" {
+		return false, nil
+	}
+	i := slices.IndexFunc(nodes, func(node string) bool { return r.graph.nodes[node].SideEffects })
+	if i < 0 {
+		return false, nil
+	}
+
+	interrupt := &Interrupt{Node: nodes[i], Step: index, Next: slices.Clone(nodes), From: slices.Clone(from), ThreadID: threadID, SideEffect: true}
+	encoded, err := json.Marshal(interrupt)
+	if err != nil {
+		return false, fmt.Errorf("saving side effects of node %s to thread %s: %w", interrupt.Node, threadID, err)
+	}
+	metadata := map[string]string{metadataInterrupt: string(encoded), metadataRunID: RunID(ctx)}
+	if err := r.save(ctx, threadID, interrupt.Node, state, metadata); err != nil {
+		return false, fmt.Errorf("saving side effects of node %s to thread %s: %w", interrupt.Node, threadID, err)
+	}
+	return true, nil
+}
+
+// checkRerun returns ErrSideEffectNotConfirmed when an invocation on a thread with the run ID
+// of ctx, such as a run redelivered by a queue, would execute again the side-effecting nodes
+// its previous execution left the thread paused at.
+func (r *Runnable[T]) checkRerun(ctx context.Context) error {
+	threadID, runID := threadIDFromContext(ctx), RunID(ctx)
+	if r.checkpointer == nil || threadID == "" || runID == "" || currentNodeName(ctx) != "" || sideEffectsConfirmed(ctx) {
+		return nil
+	}
+	latest, err := r.checkpointer.Latest(ctx, threadID)
+	switch {
+	case errors.Is(err, checkpoint.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case latest.Metadata[metadataRunID] != runID:
+		return nil
+	}
+	return fmt.Errorf("%w: run %s may have executed node %s of thread %s", ErrSideEffectNotConfirmed, runID, latest.Node, threadID)
+}
+
+// checkResume returns ErrSideEffectNotConfirmed when resuming the interrupt would execute again
+// side-effecting nodes without confirmation.
+func checkResume(ctx context.Context, interrupt *Interrupt) error {
+	if !interrupt.SideEffect || sideEffectsConfirmed(ctx) {
+		return nil
+	}
+	return fmt.Errorf("%w: node %s of thread %s may have run", ErrSideEffectNotConfirmed, interrupt.Node, interrupt.ThreadID)
+}
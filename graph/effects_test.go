@@ -0,0 +1,130 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestWithSideEffectsRetry(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		attempts int32
+	}{
+		{name: "unsafe", err: errors.New("timeout after sending"), attempts: 1},
+		{name: "retry safe", err: graph.RetrySafe(errors.New("connection refused")), attempts: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			g := graph.NewMessageGraph[[]string]("send")
+			g.AddNodeWithOptions("send", func(context.Context, []string) ([]string, error) {
+				attempts.Add(1)
+				return nil, tc.err
+			}, graph.WithSideEffects(), graph.WithRetry(3, 0))
+			g.SetFinishPoint("send")
+			runnable, err := g.Compile()
+			require.NoError(t, err)
+
+			_, err = runnable.Invoke(context.Background(), nil)
+			require.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.attempts, attempts.Load())
+		})
+	}
+}
+
+func TestRetrySafeNil(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, graph.RetrySafe(nil))
+}
+
+// sendGraph returns a graph drafting then sending an email, counting the emails sent, and
+// failing after sending while fail is set.
+func sendGraph(t *testing.T, sent *atomic.Int32, fail *atomic.Bool) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNodeWithOptions("send", func(_ context.Context, state []string) ([]string, error) {
+		sent.Add(1)
+		if fail.Load() {
+			return state, errors.New("connection reset")
+		}
+		return graph.AppendMessages(state, "send"), nil
+	}, graph.WithSideEffects())
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestWithSideEffectsRerun(t *testing.T) {
+	t.Parallel()
+
+	var sent atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	runnable := sendGraph(t, &sent, &fail)
+	ctx := graph.WithThreadID(graph.WithRunID(context.Background(), "run-1"), "thread")
+
+	// The run fails after sending: the thread is left paused before the send.
+	_, err := runnable.Invoke(ctx, nil)
+	require.Error(t, err)
+	interrupt, state, err := runnable.Pending(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, &graph.Interrupt{Node: "send", Step: 1, Next: []string{"send"}, From: []string{"draft"}, ThreadID: "thread", SideEffect: true}, interrupt)
+	assert.Equal(t, []string{"draft"}, state)
+
+	// Redelivering the run is refused, resuming it too, until confirmed.
+	fail.Store(false)
+	_, err = runnable.Invoke(ctx, nil)
+	require.ErrorIs(t, err, graph.ErrSideEffectNotConfirmed)
+	_, err = runnable.Resume(ctx, interrupt, state)
+	require.ErrorIs(t, err, graph.ErrSideEffectNotConfirmed)
+	assert.Equal(t, int32(1), sent.Load())
+
+	out, err := runnable.Resume(graph.ConfirmSideEffects(ctx), interrupt, state)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "send"}, out)
+	assert.Equal(t, int32(2), sent.Load())
+
+	// Once completed, the thread is no longer paused, and other runs send again.
+	_, _, err = runnable.Pending(ctx, "thread")
+	require.ErrorIs(t, err, graph.ErrNotInterrupted)
+	_, err = runnable.Invoke(graph.WithRunID(ctx, "run-2"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), sent.Load())
+}
+
+func TestWithSideEffectsNewRun(t *testing.T) {
+	t.Parallel()
+
+	var sent atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	runnable := sendGraph(t, &sent, &fail)
+	ctx := graph.WithThreadID(context.Background(), "thread")
+
+	// Runs without run ID are new runs.
+	_, err := runnable.Invoke(ctx, nil)
+	require.Error(t, err)
+	fail.Store(false)
+	_, err = runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), sent.Load())
+}
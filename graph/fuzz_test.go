@@ -0,0 +1,105 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+var errInjected = errors.New("injected failure")
+
+// FuzzMessageGraph builds random topologies from the input bytes and checks that compiling and
+// invoking them never panics, always terminates and only fails with the documented errors.
+//
+// Every node is described by one byte:
+//   - bits 0-3 select the edge target (another node, END, a missing node or no edge),
+//   - bit 4 makes the node fail,
+//   - bit 5 leaves the node without a function.
+func FuzzMessageGraph(f *testing.F) {
+	f.Add([]byte{0x01, 0x02, 0x03})       // chain ending in END
+	f.Add([]byte{0x01, 0x00})             // cycle
+	f.Add([]byte{0x13, 0x00, 0x01})       // failing node
+	f.Add([]byte{0x22, 0x01})             // node without function
+	f.Add([]byte{0x0f, 0x0e, 0x05, 0x02}) // missing targets and missing edges
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 16 {
+			data = data[:16]
+		}
+		n := len(data)
+		name := func(i int) string { return fmt.Sprintf("n%d", i) }
+
+		entry := "n0"
+		if n == 0 {
+			entry = "missing"
+		}
+		g := graph.NewMessageGraph[[]string](entry)
+
+		edges := map[string]string{}
+		for i, b := range data {
+			from := name(i)
+			var fn func(context.Context, []string) ([]string, error)
+			if b&0x20 == 0 {
+				fail := b&0x10 != 0
+				fn = func(_ context.Context, state []string) ([]string, error) {
+					if fail {
+						return state, errInjected
+					}
+					return graph.AppendMessages(state, from), nil
+				}
+			}
+			g.AddNode(from, fn)
+
+			switch target := int(b & 0x0f); {
+			case target < n:
+				edges[from] = name(target)
+			case target == n:
+				edges[from] = graph.END
+			case target == n+1:
+				edges[from] = "missing"
+			default:
+				continue
+			}
+			g.AddEdge(from, edges[from])
+		}
+
+		runnable, err := g.Compile()
+		if err != nil {
+			for _, expected := range []error{graph.ErrNodeNotFound, graph.ErrNilNodeFunction, graph.ErrNoOutgoingEdge, graph.ErrUnreachableNode} {
+				if errors.Is(err, expected) {
+					return
+				}
+			}
+			t.Fatalf("unexpected compile error: %v", err)
+			return
+		}
+
+		// Compile rejects the graphs whose structure makes invocations fail, and the step limit
+		// ends the static cycles.
+		output, err := runnable.Invoke(context.Background(), nil)
+		switch {
+		case err == nil:
+		case errors.Is(err, errInjected),
+			errors.Is(err, graph.ErrMaxStepsExceeded):
+			return
+		default:
+			t.Fatalf("unexpected invoke error: %v", err)
+		}
+
+		// A successful run must follow the edges from the entry point to END.
+		current := entry
+		for _, visited := range output {
+			if visited != current {
+				t.Fatalf("visited %s, expected %s (path %v)", visited, current, output)
+			}
+			current = edges[current]
+		}
+		if current != graph.END {
+			t.Fatalf("run finished at %s instead of END (path %v)", current, output)
+		}
+	})
+}
@@ -4,19 +4,81 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
 )
 
 // END is a special constant used to represent the end node in the graph.
 const END = "END"
 
+// START is a special constant used to represent the start of the graph: the edge from START
+// leads to the entry point.
+const START = "START"
+
 var (
 	// ErrNodeNotFound is returned when a node is not found in the graph.
 	ErrNodeNotFound = errors.New("node not found")
 
 	// ErrNoOutgoingEdge is returned when no outgoing edge is found for a node.
 	ErrNoOutgoingEdge = errors.New("no outgoing edge found for node")
+
+	// ErrEntryPointNotSet is returned by Compile when the graph has no entry point.
+	ErrEntryPointNotSet = errors.New("entry point not set")
+
+	// ErrNilNodeFunction is returned by Compile when a node has no function.
+	ErrNilNodeFunction = errors.New("node function is nil")
+
+	// ErrNilRouter is returned by Compile when a conditional edge has no router, or a send edge
+	// no sender.
+	ErrNilRouter = errors.New("router function is nil")
+
+	// ErrJoinNotSet is returned by Compile when a node has several outgoing edges or a send edge
+	// but the graph has no join to merge the states of the parallel branches.
+	ErrJoinNotSet = errors.New("join not set for parallel edges")
+
+	// ErrUndeclaredRoute is returned when a router returns a node that is not one of the routes
+	// declared with AddConditionalEdge.
+	ErrUndeclaredRoute = errors.New("router returned an undeclared route")
+
+	// ErrMaxStepsExceeded is returned by Invoke when a run reaches its step limit, typically
+	// because a cycle never exits; see WithMaxSteps.
+	ErrMaxStepsExceeded = errors.New("maximum number of steps exceeded")
 )
 
+// DefaultMaxSteps is the number of steps a run of a graph with cycles executes at most unless
+// WithMaxSteps sets another limit.
+const DefaultMaxSteps = 25
+
+type maxStepsKey struct{}
+
+// WithMaxSteps returns a context limiting the runs of Invoke to the given number of steps,
+// after which they fail with ErrMaxStepsExceeded. Nodes executed in parallel count as one
+// step, and resumed runs keep counting from the step they were interrupted at. A limit that is
+// not positive removes the limit. The limit also applies to each graph invoked by the nodes.
+func WithMaxSteps(ctx context.Context, steps int) context.Context {
+	return context.WithValue(ctx, maxStepsKey{}, steps)
+}
+
+// maxSteps returns the step limit of a run. Graphs without cycles always end, so they are
+// only limited by WithMaxSteps, unless rerouted edges added cycles.
+func (r *Runnable[T]) maxSteps(ctx context.Context) int {
+	if steps, ok := ctx.Value(maxStepsKey{}).(int); ok {
+		return steps
+	}
+	if r.isCyclic() {
+		return DefaultMaxSteps
+	}
+	return 0
+}
+
 // Node represents a node in the message graph.
 type Node[T any] struct {
 	// Name is the unique identifier for the node.
@@ -24,6 +86,21 @@ type Node[T any] struct {
 
 	// Function is the function associated with the node.
 	Function func(ctx context.Context, state T) (T, error)
+
+	// Resources are the resource hints of the node.
+	Resources Resources
+
+	// Retry tells how the node is retried when it fails.
+	Retry RetryPolicy
+
+	// Timeout bounds every execution of the node when positive.
+	Timeout time.Duration
+
+	// Chunks tells how the chunks the node streams are coalesced.
+	Chunks ChunkShaping
+
+	// SideEffects is set on side-effecting nodes; see WithSideEffects.
+	SideEffects bool
 }
 
 // Edge represents an edge in the message graph.
@@ -33,6 +110,38 @@ type Edge struct {
 
 	// To is the name of the node to which the edge points.
 	To string
+
+	// Label is a short name of the transition, shown on the edge in Mermaid and DOT output.
+	Label string
+
+	// Description explains why the transition exists, for reviewers of the rendered graph.
+	Description string
+
+	// Conditional is set on the edges of a Topology that are routes of a conditional edge.
+	Conditional bool
+
+	// Error is set on the edges of a Topology taken when their source node fails; see
+	// AddErrorEdge.
+	Error bool
+}
+
+// conditionalEdge is an outgoing edge whose target is picked by a router, or whose targets are
+// spawned by a sender.
+type conditionalEdge[T any] struct {
+	// router returns the name of the next node.
+	router func(ctx context.Context, state T) (string, error)
+
+	// sender returns the sends to execute next, for send edges.
+	sender func(ctx context.Context, state T) ([]Send[T], error)
+
+	// command is set on the edges of command nodes, which return the next node themselves.
+	command bool
+
+	// routes are the nodes the router may return, or the sends target; empty if not declared.
+	routes []string
+
+	// declared is the set of the routes, built by Compile so that dispatch does not scan them.
+	declared map[string]bool
 }
 
 // MessageGraph represents a message graph.
@@ -40,19 +149,48 @@ type MessageGraph[T any] struct {
 	// nodes is a map of node names to their corresponding Node objects.
 	nodes map[string]Node[T]
 
-	// edges is a slice of Edge objects representing the connections between nodes.
-	edges map[string]Edge
+	// edges is a map of node names to their outgoing edges, in the order they were added.
+	edges map[string][]Edge
+
+	// conditionalEdges is a map of node names to the conditional edge leaving them.
+	conditionalEdges map[string]conditionalEdge[T]
+
+	// errorEdges is a map of node names to the edge taken when they fail.
+	errorEdges map[string]errorEdge[T]
 
 	// entryPoint is the name of the entry point node in the graph.
 	entryPoint string
+
+	// join merges the states of the nodes executed in parallel.
+	join JoinFunc[T]
+
+	// branchPolicies is a map of node names to the policy of the parallel steps they fan out
+	// to; FailFast when missing.
+	branchPolicies map[string]BranchPolicy
+
+	// prefetches is a map of prefetch names to the functions fetching them.
+	prefetches map[string]func(ctx context.Context, state T) (any, error)
+
+	// speculations is a map of node names to the routing stats their conditional edge is
+	// speculated on; see SetSpeculation.
+	speculations map[string]*RoutingStats
+
+	// reduce applies the updates of UpdateState; nil replaces the state.
+	reduce func(state, update T) T
 }
 
-// NewMessageGraph creates a new instance of MessageGraph.
+// NewMessageGraph creates a new instance of MessageGraph. The entry point may be left empty
+// and set later with SetEntryPoint or an edge from START.
 func NewMessageGraph[T any](entryPoint string) *MessageGraph[T] {
 	g := &MessageGraph[T]{
-		nodes:      make(map[string]Node[T]),
-		entryPoint: entryPoint,
-		edges:      make(map[string]Edge),
+		nodes:            make(map[string]Node[T]),
+		entryPoint:       entryPoint,
+		edges:            make(map[string][]Edge),
+		conditionalEdges: make(map[string]conditionalEdge[T]),
+		errorEdges:       make(map[string]errorEdge[T]),
+		branchPolicies:   make(map[string]BranchPolicy),
+		prefetches:       make(map[string]func(ctx context.Context, state T) (any, error)),
+		speculations:     make(map[string]*RoutingStats),
 	}
 
 	g.AddNode(END, nil)
@@ -61,64 +199,619 @@ func NewMessageGraph[T any](entryPoint string) *MessageGraph[T] {
 
 // AddNode adds a new node to the message graph with the given name and function.
 func (g *MessageGraph[T]) AddNode(name string, fn func(ctx context.Context, state T) (T, error)) {
+	g.AddNodeWithOptions(name, fn)
+}
+
+// AddNodeWithOptions is like AddNode but configures the node with options, such as its
+// resource hints, its retry policy or its timeout.
+func (g *MessageGraph[T]) AddNodeWithOptions(name string, fn func(ctx context.Context, state T) (T, error), opts ...NodeOption) {
+	var o nodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	g.nodes[name] = Node[T]{
-		Name:     name,
-		Function: fn,
+		Name:        name,
+		Function:    fn,
+		Resources:   o.resources,
+		Retry:       o.retry,
+		Timeout:     o.timeout,
+		Chunks:      o.chunks,
+		SideEffects: o.sideEffects,
 	}
 }
 
 // AddEdge adds a new edge to the message graph between the "from" and "to" nodes.
+//
+// A node may have several outgoing edges: their targets are then executed concurrently on the
+// state the node returned, and their states are merged with the join set with SetJoin before
+// execution continues with the targets of their own edges.
+//
+// An edge from START sets the entry point, like SetEntryPoint: the graph has a single entry
+// point, so it replaces the previous one.
 func (g *MessageGraph[T]) AddEdge(from, to string) {
-	g.edges[from] = Edge{
-		From: from,
-		To:   to,
+	g.AddLabeledEdge(from, to, "", "")
+}
+
+// AddLabeledEdge is like AddEdge but attaches a label and a description to the edge. Adding
+// an edge between the same nodes again replaces its label and description. Edges from START
+// are not labeled.
+func (g *MessageGraph[T]) AddLabeledEdge(from, to, label, description string) {
+	if from == START {
+		g.SetEntryPoint(to)
+		return
+	}
+	delete(g.conditionalEdges, from)
+	edge := Edge{
+		From:        from,
+		To:          to,
+		Label:       label,
+		Description: description,
+	}
+	for i, existing := range g.edges[from] {
+		if existing.To == to {
+			g.edges[from][i] = edge
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], edge)
+}
+
+// SetEntryPoint sets the node executed first, replacing the one passed to NewMessageGraph.
+func (g *MessageGraph[T]) SetEntryPoint(name string) {
+	g.entryPoint = name
+}
+
+// SetFinishPoint makes execution end after the node: it is a shorthand for AddEdge(name, END).
+func (g *MessageGraph[T]) SetFinishPoint(name string) {
+	g.AddEdge(name, END)
+}
+
+// SetJoin sets the function merging the states of the nodes executed in parallel, which is
+// required when a node has several outgoing edges or a send edge. It is called with the state the parallel
+// nodes received and their results, in the order their edges were added. With the default
+// FailFast policy all the results passed to join succeeded; see SetBranchPolicy.
+func (g *MessageGraph[T]) SetJoin(join JoinFunc[T]) {
+	g.join = join
+}
+
+// SetBranchPolicy sets how the nodes executed in parallel after the "from" node, through its
+// parallel edges or its send edge, react to their failures, as FanOut does for its branches:
+// FailFast, the default, fails the run on the first failure; BestEffort and Quorum pass the
+// failed results, with their Err set, to the join, and continue with the targets of the edges
+// of the nodes that succeeded. A step whose nodes all failed fails the run.
+//
+// When the nodes of a step were reached from several nodes, e.g. after a parallel step, the
+// policy of the first of them that has one applies.
+func (g *MessageGraph[T]) SetBranchPolicy(from string, policy BranchPolicy) {
+	g.branchPolicies[from] = policy
+}
+
+// AddConditionalEdge adds an outgoing edge to the "from" node whose target is the node returned
+// by router, called with the state the node returned. It replaces the edges added before from
+// the same node, and is replaced by the edges added after.
+//
+// The routes are the nodes the router may return. They are optional but let Topology, Lint and
+// the renderers know the possible transitions, and invocations fail with ErrUndeclaredRoute
+// when the router returns another node.
+func (g *MessageGraph[T]) AddConditionalEdge(from string, router func(ctx context.Context, state T) (string, error), routes ...string) {
+	delete(g.edges, from)
+	g.conditionalEdges[from] = conditionalEdge[T]{
+		router: router,
+		routes: append([]string(nil), routes...),
 	}
 }
 
 // Runnable represents a compiled message graph that can be invoked.
+//
+// A Runnable is safe for concurrent use: Invoke may be called from many goroutines at once.
+// It executes a snapshot of the graph taken by Compile, so the graph it was compiled from may
+// be modified, and compiled again, without affecting it.
 type Runnable[T any] struct {
-	// graph is the underlying MessageGraph object.
+	// graph is the snapshot of the MessageGraph taken by Compile, which is never modified.
 	graph *MessageGraph[T]
+
+	// interruptsBefore are the nodes execution pauses before.
+	interruptsBefore []string
+
+	// interruptsAfter are the nodes execution pauses after.
+	interruptsAfter []string
+
+	// checkpointer saves the interrupts of invocations with a thread ID.
+	checkpointer checkpoint.Checkpointer
+
+	// strictState rejects the states read from the checkpointer not matching their type.
+	strictState bool
+
+	// snapshotEvery is the number of checkpoints between full snapshots of the invocations
+	// saving delta checkpoints; 0 if they save full checkpoints only. See WithDeltaCheckpoints.
+	snapshotEvery int
+
+	// propagatePanics lets the panics of nodes crash the process; see WithPanicPropagation.
+	propagatePanics bool
+
+	// cyclic is set when runs may execute a node several times, through a cycle or a router
+	// without declared routes.
+	cyclic bool
+
+	// plan is the plan the graph was compiled with; see WithPlan.
+	plan Plan
+
+	// compiledCallbacks are the callbacks registered with WithCallbacks.
+	compiledCallbacks []Callbacks[T]
+
+	// tracer traces invocations when set with WithTracerProvider.
+	tracer trace.Tracer
+
+	// traceRedact rewrites the strings recorded on spans when set with WithTraceRedaction.
+	traceRedact func(string) string
+
+	// patches are the patches applied at runtime; see Patch.
+	patches patches[T]
 }
 
 // Compile compiles the message graph and returns a Runnable instance.
-// It returns an error if the entry point is not set.
-func (g *MessageGraph[T]) Compile() (*Runnable[T], error) {
+// It returns an error if the entry point is not set (ErrEntryPointNotSet) or is not a node. Otherwise it validates
+// the whole graph and reports every problem found, joined: nodes named invalidly
+// (ErrEmptyName, ErrReservedName or ErrInvalidName; see ValidateName), nodes without function
+// (ErrNilNodeFunction), conditional edges without router (ErrNilRouter), edges and routes to
+// missing nodes (ErrNodeNotFound), nodes without outgoing edge (ErrNoOutgoingEdge), nodes
+// that cannot be reached from the entry point (ErrUnreachableNode) and parallel or send edges
+// without join (ErrJoinNotSet), as well as interrupts configured on missing nodes. With
+// WithDeltaCheckpoints, the state must be a slice (ErrStateNotSlice). With
+// WithPlan, the validation is skipped when the plan matches the graph.
+// The Runnable executes a snapshot of the graph: modifying the graph after Compile does not
+// affect it.
+func (g *MessageGraph[T]) Compile(opts ...CompileOption) (*Runnable[T], error) {
+	if g.entryPoint == "" {
+		return nil, ErrEntryPointNotSet
+	}
+	if _, ok := g.nodes[g.entryPoint]; !ok {
+		return nil, fmt.Errorf("entry point: %w: %s", ErrNodeNotFound, g.entryPoint)
+	}
+	var o compileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if t := reflect.TypeFor[T](); o.deltas && t.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("delta checkpoints: %w: %s", ErrStateNotSlice, t)
+	}
+	topology := g.Topology()
+	plan := g.planOf(topology, o, false)
+	if o.plan == nil || o.plan.Version != PlanVersion || o.plan.Fingerprint != plan.Fingerprint {
+		if err := errors.Join(g.validate(), g.validateInterrupts(o)); err != nil {
+			return nil, err
+		}
+		plan.Cyclic = topology.cyclic()
+	} else {
+		plan.Cyclic = o.plan.Cyclic
+	}
+	callbacks, err := compileCallbacks[T](o.callbacks)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Runnable[T]{
-		graph: g,
+		graph:             g.snapshot(),
+		interruptsBefore:  o.interruptBefore,
+		interruptsAfter:   o.interruptAfter,
+		checkpointer:      o.checkpointer,
+		strictState:       o.strictState,
+		snapshotEvery:     o.snapshotEvery,
+		propagatePanics:   o.propagatePanics,
+		compiledCallbacks: callbacks,
+		tracer:            o.tracer,
+		traceRedact:       o.traceRedact,
+		cyclic:            plan.Cyclic,
+		plan:              plan,
 	}, nil
 }
 
+// snapshot returns a copy of the graph sharing nothing the methods of the graph modify.
+func (g *MessageGraph[T]) snapshot() *MessageGraph[T] {
+	edges := make(map[string][]Edge, len(g.edges))
+	for from, out := range g.edges {
+		edges[from] = slices.Clone(out)
+	}
+	conditionalEdges := make(map[string]conditionalEdge[T], len(g.conditionalEdges))
+	for from, edge := range g.conditionalEdges {
+		edge.routes = slices.Clone(edge.routes)
+		edge.declared = make(map[string]bool, len(edge.routes))
+		for _, route := range edge.routes {
+			edge.declared[route] = true
+		}
+		conditionalEdges[from] = edge
+	}
+	return &MessageGraph[T]{
+		nodes:            maps.Clone(g.nodes),
+		edges:            edges,
+		conditionalEdges: conditionalEdges,
+		errorEdges:       maps.Clone(g.errorEdges),
+		entryPoint:       g.entryPoint,
+		join:             g.join,
+		branchPolicies:   maps.Clone(g.branchPolicies),
+		prefetches:       maps.Clone(g.prefetches),
+		speculations:     maps.Clone(g.speculations),
+		reduce:           g.reduce,
+	}
+}
+
 // Invoke executes the compiled message graph with the given input messages.
 // It returns the resulting state and an error if any occurs during the execution.
-// Invoke executes the compiled message graph with the given input messages.
-// It returns the resulting state and an error if any occurs during the execution.
+// Execution stops before the next node once the context is done.
+//
+// Nodes with several outgoing edges fan out: the targets of their edges run concurrently as one
+// step, their states are merged with the join of the graph and the next step runs the targets
+// of all their edges. A branch reaching END ends while the others continue. Nodes with a send
+// edge fan out the same way, over the sends they spawn; see AddSendEdge.
+//
+// When the context was created by WithScoring, the states produced are scored as they are
+// produced, and a hard scorer rejecting one aborts the run.
+//
+// Graphs may have cycles, such as an agent calling tools until it can answer. Their runs
+// execute at most DefaultMaxSteps steps, or the limit set with WithMaxSteps, and fail with
+// ErrMaxStepsExceeded beyond, returning the state reached.
+//
+// When execution reaches an interrupt configured at Compile, Invoke returns the state reached
+// and an *Interrupt error, from which Resume continues.
+//
+// When the graph was compiled WithCheckpointer and the context carries a thread ID set with
+// WithThreadID, the state after every step is saved to the thread, so InvokeFrom can replay it
+// from there, and the final state as a checkpoint of END, which GetState returns and UpdateState
+// patches between invocations. Invocations made by nodes, of subgraphs, are not saved.
 func (r *Runnable[T]) Invoke(ctx context.Context, state T) (T, error) {
-	currentNode := r.graph.entryPoint
+	if err := r.checkRerun(ctx); err != nil {
+		return state, err
+	}
+	ctx = r.withDeltaChain(ctx)
+	state, err := r.run(ctx, state, []string{r.graph.entryPoint}, nil, 0, false)
+	if err != nil {
+		return state, err
+	}
+	if threadID := threadIDFromContext(ctx); r.checkpointer != nil && threadID != "" && currentNodeName(ctx) == "" {
+		if err := r.save(ctx, threadID, END, state, nil); err != nil {
+			return state, fmt.Errorf("saving thread %s: %w", threadID, err)
+		}
+	}
+	return state, nil
+}
 
-	for {
-		if currentNode == END {
+// run executes the graph from the nodes of the step with the given index, reached from the
+// "from" nodes, notifying the callbacks of the invocation and tracing it.
+func (r *Runnable[T]) run(ctx context.Context, state T, current, from []string, index int, resumed bool) (T, error) {
+	var attrs []attribute.KeyValue
+	if resumed {
+		attrs = append(attrs, AttributeResumed.Bool(true))
+	}
+	ctx, end := r.startSpan(withRun(ctx), "graph.invoke", attrs...)
+	callbacks := r.callbacks(ctx)
+	callbacks.graphStart(ctx, state)
+	state, err := r.steps(ctx, state, current, from, index, resumed)
+	callbacks.graphEnd(ctx, state, err)
+	end(err)
+	return state, err
+}
+
+// steps executes the graph from the nodes of the step with the given index, reached from the
+// "from" nodes. The interrupts before the nodes of the first step are skipped when resumed is
+// set.
+func (r *Runnable[T]) steps(ctx context.Context, state T, current, from []string, index int, resumed bool) (T, error) {
+	start := time.Now()
+	maxSteps := r.maxSteps(ctx)
+
+	profile := profileFromContext(ctx)
+	outermost := currentNodeName(ctx) == ""
+	if outermost {
+		// Only the outermost invocation measures the total time.
+		defer profile.finish()
+	}
+
+	if len(r.graph.prefetches) > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		ctx = r.startPrefetches(ctx, state)
+	}
+
+	// speculation is the speculative execution of the node of the next step, if any; see
+	// SetSpeculation.
+	var speculation speculationSlot[T]
+	defer speculation.discard()
+
+	// sends are the sends to execute in the next step instead of the current nodes.
+	var sends []Send[T]
+	// stepped is set once a step was executed.
+	stepped := false
+	for ; ; index++ {
+		current = slices.DeleteFunc(current, func(node string) bool { return node == END })
+		if len(sends) > 0 {
+			current = sendNodes(sends)
+		}
+		if len(current) == 0 {
 			break
 		}
 
-		node, ok := r.graph.nodes[currentNode]
-		if !ok {
-			return state, fmt.Errorf("%w: %s", ErrNodeNotFound, currentNode)
+		if err := ctx.Err(); err != nil {
+			return state, err
+		}
+		if maxSteps > 0 && index >= maxSteps {
+			return state, fmt.Errorf("%w: limit of %d reached before node %s", ErrMaxStepsExceeded, maxSteps, strings.Join(current, ", "))
+		}
+		if meter := UsageMeterFrom(ctx); meter.budgetExceeded() {
+			return state, fmt.Errorf("%w: budget of %d tokens used before node %s", ErrTokenBudgetExceeded, meter.TokenBudget, strings.Join(current, ", "))
 		}
 
+		// The states of sends are not saved, so they cannot be resumed.
+		if interrupt := r.interruptBefore(index, current, from); interrupt != nil && !resumed && len(sends) == 0 {
+			return state, r.pause(ctx, interrupt, state)
+		}
+		// The step the invocation started or resumed from needs no checkpoint of its own.
+		if len(sends) == 0 {
+			marked, err := r.markSideEffects(ctx, index, current, from, state)
+			if err != nil {
+				return state, err
+			}
+			if !marked && stepped {
+				if err := r.saveStep(ctx, index, current, from, state); err != nil {
+					return state, err
+				}
+			}
+		}
+		resumed, stepped = false, true
+
+		executed := current
+		policy := r.branchPolicy(from)
+		from = executed
 		var err error
-		state, err = node.Function(ctx, state)
+		switch {
+		case len(sends) > 0:
+			speculation.discard()
+			state, current, sends, err = r.parallelStep(ctx, index, policy, sends, state)
+		case len(current) == 1:
+			var taken []Edge
+			state, taken, sends, err = r.step(ctx, index, current[0], state, &speculation)
+			current = targets(taken)
+		default:
+			speculation.discard()
+			state, current, sends, err = r.parallelStep(ctx, index, policy, sendAll(current, state), state)
+		}
+		if len(sends) > 0 {
+			current = sendNodes(sends)
+		}
 		if err != nil {
-			return state, fmt.Errorf("error in node %s: %w", currentNode, err)
+			return state, err
 		}
 
-		edge, foundNext := r.graph.edges[currentNode]
-		if foundNext {
-			currentNode = edge.To
-		} else {
-			return state, fmt.Errorf("%w: %s", ErrNoOutgoingEdge, currentNode)
+		if interrupt := r.interruptAfter(index, executed, current); interrupt != nil && len(sends) == 0 {
+			return state, r.pause(ctx, interrupt, state)
 		}
 	}
 
+	if outermost {
+		final := ScoreInput[T]{State: state, Duration: time.Since(start)}
+		if err := scoringFromContext[T](ctx).score(ctx, final); err != nil {
+			return state, err
+		}
+	}
 	return state, nil
 }
+
+// step executes a node as the step with the given index and returns its state and the edges
+// leading to the nodes to execute next, or the sends to execute next if the node leaves through
+// a send edge. The node uses the result of the speculative execution of the slot, if it executed
+// the node, and may leave the speculative execution of the next step in the slot; the slot is
+// nil in parallel steps.
+func (r *Runnable[T]) step(ctx context.Context, index int, currentNode string, state T, speculation *speculationSlot[T]) (T, []Edge, []Send[T], error) {
+	speculated := speculation.take(currentNode)
+	node, ok := r.graph.nodes[currentNode]
+	if !ok {
+		speculated.discard()
+		return state, nil, nil, fmt.Errorf("%w: %s", ErrNodeNotFound, currentNode)
+	}
+
+	resources := node.Resources
+	if speculated != nil {
+		// The speculative execution holds the resources of the node.
+		resources = Resources{}
+	}
+	nodeCtx, release, err := acquireResources(ctx, resources)
+	if err != nil {
+		speculated.discard()
+		return state, nil, nil, err
+	}
+
+	callbacks := r.callbacks(ctx)
+	callbacks.nodeStart(ctx, currentNode, state)
+	profile := profileFromContext(ctx)
+	input := profile.captureInput(state)
+	start := time.Now()
+	nodeCtx, end := r.startSpan(nodeCtx, currentNode, nodeSpanAttributes(ctx, currentNode, index)...)
+	nodeCtx, flushChunks := withChunks(nodeCtx, streamFromContext[T](ctx), index, currentNode, currentBranch(ctx), node.Chunks)
+	nodeCtx = withActivity(nodeCtx, streamFromContext[T](ctx), index, currentNode, currentBranch(ctx), flushChunks)
+	conditional, routed := r.graph.conditionalEdges[currentNode]
+	var command commandTarget
+	if conditional.command {
+		nodeCtx = withCommand(nodeCtx, &command)
+	}
+	called := state
+	if p, disabled := r.patch(PatchDisable, currentNode); disabled {
+		speculated.discard()
+		state = r.fallback(p, state)
+		command.next = p.To
+	} else if speculated != nil {
+		state, err = speculated.wait()
+	} else {
+		state, err = r.call(withNodeName(withoutStream(nodeCtx), currentNode), node, state)
+	}
+	_ = flushChunks()
+	end(err)
+	release()
+	callbacks.nodeEnd(ctx, currentNode, state, err)
+	profile.record(ProfileEntry{
+		Kind:      SpanNode,
+		Name:      currentNode,
+		Node:      currentNode,
+		Start:     start,
+		Input:     input.data,
+		InputSize: input.size,
+	})
+	if err != nil {
+		state, edges, err := r.onError(ctx, index, called, state, &NodeError{Node: currentNode, Err: err})
+		return state, edges, nil, err
+	}
+
+	stream := streamFromContext[T](ctx)
+	branch := currentBranch(ctx)
+	stream.emit(StreamEvent[T]{Kind: EventNode, Step: index, Node: currentNode, Branch: branch, State: state})
+
+	scored := ScoreInput[T]{Node: currentNode, State: state, Duration: time.Since(start)}
+	if err := scoringFromContext[T](ctx).score(ctx, scored); err != nil {
+		return state, nil, nil, err
+	}
+
+	edges := r.graph.edges[currentNode]
+	var sends []Send[T]
+	p, rerouted := r.patch(PatchReroute, currentNode)
+	switch {
+	case rerouted:
+		edges = []Edge{{From: currentNode, To: p.To}}
+	case routed && conditional.command:
+		next, err := conditional.goTo(currentNode, &command)
+		if err != nil {
+			return state, nil, nil, fmt.Errorf("error in command of node %s: %w", currentNode, err)
+		}
+		edges = []Edge{{From: currentNode, To: next, Conditional: true}}
+	case routed && conditional.sender != nil:
+		sends, err = conditional.send(withNodeName(withoutStream(ctx), currentNode), state)
+		if err != nil {
+			return state, nil, nil, fmt.Errorf("error in sender of node %s: %w", currentNode, err)
+		}
+		edges = nil
+		seen := make(map[string]bool, len(sends))
+		for _, send := range sends {
+			if !seen[send.Node] {
+				seen[send.Node] = true
+				edges = append(edges, Edge{From: currentNode, To: send.Node, Conditional: true})
+			}
+		}
+	case routed:
+		run := r.speculate(ctx, currentNode, conditional, speculation, state)
+		next, err := conditional.route(withNodeName(withoutStream(ctx), currentNode), state)
+		if err != nil {
+			run.discard()
+			return state, nil, nil, fmt.Errorf("error in router of node %s: %w", currentNode, err)
+		}
+		r.routeSpeculated(currentNode, next, run, speculation)
+		edges = []Edge{{From: currentNode, To: next, Conditional: true}}
+	}
+	if len(edges) == 0 && conditional.sender == nil {
+		return state, nil, nil, fmt.Errorf("%w: %s", ErrNoOutgoingEdge, currentNode)
+	}
+
+	stream.emit(StreamEvent[T]{Kind: EventRoute, Step: index, Node: currentNode, Branch: branch, Edges: edges})
+	if sends != nil {
+		// The sends replace the edges, which are only reported.
+		return state, nil, sends, nil
+	}
+	return state, edges, nil, nil
+}
+
+// targets returns the nodes the edges point to.
+func targets(edges []Edge) []string {
+	nodes := make([]string, len(edges))
+	for i, edge := range edges {
+		nodes[i] = edge.To
+	}
+	return nodes
+}
+
+// branchPolicy returns the policy of the parallel step reached from the nodes.
+func (r *Runnable[T]) branchPolicy(from []string) BranchPolicy {
+	for _, node := range from {
+		if policy, ok := r.graph.branchPolicies[node]; ok {
+			return policy
+		}
+	}
+	return FailFast
+}
+
+// parallelStep executes the sends concurrently with the policy, merges their states with the
+// join of the graph and returns the nodes to execute next, each once, in the order of the sends
+// leading to them. If some of the sends spawned sends themselves, it returns the sends to
+// execute next instead: those spawned, after the nodes to execute next on the merged state.
+// Failed sends lead nowhere.
+func (r *Runnable[T]) parallelStep(ctx context.Context, index int, policy BranchPolicy, tasks []Send[T], state T) (T, []string, []Send[T], error) {
+	nodes := sendNodes(tasks)
+	names := branchNames(nodes)
+	next := make([][]Edge, len(tasks))
+	spawned := make([][]Send[T], len(tasks))
+	branches := make([]Branch[T], len(tasks))
+	for i, task := range tasks {
+		branches[i] = Branch[T]{
+			Name: names[i],
+			Function: func(ctx context.Context, _ T) (T, error) {
+				out, edges, sends, err := r.step(withBranch(ctx, names[i]), index, task.Node, Clip(task.State), nil)
+				next[i], spawned[i] = edges, sends
+				return out, err
+			},
+		}
+	}
+
+	results, err := runBranches(ctx, policy, state, branches)
+	if err != nil {
+		return state, nil, nil, err
+	}
+	var errs []error
+	for i, task := range tasks {
+		results[i].Input = Clip(task.State)
+		if results[i].Err != nil {
+			next[i], spawned[i] = nil, nil
+			errs = append(errs, fmt.Errorf("branch %s: %w", results[i].Name, results[i].Err))
+		}
+	}
+	if len(errs) == len(tasks) {
+		return state, nil, nil, errors.Join(errs...)
+	}
+	merged, err := r.graph.join(ctx, state, results)
+	if err != nil {
+		return state, nil, nil, fmt.Errorf("error joining nodes %s: %w", strings.Join(nodes, ", "), err)
+	}
+	streamFromContext[T](ctx).emit(StreamEvent[T]{Kind: EventMerge, Step: index, Branches: names, State: merged})
+
+	var union []string
+	for _, edges := range next {
+		for _, target := range targets(edges) {
+			if !slices.Contains(union, target) {
+				union = append(union, target)
+			}
+		}
+	}
+	sends := slices.Concat(spawned...)
+	if len(sends) == 0 {
+		return merged, union, nil, nil
+	}
+	return merged, nil, append(sendAll(union, merged), sends...), nil
+}
+
+// route calls the router and checks it returned one of the declared routes, if any.
+func (c conditionalEdge[T]) route(ctx context.Context, state T) (string, error) {
+	next, err := c.router(ctx, state)
+	if err != nil {
+		return "", err
+	}
+	if !c.declares(next) {
+		return "", fmt.Errorf("%w: %q", ErrUndeclaredRoute, next)
+	}
+	return next, nil
+}
+
+// declares reports whether the node is one of the routes of the edge, or the edge declares
+// none.
+func (c conditionalEdge[T]) declares(node string) bool {
+	if len(c.routes) == 0 {
+		return true
+	}
+	if c.declared != nil {
+		return c.declared[node]
+	}
+	return slices.Contains(c.routes, node)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cesto93/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExampleMessageGraph(t *testing.T) {
@@ -74,7 +75,7 @@ func TestMessageGraph(t *testing.T) {
 				g.AddEdge("node1", "node2")
 				return g
 			},
-			expectedError: fmt.Errorf("%w: node2", graph.ErrNodeNotFound),
+			expectedError: graph.ErrNodeNotFound,
 		},
 		{
 			name: "No outgoing edge",
@@ -85,7 +86,31 @@ func TestMessageGraph(t *testing.T) {
 				})
 				return g
 			},
-			expectedError: fmt.Errorf("%w: node1", graph.ErrNoOutgoingEdge),
+			expectedError: graph.ErrNoOutgoingEdge,
+		},
+		{
+			name: "Entry point not set",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				return graph.NewMessageGraph[[]string]("")
+			},
+			expectedError: graph.ErrEntryPointNotSet,
+		},
+		{
+			name: "Entry point not found",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				return graph.NewMessageGraph[[]string]("node1")
+			},
+			expectedError: graph.ErrNodeNotFound,
+		},
+		{
+			name: "Nil node function",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("node1")
+				g.AddNode("node1", nil)
+				g.AddEdge("node1", graph.END)
+				return g
+			},
+			expectedError: graph.ErrNilNodeFunction,
 		},
 		{
 			name: "Error in node function",
@@ -99,6 +124,84 @@ func TestMessageGraph(t *testing.T) {
 			},
 			expectedError: errors.New("error in node node1: node error"),
 		},
+		{
+			name: "Conditional edge loop",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("agent")
+				g.AddNode("agent", func(_ context.Context, state []string) ([]string, error) {
+					return append(state, "agent"), nil
+				})
+				g.AddNode("tools", func(_ context.Context, state []string) ([]string, error) {
+					return append(state, "tools"), nil
+				})
+				g.AddConditionalEdge("agent", func(_ context.Context, state []string) (string, error) {
+					if len(state) < 4 {
+						return "tools", nil
+					}
+					return graph.END, nil
+				}, "tools", graph.END)
+				g.AddEdge("tools", "agent")
+				return g
+			},
+			inputMessages:  []string{"Input"},
+			expectedOutput: []string{"Input", "agent", "tools", "agent"},
+		},
+		{
+			name: "Conditional edge replaces edge",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("node1")
+				g.AddNode("node1", func(_ context.Context, state []string) ([]string, error) {
+					return append(state, "Node 1"), nil
+				})
+				g.AddEdge("node1", "node1")
+				g.AddConditionalEdge("node1", func(context.Context, []string) (string, error) {
+					return graph.END, nil
+				})
+				return g
+			},
+			inputMessages:  []string{"Input"},
+			expectedOutput: []string{"Input", "Node 1"},
+		},
+		{
+			name: "Error in router",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("node1")
+				g.AddNode("node1", func(_ context.Context, state []string) ([]string, error) {
+					return state, nil
+				})
+				g.AddConditionalEdge("node1", func(context.Context, []string) (string, error) {
+					return "", errors.New("router error")
+				})
+				return g
+			},
+			expectedError: errors.New("error in router of node node1: router error"),
+		},
+		{
+			name: "Undeclared route",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("node1")
+				g.AddNode("node1", func(_ context.Context, state []string) ([]string, error) {
+					return state, nil
+				})
+				g.AddConditionalEdge("node1", func(context.Context, []string) (string, error) {
+					return "node2", nil
+				}, graph.END)
+				return g
+			},
+			expectedError: fmt.Errorf("error in router of node node1: %w: \"node2\"", graph.ErrUndeclaredRoute),
+		},
+		{
+			name: "Nil router",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("node1")
+				g.AddNode("node1", func(_ context.Context, state []string) ([]string, error) {
+					return state, nil
+				})
+				g.AddConditionalEdge("node1", nil)
+				return g
+			},
+			expectedError: graph.ErrNilRouter,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -139,3 +242,290 @@ func TestMessageGraph(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileReportsAllProblems(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("node1")
+	g.AddNode("node1", func(_ context.Context, state []string) ([]string, error) {
+		return state, nil
+	})
+	g.AddNode("node2", nil)
+	g.AddNode("node3", func(_ context.Context, state []string) ([]string, error) {
+		return state, nil
+	})
+	g.AddConditionalEdge("node1", func(context.Context, []string) (string, error) {
+		return graph.END, nil
+	}, "missing", graph.END)
+	g.AddEdge("node2", graph.END)
+
+	_, err := g.Compile()
+	for _, expected := range []error{graph.ErrNilNodeFunction, graph.ErrNodeNotFound, graph.ErrNoOutgoingEdge, graph.ErrUnreachableNode} {
+		assert.ErrorIs(t, err, expected)
+	}
+	assert.EqualError(t, err, `node function is nil: node2
+edge from node1: node not found: missing
+no outgoing edge found for node: node3
+node is not reachable from the entry point: node2
+node is not reachable from the entry point: node3`)
+}
+
+func TestStartAndFinishPoint(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("send", appendNode("send"))
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrEntryPointNotSet)
+
+	g.AddEdge(graph.START, "draft")
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	assert.Equal(t, "draft", g.Topology().EntryPoint)
+	assert.Equal(t, []string{graph.END}, g.Topology().Successors("send"))
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	out, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "send"}, out)
+
+	// The graph has a single entry point.
+	g.AddEdge(graph.START, "send")
+	assert.Equal(t, "send", g.Topology().EntryPoint)
+	g.SetEntryPoint("missing")
+	_, err = g.Compile()
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+}
+
+func TestParallelEdges(t *testing.T) {
+	t.Parallel()
+
+	appendNode := func(name string) func(context.Context, []string) ([]string, error) {
+		return func(_ context.Context, state []string) ([]string, error) {
+			return graph.AppendMessages(state, name), nil
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		buildGraph    func() *graph.MessageGraph[[]string]
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "fan-out and fan-in",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("split")
+				for _, name := range []string{"split", "left", "right", "merge"} {
+					g.AddNode(name, appendNode(name))
+				}
+				g.AddEdge("split", "left")
+				g.AddEdge("split", "right")
+				g.AddEdge("left", "merge")
+				g.AddEdge("right", "merge")
+				g.AddEdge("merge", graph.END)
+				g.SetJoin(graph.Concatenate[string]())
+				return g
+			},
+			expected: []string{"input", "split", "left", "right", "merge"},
+		},
+		{
+			name: "branch reaching END",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("split")
+				for _, name := range []string{"split", "done", "more", "last"} {
+					g.AddNode(name, appendNode(name))
+				}
+				g.AddEdge("split", "done")
+				g.AddEdge("split", "more")
+				g.AddEdge("done", graph.END)
+				g.AddEdge("more", "last")
+				g.AddEdge("last", graph.END)
+				g.SetJoin(graph.Concatenate[string]())
+				return g
+			},
+			expected: []string{"input", "split", "done", "more", "last"},
+		},
+		{
+			name: "failing branch",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("split")
+				g.AddNode("split", appendNode("split"))
+				g.AddNode("ok", appendNode("ok"))
+				g.AddNode("broken", func(context.Context, []string) ([]string, error) {
+					return nil, errors.New("broken")
+				})
+				g.AddEdge("split", "ok")
+				g.AddEdge("split", "broken")
+				g.AddEdge("ok", graph.END)
+				g.AddEdge("broken", graph.END)
+				g.SetJoin(graph.Concatenate[string]())
+				return g
+			},
+			expectedError: "branch broken: error in node broken: broken",
+		},
+		{
+			name: "failing join",
+			buildGraph: func() *graph.MessageGraph[[]string] {
+				g := graph.NewMessageGraph[[]string]("split")
+				for _, name := range []string{"split", "left", "right"} {
+					g.AddNode(name, appendNode(name))
+				}
+				g.AddEdge("split", "left")
+				g.AddEdge("split", "right")
+				g.AddEdge("left", graph.END)
+				g.AddEdge("right", graph.END)
+				g.SetJoin(func(context.Context, []string, []graph.BranchResult[[]string]) ([]string, error) {
+					return nil, errors.New("conflict")
+				})
+				return g
+			},
+			expectedError: "error joining nodes left, right: conflict",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, err := tc.buildGraph().Compile()
+			require.NoError(t, err)
+
+			output, err := runnable.Invoke(context.Background(), []string{"input"})
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestParallelEdgesRequireJoin(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("split")
+	for _, name := range []string{"split", "left", "right"} {
+		g.AddNode(name, func(_ context.Context, state []string) ([]string, error) { return state, nil })
+	}
+	g.AddEdge("split", "left")
+	g.AddEdge("split", "right")
+	g.AddEdge("split", "right")
+	g.AddEdge("left", graph.END)
+	g.AddEdge("right", graph.END)
+
+	assert.Len(t, g.Topology().Successors("split"), 2, "edges between the same nodes are added once")
+	_, err := g.Compile()
+	assert.ErrorIs(t, err, graph.ErrJoinNotSet)
+}
+
+func TestMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	// agent calls tools until it ran the given number of times.
+	agentLoop := func(rounds int) *graph.Runnable[int] {
+		g := graph.NewMessageGraph[int]("agent")
+		g.AddNode("agent", func(_ context.Context, state int) (int, error) { return state + 1, nil })
+		g.AddNode("tools", func(_ context.Context, state int) (int, error) { return state, nil })
+		g.AddConditionalEdge("agent", func(_ context.Context, state int) (string, error) {
+			if state >= rounds {
+				return graph.END, nil
+			}
+			return "tools", nil
+		}, "tools", graph.END)
+		g.AddEdge("tools", "agent")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	testCases := []struct {
+		name     string
+		runnable *graph.Runnable[int]
+		limit    *int
+		expected int
+		err      error
+	}{
+		{
+			name:     "cycle within default limit",
+			runnable: agentLoop(3),
+			expected: 3,
+		},
+		{
+			name:     "cycle beyond default limit",
+			runnable: agentLoop(100),
+			expected: (graph.DefaultMaxSteps + 1) / 2,
+			err:      graph.ErrMaxStepsExceeded,
+		},
+		{
+			name:     "custom limit",
+			runnable: agentLoop(100),
+			limit:    ptr(4),
+			expected: 2,
+			err:      graph.ErrMaxStepsExceeded,
+		},
+		{
+			name:     "no limit",
+			runnable: agentLoop(100),
+			limit:    ptr(0),
+			expected: 100,
+		},
+		{
+			name:     "long chain without cycle",
+			runnable: chain(t, 2*graph.DefaultMaxSteps),
+			expected: 2 * graph.DefaultMaxSteps,
+		},
+		{
+			name:     "limited chain",
+			runnable: chain(t, 2*graph.DefaultMaxSteps),
+			limit:    ptr(10),
+			expected: 10,
+			err:      graph.ErrMaxStepsExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tc.limit != nil {
+				ctx = graph.WithMaxSteps(ctx, *tc.limit)
+			}
+			state, err := tc.runnable.Invoke(ctx, 0)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, state)
+		})
+	}
+}
+
+// chain returns a graph of n nodes in sequence, each incrementing the state.
+func chain(t *testing.T, n int) *graph.Runnable[int] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[int]("n0")
+	for i := range n {
+		g.AddNode(fmt.Sprintf("n%d", i), func(_ context.Context, state int) (int, error) { return state + 1, nil })
+		if i+1 < n {
+			g.AddEdge(fmt.Sprintf("n%d", i), fmt.Sprintf("n%d", i+1))
+		} else {
+			g.AddEdge(fmt.Sprintf("n%d", i), graph.END)
+		}
+	}
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func ptr[V any](v V) *V {
+	return &v
+}
@@ -0,0 +1,150 @@
+// Package graphtest provides helpers for testing graphs.
+//
+// Screenshot renders the graph under test to an image kept as a test artifact, so topology
+// changes show up in code review:
+//
+//	func TestSupportGraph(t *testing.T) {
+//		g := buildSupportGraph()
+//		graphtest.Screenshot(t, g.Topology())
+//		...
+//	}
+//
+// CI can collect the images by pointing ArtifactsEnv to a directory it uploads.
+//
+// Simulation plays a scripted or synthetic user against a graph for several turns, checking
+// the state after every turn, to test stateful behavior beyond single invocations.
+package graphtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ArtifactsEnv is the environment variable naming the directory screenshots are written to.
+// When it is not set, they are written to a langgraphgo-artifacts directory of the temporary
+// directory.
+const ArtifactsEnv = "LANGGRAPH_TEST_ARTIFACTS"
+
+// renderTimeout bounds the run of an external renderer.
+const renderTimeout = 30 * time.Second
+
+// Format is an image format of screenshots.
+type Format string
+
+const (
+	// FormatSVG renders SVG images, with Graphviz or Mermaid CLI if installed and the built-in
+	// layout otherwise.
+	FormatSVG Format = "svg"
+
+	// FormatPNG renders PNG images; it requires Graphviz or Mermaid CLI.
+	FormatPNG Format = "png"
+)
+
+// Screenshot renders the topology as an SVG image written to the artifacts directory, named
+// after the test, and returns its path. Failures are reported as test errors.
+func Screenshot(t testing.TB, topology graph.Topology) string {
+	t.Helper()
+	return ScreenshotAs(t, topology, FormatSVG)
+}
+
+// ScreenshotAs is like Screenshot but renders the image in the given format. The test is
+// skipped when no renderer supports the format.
+func ScreenshotAs(t testing.TB, topology graph.Topology, format Format) string {
+	t.Helper()
+
+	image, renderer, err := Render(context.Background(), topology, format)
+	if err != nil {
+		t.Errorf("rendering graph screenshot: %v", err)
+		return ""
+	}
+	if image == nil {
+		t.Skipf("no renderer for %s screenshots: install Graphviz or Mermaid CLI", format)
+	}
+
+	dir := os.Getenv(ArtifactsEnv)
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "langgraphgo-artifacts")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Errorf("creating artifacts directory: %v", err)
+		return ""
+	}
+
+	path := filepath.Join(dir, artifactName(t.Name())+"."+string(format))
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		t.Errorf("writing graph screenshot: %v", err)
+		return ""
+	}
+	t.Logf("graph screenshot rendered with %s: %s", renderer, path)
+	return path
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// artifactName turns a test name, which may hold slashes and spaces, into a file name.
+func artifactName(test string) string {
+	return unsafeChars.ReplaceAllString(test, "_")
+}
+
+// Render renders the topology in the given format and returns the image with the name of the
+// renderer used. Graphviz dot is preferred, then Mermaid CLI, then for SVG the built-in layout
+// of graph.Topology.SVG. It returns a nil image when no renderer supports the format.
+func Render(ctx context.Context, topology graph.Topology, format Format) ([]byte, string, error) {
+	if path, err := exec.LookPath("dot"); err == nil {
+		image, err := run(ctx, topology.DOT(), path, "-T"+string(format))
+		return image, "dot", err
+	}
+
+	if path, err := exec.LookPath("mmdc"); err == nil {
+		image, err := mermaid(ctx, topology, path, format)
+		return image, "mmdc", err
+	}
+
+	if format == FormatSVG {
+		return []byte(topology.SVG()), "built-in layout", nil
+	}
+	return nil, "", nil
+}
+
+// run runs a renderer reading the graph from its standard input and writing the image to its
+// standard output.
+func run(ctx context.Context, input, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewBufferString(input)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(name), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// mermaid renders with Mermaid CLI, which only writes images to files.
+func mermaid(ctx context.Context, topology graph.Topology, path string, format Format) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "graphtest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input, output := filepath.Join(dir, "graph.mmd"), filepath.Join(dir, "graph."+string(format))
+	if err := os.WriteFile(input, []byte(topology.Mermaid()), 0o600); err != nil {
+		return nil, err
+	}
+	if _, err := run(ctx, "", path, "-i", input, "-o", output); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(output)
+}
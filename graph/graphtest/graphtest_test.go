@@ -0,0 +1,49 @@
+package graphtest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/graph/graphtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func topology() graph.Topology {
+	g := graph.NewMessageGraph[int]("a")
+	g.AddNode("a", func(_ context.Context, state int) (int, error) { return state, nil })
+	g.AddLabeledEdge("a", graph.END, "done", "")
+	return g.Topology()
+}
+
+func TestScreenshot(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(graphtest.ArtifactsEnv, dir)
+	t.Setenv("PATH", "")
+
+	t.Run("sub test/with spaces", func(t *testing.T) {
+		path := graphtest.Screenshot(t, topology())
+		assert.Equal(t, filepath.Join(dir, "TestScreenshot_sub_test_with_spaces.svg"), path)
+
+		image, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(image), "<svg "))
+	})
+}
+
+func TestRenderWithoutTools(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	image, renderer, err := graphtest.Render(context.Background(), topology(), graphtest.FormatSVG)
+	require.NoError(t, err)
+	assert.Equal(t, "built-in layout", renderer)
+	assert.Equal(t, topology().SVG(), string(image))
+
+	image, _, err = graphtest.Render(context.Background(), topology(), graphtest.FormatPNG)
+	require.NoError(t, err)
+	assert.Nil(t, image)
+}
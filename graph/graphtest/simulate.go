@@ -0,0 +1,144 @@
+package graphtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrScriptExhausted is returned by a scripted user that has no message left. Simulations
+// treat it as the end of the conversation.
+var ErrScriptExhausted = errors.New("script exhausted")
+
+// User plays the user side of a simulated conversation.
+type User interface {
+	// Say returns the next user message given the conversation so far. It returns
+	// ErrScriptExhausted to end the conversation.
+	Say(ctx context.Context, conversation []Turn) (string, error)
+}
+
+// UserFunc adapts a function to the User interface, e.g. a model prompted to act as a
+// synthetic user from the transcript.
+type UserFunc func(ctx context.Context, conversation []Turn) (string, error)
+
+// Say calls f.
+func (f UserFunc) Say(ctx context.Context, conversation []Turn) (string, error) {
+	return f(ctx, conversation)
+}
+
+// Script returns a User saying the messages in order.
+func Script(messages ...string) User {
+	return UserFunc(func(_ context.Context, conversation []Turn) (string, error) {
+		if len(conversation) >= len(messages) {
+			return "", ErrScriptExhausted
+		}
+		return messages[len(conversation)], nil
+	})
+}
+
+// Turn is an exchange of a simulated conversation.
+type Turn struct {
+	// User is the message of the user.
+	User string
+
+	// Reply is the reply of the graph.
+	Reply string
+
+	// Failures are the messages of the checks that failed on the turn.
+	Failures []string
+}
+
+// Check asserts something about the state after a turn, returning an error describing the
+// failure. turn is the index of the turn, from zero.
+type Check[T any] func(turn int, state T) error
+
+// Simulation plays a User against a compiled graph, invoking the graph once per turn with the
+// state accumulated over the previous turns, to test stateful behavior across turns.
+type Simulation[T any] struct {
+	// Runnable is the graph under test.
+	Runnable *graph.Runnable[T]
+
+	// User plays the user.
+	User User
+
+	// AddMessage returns the state with the user message added, before the graph is invoked.
+	AddMessage func(state T, message string) T
+
+	// Reply extracts the reply of the graph from the state it returned.
+	Reply func(state T) string
+
+	// MaxTurns bounds the number of turns; zero means no bound, which requires a User ending
+	// the conversation.
+	MaxTurns int
+
+	// Checks are run on the state after every turn.
+	Checks []Check[T]
+}
+
+// Run plays the conversation from the initial state until the user ends it or MaxTurns is
+// reached, and returns its turns and the final state. Failed checks are recorded in the turns
+// and do not stop the conversation; errors of the user or the graph do.
+func (s Simulation[T]) Run(ctx context.Context, state T) ([]Turn, T, error) {
+	var turns []Turn
+	for s.MaxTurns <= 0 || len(turns) < s.MaxTurns {
+		message, err := s.User.Say(ctx, turns)
+		if errors.Is(err, ErrScriptExhausted) {
+			break
+		}
+		if err != nil {
+			return turns, state, fmt.Errorf("turn %d: user: %w", len(turns), err)
+		}
+
+		state, err = s.Runnable.Invoke(ctx, s.AddMessage(state, message))
+		if err != nil {
+			return turns, state, fmt.Errorf("turn %d: %w", len(turns), err)
+		}
+
+		turn := Turn{User: message, Reply: s.Reply(state)}
+		for _, check := range s.Checks {
+			if err := check(len(turns), state); err != nil {
+				turn.Failures = append(turn.Failures, err.Error())
+			}
+		}
+		turns = append(turns, turn)
+	}
+	return turns, state, nil
+}
+
+// Simulate runs the simulation in a test. Failed checks and errors are reported as test
+// errors, with the transcript logged to show the context of the failures.
+func Simulate[T any](t testing.TB, s Simulation[T], state T) ([]Turn, T) {
+	t.Helper()
+
+	turns, state, err := s.Run(context.Background(), state)
+	failed := err != nil
+	if err != nil {
+		t.Errorf("simulation: %v", err)
+	}
+	for i, turn := range turns {
+		for _, failure := range turn.Failures {
+			failed = true
+			t.Errorf("turn %d: %s", i, failure)
+		}
+	}
+	if failed {
+		t.Logf("transcript:\n%s", Transcript(turns))
+	}
+	return turns, state
+}
+
+// Transcript formats the turns as a readable conversation.
+func Transcript(turns []Turn) string {
+	var b strings.Builder
+	for i, turn := range turns {
+		fmt.Fprintf(&b, "[%d] user: %s\n[%d] graph: %s\n", i, turn.User, i, turn.Reply)
+		for _, failure := range turn.Failures {
+			fmt.Fprintf(&b, "[%d] FAILED: %s\n", i, failure)
+		}
+	}
+	return b.String()
+}
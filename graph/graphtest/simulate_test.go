@@ -0,0 +1,104 @@
+package graphtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/graph/graphtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBot replies with the number of user messages and remembers names.
+func memoryBot(t *testing.T) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("bot")
+	g.AddNode("bot", func(_ context.Context, state []string) ([]string, error) {
+		last := state[len(state)-1]
+		if last == "fail" {
+			return state, errors.New("bot failure")
+		}
+		if name, ok := strings.CutPrefix(last, "my name is "); ok {
+			return append(state, "hello "+name), nil
+		}
+		return append(state, fmt.Sprintf("message %d", (len(state)+1)/2)), nil
+	})
+	g.AddEdge("bot", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func simulation(t *testing.T, user graphtest.User) graphtest.Simulation[[]string] {
+	return graphtest.Simulation[[]string]{
+		Runnable:   memoryBot(t),
+		User:       user,
+		AddMessage: func(state []string, message string) []string { return append(state, message) },
+		Reply:      func(state []string) string { return state[len(state)-1] },
+		MaxTurns:   5,
+	}
+}
+
+func TestSimulationScript(t *testing.T) {
+	t.Parallel()
+
+	s := simulation(t, graphtest.Script("my name is Ada", "how are you?"))
+	s.Checks = []graphtest.Check[[]string]{
+		func(turn int, state []string) error {
+			if len(state) != 2*(turn+1) {
+				return fmt.Errorf("state has %d messages", len(state))
+			}
+			return nil
+		},
+		func(turn int, state []string) error {
+			if turn == 1 {
+				return errors.New("forgot the name")
+			}
+			return nil
+		},
+	}
+
+	turns, state, err := s.Run(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []graphtest.Turn{
+		{User: "my name is Ada", Reply: "hello Ada"},
+		{User: "how are you?", Reply: "message 2", Failures: []string{"forgot the name"}},
+	}, turns)
+	assert.Len(t, state, 4)
+	assert.Equal(t, "[0] user: my name is Ada\n[0] graph: hello Ada\n[1] user: how are you?\n[1] graph: message 2\n[1] FAILED: forgot the name\n", graphtest.Transcript(turns))
+}
+
+func TestSimulationSyntheticUser(t *testing.T) {
+	t.Parallel()
+
+	// The synthetic user reacts to the last reply, as a model playing a user would.
+	user := graphtest.UserFunc(func(_ context.Context, conversation []graphtest.Turn) (string, error) {
+		if len(conversation) == 0 {
+			return "hi", nil
+		}
+		return "you said " + conversation[len(conversation)-1].Reply, nil
+	})
+
+	turns, _ := graphtest.Simulate(t, simulation(t, user), nil)
+	require.Len(t, turns, 5, "bounded by MaxTurns")
+	assert.Equal(t, "you said message 3", turns[3].User)
+}
+
+func TestSimulationErrors(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := simulation(t, graphtest.Script("ok", "fail")).Run(context.Background(), nil)
+	assert.EqualError(t, err, "turn 1: error in node bot: bot failure")
+
+	failingUser := graphtest.UserFunc(func(context.Context, []graphtest.Turn) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	_, _, err = simulation(t, failingUser).Run(context.Background(), nil)
+	assert.EqualError(t, err, "turn 0: user: model unavailable")
+}
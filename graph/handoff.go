@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// metadataHandoff is the checkpoint metadata key holding the encoded Handoff of the checkpoints
+// saved when a thread is handed off.
+const metadataHandoff = "handoff"
+
+// Handoff describes the transfer of a thread from a graph to another; see HandOff.
+type Handoff struct {
+	// From names the graph handing the thread off, e.g. a triage graph.
+	From string `json:"from"`
+
+	// To names the graph the thread is handed off to, e.g. a specialist graph.
+	To string `json:"to"`
+
+	// ThreadID is the thread handed off.
+	ThreadID string `json:"thread_id"`
+
+	// CheckpointID identifies the latest checkpoint of the thread in From when it was handed
+	// off; set by HandOff.
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+
+	// RunID is the ID of the invocation of To continuing the thread; set by HandOff.
+	RunID string `json:"run_id,omitempty"`
+
+	// At is the time of the handoff; set by HandOff.
+	At time.Time `json:"at"`
+}
+
+// HandOff transfers the thread h.ThreadID from the graph from to the graph to, e.g. from a
+// triage graph to the specialist it selected, and continues it there: the latest state of the
+// thread in from is translated to the state of to, saved to the thread in the checkpointer of
+// to as a checkpoint of START recording h, whose snapshot has Handoff set, then to is invoked on
+// it with the thread ID, as for Invoke, and its result returned. Both graphs must be compiled
+// WithCheckpointer.
+//
+// The thread keeps its ID, so its history continues in the checkpointer of to: when both graphs
+// share their checkpointer, the checkpoints of to follow those of from, and from no longer
+// reads the thread, whose state is of another type. The handoff is traced as a "handoff" event
+// of the span of ctx, and as a "graph.handoff" span of the tracer of to, parent of the span of
+// the invocation, both with the AttributeHandoffFrom and AttributeHandoffTo attributes.
+func HandOff[From, To any](ctx context.Context, from *Runnable[From], to *Runnable[To], h Handoff, translate func(ctx context.Context, state From) (To, error)) (To, error) {
+	var state To
+	if from.checkpointer == nil || to.checkpointer == nil {
+		return state, ErrNoCheckpointer
+	}
+	current, err := from.GetState(ctx, h.ThreadID)
+	if err != nil {
+		return state, fmt.Errorf("handing off thread %s from %s to %s: %w", h.ThreadID, h.From, h.To, err)
+	}
+	state, err = translate(ctx, current.State)
+	if err != nil {
+		return state, fmt.Errorf("translating state of thread %s from %s to %s: %w", h.ThreadID, h.From, h.To, err)
+	}
+
+	ctx = withRun(WithThreadID(ctx, h.ThreadID))
+	h.CheckpointID, h.RunID, h.At = current.CheckpointID, RunID(ctx), time.Now()
+	attrs := trace.WithAttributes(AttributeHandoffFrom.String(h.From), AttributeHandoffTo.String(h.To))
+	trace.SpanFromContext(ctx).AddEvent("handoff", attrs)
+	ctx, end := to.startSpan(ctx, "graph.handoff", AttributeHandoffFrom.String(h.From), AttributeHandoffTo.String(h.To))
+
+	encoded, err := json.Marshal(h)
+	if err == nil {
+		err = to.save(ctx, h.ThreadID, START, state, map[string]string{metadataHandoff: string(encoded)})
+	}
+	if err != nil {
+		err = fmt.Errorf("handing off thread %s from %s to %s: %w", h.ThreadID, h.From, h.To, err)
+		end(err)
+		return state, err
+	}
+	state, err = to.Invoke(ctx, state)
+	end(err)
+	return state, err
+}
@@ -0,0 +1,151 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+type ticket struct {
+	Request string
+	Team    string
+}
+
+// handoffGraphs returns a triage graph assigning tickets to billing, and a billing graph
+// answering the requests, saved to cp.
+func handoffGraphs(t *testing.T, cp checkpoint.Checkpointer, opts ...graph.CompileOption) (*graph.Runnable[ticket], *graph.Runnable[[]string]) {
+	t.Helper()
+
+	triage := graph.NewMessageGraph[ticket]("triage")
+	triage.AddNode("triage", func(_ context.Context, state ticket) (ticket, error) {
+		return ticket{Request: state.Request, Team: "billing"}, nil
+	})
+	triage.SetFinishPoint("triage")
+	triageRunnable, err := triage.Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+
+	billing := graph.NewMessageGraph[[]string]("answer")
+	billing.AddNode("answer", appendNode("refunded"))
+	billing.SetFinishPoint("answer")
+	billingRunnable, err := billing.Compile(append(opts, graph.WithCheckpointer(cp))...)
+	require.NoError(t, err)
+
+	return triageRunnable, billingRunnable
+}
+
+func translateTicket(_ context.Context, state ticket) ([]string, error) {
+	return []string{state.Request}, nil
+}
+
+func TestHandOff(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	triage, billing := handoffGraphs(t, cp, graph.WithTracerProvider(tp))
+
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	_, err := triage.Invoke(ctx, ticket{Request: "refund"})
+	require.NoError(t, err)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	out, err := graph.HandOff(ctx, triage, billing, graph.Handoff{From: "triage", To: "billing", ThreadID: "t1"}, translateTicket)
+	span.End()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refund", "refunded"}, out)
+
+	// The thread continues in billing, after the checkpoint of the handoff.
+	history, err := cp.List(context.Background(), "t1")
+	require.NoError(t, err)
+	nodes := make([]string, 0, len(history))
+	for _, c := range history {
+		nodes = append(nodes, c.Node)
+	}
+	assert.Equal(t, []string{graph.END, graph.START, graph.END}, nodes)
+	snapshot, err := billing.GetState(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refund", "refunded"}, snapshot.State)
+	assert.Nil(t, snapshot.Handoff)
+
+	// The handoff is an event of the span of the caller, and the parent span of the invocation.
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	require.Len(t, spans["request"].Events(), 1)
+	assert.Equal(t, "handoff", spans["request"].Events()[0].Name)
+	assert.Contains(t, spans["graph.handoff"].Attributes(), graph.AttributeHandoffTo.String("billing"))
+	assert.Contains(t, spans["graph.handoff"].Attributes(), graph.AttributeThreadID.String("t1"))
+	assert.Equal(t, spans["graph.handoff"].SpanContext().SpanID(), spans["graph.invoke"].Parent().SpanID())
+}
+
+func TestHandOffFailed(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	triage, _ := handoffGraphs(t, cp)
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	_, err := triage.Invoke(ctx, ticket{Request: "refund"})
+	require.NoError(t, err)
+	before, err := triage.GetState(ctx, "t1")
+	require.NoError(t, err)
+
+	g := graph.NewMessageGraph[[]string]("answer")
+	g.AddNode("answer", func(_ context.Context, state []string) ([]string, error) {
+		return state, errors.New("refund failed")
+	})
+	g.SetFinishPoint("answer")
+	billing, err := g.Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+
+	_, err = graph.HandOff(context.Background(), triage, billing, graph.Handoff{From: "triage", To: "billing", ThreadID: "t1"}, translateTicket)
+	require.ErrorContains(t, err, "refund failed")
+
+	// The thread is left at the checkpoint of the handoff.
+	snapshot, err := billing.GetState(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, graph.START, snapshot.Node)
+	assert.Equal(t, []string{"refund"}, snapshot.State)
+	require.NotNil(t, snapshot.Handoff)
+	assert.Equal(t, "triage", snapshot.Handoff.From)
+	assert.Equal(t, "billing", snapshot.Handoff.To)
+	assert.Equal(t, "t1", snapshot.Handoff.ThreadID)
+	assert.Equal(t, before.CheckpointID, snapshot.Handoff.CheckpointID)
+	assert.NotEmpty(t, snapshot.Handoff.RunID)
+	assert.False(t, snapshot.Handoff.At.IsZero())
+}
+
+func TestHandOffErrors(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	triage, billing := handoffGraphs(t, cp)
+	_, err := triage.Invoke(graph.WithThreadID(context.Background(), "t1"), ticket{Request: "refund"})
+	require.NoError(t, err)
+
+	h := graph.Handoff{From: "triage", To: "billing", ThreadID: "t1"}
+	_, err = graph.HandOff(context.Background(), triage, billing, h, func(context.Context, ticket) ([]string, error) {
+		return nil, errors.New("no team")
+	})
+	require.ErrorContains(t, err, "translating state of thread t1 from triage to billing: no team")
+
+	_, err = graph.HandOff(context.Background(), triage, billing, graph.Handoff{From: "triage", To: "billing", ThreadID: "missing"}, translateTicket)
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	g := graph.NewMessageGraph[[]string]("answer")
+	g.AddNode("answer", appendNode("refunded"))
+	g.SetFinishPoint("answer")
+	stateless, err := g.Compile()
+	require.NoError(t, err)
+	_, err = graph.HandOff(context.Background(), triage, stateless, h, translateTicket)
+	require.ErrorIs(t, err, graph.ErrNoCheckpointer)
+}
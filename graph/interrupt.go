@@ -0,0 +1,293 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+var (
+	// ErrInterrupted is matched by the *Interrupt errors returned when execution pauses at a
+	// node configured with WithInterruptBefore or WithInterruptAfter.
+	ErrInterrupted = errors.New("execution interrupted")
+
+	// ErrNotInterrupted is returned by Pending when the latest checkpoint of a thread is not an
+	// interrupt.
+	ErrNotInterrupted = errors.New("thread is not interrupted")
+
+	// ErrNoCheckpointer is returned by Pending when the graph was compiled without
+	// WithCheckpointer.
+	ErrNoCheckpointer = errors.New("no checkpointer")
+)
+
+// metadataInterrupt is the checkpoint metadata key holding the encoded Interrupt of the
+// checkpoints saved when execution pauses.
+const metadataInterrupt = "interrupt"
+
+// CompileOption configures a Runnable at Compile.
+type CompileOption func(*compileOptions)
+
+type compileOptions struct {
+	interruptBefore []string
+	interruptAfter  []string
+	checkpointer    checkpoint.Checkpointer
+	strictState     bool
+	deltas          bool
+	snapshotEvery   int
+	propagatePanics bool
+	callbacks       []typedCallbacks
+	tracer          trace.Tracer
+	traceRedact     func(string) string
+	plan            *Plan
+}
+
+// WithInterruptBefore pauses execution before the given nodes run, e.g. to have a human
+// approve a sensitive action. Invoke then returns the state reached with an *Interrupt error,
+// and Resume continues from the interrupted node.
+func WithInterruptBefore(nodes ...string) CompileOption {
+	return func(o *compileOptions) {
+		o.interruptBefore = append(o.interruptBefore, nodes...)
+	}
+}
+
+// WithInterruptAfter pauses execution after the given nodes ran, e.g. to have a human review
+// their output. Invoke then returns the state they produced with an *Interrupt error, and
+// Resume continues with the nodes following them. Nodes leading only to END do not pause.
+func WithInterruptAfter(nodes ...string) CompileOption {
+	return func(o *compileOptions) {
+		o.interruptAfter = append(o.interruptAfter, nodes...)
+	}
+}
+
+// WithCheckpointer saves the state of interrupted invocations to cp, in the thread set on
+// their context with WithThreadID, so they can be resumed later, possibly by another process,
+// with Pending and Resume.
+func WithCheckpointer(cp checkpoint.Checkpointer) CompileOption {
+	return func(o *compileOptions) {
+		o.checkpointer = cp
+	}
+}
+
+// WithStrictState makes the states read from the checkpointer decode with
+// checkpoint.DecodeStrict: reading a state saved with fields the state type no longer declares,
+// or with values of another type, fails instead of silently dropping them.
+func WithStrictState() CompileOption {
+	return func(o *compileOptions) {
+		o.strictState = true
+	}
+}
+
+type threadIDKey struct{}
+
+// WithThreadID returns a context making invocations of graphs compiled with WithCheckpointer
+// save their interrupts to the given thread.
+func WithThreadID(ctx context.Context, threadID string) context.Context {
+	return context.WithValue(ctx, threadIDKey{}, threadID)
+}
+
+// ThreadID returns the thread set on the context with WithThreadID, or the empty string.
+func ThreadID(ctx context.Context) string {
+	return threadIDFromContext(ctx)
+}
+
+func threadIDFromContext(ctx context.Context) string {
+	threadID, _ := ctx.Value(threadIDKey{}).(string)
+	return threadID
+}
+
+// Interrupt is the error returned when execution pauses at an interrupt. It matches
+// ErrInterrupted.
+type Interrupt struct {
+	// Node is the node the interrupt is configured on.
+	Node string `json:"node"`
+
+	// After is set when execution paused after Node rather than before it.
+	After bool `json:"after,omitempty"`
+
+	// Step is the index of the next step to execute.
+	Step int `json:"step"`
+
+	// Next are the nodes to execute when resuming.
+	Next []string `json:"next"`
+
+	// From are the nodes Next were reached from, whose branch policy applies when Next run in
+	// parallel; see SetBranchPolicy.
+	From []string `json:"from,omitempty"`
+
+	// ThreadID is the thread the interrupt was saved to; empty if it was not saved.
+	ThreadID string `json:"thread_id,omitempty"`
+
+	// SideEffect is set on the interrupts saved before executing side-effecting nodes, which
+	// the thread stays paused at when the run did not complete; see WithSideEffects.
+	SideEffect bool `json:"side_effect,omitempty"`
+}
+
+// Error implements the error interface.
+func (i *Interrupt) Error() string {
+	position := "before"
+	if i.After {
+		position = "after"
+	}
+	return fmt.Sprintf("%s %s node %s", ErrInterrupted, position, i.Node)
+}
+
+// Is makes errors.Is match ErrInterrupted.
+func (i *Interrupt) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+// Resume continues an interrupted invocation from the point it paused, with state, which is
+// the state returned with the interrupt, possibly edited, e.g. to record an approval. The
+// interrupt that paused execution does not fire again, but the following ones do.
+//
+// When the interrupt was saved to a thread, the next interrupts are saved to the same thread,
+// and so is the final state once execution completes, as a checkpoint of END, so the thread is
+// no longer pending.
+func (r *Runnable[T]) Resume(ctx context.Context, interrupt *Interrupt, state T) (T, error) {
+	if err := checkResume(ctx, interrupt); err != nil {
+		return state, err
+	}
+	if interrupt.ThreadID == "" || r.checkpointer == nil {
+		return r.run(ctx, state, slices.Clone(interrupt.Next), interrupt.From, interrupt.Step, true)
+	}
+
+	ctx = r.withDeltaChain(WithThreadID(ctx, interrupt.ThreadID))
+	state, err := r.run(ctx, state, slices.Clone(interrupt.Next), interrupt.From, interrupt.Step, true)
+	if err != nil {
+		return state, err
+	}
+	if err := r.save(ctx, interrupt.ThreadID, END, state, nil); err != nil {
+		return state, fmt.Errorf("saving thread %s: %w", interrupt.ThreadID, err)
+	}
+	return state, nil
+}
+
+// Pending returns the interrupt the thread is paused at, with its state, from the checkpointer
+// set with WithCheckpointer. It returns ErrNotInterrupted if the latest checkpoint of the thread
+// is not an interrupt, for instance because it was resumed since.
+func (r *Runnable[T]) Pending(ctx context.Context, threadID string) (*Interrupt, T, error) {
+	var state T
+	if r.checkpointer == nil {
+		return nil, state, ErrNoCheckpointer
+	}
+
+	cp, err := r.checkpointer.Latest(ctx, threadID)
+	if err != nil {
+		return nil, state, err
+	}
+	encoded, ok := cp.Metadata[metadataInterrupt]
+	if !ok {
+		return nil, state, fmt.Errorf("%w: %s", ErrNotInterrupted, threadID)
+	}
+
+	var interrupt Interrupt
+	if err := json.Unmarshal([]byte(encoded), &interrupt); err != nil {
+		return nil, state, fmt.Errorf("decoding interrupt of thread %s: %w", threadID, err)
+	}
+	state, err = r.decodeCheckpoint(ctx, cp)
+	if err != nil {
+		return nil, state, err
+	}
+	return &interrupt, state, nil
+}
+
+// interruptBefore returns the interrupt to fire before executing the nodes, reached from the
+// "from" nodes, if any.
+func (r *Runnable[T]) interruptBefore(index int, nodes, from []string) *Interrupt {
+	for _, node := range nodes {
+		if slices.Contains(r.interruptsBefore, node) {
+			return &Interrupt{Node: node, Step: index, Next: slices.Clone(nodes), From: slices.Clone(from)}
+		}
+	}
+	return nil
+}
+
+// interruptAfter returns the interrupt to fire after executing the nodes, which lead to next,
+// if any.
+func (r *Runnable[T]) interruptAfter(index int, nodes, next []string) *Interrupt {
+	if !slices.ContainsFunc(next, func(node string) bool { return node != END }) {
+		return nil
+	}
+	for _, node := range nodes {
+		if slices.Contains(r.interruptsAfter, node) {
+			return &Interrupt{Node: node, After: true, Step: index + 1, Next: slices.Clone(next), From: slices.Clone(nodes)}
+		}
+	}
+	return nil
+}
+
+// pause saves the interrupt and the state to the thread of the context, if any, and returns
+// the interrupt as an error.
+func (r *Runnable[T]) pause(ctx context.Context, interrupt *Interrupt, state T) error {
+	threadID := threadIDFromContext(ctx)
+	if r.checkpointer == nil || threadID == "" {
+		return interrupt
+	}
+	interrupt.ThreadID = threadID
+
+	encoded, err := json.Marshal(interrupt)
+	if err != nil {
+		return fmt.Errorf("saving interrupt to thread %s: %w", threadID, err)
+	}
+	metadata := map[string]string{metadataInterrupt: string(encoded)}
+	if err := r.save(ctx, threadID, interrupt.Node, state, metadata); err != nil {
+		return fmt.Errorf("saving interrupt to thread %s: %w", threadID, err)
+	}
+	return interrupt
+}
+
+// save appends a checkpoint of the state produced by node to the thread. The checkpointer
+// numbers it, atomically if it implements checkpoint.Appender, so concurrent saves to the thread
+// do not overwrite each other. The profile of the context, if any, times the encoding of the
+// state as a SpanSerialization span and the write as a SpanCheckpoint span, named after node.
+func (r *Runnable[T]) save(ctx context.Context, threadID, node string, state T, metadata map[string]string) error {
+	chain := r.deltaChain(ctx)
+	if chain != nil {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+	}
+
+	cp := checkpoint.Checkpoint{
+		ThreadID:  threadID,
+		Node:      node,
+		Version:   checkpoint.FormatVersion,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	}
+	endEncode := ProfileSpan(ctx, SpanSerialization, node)
+	length, err := r.encodeCheckpoint(&cp, chain, state)
+	endEncode()
+	if err != nil {
+		return err
+	}
+	endWrite := ProfileSpan(ctx, SpanCheckpoint, node)
+	saved, err := checkpoint.Append(ctx, r.checkpointer, cp)
+	endWrite()
+	if err != nil {
+		return err
+	}
+	chain.saved(saved, length)
+	return nil
+}
+
+// validateInterrupts checks the interrupts are configured on nodes of the graph.
+func (g *MessageGraph[T]) validateInterrupts(o compileOptions) error {
+	var errs []error
+	check := func(position string, nodes []string) {
+		for _, node := range nodes {
+			if _, ok := g.nodes[node]; !ok || node == END {
+				errs = append(errs, fmt.Errorf("interrupt %s: %w: %s", position, ErrNodeNotFound, node))
+			}
+		}
+	}
+	check("before", o.interruptBefore)
+	check("after", o.interruptAfter)
+	return errors.Join(errs...)
+}
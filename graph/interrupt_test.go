@@ -0,0 +1,199 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// approvalGraph appends the name of every node it runs to the state: draft, then approve, then
+// send.
+func approvalGraph() *graph.MessageGraph[[]string] {
+	g := graph.NewMessageGraph[[]string]("draft")
+	for _, name := range []string{"draft", "approve", "send"} {
+		g.AddNode(name, func(_ context.Context, state []string) ([]string, error) {
+			return append(state, name), nil
+		})
+	}
+	g.AddEdge("draft", "approve")
+	g.AddEdge("approve", "send")
+	g.AddEdge("send", graph.END)
+	return g
+}
+
+func TestInterrupts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		opts        []graph.CompileOption
+		interrupted []string
+		expected    *graph.Interrupt
+		resumed     []string
+	}{
+		{
+			name:        "before",
+			opts:        []graph.CompileOption{graph.WithInterruptBefore("approve")},
+			interrupted: []string{"draft"},
+			expected:    &graph.Interrupt{Node: "approve", Step: 1, Next: []string{"approve"}, From: []string{"draft"}},
+			resumed:     []string{"draft", "edited", "approve", "send"},
+		},
+		{
+			name:        "after",
+			opts:        []graph.CompileOption{graph.WithInterruptAfter("approve")},
+			interrupted: []string{"draft", "approve"},
+			expected:    &graph.Interrupt{Node: "approve", After: true, Step: 2, Next: []string{"send"}, From: []string{"approve"}},
+			resumed:     []string{"draft", "approve", "edited", "send"},
+		},
+		{
+			name:        "before entry point",
+			opts:        []graph.CompileOption{graph.WithInterruptBefore("draft")},
+			interrupted: nil,
+			expected:    &graph.Interrupt{Node: "draft", Step: 0, Next: []string{"draft"}},
+			resumed:     []string{"edited", "draft", "approve", "send"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, err := approvalGraph().Compile(tc.opts...)
+			require.NoError(t, err)
+
+			state, err := runnable.Invoke(context.Background(), nil)
+			require.ErrorIs(t, err, graph.ErrInterrupted)
+			assert.Equal(t, tc.interrupted, state)
+
+			var interrupt *graph.Interrupt
+			require.True(t, errors.As(err, &interrupt))
+			assert.Equal(t, tc.expected, interrupt)
+
+			state, err = runnable.Resume(context.Background(), interrupt, append(state, "edited"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.resumed, state)
+		})
+	}
+}
+
+func TestInterruptAfterLastNode(t *testing.T) {
+	t.Parallel()
+
+	runnable, err := approvalGraph().Compile(graph.WithInterruptAfter("send"))
+	require.NoError(t, err)
+
+	state, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "approve", "send"}, state)
+}
+
+func TestInterruptResumeFromThread(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	runnable, err := approvalGraph().Compile(
+		graph.WithInterruptBefore("approve"),
+		graph.WithInterruptAfter("approve"),
+		graph.WithCheckpointer(cp),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = runnable.Invoke(graph.WithThreadID(ctx, "t1"), nil)
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+
+	// Another process picks up the thread.
+	interrupt, state, err := runnable.Pending(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, &graph.Interrupt{Node: "approve", Step: 1, Next: []string{"approve"}, From: []string{"draft"}, ThreadID: "t1"}, interrupt)
+	assert.Equal(t, []string{"draft"}, state)
+
+	_, err = runnable.Resume(ctx, interrupt, state)
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+
+	interrupt, state, err = runnable.Pending(ctx, "t1")
+	require.NoError(t, err)
+	assert.True(t, interrupt.After)
+	assert.Equal(t, []string{"draft", "approve"}, state)
+
+	state, err = runnable.Resume(ctx, interrupt, state)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "approve", "send"}, state)
+
+	_, _, err = runnable.Pending(ctx, "t1")
+	require.ErrorIs(t, err, graph.ErrNotInterrupted)
+
+	checkpoints, err := cp.List(ctx, "t1")
+	require.NoError(t, err)
+	nodes := make([]string, len(checkpoints))
+	for i, c := range checkpoints {
+		nodes[i] = c.Node
+	}
+	assert.Equal(t, []string{"approve", "approve", graph.END}, nodes)
+}
+
+// slowLatest is a checkpointer whose Latest is slow, so that saves numbering checkpoints from
+// it collide.
+type slowLatest struct {
+	*checkpoint.Memory
+}
+
+func (c slowLatest) Latest(ctx context.Context, threadID string) (checkpoint.Checkpoint, error) {
+	cp, err := c.Memory.Latest(ctx, threadID)
+	time.Sleep(time.Millisecond)
+	return cp, err
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	t.Parallel()
+
+	cp := slowLatest{checkpoint.NewMemory()}
+	runnable, err := approvalGraph().Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+
+	// Invocations of one thread finishing together save distinct checkpoints.
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := runnable.Invoke(ctx, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Every invocation saves a checkpoint after draft, after approve, and of END.
+	checkpoints, err := cp.List(ctx, "t1")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3*20)
+	for i, c := range checkpoints {
+		assert.Equal(t, i, c.Step)
+	}
+}
+
+func TestInterruptErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := approvalGraph().Compile(graph.WithInterruptBefore("missing"), graph.WithInterruptAfter(graph.END))
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+	assert.EqualError(t, err, "interrupt before: node not found: missing\ninterrupt after: node not found: END")
+
+	runnable, err := approvalGraph().Compile()
+	require.NoError(t, err)
+	_, _, err = runnable.Pending(context.Background(), "t1")
+	require.ErrorIs(t, err, graph.ErrNoCheckpointer)
+
+	runnable, err = approvalGraph().Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	_, _, err = runnable.Pending(context.Background(), "t1")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
@@ -0,0 +1,322 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Topology is a read-only description of the structure of a graph, as inspected by lint rules.
+type Topology struct {
+	// EntryPoint is the name of the first node executed.
+	EntryPoint string
+
+	// Nodes are the names of the nodes, END excluded, in lexical order.
+	Nodes []string
+
+	// Edges are the edges, ordered by source node, including one conditional edge per declared
+	// route of the conditional edges and the error edges.
+	Edges []Edge
+
+	// Routers are the nodes leaving through a conditional edge, including the command nodes, in
+	// lexical order.
+	Routers []string
+
+	// Resources are the resource hints of the nodes declaring some, by node name; nil if
+	// none does.
+	Resources map[string]Resources
+
+	// Retries are the retry policies of the nodes retried, by node name; nil if none is.
+	Retries map[string]RetryPolicy
+}
+
+// Topology returns the structure of the graph.
+func (g *MessageGraph[T]) Topology() Topology {
+	t := Topology{EntryPoint: g.entryPoint}
+	for name, node := range g.nodes {
+		if name == END {
+			continue
+		}
+		t.Nodes = append(t.Nodes, name)
+		if !node.Resources.IsZero() {
+			if t.Resources == nil {
+				t.Resources = make(map[string]Resources)
+			}
+			t.Resources[name] = node.Resources
+		}
+		if node.Retry.MaxAttempts > 1 {
+			if t.Retries == nil {
+				t.Retries = make(map[string]RetryPolicy)
+			}
+			t.Retries[name] = node.Retry
+		}
+	}
+	sort.Strings(t.Nodes)
+
+	for _, edges := range g.edges {
+		t.Edges = append(t.Edges, edges...)
+	}
+	for from, conditional := range g.conditionalEdges {
+		t.Routers = append(t.Routers, from)
+		for _, to := range conditional.routes {
+			t.Edges = append(t.Edges, Edge{From: from, To: to, Conditional: true})
+		}
+	}
+	for from, e := range g.errorEdges {
+		t.Edges = append(t.Edges, Edge{From: from, To: e.to, Label: ErrorLabel, Error: true})
+	}
+	sort.Strings(t.Routers)
+	sort.Slice(t.Edges, func(i, j int) bool {
+		if t.Edges[i].From != t.Edges[j].From {
+			return t.Edges[i].From < t.Edges[j].From
+		}
+		return t.Edges[i].To < t.Edges[j].To
+	})
+	return t
+}
+
+// HasNode reports whether the graph declares the node. END is always declared.
+func (t Topology) HasNode(name string) bool {
+	if name == END {
+		return true
+	}
+	i := sort.SearchStrings(t.Nodes, name)
+	return i < len(t.Nodes) && t.Nodes[i] == name
+}
+
+// IsRouter reports whether the node leaves through a conditional edge.
+func (t Topology) IsRouter(name string) bool {
+	i := sort.SearchStrings(t.Routers, name)
+	return i < len(t.Routers) && t.Routers[i] == name
+}
+
+// opaque reports whether the successors of the node are unknown: it is a router without
+// declared routes. Its error edge, if any, is not a route.
+func (t Topology) opaque(name string) bool {
+	return t.IsRouter(name) && !t.leaves(name)
+}
+
+// leaves reports whether the node has an edge taken when it succeeds, that is other than its
+// error edge.
+func (t Topology) leaves(name string) bool {
+	for _, edge := range t.Edges {
+		if edge.From == name && !edge.Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Successors returns the nodes the node has edges to.
+func (t Topology) Successors(name string) []string {
+	var out []string
+	for _, edge := range t.Edges {
+		if edge.From == name {
+			out = append(out, edge.To)
+		}
+	}
+	return out
+}
+
+// Reachable returns the nodes reachable from the given node, including itself.
+func (t Topology) Reachable(from string) map[string]bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range t.Successors(node) {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// Severity tells how serious a lint issue is.
+type Severity string
+
+const (
+	// SeverityError marks issues that make invocations fail or never end.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks issues that are likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem reported by a lint rule.
+type Issue struct {
+	// Rule is the name of the rule reporting the issue.
+	Rule string
+
+	// Severity tells how serious the issue is.
+	Severity Severity
+
+	// Node is the node the issue is about, if any.
+	Node string
+
+	// Message describes the issue.
+	Message string
+}
+
+// String formats the issue as "severity rule node: message".
+func (i Issue) String() string {
+	if i.Node == "" {
+		return fmt.Sprintf("%s %s: %s", i.Severity, i.Rule, i.Message)
+	}
+	return fmt.Sprintf("%s %s %s: %s", i.Severity, i.Rule, i.Node, i.Message)
+}
+
+// Rule checks the topology of a graph.
+type Rule interface {
+	// Name identifies the rule in the issues it reports.
+	Name() string
+
+	// Check returns the issues found in the topology. The Rule field of the issues is filled in by Lint.
+	Check(t Topology) []Issue
+}
+
+// ruleFunc is a Rule implemented by a function.
+type ruleFunc struct {
+	name  string
+	check func(t Topology) []Issue
+}
+
+func (r ruleFunc) Name() string { return r.name }
+
+func (r ruleFunc) Check(t Topology) []Issue { return r.check(t) }
+
+// NewRule returns a Rule named name checking topologies with check, so teams can add their own
+// checks to Lint.
+func NewRule(name string, check func(t Topology) []Issue) Rule {
+	return ruleFunc{name: name, check: check}
+}
+
+// Lint checks the graph with the given rules, or with DefaultRules if none are given, and
+// returns the issues found ordered by node and rule.
+func Lint[T any](g *MessageGraph[T], rules ...Rule) []Issue {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	t := g.Topology()
+	var issues []Issue
+	for _, rule := range rules {
+		for _, issue := range rule.Check(t) {
+			issue.Rule = rule.Name()
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Node != issues[j].Node {
+			return issues[i].Node < issues[j].Node
+		}
+		return issues[i].Rule < issues[j].Rule
+	})
+	return issues
+}
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{EndReachable, NoOrphanNodes, NoDanglingEdges, NoDeadEnds, RoutesDeclared, RetryExternalAPIs}
+}
+
+// EndReachable reports nodes reachable from the entry point from which END cannot be reached,
+// such as nodes in a cycle without exit. Nodes without outgoing edge are left to NoDeadEnds, and
+// nodes that may reach a router without declared routes are assumed to reach END.
+var EndReachable = NewRule("end-reachable", func(t Topology) []Issue {
+	if !t.HasNode(t.EntryPoint) {
+		return []Issue{{Severity: SeverityError, Message: fmt.Sprintf("entry point %q is not a node", t.EntryPoint)}}
+	}
+
+	var issues []Issue
+	reachable := t.Reachable(t.EntryPoint)
+	for _, node := range t.Nodes {
+		if reachable[node] && len(t.Successors(node)) > 0 && !t.mayReachEnd(node) {
+			issues = append(issues, Issue{Severity: SeverityError, Node: node, Message: "END cannot be reached from this node"})
+		}
+	}
+	return issues
+})
+
+// mayReachEnd reports whether END or a router without declared routes can be reached from the
+// node.
+func (t Topology) mayReachEnd(from string) bool {
+	for node := range t.Reachable(from) {
+		if node == END || t.opaque(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// NoOrphanNodes reports nodes that cannot be reached from the entry point. It is not checked
+// when a router without declared routes can be reached, as any node may follow it.
+var NoOrphanNodes = NewRule("no-orphan-nodes", func(t Topology) []Issue {
+	reachable := t.Reachable(t.EntryPoint)
+	for node := range reachable {
+		if t.opaque(node) {
+			return nil
+		}
+	}
+
+	var issues []Issue
+	for _, node := range t.Nodes {
+		if !reachable[node] {
+			issues = append(issues, Issue{Severity: SeverityWarning, Node: node, Message: "node is not reachable from the entry point"})
+		}
+	}
+	return issues
+})
+
+// NoDanglingEdges reports edges from or to undeclared nodes.
+var NoDanglingEdges = NewRule("no-dangling-edges", func(t Topology) []Issue {
+	var issues []Issue
+	for _, edge := range t.Edges {
+		if !t.HasNode(edge.From) {
+			issues = append(issues, Issue{Severity: SeverityWarning, Node: edge.From, Message: fmt.Sprintf("edge to %s starts from an undeclared node", edge.To)})
+		}
+		if !t.HasNode(edge.To) {
+			issues = append(issues, Issue{Severity: SeverityError, Node: edge.From, Message: fmt.Sprintf("edge points to undeclared node %s", edge.To)})
+		}
+	}
+	return issues
+})
+
+// NoDeadEnds reports nodes without outgoing edge besides their error edge, on which successful
+// invocations fail with ErrNoOutgoingEdge.
+var NoDeadEnds = NewRule("no-dead-ends", func(t Topology) []Issue {
+	var issues []Issue
+	for _, node := range t.Nodes {
+		if !t.leaves(node) && !t.IsRouter(node) {
+			issues = append(issues, Issue{Severity: SeverityError, Node: node, Message: "node has no outgoing edge"})
+		}
+	}
+	return issues
+})
+
+// RoutesDeclared reports conditional edges without declared routes, whose transitions cannot be
+// checked by the other rules nor rendered.
+var RoutesDeclared = NewRule("routes-declared", func(t Topology) []Issue {
+	var issues []Issue
+	for _, node := range t.Routers {
+		if t.opaque(node) {
+			issues = append(issues, Issue{Severity: SeverityWarning, Node: node, Message: "conditional edge declares no routes"})
+		}
+	}
+	return issues
+})
+
+// RetryExternalAPIs reports nodes declaring ResourceExternalAPI resource hints without a retry
+// policy, which fail their invocation on the first transient error of the API; see WithRetry.
+var RetryExternalAPIs = NewRule("retry-external-apis", func(t Topology) []Issue {
+	var issues []Issue
+	for _, node := range t.Nodes {
+		if _, retried := t.Retries[node]; t.Resources[node].Class == ResourceExternalAPI && !retried {
+			issues = append(issues, Issue{Severity: SeverityWarning, Node: node, Message: "external API node is not retried"})
+		}
+	}
+	return issues
+})
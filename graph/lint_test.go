@@ -0,0 +1,158 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func noop(_ context.Context, state int) (int, error) { return state, nil }
+
+func lintGraph(entryPoint string, nodes []string, edges ...[2]string) *graph.MessageGraph[int] {
+	g := graph.NewMessageGraph[int](entryPoint)
+	for _, name := range nodes {
+		g.AddNode(name, noop)
+	}
+	for _, edge := range edges {
+		g.AddEdge(edge[0], edge[1])
+	}
+	return g
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		graph    *graph.MessageGraph[int]
+		expected []string
+	}{
+		{
+			name:  "clean",
+			graph: lintGraph("a", []string{"a", "b"}, [2]string{"a", "b"}, [2]string{"b", graph.END}),
+		},
+		{
+			name:  "cycle without exit",
+			graph: lintGraph("a", []string{"a", "b"}, [2]string{"a", "b"}, [2]string{"b", "a"}),
+			expected: []string{
+				"error end-reachable a: END cannot be reached from this node",
+				"error end-reachable b: END cannot be reached from this node",
+			},
+		},
+		{
+			name:  "orphan and dead end",
+			graph: lintGraph("a", []string{"a", "orphan"}, [2]string{"a", graph.END}),
+			expected: []string{
+				"error no-dead-ends orphan: node has no outgoing edge",
+				"warning no-orphan-nodes orphan: node is not reachable from the entry point",
+			},
+		},
+		{
+			name:  "dangling edges",
+			graph: lintGraph("a", []string{"a"}, [2]string{"a", "missing"}, [2]string{"ghost", graph.END}),
+			expected: []string{
+				"error end-reachable a: END cannot be reached from this node",
+				"error no-dangling-edges a: edge points to undeclared node missing",
+				"warning no-dangling-edges ghost: edge to END starts from an undeclared node",
+			},
+		},
+		{
+			name:  "unknown entry point",
+			graph: lintGraph("missing", []string{"a"}, [2]string{"a", graph.END}),
+			expected: []string{
+				"error end-reachable: entry point \"missing\" is not a node",
+				"warning no-orphan-nodes a: node is not reachable from the entry point",
+			},
+		},
+		{
+			name: "router with routes",
+			graph: func() *graph.MessageGraph[int] {
+				g := lintGraph("agent", []string{"agent", "tools", "unused"}, [2]string{"tools", "agent"})
+				g.AddConditionalEdge("agent", func(context.Context, int) (string, error) { return graph.END, nil }, "tools", graph.END)
+				return g
+			}(),
+			expected: []string{
+				"error no-dead-ends unused: node has no outgoing edge",
+				"warning no-orphan-nodes unused: node is not reachable from the entry point",
+			},
+		},
+		{
+			name: "router without routes",
+			graph: func() *graph.MessageGraph[int] {
+				g := lintGraph("agent", []string{"agent", "tools", "unused"}, [2]string{"tools", "agent"}, [2]string{"unused", graph.END})
+				g.AddConditionalEdge("agent", func(context.Context, int) (string, error) { return graph.END, nil })
+				return g
+			}(),
+			expected: []string{
+				"warning routes-declared agent: conditional edge declares no routes",
+			},
+		},
+		{
+			name: "external API without retry",
+			graph: func() *graph.MessageGraph[int] {
+				g := lintGraph("search", nil, [2]string{"search", "fetch"}, [2]string{"fetch", "parse"}, [2]string{"parse", graph.END})
+				api := graph.WithResources(graph.Resources{Class: graph.ResourceExternalAPI, API: "web"})
+				g.AddNodeWithOptions("search", noop, api)
+				g.AddNodeWithOptions("fetch", noop, api, graph.WithRetry(3, time.Second))
+				g.AddNodeWithOptions("parse", noop, graph.WithResources(graph.Resources{Class: graph.ResourceCPU}))
+				return g
+			}(),
+			expected: []string{
+				"warning retry-external-apis search: external API node is not retried",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var issues []string
+			for _, issue := range graph.Lint(tc.graph) {
+				issues = append(issues, issue.String())
+			}
+			assert.Equal(t, tc.expected, issues)
+		})
+	}
+}
+
+func TestLintCustomRule(t *testing.T) {
+	t.Parallel()
+
+	snakeCase := graph.NewRule("snake-case", func(topology graph.Topology) []graph.Issue {
+		var issues []graph.Issue
+		for _, node := range topology.Nodes {
+			if strings.ToLower(node) != node {
+				issues = append(issues, graph.Issue{Severity: graph.SeverityWarning, Node: node, Message: "node names must be snake_case"})
+			}
+		}
+		return issues
+	})
+
+	g := lintGraph("Fetch", []string{"Fetch", "parse"}, [2]string{"Fetch", "parse"}, [2]string{"parse", graph.END})
+	issues := graph.Lint(g, snakeCase, graph.NoDeadEnds)
+	assert.Equal(t, []graph.Issue{{Rule: "snake-case", Severity: graph.SeverityWarning, Node: "Fetch", Message: "node names must be snake_case"}}, issues)
+}
+
+func TestTopology(t *testing.T) {
+	t.Parallel()
+
+	topology := lintGraph("a", []string{"b", "a"}, [2]string{"b", graph.END}, [2]string{"a", "b"}).Topology()
+	assert.Equal(t, graph.Topology{
+		EntryPoint: "a",
+		Nodes:      []string{"a", "b"},
+		Edges:      []graph.Edge{{From: "a", To: "b"}, {From: "b", To: graph.END}},
+	}, topology)
+	assert.True(t, topology.HasNode(graph.END))
+	assert.False(t, topology.HasNode("c"))
+	assert.Equal(t, map[string]bool{"b": true, graph.END: true}, topology.Reachable("b"))
+
+	g := lintGraph("a", nil, [2]string{"a", graph.END})
+	g.AddNodeWithOptions("a", noop, graph.WithRetry(3, time.Second))
+	assert.Equal(t, map[string]graph.RetryPolicy{"a": {MaxAttempts: 3, Backoff: time.Second}}, g.Topology().Retries)
+}
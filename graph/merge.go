@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoBranchSucceeded is returned by merge strategies when every branch of a fan-out failed.
+var ErrNoBranchSucceeded = errors.New("no branch succeeded")
+
+// successful returns the states of the branches that did not fail.
+func successful[T any](results []BranchResult[T]) []T {
+	states := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.Err == nil {
+			states = append(states, r.State)
+		}
+	}
+	return states
+}
+
+// Concatenate returns a join that appends to the input state the elements each successful
+// branch added to it, in branch order. Branches are expected to extend the input state;
+// a branch returning fewer elements than the input contributes its whole state.
+func Concatenate[E any]() JoinFunc[[]E] {
+	return func(_ context.Context, state []E, results []BranchResult[[]E]) ([]E, error) {
+		states := successful(results)
+		if len(states) == 0 && len(results) > 0 {
+			return state, ErrNoBranchSucceeded
+		}
+
+		merged := make([]E, len(state))
+		copy(merged, state)
+		for _, s := range states {
+			if len(s) >= len(state) {
+				s = s[len(state):]
+			}
+			merged = append(merged, s...)
+		}
+		return merged, nil
+	}
+}
+
+// Synthesize returns a join that hands the states of the successful branches to synthesize,
+// typically a model call combining several candidate answers into one.
+func Synthesize[T any](synthesize func(ctx context.Context, state T, candidates []T) (T, error)) JoinFunc[T] {
+	return func(ctx context.Context, state T, results []BranchResult[T]) (T, error) {
+		candidates := successful(results)
+		if len(candidates) == 0 {
+			return state, ErrNoBranchSucceeded
+		}
+
+		merged, err := synthesize(ctx, state, candidates)
+		if err != nil {
+			return state, fmt.Errorf("synthesizing branch results: %w", err)
+		}
+		return merged, nil
+	}
+}
+
+// PickBest returns a join that scores the state of every successful branch with judge
+// and keeps the highest scoring one. Ties are resolved in favour of the earliest branch.
+func PickBest[T any](judge func(ctx context.Context, state T, candidate T) (float64, error)) JoinFunc[T] {
+	return func(ctx context.Context, state T, results []BranchResult[T]) (T, error) {
+		var (
+			best      T
+			bestScore float64
+			found     bool
+		)
+		for _, r := range results {
+			if r.Err != nil {
+				continue
+			}
+
+			score, err := judge(ctx, state, r.State)
+			if err != nil {
+				return state, fmt.Errorf("judging branch %s: %w", r.Name, err)
+			}
+			if !found || score > bestScore {
+				best, bestScore, found = r.State, score, true
+			}
+		}
+
+		if !found {
+			return state, ErrNoBranchSucceeded
+		}
+		return best, nil
+	}
+}
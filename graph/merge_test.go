@@ -0,0 +1,95 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcatenate(t *testing.T) {
+	t.Parallel()
+
+	results := []graph.BranchResult[[]string]{
+		{Name: "a", State: []string{"in", "a1", "a2"}},
+		{Name: "b", Err: errors.New("failed")},
+		{Name: "c", State: []string{"in", "c1"}},
+	}
+
+	merged, err := graph.Concatenate[string]()(context.Background(), []string{"in"}, results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"in", "a1", "a2", "c1"}, merged)
+
+	_, err = graph.Concatenate[string]()(context.Background(), nil, results[1:2])
+	assert.ErrorIs(t, err, graph.ErrNoBranchSucceeded)
+}
+
+func TestSynthesize(t *testing.T) {
+	t.Parallel()
+
+	join := graph.Synthesize(func(_ context.Context, state string, candidates []string) (string, error) {
+		return state + ": " + strings.Join(candidates, " & "), nil
+	})
+
+	merged, err := join(context.Background(), "answers", []graph.BranchResult[string]{
+		{Name: "a", State: "yes"},
+		{Name: "b", Err: errors.New("failed")},
+		{Name: "c", State: "maybe"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "answers: yes & maybe", merged)
+
+	_, err = join(context.Background(), "answers", nil)
+	assert.ErrorIs(t, err, graph.ErrNoBranchSucceeded)
+}
+
+func TestPickBest(t *testing.T) {
+	t.Parallel()
+
+	judgeErr := errors.New("judge unavailable")
+	join := graph.PickBest(func(_ context.Context, _ string, candidate string) (float64, error) {
+		if candidate == "bad" {
+			return 0, judgeErr
+		}
+		return float64(len(candidate)), nil
+	})
+
+	best, err := join(context.Background(), "", []graph.BranchResult[string]{
+		{Name: "a", State: "short"},
+		{Name: "b", State: "longest"},
+		{Name: "c", State: "tieing"},
+		{Name: "d", State: "ignored-because-failed", Err: errors.New("failed")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "longest", best)
+
+	_, err = join(context.Background(), "", []graph.BranchResult[string]{{Name: "a", State: "bad"}})
+	require.ErrorIs(t, err, judgeErr)
+
+	_, err = join(context.Background(), "", nil)
+	assert.ErrorIs(t, err, graph.ErrNoBranchSucceeded)
+}
+
+func TestFanOutWithMergeStrategy(t *testing.T) {
+	t.Parallel()
+
+	appendBranch := func(name string) graph.Branch[[]string] {
+		return graph.Branch[[]string]{
+			Name: name,
+			Function: func(_ context.Context, state []string) ([]string, error) {
+				return graph.AppendMessages(state, name), nil
+			},
+		}
+	}
+
+	node := graph.FanOut(graph.FailFast, graph.Concatenate[string](),
+		appendBranch("doc1"), appendBranch("doc2"), appendBranch("doc3"))
+
+	output, err := node(context.Background(), []string{"query"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"query", "doc1", "doc2", "doc3"}, output)
+}
@@ -0,0 +1,27 @@
+package graph
+
+import "reflect"
+
+// AppendMessages returns state with msgs appended, without modifying any element of state.
+//
+// Node functions receiving a message history should extend it with AppendMessages (or append)
+// and never modify elements in place. This makes appending in place safe: the runtime hands
+// states shared between concurrent branches to each branch with its capacity clipped, so the
+// first append of every branch copies the history while sequential steps reuse the spare
+// capacity left by the previous step.
+func AppendMessages[E any](state []E, msgs ...E) []E {
+	return append(state, msgs...)
+}
+
+// Clip returns the state with its capacity clipped to its length when it is a slice, so that
+// appends by the receiver never write into memory visible to other holders of the state.
+// Other states are returned unchanged.
+func Clip[T any](state T) T {
+	v := reflect.ValueOf(&state).Elem()
+	if v.Kind() != reflect.Slice || v.Len() == v.Cap() {
+		return state
+	}
+
+	clipped, _ := v.Slice3(0, v.Len(), v.Len()).Interface().(T)
+	return clipped
+}
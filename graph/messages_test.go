@@ -0,0 +1,78 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMessages(t *testing.T) {
+	t.Parallel()
+
+	state := make([]string, 1, 8)
+	state[0] = "q"
+	next := graph.AppendMessages(state, "a1", "a2")
+
+	assert.Equal(t, []string{"q", "a1", "a2"}, next)
+	assert.Equal(t, []string{"q"}, state)
+	assert.Same(t, &state[0], &next[0], "sequential appends reuse spare capacity")
+}
+
+func TestClip(t *testing.T) {
+	t.Parallel()
+
+	state := make([]string, 2, 8)
+	clipped := graph.Clip(state)
+	assert.Equal(t, 2, cap(clipped))
+	assert.Equal(t, 8, cap(state))
+
+	type named []int
+	assert.Equal(t, 1, cap(graph.Clip(append(make(named, 0, 4), 1))))
+
+	assert.Equal(t, "unchanged", graph.Clip("unchanged"))
+}
+
+func TestAppendMessagesInParallelBranches(t *testing.T) {
+	t.Parallel()
+
+	// The input has spare capacity every branch would write into without clipping.
+	input := make([]string, 1, 64)
+	input[0] = "q"
+
+	branches := make([]graph.Branch[[]string], 16)
+	for i := range branches {
+		name := fmt.Sprintf("b%d", i)
+		branches[i] = graph.Branch[[]string]{
+			Name: name,
+			Function: func(_ context.Context, state []string) ([]string, error) {
+				for j := range 4 {
+					state = graph.AppendMessages(state, fmt.Sprintf("%s-%d", name, j))
+				}
+				return state, nil
+			},
+		}
+	}
+
+	join := func(_ context.Context, _ []string, results []graph.BranchResult[[]string]) ([]string, error) {
+		for i, r := range results {
+			expected := []string{"q"}
+			for j := range 4 {
+				expected = append(expected, fmt.Sprintf("b%d-%d", i, j))
+			}
+			if fmt.Sprint(expected) != fmt.Sprint(r.State) {
+				return nil, fmt.Errorf("branch %s state corrupted: %v", r.Name, r.State)
+			}
+		}
+		return nil, nil
+	}
+
+	for range 20 {
+		_, err := graph.FanOut(graph.FailFast, join, branches...)(context.Background(), input)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"q"}, input)
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorLabel is the label of the edges added with AddErrorEdge, shown in renderings.
+const ErrorLabel = "error"
+
+// errorEdge is the edge taken when its source node fails.
+type errorEdge[T any] struct {
+	// to is the node handling the failure.
+	to string
+
+	// inject returns the state the handler is called with; nil leaves it unchanged.
+	inject func(state T, err *NodeError) T
+}
+
+// AddErrorEdge makes the runs continue with the handler node when the "from" node fails, after
+// its retries, instead of failing: e.g. a node answering from a cache when a search API is down,
+// or apologizing to the user. The handler is called with the state the failed node was called
+// with, into which inject, unless nil, puts the failure, e.g. in a field of the state or as a
+// message. The failure is still reported to callbacks and traces as an error of the node.
+//
+// Failures due to the context of the run being done and interrupts are not handled. Adding an
+// error edge from the same node again replaces it. In Topology, error edges are labeled
+// ErrorLabel and flagged Error.
+func (g *MessageGraph[T]) AddErrorEdge(from, handler string, inject func(state T, err *NodeError) T) {
+	g.errorEdges[from] = errorEdge[T]{to: handler, inject: inject}
+}
+
+// onError handles the failure of a node called with the input state through its error edge:
+// it returns the state to call the handler with and the edge leading to it. It returns the
+// failure if the node has no error edge or the failure is not to be handled.
+func (r *Runnable[T]) onError(ctx context.Context, index int, input, state T, failure *NodeError) (T, []Edge, error) {
+	e, ok := r.graph.errorEdges[failure.Node]
+	if !ok || ctx.Err() != nil || errors.Is(failure, ErrInterrupted) {
+		return state, nil, failure
+	}
+
+	// The handler must not see the appends of the failed node.
+	state = Clip(input)
+	if e.inject != nil {
+		state = e.inject(state, failure)
+	}
+	edges := []Edge{{From: failure.Node, To: e.to, Label: ErrorLabel, Error: true}}
+	streamFromContext[T](ctx).emit(StreamEvent[T]{Kind: EventRoute, Step: index, Node: failure.Node, Branch: currentBranch(ctx), Edges: edges})
+	return state, edges, nil
+}
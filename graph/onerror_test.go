@@ -0,0 +1,195 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// errorEdgeGraph returns a graph classifying, searching with the search node then answering,
+// whose search failures are handled by a cache node.
+func errorEdgeGraph(t *testing.T, search func(context.Context, []string) ([]string, error)) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("classify")
+	g.AddNode("classify", appendNode("classify"))
+	g.AddNode("search", search)
+	g.AddNode("cache", appendNode("cache"))
+	g.AddNode("answer", appendNode("answer"))
+	g.AddEdge("classify", "search")
+	g.AddEdge("search", "answer")
+	g.AddEdge("cache", "answer")
+	g.AddErrorEdge("search", "cache", func(state []string, err *graph.NodeError) []string {
+		return append(state, err.Error())
+	})
+	g.SetFinishPoint("answer")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestErrorEdge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		search   func(context.Context, []string) ([]string, error)
+		expected []string
+	}{
+		{
+			name:     "success",
+			search:   appendNode("search"),
+			expected: []string{"classify", "search", "answer"},
+		},
+		{
+			name: "error",
+			search: func(_ context.Context, state []string) ([]string, error) {
+				return append(state, "partial"), errors.New("search API down")
+			},
+			expected: []string{"classify", "error in node search: search API down", "cache", "answer"},
+		},
+		{
+			name: "panic",
+			search: func(context.Context, []string) ([]string, error) {
+				panic("search bug")
+			},
+			expected: []string{"classify", "error in node search: node search panicked: search bug", "cache", "answer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := errorEdgeGraph(t, tc.search).Invoke(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestErrorEdgeStream(t *testing.T) {
+	t.Parallel()
+
+	runnable := errorEdgeGraph(t, func(_ context.Context, state []string) ([]string, error) {
+		return state, errors.New("search API down")
+	})
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+
+	var routes []graph.Edge
+	for event := range events {
+		if event.Kind == graph.EventRoute && event.Node == "search" {
+			routes = append(routes, event.Edges...)
+		}
+	}
+	assert.Equal(t, []graph.Edge{{From: "search", To: "cache", Label: graph.ErrorLabel, Error: true}}, routes)
+}
+
+func TestErrorEdgeNotHandled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runnable := errorEdgeGraph(t, func(ctx context.Context, state []string) ([]string, error) {
+		cancel()
+		return state, ctx.Err()
+	})
+
+	// The failures of canceled runs are not handled.
+	_, err := runnable.Invoke(ctx, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	var nodeErr *graph.NodeError
+	require.ErrorAs(t, err, &nodeErr)
+	assert.Equal(t, "search", nodeErr.Node)
+}
+
+func TestErrorEdgeTopology(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("search")
+	g.AddNode("search", appendNode("search"))
+	g.AddNode("apologize", appendNode("apologize"))
+	g.SetFinishPoint("search")
+	g.SetFinishPoint("apologize")
+	g.AddErrorEdge("search", "missing", nil)
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+
+	// The handler is reachable through the error edge.
+	g.AddErrorEdge("search", "apologize", nil)
+	_, err = g.Compile()
+	require.NoError(t, err)
+	assert.Contains(t, g.Topology().Edges, graph.Edge{From: "search", To: "apologize", Label: graph.ErrorLabel, Error: true})
+	assert.Contains(t, g.DOT(), `"search" -> "apologize" [label="error", style=dashed, color=red];`)
+}
+
+func TestErrorEdgeStateGraph(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Answers []string `reducer:"append"`
+		Failure string
+	}
+	g := graph.NewStateGraph[state]("search")
+	g.AddNode("search", func(context.Context, state) (state, error) {
+		return state{Answers: []string{"partial"}}, errors.New("search API down")
+	})
+	g.AddNode("apologize", func(_ context.Context, s state) (state, error) {
+		return state{Answers: []string{"sorry: " + s.Failure}}, nil
+	})
+	g.SetFinishPoint("search")
+	g.SetFinishPoint("apologize")
+	g.AddErrorEdge("search", "apologize", func(s state, err *graph.NodeError) state {
+		s.Failure = errors.Unwrap(err).Error()
+		return s
+	})
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	out, err := runnable.Invoke(context.Background(), state{Answers: []string{"cached"}})
+	require.NoError(t, err)
+	assert.Equal(t, state{Answers: []string{"cached", "sorry: search API down"}, Failure: "search API down"}, out)
+}
+
+func TestErrorEdgeNotOutgoing(t *testing.T) {
+	t.Parallel()
+
+	// The error edge is only taken on failures: successful runs would have nowhere to go.
+	g := graph.NewMessageGraph[[]string]("search")
+	g.AddNode("search", appendNode("search"))
+	g.AddNode("apologize", appendNode("apologize"))
+	g.SetFinishPoint("apologize")
+	g.AddErrorEdge("search", "apologize", nil)
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrNoOutgoingEdge)
+	assert.Equal(t, []graph.Issue{{Rule: "no-dead-ends", Severity: graph.SeverityError, Node: "search", Message: "node has no outgoing edge"}}, graph.Lint(g, graph.NoDeadEnds))
+}
+
+func TestErrorEdgeNotRoute(t *testing.T) {
+	t.Parallel()
+
+	// The router declares no routes, so it may lead to answer and loop: its error edge is not
+	// one of its routes.
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", appendNode("agent"))
+	g.AddNode("answer", appendNode("answer"))
+	g.AddNode("apologize", appendNode("apologize"))
+	g.AddConditionalEdge("agent", func(context.Context, []string) (string, error) {
+		return "agent", nil
+	})
+	g.AddErrorEdge("agent", "apologize", nil)
+	g.SetFinishPoint("answer")
+	g.SetFinishPoint("apologize")
+	runnable, err := g.Compile()
+	require.NoError(t, err, "answer may be reached through the router")
+	assert.True(t, runnable.Plan().Cyclic)
+	assert.Equal(t, []graph.Issue{{Rule: "routes-declared", Severity: graph.SeverityWarning, Node: "agent", Message: "conditional edge declares no routes"}}, graph.Lint(g, graph.RoutesDeclared, graph.NoOrphanNodes))
+
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrMaxStepsExceeded)
+}
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"slices"
+	"sort"
+)
+
+// Topology returns the structure of the compiled graph.
+func (r *Runnable[T]) Topology() Topology {
+	return r.graph.Topology()
+}
+
+// vertices returns the declared nodes, with END appended if an edge points to it.
+func (t Topology) vertices() []string {
+	vertices := append([]string(nil), t.Nodes...)
+	for _, edge := range t.Edges {
+		if edge.To == END {
+			return append(vertices, END)
+		}
+	}
+	return vertices
+}
+
+// Components returns the strongly connected components of the graph in topological order: a
+// component comes before every component it has edges to. Nodes of a component are in lexical
+// order, and components that do not depend on each other are ordered by their first node.
+// Edges from or to undeclared nodes are ignored.
+func (t Topology) Components() [][]string {
+	vertices := t.vertices()
+	index := make(map[string]int, len(vertices))
+	for i, v := range vertices {
+		index[v] = i
+	}
+	successors := make([][]int, len(vertices))
+	for _, edge := range t.Edges {
+		from, okFrom := index[edge.From]
+		to, okTo := index[edge.To]
+		if okFrom && okTo {
+			successors[from] = append(successors[from], to)
+		}
+	}
+
+	component := tarjan(successors)
+	count := 0
+	for _, c := range component {
+		count = max(count, c+1)
+	}
+
+	members := make([][]string, count)
+	for v, c := range component {
+		members[c] = append(members[c], vertices[v])
+	}
+
+	// Order the condensation with Kahn's algorithm, picking the ready component with the
+	// smallest first node so the order does not depend on map iteration.
+	indegree := make([]int, count)
+	next := make([]map[int]bool, count)
+	for from, tos := range successors {
+		for _, to := range tos {
+			a, b := component[from], component[to]
+			if a == b || next[a][b] {
+				continue
+			}
+			if next[a] == nil {
+				next[a] = make(map[int]bool)
+			}
+			next[a][b] = true
+			indegree[b]++
+		}
+	}
+
+	var ready []int
+	for c := range members {
+		if indegree[c] == 0 {
+			ready = append(ready, c)
+		}
+	}
+	ordered := make([][]string, 0, count)
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return members[ready[i]][0] < members[ready[j]][0] })
+		c := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, members[c])
+		for to := range next[c] {
+			if indegree[to]--; indegree[to] == 0 {
+				ready = append(ready, to)
+			}
+		}
+	}
+	return ordered
+}
+
+// tarjan returns the strongly connected component of each vertex of a graph given as adjacency
+// lists. Vertices are visited in index order, and members of a component are thus listed in
+// index order by the caller.
+func tarjan(successors [][]int) []int {
+	const unvisited = -1
+
+	n := len(successors)
+	index, low, component := make([]int, n), make([]int, n), make([]int, n)
+	for v := range index {
+		index[v], component[v] = unvisited, unvisited
+	}
+	onStack := make([]bool, n)
+	var stack []int
+	next, count := 0, 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range successors[v] {
+			if index[w] == unvisited {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component[w] = count
+				if w == v {
+					break
+				}
+			}
+			count++
+		}
+	}
+
+	for v := range successors {
+		if index[v] == unvisited {
+			visit(v)
+		}
+	}
+	return component
+}
+
+// Loops returns the components of the graph forming a loop: those with several nodes and the
+// nodes with an edge to themselves, in the order of Components.
+func (t Topology) Loops() [][]string {
+	var loops [][]string
+	for _, c := range t.Components() {
+		if len(c) > 1 || t.hasEdge(c[0], c[0]) {
+			loops = append(loops, c)
+		}
+	}
+	return loops
+}
+
+// cyclic reports whether runs may execute a node several times, through a loop or a router
+// without declared routes.
+func (t Topology) cyclic() bool {
+	return len(t.Loops()) > 0 || slices.ContainsFunc(t.Routers, t.opaque)
+}
+
+func (t Topology) hasEdge(from, to string) bool {
+	for _, edge := range t.Edges {
+		if edge.From == from && edge.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Order returns the nodes of the graph in topological order, a node coming before the nodes
+// it has edges to. Loops cannot be ordered: their nodes are kept together, in lexical order.
+// END is included if an edge points to it.
+func (t Topology) Order() []string {
+	var order []string
+	for _, c := range t.Components() {
+		order = append(order, c...)
+	}
+	return order
+}
+
+// Layers returns the nodes of the graph grouped by depth: nodes without incoming edge are in
+// the first layer, and every other node is one layer below the deepest node with an edge to
+// it. Nodes of a loop share their layer. Nodes of a layer are in lexical order. END is
+// included if an edge points to it.
+func (t Topology) Layers() [][]string {
+	components := t.Components()
+	of := make(map[string]int)
+	for c, members := range components {
+		for _, node := range members {
+			of[node] = c
+		}
+	}
+
+	// Components are in topological order, so the depth of the sources of an edge is final
+	// when the edge is relaxed.
+	depth := make([]int, len(components))
+	for c, members := range components {
+		for _, node := range members {
+			for _, next := range t.Successors(node) {
+				if to, ok := of[next]; ok && to != c {
+					depth[to] = max(depth[to], depth[c]+1)
+				}
+			}
+		}
+	}
+
+	var layers [][]string
+	for c, members := range components {
+		for len(layers) <= depth[c] {
+			layers = append(layers, nil)
+		}
+		layers[depth[c]] = append(layers[depth[c]], members...)
+	}
+	for _, layer := range layers {
+		sort.Strings(layer)
+	}
+	return layers
+}
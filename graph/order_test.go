@@ -0,0 +1,99 @@
+package graph_test
+
+import (
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyOrdering(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		topology   graph.Topology
+		components [][]string
+		loops      [][]string
+		layers     [][]string
+	}{
+		{
+			name: "linear",
+			topology: lintGraph("a", []string{"c", "b", "a"},
+				[2]string{"a", "b"}, [2]string{"b", "c"}, [2]string{"c", graph.END}).Topology(),
+			components: [][]string{{"a"}, {"b"}, {"c"}, {graph.END}},
+			layers:     [][]string{{"a"}, {"b"}, {"c"}, {graph.END}},
+		},
+		{
+			name: "loop",
+			topology: graph.Topology{
+				EntryPoint: "plan",
+				Nodes:      []string{"act", "observe", "plan", "report"},
+				Edges: []graph.Edge{
+					{From: "act", To: "observe"},
+					{From: "observe", To: "act"},
+					{From: "observe", To: "report"},
+					{From: "plan", To: "act"},
+					{From: "report", To: graph.END},
+				},
+			},
+			components: [][]string{{"plan"}, {"act", "observe"}, {"report"}, {graph.END}},
+			loops:      [][]string{{"act", "observe"}},
+			layers:     [][]string{{"plan"}, {"act", "observe"}, {"report"}, {graph.END}},
+		},
+		{
+			name: "diamond",
+			topology: graph.Topology{
+				EntryPoint: "a",
+				Nodes:      []string{"a", "b", "c", "d"},
+				Edges: []graph.Edge{
+					{From: "a", To: "b"},
+					{From: "a", To: "c"},
+					{From: "b", To: "d"},
+					{From: "c", To: "b"},
+					{From: "d", To: "d"},
+				},
+			},
+			components: [][]string{{"a"}, {"c"}, {"b"}, {"d"}},
+			loops:      [][]string{{"d"}},
+			layers:     [][]string{{"a"}, {"c"}, {"b"}, {"d"}},
+		},
+		{
+			name: "independent sources",
+			topology: graph.Topology{
+				EntryPoint: "b",
+				Nodes:      []string{"a", "b", "c"},
+				Edges:      []graph.Edge{{From: "b", To: "c"}, {From: "a", To: "c"}, {From: "c", To: "missing"}},
+			},
+			components: [][]string{{"a"}, {"b"}, {"c"}},
+			layers:     [][]string{{"a", "b"}, {"c"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.components, tc.topology.Components())
+			assert.Equal(t, tc.loops, tc.topology.Loops())
+			assert.Equal(t, tc.layers, tc.topology.Layers())
+
+			var order []string
+			for _, c := range tc.components {
+				order = append(order, c...)
+			}
+			assert.Equal(t, order, tc.topology.Order())
+		})
+	}
+}
+
+func TestRunnableTopology(t *testing.T) {
+	t.Parallel()
+
+	g := lintGraph("a", []string{"a"}, [2]string{"a", graph.END})
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, g.Topology(), runnable.Topology())
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrNodePanic is matched by the *PanicError errors of nodes that panicked.
+var ErrNodePanic = errors.New("node panicked")
+
+// PanicError is returned when a node panics, instead of crashing the process running the
+// graph, unless the graph was compiled WithPanicPropagation. It matches ErrNodePanic, and
+// unwraps to the value of the panic when it is an error. Nodes that panicked are not retried.
+type PanicError struct {
+	// Node is the name of the node, or of the prefetch that panicked.
+	Node string
+
+	// Value is the value the node panicked with.
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked, as formatted by debug.Stack.
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("node %s panicked: %v", e.Node, e.Value)
+}
+
+// Is makes errors.Is match ErrNodePanic.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrNodePanic
+}
+
+// Unwrap returns the value of the panic if it is an error, e.g. a runtime.Error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithPanicPropagation lets the panics of nodes propagate and crash the process, e.g. to debug
+// them in tests, instead of failing the invocation with a *PanicError.
+func WithPanicPropagation() CompileOption {
+	return func(o *compileOptions) {
+		o.propagatePanics = true
+	}
+}
+
+type recoverPanicsKey struct{}
+
+// call calls the function of the node, turning its panics, and those of the branches of
+// FanOut it runs, into a *PanicError unless they propagate.
+func (r *Runnable[T]) call(ctx context.Context, node Node[T], state T) (out T, err error) {
+	if r.propagatePanics {
+		return node.call(context.WithValue(ctx, recoverPanicsKey{}, false), state)
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			out, err = state, &PanicError{Node: node.Name, Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return node.call(context.WithValue(ctx, recoverPanicsKey{}, true), state)
+}
+
+// recoverBranch turns the panic of a parallel branch, or of a speculative handler, into its
+// error when the node running it recovers panics. It must be deferred.
+func recoverBranch(ctx context.Context, err *error) {
+	if recovering, _ := ctx.Value(recoverPanicsKey{}).(bool); !recovering {
+		return
+	}
+	if v := recover(); v != nil {
+		*err = &PanicError{Node: currentNodeName(ctx), Value: v, Stack: debug.Stack()}
+	}
+}
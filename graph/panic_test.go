@@ -0,0 +1,116 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		node    func(context.Context, []string) ([]string, error)
+		value   any
+		message string
+	}{
+		{
+			name: "value",
+			node: func(context.Context, []string) ([]string, error) {
+				panic("tool bug")
+			},
+			value:   "tool bug",
+			message: "error in node tool: node tool panicked: tool bug",
+		},
+		{
+			name: "runtime error",
+			node: func(_ context.Context, state []string) ([]string, error) {
+				return state[:len(state)+1], nil
+			},
+			message: "node tool panicked: runtime error: slice bounds out of range",
+		},
+		{
+			name: "fan out branch",
+			node: graph.FanOut(graph.FailFast, graph.Concatenate[string](), graph.Branch[[]string]{
+				Name: "lookup",
+				Function: func(context.Context, []string) ([]string, error) {
+					panic("tool bug")
+				},
+			}),
+			value:   "tool bug",
+			message: "error in node tool: branch lookup: node tool panicked: tool bug",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewMessageGraph[[]string]("start")
+			g.AddNode("start", appendNode("start"))
+			g.AddNode("tool", tc.node)
+			g.AddEdge("start", "tool")
+			g.SetFinishPoint("tool")
+			runnable, err := g.Compile()
+			require.NoError(t, err)
+
+			out, err := runnable.Invoke(context.Background(), nil)
+			require.ErrorIs(t, err, graph.ErrNodePanic)
+			assert.ErrorContains(t, err, tc.message)
+			assert.Equal(t, []string{"start"}, out)
+
+			var panicErr *graph.PanicError
+			require.ErrorAs(t, err, &panicErr)
+			assert.Equal(t, "tool", panicErr.Node)
+			assert.Contains(t, string(panicErr.Stack), "panic_test.go")
+			if tc.value != nil {
+				assert.Equal(t, tc.value, panicErr.Value)
+			} else {
+				var runtimeErr runtime.Error
+				assert.ErrorAs(t, err, &runtimeErr)
+			}
+		})
+	}
+}
+
+func TestPanicNotRetried(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	g := graph.NewMessageGraph[[]string]("tool")
+	g.AddNodeWithOptions("tool", func(context.Context, []string) ([]string, error) {
+		calls++
+		panic(errors.New("tool bug"))
+	}, graph.WithRetry(3, 0))
+	g.SetFinishPoint("tool")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrNodePanic)
+	assert.EqualError(t, errors.Unwrap(errors.Unwrap(err)), "tool bug")
+	assert.Equal(t, 1, calls)
+}
+
+func TestPanicPropagation(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("tool")
+	g.AddNode("tool", func(context.Context, []string) ([]string, error) {
+		panic("tool bug")
+	})
+	g.SetFinishPoint("tool")
+	runnable, err := g.Compile(graph.WithPanicPropagation())
+	require.NoError(t, err)
+
+	assert.PanicsWithValue(t, "tool bug", func() {
+		_, _ = runnable.Invoke(context.Background(), nil)
+	})
+}
@@ -0,0 +1,179 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrQuorumNotReached is returned when fewer branches than required by a quorum policy succeed.
+	ErrQuorumNotReached = errors.New("quorum not reached")
+
+	// ErrBranchCancelled is recorded for branches that were still running when a quorum was reached.
+	ErrBranchCancelled = errors.New("branch cancelled")
+)
+
+// Branch is a named unit of work run concurrently by a fan-out.
+type Branch[T any] struct {
+	// Name identifies the branch in results and errors.
+	Name string
+
+	// Function is the function executed by the branch.
+	Function func(ctx context.Context, state T) (T, error)
+}
+
+// BranchResult is the outcome of a single branch of a fan-out.
+type BranchResult[T any] struct {
+	// Name is the name of the branch.
+	Name string
+
+	// Input is the state the branch received.
+	Input T
+
+	// State is the state returned by the branch.
+	State T
+
+	// Err is the error returned by the branch, if any.
+	Err error
+}
+
+// JoinFunc merges the results of a fan-out into a single state.
+// Results are passed in the order the branches were declared.
+type JoinFunc[T any] func(ctx context.Context, state T, results []BranchResult[T]) (T, error)
+
+type branchMode int
+
+const (
+	failFast branchMode = iota
+	bestEffort
+	quorum
+)
+
+// BranchPolicy controls how a fan-out reacts to branch failures.
+type BranchPolicy struct {
+	mode     branchMode
+	required int
+}
+
+var (
+	// FailFast cancels the remaining branches and fails the fan-out on the first branch error,
+	// once they returned.
+	FailFast = BranchPolicy{mode: failFast}
+
+	// BestEffort waits for every branch and passes all results, including failed ones, to the join.
+	BestEffort = BranchPolicy{mode: bestEffort}
+)
+
+// Quorum proceeds as soon as k branches succeed, cancelling the ones still running and waiting
+// for them to return, and fails once k successes are no longer possible. Values of k below one are treated as one.
+func Quorum(k int) BranchPolicy {
+	return BranchPolicy{mode: quorum, required: max(k, 1)}
+}
+
+// String returns a human readable name of the policy.
+func (p BranchPolicy) String() string {
+	switch p.mode {
+	case bestEffort:
+		return "best-effort"
+	case quorum:
+		return fmt.Sprintf("quorum(%d)", p.required)
+	default:
+		return "fail-fast"
+	}
+}
+
+// FanOut returns a node function that runs the branches concurrently on the same input state,
+// applies the policy to their failures and merges the results with join.
+// Slice states are clipped before being shared, so branches can append to them safely.
+func FanOut[T any](policy BranchPolicy, join JoinFunc[T], branches ...Branch[T]) func(ctx context.Context, state T) (T, error) {
+	return func(ctx context.Context, state T) (T, error) {
+		results, err := runBranches(ctx, policy, state, branches)
+		if err != nil {
+			return state, err
+		}
+		return join(ctx, state, results)
+	}
+}
+
+type indexedResult[T any] struct {
+	index int
+	state T
+	err   error
+}
+
+// runBranches executes the branches and collects their results according to the policy.
+func runBranches[T any](ctx context.Context, policy BranchPolicy, state T, branches []Branch[T]) ([]BranchResult[T], error) {
+	if policy.mode == quorum && policy.required > len(branches) {
+		return nil, fmt.Errorf("%w: %d required but only %d branches", ErrQuorumNotReached, policy.required, len(branches))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan indexedResult[T], len(branches))
+	pending := len(branches)
+	// Branches still running on return are canceled and waited for, so none of them reports
+	// to the stream, the callbacks or the profile of the invocation after it returned.
+	defer func() {
+		cancel()
+		for ; pending > 0; pending-- {
+			<-done
+		}
+	}()
+
+	shared := Clip(state)
+	for i, b := range branches {
+		go func() {
+			var s T
+			var err error
+			func() {
+				defer recoverBranch(ctx, &err)
+				s, err = b.Function(ctx, shared)
+			}()
+			done <- indexedResult[T]{index: i, state: s, err: err}
+		}()
+	}
+
+	results := make([]BranchResult[T], len(branches))
+	finished := make([]bool, len(branches))
+	for i, b := range branches {
+		results[i].Name = b.Name
+		results[i].Input = shared
+	}
+
+	var succeeded int
+	var errs []error
+	for range branches {
+		r := <-done
+		pending--
+		finished[r.index] = true
+		results[r.index].State = r.state
+		results[r.index].Err = r.err
+
+		if r.err != nil {
+			err := fmt.Errorf("branch %s: %w", branches[r.index].Name, r.err)
+			errs = append(errs, err)
+
+			if policy.mode == failFast {
+				return nil, err
+			}
+			if policy.mode == quorum && len(branches)-len(errs) < policy.required {
+				return nil, fmt.Errorf("%w: %d of %d branches failed, %d required: %w",
+					ErrQuorumNotReached, len(errs), len(branches), policy.required, errors.Join(errs...))
+			}
+			continue
+		}
+
+		succeeded++
+		if policy.mode == quorum && succeeded == policy.required {
+			break
+		}
+	}
+
+	for i := range results {
+		if !finished[i] {
+			results[i].Err = ErrBranchCancelled
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,274 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func constBranch(name, output string, delay time.Duration, err error) graph.Branch[string] {
+	return graph.Branch[string]{
+		Name: name,
+		Function: func(ctx context.Context, _ string) (string, error) {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			return output, err
+		},
+	}
+}
+
+// joinSummary renders every branch result as name=state or name!error.
+func joinSummary(_ context.Context, _ string, results []graph.BranchResult[string]) (string, error) {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			parts = append(parts, r.Name+"!"+r.Err.Error())
+			continue
+		}
+		parts = append(parts, r.Name+"="+r.State)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func TestFanOutPolicies(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+
+	testCases := []struct {
+		name           string
+		policy         graph.BranchPolicy
+		branches       []graph.Branch[string]
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			name:   "Fail-fast all succeed",
+			policy: graph.FailFast,
+			branches: []graph.Branch[string]{
+				constBranch("a", "1", 0, nil),
+				constBranch("b", "2", time.Millisecond, nil),
+			},
+			expectedOutput: "a=1,b=2",
+		},
+		{
+			name:   "Fail-fast cancels siblings",
+			policy: graph.FailFast,
+			branches: []graph.Branch[string]{
+				constBranch("a", "", 0, boom),
+				constBranch("b", "2", time.Hour, nil),
+			},
+			expectedError: boom,
+		},
+		{
+			name:   "Best-effort records errors",
+			policy: graph.BestEffort,
+			branches: []graph.Branch[string]{
+				constBranch("a", "", 0, boom),
+				constBranch("b", "2", time.Millisecond, nil),
+			},
+			expectedOutput: "a!boom,b=2",
+		},
+		{
+			name:   "Quorum reached",
+			policy: graph.Quorum(2),
+			branches: []graph.Branch[string]{
+				constBranch("a", "1", 0, nil),
+				constBranch("b", "", 0, boom),
+				constBranch("c", "3", time.Millisecond, nil),
+				constBranch("d", "4", time.Hour, nil),
+			},
+			expectedOutput: "a=1,b!boom,c=3,d!branch cancelled",
+		},
+		{
+			name:   "Quorum not reached",
+			policy: graph.Quorum(2),
+			branches: []graph.Branch[string]{
+				constBranch("a", "", 0, boom),
+				constBranch("b", "", 0, boom),
+				constBranch("c", "3", time.Hour, nil),
+			},
+			expectedError: graph.ErrQuorumNotReached,
+		},
+		{
+			name:   "Quorum larger than branches",
+			policy: graph.Quorum(3),
+			branches: []graph.Branch[string]{
+				constBranch("a", "1", 0, nil),
+			},
+			expectedError: graph.ErrQuorumNotReached,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			node := graph.FanOut(tc.policy, joinSummary, tc.branches...)
+			output, err := node(context.Background(), "input")
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func TestFanOutInGraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[string]("fanout")
+	g.AddNode("fanout", graph.FanOut(graph.BestEffort, joinSummary,
+		constBranch("left", "L", 0, nil),
+		constBranch("right", "R", 0, nil),
+	))
+	g.AddEdge("fanout", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "left=L,right=R", output)
+}
+
+func TestBranchPolicyString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "fail-fast", graph.FailFast.String())
+	assert.Equal(t, "best-effort", graph.BestEffort.String())
+	assert.Equal(t, "quorum(1)", graph.Quorum(0).String())
+}
+
+// policyGraph returns a graph fanning out from split to a, b and c, of which b fails, then
+// reporting the merged state.
+func policyGraph(policies map[string]graph.BranchPolicy) *graph.MessageGraph[string] {
+	g := graph.NewMessageGraph[string]("split")
+	g.AddNode("split", func(context.Context, string) (string, error) { return "", nil })
+	for _, name := range []string{"a", "c"} {
+		g.AddNode(name, func(context.Context, string) (string, error) { return name, nil })
+	}
+	g.AddNode("b", func(context.Context, string) (string, error) { return "", errors.New("boom") })
+	g.AddNode("report", func(_ context.Context, state string) (string, error) { return state + ";report", nil })
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddEdge("split", name)
+		g.AddEdge(name, "report")
+	}
+	g.SetFinishPoint("report")
+	g.SetJoin(joinSummary)
+	for from, policy := range policies {
+		g.SetBranchPolicy(from, policy)
+	}
+	return g
+}
+
+func TestBranchPolicyParallelEdges(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policies map[string]graph.BranchPolicy
+		expected string
+		err      error
+	}{
+		{
+			name: "fail fast by default",
+			err:  errors.New("branch b: error in node b: boom"),
+		},
+		{
+			name:     "best effort",
+			policies: map[string]graph.BranchPolicy{"split": graph.BestEffort},
+			expected: "a=a,b!error in node b: boom,c=c;report",
+		},
+		{
+			name:     "quorum reached",
+			policies: map[string]graph.BranchPolicy{"split": graph.Quorum(1)},
+		},
+		{
+			name:     "quorum not reached",
+			policies: map[string]graph.BranchPolicy{"split": graph.Quorum(3)},
+			err:      graph.ErrQuorumNotReached,
+		},
+		{
+			name:     "policy of another node",
+			policies: map[string]graph.BranchPolicy{"a": graph.BestEffort},
+			err:      errors.New("branch b: error in node b: boom"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, err := policyGraph(tc.policies).Compile()
+			require.NoError(t, err)
+
+			output, err := runnable.Invoke(context.Background(), "")
+			switch {
+			case errors.Is(tc.err, graph.ErrQuorumNotReached):
+				require.ErrorIs(t, err, tc.err)
+			case tc.err != nil:
+				require.ErrorContains(t, err, tc.err.Error())
+			case tc.expected == "":
+				// Which branches the quorum waits for depends on their scheduling.
+				require.NoError(t, err)
+				assert.True(t, strings.HasSuffix(output, ";report"))
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestBranchPolicyAllFailed(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[string]("split")
+	g.AddNode("split", func(context.Context, string) (string, error) { return "", nil })
+	for _, name := range []string{"a", "b"} {
+		g.AddNode(name, func(context.Context, string) (string, error) { return "", errors.New(name + " down") })
+		g.AddEdge("split", name)
+		g.SetFinishPoint(name)
+	}
+	g.SetJoin(joinSummary)
+	g.SetBranchPolicy("split", graph.BestEffort)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), "")
+	require.ErrorContains(t, err, "branch a: error in node a: a down")
+	require.ErrorContains(t, err, "branch b: error in node b: b down")
+}
+
+func TestBranchPolicyResumed(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	runnable, err := policyGraph(map[string]graph.BranchPolicy{"split": graph.BestEffort}).Compile(
+		graph.WithCheckpointer(cp), graph.WithInterruptBefore("a"))
+	require.NoError(t, err)
+	ctx := graph.WithThreadID(context.Background(), "thread")
+
+	// The policy of split still applies to its branches when they are resumed.
+	_, err = runnable.Invoke(ctx, "")
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+	interrupt, state, err := runnable.Pending(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"split"}, interrupt.From)
+	output, err := runnable.Resume(ctx, interrupt, state)
+	require.NoError(t, err)
+	assert.Equal(t, "a=a,b!error in node b: boom,c=c;report", output)
+}
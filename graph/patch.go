@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidPatch is returned by Patch for patches of an unknown kind.
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrPatchNotFound is returned by Unpatch when the node has no patch of the kind.
+	ErrPatchNotFound = errors.New("patch not found")
+)
+
+// PatchKind is the kind of a Patch.
+type PatchKind string
+
+const (
+	// PatchDisable disables a node: its function is not called, and the node returns the
+	// fallback of the patch instead. A disabled command node goes to the target of the patch.
+	PatchDisable PatchKind = "disable"
+
+	// PatchReroute reroutes the outgoing edges of a node: whatever its edges, router or sender,
+	// execution continues with the target of the patch.
+	PatchReroute PatchKind = "reroute"
+)
+
+// Patch is a change of the behavior of a compiled graph made at runtime, e.g. to disable a
+// node calling a failing API during an incident without redeploying; see Runnable.Patch.
+type Patch[T any] struct {
+	// Kind is the kind of the patch.
+	Kind PatchKind `json:"kind"`
+
+	// Node is the node disabled, or whose edges are rerouted.
+	Node string `json:"node"`
+
+	// To is the node the edges of Node lead to, for PatchReroute, and the node a disabled
+	// command node goes to, for PatchDisable; END ends the runs.
+	To string `json:"to,omitempty"`
+
+	// Fallback is the state returned by Node, for PatchDisable, applied as if Node had
+	// returned it: it replaces the state, or is reduced into it for a StateGraph. Without
+	// fallback, the node returns the state unchanged.
+	Fallback *T `json:"fallback,omitempty"`
+
+	// Reason records why the patch was applied, e.g. the incident it mitigates.
+	Reason string `json:"reason,omitempty"`
+
+	// AppliedAt is the time the patch was applied; set by Patch.
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// patches are the patches of a Runnable, by kind and node.
+type patches[T any] struct {
+	mu      sync.RWMutex
+	patches map[PatchKind]map[string]Patch[T]
+
+	// cyclic is set when the graph with the reroute patches applied may execute a node several
+	// times; only meaningful while edges are rerouted.
+	cyclic bool
+}
+
+// Patch applies the patch to the Runnable, replacing the patch of the same kind of the node, if
+// any; Patches lists the patches applied. Patches apply to the steps started after Patch returns, including those of
+// the invocations in progress, until Unpatch reverts them. They are not saved anywhere: a
+// graph compiled again, e.g. by a new deployment, has none, so hotfixes do not outlive the
+// incidents they mitigate.
+func (r *Runnable[T]) Patch(p Patch[T]) error {
+	if _, ok := r.graph.nodes[p.Node]; !ok || p.Node == END {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, p.Node)
+	}
+	switch p.Kind {
+	case PatchDisable:
+		if !r.graph.conditionalEdges[p.Node].command {
+			p.To = ""
+			break
+		}
+		// Without its function, the command node has nowhere to go.
+		if p.To == "" {
+			return fmt.Errorf("%w: disabling command node %s without target", ErrInvalidPatch, p.Node)
+		}
+		if _, ok := r.graph.nodes[p.To]; !ok {
+			return fmt.Errorf("disabling node %s: %w: %s", p.Node, ErrNodeNotFound, p.To)
+		}
+	case PatchReroute:
+		if _, ok := r.graph.nodes[p.To]; !ok {
+			return fmt.Errorf("rerouting node %s: %w: %s", p.Node, ErrNodeNotFound, p.To)
+		}
+		p.Fallback = nil
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidPatch, p.Kind)
+	}
+	p.AppliedAt = time.Now()
+
+	r.patches.mu.Lock()
+	defer r.patches.mu.Unlock()
+	if r.patches.patches == nil {
+		r.patches.patches = make(map[PatchKind]map[string]Patch[T])
+	}
+	if r.patches.patches[p.Kind] == nil {
+		r.patches.patches[p.Kind] = make(map[string]Patch[T])
+	}
+	r.patches.patches[p.Kind][p.Node] = p
+	r.patches.cyclic = r.patchedCyclic()
+	return nil
+}
+
+// Unpatch reverts the patch of the kind of the node. It returns ErrPatchNotFound if the node
+// has none.
+func (r *Runnable[T]) Unpatch(kind PatchKind, node string) error {
+	r.patches.mu.Lock()
+	defer r.patches.mu.Unlock()
+	if _, ok := r.patches.patches[kind][node]; !ok {
+		return fmt.Errorf("%w: %s %s", ErrPatchNotFound, kind, node)
+	}
+	delete(r.patches.patches[kind], node)
+	r.patches.cyclic = r.patchedCyclic()
+	return nil
+}
+
+// Patches returns the patches applied to the Runnable, by kind then node.
+func (r *Runnable[T]) Patches() []Patch[T] {
+	r.patches.mu.RLock()
+	defer r.patches.mu.RUnlock()
+	var all []Patch[T]
+	for _, byNode := range r.patches.patches {
+		for _, p := range byNode {
+			all = append(all, p)
+		}
+	}
+	slices.SortFunc(all, func(a, b Patch[T]) int {
+		if c := strings.Compare(string(a.Kind), string(b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Node, b.Node)
+	})
+	return all
+}
+
+// patch returns the patch of the kind of the node, if any.
+func (r *Runnable[T]) patch(kind PatchKind, node string) (Patch[T], bool) {
+	r.patches.mu.RLock()
+	defer r.patches.mu.RUnlock()
+	p, ok := r.patches.patches[kind][node]
+	return p, ok
+}
+
+// isCyclic reports whether runs may execute a node several times, with the reroute patches
+// applied.
+func (r *Runnable[T]) isCyclic() bool {
+	r.patches.mu.RLock()
+	defer r.patches.mu.RUnlock()
+	if len(r.patches.patches[PatchReroute]) == 0 {
+		return r.cyclic
+	}
+	return r.patches.cyclic
+}
+
+// patchedCyclic returns whether the graph with the reroute patches applied is cyclic. The
+// patches must be locked.
+func (r *Runnable[T]) patchedCyclic() bool {
+	reroutes := r.patches.patches[PatchReroute]
+	if len(reroutes) == 0 {
+		return r.cyclic
+	}
+	t := r.graph.Topology()
+	t.Edges = slices.DeleteFunc(t.Edges, func(edge Edge) bool {
+		_, rerouted := reroutes[edge.From]
+		return rerouted && !edge.Error
+	})
+	t.Routers = slices.DeleteFunc(t.Routers, func(node string) bool {
+		_, rerouted := reroutes[node]
+		return rerouted
+	})
+	for node, p := range reroutes {
+		t.Edges = append(t.Edges, Edge{From: node, To: p.To})
+	}
+	return t.cyclic()
+}
+
+// fallback returns the state returned by the node disabled by p instead of calling it.
+func (r *Runnable[T]) fallback(p Patch[T], state T) T {
+	switch {
+	case p.Fallback == nil:
+		return state
+	case r.graph.reduce != nil:
+		return r.graph.reduce(state, *p.Fallback)
+	default:
+		return *p.Fallback
+	}
+}
@@ -0,0 +1,217 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// patchGraph returns a graph searching then answering, whose search node fails.
+func patchGraph(t *testing.T) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("classify")
+	g.AddNode("classify", appendNode("classify"))
+	g.AddNode("search", func(_ context.Context, state []string) ([]string, error) {
+		return state, errors.New("search API down")
+	})
+	g.AddNode("answer", appendNode("answer"))
+	g.AddNode("escalate", appendNode("escalate"))
+	g.AddConditionalEdge("classify", func(context.Context, []string) (string, error) {
+		return "search", nil
+	}, "search", "escalate")
+	g.AddEdge("search", "answer")
+	g.SetFinishPoint("answer")
+	g.SetFinishPoint("escalate")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	fallback := []string{"no results"}
+	testCases := []struct {
+		name     string
+		patches  []graph.Patch[[]string]
+		expected []string
+	}{
+		{
+			name:     "disable",
+			patches:  []graph.Patch[[]string]{{Kind: graph.PatchDisable, Node: "search"}},
+			expected: []string{"classify", "answer"},
+		},
+		{
+			name:     "disable with fallback",
+			patches:  []graph.Patch[[]string]{{Kind: graph.PatchDisable, Node: "search", Fallback: &fallback}},
+			expected: []string{"no results", "answer"},
+		},
+		{
+			name:     "reroute",
+			patches:  []graph.Patch[[]string]{{Kind: graph.PatchReroute, Node: "classify", To: "escalate"}},
+			expected: []string{"classify", "escalate"},
+		},
+		{
+			name:     "reroute to END",
+			patches:  []graph.Patch[[]string]{{Kind: graph.PatchReroute, Node: "classify", To: graph.END}},
+			expected: []string{"classify"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable := patchGraph(t)
+			_, err := runnable.Invoke(context.Background(), nil)
+			require.ErrorContains(t, err, "search API down")
+
+			for _, p := range tc.patches {
+				require.NoError(t, runnable.Patch(p))
+			}
+			out, err := runnable.Invoke(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+
+			for _, p := range tc.patches {
+				require.NoError(t, runnable.Unpatch(p.Kind, p.Node))
+			}
+			_, err = runnable.Invoke(context.Background(), nil)
+			require.ErrorContains(t, err, "search API down")
+		})
+	}
+}
+
+func TestPatchStateGraph(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Answers []string `reducer:"append"`
+	}
+	g := graph.NewStateGraph[state]("search")
+	g.AddNode("search", func(context.Context, state) (state, error) {
+		return state{}, errors.New("search API down")
+	})
+	g.SetFinishPoint("search")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	require.NoError(t, runnable.Patch(graph.Patch[state]{Kind: graph.PatchDisable, Node: "search", Fallback: &state{Answers: []string{"unavailable"}}}))
+	out, err := runnable.Invoke(context.Background(), state{Answers: []string{"cached"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cached", "unavailable"}, out.Answers)
+}
+
+func TestPatchDisableCommand(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("triage")
+	g.AddCommandNode("triage", func(_ context.Context, state []string) (graph.Command[[]string], error) {
+		return graph.Command[[]string]{Update: append(state, "triage"), Goto: "search"}, errors.New("triage API down")
+	}, "search", "escalate")
+	g.AddNode("search", appendNode("search"))
+	g.AddNode("escalate", appendNode("escalate"))
+	g.SetFinishPoint("search")
+	g.SetFinishPoint("escalate")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// The command node has no next node unless the patch sets it.
+	err = runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "triage"})
+	require.ErrorIs(t, err, graph.ErrInvalidPatch)
+	err = runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "triage", To: "missing"})
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+	assert.Empty(t, runnable.Patches())
+
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "triage", To: "escalate"}))
+	out, err := runnable.Invoke(context.Background(), []string{"question"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"question", "escalate"}, out)
+}
+
+func TestPatchRerouteCycle(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("a")
+	g.AddNode("a", appendNode("a"))
+	g.AddNode("b", appendNode("b"))
+	g.AddEdge("a", "b")
+	g.SetFinishPoint("b")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// The cycle added by the patch is bounded by the default step limit.
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "b", To: "a"}))
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrMaxStepsExceeded)
+}
+
+func TestPatchRerouteAcyclic(t *testing.T) {
+	t.Parallel()
+
+	// A chain longer than the default step limit.
+	g := graph.NewMessageGraph[[]string]("node-0")
+	for i := range 40 {
+		name := fmt.Sprintf("node-%d", i)
+		g.AddNode(name, appendNode(name))
+		if i > 0 {
+			g.AddEdge(fmt.Sprintf("node-%d", i-1), name)
+		}
+	}
+	g.SetFinishPoint("node-39")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// A patch keeping the graph acyclic does not limit the runs.
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "node-38", To: "node-39"}))
+	out, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, out, 40)
+
+	// Nor does a patch skipping nodes.
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "node-10", To: "node-39"}))
+	out, err = runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, out, 12)
+
+	// A patch adding a cycle does, until it is reverted.
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "node-39", To: "node-0"}))
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrMaxStepsExceeded)
+	require.NoError(t, runnable.Unpatch(graph.PatchReroute, "node-39"))
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+}
+
+func TestPatches(t *testing.T) {
+	t.Parallel()
+
+	runnable := patchGraph(t)
+	assert.Empty(t, runnable.Patches())
+
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "classify", To: "escalate", Reason: "INC-1"}))
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "search", To: "ignored"}))
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "answer"}))
+	require.NoError(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "classify", To: "search", Reason: "INC-2"}))
+
+	patches := runnable.Patches()
+	require.Len(t, patches, 3)
+	assert.Equal(t, []string{"answer", "search", "classify"}, []string{patches[0].Node, patches[1].Node, patches[2].Node})
+	assert.Empty(t, patches[1].To)
+	assert.Equal(t, "search", patches[2].To)
+	assert.Equal(t, "INC-2", patches[2].Reason)
+	assert.False(t, patches[2].AppliedAt.IsZero())
+
+	require.ErrorIs(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: "missing"}), graph.ErrNodeNotFound)
+	require.ErrorIs(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchDisable, Node: graph.END}), graph.ErrNodeNotFound)
+	require.ErrorIs(t, runnable.Patch(graph.Patch[[]string]{Kind: graph.PatchReroute, Node: "classify", To: "missing"}), graph.ErrNodeNotFound)
+	require.ErrorIs(t, runnable.Patch(graph.Patch[[]string]{Kind: "replace", Node: "classify"}), graph.ErrInvalidPatch)
+	require.ErrorIs(t, runnable.Unpatch(graph.PatchReroute, "search"), graph.ErrPatchNotFound)
+}
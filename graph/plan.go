@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// PlanVersion is the version of the format of Plans. Plans of other versions are ignored.
+const PlanVersion = 1
+
+// Plan is the structural metadata Compile derives from a graph: the result of its validation
+// and analysis, and the fingerprint of the structure they were derived from. Plans are saved
+// to disk, e.g. at build time, and passed back to Compile with WithPlan to skip the validation
+// and analysis of the graph, as in the cold starts of serverless functions.
+type Plan struct {
+	// Version is the version of the format of the plan, PlanVersion.
+	Version int `json:"version"`
+
+	// Fingerprint is the hash of the structure of the graph: its entry point, nodes, edges,
+	// routes, join, branch policies and interrupts, and the names of the functions of its nodes
+	// and routers.
+	Fingerprint string `json:"fingerprint"`
+
+	// Nodes are the nodes of the graph, in order.
+	Nodes []string `json:"nodes"`
+
+	// Cyclic is set when runs may execute a node several times.
+	Cyclic bool `json:"cyclic"`
+}
+
+// WithPlan makes Compile trust a plan of the graph, returned by Runnable.Plan, instead of
+// validating and analyzing the graph again. The plan is only trusted when its fingerprint is
+// the fingerprint of the graph being compiled, so that changes of the structure or of the
+// functions registered for the nodes since the plan was saved are noticed; otherwise Compile
+// validates the graph as without plan. Check whether a plan was used by comparing it with the
+// plan of the compiled graph.
+func WithPlan(plan Plan) CompileOption {
+	return func(o *compileOptions) {
+		o.plan = &plan
+	}
+}
+
+// Plan returns the plan of the graph, to compile it again with WithPlan.
+func (r *Runnable[T]) Plan() Plan {
+	plan := r.plan
+	plan.Nodes = slices.Clone(plan.Nodes)
+	return plan
+}
+
+// planOf returns the plan of the graph with the analysis of its topology.
+func (g *MessageGraph[T]) planOf(t Topology, o compileOptions, cyclic bool) Plan {
+	return Plan{Version: PlanVersion, Fingerprint: g.fingerprint(t, o), Nodes: t.Nodes, Cyclic: cyclic}
+}
+
+// fingerprint returns the hash of everything validation and analysis read from the graph and
+// the compile options.
+func (g *MessageGraph[T]) fingerprint(t Topology, o compileOptions) string {
+	h := sha256.New()
+	field := func(w io.Writer, values ...string) {
+		// Length prefixes keep the encoding unambiguous.
+		for _, v := range values {
+			fmt.Fprintf(w, "%d:%s;", len(v), v)
+		}
+		fmt.Fprintln(w)
+	}
+
+	field(h, "entry", t.EntryPoint)
+	for _, name := range t.Nodes {
+		field(h, "node", name, funcName(g.nodes[name].Function))
+	}
+	for _, edge := range t.Edges {
+		field(h, "edge", edge.From, edge.To, fmt.Sprint(edge.Conditional))
+		if edge.Error {
+			field(h, "error", edge.From, funcName(g.errorEdges[edge.From].inject))
+		}
+	}
+	for _, from := range t.Routers {
+		c := g.conditionalEdges[from]
+		field(h, "router", from, funcName(c.router), funcName(c.sender), fmt.Sprint(c.command))
+	}
+	// Parallel edges are only told apart from the edges of the topology by their count.
+	froms := make([]string, 0, len(g.edges))
+	for from := range g.edges {
+		froms = append(froms, from)
+	}
+	slices.Sort(froms)
+	for _, from := range froms {
+		field(h, "out", from, fmt.Sprint(len(g.edges[from])))
+	}
+	field(h, "join", fmt.Sprint(g.join != nil))
+	fanOuts := make([]string, 0, len(g.branchPolicies))
+	for from := range g.branchPolicies {
+		fanOuts = append(fanOuts, from)
+	}
+	slices.Sort(fanOuts)
+	for _, from := range fanOuts {
+		field(h, "policy", from, g.branchPolicies[from].String())
+	}
+	field(h, "before", strings.Join(o.interruptBefore, ","))
+	field(h, "after", strings.Join(o.interruptAfter, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// funcName returns the name of the function f, or an empty string if it is nil. Closures are
+// named after the functions declaring them, including those they are inlined in, so their
+// names are only stable within a binary.
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if !v.IsValid() || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return "?"
+	}
+	return fn.Name()
+}
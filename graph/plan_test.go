@@ -0,0 +1,110 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		change  func(g *graph.MessageGraph[[]string])
+		opts    []graph.CompileOption
+		matches bool
+		err     error
+	}{
+		{
+			name:    "unchanged",
+			matches: true,
+		},
+		{
+			name: "node function",
+			change: func(g *graph.MessageGraph[[]string]) {
+				g.AddNode("send", appendNode("send"))
+			},
+		},
+		{
+			name: "edge",
+			change: func(g *graph.MessageGraph[[]string]) {
+				g.AddEdge("send", "missing")
+			},
+			err: graph.ErrNodeNotFound,
+		},
+		{
+			name: "node",
+			change: func(g *graph.MessageGraph[[]string]) {
+				g.AddNode("orphan", appendNode("orphan"))
+				g.AddEdge("orphan", graph.END)
+			},
+			err: graph.ErrUnreachableNode,
+		},
+		{
+			name: "interrupts",
+			opts: []graph.CompileOption{graph.WithInterruptBefore("missing")},
+			err:  graph.ErrNodeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, err := approvalGraph().Compile()
+			require.NoError(t, err)
+			data, err := json.Marshal(runnable.Plan())
+			require.NoError(t, err)
+			var plan graph.Plan
+			require.NoError(t, json.Unmarshal(data, &plan))
+			assert.Equal(t, graph.PlanVersion, plan.Version)
+			assert.Equal(t, []string{"approve", "draft", "send"}, plan.Nodes)
+			assert.False(t, plan.Cyclic)
+
+			g := approvalGraph()
+			if tc.change != nil {
+				tc.change(g)
+			}
+			replanned, err := g.Compile(append(tc.opts, graph.WithPlan(plan))...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, replanned.Plan().Fingerprint == plan.Fingerprint)
+
+			out, err := replanned.Invoke(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Len(t, out, 3)
+		})
+	}
+}
+
+func TestPlanSkipsAnalysis(t *testing.T) {
+	t.Parallel()
+
+	g := approvalGraph()
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// The plan is trusted as long as the graph matches it.
+	plan := runnable.Plan()
+	plan.Cyclic = true
+	planned, err := g.Compile(graph.WithPlan(plan))
+	require.NoError(t, err)
+	assert.True(t, planned.Plan().Cyclic)
+
+	_, err = planned.Invoke(graph.WithMaxSteps(context.Background(), 2), nil)
+	require.ErrorIs(t, err, graph.ErrMaxStepsExceeded)
+
+	plan.Version = graph.PlanVersion + 1
+	unplanned, err := g.Compile(graph.WithPlan(plan))
+	require.NoError(t, err)
+	assert.False(t, unplanned.Plan().Cyclic)
+}
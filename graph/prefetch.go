@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPrefetchNotFound is returned when a node awaits a prefetch that was not declared.
+var ErrPrefetchNotFound = errors.New("prefetch not found")
+
+type prefetchKey struct{}
+
+// prefetchResult holds the outcome of a prefetch once done is closed.
+type prefetchResult struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// AddPrefetch declares a dependency that is fetched concurrently with the execution of the graph.
+// Every prefetch starts when Invoke is called, using the input state, and is awaited by the nodes
+// that need it through Prefetched. Prefetches still running when Invoke returns are cancelled.
+func (g *MessageGraph[T]) AddPrefetch(name string, fn func(ctx context.Context, state T) (any, error)) {
+	g.prefetches[name] = fn
+}
+
+// startPrefetches starts all the prefetches and returns a context that gives nodes access to them.
+// The panics of the prefetches become their *PanicError unless the runnable propagates panics.
+func (r *Runnable[T]) startPrefetches(ctx context.Context, state T) context.Context {
+	results := make(map[string]*prefetchResult, len(r.graph.prefetches))
+	for name, fn := range r.graph.prefetches {
+		result := &prefetchResult{done: make(chan struct{})}
+		results[name] = result
+		go func() {
+			defer close(result.done)
+			defer func() {
+				if r.propagatePanics {
+					return
+				}
+				if v := recover(); v != nil {
+					result.err = &PanicError{Node: name, Value: v, Stack: debug.Stack()}
+				}
+			}()
+			result.value, result.err = fn(ctx, state)
+		}()
+	}
+	return context.WithValue(ctx, prefetchKey{}, results)
+}
+
+// Prefetched waits for the named prefetch of the running invocation and returns its result.
+func Prefetched(ctx context.Context, name string) (any, error) {
+	results, _ := ctx.Value(prefetchKey{}).(map[string]*prefetchResult)
+	r, ok := results[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPrefetchNotFound, name)
+	}
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("prefetch %s: %w", name, r.err)
+	}
+	return r.value, nil
+}
+
+// PrefetchedAs is like Prefetched but asserts the result to type V.
+func PrefetchedAs[V any](ctx context.Context, name string) (V, error) {
+	var zero V
+
+	value, err := Prefetched(ctx, name)
+	if err != nil {
+		return zero, err
+	}
+	v, ok := value.(V)
+	if !ok {
+		return zero, fmt.Errorf("prefetch %s: unexpected type %T", name, value)
+	}
+	return v, nil
+}
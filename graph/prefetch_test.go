@@ -0,0 +1,106 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetch(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	g := graph.NewMessageGraph[[]string]("plan")
+	g.AddPrefetch("docs", func(_ context.Context, state []string) (any, error) {
+		close(started)
+		return []string{"doc for " + state[0]}, nil
+	})
+	g.AddNode("plan", func(_ context.Context, state []string) ([]string, error) {
+		// The prefetch runs concurrently with the earlier nodes.
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			return nil, errors.New("prefetch did not start")
+		}
+		return append(state, "plan"), nil
+	})
+	g.AddNode("answer", func(ctx context.Context, state []string) ([]string, error) {
+		docs, err := graph.PrefetchedAs[[]string](ctx, "docs")
+		if err != nil {
+			return nil, err
+		}
+		return append(state, docs...), nil
+	})
+	g.AddEdge("plan", "answer")
+	g.AddEdge("answer", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), []string{"question"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"question", "plan", "doc for question"}, output)
+}
+
+func TestPrefetchErrors(t *testing.T) {
+	t.Parallel()
+
+	fetchErr := errors.New("search unavailable")
+	g := graph.NewMessageGraph[string]("node")
+	g.AddPrefetch("failing", func(context.Context, string) (any, error) {
+		return nil, fetchErr
+	})
+	g.AddPrefetch("number", func(context.Context, string) (any, error) {
+		return 42, nil
+	})
+	g.AddNode("node", func(ctx context.Context, _ string) (string, error) {
+		_, err := graph.Prefetched(ctx, "failing")
+		assert.ErrorIs(t, err, fetchErr)
+
+		_, err = graph.Prefetched(ctx, "unknown")
+		assert.ErrorIs(t, err, graph.ErrPrefetchNotFound)
+
+		_, err = graph.PrefetchedAs[string](ctx, "number")
+		assert.Error(t, err)
+
+		return "done", nil
+	})
+	g.AddEdge("node", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), "")
+	require.NoError(t, err)
+}
+
+func TestPrefetchPanic(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[string]("node")
+	g.AddPrefetch("docs", func(context.Context, string) (any, error) {
+		panic("search bug")
+	})
+	g.AddNode("node", func(ctx context.Context, _ string) (string, error) {
+		_, err := graph.Prefetched(ctx, "docs")
+		return "", err
+	})
+	g.AddEdge("node", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), "")
+	require.ErrorIs(t, err, graph.ErrNodePanic)
+	assert.ErrorContains(t, err, "prefetch docs: node docs panicked: search bug")
+
+	var panicErr *graph.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "docs", panicErr.Node)
+	assert.Contains(t, string(panicErr.Stack), "prefetch_test.go")
+}
@@ -0,0 +1,235 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// SpanKind classifies the time recorded in a profile.
+type SpanKind string
+
+const (
+	// SpanNode is the wall time of a node execution.
+	SpanNode SpanKind = "node"
+
+	// SpanModel is the latency of a model call.
+	SpanModel SpanKind = "model"
+
+	// SpanTool is the latency of a tool call.
+	SpanTool SpanKind = "tool"
+
+	// SpanSerialization is the time spent encoding or decoding state, e.g. the states of the
+	// checkpoints of the invocations of graphs compiled WithCheckpointer.
+	SpanSerialization SpanKind = "serialization"
+
+	// SpanCheckpoint is the time spent writing checkpoints to the checkpointer.
+	SpanCheckpoint SpanKind = "checkpoint"
+)
+
+type profileKey struct{}
+
+type nodeNameKey struct{}
+
+// ProfileEntry is a single timed span of an invocation.
+type ProfileEntry struct {
+	// Kind classifies the span.
+	Kind SpanKind `json:"kind"`
+
+	// Name identifies the span, e.g. the node, model or tool name.
+	Name string `json:"name"`
+
+	// Node is the node that was running when the span was recorded.
+	Node string `json:"node,omitempty"`
+
+	// Start is the time the span started.
+	Start time.Time `json:"start"`
+
+	// Duration is the duration of the span.
+	Duration time.Duration `json:"duration"`
+
+	// Input is the JSON-encoded state the node received, when input capture is enabled and the
+	// execution was sampled. It is cut to CaptureOptions.MaxBytes.
+	Input string `json:"input,omitempty"`
+
+	// InputSize is the size in bytes of the encoded input before it was cut.
+	InputSize int `json:"input_size,omitempty"`
+
+	// CallID identifies the model call recorded by the CallRecorder of the invocation, if any.
+	CallID string `json:"call_id,omitempty"`
+}
+
+// ProfileSummary aggregates the entries of a profile sharing the same kind and name.
+type ProfileSummary struct {
+	// Kind classifies the spans.
+	Kind SpanKind `json:"kind"`
+
+	// Name identifies the spans.
+	Name string `json:"name"`
+
+	// Count is the number of spans.
+	Count int `json:"count"`
+
+	// Total is the summed duration of the spans.
+	Total time.Duration `json:"total"`
+
+	// Max is the duration of the longest span.
+	Max time.Duration `json:"max"`
+}
+
+// Profile collects timings of a graph invocation.
+// It is safe for concurrent use, so nodes running in parallel can record spans.
+type Profile struct {
+	mu      sync.Mutex
+	start   time.Time
+	total   time.Duration
+	entries []ProfileEntry
+	capture *CaptureOptions
+}
+
+// WithProfiling returns a context that makes Invoke record per-node timings into the returned profile.
+// Nodes can record model, tool and other spans with ProfileSpan.
+func WithProfiling(ctx context.Context) (context.Context, *Profile) {
+	p := &Profile{start: time.Now()}
+	return context.WithValue(ctx, profileKey{}, p), p
+}
+
+// ProfileSpan starts a span of the given kind and returns the function that ends it.
+// It is a no-op when the context was not created by WithProfiling.
+//
+//	defer graph.ProfileSpan(ctx, graph.SpanModel, "gpt-4o")()
+func ProfileSpan(ctx context.Context, kind SpanKind, name string) func() {
+	p := profileFromContext(ctx)
+	if p == nil {
+		return func() {}
+	}
+
+	node := currentNodeName(ctx)
+	start := time.Now()
+	return func() {
+		p.record(ProfileEntry{Kind: kind, Name: name, Node: node, Start: start})
+	}
+}
+
+func profileFromContext(ctx context.Context) *Profile {
+	p, _ := ctx.Value(profileKey{}).(*Profile)
+	return p
+}
+
+func withNodeName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nodeNameKey{}, name)
+}
+
+func currentNodeName(ctx context.Context) string {
+	name, _ := ctx.Value(nodeNameKey{}).(string)
+	return name
+}
+
+// record adds an entry ending now.
+func (p *Profile) record(entry ProfileEntry) {
+	if p == nil {
+		return
+	}
+	entry.Duration = time.Since(entry.Start)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = append(p.entries, entry)
+}
+
+func (p *Profile) finish() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = time.Since(p.start)
+}
+
+// Total returns the wall time of the invocation. It is zero until Invoke returns.
+func (p *Profile) Total() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.total
+}
+
+// Entries returns a copy of the recorded spans in the order they ended.
+func (p *Profile) Entries() []ProfileEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ProfileEntry(nil), p.entries...)
+}
+
+// Summary aggregates the spans by kind and name, sorted by descending total duration.
+func (p *Profile) Summary() []ProfileSummary {
+	type key struct {
+		kind SpanKind
+		name string
+	}
+
+	index := make(map[key]int)
+	var summaries []ProfileSummary
+	for _, e := range p.Entries() {
+		k := key{e.Kind, e.Name}
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, ProfileSummary{Kind: e.Kind, Name: e.Name})
+		}
+		summaries[i].Count++
+		summaries[i].Total += e.Duration
+		summaries[i].Max = max(summaries[i].Max, e.Duration)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Total > summaries[j].Total
+	})
+	return summaries
+}
+
+// Text renders the profile summary as an aligned table.
+func (p *Profile) Text() string {
+	total := p.Total()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "total %s\n", total)
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tCOUNT\tTOTAL\tMAX\tSHARE")
+	for _, s := range p.Summary() {
+		share := 0.0
+		if total > 0 {
+			share = float64(s.Total) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%.1f%%\n", s.Kind, s.Name, s.Count, s.Total, s.Max, share)
+	}
+	_ = w.Flush()
+
+	return buf.String()
+}
+
+// ProfileFormatVersion is the version of the JSON encoding of profiles, stamped in their
+// "version" field so consumers can detect format changes.
+const ProfileFormatVersion = 1
+
+// MarshalJSON encodes the format version, the total, the summary and the individual spans.
+func (p *Profile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Version int              `json:"version"`
+		Total   time.Duration    `json:"total"`
+		Summary []ProfileSummary `json:"summary"`
+		Entries []ProfileEntry   `json:"entries"`
+	}{
+		Version: ProfileFormatVersion,
+		Total:   p.Total(),
+		Summary: p.Summary(),
+		Entries: p.Entries(),
+	})
+}
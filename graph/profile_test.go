@@ -0,0 +1,106 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiling(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[string]("agent")
+	g.AddNode("agent", func(ctx context.Context, state string) (string, error) {
+		stop := graph.ProfileSpan(ctx, graph.SpanModel, "gpt")
+		time.Sleep(2 * time.Millisecond)
+		stop()
+
+		defer graph.ProfileSpan(ctx, graph.SpanTool, "search")()
+		return state + "a", nil
+	})
+	g.AddNode("format", func(_ context.Context, state string) (string, error) {
+		return state + "f", nil
+	})
+	g.AddEdge("agent", "format")
+	g.AddEdge("format", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx, profile := graph.WithProfiling(context.Background())
+	_, err = runnable.Invoke(ctx, "")
+	require.NoError(t, err)
+
+	entries := profile.Entries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, graph.SpanModel, entries[0].Kind)
+	assert.Equal(t, "agent", entries[0].Node)
+	assert.Equal(t, graph.SpanTool, entries[1].Kind)
+	assert.Equal(t, graph.ProfileEntry{Kind: graph.SpanNode, Name: "format", Node: "format"},
+		graph.ProfileEntry{Kind: entries[3].Kind, Name: entries[3].Name, Node: entries[3].Node})
+
+	assert.GreaterOrEqual(t, profile.Total(), entries[0].Duration)
+
+	summary := profile.Summary()
+	require.Len(t, summary, 4)
+	assert.Equal(t, "agent", summary[0].Name, "summary is sorted by total time")
+
+	text := profile.Text()
+	assert.Contains(t, text, "model")
+	assert.Contains(t, text, "search")
+
+	data, err := json.Marshal(profile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"summary"`)
+	assert.Contains(t, string(data), `"version":1`)
+}
+
+func TestProfilingCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[string]("a")
+	g.AddNode("a", func(_ context.Context, state string) (string, error) { return state + "a", nil })
+	g.AddNode("b", func(_ context.Context, state string) (string, error) { return state + "b", nil })
+	g.AddEdge("a", "b")
+	g.SetFinishPoint("b")
+	store := checkpoint.NewMemory()
+	runnable, err := g.Compile(graph.WithCheckpointer(store))
+	require.NoError(t, err)
+
+	ctx, profile := graph.WithProfiling(graph.WithThreadID(context.Background(), "thread"))
+	_, err = runnable.Invoke(ctx, "")
+	require.NoError(t, err)
+
+	// The state is saved after a, then once complete.
+	var spans []graph.ProfileEntry
+	for _, entry := range profile.Entries() {
+		if entry.Kind != graph.SpanNode {
+			spans = append(spans, graph.ProfileEntry{Kind: entry.Kind, Name: entry.Name})
+		}
+	}
+	assert.Equal(t, []graph.ProfileEntry{
+		{Kind: graph.SpanSerialization, Name: "a"},
+		{Kind: graph.SpanCheckpoint, Name: "a"},
+		{Kind: graph.SpanSerialization, Name: graph.END},
+		{Kind: graph.SpanCheckpoint, Name: graph.END},
+	}, spans)
+
+	ctx, profile = graph.WithProfiling(context.Background())
+	_, err = runnable.GetState(ctx, "thread")
+	require.NoError(t, err)
+	require.Len(t, profile.Entries(), 1)
+	assert.Equal(t, graph.SpanSerialization, profile.Entries()[0].Kind)
+}
+
+func TestProfileSpanWithoutProfiling(t *testing.T) {
+	t.Parallel()
+
+	// Must not panic when profiling is disabled.
+	graph.ProfileSpan(context.Background(), graph.SpanModel, "gpt")()
+}
@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTokenBudgetExceeded is returned by Invoke when a run metered by a UsageMeter with a
+	// TokenBudget used it up.
+	ErrTokenBudgetExceeded = errors.New("token budget exceeded")
+
+	// ErrPolicyViolation is the error nodes wrap to abort a run violating a policy, such as a
+	// guardrail rejecting the output of a model. Runs aborted by hard scorers match it too; see
+	// ErrScoreViolation.
+	ErrPolicyViolation = errors.New("policy violation")
+)
+
+// Reason is why a run ended, so clients can render an appropriate message without parsing
+// errors; see ReasonOf.
+type Reason string
+
+const (
+	// ReasonCompleted is the reason of runs that reached END.
+	ReasonCompleted Reason = "completed"
+
+	// ReasonInterrupted is the reason of runs that paused at an interrupt.
+	ReasonInterrupted Reason = "interrupted"
+
+	// ReasonRecursionLimit is the reason of runs that reached their step limit; see
+	// ErrMaxStepsExceeded.
+	ReasonRecursionLimit Reason = "recursion_limit"
+
+	// ReasonTokenBudget is the reason of runs that used up their token budget; see
+	// ErrTokenBudgetExceeded.
+	ReasonTokenBudget Reason = "token_budget"
+
+	// ReasonCancelled is the reason of runs whose context was canceled or timed out.
+	ReasonCancelled Reason = "cancelled"
+
+	// ReasonPolicyViolation is the reason of runs aborted by a policy; see ErrPolicyViolation.
+	ReasonPolicyViolation Reason = "policy_violation"
+
+	// ReasonNodeError is the reason of runs that failed because a node failed, including nodes
+	// timing out; see NodeError.
+	ReasonNodeError Reason = "node_error"
+
+	// ReasonError is the reason of runs that failed for another reason, such as a router
+	// returning an undeclared route or a checkpointer failing.
+	ReasonError Reason = "error"
+)
+
+// ReasonOf returns the reason of a run that ended with err, the error returned by Invoke, Resume
+// or the final event of Stream. The most specific reason wins: a node failing because the run
+// was canceled ended the run with ReasonCancelled, and a node aborting the run with
+// ErrPolicyViolation with ReasonPolicyViolation.
+func ReasonOf(err error) Reason {
+	var nodeErr *NodeError
+	switch {
+	case err == nil:
+		return ReasonCompleted
+	case errors.Is(err, ErrInterrupted):
+		return ReasonInterrupted
+	case errors.Is(err, ErrPolicyViolation), errors.Is(err, ErrScoreViolation):
+		return ReasonPolicyViolation
+	case errors.Is(err, ErrTokenBudgetExceeded):
+		return ReasonTokenBudget
+	case errors.Is(err, ErrMaxStepsExceeded):
+		return ReasonRecursionLimit
+	case errors.Is(err, ErrNodeTimeout):
+		return ReasonNodeError
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ReasonCancelled
+	case errors.As(err, &nodeErr):
+		return ReasonNodeError
+	}
+	return ReasonError
+}
+
+// NodeError is returned when a node fails. It wraps the error the node returned.
+type NodeError struct {
+	// Node is the name of the node.
+	Node string
+
+	// Err is the error the node returned.
+	Err error
+}
+
+// Error implements error.
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("error in node %s: %v", e.Node, e.Err)
+}
+
+// Unwrap returns the error the node returned.
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
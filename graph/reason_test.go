@@ -0,0 +1,122 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestReasonOf(t *testing.T) {
+	t.Parallel()
+
+	// The agent loops until it answers, recording the tokens of a model call per step.
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNodeWithOptions("agent", func(ctx context.Context, state []string) ([]string, error) {
+		graph.RecordUsage(ctx, graph.Usage{Calls: 1, InputTokens: 40, OutputTokens: 10})
+		if len(state) == 0 {
+			return state, nil
+		}
+		switch state[0] {
+		case "fail":
+			return state, errors.New("model unavailable")
+		case "unsafe":
+			return state, fmt.Errorf("%w: output flagged", graph.ErrPolicyViolation)
+		case "slow":
+			<-ctx.Done()
+			return state, ctx.Err()
+		case "cancel":
+			return state, context.Canceled
+		}
+		return graph.AppendMessages(state, "agent"), nil
+	}, graph.WithTimeout(10*time.Millisecond))
+	g.AddConditionalEdge("agent", func(_ context.Context, state []string) (string, error) {
+		switch {
+		case len(state) == 0:
+			return graph.END, nil
+		case state[0] == "stray":
+			return "stray", nil
+		}
+		return "agent", nil
+	}, "agent", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	interrupted, err := g.Compile(graph.WithInterruptBefore("agent"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		runnable  *graph.Runnable[[]string]
+		ctx       func(context.Context) context.Context
+		input     []string
+		expected  graph.Reason
+		nodeError bool
+	}{
+		{name: "completed", expected: graph.ReasonCompleted},
+		{name: "interrupted", runnable: interrupted, expected: graph.ReasonInterrupted},
+		{
+			name:     "recursion limit",
+			ctx:      func(ctx context.Context) context.Context { return graph.WithMaxSteps(ctx, 3) },
+			input:    []string{"loop"},
+			expected: graph.ReasonRecursionLimit,
+		},
+		{
+			name: "token budget",
+			ctx: func(ctx context.Context) context.Context {
+				return graph.WithUsageMeter(ctx, &graph.UsageMeter{TokenBudget: 100})
+			},
+			input:    []string{"loop"},
+			expected: graph.ReasonTokenBudget,
+		},
+		{
+			name: "cancelled",
+			ctx: func(ctx context.Context) context.Context {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				return ctx
+			},
+			expected: graph.ReasonCancelled,
+		},
+		{name: "cancelled in node", input: []string{"cancel"}, expected: graph.ReasonCancelled, nodeError: true},
+		{name: "policy violation", input: []string{"unsafe"}, expected: graph.ReasonPolicyViolation, nodeError: true},
+		{name: "node error", input: []string{"fail"}, expected: graph.ReasonNodeError, nodeError: true},
+		{name: "node timeout", input: []string{"slow"}, expected: graph.ReasonNodeError, nodeError: true},
+		{name: "other error", input: []string{"stray"}, expected: graph.ReasonError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := runnable
+			if tc.runnable != nil {
+				r = tc.runnable
+			}
+			ctx := context.Background()
+			if tc.ctx != nil {
+				ctx = tc.ctx(ctx)
+			}
+			_, err := r.Invoke(ctx, tc.input)
+			assert.Equal(t, tc.expected, graph.ReasonOf(err), "error: %v", err)
+
+			var nodeErr *graph.NodeError
+			if assert.Equal(t, tc.nodeError, errors.As(err, &nodeErr)) && tc.nodeError {
+				assert.Equal(t, "agent", nodeErr.Node)
+				assert.EqualError(t, err, "error in node agent: "+nodeErr.Err.Error())
+			}
+		})
+	}
+}
+
+func TestReasonOfScoreViolation(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("%w: toxicity scored 0.9", graph.ErrScoreViolation)
+	assert.Equal(t, graph.ReasonPolicyViolation, graph.ReasonOf(err))
+}
@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mermaid renders the graph as a Mermaid flowchart. Edge labels are shown on the edges, edge
+// descriptions are written as comments next to them and the routes of conditional edges and
+// the error edges are dotted.
+func (t Topology) Mermaid() string {
+	ids := t.renderIDs()
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	b.WriteString("\tstart([start])\n")
+	for _, node := range t.renderNodes() {
+		if node == END {
+			fmt.Fprintf(&b, "\t%s([%s])\n", ids[node], mermaidText(node))
+		} else {
+			fmt.Fprintf(&b, "\t%s[%s]\n", ids[node], mermaidText(node))
+		}
+	}
+
+	if t.HasNode(t.EntryPoint) {
+		fmt.Fprintf(&b, "\tstart --> %s\n", ids[t.EntryPoint])
+	}
+	for _, edge := range t.Edges {
+		if edge.Description != "" {
+			fmt.Fprintf(&b, "\t%%%% %s -> %s: %s\n", edge.From, edge.To, oneLine(edge.Description))
+		}
+		arrow := "-->"
+		if edge.Conditional || edge.Error {
+			arrow = "-.->"
+		}
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "\t%s %s|%s| %s\n", ids[edge.From], arrow, mermaidText(edge.Label), ids[edge.To])
+		} else {
+			fmt.Fprintf(&b, "\t%s %s %s\n", ids[edge.From], arrow, ids[edge.To])
+		}
+	}
+	return b.String()
+}
+
+// DOT renders the graph in the Graphviz DOT language, e.g. to render it with dot -Tsvg. It is a
+// shorthand for Topology().DOT().
+func (g *MessageGraph[T]) DOT() string {
+	return g.Topology().DOT()
+}
+
+// dotTerminal are the attributes of the start and END nodes in DOT.
+const dotTerminal = "shape=oval, style=filled, fillcolor=lightgrey"
+
+// DOT renders the graph in the Graphviz DOT language. The start and END are filled ovals and the
+// entry point is bold. Edge labels are shown on the edges, edge descriptions become their
+// tooltips, the routes of conditional edges are dashed and the error edges dashed in red.
+func (t Topology) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	fmt.Fprintf(&b, "\t__start__ [label=\"start\", %s];\n", dotTerminal)
+	for _, node := range t.renderNodes() {
+		attrs := "shape=box"
+		switch node {
+		case END:
+			attrs = dotTerminal
+		case t.EntryPoint:
+			attrs += ", style=bold"
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", dotText(node), attrs)
+	}
+
+	if t.HasNode(t.EntryPoint) {
+		fmt.Fprintf(&b, "\t__start__ -> %s;\n", dotText(t.EntryPoint))
+	}
+	for _, edge := range t.Edges {
+		var attrs []string
+		if edge.Label != "" {
+			attrs = append(attrs, "label="+dotText(edge.Label))
+		}
+		if edge.Description != "" {
+			attrs = append(attrs, "tooltip="+dotText(edge.Description))
+		}
+		if edge.Conditional {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.Error {
+			attrs = append(attrs, "style=dashed", "color=red")
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", dotText(edge.From), dotText(edge.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// renderNodes returns the nodes to draw: the declared nodes, the undeclared nodes edges refer
+// to, and END if an edge points to it.
+func (t Topology) renderNodes() []string {
+	nodes := append([]string(nil), t.Nodes...)
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		seen[node] = true
+	}
+	end := false
+	for _, edge := range t.Edges {
+		for _, node := range []string{edge.From, edge.To} {
+			if node == END {
+				end = true
+			} else if !seen[node] {
+				seen[node] = true
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	if end {
+		nodes = append(nodes, END)
+	}
+	return nodes
+}
+
+// renderIDs returns Mermaid identifiers for the nodes, as node names may hold characters
+// Mermaid does not accept in identifiers.
+func (t Topology) renderIDs() map[string]string {
+	ids := make(map[string]string)
+	for i, node := range t.renderNodes() {
+		ids[node] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+// mermaidText quotes text for use as a Mermaid node or edge label.
+func mermaidText(s string) string {
+	return `"` + strings.ReplaceAll(oneLine(s), `"`, "#quot;") + `"`
+}
+
+// dotText quotes text as a DOT identifier.
+func dotText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,67 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func renderGraph() graph.Topology {
+	g := lintGraph("classify", []string{"classify", "answer"})
+	g.AddLabeledEdge("classify", "answer", "in scope", "the request \"fits\"\nthe assistant's scope")
+	g.AddEdge("answer", graph.END)
+	return g.Topology()
+}
+
+func TestMermaid(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `flowchart TD
+	start([start])
+	n0["answer"]
+	n1["classify"]
+	n2(["END"])
+	start --> n1
+	n0 --> n2
+	%% classify -> answer: the request "fits" the assistant's scope
+	n1 -->|"in scope"| n0
+`, renderGraph().Mermaid())
+}
+
+func TestDOT(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `digraph {
+	__start__ [label="start", shape=oval, style=filled, fillcolor=lightgrey];
+	"answer" [shape=box];
+	"classify" [shape=box, style=bold];
+	"END" [shape=oval, style=filled, fillcolor=lightgrey];
+	__start__ -> "classify";
+	"answer" -> "END";
+	"classify" -> "answer" [label="in scope", tooltip="the request \"fits\"\nthe assistant's scope"];
+}
+`, renderGraph().DOT())
+}
+
+func TestRenderUndeclaredNodes(t *testing.T) {
+	t.Parallel()
+
+	topology := graph.Topology{EntryPoint: "missing", Edges: []graph.Edge{{From: "a", To: "b"}}}
+	assert.Equal(t, "flowchart TD\n\tstart([start])\n\tn0[\"a\"]\n\tn1[\"b\"]\n\tn0 --> n1\n", topology.Mermaid())
+}
+
+func TestRenderConditionalEdges(t *testing.T) {
+	t.Parallel()
+
+	g := lintGraph("agent", []string{"agent", "tools"}, [2]string{"tools", "agent"})
+	g.AddConditionalEdge("agent", func(context.Context, int) (string, error) { return graph.END, nil }, "tools", graph.END)
+	topology := g.Topology()
+
+	assert.Equal(t, []string{"agent"}, topology.Routers)
+	assert.Contains(t, topology.Mermaid(), "\tn0 -.-> n2\n\tn0 -.-> n1\n")
+	assert.Contains(t, topology.DOT(), "\t\"agent\" -> \"tools\" [style=dashed];\n")
+	assert.Equal(t, topology.DOT(), g.DOT())
+	assert.Contains(t, topology.SVG(), `stroke-dasharray="6,4"`)
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// metadataStep is the checkpoint metadata key holding the encoded stepPoint of the checkpoints
+// saved between the steps of invocations on a thread.
+const metadataStep = "step"
+
+// stepPoint is the point between two steps a checkpoint was saved at.
+type stepPoint struct {
+	// Step is the index of the next step to execute.
+	Step int `json:"step"`
+
+	// Next are the nodes of the next step.
+	Next []string `json:"next"`
+
+	// From are the nodes Next were reached from.
+	From []string `json:"from,omitempty"`
+}
+
+// InvokeFrom replays the thread from one of its checkpoints, saved by an earlier invocation or
+// by UpdateState, to see what would have happened from there, possibly with an edited state.
+// The overrides are applied in order to the state of the checkpoint as if a node had returned
+// them: they replace the state, or are reduced into it for a StateGraph.
+//
+// Execution continues from the nodes the checkpoint was saved before, as Resume does: the
+// interrupt the checkpoint was saved at does not fire again, but the following ones do. Every
+// checkpoint can be replayed: those of interrupts, those saved after every step, and those of a
+// handoff, replayed from the entry point. The checkpoints of END, saved once an invocation
+// completed, have no nodes left to execute: their replay only saves the state with the
+// overrides. The replay forks the thread: its checkpoints and final state are appended to the
+// thread, so GetState returns them, while the checkpoints it started from stay in the history of
+// the checkpointer.
+func (r *Runnable[T]) InvokeFrom(ctx context.Context, threadID, checkpointID string, overrides ...T) (T, error) {
+	var state T
+	if r.checkpointer == nil {
+		return state, ErrNoCheckpointer
+	}
+
+	cp, err := r.checkpointer.Get(ctx, threadID, checkpointID)
+	if err != nil {
+		return state, err
+	}
+	snapshot, err := r.snapshotOf(ctx, cp)
+	if err != nil {
+		return state, err
+	}
+
+	state = snapshot.State
+	for _, override := range overrides {
+		if r.graph.reduce != nil {
+			state = r.graph.reduce(state, override)
+		} else {
+			state = override
+		}
+	}
+
+	interrupt := Interrupt{Node: cp.Node}
+	switch point, err := stepPointOf(cp); {
+	case err != nil:
+		return state, err
+	case snapshot.Interrupt != nil:
+		interrupt = *snapshot.Interrupt
+	case point != nil:
+		interrupt.Step, interrupt.Next, interrupt.From = point.Step, point.Next, point.From
+	case snapshot.Handoff != nil:
+		interrupt.Next = []string{r.graph.entryPoint}
+	}
+	interrupt.ThreadID = threadID
+	return r.Resume(ctx, &interrupt, state)
+}
+
+// saveStep saves the state before the step with the given index, executing the nodes reached
+// from the "from" nodes, to the thread of the outermost invocation on a thread, so InvokeFrom
+// can replay the thread from there.
+func (r *Runnable[T]) saveStep(ctx context.Context, index int, nodes, from []string, state T) error {
+	threadID := threadIDFromContext(ctx)
+	if r.checkpointer == nil || threadID == "" || currentNodeName(ctx) != "" {
+		return nil
+	}
+
+	encoded, err := json.Marshal(stepPoint{Step: index, Next: slices.Clone(nodes), From: slices.Clone(from)})
+	if err != nil {
+		return fmt.Errorf("saving step %d to thread %s: %w", index, threadID, err)
+	}
+	metadata := map[string]string{metadataStep: string(encoded)}
+	if err := r.save(ctx, threadID, strings.Join(from, ","), state, metadata); err != nil {
+		return fmt.Errorf("saving step %d to thread %s: %w", index, threadID, err)
+	}
+	return nil
+}
+
+// stepPointOf returns the point between steps the checkpoint was saved at, if it was saved by
+// saveStep.
+func stepPointOf(cp checkpoint.Checkpoint) (*stepPoint, error) {
+	encoded, ok := cp.Metadata[metadataStep]
+	if !ok {
+		return nil, nil
+	}
+	var point stepPoint
+	if err := json.Unmarshal([]byte(encoded), &point); err != nil {
+		return nil, fmt.Errorf("decoding step of thread %s: %w", cp.ThreadID, err)
+	}
+	return &point, nil
+}
@@ -0,0 +1,226 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestInvokeFrom(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		overrides [][]string
+		expected  []string
+	}{
+		{
+			name:     "replay",
+			expected: []string{"hi", "draft", "approve", "send"},
+		},
+		{
+			name:      "overrides",
+			overrides: [][]string{{"ignored"}, {"edited"}},
+			expected:  []string{"edited", "approve", "send"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cp := checkpoint.NewMemory()
+			runnable, err := approvalGraph().Compile(graph.WithCheckpointer(cp), graph.WithInterruptBefore("approve", "send"))
+			require.NoError(t, err)
+			ctx := graph.WithThreadID(context.Background(), "t1")
+
+			// Run the thread to completion, saving an interrupt before approve and before send.
+			_, err = runnable.Invoke(ctx, []string{"hi"})
+			require.ErrorIs(t, err, graph.ErrInterrupted)
+			first, err := runnable.GetState(ctx, "t1")
+			require.NoError(t, err)
+			interrupt, state, err := runnable.Pending(ctx, "t1")
+			require.NoError(t, err)
+			_, err = runnable.Resume(ctx, interrupt, state)
+			require.ErrorIs(t, err, graph.ErrInterrupted)
+			interrupt, state, err = runnable.Pending(ctx, "t1")
+			require.NoError(t, err)
+			_, err = runnable.Resume(ctx, interrupt, state)
+			require.NoError(t, err)
+
+			// Replay from before approve: the following interrupt fires again.
+			_, err = runnable.InvokeFrom(context.Background(), "t1", first.CheckpointID, tc.overrides...)
+			require.ErrorIs(t, err, graph.ErrInterrupted)
+			interrupt, state, err = runnable.Pending(ctx, "t1")
+			require.NoError(t, err)
+			assert.Equal(t, "send", interrupt.Node)
+			output, err := runnable.Resume(ctx, interrupt, state)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+
+			// The replay forked the thread, whose history is kept.
+			snapshot, err := runnable.GetState(ctx, "t1")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, snapshot.State)
+			history, err := cp.List(ctx, "t1")
+			require.NoError(t, err)
+			assert.Len(t, history, 5)
+		})
+	}
+}
+
+func TestInvokeFromStateGraph(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Topic string
+		Notes []string `reducer:"append"`
+	}
+
+	g := graph.NewStateGraph[state]("research")
+	g.AddNode("research", func(_ context.Context, s state) (state, error) {
+		return state{Notes: []string{"research " + s.Topic}}, nil
+	})
+	g.AddNode("write", func(_ context.Context, s state) (state, error) {
+		return state{Notes: []string{"write " + s.Topic}}, nil
+	})
+	g.AddEdge("research", "write")
+	g.AddEdge("write", graph.END)
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()), graph.WithInterruptAfter("research"))
+	require.NoError(t, err)
+
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	_, err = runnable.Invoke(ctx, state{Topic: "go"})
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+	snapshot, err := runnable.GetState(ctx, "t1")
+	require.NoError(t, err)
+
+	// Overrides are reduced into the state of the checkpoint.
+	output, err := runnable.InvokeFrom(ctx, "t1", snapshot.CheckpointID, state{Topic: "rust", Notes: []string{"edited"}})
+	require.NoError(t, err)
+	assert.Equal(t, state{Topic: "rust", Notes: []string{"research go", "edited", "write rust"}}, output)
+}
+
+func TestInvokeFromStep(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		checkpoint int
+		overrides  [][]string
+		saved      []string
+		expected   []string
+	}{
+		{
+			name:       "after draft",
+			checkpoint: 0,
+			overrides:  [][]string{{"edited"}},
+			saved:      []string{"approve", graph.END},
+			expected:   []string{"edited", "approve", "send"},
+		},
+		{
+			name:       "after approve",
+			checkpoint: 1,
+			saved:      []string{graph.END},
+			expected:   []string{"hi", "draft", "approve", "send"},
+		},
+		{
+			name:       "end",
+			checkpoint: 2,
+			overrides:  [][]string{{"edited"}},
+			saved:      []string{graph.END},
+			expected:   []string{"edited"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cp := checkpoint.NewMemory()
+			runnable, err := approvalGraph().Compile(graph.WithCheckpointer(cp))
+			require.NoError(t, err)
+			ctx := graph.WithThreadID(context.Background(), "t1")
+			_, err = runnable.Invoke(ctx, []string{"hi"})
+			require.NoError(t, err)
+
+			// The invocation saved the state after draft, after approve, and of END.
+			history, err := cp.List(ctx, "t1")
+			require.NoError(t, err)
+			require.Len(t, history, 3)
+			assert.Equal(t, []string{"draft", "approve", graph.END}, []string{history[0].Node, history[1].Node, history[2].Node})
+
+			output, err := runnable.InvokeFrom(context.Background(), "t1", history[tc.checkpoint].ID, tc.overrides...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+
+			// The replay executed the nodes following the checkpoint only.
+			replayed, err := cp.List(ctx, "t1")
+			require.NoError(t, err)
+			var saved []string
+			for _, c := range replayed[len(history):] {
+				saved = append(saved, c.Node)
+			}
+			assert.Equal(t, tc.saved, saved)
+			snapshot, err := runnable.GetState(ctx, "t1")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, snapshot.State)
+			assert.Empty(t, snapshot.Next)
+		})
+	}
+}
+
+func TestInvokeFromFailedRun(t *testing.T) {
+	t.Parallel()
+
+	fail := true
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("send", func(_ context.Context, state []string) ([]string, error) {
+		if fail {
+			return state, errors.New("mail server down")
+		}
+		return append(state, "send"), nil
+	})
+	g.AddEdge("draft", "send")
+	g.AddEdge("send", graph.END)
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	_, err = runnable.Invoke(ctx, []string{"hi"})
+	require.ErrorContains(t, err, "mail server down")
+
+	// The thread kept the state after draft, which is corrected and replayed from there.
+	snapshot, err := runnable.UpdateState(ctx, "t1", []string{"corrected"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"send"}, snapshot.Next)
+	fail = false
+	output, err := runnable.InvokeFrom(ctx, "t1", snapshot.CheckpointID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"corrected", "send"}, output)
+}
+
+func TestInvokeFromErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	runnable, err := approvalGraph().Compile()
+	require.NoError(t, err)
+	_, err = runnable.InvokeFrom(ctx, "t1", "0")
+	require.ErrorIs(t, err, graph.ErrNoCheckpointer)
+
+	runnable, err = approvalGraph().Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	_, err = runnable.InvokeFrom(ctx, "t1", "0")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	_, err = runnable.InvokeFrom(ctx, "t1", "bad", []string{"override"})
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
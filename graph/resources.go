@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ResourceClass tells what bounds the execution of a node.
+type ResourceClass string
+
+const (
+	// ResourceCPU marks nodes bound by local computation, such as parsing or embedding.
+	ResourceCPU ResourceClass = "cpu"
+
+	// ResourceGPU marks nodes requiring a GPU, such as local model inference.
+	ResourceGPU ResourceClass = "gpu"
+
+	// ResourceExternalAPI marks nodes bound by calls to an external API, such as a hosted
+	// model or a tool behind a rate limit.
+	ResourceExternalAPI ResourceClass = "external_api"
+)
+
+// Resources are the resource hints of a node, which schedulers use to place its executions on
+// suitable workers and to throttle them.
+type Resources struct {
+	// Class tells what bounds the execution of the node; empty if unknown.
+	Class ResourceClass `json:"class,omitempty"`
+
+	// API names the external API of ResourceExternalAPI nodes, so calls to each API can be
+	// limited separately.
+	API string `json:"api,omitempty"`
+
+	// Memory is the estimated peak memory of an execution, in bytes; zero if unknown.
+	Memory int64 `json:"memory,omitempty"`
+}
+
+// IsZero reports whether no hint is set.
+func (r Resources) IsZero() bool {
+	return r == Resources{}
+}
+
+// NodeOption configures a node added with AddNodeWithOptions.
+type NodeOption func(*nodeOptions)
+
+type nodeOptions struct {
+	resources   Resources
+	retry       RetryPolicy
+	timeout     time.Duration
+	chunks      ChunkShaping
+	sideEffects bool
+}
+
+// WithResources declares the resource hints of a node.
+func WithResources(resources Resources) NodeOption {
+	return func(o *nodeOptions) {
+		o.resources = resources
+	}
+}
+
+// ResourceLimits bound the executions running at once on a ResourceLimiter. Zero values do not
+// bound anything.
+type ResourceLimits struct {
+	// CPU is the number of ResourceCPU executions running at once.
+	CPU int
+
+	// GPU is the number of ResourceGPU executions running at once.
+	GPU int
+
+	// APIs are the numbers of ResourceExternalAPI executions running at once, by API name.
+	// APIs that are not listed are not bounded.
+	APIs map[string]int
+
+	// Memory is the total estimated memory of the executions running at once, in bytes. An
+	// execution estimated above it alone still runs when nothing else does.
+	Memory int64
+}
+
+// ResourceLimiter throttles node executions according to their resource hints, e.g. to share
+// the GPU of a worker between the graphs it runs. It is safe for concurrent use and is meant to
+// be shared by all the invocations running on a worker.
+type ResourceLimiter struct {
+	limits ResourceLimits
+
+	mu      sync.Mutex
+	cpu     int
+	gpu     int
+	apis    map[string]int
+	memory  int64
+	running int
+
+	// released is closed and replaced whenever resources are released.
+	released chan struct{}
+}
+
+// NewResourceLimiter returns a limiter enforcing the limits.
+func NewResourceLimiter(limits ResourceLimits) *ResourceLimiter {
+	return &ResourceLimiter{
+		limits:   limits,
+		apis:     make(map[string]int),
+		released: make(chan struct{}),
+	}
+}
+
+// Acquire waits until an execution with the given resources fits in the limits and reserves
+// them. The returned function releases them and must be called once the execution completes.
+// It returns the error of ctx if ctx is done first.
+func (l *ResourceLimiter) Acquire(ctx context.Context, resources Resources) (func(), error) {
+	for {
+		l.mu.Lock()
+		if l.fits(resources) {
+			l.reserve(resources, 1)
+			l.mu.Unlock()
+
+			var once sync.Once
+			return func() { once.Do(func() { l.release(resources) }) }, nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fits reports whether an execution with the resources fits in the limits. The caller holds mu.
+func (l *ResourceLimiter) fits(resources Resources) bool {
+	switch resources.Class {
+	case ResourceCPU:
+		if l.limits.CPU > 0 && l.cpu >= l.limits.CPU {
+			return false
+		}
+	case ResourceGPU:
+		if l.limits.GPU > 0 && l.gpu >= l.limits.GPU {
+			return false
+		}
+	case ResourceExternalAPI:
+		if limit, ok := l.limits.APIs[resources.API]; ok && l.apis[resources.API] >= limit {
+			return false
+		}
+	}
+	return l.limits.Memory <= 0 || l.running == 0 || l.memory+resources.Memory <= l.limits.Memory
+}
+
+// reserve adds the resources of sign executions to the usage. The caller holds mu.
+func (l *ResourceLimiter) reserve(resources Resources, sign int) {
+	switch resources.Class {
+	case ResourceCPU:
+		l.cpu += sign
+	case ResourceGPU:
+		l.gpu += sign
+	case ResourceExternalAPI:
+		l.apis[resources.API] += sign
+	}
+	l.memory += int64(sign) * resources.Memory
+	l.running += sign
+}
+
+func (l *ResourceLimiter) release(resources Resources) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reserve(resources, -1)
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+type resourceLimiterKey struct{}
+
+// WithResourceLimiter returns a context making Invoke wait for the limiter before executing
+// the nodes that declare resource hints. Nodes without hints are not throttled, and graphs
+// invoked by a throttled node run within its reservation, so they cannot wait for it.
+func WithResourceLimiter(ctx context.Context, limiter *ResourceLimiter) context.Context {
+	return context.WithValue(ctx, resourceLimiterKey{}, limiter)
+}
+
+// acquireResources reserves the resources of the node on the limiter of the context, if any,
+// and returns the context to execute the node with and the function releasing the resources.
+func acquireResources(ctx context.Context, resources Resources) (context.Context, func(), error) {
+	limiter, _ := ctx.Value(resourceLimiterKey{}).(*ResourceLimiter)
+	if limiter == nil || resources.IsZero() {
+		return ctx, func() {}, nil
+	}
+	release, err := limiter.Acquire(ctx, resources)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, resourceLimiterKey{}, nil), release, nil
+}
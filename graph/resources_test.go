@@ -0,0 +1,136 @@
+package graph_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceLimiter(t *testing.T) {
+	t.Parallel()
+
+	limits := graph.ResourceLimits{CPU: 1, GPU: 2, APIs: map[string]int{"openai": 1}, Memory: 100}
+	testCases := []struct {
+		name    string
+		held    graph.Resources
+		request graph.Resources
+		fits    bool
+	}{
+		{
+			name:    "cpu",
+			held:    graph.Resources{Class: graph.ResourceCPU},
+			request: graph.Resources{Class: graph.ResourceCPU},
+		},
+		{
+			name:    "other class",
+			held:    graph.Resources{Class: graph.ResourceCPU},
+			request: graph.Resources{Class: graph.ResourceGPU},
+			fits:    true,
+		},
+		{
+			name:    "api",
+			held:    graph.Resources{Class: graph.ResourceExternalAPI, API: "openai"},
+			request: graph.Resources{Class: graph.ResourceExternalAPI, API: "openai"},
+		},
+		{
+			name:    "unbounded api",
+			held:    graph.Resources{Class: graph.ResourceExternalAPI, API: "openai"},
+			request: graph.Resources{Class: graph.ResourceExternalAPI, API: "search"},
+			fits:    true,
+		},
+		{
+			name:    "memory",
+			held:    graph.Resources{Memory: 60},
+			request: graph.Resources{Memory: 50},
+		},
+		{
+			name:    "memory within limit",
+			held:    graph.Resources{Memory: 50},
+			request: graph.Resources{Memory: 50},
+			fits:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := graph.NewResourceLimiter(limits)
+			release, err := limiter.Acquire(context.Background(), tc.held)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			second, err := limiter.Acquire(ctx, tc.request)
+			if tc.fits {
+				require.NoError(t, err)
+				second()
+			} else {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+
+			release()
+			second, err = limiter.Acquire(context.Background(), tc.request)
+			require.NoError(t, err)
+			second()
+		})
+	}
+}
+
+func TestResourceLimiterOversized(t *testing.T) {
+	t.Parallel()
+
+	limiter := graph.NewResourceLimiter(graph.ResourceLimits{Memory: 10})
+	release, err := limiter.Acquire(context.Background(), graph.Resources{Memory: 100})
+	require.NoError(t, err)
+	release()
+	release()
+}
+
+func TestInvokeWithResourceLimiter(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	gpu := func(_ context.Context, state int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(10 * time.Millisecond)
+		return state + 1, nil
+	}
+
+	g := graph.NewMessageGraph[int]("split")
+	g.AddNode("split", func(_ context.Context, state int) (int, error) { return state, nil })
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNodeWithOptions(name, gpu, graph.WithResources(graph.Resources{Class: graph.ResourceGPU}))
+		g.AddEdge("split", name)
+		g.AddEdge(name, graph.END)
+	}
+	g.SetJoin(func(_ context.Context, _ int, results []graph.BranchResult[int]) (int, error) {
+		sum := 0
+		for _, r := range results {
+			sum += r.State
+		}
+		return sum, nil
+	})
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]graph.Resources{
+		"a": {Class: graph.ResourceGPU},
+		"b": {Class: graph.ResourceGPU},
+		"c": {Class: graph.ResourceGPU},
+	}, runnable.Topology().Resources)
+
+	limiter := graph.NewResourceLimiter(graph.ResourceLimits{GPU: 1})
+	state, err := runnable.Invoke(graph.WithResourceLimiter(context.Background(), limiter), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, state)
+	assert.Equal(t, int32(1), peak.Load())
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventRetry is the name of the span event recording the failed attempts of nodes retried.
+const EventRetry = "retry"
+
+// AttributeAttempt is the number of the failed attempt of a node, from 1, on EventRetry events.
+const AttributeAttempt = attribute.Key("langgraph.attempt")
+
+// RetryPolicy tells how a node is retried when it fails.
+type RetryPolicy struct {
+	// MaxAttempts is the number of executions of the node before its error is returned. Values
+	// lower than 2 do not retry.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Backoff is the base delay before the first retry, doubled before every further one. Every
+	// delay is jittered down to half of its value, so nodes failing together do not retry in step.
+	Backoff time.Duration `json:"backoff,omitempty"`
+}
+
+// WithRetry retries a node failing up to maxAttempts executions in total, with exponential
+// backoff from backoff and jitter, e.g. to ride out the transient errors of a model or tool API.
+// Every execution receives the state the node was called with. Retries stop once the context
+// of the invocation is done. Failed attempts are recorded as EventRetry events of the span of
+// the node; see WithTracerProvider.
+func WithRetry(maxAttempts int, backoff time.Duration) NodeOption {
+	return func(o *nodeOptions) {
+		o.retry = RetryPolicy{MaxAttempts: maxAttempts, Backoff: backoff}
+	}
+}
+
+// delay returns the jittered delay before the given retry, from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// call executes the function of the node on the state, retrying it with its retry policy.
+func (n Node[T]) call(ctx context.Context, state T) (T, error) {
+	if n.Retry.MaxAttempts > 1 {
+		// Attempts must not see the appends of the previous ones.
+		state = Clip(state)
+	}
+	for attempt := 1; ; attempt++ {
+		out, err := n.attempt(ctx, state)
+		if err == nil || attempt >= n.Retry.MaxAttempts || ctx.Err() != nil || !n.retryable(err) {
+			return out, err
+		}
+
+		trace.SpanFromContext(ctx).AddEvent(EventRetry, trace.WithAttributes(
+			AttributeAttempt.Int(attempt),
+			attribute.String("langgraph.error", err.Error()),
+		))
+		timer := time.NewTimer(n.Retry.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return out, errors.Join(err, ctx.Err())
+		}
+	}
+}
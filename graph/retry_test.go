@@ -0,0 +1,112 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	flaky := errors.New("flaky")
+	testCases := []struct {
+		name             string
+		failures         int
+		maxAttempts      int
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:             "no retry",
+			failures:         1,
+			expectedErr:      flaky,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "recovers",
+			failures:         2,
+			maxAttempts:      3,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "exhausted",
+			failures:         5,
+			maxAttempts:      3,
+			expectedErr:      flaky,
+			expectedAttempts: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+			g := graph.NewMessageGraph[[]string]("flaky")
+			g.AddNodeWithOptions("flaky", func(_ context.Context, state []string) ([]string, error) {
+				attempts++
+				state = append(state, "attempt")
+				if attempts <= tc.failures {
+					return state, flaky
+				}
+				return state, nil
+			}, graph.WithRetry(tc.maxAttempts, time.Millisecond))
+			g.AddEdge("flaky", graph.END)
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			runnable, err := g.Compile(graph.WithTracerProvider(tp))
+			require.NoError(t, err)
+
+			state, err := runnable.Invoke(context.Background(), make([]string, 0, 8))
+			assert.Equal(t, tc.expectedAttempts, attempts)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"attempt"}, state, "attempts start from the input state")
+			}
+
+			node := recorder.Ended()[0]
+			require.Equal(t, "flaky", node.Name())
+			retries := min(tc.failures, max(tc.maxAttempts, 1)-1)
+			var events int
+			for _, event := range node.Events() {
+				if event.Name == graph.EventRetry {
+					events++
+				}
+			}
+			assert.Equal(t, retries, events)
+		})
+	}
+}
+
+func TestWithRetryCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	flaky := errors.New("flaky")
+	g := graph.NewMessageGraph[int]("flaky")
+	g.AddNodeWithOptions("flaky", func(context.Context, int) (int, error) {
+		cancel()
+		return 0, flaky
+	}, graph.WithRetry(5, time.Hour))
+	g.AddEdge("flaky", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = runnable.Invoke(ctx, 0)
+	require.ErrorIs(t, err, flaky)
+	assert.Less(t, time.Since(start), time.Minute)
+}
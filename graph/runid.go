@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type runIDKey struct{}
+
+// WithRunID returns a context making the next invocation made with it identify itself with id,
+// e.g. the ID of the queued run executing it, instead of a generated ID.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunID returns the ID of the invocation executing with ctx, as seen by its nodes and
+// callbacks, or the ID set with WithRunID. Every invocation has an ID, generated unless set
+// with WithRunID; the graphs invoked by nodes get their own.
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// withRun returns the context of an invocation, carrying its run ID.
+func withRun(ctx context.Context) context.Context {
+	if RunID(ctx) != "" && currentNodeName(ctx) == "" {
+		return ctx
+	}
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return WithRunID(ctx, hex.EncodeToString(id[:]))
+}
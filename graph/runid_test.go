@@ -0,0 +1,70 @@
+package graph_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// runIDs records the run IDs its callbacks are called with.
+type runIDs struct {
+	graph.NopCallbacks[[]string]
+
+	mu  sync.Mutex
+	ids []string
+}
+
+func (r *runIDs) OnGraphStart(ctx context.Context, _ []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, graph.RunID(ctx))
+}
+
+func TestRunID(t *testing.T) {
+	t.Parallel()
+
+	inner := graph.NewMessageGraph[[]string]("inner")
+	var innerID string
+	inner.AddNode("inner", func(ctx context.Context, state []string) ([]string, error) {
+		innerID = graph.RunID(ctx)
+		return state, nil
+	})
+	inner.AddEdge("inner", graph.END)
+	innerRunnable, err := inner.Compile()
+	require.NoError(t, err)
+
+	outer := graph.NewMessageGraph[[]string]("outer")
+	var outerID string
+	outer.AddNode("outer", func(ctx context.Context, state []string) ([]string, error) {
+		outerID = graph.RunID(ctx)
+		return innerRunnable.Invoke(ctx, state)
+	})
+	outer.AddEdge("outer", graph.END)
+	callbacks := &runIDs{}
+	runnable, err := outer.Compile(graph.WithCallbacks[[]string](callbacks))
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, callbacks.ids, 1)
+	assert.Len(t, outerID, 32)
+	assert.Equal(t, callbacks.ids[0], outerID)
+	assert.NotEmpty(t, innerID)
+	assert.NotEqual(t, outerID, innerID, "invoked graphs get their own run ID")
+
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, callbacks.ids, 2)
+	assert.NotEqual(t, callbacks.ids[0], callbacks.ids[1])
+
+	_, err = runnable.Invoke(graph.WithRunID(context.Background(), "run-1"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", callbacks.ids[2])
+	assert.Equal(t, "run-1", outerID)
+	assert.Empty(t, graph.RunID(context.Background()))
+}
@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DescriptionTag is the struct tag documenting a field of the state in its schema, e.g.
+// `description:"Answers found so far."`.
+const DescriptionTag = "description"
+
+// StateSchema documents the state of a graph, so the consumers of a graph know what its state
+// contains.
+type StateSchema struct {
+	// Type is the Go type of the state.
+	Type string `json:"type"`
+
+	// Fields are the fields of the JSON encoding of the state, in declaration order; empty when
+	// the state is not a struct.
+	Fields []FieldSchema `json:"fields,omitempty"`
+
+	// JSON is the JSON Schema of the JSON encoding of the state. Fields carry their description
+	// and, when they have one, their reducer as the x-reducer keyword.
+	JSON map[string]any `json:"json_schema"`
+}
+
+// FieldSchema documents a field of the state.
+type FieldSchema struct {
+	// Name is the name of the field in the JSON encoding of the state.
+	Name string `json:"name"`
+
+	// Type is the Go type of the field.
+	Type string `json:"type"`
+
+	// Reducer is the reducer updating the field, empty when the nodes return the whole state.
+	Reducer string `json:"reducer,omitempty"`
+
+	// Description is the description declared with DescriptionTag.
+	Description string `json:"description,omitempty"`
+}
+
+// SchemaOf returns the schema of the state type S, with the reducers a StateGraph applies to
+// its fields.
+func SchemaOf[S any]() StateSchema {
+	return schemaOf(reflect.TypeFor[S](), true)
+}
+
+// Schema returns the schema of the state of the graph.
+func (g *StateGraph[S]) Schema() StateSchema {
+	return SchemaOf[S]()
+}
+
+// Schema returns the schema of the state of the graph. Fields have reducers when the graph is
+// compiled from a StateGraph.
+func (r *Runnable[T]) Schema() StateSchema {
+	return schemaOf(reflect.TypeFor[T](), r.graph.reduce != nil)
+}
+
+// Markdown renders the schema as a Markdown document: a table of the fields of the state, with
+// their type, reducer and description.
+func (s StateSchema) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# State `%s`\n", s.Type)
+	if len(s.Fields) == 0 {
+		return b.String()
+	}
+
+	reducers := false
+	for _, f := range s.Fields {
+		reducers = reducers || f.Reducer != ""
+	}
+	if reducers {
+		b.WriteString("\n| Field | Type | Reducer | Description |\n| --- | --- | --- | --- |\n")
+	} else {
+		b.WriteString("\n| Field | Type | Description |\n| --- | --- | --- |\n")
+	}
+	for _, f := range s.Fields {
+		fmt.Fprintf(&b, "| `%s` | `%s` |", f.Name, f.Type)
+		if reducers {
+			fmt.Fprintf(&b, " %s |", f.Reducer)
+		}
+		fmt.Fprintf(&b, " %s |\n", strings.NewReplacer("|", `\|`, "\n", " ").Replace(f.Description))
+	}
+	return b.String()
+}
+
+// schemaOf returns the schema of the state type t, with the reducers of its fields if
+// reducers is set.
+func schemaOf(t reflect.Type, reducers bool) StateSchema {
+	s := StateSchema{Type: t.String(), JSON: jsonSchemaOf(t, map[reflect.Type]bool{})}
+	s.JSON["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s.JSON["title"] = t.String()
+	if t.Kind() != reflect.Struct {
+		return s
+	}
+
+	properties, _ := s.JSON["properties"].(map[string]any)
+	for _, f := range jsonFields(t) {
+		field := FieldSchema{Name: f.name, Type: f.Type.String(), Description: f.Tag.Get(DescriptionTag)}
+		if reducers && f.reducer != "" {
+			field.Reducer = f.reducer
+			if property, ok := properties[f.name].(map[string]any); ok {
+				property["x-reducer"] = f.reducer
+			}
+		}
+		s.Fields = append(s.Fields, field)
+	}
+	return s
+}
+
+// jsonField is a field of the JSON encoding of a struct.
+type jsonField struct {
+	reflect.StructField
+
+	// name is the name of the field in the JSON encoding.
+	name string
+
+	// reducer is the reducer of the field, or of the embedded struct it is promoted from; empty
+	// when StateGraph does not update it.
+	reducer string
+}
+
+// jsonFields returns the fields of the JSON encoding of a struct, in order, with the fields of
+// embedded structs promoted like encoding/json does.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		reducer := f.Tag.Get(ReducerTag)
+		if reducer == "" {
+			reducer = ReduceOverwrite
+		}
+
+		embedded := f.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if f.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			for _, promoted := range jsonFields(embedded) {
+				// StateGraph never updates unexported fields, embedded structs included.
+				promoted.reducer = ""
+				if f.IsExported() {
+					promoted.reducer = reducer
+				}
+				fields = append(fields, promoted)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{StructField: f, name: name, reducer: reducer})
+	}
+	return fields
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// jsonSchemaOf returns the JSON Schema of the JSON encoding of values of type t. Types
+// encoding themselves, and recursive types below their first occurrence, accept any value.
+func jsonSchemaOf(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := make(map[string]any)
+		for _, f := range jsonFields(t) {
+			property := jsonSchemaOf(f.Type, seen)
+			if description := f.Tag.Get(DescriptionTag); description != "" {
+				property["description"] = description
+			}
+			properties[f.name] = property
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,108 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+type Source struct {
+	URL   string `json:"url" description:"Where the answer was found."`
+	Score float64
+}
+
+type documented struct {
+	Source
+	Question string                    `json:"question" description:"The question asked, | escaped."`
+	Answers  []string                  `json:"answers,omitempty" reducer:"append" description:"Answers found so far."`
+	Calls    int                       `reducer:"counter"`
+	Updated  graph.Timestamped[string] `reducer:"lww"`
+	Tags     map[string][]byte         `reducer:"merge"`
+	Next     *documented               `json:"next"`
+	At       time.Time                 `json:"at"`
+	Ignored  string                    `json:"-"`
+	internal int
+}
+
+func TestSchemaOf(t *testing.T) {
+	t.Parallel()
+
+	schema := graph.SchemaOf[documented]()
+	assert.Equal(t, "graph_test.documented", schema.Type)
+	assert.Equal(t, []graph.FieldSchema{
+		{Name: "url", Type: "string", Reducer: graph.ReduceOverwrite, Description: "Where the answer was found."},
+		{Name: "Score", Type: "float64", Reducer: graph.ReduceOverwrite},
+		{Name: "question", Type: "string", Reducer: graph.ReduceOverwrite, Description: "The question asked, | escaped."},
+		{Name: "answers", Type: "[]string", Reducer: graph.ReduceAppend, Description: "Answers found so far."},
+		{Name: "Calls", Type: "int", Reducer: graph.ReduceCounter},
+		{Name: "Updated", Type: "graph.Timestamped[string]", Reducer: graph.ReduceLastWrite},
+		{Name: "Tags", Type: "map[string][]uint8", Reducer: graph.ReduceMerge},
+		{Name: "next", Type: "*graph_test.documented", Reducer: graph.ReduceOverwrite},
+		{Name: "at", Type: "time.Time", Reducer: graph.ReduceOverwrite},
+	}, schema.Fields)
+
+	encoded, err := json.Marshal(schema.JSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "graph_test.documented",
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "description": "Where the answer was found.", "x-reducer": "overwrite"},
+			"Score": {"type": "number", "x-reducer": "overwrite"},
+			"question": {"type": "string", "description": "The question asked, | escaped.", "x-reducer": "overwrite"},
+			"answers": {"type": "array", "items": {"type": "string"}, "description": "Answers found so far.", "x-reducer": "append"},
+			"Calls": {"type": "integer", "x-reducer": "counter"},
+			"Updated": {"type": "object", "properties": {
+				"value": {"type": "string"},
+				"time": {"type": "string", "format": "date-time"}
+			}, "x-reducer": "lww"},
+			"Tags": {"type": "object", "additionalProperties": {"type": "string", "contentEncoding": "base64"}, "x-reducer": "merge"},
+			"next": {"x-reducer": "overwrite"},
+			"at": {"type": "string", "format": "date-time", "x-reducer": "overwrite"}
+		}
+	}`, string(encoded))
+
+	assert.Equal(t, "# State `graph_test.documented`\n"+
+		"\n| Field | Type | Reducer | Description |\n| --- | --- | --- | --- |\n"+
+		"| `url` | `string` | overwrite | Where the answer was found. |\n"+
+		"| `Score` | `float64` | overwrite |  |\n"+
+		"| `question` | `string` | overwrite | The question asked, \\| escaped. |\n"+
+		"| `answers` | `[]string` | append | Answers found so far. |\n"+
+		"| `Calls` | `int` | counter |  |\n"+
+		"| `Updated` | `graph.Timestamped[string]` | lww |  |\n"+
+		"| `Tags` | `map[string][]uint8` | merge |  |\n"+
+		"| `next` | `*graph_test.documented` | overwrite |  |\n"+
+		"| `at` | `time.Time` | overwrite |  |\n", schema.Markdown())
+}
+
+func TestRunnableSchema(t *testing.T) {
+	t.Parallel()
+
+	identity := func(_ context.Context, state documented) (documented, error) { return state, nil }
+
+	sg := graph.NewStateGraph[documented]("a")
+	sg.AddNode("a", identity)
+	sg.AddEdge("a", graph.END)
+	runnable, err := sg.Compile()
+	require.NoError(t, err)
+	assert.Equal(t, sg.Schema(), runnable.Schema())
+
+	mg := graph.NewMessageGraph[documented]("a")
+	mg.AddNode("a", identity)
+	mg.AddEdge("a", graph.END)
+	runnable, err = mg.Compile()
+	require.NoError(t, err)
+	schema := runnable.Schema()
+	for _, field := range schema.Fields {
+		assert.Empty(t, field.Reducer, field.Name)
+	}
+	assert.Equal(t, map[string]any{"type": "integer"}, schema.JSON["properties"].(map[string]any)["Calls"])
+	assert.Contains(t, schema.Markdown(), "| Field | Type | Description |")
+}
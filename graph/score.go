@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrScoreViolation is returned when a hard scorer rejects the state of a run.
+var ErrScoreViolation = errors.New("score violates a hard constraint")
+
+// ScoreInput is what a scorer evaluates: the state produced by a node, or the final state of
+// the run.
+type ScoreInput[T any] struct {
+	// Node is the node that produced the state; empty for the final state.
+	Node string
+
+	// State is the state produced.
+	State T
+
+	// Duration is the duration of the node, or of the whole run for the final state.
+	Duration time.Duration
+}
+
+// Final reports whether the input is the final state of the run.
+func (in ScoreInput[T]) Final() bool {
+	return in.Node == ""
+}
+
+// Scorer evaluates the states of a run online, such as toxicity, groundedness or a latency SLO.
+type Scorer[T any] struct {
+	// Name identifies the scorer in scores.
+	Name string
+
+	// Score returns the score of the input.
+	Score func(ctx context.Context, in ScoreInput[T]) (float64, error)
+
+	// Accept tells whether a score satisfies the constraint of the scorer; nil accepts every
+	// score. See AtLeast and AtMost.
+	Accept func(score float64) bool
+
+	// Hard makes a score that is not accepted abort the run with ErrScoreViolation.
+	Hard bool
+}
+
+// AtLeast accepts the scores greater than or equal to min.
+func AtLeast(min float64) func(float64) bool {
+	return func(score float64) bool { return score >= min }
+}
+
+// AtMost accepts the scores less than or equal to max.
+func AtMost(max float64) func(float64) bool {
+	return func(score float64) bool { return score <= max }
+}
+
+// LatencySLO returns a scorer of the duration of nodes and of the whole run, in seconds,
+// rejecting durations above limit.
+func LatencySLO[T any](limit time.Duration, hard bool) Scorer[T] {
+	return Scorer[T]{
+		Name: "latency",
+		Score: func(_ context.Context, in ScoreInput[T]) (float64, error) {
+			return in.Duration.Seconds(), nil
+		},
+		Accept: AtMost(limit.Seconds()),
+		Hard:   hard,
+	}
+}
+
+// Score is the outcome of a scorer on a state.
+type Score struct {
+	// Scorer is the name of the scorer.
+	Scorer string `json:"scorer"`
+
+	// Node is the node that produced the state scored; empty for the final state.
+	Node string `json:"node,omitempty"`
+
+	// Value is the score.
+	Value float64 `json:"value"`
+
+	// Rejected is set when the score does not satisfy the constraint of the scorer.
+	Rejected bool `json:"rejected,omitempty"`
+
+	// Hard is set when the scorer aborts runs it rejects.
+	Hard bool `json:"hard,omitempty"`
+
+	// Err is the error returned by the scorer, if any; the score is then not checked.
+	Err string `json:"error,omitempty"`
+}
+
+// scoring is the scoring configuration of an invocation.
+type scoring[T any] struct {
+	scorers []Scorer[T]
+
+	// mu serializes the calls to onScore, as parallel nodes are scored concurrently.
+	mu      sync.Mutex
+	onScore func(Score)
+}
+
+type scoringKey struct{}
+
+// WithScoring returns a context making Invoke evaluate the scorers on the state produced by
+// every node and on the final state, and pass the scores to onScore, which may be nil. Calls to
+// onScore are serialized. Streamed invocations also emit the scores as EventScore events. The
+// scorers only apply to invocations of graphs with state type T.
+func WithScoring[T any](ctx context.Context, onScore func(Score), scorers ...Scorer[T]) context.Context {
+	return context.WithValue(ctx, scoringKey{}, &scoring[T]{scorers: scorers, onScore: onScore})
+}
+
+func scoringFromContext[T any](ctx context.Context) *scoring[T] {
+	s, _ := ctx.Value(scoringKey{}).(*scoring[T])
+	return s
+}
+
+// score evaluates the scorers on the input and returns an error wrapping ErrScoreViolation if a
+// hard scorer rejected it. Every scorer is evaluated even if one rejects the input.
+func (s *scoring[T]) score(ctx context.Context, in ScoreInput[T]) error {
+	if s == nil {
+		return nil
+	}
+
+	var violations []error
+	for _, scorer := range s.scorers {
+		score := Score{Scorer: scorer.Name, Node: in.Node, Hard: scorer.Hard}
+		value, err := scorer.Score(ctx, in)
+		if err != nil {
+			score.Err = err.Error()
+		} else {
+			score.Value = value
+			score.Rejected = scorer.Accept != nil && !scorer.Accept(value)
+		}
+
+		if s.onScore != nil {
+			s.mu.Lock()
+			s.onScore(score)
+			s.mu.Unlock()
+		}
+		streamFromContext[T](ctx).emit(StreamEvent[T]{Kind: EventScore, Node: in.Node, Branch: currentBranch(ctx), Score: &score})
+
+		if score.Rejected && score.Hard {
+			target := "final state"
+			if !in.Final() {
+				target = "node " + in.Node
+			}
+			violations = append(violations, fmt.Errorf("%w: %s scored %g on %s", ErrScoreViolation, scorer.Name, value, target))
+		}
+	}
+	return errors.Join(violations...)
+}
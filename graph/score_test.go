@@ -0,0 +1,133 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scoredGraph(t *testing.T) *graph.Runnable[[]string] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", func(_ context.Context, state []string) ([]string, error) {
+		return graph.AppendMessages(state, "draft"), nil
+	})
+	g.AddNode("polish", func(_ context.Context, state []string) ([]string, error) {
+		return graph.AppendMessages(state, "rude answer"), nil
+	})
+	g.AddEdge("draft", "polish")
+	g.AddEdge("polish", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+// politeness scores 0 for states ending with a rude answer and 1 otherwise.
+func politeness(hard bool) graph.Scorer[[]string] {
+	return graph.Scorer[[]string]{
+		Name: "politeness",
+		Score: func(_ context.Context, in graph.ScoreInput[[]string]) (float64, error) {
+			if in.State[len(in.State)-1] == "rude answer" {
+				return 0, nil
+			}
+			return 1, nil
+		},
+		Accept: graph.AtLeast(0.5),
+		Hard:   hard,
+	}
+}
+
+func TestScoring(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		scorers       []graph.Scorer[[]string]
+		expected      []graph.Score
+		expectedError string
+	}{
+		{
+			name:    "soft scorer",
+			scorers: []graph.Scorer[[]string]{politeness(false)},
+			expected: []graph.Score{
+				{Scorer: "politeness", Node: "draft", Value: 1},
+				{Scorer: "politeness", Node: "polish", Value: 0, Rejected: true},
+				{Scorer: "politeness", Value: 0, Rejected: true},
+			},
+		},
+		{
+			name:    "hard scorer aborts",
+			scorers: []graph.Scorer[[]string]{politeness(true)},
+			expected: []graph.Score{
+				{Scorer: "politeness", Node: "draft", Value: 1, Hard: true},
+				{Scorer: "politeness", Node: "polish", Value: 0, Rejected: true, Hard: true},
+			},
+			expectedError: "score violates a hard constraint: politeness scored 0 on node polish",
+		},
+		{
+			name: "failing scorer",
+			scorers: []graph.Scorer[[]string]{{
+				Name:   "groundedness",
+				Score:  func(context.Context, graph.ScoreInput[[]string]) (float64, error) { return 0, errors.New("no sources") },
+				Accept: graph.AtLeast(1),
+				Hard:   true,
+			}},
+			expected: []graph.Score{
+				{Scorer: "groundedness", Node: "draft", Hard: true, Err: "no sources"},
+				{Scorer: "groundedness", Node: "polish", Hard: true, Err: "no sources"},
+				{Scorer: "groundedness", Hard: true, Err: "no sources"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var scores []graph.Score
+			ctx := graph.WithScoring(context.Background(), func(s graph.Score) { scores = append(scores, s) }, tc.scorers...)
+			_, err := scoredGraph(t).Invoke(ctx, nil)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				assert.ErrorIs(t, err, graph.ErrScoreViolation)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, scores)
+		})
+	}
+}
+
+func TestLatencySLO(t *testing.T) {
+	t.Parallel()
+
+	scorer := graph.LatencySLO[[]string](time.Second, true)
+	score, err := scorer.Score(context.Background(), graph.ScoreInput[[]string]{Duration: 1500 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, score)
+	assert.False(t, scorer.Accept(score))
+	assert.True(t, scorer.Accept(0.2))
+
+	ctx := graph.WithScoring(context.Background(), nil, scorer)
+	_, err = scoredGraph(t).Invoke(ctx, nil)
+	assert.NoError(t, err)
+}
+
+func TestScoringOtherStateType(t *testing.T) {
+	t.Parallel()
+
+	ctx := graph.WithScoring(context.Background(), nil, graph.Scorer[int]{
+		Name:  "never",
+		Score: func(context.Context, graph.ScoreInput[int]) (float64, error) { panic("scored another state type") },
+	})
+	_, err := scoredGraph(t).Invoke(ctx, nil)
+	assert.NoError(t, err)
+}
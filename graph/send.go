@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Send is a task spawned by a send edge: the execution of a node on its own state.
+type Send[T any] struct {
+	// Node is the name of the node to execute.
+	Node string
+
+	// State is the state the node is executed on.
+	State T
+}
+
+// AddSendEdge adds an outgoing edge to the "from" node whose targets are spawned by sender,
+// called with the state the node returned: every Send executes its node on its own state, e.g.
+// to map a node over documents or subtasks whose number is only known at runtime. The sends
+// run concurrently as one step, even when there is a single one, and their states are merged
+// with the join set with SetJoin, called with the state the "from" node returned and their
+// results in the order of the sends. Execution then continues with the targets of their own
+// edges. Sends to END are dropped, and a sender returning no send ends the branch.
+//
+// Like AddConditionalEdge, it replaces the edges added before from the same node, and is
+// replaced by the edges added after. The routes are the nodes the sends may target; when
+// declared, invocations fail with ErrUndeclaredRoute on sends to other nodes.
+//
+// The states of sends are not saved by interrupts, so interrupts configured before their
+// nodes do not fire, and neither do the interrupts after the nodes spawning them.
+func (g *MessageGraph[T]) AddSendEdge(from string, sender func(ctx context.Context, state T) ([]Send[T], error), routes ...string) {
+	delete(g.edges, from)
+	g.conditionalEdges[from] = conditionalEdge[T]{
+		sender: sender,
+		routes: append([]string(nil), routes...),
+	}
+}
+
+// send calls the sender, checks the sends target declared routes, if any, and drops the sends
+// to END.
+func (c conditionalEdge[T]) send(ctx context.Context, state T) ([]Send[T], error) {
+	sends, err := c.sender(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	for _, send := range sends {
+		if !c.declares(send.Node) {
+			return nil, fmt.Errorf("%w: %q", ErrUndeclaredRoute, send.Node)
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(sends), func(send Send[T]) bool { return send.Node == END }), nil
+}
+
+// sendAll returns the sends executing the nodes on the same state.
+func sendAll[T any](nodes []string, state T) []Send[T] {
+	sends := make([]Send[T], 0, len(nodes))
+	for _, node := range nodes {
+		if node != END {
+			sends = append(sends, Send[T]{Node: node, State: state})
+		}
+	}
+	return sends
+}
+
+// sendNodes returns the nodes of the sends.
+func sendNodes[T any](sends []Send[T]) []string {
+	nodes := make([]string, len(sends))
+	for i, send := range sends {
+		nodes[i] = send.Node
+	}
+	return nodes
+}
+
+// branchNames returns the names of the branches executing the nodes: the nodes themselves,
+// suffixed with their index among the branches executing the same node, if any.
+func branchNames(nodes []string) []string {
+	names := make([]string, len(nodes))
+	seen := make(map[string]int)
+	for i, node := range nodes {
+		names[i] = node
+		if count(nodes, node) > 1 {
+			names[i] = fmt.Sprintf("%s[%d]", node, seen[node])
+			seen[node]++
+		}
+	}
+	return names
+}
+
+// count returns the number of occurrences of node in nodes.
+func count(nodes []string, node string) int {
+	n := 0
+	for _, other := range nodes {
+		if other == node {
+			n++
+		}
+	}
+	return n
+}
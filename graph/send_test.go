@@ -0,0 +1,183 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// gather is a join replacing the state with the states of the branches, concatenated in order.
+func gather(_ context.Context, _ []string, results []graph.BranchResult[[]string]) ([]string, error) {
+	var state []string
+	for _, result := range results {
+		state = append(state, result.State...)
+	}
+	return state, nil
+}
+
+// sendEach returns a sender sending every element of the state to the node, on its own.
+func sendEach(node string) func(context.Context, []string) ([]graph.Send[[]string], error) {
+	return func(_ context.Context, state []string) ([]graph.Send[[]string], error) {
+		sends := make([]graph.Send[[]string], len(state))
+		for i, item := range state {
+			sends[i] = graph.Send[[]string]{Node: node, State: []string{item}}
+		}
+		return sends, nil
+	}
+}
+
+func TestSendEdges(t *testing.T) {
+	t.Parallel()
+
+	identity := func(_ context.Context, state []string) ([]string, error) { return state, nil }
+	upper := func(_ context.Context, state []string) ([]string, error) {
+		if state[0] == "bad" {
+			return nil, errors.New("bad document")
+		}
+		return []string{strings.ToUpper(state[0])}, nil
+	}
+
+	testCases := []struct {
+		name          string
+		input         []string
+		sender        func(context.Context, []string) ([]graph.Send[[]string], error)
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "map-reduce",
+			input:    []string{"a", "b", "c"},
+			sender:   sendEach("summarize"),
+			expected: []string{"A", "B", "C", "reduce"},
+		},
+		{
+			name:     "no sends",
+			input:    []string{},
+			sender:   sendEach("summarize"),
+			expected: []string{},
+		},
+		{
+			name:  "sends to END",
+			input: []string{"a"},
+			sender: func(ctx context.Context, state []string) ([]graph.Send[[]string], error) {
+				sends, err := sendEach("summarize")(ctx, state)
+				return append(sends, graph.Send[[]string]{Node: graph.END}), err
+			},
+			expected: []string{"A", "reduce"},
+		},
+		{
+			name:          "undeclared route",
+			input:         []string{"a"},
+			sender:        sendEach("reduce"),
+			expectedError: `error in sender of node split: router returned an undeclared route: "reduce"`,
+		},
+		{
+			name:          "failing send",
+			input:         []string{"a", "bad"},
+			sender:        sendEach("summarize"),
+			expectedError: "branch summarize[1]: error in node summarize: bad document",
+		},
+		{
+			name:  "failing sender",
+			input: []string{"a"},
+			sender: func(context.Context, []string) ([]graph.Send[[]string], error) {
+				return nil, errors.New("no documents")
+			},
+			expectedError: "error in sender of node split: no documents",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewMessageGraph[[]string]("split")
+			g.AddNode("split", identity)
+			g.AddNode("summarize", upper)
+			g.AddNode("reduce", func(_ context.Context, state []string) ([]string, error) {
+				return graph.AppendMessages(state, "reduce"), nil
+			})
+			g.AddSendEdge("split", tc.sender, "summarize", graph.END)
+			g.AddEdge("summarize", "reduce")
+			g.AddEdge("reduce", graph.END)
+			g.SetJoin(gather)
+
+			runnable, err := g.Compile()
+			require.NoError(t, err)
+
+			output, err := runnable.Invoke(context.Background(), tc.input)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestSendEdgesFromParallelNodes(t *testing.T) {
+	t.Parallel()
+
+	// left maps its state over upper while right continues to last, on the merged state.
+	g := graph.NewMessageGraph[[]string]("split")
+	for _, name := range []string{"split", "left", "right", "last"} {
+		g.AddNode(name, func(_ context.Context, state []string) ([]string, error) {
+			return graph.AppendMessages(state, name), nil
+		})
+	}
+	g.AddNode("upper", func(_ context.Context, state []string) ([]string, error) {
+		return []string{strings.ToUpper(state[0])}, nil
+	})
+	g.AddEdge("split", "left")
+	g.AddEdge("split", "right")
+	g.AddSendEdge("left", sendEach("upper"), "upper")
+	g.AddEdge("right", "last")
+	g.AddEdge("upper", graph.END)
+	g.AddEdge("last", graph.END)
+	g.SetJoin(gather)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// The first join gathers split,left and split,right, the second last and the uppercased
+	// elements of the state of left.
+	output, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"split", "left", "split", "right", "last", "SPLIT", "LEFT"}, output)
+}
+
+func TestSendEdgesValidation(t *testing.T) {
+	t.Parallel()
+
+	build := func() *graph.MessageGraph[[]string] {
+		g := graph.NewMessageGraph[[]string]("split")
+		for _, name := range []string{"split", "map"} {
+			g.AddNode(name, func(_ context.Context, state []string) ([]string, error) { return state, nil })
+		}
+		g.AddEdge("map", graph.END)
+		return g
+	}
+
+	g := build()
+	g.AddSendEdge("split", sendEach("map"), "map")
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrJoinNotSet)
+
+	g = build()
+	g.AddSendEdge("split", nil, "map")
+	g.SetJoin(gather)
+	_, err = g.Compile()
+	require.ErrorIs(t, err, graph.ErrNilRouter)
+
+	g = build()
+	g.AddSendEdge("split", sendEach("map"), "map")
+	g.SetJoin(gather)
+	assert.Equal(t, []string{"map"}, g.Topology().Successors("split"))
+}
@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"sync"
+	"time"
+)
+
+// ChunkShaping tells how the chunks a node forwards with StreamChunk are coalesced into fewer
+// EventChunk events, to reduce the overhead of events for fast models streaming many small
+// tokens.
+type ChunkShaping struct {
+	// Interval is the longest time a chunk is held before its event is emitted, when positive.
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// Chunks is the number of chunks coalesced into one event, when above 1: their event is
+	// emitted once that many were forwarded, without waiting for Interval.
+	Chunks int `json:"chunks,omitempty"`
+}
+
+// WithChunkShaping coalesces the chunks a node forwards with StreamChunk: they are concatenated
+// into one EventChunk event emitted every interval or every chunks chunks, whichever comes
+// first, and when the node completes. For example, WithChunkShaping(50*time.Millisecond, 0)
+// emits at most one event every 50ms while the output still appears as it is generated. Events
+// a node reports with StreamActivity are emitted after the chunks held. Chunks forwarded by
+// the nodes of graphs the node invokes are coalesced with its own.
+func WithChunkShaping(interval time.Duration, chunks int) NodeOption {
+	return func(o *nodeOptions) {
+		o.chunks = ChunkShaping{Interval: interval, Chunks: chunks}
+	}
+}
+
+// enabled reports whether chunks are coalesced.
+func (c ChunkShaping) enabled() bool {
+	return c.Interval > 0 || c.Chunks > 1
+}
+
+// chunkBuffer holds the chunks of a node until their event is emitted.
+type chunkBuffer struct {
+	send    func(chunk []byte) error
+	shaping ChunkShaping
+
+	mu     sync.Mutex
+	chunk  []byte
+	chunks int
+	timer  *time.Timer
+	// err is the error of the last emission, returned by the next add.
+	err error
+}
+
+// add holds a chunk, emitting the chunks held once there are enough of them.
+func (b *chunkBuffer) add(chunk []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	b.chunk = append(b.chunk, chunk...)
+	b.chunks++
+	if b.shaping.Chunks > 1 && b.chunks >= b.shaping.Chunks {
+		return b.flushLocked()
+	}
+	if b.timer == nil && b.shaping.Interval > 0 {
+		b.timer = time.AfterFunc(b.shaping.Interval, func() { _ = b.flush() })
+	}
+	return nil
+}
+
+// flush emits the chunks held, if any.
+func (b *chunkBuffer) flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flushLocked()
+}
+
+func (b *chunkBuffer) flushLocked() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if b.chunks == 0 {
+		return b.err
+	}
+	chunk := b.chunk
+	b.chunk, b.chunks = nil, 0
+	if err := b.send(chunk); err != nil {
+		b.err = err
+	}
+	return b.err
+}
@@ -0,0 +1,124 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// chunkNode returns a node streaming the chunks, then calling the tool if any.
+func chunkNode(tool string, chunks ...string) func(context.Context, []string) ([]string, error) {
+	return func(ctx context.Context, state []string) ([]string, error) {
+		for _, chunk := range chunks {
+			if err := graph.StreamChunk(ctx, []byte(chunk)); err != nil {
+				return state, err
+			}
+		}
+		if tool != "" {
+			if err := graph.StreamActivity(ctx, graph.ActivityToolRunning, tool); err != nil {
+				return state, err
+			}
+		}
+		return graph.AppendMessages(state, "done"), nil
+	}
+}
+
+func TestWithChunkShaping(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		shaping  []graph.NodeOption
+		tool     string
+		expected []string
+	}{
+		{
+			name:     "without shaping",
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:     "chunks",
+			shaping:  []graph.NodeOption{graph.WithChunkShaping(0, 2)},
+			expected: []string{"ab", "cd", "e"},
+		},
+		{
+			name:     "interval",
+			shaping:  []graph.NodeOption{graph.WithChunkShaping(time.Hour, 0)},
+			expected: []string{"abcde"},
+		},
+		{
+			name:     "interval or chunks",
+			shaping:  []graph.NodeOption{graph.WithChunkShaping(time.Hour, 3)},
+			expected: []string{"abc", "de"},
+		},
+		{
+			name:     "activity",
+			shaping:  []graph.NodeOption{graph.WithChunkShaping(time.Hour, 0)},
+			tool:     "search",
+			expected: []string{"abcde", "tool_running search"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewMessageGraph[[]string]("model")
+			g.AddNodeWithOptions("model", chunkNode(tc.tool, "a", "b", "c", "d", "e"), tc.shaping...)
+			g.SetFinishPoint("model")
+			runnable, err := g.Compile()
+			require.NoError(t, err)
+
+			events, err := runnable.Stream(graph.WithActivity(context.Background()), nil)
+			require.NoError(t, err)
+			var got []string
+			for _, event := range collect(t, events) {
+				switch {
+				case event.Kind == graph.EventChunk:
+					got = append(got, event.Chunk)
+				case event.Kind == graph.EventActivity && event.Activity != graph.ActivityThinking:
+					got = append(got, string(event.Activity)+" "+event.Tool)
+				}
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestWithChunkShapingInterval(t *testing.T) {
+	t.Parallel()
+
+	// The node completes only once its first chunk was received, before its second.
+	received := make(chan struct{})
+	g := graph.NewMessageGraph[[]string]("model")
+	g.AddNodeWithOptions("model", func(ctx context.Context, state []string) ([]string, error) {
+		if err := graph.StreamChunk(ctx, []byte("a")); err != nil {
+			return state, err
+		}
+		if err := graph.StreamChunk(ctx, []byte("b")); err != nil {
+			return state, err
+		}
+		<-received
+		return state, graph.StreamChunk(ctx, []byte("c"))
+	}, graph.WithChunkShaping(time.Millisecond, 0))
+	g.SetFinishPoint("model")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	first := <-events
+	assert.Equal(t, graph.EventChunk, first.Kind)
+	assert.Equal(t, "ab", first.Chunk)
+	close(received)
+
+	rest := collect(t, events)
+	require.NotEmpty(t, rest)
+	assert.Equal(t, graph.StreamEvent[[]string]{Kind: graph.EventChunk, Node: "model", Chunk: "c"}, rest[0])
+	assert.Equal(t, graph.EventEnd, rest[len(rest)-1].Kind)
+}
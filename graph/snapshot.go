@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// ErrSendNotResumable is returned by UpdateState when the node the update is attributed to
+// leaves through a send edge, whose sends cannot be saved.
+var ErrSendNotResumable = errors.New("send edges cannot be resumed")
+
+// StateSnapshot is the state persisted for a thread, as returned by GetState.
+type StateSnapshot[T any] struct {
+	// State is the state of the thread.
+	State T
+
+	// Node is the node that produced the state: END once an invocation completed, or the node
+	// an update was attributed to.
+	Node string
+
+	// Next are the nodes to execute when resuming the thread; empty once it completed.
+	Next []string
+
+	// Interrupt is the interrupt the thread is paused at, if any; Resume continues from it.
+	Interrupt *Interrupt
+
+	// Handoff is the handoff of the thread recorded by the checkpoint, for the checkpoints
+	// saved by HandOff.
+	Handoff *Handoff
+
+	// CheckpointID identifies the checkpoint the snapshot was read from.
+	CheckpointID string
+
+	// CreatedAt is the time the state was saved.
+	CreatedAt time.Time
+}
+
+// GetState returns the latest state of the thread, from the checkpointer set with
+// WithCheckpointer: the final state of its last invocation, the state it is paused at, or the
+// state set by UpdateState. It returns an error matching checkpoint.ErrNotFound when the thread
+// has no state.
+func (r *Runnable[T]) GetState(ctx context.Context, threadID string) (StateSnapshot[T], error) {
+	if r.checkpointer == nil {
+		return StateSnapshot[T]{}, ErrNoCheckpointer
+	}
+	cp, err := r.checkpointer.Latest(ctx, threadID)
+	if err != nil {
+		return StateSnapshot[T]{}, err
+	}
+	return r.snapshotOf(ctx, cp)
+}
+
+// UpdateState patches the state of the thread between invocations, e.g. to correct a message
+// or to record a human decision, and returns the new snapshot. The update is applied as if node
+// asNode had returned it: it replaces the state, or is reduced into it for a StateGraph.
+//
+// With asNode empty, the update only changes the state: a thread paused at an interrupt stays
+// paused at it, and Resume continues from it with the new state; a thread saved after a step
+// is replayed by InvokeFrom from the same step. Otherwise the thread is paused after asNode, as
+// an interrupt whose next nodes are the successors of asNode on the new state, so Resume
+// continues from there. A thread without state is updated from the zero state.
+func (r *Runnable[T]) UpdateState(ctx context.Context, threadID string, update T, asNode string) (StateSnapshot[T], error) {
+	if r.checkpointer == nil {
+		return StateSnapshot[T]{}, ErrNoCheckpointer
+	}
+	if _, ok := r.graph.nodes[asNode]; asNode != "" && (!ok || asNode == END) {
+		return StateSnapshot[T]{}, fmt.Errorf("%w: %s", ErrNodeNotFound, asNode)
+	}
+
+	var current StateSnapshot[T]
+	latest, err := r.checkpointer.Latest(ctx, threadID)
+	switch {
+	case err == nil:
+		if current, err = r.snapshotOf(ctx, latest); err != nil {
+			return StateSnapshot[T]{}, err
+		}
+	case !errors.Is(err, checkpoint.ErrNotFound):
+		return StateSnapshot[T]{}, err
+	}
+	state := update
+	if r.graph.reduce != nil {
+		state = r.graph.reduce(current.State, update)
+	}
+
+	node, interrupt := current.Node, current.Interrupt
+	if node == "" {
+		node = END
+	}
+	if asNode != "" {
+		next, err := r.successors(ctx, asNode, state)
+		if err != nil {
+			return StateSnapshot[T]{}, fmt.Errorf("updating state as node %s: %w", asNode, err)
+		}
+		node, interrupt = asNode, nil
+		if slices.ContainsFunc(next, func(node string) bool { return node != END }) {
+			step := 0
+			if current.Interrupt != nil {
+				step = current.Interrupt.Step
+			}
+			interrupt = &Interrupt{Node: asNode, After: true, Step: step, Next: next, From: []string{asNode}, ThreadID: threadID}
+		}
+	}
+
+	var metadata map[string]string
+	if point, ok := latest.Metadata[metadataStep]; ok && asNode == "" {
+		// The thread continues from the same step.
+		metadata = map[string]string{metadataStep: point}
+	}
+	if interrupt != nil {
+		encoded, err := json.Marshal(interrupt)
+		if err != nil {
+			return StateSnapshot[T]{}, fmt.Errorf("updating thread %s: %w", threadID, err)
+		}
+		metadata = map[string]string{metadataInterrupt: string(encoded)}
+	}
+	if err := r.save(ctx, threadID, node, state, metadata); err != nil {
+		return StateSnapshot[T]{}, fmt.Errorf("updating thread %s: %w", threadID, err)
+	}
+	return r.GetState(ctx, threadID)
+}
+
+// successors returns the nodes following node on the state, without executing it.
+func (r *Runnable[T]) successors(ctx context.Context, node string, state T) ([]string, error) {
+	conditional, ok := r.graph.conditionalEdges[node]
+	switch {
+	case ok && conditional.sender != nil:
+		return nil, ErrSendNotResumable
+	case ok && conditional.command:
+		return nil, ErrCommandNotResumable
+	case ok:
+		next, err := conditional.route(withNodeName(withoutStream(ctx), node), state)
+		if err != nil {
+			return nil, fmt.Errorf("error in router of node %s: %w", node, err)
+		}
+		return []string{next}, nil
+	default:
+		return targets(r.graph.edges[node]), nil
+	}
+}
+
+// snapshotOf decodes a checkpoint saved by a Runnable.
+func (r *Runnable[T]) snapshotOf(ctx context.Context, cp checkpoint.Checkpoint) (StateSnapshot[T], error) {
+	state, err := r.decodeCheckpoint(ctx, cp)
+	if err != nil {
+		return StateSnapshot[T]{}, err
+	}
+	snapshot := StateSnapshot[T]{State: state, Node: cp.Node, CheckpointID: cp.ID, CreatedAt: cp.CreatedAt}
+	if encoded, ok := cp.Metadata[metadataInterrupt]; ok {
+		var interrupt Interrupt
+		if err := json.Unmarshal([]byte(encoded), &interrupt); err != nil {
+			return StateSnapshot[T]{}, fmt.Errorf("decoding interrupt of thread %s: %w", cp.ThreadID, err)
+		}
+		snapshot.Interrupt, snapshot.Next = &interrupt, slices.Clone(interrupt.Next)
+	}
+	point, err := stepPointOf(cp)
+	if err != nil {
+		return StateSnapshot[T]{}, err
+	}
+	if point != nil {
+		snapshot.Next = slices.Clone(point.Next)
+	}
+	if encoded, ok := cp.Metadata[metadataHandoff]; ok {
+		var handoff Handoff
+		if err := json.Unmarshal([]byte(encoded), &handoff); err != nil {
+			return StateSnapshot[T]{}, fmt.Errorf("decoding handoff of thread %s: %w", cp.ThreadID, err)
+		}
+		snapshot.Handoff = &handoff
+	}
+	return snapshot, nil
+}
+
+// decode decodes a state read from the checkpointer, strictly if WithStrictState was set.
+func (r *Runnable[T]) decode(data []byte) (T, error) {
+	if r.strictState {
+		return checkpoint.DecodeStrict[T](data)
+	}
+	return checkpoint.Decode[T](data)
+}
@@ -0,0 +1,236 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestGetState(t *testing.T) {
+	t.Parallel()
+
+	runnable, err := approvalGraph().Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = runnable.GetState(ctx, "t1")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+
+	_, err = runnable.Invoke(graph.WithThreadID(ctx, "t1"), []string{"hi"})
+	require.NoError(t, err)
+	snapshot, err := runnable.GetState(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hi", "draft", "approve", "send"}, snapshot.State)
+	assert.Equal(t, graph.END, snapshot.Node)
+	assert.Empty(t, snapshot.Next)
+	assert.Nil(t, snapshot.Interrupt)
+
+	// Invocations without thread are not saved.
+	_, err = runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	_, err = runnable.GetState(ctx, "")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestUpdateState(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		interrupt bool
+		asNode    string
+		expected  graph.StateSnapshot[[]string]
+		resumed   []string
+	}{
+		{
+			name:      "edit interrupted thread",
+			interrupt: true,
+			expected: graph.StateSnapshot[[]string]{
+				State: []string{"edited"},
+				Node:  "approve",
+				Next:  []string{"approve"},
+			},
+			resumed: []string{"edited", "approve", "send"},
+		},
+		{
+			name:      "as node of interrupted thread",
+			interrupt: true,
+			asNode:    "approve",
+			expected: graph.StateSnapshot[[]string]{
+				State: []string{"edited"},
+				Node:  "approve",
+				Next:  []string{"send"},
+			},
+			resumed: []string{"edited", "send"},
+		},
+		{
+			name: "edit completed thread",
+			expected: graph.StateSnapshot[[]string]{
+				State: []string{"edited"},
+				Node:  graph.END,
+			},
+		},
+		{
+			name:   "as node of completed thread",
+			asNode: "draft",
+			expected: graph.StateSnapshot[[]string]{
+				State: []string{"edited"},
+				Node:  "draft",
+				Next:  []string{"approve"},
+			},
+			resumed: []string{"edited", "approve", "send"},
+		},
+		{
+			name:   "as last node",
+			asNode: "send",
+			expected: graph.StateSnapshot[[]string]{
+				State: []string{"edited"},
+				Node:  "send",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []graph.CompileOption{graph.WithCheckpointer(checkpoint.NewMemory())}
+			if tc.interrupt {
+				opts = append(opts, graph.WithInterruptBefore("approve"))
+			}
+			runnable, err := approvalGraph().Compile(opts...)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			_, err = runnable.Invoke(graph.WithThreadID(ctx, "t1"), nil)
+			if tc.interrupt {
+				require.ErrorIs(t, err, graph.ErrInterrupted)
+			} else {
+				require.NoError(t, err)
+			}
+
+			snapshot, err := runnable.UpdateState(ctx, "t1", []string{"edited"}, tc.asNode)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected.State, snapshot.State)
+			assert.Equal(t, tc.expected.Node, snapshot.Node)
+			assert.Equal(t, tc.expected.Next, snapshot.Next)
+			assert.Equal(t, len(tc.expected.Next) > 0, snapshot.Interrupt != nil)
+
+			if tc.resumed == nil {
+				_, _, err := runnable.Pending(ctx, "t1")
+				require.ErrorIs(t, err, graph.ErrNotInterrupted)
+				return
+			}
+			interrupt, state, err := runnable.Pending(ctx, "t1")
+			require.NoError(t, err)
+			out, err := runnable.Resume(ctx, interrupt, state)
+			require.NoError(t, err)
+			assert.Equal(t, tc.resumed, out)
+
+			final, err := runnable.GetState(ctx, "t1")
+			require.NoError(t, err)
+			assert.Equal(t, tc.resumed, final.State)
+		})
+	}
+}
+
+func TestUpdateStateReduces(t *testing.T) {
+	t.Parallel()
+
+	type State struct {
+		Topic string
+		Notes []string `reducer:"append"`
+	}
+	g := graph.NewStateGraph[State]("note")
+	g.AddNode("note", func(context.Context, State) (State, error) {
+		return State{Notes: []string{"noted"}}, nil
+	})
+	g.AddEdge("note", graph.END)
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = runnable.Invoke(graph.WithThreadID(ctx, "t1"), State{Topic: "go"})
+	require.NoError(t, err)
+	snapshot, err := runnable.UpdateState(ctx, "t1", State{Notes: []string{"by hand"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, State{Topic: "go", Notes: []string{"noted", "by hand"}}, snapshot.State)
+
+	snapshot, err = runnable.UpdateState(ctx, "t2", State{Topic: "new"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, State{Topic: "new"}, snapshot.State)
+}
+
+func TestUpdateStateErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	runnable, err := approvalGraph().Compile()
+	require.NoError(t, err)
+	_, err = runnable.GetState(ctx, "t1")
+	require.ErrorIs(t, err, graph.ErrNoCheckpointer)
+	_, err = runnable.UpdateState(ctx, "t1", nil, "")
+	require.ErrorIs(t, err, graph.ErrNoCheckpointer)
+
+	runnable, err = approvalGraph().Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	_, err = runnable.UpdateState(ctx, "t1", nil, "missing")
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+	_, err = runnable.UpdateState(ctx, "t1", nil, graph.END)
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+
+	g := graph.NewMessageGraph[[]string]("split")
+	g.AddNode("split", func(_ context.Context, state []string) ([]string, error) { return state, nil })
+	g.AddNode("work", func(_ context.Context, state []string) ([]string, error) { return state, nil })
+	g.AddSendEdge("split", func(context.Context, []string) ([]graph.Send[[]string], error) { return nil, nil }, "work")
+	g.AddEdge("work", graph.END)
+	g.SetJoin(graph.Concatenate[string]())
+	runnable, err = g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()))
+	require.NoError(t, err)
+	_, err = runnable.UpdateState(ctx, "t1", nil, "split")
+	require.ErrorIs(t, err, graph.ErrSendNotResumable)
+}
+
+func TestStrictState(t *testing.T) {
+	t.Parallel()
+
+	type v1 struct {
+		Question string `json:"question"`
+		Draft    string `json:"draft"`
+	}
+	type v2 struct {
+		Question string `json:"question"`
+	}
+
+	cp := checkpoint.NewMemory()
+	ctx := context.Background()
+	g1 := graph.NewMessageGraph[v1]("write")
+	g1.AddNode("write", func(_ context.Context, state v1) (v1, error) { return v1{Question: state.Question, Draft: "42"}, nil })
+	g1.AddEdge("write", graph.END)
+	old, err := g1.Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+	_, err = old.Invoke(graph.WithThreadID(ctx, "t1"), v1{Question: "why?"})
+	require.NoError(t, err)
+
+	// The state type of a new release dropped the draft.
+	g2 := graph.NewMessageGraph[v2]("read")
+	g2.AddNode("read", func(_ context.Context, state v2) (v2, error) { return state, nil })
+	g2.AddEdge("read", graph.END)
+
+	lenient, err := g2.Compile(graph.WithCheckpointer(cp))
+	require.NoError(t, err)
+	snapshot, err := lenient.GetState(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, v2{Question: "why?"}, snapshot.State)
+
+	strict, err := g2.Compile(graph.WithCheckpointer(cp), graph.WithStrictState())
+	require.NoError(t, err)
+	_, err = strict.GetState(ctx, "t1")
+	require.ErrorIs(t, err, checkpoint.ErrUnknownField)
+	assert.ErrorContains(t, err, "$.draft")
+}
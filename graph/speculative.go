@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// RoutingStats records routing decisions and speculation outcomes.
+// It is safe for concurrent use; the zero value is ready to use.
+type RoutingStats struct {
+	mu     sync.Mutex
+	counts map[string]int
+	hits   int
+	misses int
+}
+
+// Record counts a routing decision.
+func (s *RoutingStats) Record(choice string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[choice]++
+}
+
+// MostLikely returns the most frequently recorded choice.
+// Ties are resolved in favour of the lexically smallest choice so predictions are deterministic.
+func (s *RoutingStats) MostLikely() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best string
+	var bestCount int
+	for choice, count := range s.counts {
+		if count > bestCount || (count == bestCount && choice < best) {
+			best, bestCount = choice, count
+		}
+	}
+	return best, bestCount > 0
+}
+
+// Counts returns a copy of the recorded decision counts.
+func (s *RoutingStats) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int, len(s.counts))
+	for choice, count := range s.counts {
+		counts[choice] = count
+	}
+	return counts
+}
+
+// Speculation returns how many speculative executions were used and how many were discarded.
+func (s *RoutingStats) Speculation() (hits, misses int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hits, s.misses
+}
+
+func (s *RoutingStats) recordSpeculation(hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hit {
+		s.hits++
+	} else {
+		s.misses++
+	}
+}
+
+type speculativeResult[T any] struct {
+	state T
+	err   error
+}
+
+// Speculate returns a node function that asks router which handler should process the state
+// and runs it. While the router is deciding, the handler chosen most often according to stats
+// is executed speculatively; its result is used if the router agrees and discarded otherwise.
+// Speculatively executed handlers must be free of side effects. Their panics fail the node like
+// the panics of the node itself.
+func Speculate[T any](
+	router func(ctx context.Context, state T) (string, error),
+	handlers map[string]func(ctx context.Context, state T) (T, error),
+	stats *RoutingStats,
+) func(ctx context.Context, state T) (T, error) {
+	return func(ctx context.Context, state T) (T, error) {
+		// The speculative and the chosen handler may run at the same time on the same state.
+		state = Clip(state)
+
+		predicted, ok := stats.MostLikely()
+		speculative, hasHandler := handlers[predicted]
+
+		var pending chan speculativeResult[T]
+		cancel := func() {}
+		if ok && hasHandler {
+			var specCtx context.Context
+			specCtx, cancel = context.WithCancel(ctx)
+			pending = make(chan speculativeResult[T], 1)
+			go func() {
+				var s T
+				var err error
+				func() {
+					defer recoverBranch(ctx, &err)
+					s, err = speculative(specCtx, state)
+				}()
+				pending <- speculativeResult[T]{state: s, err: err}
+			}()
+		}
+		// discard cancels the speculative handler and waits for it, so it never outlives the
+		// node.
+		discard := func() {
+			cancel()
+			if pending != nil {
+				<-pending
+			}
+		}
+
+		route, err := router(ctx, state)
+		if err != nil {
+			discard()
+			return state, fmt.Errorf("routing: %w", err)
+		}
+
+		handler, found := handlers[route]
+		if !found {
+			discard()
+			return state, fmt.Errorf("%w: %s", ErrNodeNotFound, route)
+		}
+		stats.Record(route)
+
+		if pending != nil {
+			if route == predicted {
+				stats.recordSpeculation(true)
+				r := <-pending
+				cancel()
+				return r.state, r.err
+			}
+			stats.recordSpeculation(false)
+			discard()
+		}
+
+		return handler(ctx, state)
+	}
+}
+
+// SetSpeculation speculates on the conditional edge of the "from" node: while its router decides,
+// the route chosen most often according to stats is executed speculatively on the state the node
+// returned. When the router chooses it, the next step uses the result of the speculative execution
+// instead of executing the node again; otherwise the execution is canceled and awaited before the
+// run continues. The choices of the router are recorded in stats, which may be shared by runnables.
+//
+// Routes to END, to nodes paused before by WithInterruptBefore and to side-effecting nodes are not
+// speculated, nor is anything when execution pauses after the "from" node or when the node runs
+// in parallel with others. Speculative executions do not stream chunks or activities. Like the
+// handlers of Speculate, the speculated nodes must be free of side effects.
+func (g *MessageGraph[T]) SetSpeculation(from string, stats *RoutingStats) {
+	g.speculations[from] = stats
+}
+
+// speculativeRun is the execution of a node started before the router of the previous node chose
+// it.
+type speculativeRun[T any] struct {
+	node   string
+	cancel context.CancelFunc
+	done   chan struct{}
+	state  T
+	err    error
+}
+
+// wait waits for the execution and returns its result.
+func (s *speculativeRun[T]) wait() (T, error) {
+	<-s.done
+	s.cancel()
+	return s.state, s.err
+}
+
+// discard cancels the execution, if any, and waits for it to end.
+func (s *speculativeRun[T]) discard() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// speculationSlot holds the speculative execution of the next step of a run, if any.
+type speculationSlot[T any] struct {
+	run *speculativeRun[T]
+}
+
+// take removes the speculative execution from the slot and returns it if it executes the node,
+// discarding it otherwise. A nil slot holds nothing.
+func (s *speculationSlot[T]) take(node string) *speculativeRun[T] {
+	if s == nil || s.run == nil {
+		return nil
+	}
+	run := s.run
+	s.run = nil
+	if run.node != node {
+		run.discard()
+		return nil
+	}
+	return run
+}
+
+// discard discards the speculative execution held, if any.
+func (s *speculationSlot[T]) discard() {
+	if s != nil {
+		s.run.discard()
+		s.run = nil
+	}
+}
+
+// speculate starts the speculative execution of the route of the conditional edge of the node
+// predicted by the stats set with SetSpeculation, on the state the node returned, if any and
+// eligible.
+func (r *Runnable[T]) speculate(ctx context.Context, from string, conditional conditionalEdge[T], slot *speculationSlot[T], state T) *speculativeRun[T] {
+	stats := r.graph.speculations[from]
+	if stats == nil || slot == nil || slices.Contains(r.interruptsAfter, from) {
+		return nil
+	}
+	predicted, ok := stats.MostLikely()
+	node, exists := r.graph.nodes[predicted]
+	switch {
+	case !ok || !exists || predicted == END || node.SideEffects:
+		return nil
+	case len(conditional.routes) > 0 && !conditional.declared[predicted]:
+		return nil
+	case slices.Contains(r.interruptsBefore, predicted):
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	run := &speculativeRun[T]{node: predicted, cancel: cancel, done: make(chan struct{})}
+	// The node and the speculative execution may run at the same time on the same state.
+	state = Clip(state)
+	go func() {
+		defer close(run.done)
+		nodeCtx, release, err := acquireResources(ctx, node.Resources)
+		if err != nil {
+			run.state, run.err = state, err
+			return
+		}
+		defer release()
+		run.state, run.err = r.call(withNodeName(withoutStream(nodeCtx), predicted), node, state)
+	}()
+	return run
+}
+
+// routeSpeculated records the choice of the router of the node in the stats set with
+// SetSpeculation, and hands the speculative execution over to the next step through the slot if
+// the router chose its node, discarding it otherwise.
+func (r *Runnable[T]) routeSpeculated(from, next string, run *speculativeRun[T], slot *speculationSlot[T]) {
+	stats := r.graph.speculations[from]
+	if stats == nil {
+		return
+	}
+	stats.Record(next)
+	if run == nil {
+		return
+	}
+	stats.recordSpeculation(run.node == next)
+	if run.node != next {
+		run.discard()
+		return
+	}
+	slot.run = run
+}
@@ -0,0 +1,241 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingStats(t *testing.T) {
+	t.Parallel()
+
+	var stats graph.RoutingStats
+	_, ok := stats.MostLikely()
+	assert.False(t, ok)
+
+	stats.Record("tools")
+	stats.Record("finish")
+	likely, ok := stats.MostLikely()
+	require.True(t, ok)
+	assert.Equal(t, "finish", likely, "ties resolve to the smallest choice")
+
+	stats.Record("tools")
+	likely, _ = stats.MostLikely()
+	assert.Equal(t, "tools", likely)
+	assert.Equal(t, map[string]int{"tools": 2, "finish": 1}, stats.Counts())
+}
+
+func TestSpeculate(t *testing.T) {
+	t.Parallel()
+
+	var toolRuns atomic.Int32
+	handlers := map[string]func(context.Context, string) (string, error){
+		"tools": func(_ context.Context, state string) (string, error) {
+			toolRuns.Add(1)
+			return state + "+tools", nil
+		},
+		"finish": func(_ context.Context, state string) (string, error) {
+			return state + "+finish", nil
+		},
+	}
+
+	route := "tools"
+	router := func(_ context.Context, _ string) (string, error) {
+		time.Sleep(time.Millisecond)
+		return route, nil
+	}
+
+	stats := &graph.RoutingStats{}
+	node := graph.Speculate(router, handlers, stats)
+
+	// No history yet: nothing is speculated.
+	output, err := node(context.Background(), "s")
+	require.NoError(t, err)
+	assert.Equal(t, "s+tools", output)
+	assert.Equal(t, int32(1), toolRuns.Load())
+
+	// The router agrees with the prediction: the speculative result is reused.
+	output, err = node(context.Background(), "s")
+	require.NoError(t, err)
+	assert.Equal(t, "s+tools", output)
+	assert.Equal(t, int32(2), toolRuns.Load())
+
+	// The router disagrees: the speculative result is discarded.
+	route = "finish"
+	output, err = node(context.Background(), "s")
+	require.NoError(t, err)
+	assert.Equal(t, "s+finish", output)
+
+	hits, misses := stats.Speculation()
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+}
+
+func TestSpeculateErrors(t *testing.T) {
+	t.Parallel()
+
+	// The speculative handler runs until canceled.
+	var ended atomic.Bool
+	handlers := map[string]func(context.Context, string) (string, error){
+		"slow": func(ctx context.Context, state string) (string, error) {
+			<-ctx.Done()
+			ended.Store(true)
+			return state, ctx.Err()
+		},
+	}
+	stats := &graph.RoutingStats{}
+	stats.Record("slow")
+
+	routerErr := errors.New("router failed")
+	node := graph.Speculate(func(context.Context, string) (string, error) {
+		return "", routerErr
+	}, handlers, stats)
+	_, err := node(context.Background(), "")
+	require.ErrorIs(t, err, routerErr)
+	assert.True(t, ended.Load(), "the speculative handler is awaited")
+
+	node = graph.Speculate(func(context.Context, string) (string, error) {
+		return "missing", nil
+	}, handlers, &graph.RoutingStats{})
+	_, err = node(context.Background(), "")
+	require.ErrorIs(t, err, graph.ErrNodeNotFound)
+}
+
+func TestSpeculatePanic(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]func(context.Context, []string) ([]string, error){
+		"tools": func(context.Context, []string) ([]string, error) {
+			panic("tool bug")
+		},
+	}
+	stats := &graph.RoutingStats{}
+	stats.Record("tools")
+
+	g := graph.NewMessageGraph[[]string]("route")
+	g.AddNode("route", graph.Speculate(func(context.Context, []string) (string, error) {
+		return "tools", nil
+	}, handlers, stats))
+	g.SetFinishPoint("route")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrNodePanic)
+
+	var panicErr *graph.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "route", panicErr.Node)
+	assert.Equal(t, "tool bug", panicErr.Value)
+	hits, _ := stats.Speculation()
+	assert.Equal(t, 1, hits, "the speculative handler panicked")
+}
+
+func TestSetSpeculation(t *testing.T) {
+	t.Parallel()
+
+	var toolRuns atomic.Int32
+	var canceled atomic.Bool
+	var route atomic.Value
+	routerErr := errors.New("router failed")
+
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", func(_ context.Context, state []string) ([]string, error) {
+		return append(state, "agent"), nil
+	})
+	g.AddNode("tools", func(ctx context.Context, state []string) ([]string, error) {
+		toolRuns.Add(1)
+		if route.Load() != "tools" {
+			// Mispredicted: runs until canceled.
+			<-ctx.Done()
+			canceled.Store(true)
+			return state, ctx.Err()
+		}
+		return append(state, "tools"), nil
+	})
+	g.AddNode("finish", func(_ context.Context, state []string) ([]string, error) {
+		return append(state, "finish"), nil
+	})
+	g.AddConditionalEdge("agent", func(context.Context, []string) (string, error) {
+		time.Sleep(time.Millisecond)
+		if route.Load() == "error" {
+			return "", routerErr
+		}
+		return route.Load().(string), nil
+	}, "tools", "finish")
+	g.AddEdge("tools", graph.END)
+	g.AddEdge("finish", graph.END)
+	stats := &graph.RoutingStats{}
+	g.SetSpeculation("agent", stats)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// No history yet: nothing is speculated.
+	route.Store("tools")
+	output, err := runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent", "tools"}, output)
+	assert.Equal(t, int32(1), toolRuns.Load())
+
+	// The router agrees with the prediction: tools is not executed again.
+	output, err = runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent", "tools"}, output)
+	assert.Equal(t, int32(2), toolRuns.Load())
+
+	// The router disagrees: the speculative execution is canceled and awaited.
+	route.Store("finish")
+	output, err = runnable.Invoke(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent", "finish"}, output)
+	assert.True(t, canceled.Load())
+
+	// The router fails: the same.
+	canceled.Store(false)
+	route.Store("error")
+	_, err = runnable.Invoke(ctx, nil)
+	require.ErrorIs(t, err, routerErr)
+	assert.True(t, canceled.Load())
+
+	hits, misses := stats.Speculation()
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+	assert.Equal(t, map[string]int{"tools": 2, "finish": 1}, stats.Counts())
+}
+
+func TestSetSpeculationInterrupt(t *testing.T) {
+	t.Parallel()
+
+	var toolRuns atomic.Int32
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", func(_ context.Context, state []string) ([]string, error) {
+		return append(state, "agent"), nil
+	})
+	g.AddNode("tools", func(_ context.Context, state []string) ([]string, error) {
+		toolRuns.Add(1)
+		return append(state, "tools"), nil
+	})
+	g.AddConditionalEdge("agent", func(context.Context, []string) (string, error) {
+		return "tools", nil
+	}, "tools")
+	g.AddEdge("tools", graph.END)
+	stats := &graph.RoutingStats{}
+	stats.Record("tools")
+	g.SetSpeculation("agent", stats)
+	runnable, err := g.Compile(graph.WithInterruptBefore("tools"))
+	require.NoError(t, err)
+
+	// Nodes awaiting approval are not executed speculatively.
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, graph.ErrInterrupted)
+	assert.Zero(t, toolRuns.Load())
+	hits, misses := stats.Speculation()
+	assert.Zero(t, hits+misses)
+}
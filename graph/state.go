@@ -0,0 +1,420 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrStateNotStruct is returned by StateGraph.Compile when the state is not a struct.
+	ErrStateNotStruct = errors.New("state is not a struct")
+
+	// ErrInvalidReducer is returned by StateGraph.Compile when a field declares an unknown
+	// reducer, or a reducer that does not apply to its type.
+	ErrInvalidReducer = errors.New("invalid reducer")
+)
+
+// ReducerTag is the struct tag declaring the reducer of a field of the state of a StateGraph,
+// e.g. `reducer:"append"`.
+const ReducerTag = "reducer"
+
+const (
+	// ReduceOverwrite replaces the value of the field with the value of the update, unless it is
+	// the zero value. It is the reducer of the fields that declare none.
+	ReduceOverwrite = "overwrite"
+
+	// ReduceAppend appends the elements of the update to the field, which must be a slice.
+	ReduceAppend = "append"
+
+	// ReduceMerge sets the entries of the update in the field, which must be a map.
+	ReduceMerge = "merge"
+
+	// ReduceCounter adds the update to the field, which must be a number, so nodes return
+	// increments, e.g. State{Calls: 1}.
+	ReduceCounter = "counter"
+
+	// ReduceUnion appends the elements of the update missing from the field, which must be a
+	// slice of comparable elements, keeping it free of duplicates.
+	ReduceUnion = "union"
+
+	// ReduceMax keeps the greatest of the field and the update, which must be a number or a
+	// string, unless the update is the zero value.
+	ReduceMax = "max"
+
+	// ReduceLastWrite keeps the most recent of the field and the update, which must be a
+	// Timestamped value, not a pointer to one, by their time, unless the update has no time.
+	// Unlike overwrite, the last write wins whatever the order of the parallel branches making
+	// them.
+	ReduceLastWrite = "lww"
+)
+
+// Timestamped is a value written at a given time, for the fields reduced with ReduceLastWrite.
+type Timestamped[V any] struct {
+	// Value is the value written.
+	Value V `json:"value"`
+
+	// Time is the time the value was written.
+	Time time.Time `json:"time"`
+}
+
+// Stamp returns the value written now.
+func Stamp[V any](value V) Timestamped[V] {
+	return Timestamped[V]{Value: value, Time: time.Now()}
+}
+
+// writtenAt implements timestamped.
+func (t Timestamped[V]) writtenAt() time.Time { return t.Time }
+
+// timestamped is implemented by Timestamped.
+type timestamped interface {
+	writtenAt() time.Time
+}
+
+// StateGraph is a MessageGraph whose state is a struct whose fields are updated by reducers:
+// its nodes return partial updates, which are reduced into the state field by field, with the
+// reducer each field declares with ReducerTag. For example, with the state
+//
+//	type State struct {
+//		Question string
+//		Answers  []string       `reducer:"append"`
+//		Sources  map[string]int `reducer:"merge"`
+//	}
+//
+// a node returning State{Answers: []string{"42"}} appends an answer and leaves the question
+// and the sources unchanged. Fields left at their zero value in an update are not updated, so
+// an overwritten field cannot be reset to its zero value. Unexported fields are never updated.
+//
+// Nodes executed in parallel are merged the same way, so they need no join: the update of
+// every branch, the difference between the state it received and the state it returned, is
+// reduced into the state in the order of the branches. Branches overwriting the same field
+// resolve in favor of the last one; the counter, union, max and lww reducers accumulate
+// aggregates whatever the order of the branches.
+type StateGraph[S any] struct {
+	*MessageGraph[S]
+
+	// reducers are the reducers of the fields of the state, by index; nil for unexported
+	// fields.
+	reducers []reducer
+
+	// err is the problem found with the reducers, reported by Compile.
+	err error
+}
+
+// NewStateGraph creates a new instance of StateGraph, whose nodes are merged with their
+// reducers when executed in parallel.
+func NewStateGraph[S any](entryPoint string) *StateGraph[S] {
+	g := &StateGraph[S]{MessageGraph: NewMessageGraph[S](entryPoint)}
+	g.reducers, g.err = reducersOf(reflect.TypeFor[S]())
+	g.SetJoin(g.merge)
+	g.MessageGraph.reduce = g.Reduce
+	return g
+}
+
+// AddNode adds a new node to the state graph with the given name and function, which returns
+// the update of the state.
+func (g *StateGraph[S]) AddNode(name string, fn func(ctx context.Context, state S) (S, error)) {
+	g.AddNodeWithOptions(name, fn)
+}
+
+// AddNodeWithOptions is like AddNode but configures the node with options, such as its
+// resource hints, its retry policy or its timeout.
+func (g *StateGraph[S]) AddNodeWithOptions(name string, fn func(ctx context.Context, state S) (S, error), opts ...NodeOption) {
+	if fn == nil {
+		g.MessageGraph.AddNodeWithOptions(name, nil, opts...)
+		return
+	}
+	g.MessageGraph.AddNodeWithOptions(name, func(ctx context.Context, state S) (S, error) {
+		update, err := fn(ctx, state)
+		if err != nil {
+			return state, err
+		}
+		return g.Reduce(state, update), nil
+	}, opts...)
+}
+
+// Compile is like MessageGraph.Compile but first checks that the state is a struct
+// (ErrStateNotStruct) whose fields declare valid reducers (ErrInvalidReducer).
+func (g *StateGraph[S]) Compile(opts ...CompileOption) (*Runnable[S], error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.MessageGraph.Compile(opts...)
+}
+
+// Reduce returns the state with the update reduced into it, field by field. The state passed
+// is left unchanged.
+func (g *StateGraph[S]) Reduce(state, update S) S {
+	reduced := reflect.ValueOf(&state).Elem()
+	changes := reflect.ValueOf(update)
+	for i, r := range g.reducers {
+		if r != nil {
+			reduced.Field(i).Set(r.reduce(reduced.Field(i), changes.Field(i)))
+		}
+	}
+	return state
+}
+
+// merge is the join of state graphs: it reduces the update of every branch into the state.
+func (g *StateGraph[S]) merge(_ context.Context, state S, results []BranchResult[S]) (S, error) {
+	for _, result := range results {
+		if result.Err == nil {
+			state = g.Reduce(state, g.diff(result.Input, result.State))
+		}
+	}
+	return state, nil
+}
+
+// diff returns the update turning the input state into the output state.
+func (g *StateGraph[S]) diff(input, output S) S {
+	var update S
+	changes := reflect.ValueOf(&update).Elem()
+	in, out := reflect.ValueOf(input), reflect.ValueOf(output)
+	for i, r := range g.reducers {
+		if r != nil {
+			changes.Field(i).Set(r.diff(in.Field(i), out.Field(i)))
+		}
+	}
+	return update
+}
+
+// reducer updates a field of a state.
+type reducer interface {
+	// reduce returns the field with the update reduced into it, without modifying it.
+	reduce(current, update reflect.Value) reflect.Value
+
+	// diff returns the update turning the input field into the output field.
+	diff(input, output reflect.Value) reflect.Value
+}
+
+// reducersOf returns the reducers of the fields of a state type.
+func reducersOf(t reflect.Type) ([]reducer, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrStateNotStruct, t)
+	}
+
+	reducers := make([]reducer, t.NumField())
+	var errs []error
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch name := field.Tag.Get(ReducerTag); {
+		case name == "" || name == ReduceOverwrite:
+			reducers[i] = overwriteReducer{}
+		case name == ReduceAppend && field.Type.Kind() == reflect.Slice:
+			reducers[i] = appendReducer{}
+		case name == ReduceMerge && field.Type.Kind() == reflect.Map:
+			reducers[i] = mergeReducer{}
+		case name == ReduceCounter && isNumber(field.Type):
+			reducers[i] = counterReducer{}
+		case name == ReduceUnion && field.Type.Kind() == reflect.Slice && field.Type.Elem().Comparable():
+			reducers[i] = unionReducer{}
+		case name == ReduceMax && (isNumber(field.Type) || field.Type.Kind() == reflect.String):
+			reducers[i] = maxReducer{}
+		case name == ReduceLastWrite && field.Type.Kind() != reflect.Pointer && field.Type.Implements(reflect.TypeFor[timestamped]()):
+			reducers[i] = lastWriteReducer{}
+		default:
+			errs = append(errs, fmt.Errorf("%w: %q on field %s of type %s", ErrInvalidReducer, name, field.Name, field.Type))
+		}
+	}
+	return reducers, errors.Join(errs...)
+}
+
+// overwriteReducer implements ReduceOverwrite.
+type overwriteReducer struct{}
+
+func (overwriteReducer) reduce(current, update reflect.Value) reflect.Value {
+	if update.IsZero() {
+		return current
+	}
+	return update
+}
+
+func (overwriteReducer) diff(input, output reflect.Value) reflect.Value {
+	if reflect.DeepEqual(input.Interface(), output.Interface()) {
+		return reflect.Zero(output.Type())
+	}
+	return output
+}
+
+// appendReducer implements ReduceAppend.
+type appendReducer struct{}
+
+func (appendReducer) reduce(current, update reflect.Value) reflect.Value {
+	if update.Len() == 0 {
+		return current
+	}
+	// Copy the elements, so that appends to the result never write into the current field.
+	reduced := reflect.MakeSlice(current.Type(), 0, current.Len()+update.Len())
+	return reflect.AppendSlice(reflect.AppendSlice(reduced, current), update)
+}
+
+// diff returns the elements added to the input. Outputs shorter than their input are expected
+// to be rewritten, and are returned whole.
+func (appendReducer) diff(input, output reflect.Value) reflect.Value {
+	if output.Len() < input.Len() {
+		return output
+	}
+	return output.Slice(input.Len(), output.Len())
+}
+
+// mergeReducer implements ReduceMerge.
+type mergeReducer struct{}
+
+func (mergeReducer) reduce(current, update reflect.Value) reflect.Value {
+	if update.Len() == 0 {
+		return current
+	}
+	reduced := reflect.MakeMapWithSize(current.Type(), current.Len()+update.Len())
+	for _, m := range []reflect.Value{current, update} {
+		for entries := m.MapRange(); entries.Next(); {
+			reduced.SetMapIndex(entries.Key(), entries.Value())
+		}
+	}
+	return reduced
+}
+
+// diff returns the entries of the output that were added or changed.
+func (mergeReducer) diff(input, output reflect.Value) reflect.Value {
+	changed := reflect.MakeMap(output.Type())
+	for entries := output.MapRange(); entries.Next(); {
+		previous := input.MapIndex(entries.Key())
+		if !previous.IsValid() || !reflect.DeepEqual(previous.Interface(), entries.Value().Interface()) {
+			changed.SetMapIndex(entries.Key(), entries.Value())
+		}
+	}
+	return changed
+}
+
+// isNumber reports whether t is an integer or floating-point type.
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// counterReducer implements ReduceCounter.
+type counterReducer struct{}
+
+func (counterReducer) reduce(current, update reflect.Value) reflect.Value {
+	reduced := reflect.New(current.Type()).Elem()
+	switch {
+	case current.CanInt():
+		reduced.SetInt(current.Int() + update.Int())
+	case current.CanUint():
+		reduced.SetUint(current.Uint() + update.Uint())
+	default:
+		reduced.SetFloat(current.Float() + update.Float())
+	}
+	return reduced
+}
+
+// diff returns the increment from the input to the output.
+func (counterReducer) diff(input, output reflect.Value) reflect.Value {
+	increment := reflect.New(output.Type()).Elem()
+	switch {
+	case output.CanInt():
+		increment.SetInt(output.Int() - input.Int())
+	case output.CanUint():
+		increment.SetUint(output.Uint() - input.Uint())
+	default:
+		increment.SetFloat(output.Float() - input.Float())
+	}
+	return increment
+}
+
+// unionReducer implements ReduceUnion.
+type unionReducer struct{}
+
+func (unionReducer) reduce(current, update reflect.Value) reflect.Value {
+	if update.Len() == 0 {
+		return current
+	}
+	// Copy the elements, so that appends to the result never write into the current field.
+	reduced := reflect.AppendSlice(reflect.MakeSlice(current.Type(), 0, current.Len()+update.Len()), current)
+	seen := make(map[any]bool, current.Len()+update.Len())
+	for i := range current.Len() {
+		seen[current.Index(i).Interface()] = true
+	}
+	for i := range update.Len() {
+		if elem := update.Index(i); !seen[elem.Interface()] {
+			seen[elem.Interface()] = true
+			reduced = reflect.Append(reduced, elem)
+		}
+	}
+	return reduced
+}
+
+// diff returns the elements of the output missing from the input.
+func (unionReducer) diff(input, output reflect.Value) reflect.Value {
+	seen := make(map[any]bool, input.Len())
+	for i := range input.Len() {
+		seen[input.Index(i).Interface()] = true
+	}
+	added := reflect.MakeSlice(output.Type(), 0, 0)
+	for i := range output.Len() {
+		if elem := output.Index(i); !seen[elem.Interface()] {
+			added = reflect.Append(added, elem)
+		}
+	}
+	return added
+}
+
+// maxReducer implements ReduceMax.
+type maxReducer struct{}
+
+func (maxReducer) reduce(current, update reflect.Value) reflect.Value {
+	if update.IsZero() || !less(current, update) {
+		return current
+	}
+	return update
+}
+
+func (maxReducer) diff(input, output reflect.Value) reflect.Value {
+	if input.Equal(output) {
+		return reflect.Zero(output.Type())
+	}
+	return output
+}
+
+// less reports whether a is lower than b, which are numbers or strings of the same type.
+func less(a, b reflect.Value) bool {
+	switch {
+	case a.CanInt():
+		return a.Int() < b.Int()
+	case a.CanUint():
+		return a.Uint() < b.Uint()
+	case a.CanFloat():
+		return a.Float() < b.Float()
+	default:
+		return a.String() < b.String()
+	}
+}
+
+// lastWriteReducer implements ReduceLastWrite.
+type lastWriteReducer struct{}
+
+func (lastWriteReducer) reduce(current, update reflect.Value) reflect.Value {
+	written := update.Interface().(timestamped).writtenAt()
+	if written.IsZero() || written.Before(current.Interface().(timestamped).writtenAt()) {
+		return current
+	}
+	return update
+}
+
+func (lastWriteReducer) diff(input, output reflect.Value) reflect.Value {
+	if reflect.DeepEqual(input.Interface(), output.Interface()) {
+		return reflect.Zero(output.Type())
+	}
+	return output
+}
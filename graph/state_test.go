@@ -0,0 +1,320 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+type research struct {
+	Question string
+	Answers  []string       `reducer:"append"`
+	Sources  map[string]int `reducer:"merge"`
+	Draft    string         `reducer:"overwrite"`
+	internal int
+}
+
+func TestStateGraphReduce(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[research]("a")
+	state := research{
+		Question: "why?",
+		Answers:  []string{"because"},
+		Sources:  map[string]int{"wiki": 1},
+		internal: 1,
+	}
+
+	testCases := []struct {
+		name     string
+		update   research
+		expected research
+	}{
+		{
+			name:     "empty update",
+			update:   research{},
+			expected: state,
+		},
+		{
+			name:   "partial update",
+			update: research{Answers: []string{"why not"}, Sources: map[string]int{"wiki": 2, "book": 1}, internal: 2},
+			expected: research{
+				Question: "why?",
+				Answers:  []string{"because", "why not"},
+				Sources:  map[string]int{"wiki": 2, "book": 1},
+				internal: 1,
+			},
+		},
+		{
+			name:   "overwrite",
+			update: research{Question: "how?", Draft: "draft"},
+			expected: research{
+				Question: "how?",
+				Answers:  []string{"because"},
+				Sources:  map[string]int{"wiki": 1},
+				Draft:    "draft",
+				internal: 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, g.Reduce(state, tc.update))
+			assert.Equal(t, []string{"because"}, state.Answers, "the state is left unchanged")
+			assert.Equal(t, map[string]int{"wiki": 1}, state.Sources, "the state is left unchanged")
+		})
+	}
+}
+
+func TestStateGraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[research]("ask")
+	g.AddNode("ask", func(context.Context, research) (research, error) {
+		return research{Question: "why?"}, nil
+	})
+	g.AddNode("wiki", func(context.Context, research) (research, error) {
+		return research{Answers: []string{"because"}, Sources: map[string]int{"wiki": 1}}, nil
+	})
+	g.AddNode("book", func(context.Context, research) (research, error) {
+		return research{Answers: []string{"why not"}, Sources: map[string]int{"book": 2}, Draft: "book"}, nil
+	})
+	g.AddNode("write", func(_ context.Context, state research) (research, error) {
+		return research{Draft: state.Draft + " + " + state.Answers[0]}, nil
+	})
+	g.AddEdge("ask", "wiki")
+	g.AddEdge("ask", "book")
+	g.AddEdge("wiki", "write")
+	g.AddEdge("book", "write")
+	g.AddEdge("write", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), research{Sources: map[string]int{"input": 0}})
+	require.NoError(t, err)
+	assert.Equal(t, research{
+		Question: "why?",
+		Answers:  []string{"because", "why not"},
+		Sources:  map[string]int{"input": 0, "wiki": 1, "book": 2},
+		Draft:    "book + because",
+	}, output)
+}
+
+func TestStateGraphSends(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Doc       string
+		Summaries []string `reducer:"append"`
+	}
+
+	g := graph.NewStateGraph[state]("split")
+	g.AddNode("split", func(context.Context, state) (state, error) { return state{}, nil })
+	g.AddNode("summarize", func(_ context.Context, s state) (state, error) {
+		return state{Summaries: []string{"summary of " + s.Doc}}, nil
+	})
+	g.AddSendEdge("split", func(_ context.Context, s state) ([]graph.Send[state], error) {
+		return []graph.Send[state]{
+			{Node: "summarize", State: state{Doc: "a", Summaries: s.Summaries}},
+			{Node: "summarize", State: state{Doc: "b", Summaries: s.Summaries}},
+		}, nil
+	}, "summarize")
+	g.AddEdge("summarize", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), state{Summaries: []string{"input"}})
+	require.NoError(t, err)
+	assert.Equal(t, state{Summaries: []string{"input", "summary of a", "summary of b"}}, output)
+}
+
+type aggregates struct {
+	Calls   int                         `reducer:"counter"`
+	Cost    float64                     `reducer:"counter"`
+	Tags    []string                    `reducer:"union"`
+	Best    int                         `reducer:"max"`
+	Version string                      `reducer:"max"`
+	Status  graph.Timestamped[string]   `reducer:"lww"`
+	Owners  graph.Timestamped[[]string] `reducer:"lww"`
+}
+
+func TestStateGraphAggregates(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[aggregates]("a")
+	now := time.Now()
+	state := aggregates{
+		Calls:   2,
+		Cost:    0.5,
+		Tags:    []string{"a", "b"},
+		Best:    5,
+		Version: "v1",
+		Status:  graph.Timestamped[string]{Value: "running", Time: now},
+	}
+
+	testCases := []struct {
+		name     string
+		update   aggregates
+		expected aggregates
+	}{
+		{
+			name:     "empty update",
+			expected: state,
+		},
+		{
+			name: "accumulate",
+			update: aggregates{
+				Calls:   1,
+				Cost:    0.25,
+				Tags:    []string{"b", "c", "c"},
+				Best:    7,
+				Version: "v2",
+				Status:  graph.Timestamped[string]{Value: "done", Time: now.Add(time.Second)},
+			},
+			expected: aggregates{
+				Calls:   3,
+				Cost:    0.75,
+				Tags:    []string{"a", "b", "c"},
+				Best:    7,
+				Version: "v2",
+				Status:  graph.Timestamped[string]{Value: "done", Time: now.Add(time.Second)},
+			},
+		},
+		{
+			name:   "partial update",
+			update: aggregates{Calls: 1},
+			expected: aggregates{
+				Calls:   3,
+				Cost:    0.5,
+				Tags:    []string{"a", "b"},
+				Best:    5,
+				Version: "v1",
+				Status:  graph.Timestamped[string]{Value: "running", Time: now},
+			},
+		},
+		{
+			name: "older values",
+			update: aggregates{
+				Best:    3,
+				Version: "v0",
+				Status:  graph.Timestamped[string]{Value: "queued", Time: now.Add(-time.Second)},
+			},
+			expected: state,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, g.Reduce(state, tc.update))
+			assert.Equal(t, []string{"a", "b"}, state.Tags, "the state is left unchanged")
+		})
+	}
+}
+
+func TestStateGraphParallelAggregates(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	g := graph.NewStateGraph[aggregates]("split")
+	g.AddNode("split", func(context.Context, aggregates) (aggregates, error) {
+		return aggregates{Calls: 1}, nil
+	})
+	sends := make([]graph.Send[aggregates], 5)
+	for i := range sends {
+		sends[i] = graph.Send[aggregates]{Node: "work", State: aggregates{Best: i}}
+	}
+	g.AddSendEdge("split", func(context.Context, aggregates) ([]graph.Send[aggregates], error) {
+		return sends, nil
+	}, "work")
+	g.AddNode("work", func(_ context.Context, state aggregates) (aggregates, error) {
+		// Later branches write earlier, so the first branch wins the status.
+		written := start.Add(time.Duration(len(sends)-state.Best) * time.Second)
+		return aggregates{
+			Calls:  1,
+			Cost:   0.5,
+			Tags:   []string{"shared", fmt.Sprint(state.Best % 2)},
+			Best:   state.Best * 10,
+			Status: graph.Timestamped[string]{Value: fmt.Sprint("branch ", state.Best), Time: written},
+		}, nil
+	})
+	g.AddEdge("work", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	out, err := runnable.Invoke(context.Background(), aggregates{})
+	require.NoError(t, err)
+	assert.Equal(t, 6, out.Calls)
+	assert.InDelta(t, 2.5, out.Cost, 1e-9)
+	assert.Equal(t, []string{"shared", "0", "1"}, out.Tags)
+	assert.Equal(t, 40, out.Best)
+	assert.Equal(t, "branch 0", out.Status.Value)
+}
+
+func TestStamp(t *testing.T) {
+	t.Parallel()
+
+	stamped := graph.Stamp("x")
+	assert.Equal(t, "x", stamped.Value)
+	assert.WithinDuration(t, time.Now(), stamped.Time, time.Minute)
+}
+
+func TestStateGraphCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	type invalid struct {
+		Count int                        `reducer:"append"`
+		Tags  []string                   `reducer:"merge"`
+		Meta  map[string]string          `reducer:"sum"`
+		Total string                     `reducer:"counter"`
+		Set   [][]string                 `reducer:"union"`
+		Best  []int                      `reducer:"max"`
+		Last  string                     `reducer:"lww"`
+		Ref   *graph.Timestamped[string] `reducer:"lww"`
+	}
+
+	g := graph.NewStateGraph[invalid]("a")
+	g.AddNode("a", func(_ context.Context, state invalid) (invalid, error) { return state, nil })
+	g.AddEdge("a", graph.END)
+	_, err := g.Compile()
+	require.ErrorIs(t, err, graph.ErrInvalidReducer)
+	for _, field := range []string{"Count", "Tags", "Meta", "Total", "Set", "Best", "Last", "Ref"} {
+		assert.Contains(t, err.Error(), "field "+field)
+	}
+
+	s := graph.NewStateGraph[[]string]("a")
+	s.AddNode("a", func(_ context.Context, state []string) ([]string, error) { return state, nil })
+	s.AddEdge("a", graph.END)
+	_, err = s.Compile()
+	require.ErrorIs(t, err, graph.ErrStateNotStruct)
+}
+
+func TestStateGraphNodeError(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[research]("a")
+	g.AddNode("a", func(context.Context, research) (research, error) {
+		return research{}, errors.New("boom")
+	})
+	g.AddEdge("a", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), research{Question: "why?"})
+	require.EqualError(t, err, "error in node a: boom")
+	assert.Equal(t, research{Question: "why?"}, output)
+}
@@ -0,0 +1,221 @@
+package graph
+
+import "context"
+
+// EventKind classifies stream events.
+type EventKind string
+
+const (
+	// EventNode is emitted when a node completes, with the state it returned.
+	EventNode EventKind = "node"
+
+	// EventRoute is emitted after EventNode with the edges leading to the next nodes.
+	EventRoute EventKind = "route"
+
+	// EventMerge is emitted when the states of nodes executed in parallel were joined.
+	EventMerge EventKind = "merge"
+
+	// EventScore is emitted for every score computed by the scorers of WithScoring.
+	EventScore EventKind = "score"
+
+	// EventChunk is emitted for every chunk of output a node forwards with StreamChunk, such as
+	// the tokens of a model response, before the node completes.
+	EventChunk EventKind = "chunk"
+
+	// EventActivity is emitted for the activities of the run, such as a node thinking or running
+	// a tool, when streaming with WithActivity.
+	EventActivity EventKind = "activity"
+
+	// EventEnd is the last event of a stream, with the final state or the error of the run.
+	EventEnd EventKind = "end"
+)
+
+// StreamEvent is an event of a streamed invocation.
+type StreamEvent[T any] struct {
+	// Kind classifies the event.
+	Kind EventKind
+
+	// Step is the index of the step of node, route, chunk and merge events. Nodes executed in
+	// parallel share their step.
+	Step int
+
+	// Node is the node of node, route, chunk and score events; empty for the score of the final
+	// state.
+	Node string
+
+	// Branch identifies the parallel branch that emitted the event, so events of concurrent
+	// nodes can be told apart; empty outside parallel steps. It is the name of the node the
+	// branch executes.
+	Branch string
+
+	// Branches are the branches joined by a merge event, in the order of their edges.
+	Branches []string
+
+	// State is the state returned by the node of node events, the merged state of merge events
+	// and the final state of end events.
+	State T
+
+	// Edges are the edges taken after the node of route events; the routes chosen by routers
+	// are conditional edges.
+	Edges []Edge
+
+	// Score is the score of score events.
+	Score *Score
+
+	// Chunk is the chunk of chunk events.
+	Chunk string
+
+	// Activity is the activity of activity events.
+	Activity Activity
+
+	// Tool is the tool of ActivityToolRunning events.
+	Tool string
+
+	// Err is the error that ended the run, for end events.
+	Err error
+
+	// Reason is why the run ended, for end events; see ReasonOf.
+	Reason Reason
+}
+
+// Stream executes the graph like Invoke, but returns at once a channel receiving the events of
+// the execution as they happen: the chunks of output forwarded by nodes, the state returned by
+// every node, the edges taken, the merges of parallel nodes and the scores, then an EventEnd event with the final state or the error,
+// after which the channel is closed. With WithActivity, it also receives the activities of the
+// run.
+//
+// Events are not buffered: the execution waits for each event to be received. Callers stopping
+// before EventEnd must cancel ctx, which ends the execution as for Invoke and closes the
+// channel. Graphs invoked by nodes do not emit events to the stream, except the chunks of their
+// nodes, which are forwarded as chunks of the invoking node. An error is returned if ctx is
+// already done.
+func (r *Runnable[T]) Stream(ctx context.Context, state T) (<-chan StreamEvent[T], error) {
+	return r.stream(ctx, func(ctx context.Context) (T, error) {
+		return r.Invoke(ctx, state)
+	})
+}
+
+// ResumeStream continues an interrupted invocation like Resume, but streams the events of the
+// execution like Stream.
+func (r *Runnable[T]) ResumeStream(ctx context.Context, interrupt *Interrupt, state T) (<-chan StreamEvent[T], error) {
+	return r.stream(ctx, func(ctx context.Context) (T, error) {
+		return r.Resume(ctx, interrupt, state)
+	})
+}
+
+// stream executes run with a context emitting its events to the returned channel.
+func (r *Runnable[T]) stream(ctx context.Context, run func(ctx context.Context) (T, error)) (<-chan StreamEvent[T], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent[T])
+	s := &stream[T]{ctx: ctx, events: events, activity: activityEnabled(ctx)}
+	go func() {
+		defer close(events)
+
+		state, err := run(context.WithValue(ctx, streamKey{}, s))
+		s.awaitingInput(err)
+		s.emit(StreamEvent[T]{Kind: EventEnd, State: state, Err: err, Reason: ReasonOf(err)})
+	}()
+	return events, nil
+}
+
+// stream is where a streamed invocation sends its events.
+type stream[T any] struct {
+	ctx    context.Context
+	events chan<- StreamEvent[T]
+
+	// activity tells whether the stream emits activity events; see WithActivity.
+	activity bool
+}
+
+type streamKey struct{}
+
+type branchKey struct{}
+
+func streamFromContext[T any](ctx context.Context) *stream[T] {
+	s, _ := ctx.Value(streamKey{}).(*stream[T])
+	return s
+}
+
+// withoutStream returns a context that does not emit events, for the functions of nodes and
+// routers, so nested invocations do not write to the stream.
+func withoutStream(ctx context.Context) context.Context {
+	if ctx.Value(streamKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, streamKey{}, nil)
+}
+
+func withBranch(ctx context.Context, branch string) context.Context {
+	return context.WithValue(ctx, branchKey{}, branch)
+}
+
+func currentBranch(ctx context.Context) string {
+	branch, _ := ctx.Value(branchKey{}).(string)
+	return branch
+}
+
+type chunkKey struct{}
+
+// withChunks returns the context of a node making StreamChunk emit chunk events to the stream,
+// if any, coalesced according to shaping, and the function emitting the chunks held when the
+// node completes. Without stream, the context is returned as is, so the nodes of graphs invoked
+// by a streamed node forward their chunks as chunks of that node.
+func withChunks[T any](ctx context.Context, s *stream[T], index int, node, branch string, shaping ChunkShaping) (context.Context, func() error) {
+	if s == nil {
+		return ctx, noFlush
+	}
+	send := func(chunk []byte) error {
+		select {
+		case s.events <- StreamEvent[T]{Kind: EventChunk, Step: index, Node: node, Branch: branch, Chunk: string(chunk)}:
+			return nil
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+	if !shaping.enabled() {
+		return context.WithValue(ctx, chunkKey{}, send), noFlush
+	}
+	buffer := &chunkBuffer{send: send, shaping: shaping}
+	return context.WithValue(ctx, chunkKey{}, buffer.add), buffer.flush
+}
+
+func noFlush() error { return nil }
+
+// StreamChunk forwards a chunk of the output of the node running with ctx, such as tokens of a
+// model response, to the stream of the invocation as an EventChunk event, so users see partial
+// output while the node and the following ones are still running. It does nothing unless the
+// invocation is streamed, and returns the error of the context of the stream once it is done.
+//
+// Its signature is that of the streaming functions of langchaingo, so nodes can pass it as is:
+//
+//	resp, err := model.GenerateContent(ctx, messages, llms.WithStreamingFunc(graph.StreamChunk))
+//
+// The chunks of attempts of a node that are retried are not withdrawn from the stream.
+func StreamChunk(ctx context.Context, chunk []byte) error {
+	send, _ := ctx.Value(chunkKey{}).(func([]byte) error)
+	if send == nil {
+		return nil
+	}
+	return send(chunk)
+}
+
+// Streaming reports whether StreamChunk forwards the chunks of the node running with ctx, e.g.
+// to call a model with streaming only when its output is streamed.
+func Streaming(ctx context.Context) bool {
+	send, _ := ctx.Value(chunkKey{}).(func([]byte) error)
+	return send != nil
+}
+
+// emit sends the event, unless the stream is nil or its context is done.
+func (s *stream[T]) emit(event StreamEvent[T]) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.events <- event:
+	case <-s.ctx.Done():
+	}
+}
@@ -0,0 +1,306 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func collect[T any](t *testing.T, events <-chan graph.StreamEvent[T]) []graph.StreamEvent[T] {
+	t.Helper()
+
+	var all []graph.StreamEvent[T]
+	for event := range events {
+		all = append(all, event)
+	}
+	return all
+}
+
+func appendNode(name string) func(context.Context, []string) ([]string, error) {
+	return func(_ context.Context, state []string) ([]string, error) {
+		return graph.AppendMessages(state, name), nil
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", appendNode("agent"))
+	g.AddNode("tools", appendNode("tools"))
+	g.AddConditionalEdge("agent", func(_ context.Context, state []string) (string, error) {
+		if len(state) < 3 {
+			return "tools", nil
+		}
+		return graph.END, nil
+	}, "tools", graph.END)
+	g.AddLabeledEdge("tools", "agent", "observe", "")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), []string{"input"})
+	require.NoError(t, err)
+	assert.Equal(t, []graph.StreamEvent[[]string]{
+		{Kind: graph.EventNode, Step: 0, Node: "agent", State: []string{"input", "agent"}},
+		{Kind: graph.EventRoute, Step: 0, Node: "agent", Edges: []graph.Edge{{From: "agent", To: "tools", Conditional: true}}},
+		{Kind: graph.EventNode, Step: 1, Node: "tools", State: []string{"input", "agent", "tools"}},
+		{Kind: graph.EventRoute, Step: 1, Node: "tools", Edges: []graph.Edge{{From: "tools", To: "agent", Label: "observe"}}},
+		{Kind: graph.EventNode, Step: 2, Node: "agent", State: []string{"input", "agent", "tools", "agent"}},
+		{Kind: graph.EventRoute, Step: 2, Node: "agent", Edges: []graph.Edge{{From: "agent", To: graph.END, Conditional: true}}},
+		{Kind: graph.EventEnd, State: []string{"input", "agent", "tools", "agent"}, Reason: graph.ReasonCompleted},
+	}, collect(t, events))
+}
+
+func TestStreamParallelBranches(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("split")
+	for _, name := range []string{"split", "left", "right"} {
+		g.AddNode(name, appendNode(name))
+	}
+	g.AddEdge("split", "left")
+	g.AddEdge("split", "right")
+	g.AddEdge("left", graph.END)
+	g.AddEdge("right", graph.END)
+	g.SetJoin(graph.Concatenate[string]())
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	all := collect(t, events)
+	require.Len(t, all, 8)
+
+	// Events of the branches are interleaved in any order, but always come between the events
+	// of the fan-out and the merge.
+	branches := all[2:6]
+	sort.SliceStable(branches, func(i, j int) bool { return branches[i].Branch < branches[j].Branch })
+	for i, branch := range []string{"left", "left", "right", "right"} {
+		assert.Equal(t, branch, branches[i].Branch)
+		assert.Equal(t, branch, branches[i].Node)
+		assert.Equal(t, 1, branches[i].Step)
+	}
+	assert.Equal(t, graph.StreamEvent[[]string]{Kind: graph.EventMerge, Step: 1, Branches: []string{"left", "right"}, State: []string{"split", "left", "right"}}, all[6])
+	assert.Equal(t, graph.EventEnd, all[7].Kind)
+}
+
+func TestStreamParallelBranchFailure(t *testing.T) {
+	t.Parallel()
+
+	failed := make(chan struct{})
+	var returned atomic.Bool
+	g := graph.NewMessageGraph[[]string]("split")
+	g.AddNode("split", appendNode("split"))
+	g.AddNode("broken", func(context.Context, []string) ([]string, error) {
+		defer close(failed)
+		return nil, errors.New("broken")
+	})
+	g.AddNode("slow", func(_ context.Context, state []string) ([]string, error) {
+		// The sibling completes after the failure, ignoring the cancellation.
+		<-failed
+		time.Sleep(10 * time.Millisecond)
+		returned.Store(true)
+		return graph.AppendMessages(state, "slow"), nil
+	})
+	g.AddEdge("split", "broken")
+	g.AddEdge("split", "slow")
+	g.SetFinishPoint("broken")
+	g.SetFinishPoint("slow")
+	g.SetJoin(graph.Concatenate[string]())
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	// The failure ends the stream once the sibling returned, so the sibling never emits on a
+	// closed channel.
+	assert.True(t, returned.Load())
+	end := all[len(all)-1]
+	assert.Equal(t, graph.EventEnd, end.Kind)
+	require.ErrorContains(t, end.Err, "branch broken")
+	for _, event := range all[:len(all)-1] {
+		assert.NotEqual(t, graph.EventEnd, event.Kind)
+	}
+}
+
+func TestStreamScoresAndErrors(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("broken", func(context.Context, []string) ([]string, error) { return nil, errors.New("broken") })
+	g.AddEdge("draft", "broken")
+	g.AddEdge("broken", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx := graph.WithScoring(context.Background(), nil, graph.Scorer[[]string]{
+		Name: "length",
+		Score: func(_ context.Context, in graph.ScoreInput[[]string]) (float64, error) {
+			return float64(len(in.State)), nil
+		},
+	})
+	events, err := runnable.Stream(ctx, nil)
+	require.NoError(t, err)
+	all := collect(t, events)
+
+	require.Len(t, all, 4)
+	assert.Equal(t, graph.EventNode, all[0].Kind)
+	assert.Equal(t, graph.StreamEvent[[]string]{Kind: graph.EventScore, Node: "draft", Score: &graph.Score{Scorer: "length", Node: "draft", Value: 1}}, all[1])
+	assert.Equal(t, graph.EventRoute, all[2].Kind)
+	assert.Equal(t, graph.EventEnd, all[3].Kind)
+	assert.EqualError(t, all[3].Err, "error in node broken: broken")
+}
+
+func TestStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("loop")
+	g.AddNode("loop", appendNode("loop"))
+	g.AddConditionalEdge("loop", func(context.Context, []string) (string, error) { return "loop", nil })
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := runnable.Stream(ctx, nil)
+	require.NoError(t, err)
+
+	first := <-events
+	assert.Equal(t, "loop", first.Node)
+	cancel()
+	for range events {
+	}
+
+	_, err = runnable.Stream(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestResumeStream(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]string]("draft")
+	g.AddNode("draft", appendNode("draft"))
+	g.AddNode("send", appendNode("send"))
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	runnable, err := g.Compile(graph.WithInterruptBefore("send"))
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	all := collect(t, events)
+	end := all[len(all)-1]
+	var interrupt *graph.Interrupt
+	require.ErrorAs(t, end.Err, &interrupt)
+	assert.Equal(t, graph.ReasonInterrupted, end.Reason)
+
+	events, err = runnable.ResumeStream(context.Background(), interrupt, append(end.State, "approved"))
+	require.NoError(t, err)
+	assert.Equal(t, []graph.StreamEvent[[]string]{
+		{Kind: graph.EventNode, Step: 1, Node: "send", State: []string{"draft", "approved", "send"}},
+		{Kind: graph.EventRoute, Step: 1, Node: "send", Edges: []graph.Edge{{From: "send", To: graph.END}}},
+		{Kind: graph.EventEnd, State: []string{"draft", "approved", "send"}, Reason: graph.ReasonCompleted},
+	}, collect(t, events))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runnable.ResumeStream(ctx, interrupt, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStreamChunks(t *testing.T) {
+	t.Parallel()
+
+	// generate calls the streaming function like the models of langchaingo do.
+	generate := func(ctx context.Context, opts ...llms.CallOption) (string, error) {
+		var options llms.CallOptions
+		for _, opt := range opts {
+			opt(&options)
+		}
+		for _, chunk := range []string{"Hel", "lo"} {
+			if options.StreamingFunc != nil {
+				if err := options.StreamingFunc(ctx, []byte(chunk)); err != nil {
+					return "", err
+				}
+			}
+		}
+		return "Hello", nil
+	}
+
+	inner := graph.NewMessageGraph[[]string]("model")
+	inner.AddNode("model", func(ctx context.Context, state []string) ([]string, error) {
+		out, err := generate(ctx, llms.WithStreamingFunc(graph.StreamChunk))
+		return append(state, out), err
+	})
+	inner.AddEdge("model", graph.END)
+	subgraph, err := inner.Compile()
+	require.NoError(t, err)
+
+	var streaming []bool
+	g := graph.NewMessageGraph[[]string]("agent")
+	g.AddNode("agent", func(ctx context.Context, state []string) ([]string, error) {
+		streaming = append(streaming, graph.Streaming(ctx))
+		return subgraph.Invoke(ctx, state)
+	})
+	g.AddNode("done", func(_ context.Context, state []string) ([]string, error) {
+		return append(state, "done"), nil
+	})
+	g.AddEdge("agent", "done")
+	g.AddEdge("done", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	events, err := runnable.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	all := collect(t, events)
+	require.Len(t, all, 7)
+	assert.Equal(t, graph.StreamEvent[[]string]{Kind: graph.EventChunk, Node: "agent", Chunk: "Hel"}, all[0])
+	assert.Equal(t, graph.StreamEvent[[]string]{Kind: graph.EventChunk, Node: "agent", Chunk: "lo"}, all[1])
+	assert.Equal(t, graph.EventNode, all[2].Kind)
+	assert.Equal(t, []string{"Hello", "done"}, all[6].State)
+
+	// Invocations that are not streamed ignore the chunks.
+	state, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", "done"}, state)
+	assert.Equal(t, []bool{true, false}, streaming)
+	assert.NoError(t, graph.StreamChunk(context.Background(), []byte("ignored")))
+}
+
+func TestStreamChunksCancel(t *testing.T) {
+	t.Parallel()
+
+	chunkErr := make(chan error, 1)
+	g := graph.NewMessageGraph[int]("model")
+	g.AddNode("model", func(ctx context.Context, state int) (int, error) {
+		for {
+			if err := graph.StreamChunk(ctx, []byte("token")); err != nil {
+				chunkErr <- err
+				return state, err
+			}
+		}
+	})
+	g.AddEdge("model", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := runnable.Stream(ctx, 0)
+	require.NoError(t, err)
+	event := <-events
+	assert.Equal(t, graph.EventChunk, event.Kind)
+	cancel()
+	for range events {
+	}
+	assert.ErrorIs(t, <-chunkErr, context.Canceled)
+}
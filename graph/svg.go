@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Sizes of the SVG layout, in pixels.
+const (
+	svgNodeWidth  = 160
+	svgNodeHeight = 40
+	svgGapX       = 40
+	svgGapY       = 70
+	svgLoopOffset = 60
+)
+
+// SVG renders the graph as an SVG image without external tools. Nodes are laid out by Layers,
+// one row per layer; edges going down are drawn straight and the others curve on the right.
+// The routes of conditional edges are dashed.
+// Edges from or to undeclared nodes are not drawn.
+func (t Topology) SVG() string {
+	layers := append([][]string{{"start"}}, t.Layers()...)
+	widest := 0
+	for _, layer := range layers {
+		widest = max(widest, len(layer))
+	}
+	width := widest*(svgNodeWidth+svgGapX) + svgGapX + svgLoopOffset
+	height := len(layers) * (svgNodeHeight + svgGapY)
+
+	// Position of the top-left corner of each node, and its row.
+	type box struct{ x, y, row int }
+	boxes := make(map[string]box)
+	for row, layer := range layers {
+		offset := (width - svgLoopOffset - len(layer)*(svgNodeWidth+svgGapX) + svgGapX) / 2
+		for i, node := range layer {
+			key := node
+			if row == 0 {
+				key = ""
+			}
+			boxes[key] = box{
+				x:   offset + i*(svgNodeWidth+svgGapX),
+				y:   svgGapY/2 + row*(svgNodeHeight+svgGapY),
+				row: row,
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="13">`+"\n", width, height, width, height)
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z"/></marker></defs>` + "\n")
+
+	edges := append([]Edge(nil), t.Edges...)
+	if t.HasNode(t.EntryPoint) {
+		edges = append([]Edge{{From: "", To: t.EntryPoint}}, edges...)
+	}
+	for _, edge := range edges {
+		from, okFrom := boxes[edge.From]
+		to, okTo := boxes[edge.To]
+		if !okFrom || !okTo {
+			continue
+		}
+
+		var path string
+		var labelX, labelY int
+		if to.row > from.row {
+			x1, y1 := from.x+svgNodeWidth/2, from.y+svgNodeHeight
+			x2, y2 := to.x+svgNodeWidth/2, to.y
+			path = fmt.Sprintf("M%d,%d L%d,%d", x1, y1, x2, y2)
+			labelX, labelY = (x1+x2)/2+6, (y1+y2)/2
+		} else {
+			x1, y1 := from.x+svgNodeWidth, from.y+svgNodeHeight/2
+			x2, y2 := to.x+svgNodeWidth, to.y+svgNodeHeight/2
+			cx := max(x1, x2) + svgLoopOffset
+			if y1 == y2 {
+				y1, y2 = y1-svgNodeHeight/4, y2+svgNodeHeight/4
+			}
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, cx, y1, cx, y2, x2, y2)
+			labelX, labelY = cx-svgLoopOffset/4+6, (y1+y2)/2
+		}
+
+		b.WriteString("<g>")
+		if edge.Description != "" {
+			fmt.Fprintf(&b, "<title>%s</title>", html.EscapeString(edge.Description))
+		}
+		dash := ""
+		if edge.Conditional || edge.Error {
+			dash = ` stroke-dasharray="6,4"`
+		}
+		fmt.Fprintf(&b, `<path d="%s" fill="none" stroke="black"%s marker-end="url(#arrow)"/>`, path, dash)
+		if edge.Label != "" {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-style="italic">%s</text>`, labelX, labelY, html.EscapeString(edge.Label))
+		}
+		b.WriteString("</g>\n")
+	}
+
+	for row, layer := range layers {
+		for _, node := range layer {
+			key, rx := node, 4
+			if row == 0 {
+				key = ""
+			}
+			if row == 0 || node == END {
+				rx = svgNodeHeight / 2
+			}
+			pos := boxes[key]
+			fmt.Fprintf(&b, `<g><rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="white" stroke="black"/>`, pos.x, pos.y, svgNodeWidth, svgNodeHeight, rx)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central">%s</text></g>`+"\n", pos.x+svgNodeWidth/2, pos.y+svgNodeHeight/2, html.EscapeString(node))
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
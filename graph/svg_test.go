@@ -0,0 +1,39 @@
+package graph_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSVG(t *testing.T) {
+	t.Parallel()
+
+	g := lintGraph("plan", []string{"plan", "act", "<review>"})
+	g.AddEdge("plan", "act")
+	g.AddLabeledEdge("act", "<review>", "done?", "checks the tool results & decides")
+	g.AddLabeledEdge("<review>", "plan", "retry", "")
+	svg := g.Topology().SVG()
+
+	// The image must be well-formed XML.
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	for _, text := range []string{">start<", ">plan<", ">act<", ">&lt;review&gt;<", ">done?<", ">retry<", "<title>checks the tool results &amp; decides</title>"} {
+		assert.Contains(t, svg, text)
+	}
+	assert.NotContains(t, svg, ">"+graph.END+"<", "END has no incoming edge")
+}
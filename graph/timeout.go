@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNodeTimeout is matched by the *NodeTimeoutError errors of nodes exceeding the timeout set
+// with WithTimeout.
+var ErrNodeTimeout = errors.New("node timed out")
+
+// NodeTimeoutError is returned when an execution of a node exceeds the timeout set with
+// WithTimeout. It matches ErrNodeTimeout and wraps the error the node returned, which usually
+// matches context.DeadlineExceeded.
+type NodeTimeoutError struct {
+	// Node is the name of the node.
+	Node string
+
+	// Timeout is the timeout of the node.
+	Timeout time.Duration
+
+	// Err is the error the node returned.
+	Err error
+}
+
+// Error implements error.
+func (e *NodeTimeoutError) Error() string {
+	return fmt.Sprintf("node %s timed out after %s: %v", e.Node, e.Timeout, e.Err)
+}
+
+// Is makes errors.Is match ErrNodeTimeout.
+func (e *NodeTimeoutError) Is(target error) bool {
+	return target == ErrNodeTimeout
+}
+
+// Unwrap returns the error the node returned.
+func (e *NodeTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WithTimeout bounds every execution of a node to d: its context gets a deadline, and its error
+// once the deadline passed is returned as a *NodeTimeoutError. Node functions must return once
+// their context is done, as the calls they make to models and tools usually do. With WithRetry,
+// every attempt has its own deadline, and attempts timing out are retried.
+func WithTimeout(d time.Duration) NodeOption {
+	return func(o *nodeOptions) {
+		o.timeout = d
+	}
+}
+
+// attempt executes the function of the node once, within its timeout.
+func (n Node[T]) attempt(ctx context.Context, state T) (T, error) {
+	if n.Timeout <= 0 {
+		return n.Function(ctx, state)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, n.Timeout)
+	defer cancel()
+	out, err := n.Function(attemptCtx, state)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		err = &NodeTimeoutError{Node: n.Name, Timeout: n.Timeout, Err: err}
+	}
+	return out, err
+}
@@ -0,0 +1,104 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	// slow waits for its context unless it already ran attempts times.
+	slow := func(attempts *int, succeedAfter int) func(ctx context.Context, state int) (int, error) {
+		return func(ctx context.Context, state int) (int, error) {
+			*attempts++
+			if *attempts > succeedAfter {
+				return state + 1, nil
+			}
+			<-ctx.Done()
+			return state, ctx.Err()
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		succeedAfter     int
+		opts             []graph.NodeOption
+		expectedAttempts int
+		expectedErr      bool
+	}{
+		{
+			name:             "within timeout",
+			opts:             []graph.NodeOption{graph.WithTimeout(time.Minute)},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "timed out",
+			succeedAfter:     1,
+			opts:             []graph.NodeOption{graph.WithTimeout(time.Millisecond)},
+			expectedAttempts: 1,
+			expectedErr:      true,
+		},
+		{
+			name:             "retried",
+			succeedAfter:     1,
+			opts:             []graph.NodeOption{graph.WithTimeout(time.Millisecond), graph.WithRetry(2, time.Millisecond)},
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+			g := graph.NewMessageGraph[int]("slow")
+			g.AddNodeWithOptions("slow", slow(&attempts, tc.succeedAfter), tc.opts...)
+			g.AddEdge("slow", graph.END)
+
+			runnable, err := g.Compile()
+			require.NoError(t, err)
+
+			state, err := runnable.Invoke(context.Background(), 0)
+			assert.Equal(t, tc.expectedAttempts, attempts)
+			if !tc.expectedErr {
+				require.NoError(t, err)
+				assert.Equal(t, 1, state)
+				return
+			}
+			require.ErrorIs(t, err, graph.ErrNodeTimeout)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			var timeout *graph.NodeTimeoutError
+			require.ErrorAs(t, err, &timeout)
+			assert.Equal(t, "slow", timeout.Node)
+			assert.Equal(t, time.Millisecond, timeout.Timeout)
+		})
+	}
+}
+
+func TestWithTimeoutInvocationDeadline(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[int]("slow")
+	g.AddNodeWithOptions("slow", func(ctx context.Context, state int) (int, error) {
+		<-ctx.Done()
+		return state, ctx.Err()
+	}, graph.WithTimeout(time.Minute))
+	g.AddEdge("slow", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = runnable.Invoke(ctx, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, errors.Is(err, graph.ErrNodeTimeout), "the deadline of the invocation is not the node's")
+}
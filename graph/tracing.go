@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the OpenTelemetry tracer of the spans of graph invocations.
+const TracerName = "github.com/cesto93/langgraphgo/graph"
+
+// Attributes of the spans of graph invocations and node executions.
+const (
+	// AttributeNode is the name of the node executed.
+	AttributeNode = attribute.Key("langgraph.node")
+
+	// AttributeStep is the index of the step executing the node.
+	AttributeStep = attribute.Key("langgraph.step")
+
+	// AttributeBranch is the name of the parallel branch executing the node, if any.
+	AttributeBranch = attribute.Key("langgraph.branch")
+
+	// AttributeThreadID is the thread of the invocation set with WithThreadID, if any.
+	AttributeThreadID = attribute.Key("langgraph.thread_id")
+
+	// AttributeResumed is set on the spans of invocations resuming an interrupt.
+	AttributeResumed = attribute.Key("langgraph.resumed")
+
+	// AttributeHandoffFrom is the graph a thread is handed off from; see HandOff.
+	AttributeHandoffFrom = attribute.Key("langgraph.handoff.from")
+
+	// AttributeHandoffTo is the graph a thread is handed off to; see HandOff.
+	AttributeHandoffTo = attribute.Key("langgraph.handoff.to")
+)
+
+// WithTracerProvider traces the invocations of the Runnable with the tracers of tp: a span per
+// invocation, named "graph.invoke", with a child span per node execution, named after the node
+// and carrying its step index. Failed invocations and nodes have an error status; interrupted
+// invocations have an "interrupted" event. Nodes receive the context of their span, so the
+// spans they start, e.g. of model calls, are its children.
+func WithTracerProvider(tp trace.TracerProvider) CompileOption {
+	return func(o *compileOptions) {
+		o.tracer = tp.Tracer(TracerName)
+	}
+}
+
+// WithTraceRedaction rewrites the strings recorded on the spans traced with WithTracerProvider
+// with redact, e.g. the String method of a redact.Redactor, so secrets do not reach the tracing
+// backend: the string attributes of the spans and of their events, such as the errors of
+// EventRetry events, and the messages of the errors and statuses of failed spans. It covers the
+// attributes and events nodes add to their span, but not the spans they start.
+func WithTraceRedaction(redact func(string) string) CompileOption {
+	return func(o *compileOptions) {
+		o.traceRedact = redact
+	}
+}
+
+// startSpan starts a span with the tracer of the Runnable, if any, and returns the context of
+// the span and the function ending it with the error of the operation traced.
+func (r *Runnable[T]) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	if r.tracer == nil {
+		return ctx, func(error) {}
+	}
+	if threadID := threadIDFromContext(ctx); threadID != "" {
+		attrs = append(attrs, AttributeThreadID.String(threadID))
+	}
+	var span trace.Span
+	if r.traceRedact == nil {
+		ctx, span = r.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	} else {
+		ctx, span = r.tracer.Start(ctx, name, trace.WithAttributes(redactAttributes(attrs, r.traceRedact)...))
+		span = redactingSpan{Span: span, redact: r.traceRedact}
+		ctx = trace.ContextWithSpan(ctx, span)
+	}
+	return ctx, func(err error) {
+		switch {
+		case errors.Is(err, ErrInterrupted):
+			span.AddEvent("interrupted")
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// nodeSpanAttributes returns the attributes of the span of a node executed as the step with the
+// given index.
+func nodeSpanAttributes(ctx context.Context, node string, index int) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{AttributeNode.String(node), AttributeStep.Int(index)}
+	if branch := currentBranch(ctx); branch != "" {
+		attrs = append(attrs, AttributeBranch.String(branch))
+	}
+	return attrs
+}
+
+// redactingSpan is a span rewriting the strings recorded on it with redact.
+type redactingSpan struct {
+	trace.Span
+	redact func(string) string
+}
+
+// SetAttributes sets the attributes, redacted.
+func (s redactingSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	s.Span.SetAttributes(redactAttributes(attrs, s.redact)...)
+}
+
+// AddEvent adds the event with its attributes redacted.
+func (s redactingSpan) AddEvent(name string, opts ...trace.EventOption) {
+	s.Span.AddEvent(name, s.eventOptions(opts)...)
+}
+
+// RecordError records the error with its message and attributes redacted.
+func (s redactingSpan) RecordError(err error, opts ...trace.EventOption) {
+	if err != nil {
+		err = errors.New(s.redact(err.Error()))
+	}
+	s.Span.RecordError(err, s.eventOptions(opts)...)
+}
+
+// SetStatus sets the status with its description redacted.
+func (s redactingSpan) SetStatus(code codes.Code, description string) {
+	s.Span.SetStatus(code, s.redact(description))
+}
+
+// eventOptions returns the options of an event with its attributes redacted.
+func (s redactingSpan) eventOptions(opts []trace.EventOption) []trace.EventOption {
+	cfg := trace.NewEventConfig(opts...)
+	return []trace.EventOption{
+		trace.WithAttributes(redactAttributes(cfg.Attributes(), s.redact)...),
+		trace.WithTimestamp(cfg.Timestamp()),
+		trace.WithStackTrace(cfg.StackTrace()),
+	}
+}
+
+// redactAttributes returns the attributes with their string values rewritten with redact.
+func redactAttributes(attrs []attribute.KeyValue, redact func(string) string) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		switch attr.Value.Type() {
+		case attribute.STRING:
+			attr.Value = attribute.StringValue(redact(attr.Value.AsString()))
+		case attribute.STRINGSLICE:
+			values := attr.Value.AsStringSlice()
+			for j := range values {
+				values[j] = redact(values[j])
+			}
+			attr.Value = attribute.StringSliceValue(values)
+		}
+		out[i] = attr
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/redact"
+)
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	testCases := []struct {
+		name        string
+		fail        bool
+		opts        []graph.CompileOption
+		expectedErr error
+		nodes       []string
+	}{
+		{
+			name:  "success",
+			nodes: []string{"a", "b", "c"},
+		},
+		{
+			name:        "node error",
+			fail:        true,
+			expectedErr: boom,
+			nodes:       []string{"a", "b"},
+		},
+		{
+			name:        "interrupt",
+			opts:        []graph.CompileOption{graph.WithInterruptBefore("b", "c")},
+			expectedErr: graph.ErrInterrupted,
+			nodes:       []string{"a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			g := graph.NewMessageGraph[[]string]("a")
+			for _, name := range []string{"a", "b", "c"} {
+				g.AddNode(name, func(ctx context.Context, state []string) ([]string, error) {
+					_, span := tp.Tracer("test").Start(ctx, "model")
+					span.End()
+					if tc.fail && name == "b" {
+						return state, boom
+					}
+					return append(state, name), nil
+				})
+			}
+			g.AddEdge("a", "b")
+			g.AddEdge("a", "c")
+			g.AddEdge("b", graph.END)
+			g.AddEdge("c", graph.END)
+			g.SetJoin(graph.Concatenate[string]())
+
+			runnable, err := g.Compile(append(tc.opts, graph.WithTracerProvider(tp))...)
+			require.NoError(t, err)
+
+			_, err = runnable.Invoke(graph.WithThreadID(context.Background(), "t1"), nil)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			spans := recorder.Ended()
+			invoke := spans[len(spans)-1]
+			assert.Equal(t, "graph.invoke", invoke.Name())
+			assert.Contains(t, invoke.Attributes(), graph.AttributeThreadID.String("t1"))
+			switch {
+			case errors.Is(tc.expectedErr, graph.ErrInterrupted):
+				assert.Equal(t, codes.Unset, invoke.Status().Code)
+				require.Len(t, invoke.Events(), 1)
+				assert.Equal(t, "interrupted", invoke.Events()[0].Name)
+			case tc.expectedErr != nil:
+				assert.Equal(t, codes.Error, invoke.Status().Code)
+			default:
+				assert.Equal(t, codes.Unset, invoke.Status().Code)
+			}
+
+			nodes := map[string]sdktrace.ReadOnlySpan{}
+			models := map[trace.SpanID]bool{}
+			for _, span := range spans[:len(spans)-1] {
+				if span.Name() == "model" {
+					models[span.Parent().SpanID()] = true
+					continue
+				}
+				nodes[span.Name()] = span
+				assert.Equal(t, invoke.SpanContext().SpanID(), span.Parent().SpanID())
+			}
+			if tc.fail {
+				// c may be canceled before it starts when b fails.
+				delete(nodes, "c")
+			}
+			require.Len(t, nodes, len(tc.nodes))
+			for _, name := range tc.nodes {
+				span := nodes[name]
+				require.NotNil(t, span, name)
+				assert.True(t, models[span.SpanContext().SpanID()], "model span of %s is a child of its span", name)
+				assert.Contains(t, span.Attributes(), graph.AttributeNode.String(name))
+				if name == "a" {
+					assert.Contains(t, span.Attributes(), graph.AttributeStep.Int(0))
+					assert.NotContains(t, attributeKeys(span), graph.AttributeBranch)
+				} else {
+					assert.Contains(t, span.Attributes(), graph.AttributeStep.Int(1))
+					assert.Contains(t, span.Attributes(), graph.AttributeBranch.String(name))
+				}
+			}
+			if tc.fail {
+				assert.Equal(t, codes.Error, nodes["b"].Status().Code)
+				assert.Equal(t, "boom", nodes["b"].Status().Description)
+			}
+		})
+	}
+}
+
+func TestTracingDisabled(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[int]("a")
+	g.AddNode("a", func(ctx context.Context, state int) (int, error) {
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+		return state, nil
+	})
+	g.AddEdge("a", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), 0)
+	require.NoError(t, err)
+}
+
+func TestTraceRedaction(t *testing.T) {
+	t.Parallel()
+
+	const secret = "sk-abcdefgh1234"
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	r := redact.New(nil, regexp.MustCompile(`sk-[a-z0-9]{8,}`))
+
+	g := graph.NewMessageGraph[int]("call")
+	g.AddNodeWithOptions("call", func(ctx context.Context, state int) (int, error) {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("prompt", "key "+secret), attribute.Int("tokens", 3))
+		return state, fmt.Errorf("rejected key %s", secret)
+	}, graph.WithRetry(2, 0))
+	g.SetFinishPoint("call")
+	runnable, err := g.Compile(graph.WithTracerProvider(tp), graph.WithTraceRedaction(r.String))
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(graph.WithThreadID(context.Background(), secret), 0)
+	require.ErrorContains(t, err, secret, "errors returned are not redacted")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	var recorded []string
+	for _, span := range spans {
+		recorded = append(recorded, span.Status().Description)
+		for _, attr := range span.Attributes() {
+			recorded = append(recorded, attr.Value.Emit())
+		}
+		for _, event := range span.Events() {
+			for _, attr := range event.Attributes {
+				recorded = append(recorded, attr.Value.Emit())
+			}
+		}
+	}
+	text := strings.Join(recorded, "\n")
+	assert.NotContains(t, text, secret)
+	assert.Contains(t, text, "rejected key [REDACTED:")
+	assert.Contains(t, text, "key [REDACTED:")
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("tokens", 3))
+	assert.Len(t, spans[0].Events(), 2, "a retry event and an exception event")
+}
+
+func attributeKeys(span sdktrace.ReadOnlySpan) []attribute.Key {
+	var keys []attribute.Key
+	for _, attr := range span.Attributes() {
+		keys = append(keys, attr.Key)
+	}
+	return keys
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Usage counts the model calls made during a run and the tokens they consumed.
+type Usage struct {
+	// Calls is the number of model calls.
+	Calls int `json:"calls"`
+
+	// InputTokens is the number of prompt tokens, including those read from or written to the
+	// prompt cache of the provider.
+	InputTokens int `json:"input_tokens"`
+
+	// OutputTokens is the number of generated tokens.
+	OutputTokens int `json:"output_tokens"`
+
+	// CacheReadTokens is the number of prompt tokens served from the prompt cache of the provider.
+	CacheReadTokens int `json:"cache_read_tokens,omitempty"`
+
+	// CacheWriteTokens is the number of prompt tokens written to the prompt cache of the provider.
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Calls:            u.Calls + other.Calls,
+		InputTokens:      u.InputTokens + other.InputTokens,
+		OutputTokens:     u.OutputTokens + other.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens + other.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens + other.CacheWriteTokens,
+	}
+}
+
+// Sub returns the difference of u and other, e.g. the usage recorded between two readings of a
+// meter.
+func (u Usage) Sub(other Usage) Usage {
+	return Usage{
+		Calls:            u.Calls - other.Calls,
+		InputTokens:      u.InputTokens - other.InputTokens,
+		OutputTokens:     u.OutputTokens - other.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens - other.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens - other.CacheWriteTokens,
+	}
+}
+
+// CacheHitRate returns the share of input tokens served from the prompt cache, or 0 without
+// input tokens.
+func (u Usage) CacheHitRate() float64 {
+	if u.InputTokens == 0 {
+		return 0
+	}
+	return float64(u.CacheReadTokens) / float64(u.InputTokens)
+}
+
+// UsageMeter sums the usage reported by nodes with RecordUsage, in total and per node. Metering
+// is opt-in: RecordUsage does nothing unless the context carries a meter. The zero value is ready
+// to use and it is safe for concurrent use.
+type UsageMeter struct {
+	// TokenBudget bounds the input and output tokens of the runs metered, when positive: once the
+	// tokens recorded reach it, runs fail with ErrTokenBudgetExceeded before their next step. The
+	// budget is shared by the runs with the meter. It must not change while runs use the meter.
+	TokenBudget int
+
+	mu    sync.Mutex
+	total Usage
+	nodes map[string]Usage
+}
+
+type usageMeterKey struct{}
+
+// WithUsageMeter returns a context making RecordUsage add to m.
+func WithUsageMeter(ctx context.Context, m *UsageMeter) context.Context {
+	return context.WithValue(ctx, usageMeterKey{}, m)
+}
+
+// UsageMeterFrom returns the meter of the context set with WithUsageMeter, or nil.
+func UsageMeterFrom(ctx context.Context) *UsageMeter {
+	m, _ := ctx.Value(usageMeterKey{}).(*UsageMeter)
+	return m
+}
+
+// RecordUsage adds the usage of a model call to the meter of the context, if any, attributed to
+// the node running.
+func RecordUsage(ctx context.Context, u Usage) {
+	m := UsageMeterFrom(ctx)
+	if m == nil {
+		return
+	}
+	node := currentNodeName(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total = m.total.Add(u)
+	if m.nodes == nil {
+		m.nodes = make(map[string]Usage)
+	}
+	m.nodes[node] = m.nodes[node].Add(u)
+}
+
+// Total returns the usage recorded so far.
+func (m *UsageMeter) Total() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.total
+}
+
+// budgetExceeded reports whether the tokens recorded reached the budget of the meter, if any.
+func (m *UsageMeter) budgetExceeded() bool {
+	if m == nil || m.TokenBudget <= 0 {
+		return false
+	}
+	total := m.Total()
+	return total.InputTokens+total.OutputTokens >= m.TokenBudget
+}
+
+// Nodes returns the usage recorded so far by node. Usage recorded outside of nodes is keyed by
+// the empty string.
+func (m *UsageMeter) Nodes() map[string]Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return maps.Clone(m.nodes)
+}
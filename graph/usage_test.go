@@ -0,0 +1,47 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+func TestUsageMeter(t *testing.T) {
+	t.Parallel()
+
+	call := graph.Usage{Calls: 1, InputTokens: 100, OutputTokens: 10, CacheReadTokens: 80}
+	g := graph.NewMessageGraph[int]("a")
+	for _, name := range []string{"a", "b"} {
+		g.AddNode(name, func(ctx context.Context, state int) (int, error) {
+			graph.RecordUsage(ctx, call)
+			return state + 1, nil
+		})
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("b", graph.END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	var meter graph.UsageMeter
+	ctx := graph.WithUsageMeter(context.Background(), &meter)
+	_, err = runnable.Invoke(ctx, 0)
+	require.NoError(t, err)
+	graph.RecordUsage(ctx, graph.Usage{Calls: 1, InputTokens: 20})
+
+	total := meter.Total()
+	assert.Equal(t, graph.Usage{Calls: 3, InputTokens: 220, OutputTokens: 20, CacheReadTokens: 160}, total)
+	assert.InDelta(t, 160.0/220, total.CacheHitRate(), 1e-9)
+	assert.Equal(t, map[string]graph.Usage{"a": call, "b": call, "": {Calls: 1, InputTokens: 20}}, meter.Nodes())
+
+	assert.Equal(t, graph.Usage{Calls: 1, InputTokens: 20}, total.Sub(call).Sub(call))
+	assert.Same(t, &meter, graph.UsageMeterFrom(ctx))
+	assert.Nil(t, graph.UsageMeterFrom(context.Background()))
+
+	graph.RecordUsage(context.Background(), call)
+	assert.Zero(t, graph.Usage{}.CacheHitRate())
+}
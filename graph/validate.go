@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnreachableNode is returned by Compile when a node cannot be reached from the entry point.
+var ErrUnreachableNode = errors.New("node is not reachable from the entry point")
+
+// validate checks the structure of the graph and returns every problem found, joined: nodes
+// named invalidly, nodes without function, edges and routes to missing nodes, nodes without outgoing edge
+// besides their error edge, nodes that cannot be reached from the entry point and parallel or send edges
+// without join.
+func (g *MessageGraph[T]) validate() error {
+	t := g.Topology()
+
+	var errs []error
+	for _, node := range t.Nodes {
+		if err := ValidateName(node); err != nil {
+			errs = append(errs, err)
+		}
+		if g.nodes[node].Function == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNilNodeFunction, node))
+		}
+	}
+	for _, router := range t.Routers {
+		if c := g.conditionalEdges[router]; c.router == nil && c.sender == nil && !c.command {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNilRouter, router))
+		}
+	}
+	for _, edge := range t.Edges {
+		if !t.HasNode(edge.To) {
+			errs = append(errs, fmt.Errorf("edge from %s: %w: %s", edge.From, ErrNodeNotFound, edge.To))
+		}
+	}
+	for _, node := range t.Nodes {
+		if !t.leaves(node) && !t.IsRouter(node) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNoOutgoingEdge, node))
+		}
+		if (len(g.edges[node]) > 1 || g.conditionalEdges[node].sender != nil) && g.join == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrJoinNotSet, node))
+		}
+	}
+
+	// A router without declared routes may lead anywhere.
+	reachable := t.Reachable(t.EntryPoint)
+	for node := range reachable {
+		if t.opaque(node) {
+			return errors.Join(errs...)
+		}
+	}
+	for _, node := range t.Nodes {
+		if !reachable[node] {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnreachableNode, node))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,378 @@
+// Package loadtest replays recorded inputs against a graph server at a configurable concurrency
+// and rate, and reports latency percentiles end to end and per node, to size deployments
+// before launch.
+//
+// Per-node latencies are read from the Server-Timing header set by the app package server;
+// other servers only get end-to-end latencies.
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrNoInputs is returned by Run when there are no inputs to replay.
+var ErrNoInputs = errors.New("no inputs to replay")
+
+// Options configures a load test.
+type Options struct {
+	// URL is the route of the graph, such as http://localhost:8080/support.
+	URL string
+
+	// Inputs are the JSON request bodies replayed in turn.
+	Inputs [][]byte
+
+	// Concurrency is the number of requests in flight at most; one is used when not positive.
+	Concurrency int
+
+	// RPS bounds the rate of requests per second; zero does not bound it.
+	RPS float64
+
+	// Requests is the number of requests sent; when zero, requests are sent for Duration.
+	Requests int
+
+	// Duration is how long requests are sent when Requests is zero.
+	Duration time.Duration
+
+	// Header is added to every request.
+	Header http.Header
+
+	// Client sends the requests; http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// LoadInputs reads recorded inputs from a file holding one JSON body per line. Empty lines are
+// skipped.
+func LoadInputs(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var inputs [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			inputs = append(inputs, bytes.Clone(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return inputs, nil
+}
+
+// Latency summarizes a set of durations.
+type Latency struct {
+	// Count is the number of durations.
+	Count int `json:"count"`
+
+	// Mean is the average duration.
+	Mean time.Duration `json:"mean"`
+
+	// P50 is the median duration.
+	P50 time.Duration `json:"p50"`
+
+	// P90 is the 90th percentile.
+	P90 time.Duration `json:"p90"`
+
+	// P99 is the 99th percentile.
+	P99 time.Duration `json:"p99"`
+
+	// Max is the longest duration.
+	Max time.Duration `json:"max"`
+}
+
+// summarize computes the latency of the durations, which it sorts.
+func summarize(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	percentile := func(p float64) time.Duration {
+		i := int(p*float64(len(durations))+0.999999) - 1
+		return durations[min(max(i, 0), len(durations)-1)]
+	}
+	return Latency{
+		Count: len(durations),
+		Mean:  total / time.Duration(len(durations)),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+		Max:   durations[len(durations)-1],
+	}
+}
+
+// Report is the result of a load test.
+type Report struct {
+	// Requests is the number of requests sent.
+	Requests int `json:"requests"`
+
+	// Errors is the number of requests that failed or got a status other than 2xx.
+	Errors int `json:"errors"`
+
+	// Statuses counts the responses by status code.
+	Statuses map[int]int `json:"statuses"`
+
+	// Elapsed is the duration of the test.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Throughput is the number of successful requests per second.
+	Throughput float64 `json:"throughput"`
+
+	// EndToEnd is the latency of successful requests as seen by the client.
+	EndToEnd Latency `json:"end_to_end"`
+
+	// Nodes is the latency of the node executions of successful requests, by node.
+	Nodes map[string]Latency `json:"nodes,omitempty"`
+}
+
+// String formats the report as a table.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests: %d, errors: %d, elapsed: %s, throughput: %.1f/s\n", r.Requests, r.Errors, r.Elapsed.Round(time.Millisecond), r.Throughput)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCOUNT\tMEAN\tP50\tP90\tP99\tMAX")
+	row := func(name string, l Latency) {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", name, l.Count, l.Mean.Round(time.Microsecond), l.P50.Round(time.Microsecond),
+			l.P90.Round(time.Microsecond), l.P99.Round(time.Microsecond), l.Max.Round(time.Microsecond))
+	}
+	row("(end to end)", r.EndToEnd)
+	nodes := make([]string, 0, len(r.Nodes))
+	for node := range r.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		row(node, r.Nodes[node])
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// result is the outcome of a request.
+type result struct {
+	status   int
+	err      error
+	latency  time.Duration
+	nodes    map[string][]time.Duration
+	finished bool
+}
+
+// Run replays the inputs against the server until the requested number of requests was sent or
+// the duration elapsed, then waits for the requests in flight. Cancelling ctx stops the test
+// early and abandons the requests in flight; the report covers the requests finished by then.
+func Run(ctx context.Context, opts Options) (Report, error) {
+	if len(opts.Inputs) == 0 {
+		return Report{}, ErrNoInputs
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	// Requests in flight when the duration elapses run to completion, so they are sent with
+	// the context of the caller rather than the one bounding the test.
+	requestCtx := ctx
+	if opts.Requests <= 0 && opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var interval time.Duration
+	if opts.RPS > 0 {
+		interval = time.Duration(float64(time.Second) / opts.RPS)
+	}
+
+	results := make(chan result)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, opts.Concurrency)
+	start := time.Now()
+
+	go func() {
+		defer func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		next := start
+		for i := 0; opts.Requests <= 0 || i < opts.Requests; i++ {
+			if interval > 0 {
+				if !sleepUntil(ctx, next) {
+					return
+				}
+				next = next.Add(interval)
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func(body []byte) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				results <- send(requestCtx, opts, body)
+			}(opts.Inputs[i%len(opts.Inputs)])
+		}
+	}()
+
+	report := Report{Statuses: make(map[int]int)}
+	var latencies []time.Duration
+	nodes := make(map[string][]time.Duration)
+	for r := range results {
+		if !r.finished {
+			// Requests interrupted by the end of the test are not counted.
+			continue
+		}
+		report.Requests++
+		if r.status != 0 {
+			report.Statuses[r.status]++
+		}
+		if r.err != nil {
+			report.Errors++
+			continue
+		}
+		latencies = append(latencies, r.latency)
+		for node, durations := range r.nodes {
+			nodes[node] = append(nodes[node], durations...)
+		}
+	}
+
+	report.Elapsed = time.Since(start)
+	report.EndToEnd = summarize(latencies)
+	if report.Elapsed > 0 {
+		report.Throughput = float64(len(latencies)) / report.Elapsed.Seconds()
+	}
+	if len(nodes) > 0 {
+		report.Nodes = make(map[string]Latency, len(nodes))
+		for node, durations := range nodes {
+			report.Nodes[node] = summarize(durations)
+		}
+	}
+	return report, nil
+}
+
+// sleepUntil waits until t and reports whether ctx is still running.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// send posts a body and measures the response.
+func send(ctx context.Context, opts Options, body []byte) result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return result{err: err, finished: true}
+	}
+	for key, values := range opts.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return result{err: err, finished: ctx.Err() == nil}
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return result{status: resp.StatusCode, err: err, finished: ctx.Err() == nil}
+	}
+
+	r := result{status: resp.StatusCode, latency: latency, finished: true}
+	if resp.StatusCode/100 != 2 {
+		r.err = fmt.Errorf("status %s", resp.Status)
+		return r
+	}
+	r.nodes = parseServerTiming(resp.Header.Values("Server-Timing"))
+	return r
+}
+
+// parseServerTiming returns the durations of the "node" metrics of Server-Timing headers, by
+// node name.
+func parseServerTiming(headers []string) map[string][]time.Duration {
+	nodes := make(map[string][]time.Duration)
+	for _, header := range headers {
+		for _, metric := range splitQuoted(header, ',') {
+			params := splitQuoted(metric, ';')
+			if len(params) == 0 || strings.TrimSpace(params[0]) != "node" {
+				continue
+			}
+
+			var node string
+			var dur time.Duration
+			for _, param := range params[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				switch key {
+				case "desc":
+					if unquoted, err := strconv.Unquote(value); err == nil {
+						value = unquoted
+					}
+					node = value
+				case "dur":
+					ms, err := strconv.ParseFloat(value, 64)
+					if err == nil {
+						dur = time.Duration(ms * float64(time.Millisecond))
+					}
+				}
+			}
+			if node != "" {
+				nodes[node] = append(nodes[node], dur)
+			}
+		}
+	}
+	return nodes
+}
+
+// splitQuoted splits s on sep outside of double-quoted strings.
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	quoted, escaped, begin := false, false, 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[begin:i])
+			begin = i + 1
+		}
+	}
+	return append(parts, s[begin:])
+}
@@ -0,0 +1,99 @@
+package loadtest_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/loadtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak, calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `"bad"` {
+			http.Error(w, "bad input", http.StatusBadRequest)
+			return
+		}
+		calls.Add(1)
+		w.Header().Set("Server-Timing", `node;desc="agent";dur=1.5, node;desc="a, \"quoted\" node";dur=2, total;dur=3.5`)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		URL:         server.URL,
+		Inputs:      [][]byte{[]byte(`"one"`), []byte(`"two"`), []byte(`"bad"`)},
+		Concurrency: 3,
+		Requests:    9,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9, report.Requests)
+	assert.Equal(t, 3, report.Errors)
+	assert.Equal(t, map[int]int{http.StatusOK: 6, http.StatusBadRequest: 3}, report.Statuses)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Equal(t, 6, report.EndToEnd.Count)
+	assert.GreaterOrEqual(t, report.EndToEnd.P50, 5*time.Millisecond)
+	assert.Equal(t, loadtest.Latency{Count: 6, Mean: 1500 * time.Microsecond, P50: 1500 * time.Microsecond, P90: 1500 * time.Microsecond, P99: 1500 * time.Microsecond, Max: 1500 * time.Microsecond}, report.Nodes["agent"])
+	assert.Equal(t, 6, report.Nodes[`a, "quoted" node`].Count)
+	assert.Contains(t, report.String(), "requests: 9, errors: 3")
+}
+
+func TestRunRateAndDuration(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		URL:         server.URL,
+		Inputs:      [][]byte{[]byte(`{}`)},
+		Concurrency: 4,
+		RPS:         50,
+		Duration:    200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 10, report.Requests, 3)
+	assert.Zero(t, report.Errors)
+	assert.Nil(t, report.Nodes)
+}
+
+func TestRunWithoutInputs(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadtest.Run(context.Background(), loadtest.Options{URL: "http://localhost"})
+	assert.ErrorIs(t, err, loadtest.ErrNoInputs)
+}
+
+func TestLoadInputs(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "inputs.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("[\"a\"]\n\n  {\"b\": 1}  \n"), 0o600))
+
+	inputs, err := loadtest.LoadInputs(path)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`["a"]`), []byte(`{"b": 1}`)}, inputs)
+}
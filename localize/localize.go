@@ -0,0 +1,243 @@
+// Package localize lets graphs whose prompts are written in a single working language serve
+// users writing in any language: a Localizer translates the inputs of the users to the working
+// language before invoking the graph, and the outputs of the graph back to the language of the
+// user. The language of a user is detected once per thread.
+package localize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+var (
+	// ErrNoWorkingLanguage is returned by New when the configuration has no working language.
+	ErrNoWorkingLanguage = errors.New("no working language")
+
+	// ErrNoText is returned by New when the options lack the text functions and the state is
+	// not a list of messages.
+	ErrNoText = errors.New("no text functions for the state type")
+
+	// ErrNoLanguage is returned when the language of a text cannot be detected.
+	ErrNoLanguage = errors.New("no language detected")
+)
+
+// Config configures localization, e.g. from the localization section of config.Config.
+type Config struct {
+	// WorkingLanguage is the BCP 47 tag of the language of the prompts of the graph, e.g. "en".
+	// Inputs in other languages are translated to it.
+	WorkingLanguage string `yaml:"working_language"`
+
+	// Languages are the languages outputs are translated to. Users writing in other languages
+	// get outputs in the working language. Empty allows every language.
+	Languages []string `yaml:"languages"`
+}
+
+// Text reads and writes a text of a state.
+type Text[T any] struct {
+	// Get returns the text, or "" if the state has none.
+	Get func(state T) string
+
+	// Set returns the state with the text replaced.
+	Set func(state T, text string) T
+}
+
+// Options configures a Localizer.
+type Options[T any] struct {
+	// Input is the input of the user in the states given to Invoke. It defaults to the text of
+	// the last human message for states of type []llms.MessageContent and is required otherwise.
+	Input Text[T]
+
+	// Output is the output for the user in the states returned by the graph. It defaults to the
+	// text of the last AI message for states of type []llms.MessageContent and is required
+	// otherwise.
+	Output Text[T]
+}
+
+// Localizer invokes a graph in its working language on behalf of users writing in other
+// languages. Only the state returned to the caller is translated back: the states of the graph,
+// and its checkpoints, stay in the working language, so the prompts and the history of a thread
+// never mix languages.
+type Localizer[T any] struct {
+	runnable   *graph.Runnable[T]
+	translator Translator
+	cfg        Config
+	opts       Options[T]
+
+	mu sync.Mutex
+	// languages are the languages of the users, by thread.
+	languages map[string]string
+}
+
+// New returns a Localizer invoking r according to cfg, with translator detecting and
+// translating languages.
+func New[T any](r *graph.Runnable[T], translator Translator, cfg Config, opts Options[T]) (*Localizer[T], error) {
+	if cfg.WorkingLanguage == "" {
+		return nil, ErrNoWorkingLanguage
+	}
+	if opts.Input.Get == nil || opts.Input.Set == nil || opts.Output.Get == nil || opts.Output.Set == nil {
+		if _, ok := any(*new(T)).([]llms.MessageContent); !ok {
+			return nil, ErrNoText
+		}
+		if opts.Input.Get == nil || opts.Input.Set == nil {
+			opts.Input = messageText[T](llms.ChatMessageTypeHuman)
+		}
+		if opts.Output.Get == nil || opts.Output.Set == nil {
+			opts.Output = messageText[T](llms.ChatMessageTypeAI)
+		}
+	}
+	return &Localizer[T]{runnable: r, translator: translator, cfg: cfg, opts: opts, languages: make(map[string]string)}, nil
+}
+
+// Invoke invokes the graph as graph.Runnable.Invoke does. The input of state is translated to
+// the working language, and the output of the returned state to the language of the user, also
+// when the run is interrupted. The language of the user is detected from the input of the first
+// run of the thread of ctx, set with graph.WithThreadID, and from every input without thread.
+func (l *Localizer[T]) Invoke(ctx context.Context, state T) (T, error) {
+	language := l.cfg.WorkingLanguage
+	if input := l.opts.Input.Get(state); input != "" {
+		var err error
+		if language, err = l.language(ctx, input); err != nil {
+			return state, err
+		}
+		if !Same(language, l.cfg.WorkingLanguage) {
+			translation, err := l.translator.Translate(ctx, input, language, l.cfg.WorkingLanguage)
+			if err != nil {
+				return state, fmt.Errorf("translating input: %w", err)
+			}
+			state = l.opts.Input.Set(state, translation)
+		}
+	} else if threadID := graph.ThreadID(ctx); threadID != "" {
+		if cached, ok := l.Language(threadID); ok {
+			language = cached
+		}
+	}
+
+	out, err := l.runnable.Invoke(ctx, state)
+	if err != nil && !errors.Is(err, graph.ErrInterrupted) {
+		return out, err
+	}
+	if !l.supported(language) || Same(language, l.cfg.WorkingLanguage) {
+		return out, err
+	}
+	output := l.opts.Output.Get(out)
+	if output == "" {
+		return out, err
+	}
+	translation, translateErr := l.translator.Translate(ctx, output, l.cfg.WorkingLanguage, language)
+	if translateErr != nil {
+		return out, fmt.Errorf("translating output: %w", translateErr)
+	}
+	return l.opts.Output.Set(out, translation), err
+}
+
+// Language returns the language detected for the users of the thread, if any.
+func (l *Localizer[T]) Language(threadID string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	language, ok := l.languages[threadID]
+	return language, ok
+}
+
+// SetLanguage sets the language of the users of the thread, e.g. from the Accept-Language
+// header of their requests, instead of detecting it.
+func (l *Localizer[T]) SetLanguage(threadID, language string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.languages[threadID] = language
+}
+
+// language returns the language of the user of the run of ctx, detecting it from input unless
+// cached for its thread.
+func (l *Localizer[T]) language(ctx context.Context, input string) (string, error) {
+	threadID := graph.ThreadID(ctx)
+	if threadID != "" {
+		if language, ok := l.Language(threadID); ok {
+			return language, nil
+		}
+	}
+	language, err := l.translator.Detect(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if threadID != "" {
+		l.SetLanguage(threadID, language)
+	}
+	return language, nil
+}
+
+// supported reports whether outputs are translated to language.
+func (l *Localizer[T]) supported(language string) bool {
+	return len(l.cfg.Languages) == 0 || slices.ContainsFunc(l.cfg.Languages, func(supported string) bool {
+		return Same(supported, language)
+	})
+}
+
+// Same reports whether the language tags a and b name the same language, comparing their
+// primary subtags case-insensitively: "en-US" and "EN" are the same language.
+func Same(a, b string) bool {
+	return strings.EqualFold(primary(a), primary(b))
+}
+
+func primary(tag string) string {
+	tag, _, _ = strings.Cut(strings.TrimSpace(tag), "-")
+	tag, _, _ = strings.Cut(tag, "_")
+	return tag
+}
+
+// messageText returns the text of the last message of the role of states of type
+// []llms.MessageContent.
+func messageText[T any](role llms.ChatMessageType) Text[T] {
+	last := func(msgs []llms.MessageContent) int {
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].Role == role {
+				return i
+			}
+		}
+		return -1
+	}
+	return Text[T]{
+		Get: func(state T) string {
+			msgs, _ := any(state).([]llms.MessageContent)
+			i := last(msgs)
+			if i < 0 {
+				return ""
+			}
+			var texts []string
+			for _, part := range msgs[i].Parts {
+				if text, ok := part.(llms.TextContent); ok {
+					texts = append(texts, text.Text)
+				}
+			}
+			return strings.Join(texts, "\n")
+		},
+		Set: func(state T, text string) T {
+			msgs, _ := any(state).([]llms.MessageContent)
+			i := last(msgs)
+			if i < 0 {
+				return state
+			}
+			// The text parts are replaced by the text, where the first one was.
+			parts := make([]llms.ContentPart, 0, len(msgs[i].Parts))
+			replaced := false
+			for _, part := range msgs[i].Parts {
+				if _, ok := part.(llms.TextContent); !ok {
+					parts = append(parts, part)
+				} else if !replaced {
+					parts = append(parts, llms.TextContent{Text: text})
+					replaced = true
+				}
+			}
+			msgs = slices.Clone(msgs)
+			msgs[i].Parts = parts
+			return any(msgs).(T)
+		},
+	}
+}
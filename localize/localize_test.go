@@ -0,0 +1,233 @@
+package localize_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/localize"
+)
+
+// translator detects French from "bonjour" and English otherwise, prefixes translations with
+// their target language, and records its calls.
+type translator struct {
+	mu      sync.Mutex
+	detects int
+	calls   []string
+}
+
+func (t *translator) Detect(_ context.Context, text string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.detects++
+	if strings.Contains(strings.ToLower(text), "bonjour") {
+		return "fr-FR", nil
+	}
+	return "en", nil
+}
+
+func (t *translator) Translate(_ context.Context, text, from, to string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, from+">"+to)
+	return "[" + to + "] " + text, nil
+}
+
+// echoGraph returns a graph answering with the last message it received, and the states it
+// received.
+func echoGraph(t *testing.T, opts ...graph.CompileOption) (*graph.Runnable[[]llms.MessageContent], *[]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var inputs []string
+	g := graph.NewMessageGraph[[]llms.MessageContent]("reply")
+	g.AddNode("reply", func(_ context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+		text := state[len(state)-1].Parts[0].(llms.TextContent).Text
+		mu.Lock()
+		inputs = append(inputs, text)
+		mu.Unlock()
+		return append(state, llms.TextParts(llms.ChatMessageTypeAI, "you said: "+text)), nil
+	})
+	g.SetFinishPoint("reply")
+	runnable, err := g.Compile(opts...)
+	require.NoError(t, err)
+	return runnable, &inputs
+}
+
+func human(text string) []llms.MessageContent {
+	return []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, text)}
+}
+
+func lastText(msgs []llms.MessageContent) string {
+	return msgs[len(msgs)-1].Parts[0].(llms.TextContent).Text
+}
+
+func TestLocalizer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		cfg       localize.Config
+		input     string
+		expected  string
+		graphSaw  string
+		translate []string
+	}{
+		{
+			name:     "working language",
+			cfg:      localize.Config{WorkingLanguage: "en-US"},
+			input:    "hello",
+			expected: "you said: hello",
+			graphSaw: "hello",
+		},
+		{
+			name:      "other language",
+			cfg:       localize.Config{WorkingLanguage: "en"},
+			input:     "bonjour",
+			expected:  "[fr-FR] you said: [en] bonjour",
+			graphSaw:  "[en] bonjour",
+			translate: []string{"fr-FR>en", "en>fr-FR"},
+		},
+		{
+			name:      "supported language",
+			cfg:       localize.Config{WorkingLanguage: "en", Languages: []string{"de", "fr"}},
+			input:     "bonjour",
+			expected:  "[fr-FR] you said: [en] bonjour",
+			graphSaw:  "[en] bonjour",
+			translate: []string{"fr-FR>en", "en>fr-FR"},
+		},
+		{
+			name:      "unsupported language",
+			cfg:       localize.Config{WorkingLanguage: "en", Languages: []string{"de"}},
+			input:     "bonjour",
+			expected:  "you said: [en] bonjour",
+			graphSaw:  "[en] bonjour",
+			translate: []string{"fr-FR>en"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runnable, inputs := echoGraph(t)
+			tr := &translator{}
+			l, err := localize.New(runnable, tr, tc.cfg, localize.Options[[]llms.MessageContent]{})
+			require.NoError(t, err)
+
+			out, err := l.Invoke(context.Background(), human(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, lastText(out))
+			assert.Equal(t, []string{tc.graphSaw}, *inputs)
+			assert.Equal(t, tc.translate, tr.calls)
+		})
+	}
+}
+
+func TestLocalizerThread(t *testing.T) {
+	t.Parallel()
+
+	cp := checkpoint.NewMemory()
+	runnable, _ := echoGraph(t, graph.WithCheckpointer(cp))
+	tr := &translator{}
+	l, err := localize.New(runnable, tr, localize.Config{WorkingLanguage: "en"}, localize.Options[[]llms.MessageContent]{})
+	require.NoError(t, err)
+	ctx := graph.WithThreadID(context.Background(), "thread")
+
+	out, err := l.Invoke(ctx, human("bonjour"))
+	require.NoError(t, err)
+	assert.Equal(t, "[fr-FR] you said: [en] bonjour", lastText(out))
+
+	// The language is detected once per thread.
+	out, err = l.Invoke(ctx, human("merci"))
+	require.NoError(t, err)
+	assert.Equal(t, "[fr-FR] you said: [en] merci", lastText(out))
+	assert.Equal(t, 1, tr.detects)
+	language, ok := l.Language("thread")
+	assert.True(t, ok)
+	assert.Equal(t, "fr-FR", language)
+
+	// The checkpoints stay in the working language.
+	saved, err := runnable.GetState(ctx, "thread")
+	require.NoError(t, err)
+	assert.Equal(t, "you said: [en] merci", lastText(saved.State))
+
+	// A language set explicitly is not detected.
+	l.SetLanguage("other", "de")
+	out, err = l.Invoke(graph.WithThreadID(context.Background(), "other"), human("bonjour"))
+	require.NoError(t, err)
+	assert.Equal(t, "[de] you said: [en] bonjour", lastText(out))
+	assert.Equal(t, 1, tr.detects)
+}
+
+func TestLocalizerText(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Question, Answer string
+	}
+	g := graph.NewMessageGraph[state]("answer")
+	g.AddNode("answer", func(_ context.Context, s state) (state, error) {
+		s.Answer = "answer to " + s.Question
+		return s, nil
+	})
+	g.SetFinishPoint("answer")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = localize.New(runnable, &translator{}, localize.Config{WorkingLanguage: "en"}, localize.Options[state]{})
+	require.ErrorIs(t, err, localize.ErrNoText)
+	_, err = localize.New(runnable, &translator{}, localize.Config{}, localize.Options[state]{})
+	require.ErrorIs(t, err, localize.ErrNoWorkingLanguage)
+
+	l, err := localize.New(runnable, &translator{}, localize.Config{WorkingLanguage: "en"}, localize.Options[state]{
+		Input: localize.Text[state]{
+			Get: func(s state) string { return s.Question },
+			Set: func(s state, text string) state { s.Question = text; return s },
+		},
+		Output: localize.Text[state]{
+			Get: func(s state) string { return s.Answer },
+			Set: func(s state, text string) state { s.Answer = text; return s },
+		},
+	})
+	require.NoError(t, err)
+	out, err := l.Invoke(context.Background(), state{Question: "bonjour"})
+	require.NoError(t, err)
+	assert.Equal(t, state{Question: "[en] bonjour", Answer: "[fr-FR] answer to [en] bonjour"}, out)
+}
+
+func TestLocalizerError(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[[]llms.MessageContent]("fail")
+	g.AddNode("fail", func(_ context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+		return append(state, llms.TextParts(llms.ChatMessageTypeAI, "partial")), errors.New("failed")
+	})
+	g.SetFinishPoint("fail")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	tr := &translator{}
+	l, err := localize.New(runnable, tr, localize.Config{WorkingLanguage: "en"}, localize.Options[[]llms.MessageContent]{})
+	require.NoError(t, err)
+
+	// The outputs of failed runs are not translated.
+	_, err = l.Invoke(context.Background(), human("bonjour"))
+	require.ErrorContains(t, err, "failed")
+	assert.Equal(t, []string{"fr-FR>en"}, tr.calls)
+}
+
+func TestSame(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, localize.Same("en-US", "EN"))
+	assert.True(t, localize.Same("pt_BR", "pt-PT"))
+	assert.False(t, localize.Same("en", "fr"))
+}
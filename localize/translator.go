@@ -0,0 +1,75 @@
+package localize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// DefaultDetectPrompt are the instructions detecting the language of texts.
+	DefaultDetectPrompt = "Answer with the BCP 47 language tag, such as en or pt-BR, of the language of the following text, and nothing else."
+
+	// DefaultTranslatePrompt are the instructions translating texts, formatted with the source
+	// and the target languages.
+	DefaultTranslatePrompt = "Translate the following text from the language %s to the language %s, keeping its meaning, tone and formatting. Answer with the translation only."
+)
+
+// Translator detects the languages of texts and translates them.
+type Translator interface {
+	// Detect returns the BCP 47 tag of the language of text, e.g. "en" or "pt-BR".
+	Detect(ctx context.Context, text string) (string, error)
+
+	// Translate translates text from the language from to the language to.
+	Translate(ctx context.Context, text, from, to string) (string, error)
+}
+
+// ModelTranslator is a Translator prompting a model, typically a small and cheap one.
+type ModelTranslator struct {
+	model llms.Model
+
+	// DetectPrompt are the instructions detecting languages; DefaultDetectPrompt if empty.
+	DetectPrompt string
+
+	// TranslatePrompt are the instructions translating texts; DefaultTranslatePrompt if empty.
+	TranslatePrompt string
+}
+
+var _ Translator = (*ModelTranslator)(nil)
+
+// NewModelTranslator returns a ModelTranslator prompting model.
+func NewModelTranslator(model llms.Model) *ModelTranslator {
+	return &ModelTranslator{model: model}
+}
+
+// Detect asks the model for the language of text.
+func (t *ModelTranslator) Detect(ctx context.Context, text string) (string, error) {
+	prompt := t.DetectPrompt
+	if prompt == "" {
+		prompt = DefaultDetectPrompt
+	}
+	answer, err := llms.GenerateFromSinglePrompt(ctx, t.model, prompt+"\n\nText:\n"+text)
+	if err != nil {
+		return "", fmt.Errorf("detecting language: %w", err)
+	}
+	language := strings.Trim(strings.TrimSpace(answer), "\"'`.")
+	if language == "" {
+		return "", fmt.Errorf("%w: empty answer", ErrNoLanguage)
+	}
+	return language, nil
+}
+
+// Translate asks the model for the translation of text.
+func (t *ModelTranslator) Translate(ctx context.Context, text, from, to string) (string, error) {
+	prompt := t.TranslatePrompt
+	if prompt == "" {
+		prompt = DefaultTranslatePrompt
+	}
+	translation, err := llms.GenerateFromSinglePrompt(ctx, t.model, fmt.Sprintf(prompt, from, to)+"\n\nText:\n"+text)
+	if err != nil {
+		return "", fmt.Errorf("translating from %s to %s: %w", from, to, err)
+	}
+	return strings.TrimSpace(translation), nil
+}
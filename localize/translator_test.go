@@ -0,0 +1,56 @@
+package localize_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/localize"
+)
+
+// model answers detection prompts with a quoted tag and translation prompts with the prompt,
+// upper-cased.
+type model struct {
+	answer string
+	err    error
+}
+
+func (m *model) GenerateContent(_ context.Context, msgs []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	prompt := msgs[0].Parts[0].(llms.TextContent).Text
+	answer := m.answer
+	if answer == "" {
+		answer = strings.ToUpper(prompt[strings.LastIndex(prompt, "\n")+1:]) + "\n"
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: answer}}}, nil
+}
+
+func (m *model) Call(ctx context.Context, prompt string, opts ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, opts...)
+}
+
+func TestModelTranslator(t *testing.T) {
+	t.Parallel()
+
+	language, err := localize.NewModelTranslator(&model{answer: " \"fr\".\n"}).Detect(context.Background(), "bonjour")
+	require.NoError(t, err)
+	assert.Equal(t, "fr", language)
+
+	_, err = localize.NewModelTranslator(&model{answer: "``"}).Detect(context.Background(), "bonjour")
+	require.ErrorIs(t, err, localize.ErrNoLanguage)
+
+	translation, err := localize.NewModelTranslator(&model{}).Translate(context.Background(), "bonjour", "fr", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "BONJOUR", translation)
+
+	down := errors.New("model down")
+	_, err = localize.NewModelTranslator(&model{err: down}).Translate(context.Background(), "bonjour", "fr", "en")
+	require.ErrorIs(t, err, down)
+}
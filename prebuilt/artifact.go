@@ -0,0 +1,78 @@
+package prebuilt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/cesto93/langgraphgo/artifact"
+)
+
+// ToolResult is the result of an ArtifactTool: text for the model and typed artifacts.
+type ToolResult struct {
+	// Text is sent to the model, followed by the summaries of the artifacts.
+	Text string
+
+	// Artifacts are the rich results of the call, such as tables, files or images.
+	Artifacts []artifact.Artifact
+}
+
+// ArtifactTool is implemented by tools returning artifacts. The tools node of CreateReactAgent
+// calls CallWithArtifacts instead of Call, stores the artifacts in the artifact.Store of the
+// context and sends the model their summaries with references to them; see artifact.Refs.
+// Without store, only the summaries are sent.
+type ArtifactTool interface {
+	tools.Tool
+
+	// CallWithArtifacts runs the tool with the input of a call.
+	CallWithArtifacts(ctx context.Context, input string) (ToolResult, error)
+}
+
+// storeArtifacts stores the artifacts of the result in the store of the context, if any, and
+// returns the content of the tool response: the text of the result and a line per artifact.
+func storeArtifacts(ctx context.Context, result ToolResult) (string, error) {
+	store := artifact.StoreFromContext(ctx)
+	lines := []string{result.Text}
+	for _, a := range result.Artifacts {
+		line := "[" + a.Describe() + "]"
+		if store != nil {
+			id, err := store.Put(ctx, a)
+			if err != nil {
+				return "", fmt.Errorf("storing artifact %s: %w", a.Name, err)
+			}
+			line = "[" + a.Describe() + ": " + artifact.Ref(id) + "]"
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimPrefix(strings.Join(lines, "\n"), "\n"), nil
+}
+
+// Artifacts returns the artifacts referenced by the tool responses and text of a message, read
+// from the artifact.Store of the context.
+func Artifacts(ctx context.Context, msg llms.MessageContent) ([]artifact.Artifact, error) {
+	store := artifact.StoreFromContext(ctx)
+	var artifacts []artifact.Artifact
+	for _, part := range msg.Parts {
+		var text string
+		switch part := part.(type) {
+		case llms.TextContent:
+			text = part.Text
+		case llms.ToolCallResponse:
+			text = part.Content
+		}
+		for _, id := range artifact.Refs(text) {
+			if store == nil {
+				return nil, fmt.Errorf("%w: %s: no store", artifact.ErrNotFound, id)
+			}
+			a, err := store.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts, nil
+}
@@ -0,0 +1,101 @@
+package prebuilt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/cesto93/langgraphgo/artifact"
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+// report is a tool returning a table and a chart of its input.
+type report struct{}
+
+func (report) Name() string        { return "report" }
+func (report) Description() string { return "Reports sales." }
+
+func (report) Call(context.Context, string) (string, error) {
+	panic("report is called with artifacts")
+}
+
+func (report) CallWithArtifacts(_ context.Context, input string) (prebuilt.ToolResult, error) {
+	return prebuilt.ToolResult{
+		Text: "Sales of " + input + ":",
+		Artifacts: []artifact.Artifact{
+			{Kind: artifact.KindTable, Name: "sales", Table: &artifact.Table{Columns: []string{"month", "total"}, Rows: [][]string{{"jan", "3"}}}},
+			{Kind: artifact.KindImage, Name: "chart.png", MIMEType: "image/png", Data: []byte("png"), Summary: "chart of the sales"},
+		},
+	}, nil
+}
+
+func TestArtifactTools(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		store    bool
+		expected string
+	}{
+		{
+			name:     "stored",
+			store:    true,
+			expected: "Sales of 2024:\n[table sales: 1 rows, columns month, total: artifact://art-1]\n[chart of the sales: artifact://art-2]",
+		},
+		{
+			name:     "without store",
+			expected: "Sales of 2024:\n[table sales: 1 rows, columns month, total]\n[chart of the sales]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &scriptedModel{responses: []*llms.ContentResponse{
+				respond("", toolCall("c1", "report", `{"input":"2024"}`)),
+				respond("done"),
+			}}
+			agent, err := prebuilt.CreateReactAgent(model, []tools.Tool{report{}})
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			store := artifact.NewMemory()
+			if tc.store {
+				ctx = artifact.WithStore(ctx, store)
+			}
+			out, err := agent.Invoke(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "report")})
+			require.NoError(t, err)
+			require.Len(t, out, 4)
+			response, ok := out[2].Parts[0].(llms.ToolCallResponse)
+			require.True(t, ok)
+			assert.Equal(t, tc.expected, response.Content)
+
+			artifacts, err := prebuilt.Artifacts(ctx, out[2])
+			if !tc.store {
+				require.NoError(t, err)
+				assert.Empty(t, artifacts)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, artifacts, 2)
+			assert.Equal(t, "art-1", artifacts[0].ID)
+			assert.Equal(t, [][]string{{"jan", "3"}}, artifacts[0].Table.Rows)
+			assert.Equal(t, []byte("png"), artifacts[1].Data)
+		})
+	}
+}
+
+func TestArtifactsNotFound(t *testing.T) {
+	t.Parallel()
+
+	msg := llms.TextParts(llms.ChatMessageTypeAI, "see artifact://art-9")
+	_, err := prebuilt.Artifacts(artifact.WithStore(context.Background(), artifact.NewMemory()), msg)
+	require.ErrorIs(t, err, artifact.ErrNotFound)
+	_, err = prebuilt.Artifacts(context.Background(), msg)
+	require.ErrorIs(t, err, artifact.ErrNotFound)
+}
@@ -0,0 +1,108 @@
+package prebuilt
+
+import (
+	"context"
+	"slices"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// CacheBreakpoint marks the end of a static prefix of the prompts of an LLM node that the
+// provider may cache between calls.
+type CacheBreakpoint string
+
+const (
+	// CacheSystemPrompt marks the end of the system prompt set with WithSystemPrompt.
+	CacheSystemPrompt CacheBreakpoint = "system"
+
+	// CacheTools marks the end of the tool definitions.
+	CacheTools CacheBreakpoint = "tools"
+
+	// CacheHistory marks the end of the message history, so the next turn of a conversation
+	// reads the history up to this call from the cache.
+	CacheHistory CacheBreakpoint = "history"
+)
+
+// WithPromptCaching asks the provider to cache the prompts of the node up to the given
+// breakpoints, CacheSystemPrompt and CacheTools if none is given, cutting the cost and latency of
+// long static prompts.
+//
+// Providers caching explicitly, like Anthropic, need markers in their requests: the breakpoints
+// are passed to the model through the context of every call, where models wrapping a provider
+// client read them with CacheBreakpoints. Providers caching long prefixes automatically, like
+// OpenAI, need no marker. The tokens read from and written to the cache are reported to the
+// graph.UsageMeter of the run either way.
+func WithPromptCaching(breakpoints ...CacheBreakpoint) LLMNodeOption {
+	if len(breakpoints) == 0 {
+		breakpoints = []CacheBreakpoint{CacheSystemPrompt, CacheTools}
+	}
+	return func(o *llmNodeOptions) { o.breakpoints = slices.Clone(breakpoints) }
+}
+
+type cacheBreakpointsKey struct{}
+
+// CacheBreakpoints returns the breakpoints of the prompt cache requested by the LLM node calling
+// the model with ctx, if any; see WithPromptCaching.
+func CacheBreakpoints(ctx context.Context) []CacheBreakpoint {
+	breakpoints, _ := ctx.Value(cacheBreakpointsKey{}).([]CacheBreakpoint)
+	return slices.Clone(breakpoints)
+}
+
+// withCacheBreakpoints returns a context carrying the breakpoints, or ctx if there are none.
+func withCacheBreakpoints(ctx context.Context, breakpoints []CacheBreakpoint) context.Context {
+	if len(breakpoints) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheBreakpointsKey{}, breakpoints)
+}
+
+// Keys of the generation info of the first choice of responses read by UsageOf, by provider
+// convention: langchaingo models and the snake case of the provider APIs.
+var (
+	inputTokenKeys      = []string{"InputTokens", "PromptTokens", "input_tokens", "prompt_tokens"}
+	outputTokenKeys     = []string{"OutputTokens", "CompletionTokens", "output_tokens", "completion_tokens"}
+	cacheReadTokenKeys  = []string{"CacheReadInputTokens", "CachedTokens", "cache_read_input_tokens", "cached_tokens"}
+	cacheWriteTokenKeys = []string{"CacheCreationInputTokens", "cache_creation_input_tokens"}
+)
+
+// UsageOf returns the usage of a model call from the generation info of the first choice of its
+// response, where providers report the token counts of the whole call. Counts not reported are
+// zero.
+//
+// Providers reporting cache writes, like Anthropic, count cache reads and writes apart from the
+// other input tokens; they are added to InputTokens so it counts the whole prompt for every
+// provider.
+func UsageOf(resp *llms.ContentResponse) graph.Usage {
+	usage := graph.Usage{Calls: 1}
+	if resp == nil || len(resp.Choices) == 0 || resp.Choices[0] == nil {
+		return usage
+	}
+	info := resp.Choices[0].GenerationInfo
+	usage.InputTokens = tokens(info, inputTokenKeys)
+	usage.OutputTokens = tokens(info, outputTokenKeys)
+	usage.CacheReadTokens = tokens(info, cacheReadTokenKeys)
+	usage.CacheWriteTokens = tokens(info, cacheWriteTokenKeys)
+	if slices.ContainsFunc(cacheWriteTokenKeys, func(key string) bool { return info[key] != nil }) {
+		usage.InputTokens += usage.CacheReadTokens + usage.CacheWriteTokens
+	}
+	return usage
+}
+
+// tokens returns the first count of info found under one of keys, or 0.
+func tokens(info map[string]any, keys []string) int {
+	for _, key := range keys {
+		switch n := info[key].(type) {
+		case int:
+			return n
+		case int32:
+			return int(n)
+		case int64:
+			return int(n)
+		case float64:
+			return int(n)
+		}
+	}
+	return 0
+}
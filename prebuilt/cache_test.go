@@ -0,0 +1,123 @@
+package prebuilt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+// cachingModel records the cache breakpoints of every call.
+type cachingModel struct {
+	scriptedModel
+	breakpoints [][]prebuilt.CacheBreakpoint
+}
+
+func (m *cachingModel) GenerateContent(ctx context.Context, msgs []llms.MessageContent, opts ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.breakpoints = append(m.breakpoints, prebuilt.CacheBreakpoints(ctx))
+	return m.scriptedModel.GenerateContent(ctx, msgs, opts...)
+}
+
+func TestPromptCaching(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		opts     []prebuilt.LLMNodeOption
+		expected []prebuilt.CacheBreakpoint
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:     "default breakpoints",
+			opts:     []prebuilt.LLMNodeOption{prebuilt.WithPromptCaching()},
+			expected: []prebuilt.CacheBreakpoint{prebuilt.CacheSystemPrompt, prebuilt.CacheTools},
+		},
+		{
+			name:     "history",
+			opts:     []prebuilt.LLMNodeOption{prebuilt.WithPromptCaching(prebuilt.CacheHistory)},
+			expected: []prebuilt.CacheBreakpoint{prebuilt.CacheHistory},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := respond("hello")
+			resp.Choices[0].GenerationInfo = map[string]any{
+				"InputTokens":              10,
+				"OutputTokens":             5,
+				"CacheReadInputTokens":     100,
+				"CacheCreationInputTokens": 20,
+			}
+			model := &cachingModel{scriptedModel: scriptedModel{responses: []*llms.ContentResponse{resp}}}
+			node := prebuilt.NewLLMNode(model, tc.opts...)
+
+			var meter graph.UsageMeter
+			_, err := node(graph.WithUsageMeter(context.Background(), &meter), nil)
+			require.NoError(t, err)
+			assert.Equal(t, [][]prebuilt.CacheBreakpoint{tc.expected}, model.breakpoints)
+			assert.Equal(t, graph.Usage{
+				Calls:            1,
+				InputTokens:      130,
+				OutputTokens:     5,
+				CacheReadTokens:  100,
+				CacheWriteTokens: 20,
+			}, meter.Total())
+		})
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		info     map[string]any
+		expected graph.Usage
+	}{
+		{
+			name:     "no info",
+			expected: graph.Usage{Calls: 1},
+		},
+		{
+			name:     "openai",
+			info:     map[string]any{"PromptTokens": 1200, "CompletionTokens": 30, "CachedTokens": 1024},
+			expected: graph.Usage{Calls: 1, InputTokens: 1200, OutputTokens: 30, CacheReadTokens: 1024},
+		},
+		{
+			name:     "anthropic",
+			info:     map[string]any{"InputTokens": 12, "OutputTokens": 30, "CacheReadInputTokens": 0, "CacheCreationInputTokens": 2048},
+			expected: graph.Usage{Calls: 1, InputTokens: 2060, OutputTokens: 30, CacheWriteTokens: 2048},
+		},
+		{
+			name:     "googleai",
+			info:     map[string]any{"input_tokens": int32(7), "output_tokens": int32(3)},
+			expected: graph.Usage{Calls: 1, InputTokens: 7, OutputTokens: 3},
+		},
+		{
+			name:     "decoded JSON",
+			info:     map[string]any{"prompt_tokens": 7.0, "completion_tokens": 3.0, "cached_tokens": 4.0},
+			expected: graph.Usage{Calls: 1, InputTokens: 7, OutputTokens: 3, CacheReadTokens: 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := respond("hello")
+			resp.Choices[0].GenerationInfo = tc.info
+			assert.Equal(t, tc.expected, prebuilt.UsageOf(resp))
+		})
+	}
+
+	assert.Equal(t, graph.Usage{Calls: 1}, prebuilt.UsageOf(&llms.ContentResponse{}))
+}
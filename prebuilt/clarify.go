@@ -0,0 +1,162 @@
+// Package prebuilt contains ready-to-use graph patterns built on top of the graph package.
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// DefaultClarificationAttempts is the number of clarifying questions asked when
+// ClarificationConfig.MaxAttempts is not set.
+const DefaultClarificationAttempts = 3
+
+// ClarifyNode is the name of the node added by CreateClarificationLoop.
+const ClarifyNode = "clarify"
+
+var (
+	// ErrClarificationBudgetExceeded is returned when required fields are still missing
+	// after the configured number of clarifying questions.
+	ErrClarificationBudgetExceeded = errors.New("clarification budget exceeded")
+
+	// ErrInvalidClarificationConfig is returned when a required callback is not set.
+	ErrInvalidClarificationConfig = errors.New("invalid clarification config")
+)
+
+// ClarificationConfig configures a clarification-question loop.
+//
+// The loop does not wait for the answers: it records each question in the state and pauses, so
+// the state, saved to a checkpointer, outlives the process until the user answers; see
+// CreateClarificationLoop and AnswerClarification.
+type ClarificationConfig[T any] struct {
+	// Missing returns the names of the required fields that are not filled in the state.
+	Missing func(state T) []string
+
+	// Question builds the clarifying question asked for the missing fields.
+	Question func(ctx context.Context, state T, missing []string) (string, error)
+
+	// Ask records the question in the state, e.g. as an assistant message, for the caller to
+	// deliver to the user once execution paused.
+	Ask func(ctx context.Context, state T, question string) (T, error)
+
+	// Asked returns the number of questions recorded in the state by Ask, which the attempt
+	// budget applies to.
+	Asked func(state T) int
+
+	// Merge merges the user's answer into the state.
+	Merge func(ctx context.Context, state T, answer string) (T, error)
+
+	// MaxAttempts is the maximum number of questions asked before giving up.
+	// Zero means DefaultClarificationAttempts.
+	MaxAttempts int
+}
+
+func (c ClarificationConfig[T]) validate() error {
+	switch {
+	case c.Missing == nil:
+		return fmt.Errorf("%w: Missing is required", ErrInvalidClarificationConfig)
+	case c.Question == nil:
+		return fmt.Errorf("%w: Question is required", ErrInvalidClarificationConfig)
+	case c.Ask == nil:
+		return fmt.Errorf("%w: Ask is required", ErrInvalidClarificationConfig)
+	case c.Asked == nil:
+		return fmt.Errorf("%w: Asked is required", ErrInvalidClarificationConfig)
+	case c.Merge == nil:
+		return fmt.Errorf("%w: Merge is required", ErrInvalidClarificationConfig)
+	case c.MaxAttempts < 0:
+		return fmt.Errorf("%w: MaxAttempts must not be negative", ErrInvalidClarificationConfig)
+	}
+	return nil
+}
+
+// NewClarificationNode returns a node function that asks the user a clarifying question when
+// Missing reports missing fields, by recording it in the state with Ask, and fails with
+// ErrClarificationBudgetExceeded once the attempt budget is exhausted.
+//
+// Graphs using the node route it back to itself while fields are missing and pause after it
+// with graph.WithInterruptAfter, for the answer to be merged into the state before resuming, as
+// CreateClarificationLoop does.
+func NewClarificationNode[T any](cfg ClarificationConfig[T]) (func(ctx context.Context, state T) (T, error), error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultClarificationAttempts
+	}
+
+	return func(ctx context.Context, state T) (T, error) {
+		missing := cfg.Missing(state)
+		if len(missing) == 0 {
+			return state, nil
+		}
+		if asked := cfg.Asked(state); asked >= maxAttempts {
+			return state, fmt.Errorf("%w: missing %v after %d questions", ErrClarificationBudgetExceeded, missing, asked)
+		}
+
+		question, err := cfg.Question(ctx, state, missing)
+		if err != nil {
+			return state, fmt.Errorf("building question: %w", err)
+		}
+
+		state, err = cfg.Ask(ctx, state, question)
+		if err != nil {
+			return state, fmt.Errorf("asking question: %w", err)
+		}
+		return state, nil
+	}, nil
+}
+
+// CreateClarificationLoop returns a compiled graph consisting of a single clarification node
+// that fills the required fields of the state before finishing, compiled with opts.
+//
+// While fields are missing, invocations pause after asking a question: they return the state
+// holding the question with an error matching graph.ErrInterrupted. With a checkpointer set by
+// graph.WithCheckpointer and a thread set by graph.WithThreadID, the paused state is saved to
+// the thread, and AnswerClarification resumes it, from any process sharing the checkpointer.
+// Without a checkpointer, the returned *graph.Interrupt is resumed with Resume, after merging
+// the answer into the state with Merge.
+func CreateClarificationLoop[T any](cfg ClarificationConfig[T], opts ...graph.CompileOption) (*graph.Runnable[T], error) {
+	fn, err := NewClarificationNode(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	g := graph.NewMessageGraph[T](ClarifyNode)
+	g.AddNode(ClarifyNode, fn)
+	g.AddConditionalEdge(ClarifyNode, func(_ context.Context, state T) (string, error) {
+		if len(cfg.Missing(state)) > 0 {
+			return ClarifyNode, nil
+		}
+		return graph.END, nil
+	}, ClarifyNode, graph.END)
+
+	return g.Compile(append(slices.Clone(opts), graph.WithInterruptAfter(ClarifyNode))...)
+}
+
+// AnswerClarification merges the answer of the user into the state of the clarification loop
+// paused on the thread, read from the checkpointer of runnable, and resumes it. It returns the
+// final state, or, while fields are still missing, the state holding the next question with an
+// error matching graph.ErrInterrupted, like CreateClarificationLoop. It returns
+// graph.ErrNotInterrupted if the thread is not paused.
+func AnswerClarification[T any](ctx context.Context, runnable *graph.Runnable[T], cfg ClarificationConfig[T], threadID, answer string) (T, error) {
+	if err := cfg.validate(); err != nil {
+		var state T
+		return state, err
+	}
+
+	interrupt, state, err := runnable.Pending(ctx, threadID)
+	if err != nil {
+		return state, err
+	}
+
+	state, err = cfg.Merge(ctx, state, answer)
+	if err != nil {
+		return state, fmt.Errorf("merging answer: %w", err)
+	}
+	return runnable.Resume(ctx, interrupt, state)
+}
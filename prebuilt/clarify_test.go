@@ -0,0 +1,179 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/prebuilt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type booking struct {
+	Fields    map[string]string
+	Questions []string
+}
+
+func bookingConfig() prebuilt.ClarificationConfig[booking] {
+	return prebuilt.ClarificationConfig[booking]{
+		Missing: func(state booking) []string {
+			var missing []string
+			for _, field := range []string{"city", "date"} {
+				if state.Fields[field] == "" {
+					missing = append(missing, field)
+				}
+			}
+			return missing
+		},
+		Question: func(_ context.Context, _ booking, missing []string) (string, error) {
+			return "Please provide: " + strings.Join(missing, ", "), nil
+		},
+		Ask: func(_ context.Context, state booking, question string) (booking, error) {
+			state.Questions = append(state.Questions, question)
+			return state, nil
+		},
+		Asked: func(state booking) int {
+			return len(state.Questions)
+		},
+		Merge: func(_ context.Context, state booking, answer string) (booking, error) {
+			merged := maps.Clone(state.Fields)
+			if merged == nil {
+				merged = map[string]string{}
+			}
+			if key, value, ok := strings.Cut(answer, "="); ok {
+				merged[key] = value
+			}
+			state.Fields = merged
+			return state, nil
+		},
+	}
+}
+
+func TestClarificationLoop(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		input             booking
+		answers           []string
+		maxAttempts       int
+		expectedQuestions []string
+		expected          booking
+		expectedError     error
+	}{
+		{
+			name:     "Nothing missing",
+			input:    booking{Fields: map[string]string{"city": "Rome", "date": "today"}},
+			expected: booking{Fields: map[string]string{"city": "Rome", "date": "today"}},
+		},
+		{
+			name:              "Fills missing fields",
+			answers:           []string{"city=Rome", "date=today"},
+			expectedQuestions: []string{"Please provide: city, date", "Please provide: date"},
+			expected: booking{
+				Fields:    map[string]string{"city": "Rome", "date": "today"},
+				Questions: []string{"Please provide: city, date", "Please provide: date"},
+			},
+		},
+		{
+			name:              "Budget exceeded",
+			answers:           []string{"city=Rome", "unrelated"},
+			maxAttempts:       2,
+			expectedQuestions: []string{"Please provide: city, date", "Please provide: date"},
+			expectedError:     prebuilt.ErrClarificationBudgetExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			store := checkpoint.NewMemory()
+			cfg := bookingConfig()
+			cfg.MaxAttempts = tc.maxAttempts
+
+			runnable, err := prebuilt.CreateClarificationLoop(cfg, graph.WithCheckpointer(store))
+			require.NoError(t, err)
+			output, err := runnable.Invoke(graph.WithThreadID(ctx, "thread"), tc.input)
+
+			var questions []string
+			for _, answer := range tc.answers {
+				require.ErrorIs(t, err, graph.ErrInterrupted)
+				questions = append(questions, output.Questions[len(output.Questions)-1])
+
+				// Every answer is handled by a new runnable, as after a restart.
+				runnable, err = prebuilt.CreateClarificationLoop(cfg, graph.WithCheckpointer(store))
+				require.NoError(t, err)
+				output, err = prebuilt.AnswerClarification(ctx, runnable, cfg, "thread", answer)
+			}
+			assert.Equal(t, tc.expectedQuestions, questions)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+
+			_, err = prebuilt.AnswerClarification(ctx, runnable, cfg, "thread", "city=Paris")
+			assert.ErrorIs(t, err, graph.ErrNotInterrupted)
+		})
+	}
+}
+
+func TestClarificationResumeWithoutCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := bookingConfig()
+	runnable, err := prebuilt.CreateClarificationLoop(cfg)
+	require.NoError(t, err)
+
+	state, err := runnable.Invoke(ctx, booking{Fields: map[string]string{"city": "Rome"}})
+	var interrupt *graph.Interrupt
+	require.ErrorAs(t, err, &interrupt)
+	assert.Equal(t, []string{"Please provide: date"}, state.Questions)
+
+	state, err = cfg.Merge(ctx, state, "date=today")
+	require.NoError(t, err)
+	output, err := runnable.Resume(ctx, interrupt, state)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"city": "Rome", "date": "today"}, output.Fields)
+}
+
+func TestClarificationConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, unset := range []func(*prebuilt.ClarificationConfig[booking]){
+		func(c *prebuilt.ClarificationConfig[booking]) { c.Ask = nil },
+		func(c *prebuilt.ClarificationConfig[booking]) { c.Asked = nil },
+	} {
+		cfg := bookingConfig()
+		unset(&cfg)
+
+		_, err := prebuilt.CreateClarificationLoop(cfg)
+		assert.ErrorIs(t, err, prebuilt.ErrInvalidClarificationConfig)
+	}
+}
+
+func TestClarificationAskError(t *testing.T) {
+	t.Parallel()
+
+	askErr := errors.New("no channel to the user")
+	cfg := bookingConfig()
+	cfg.Ask = func(_ context.Context, state booking, _ string) (booking, error) {
+		return state, askErr
+	}
+
+	runnable, err := prebuilt.CreateClarificationLoop(cfg)
+	require.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), booking{})
+	assert.ErrorIs(t, err, askErr)
+}
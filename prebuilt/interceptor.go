@@ -0,0 +1,167 @@
+package prebuilt
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MessageInterceptor adapts the messages an LLM node sends to its model, e.g. to the quirks of
+// the provider of the model. It must not modify the messages it receives.
+type MessageInterceptor func(ctx context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error)
+
+// ChainInterceptors returns an interceptor applying the interceptors in order.
+func ChainInterceptors(interceptors ...MessageInterceptor) MessageInterceptor {
+	return func(ctx context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+		for _, interceptor := range interceptors {
+			var err error
+			if msgs, err = interceptor(ctx, msgs); err != nil {
+				return nil, err
+			}
+		}
+		return msgs, nil
+	}
+}
+
+// Provider returns the provider of a model: the name of the package implementing it, such as
+// "openai" or "anthropic" for the models of langchaingo.
+func Provider(model llms.Model) string {
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return ""
+	}
+	return path.Base(t.PkgPath())
+}
+
+// ProviderInterceptor returns the interceptor adapting messages to the quirks of the models of
+// a provider, as returned by Provider, or nil if they need no adaptation:
+//
+//   - anthropic reads a single part per message and concatenates the system messages, so
+//     messages are split into messages of one part and system messages are merged first;
+//   - googleai keeps the last system message only and downloads image URLs, so system
+//     messages are merged first and images of data URLs are inlined;
+//   - ollama only takes images as binary data, so images of data URLs are inlined;
+//   - openai takes a single tool response per message, so tool messages are split.
+func ProviderInterceptor(provider string) MessageInterceptor {
+	switch provider {
+	case "anthropic":
+		return ChainInterceptors(MergeSystemMessages, SplitParts)
+	case "googleai":
+		return ChainInterceptors(MergeSystemMessages, InlineImages)
+	case "ollama":
+		return InlineImages
+	case "openai":
+		return SplitToolResponses
+	default:
+		return nil
+	}
+}
+
+// MergeSystemMessages moves the text of all the system messages into a single system message
+// sent first, separated by blank lines.
+func MergeSystemMessages(_ context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+	var system []string
+	others := make([]llms.MessageContent, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Role != llms.ChatMessageTypeSystem {
+			others = append(others, msg)
+			continue
+		}
+		for _, part := range msg.Parts {
+			text, ok := part.(llms.TextContent)
+			if !ok {
+				return nil, fmt.Errorf("system message with %T part", part)
+			}
+			system = append(system, text.Text)
+		}
+	}
+	if len(system) == 0 {
+		return msgs, nil
+	}
+	merged := llms.TextParts(llms.ChatMessageTypeSystem, strings.Join(system, "\n\n"))
+	return append([]llms.MessageContent{merged}, others...), nil
+}
+
+// SplitParts splits the messages with several parts into consecutive messages of the same role
+// with one part each.
+func SplitParts(_ context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+	return splitParts(msgs, func(llms.MessageContent) bool { return true }), nil
+}
+
+// SplitToolResponses splits the tool messages with several responses into consecutive tool
+// messages with one response each.
+func SplitToolResponses(_ context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+	return splitParts(msgs, func(msg llms.MessageContent) bool { return msg.Role == llms.ChatMessageTypeTool }), nil
+}
+
+// splitParts splits the messages with several parts selected by split.
+func splitParts(msgs []llms.MessageContent, split func(llms.MessageContent) bool) []llms.MessageContent {
+	out := make([]llms.MessageContent, 0, len(msgs))
+	for _, msg := range msgs {
+		if len(msg.Parts) <= 1 || !split(msg) {
+			out = append(out, msg)
+			continue
+		}
+		for _, part := range msg.Parts {
+			out = append(out, llms.MessageContent{Role: msg.Role, Parts: []llms.ContentPart{part}})
+		}
+	}
+	return out
+}
+
+// InlineImages replaces the images given by base64 data URLs with their binary content. Other
+// image URLs are left unchanged.
+func InlineImages(_ context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+	out := make([]llms.MessageContent, len(msgs))
+	for i, msg := range msgs {
+		out[i] = msg
+		copied := false
+		for j, part := range msg.Parts {
+			image, ok := part.(llms.ImageURLContent)
+			if !ok {
+				continue
+			}
+			binary, ok, err := decodeDataURL(image.URL)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if !copied {
+				out[i].Parts, copied = append([]llms.ContentPart(nil), msg.Parts...), true
+			}
+			out[i].Parts[j] = binary
+		}
+	}
+	return out, nil
+}
+
+// decodeDataURL returns the binary content of a base64 data URL, or false if url is not one.
+func decodeDataURL(url string) (llms.BinaryContent, bool, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return llms.BinaryContent{}, false, nil
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	mimeType, ok2 := strings.CutSuffix(header, ";base64")
+	if !ok || !ok2 {
+		return llms.BinaryContent{}, false, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return llms.BinaryContent{}, false, fmt.Errorf("decoding image data URL: %w", err)
+	}
+	return llms.BinaryContent{MIMEType: mimeType, Data: decoded}, true, nil
+}
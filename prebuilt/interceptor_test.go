@@ -0,0 +1,121 @@
+package prebuilt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/fake"
+
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+
+	system := func(text string) llms.MessageContent { return llms.TextParts(llms.ChatMessageTypeSystem, text) }
+	human := llms.TextParts(llms.ChatMessageTypeHuman, "hi")
+	call := toolCall("c1", "upper", `{"input":"hi"}`)
+	ai := llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.TextPart("calling"), call}}
+	responses := llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+		llms.ToolCallResponse{ToolCallID: "c1", Content: "HI"},
+		llms.ToolCallResponse{ToolCallID: "c2", Content: "HO"},
+	}}
+	image := llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+		llms.TextPart("look"),
+		llms.ImageURLPart("data:image/png;base64,aGk="),
+		llms.ImageURLPart("https://example.com/cat.png"),
+	}}
+
+	testCases := []struct {
+		name        string
+		interceptor prebuilt.MessageInterceptor
+		input       []llms.MessageContent
+		expected    []llms.MessageContent
+	}{
+		{
+			name:        "merge system messages",
+			interceptor: prebuilt.MergeSystemMessages,
+			input:       []llms.MessageContent{system("be nice"), human, system("be brief")},
+			expected:    []llms.MessageContent{system("be nice\n\nbe brief"), human},
+		},
+		{
+			name:        "split parts",
+			interceptor: prebuilt.SplitParts,
+			input:       []llms.MessageContent{human, ai},
+			expected: []llms.MessageContent{
+				human,
+				{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.TextPart("calling")}},
+				{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+			},
+		},
+		{
+			name:        "split tool responses",
+			interceptor: prebuilt.SplitToolResponses,
+			input:       []llms.MessageContent{ai, responses},
+			expected: []llms.MessageContent{
+				ai,
+				{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{responses.Parts[0]}},
+				{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{responses.Parts[1]}},
+			},
+		},
+		{
+			name:        "inline images",
+			interceptor: prebuilt.InlineImages,
+			input:       []llms.MessageContent{image},
+			expected: []llms.MessageContent{{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+				llms.TextPart("look"),
+				llms.BinaryPart("image/png", []byte("hi")),
+				llms.ImageURLPart("https://example.com/cat.png"),
+			}}},
+		},
+		{
+			name:        "anthropic",
+			interceptor: prebuilt.ProviderInterceptor("anthropic"),
+			input:       []llms.MessageContent{human, system("be nice"), ai},
+			expected: []llms.MessageContent{
+				system("be nice"),
+				human,
+				{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.TextPart("calling")}},
+				{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := append([]llms.MessageContent(nil), tc.input...)
+			out, err := tc.interceptor(context.Background(), input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+			assert.Equal(t, tc.input, input, "the input is left unchanged")
+		})
+	}
+}
+
+func TestInterceptorErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := prebuilt.MergeSystemMessages(context.Background(), []llms.MessageContent{
+		{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.ImageURLPart("https://example.com/cat.png")}},
+	})
+	require.Error(t, err)
+
+	_, err = prebuilt.InlineImages(context.Background(), []llms.MessageContent{
+		{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.ImageURLPart("data:image/png;base64,!!")}},
+	})
+	require.Error(t, err)
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "fake", prebuilt.Provider(&fake.LLM{}))
+	assert.Equal(t, "prebuilt_test", prebuilt.Provider(&scriptedModel{}))
+	assert.Empty(t, prebuilt.Provider(nil))
+	assert.Nil(t, prebuilt.ProviderInterceptor("fake"))
+}
@@ -0,0 +1,128 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrNoChoices is returned when the model of an LLM node responds without any choice.
+var ErrNoChoices = errors.New("model returned no choices")
+
+// LLMNodeOption configures NewLLMNode.
+type LLMNodeOption func(*llmNodeOptions)
+
+type llmNodeOptions struct {
+	systemPrompt string
+	callOptions  []llms.CallOption
+	breakpoints  []CacheBreakpoint
+	recoveries   []OverflowRecovery
+
+	// interceptor replaces the interceptor of the provider of the model when custom is set.
+	interceptor MessageInterceptor
+	custom      bool
+}
+
+// WithSystemPrompt makes the node send prompt as a system message before the messages of the
+// state on every model call. The prompt is not added to the state.
+func WithSystemPrompt(prompt string) LLMNodeOption {
+	return func(o *llmNodeOptions) { o.systemPrompt = prompt }
+}
+
+// WithCallOptions adds options to every model call, e.g. llms.WithTemperature.
+func WithCallOptions(opts ...llms.CallOption) LLMNodeOption {
+	return func(o *llmNodeOptions) { o.callOptions = append(o.callOptions, opts...) }
+}
+
+// WithInterceptor makes the node adapt the messages it sends with interceptor instead of the
+// interceptor of the provider of the model; see ProviderInterceptor. A nil interceptor sends
+// the messages as they are.
+func WithInterceptor(interceptor MessageInterceptor) LLMNodeOption {
+	return func(o *llmNodeOptions) { o.interceptor, o.custom = interceptor, true }
+}
+
+// NewLLMNode returns a node function calling the model with the message history of the state
+// and appending its response: an AI message with the text and the tool calls of its first
+// choice.
+//
+// The messages are adapted to the quirks of the provider of the model before every call, so
+// graphs stay provider-agnostic; see ProviderInterceptor and WithInterceptor. The adapted
+// messages are only sent, the state keeps the original ones.
+//
+// The usage of every call is reported to the graph.UsageMeter of the run, if any; see UsageOf.
+func NewLLMNode(model llms.Model, opts ...LLMNodeOption) func(ctx context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+	var o llmNodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+		msgs := state
+		if o.systemPrompt != "" {
+			msgs = append([]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, o.systemPrompt)}, state...)
+		}
+
+		resp, err := o.call(ctx, model, msgs)
+		for attempt, recovery := range o.recoveries {
+			if !IsContextOverflow(err) {
+				break
+			}
+			next, retried, rerr := recovery(ctx, model, msgs)
+			if rerr != nil {
+				return state, fmt.Errorf("recovering from context overflow: %w", rerr)
+			}
+			recordOverflow(ctx, attempt+1, err, model, next, msgs, retried)
+			model, msgs = next, retried
+			resp, err = o.call(ctx, model, msgs)
+		}
+		if err != nil {
+			return state, err
+		}
+		graph.RecordUsage(ctx, UsageOf(resp))
+		if len(resp.Choices) == 0 {
+			return state, ErrNoChoices
+		}
+
+		choice := resp.Choices[0]
+		msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+		if choice.Content != "" {
+			msg.Parts = append(msg.Parts, llms.TextPart(choice.Content))
+		}
+		for _, call := range choice.ToolCalls {
+			msg.Parts = append(msg.Parts, call)
+		}
+		return graph.AppendMessages(state, msg), nil
+	}
+}
+
+// call sends the messages to model, adapted to its provider.
+func (o *llmNodeOptions) call(ctx context.Context, model llms.Model, msgs []llms.MessageContent) (*llms.ContentResponse, error) {
+	provider := Provider(model)
+	interceptor := o.interceptor
+	if !o.custom {
+		interceptor = ProviderInterceptor(provider)
+	}
+	span := provider
+	if span == "" {
+		span = "model"
+	}
+
+	if interceptor != nil {
+		var err error
+		if msgs, err = interceptor(ctx, msgs); err != nil {
+			return nil, fmt.Errorf("adapting messages to %s: %w", span, err)
+		}
+	}
+
+	stop := graph.ProfileSpan(ctx, graph.SpanModel, span)
+	resp, err := model.GenerateContent(withCacheBreakpoints(ctx, o.breakpoints), msgs, o.callOptions...)
+	stop()
+	if err != nil {
+		return nil, fmt.Errorf("calling model: %w", err)
+	}
+	return resp, nil
+}
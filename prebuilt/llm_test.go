@@ -0,0 +1,81 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+func TestNewLLMNode(t *testing.T) {
+	t.Parallel()
+
+	input := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+		llms.TextParts(llms.ChatMessageTypeSystem, "be brief"),
+	}
+	reversed := func(_ context.Context, msgs []llms.MessageContent) ([]llms.MessageContent, error) {
+		out := make([]llms.MessageContent, len(msgs))
+		for i, msg := range msgs {
+			out[len(msgs)-1-i] = msg
+		}
+		return out, nil
+	}
+
+	testCases := []struct {
+		name     string
+		opts     []prebuilt.LLMNodeOption
+		expected []llms.MessageContent
+	}{
+		{
+			name:     "default",
+			expected: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, "be nice"), input[0], input[1]},
+		},
+		{
+			name:     "interceptor",
+			opts:     []prebuilt.LLMNodeOption{prebuilt.WithInterceptor(reversed)},
+			expected: []llms.MessageContent{input[1], input[0], llms.TextParts(llms.ChatMessageTypeSystem, "be nice")},
+		},
+		{
+			name: "chained interceptors",
+			opts: []prebuilt.LLMNodeOption{
+				prebuilt.WithInterceptor(prebuilt.ChainInterceptors(reversed, prebuilt.MergeSystemMessages)),
+			},
+			expected: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, "be brief\n\nbe nice"), input[0]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &scriptedModel{responses: []*llms.ContentResponse{respond("hello")}}
+			node := prebuilt.NewLLMNode(model, append(tc.opts, prebuilt.WithSystemPrompt("be nice"))...)
+
+			out, err := node(context.Background(), input)
+			require.NoError(t, err)
+			assert.Equal(t, append(input, llms.TextParts(llms.ChatMessageTypeAI, "hello")), out)
+			require.Len(t, model.calls, 1)
+			assert.Equal(t, tc.expected, model.calls[0])
+		})
+	}
+}
+
+func TestNewLLMNodeInterceptorError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	model := &scriptedModel{}
+	node := prebuilt.NewLLMNode(model, prebuilt.WithInterceptor(func(context.Context, []llms.MessageContent) ([]llms.MessageContent, error) {
+		return nil, boom
+	}))
+
+	_, err := node(context.Background(), nil)
+	require.ErrorIs(t, err, boom)
+	assert.Empty(t, model.calls)
+}
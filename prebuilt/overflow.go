@@ -0,0 +1,152 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrContextOverflow can be wrapped by models to report prompts exceeding their context window.
+// IsContextOverflow also recognizes the messages of the errors of the main providers.
+var ErrContextOverflow = errors.New("context window exceeded")
+
+// EventContextOverflow is the name of the span event recording the recoveries of LLM nodes from
+// context window overflows.
+const EventContextOverflow = "context_overflow"
+
+// overflowMessages are fragments of the error messages of providers rejecting prompts exceeding
+// the context window of the model, lowercased.
+var overflowMessages = []string{
+	"context_length_exceeded", // OpenAI
+	"maximum context length",  // OpenAI, vLLM
+	"context window",          // OpenAI, Mistral, Ollama
+	"prompt is too long",      // Anthropic
+	"input is too long",       // Bedrock
+	"input token count",       // Google AI
+	"too many tokens",         // Cohere
+}
+
+// IsContextOverflow reports whether err rejects a prompt exceeding the context window of the
+// model: it wraps ErrContextOverflow or has the message of a provider doing so.
+func IsContextOverflow(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrContextOverflow) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range overflowMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// OverflowRecovery returns the model and the messages of a new call after the messages sent to
+// model exceeded its context window. The messages include the system prompt of the node and
+// are sent before adaptation to the provider; see ProviderInterceptor.
+type OverflowRecovery func(ctx context.Context, model llms.Model, msgs []llms.MessageContent) (llms.Model, []llms.MessageContent, error)
+
+// WithOverflowRecovery makes the node recover from calls exceeding the context window of the
+// model instead of failing: on every overflow the next recovery is applied to the last call
+// and the call is retried, until a call succeeds or all recoveries were tried. Every recovery
+// is recorded as an EventContextOverflow event of the span of the node.
+//
+// Recoveries only change the messages sent: the state keeps all of them, so every call of the
+// node overflowing recovers again.
+func WithOverflowRecovery(recoveries ...OverflowRecovery) LLMNodeOption {
+	return func(o *llmNodeOptions) { o.recoveries = append(o.recoveries, recoveries...) }
+}
+
+// TrimMessages returns a recovery keeping the system messages and the last keep other
+// messages. Tool responses are not kept without the AI message calling them.
+func TrimMessages(keep int) OverflowRecovery {
+	return func(_ context.Context, model llms.Model, msgs []llms.MessageContent) (llms.Model, []llms.MessageContent, error) {
+		system, others := splitSystem(msgs)
+		cut := trimPoint(others, keep)
+		return model, append(system, others[cut:]...), nil
+	}
+}
+
+// SummarizeMessages returns a recovery replacing the messages before the last keep ones,
+// system messages excepted, by a summary written by summarizer, sent as a system message.
+// Tool responses are not kept without the AI message calling them.
+func SummarizeMessages(summarizer llms.Model, keep int) OverflowRecovery {
+	return func(ctx context.Context, model llms.Model, msgs []llms.MessageContent) (llms.Model, []llms.MessageContent, error) {
+		system, others := splitSystem(msgs)
+		cut := trimPoint(others, keep)
+		if cut == 0 {
+			return model, msgs, nil
+		}
+
+		var transcript strings.Builder
+		for _, msg := range others[:cut] {
+			for _, part := range msg.Parts {
+				switch part := part.(type) {
+				case llms.TextContent:
+					fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, part.Text)
+				case llms.ToolCall:
+					fmt.Fprintf(&transcript, "%s: called %s(%s)\n", msg.Role, part.FunctionCall.Name, part.FunctionCall.Arguments)
+				case llms.ToolCallResponse:
+					fmt.Fprintf(&transcript, "%s: %s returned %s\n", msg.Role, part.Name, part.Content)
+				}
+			}
+		}
+		summary, err := llms.GenerateFromSinglePrompt(ctx, summarizer,
+			"Summarize the following conversation, keeping the facts, decisions and open questions needed to continue it:\n\n"+transcript.String())
+		if err != nil {
+			return nil, nil, fmt.Errorf("summarizing messages: %w", err)
+		}
+		summaryMsg := llms.TextParts(llms.ChatMessageTypeSystem, "Summary of the earlier conversation:\n"+summary)
+		return model, append(append(system, summaryMsg), others[cut:]...), nil
+	}
+}
+
+// SwitchModel returns a recovery sending the same messages to another model, typically one with
+// a longer context window.
+func SwitchModel(longContext llms.Model) OverflowRecovery {
+	return func(_ context.Context, _ llms.Model, msgs []llms.MessageContent) (llms.Model, []llms.MessageContent, error) {
+		return longContext, msgs, nil
+	}
+}
+
+// splitSystem returns the system messages and the others, in order, in new slices.
+func splitSystem(msgs []llms.MessageContent) (system, others []llms.MessageContent) {
+	for _, msg := range msgs {
+		if msg.Role == llms.ChatMessageTypeSystem {
+			system = append(system, msg)
+		} else {
+			others = append(others, msg)
+		}
+	}
+	return system, others
+}
+
+// trimPoint returns the index of the first of the last keep messages, moved forward past the
+// tool responses whose call would be cut.
+func trimPoint(msgs []llms.MessageContent, keep int) int {
+	cut := max(len(msgs)-max(keep, 0), 0)
+	for cut < len(msgs) && msgs[cut].Role == llms.ChatMessageTypeTool {
+		cut++
+	}
+	return cut
+}
+
+// recordOverflow records a recovery from an overflow in the span of ctx.
+func recordOverflow(ctx context.Context, attempt int, err error, before, after llms.Model, sent, retried []llms.MessageContent) {
+	trace.SpanFromContext(ctx).AddEvent(EventContextOverflow, trace.WithAttributes(
+		attribute.Int("langgraph.recovery.attempt", attempt),
+		attribute.String("langgraph.recovery.error", err.Error()),
+		attribute.String("langgraph.recovery.model", Provider(before)),
+		attribute.String("langgraph.recovery.retry_model", Provider(after)),
+		attribute.Int("langgraph.recovery.messages", len(sent)),
+		attribute.Int("langgraph.recovery.retry_messages", len(retried)),
+	))
+}
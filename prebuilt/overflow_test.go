@@ -0,0 +1,163 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+// smallModel rejects the calls of more than limit messages as exceeding its context window.
+type smallModel struct {
+	scriptedModel
+	limit int
+}
+
+func (m *smallModel) GenerateContent(ctx context.Context, msgs []llms.MessageContent, opts ...llms.CallOption) (*llms.ContentResponse, error) {
+	if len(msgs) > m.limit {
+		m.calls = append(m.calls, msgs)
+		return nil, errors.New("This model's maximum context length is 8192 tokens")
+	}
+	return m.scriptedModel.GenerateContent(ctx, msgs, opts...)
+}
+
+func TestIsContextOverflow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil},
+		{err: errors.New("rate limited")},
+		{err: fmt.Errorf("calling: %w", prebuilt.ErrContextOverflow), expected: true},
+		{err: errors.New(`{"error":{"code":"context_length_exceeded"}}`), expected: true},
+		{err: errors.New("prompt is too long: 210000 tokens > 200000 maximum"), expected: true},
+		{err: errors.New("The input token count (1200000) exceeds the maximum number of tokens allowed"), expected: true},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, prebuilt.IsContextOverflow(tc.err), "%v", tc.err)
+	}
+}
+
+func TestOverflowRecovery(t *testing.T) {
+	t.Parallel()
+
+	human := func(text string) llms.MessageContent { return llms.TextParts(llms.ChatMessageTypeHuman, text) }
+	system := llms.TextParts(llms.ChatMessageTypeSystem, "be nice")
+	call := toolCall("c1", "upper", `{"input":"hi"}`)
+	state := []llms.MessageContent{
+		human("one"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "c1", Name: "upper", Content: "HI"}}},
+		human("two"),
+	}
+
+	testCases := []struct {
+		name        string
+		limit       int
+		recoveries  func(long, summarizer llms.Model) []prebuilt.OverflowRecovery
+		expected    []llms.MessageContent
+		switched    bool
+		expectedErr bool
+	}{
+		{
+			name:  "trim",
+			limit: 2,
+			recoveries: func(llms.Model, llms.Model) []prebuilt.OverflowRecovery {
+				return []prebuilt.OverflowRecovery{prebuilt.TrimMessages(2)}
+			},
+			// The tool response is dropped with the call.
+			expected: []llms.MessageContent{system, human("two")},
+		},
+		{
+			name:  "summarize",
+			limit: 3,
+			recoveries: func(_ llms.Model, summarizer llms.Model) []prebuilt.OverflowRecovery {
+				return []prebuilt.OverflowRecovery{prebuilt.SummarizeMessages(summarizer, 1)}
+			},
+			expected: []llms.MessageContent{
+				system,
+				llms.TextParts(llms.ChatMessageTypeSystem, "Summary of the earlier conversation:\nthey said one"),
+				human("two"),
+			},
+		},
+		{
+			name:  "switch model",
+			limit: 0,
+			recoveries: func(long llms.Model, _ llms.Model) []prebuilt.OverflowRecovery {
+				return []prebuilt.OverflowRecovery{prebuilt.TrimMessages(1), prebuilt.SwitchModel(long)}
+			},
+			expected: []llms.MessageContent{system, human("two")},
+			switched: true,
+		},
+		{
+			name:  "exhausted",
+			limit: 1,
+			recoveries: func(llms.Model, llms.Model) []prebuilt.OverflowRecovery {
+				return []prebuilt.OverflowRecovery{prebuilt.TrimMessages(3)}
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &smallModel{limit: tc.limit, scriptedModel: scriptedModel{responses: []*llms.ContentResponse{respond("hello")}}}
+			long := &scriptedModel{responses: []*llms.ContentResponse{respond("hello")}}
+			summarizer := &scriptedModel{responses: []*llms.ContentResponse{respond("they said one")}}
+			node := prebuilt.NewLLMNode(model,
+				prebuilt.WithSystemPrompt("be nice"),
+				prebuilt.WithInterceptor(nil),
+				prebuilt.WithOverflowRecovery(tc.recoveries(long, summarizer)...))
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			ctx, span := tp.Tracer("test").Start(context.Background(), "node")
+			out, err := node(ctx, state)
+			span.End()
+			if tc.expectedErr {
+				require.True(t, prebuilt.IsContextOverflow(err))
+				assert.Equal(t, state, out)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, append(state, llms.TextParts(llms.ChatMessageTypeAI, "hello")), out)
+
+			sent := model.calls[len(model.calls)-1]
+			if tc.switched {
+				require.Len(t, long.calls, 1)
+				sent = long.calls[0]
+			}
+			assert.Equal(t, tc.expected, sent)
+
+			events := recorder.Ended()[0].Events()
+			require.NotEmpty(t, events)
+			assert.Equal(t, prebuilt.EventContextOverflow, events[0].Name)
+			assert.Contains(t, events[0].Attributes, attribute.Int("langgraph.recovery.messages", len(state)+1))
+		})
+	}
+}
+
+func TestOverflowRecoveryError(t *testing.T) {
+	t.Parallel()
+
+	model := &smallModel{limit: 0}
+	summarizer := &scriptedModel{}
+	node := prebuilt.NewLLMNode(model, prebuilt.WithOverflowRecovery(prebuilt.SummarizeMessages(summarizer, 0)))
+
+	_, err := node(context.Background(), []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")})
+	require.ErrorContains(t, err, "summarizing messages")
+}
@@ -0,0 +1,154 @@
+package prebuilt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+const (
+	// AgentNode is the name of the node calling the model in the graphs built by
+	// CreateReactAgent.
+	AgentNode = "agent"
+
+	// ToolsNode is the name of the node calling the tools in the graphs built by
+	// CreateReactAgent.
+	ToolsNode = "tools"
+)
+
+// ReactAgentOption configures CreateReactAgent. The options apply to the agent node, an LLM
+// node; see NewLLMNode.
+type ReactAgentOption = LLMNodeOption
+
+// CreateReactAgent returns a compiled graph running the ReAct loop over a message history: the
+// agent node calls the model with the tools, and while the model responds with tool calls, the
+// tools node runs them and the model is called again with their results. The graph ends once
+// the model responds without tool calls.
+//
+// Tools take the input argument of their calls. Calls of unknown tools and tool errors are
+// reported to the model as the result of the call, so it can recover. Tools returning typed
+// artifacts implement ArtifactTool. Tool results are cached in the ToolCache of the context, if
+// any; see WithToolCache. The loop is bounded by
+// the step limit of the graph; see graph.WithMaxSteps. The tools node reports the tools it runs
+// as graph.ActivityToolRunning activities; see graph.WithActivity.
+func CreateReactAgent(model llms.Model, tools []tools.Tool, opts ...ReactAgentOption) (*graph.Runnable[[]llms.MessageContent], error) {
+	if len(tools) > 0 {
+		opts = append(opts[:len(opts):len(opts)], WithCallOptions(llms.WithTools(toolDefinitions(tools))))
+	}
+
+	g := graph.NewMessageGraph[[]llms.MessageContent](AgentNode)
+	g.AddNode(AgentNode, NewLLMNode(model, opts...))
+	g.AddNode(ToolsNode, func(ctx context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+		calls := toolCalls(state)
+		results := make([]llms.MessageContent, 0, len(calls))
+		for _, call := range calls {
+			if err := graph.StreamActivity(ctx, graph.ActivityToolRunning, call.FunctionCall.Name); err != nil {
+				return state, err
+			}
+			content, err := callTool(ctx, tools, call)
+			if err != nil {
+				return state, err
+			}
+			results = append(results, llms.MessageContent{
+				Role: llms.ChatMessageTypeTool,
+				Parts: []llms.ContentPart{llms.ToolCallResponse{
+					ToolCallID: call.ID,
+					Name:       call.FunctionCall.Name,
+					Content:    content,
+				}},
+			})
+		}
+		return graph.AppendMessages(state, results...), nil
+	})
+	g.AddConditionalEdge(AgentNode, func(_ context.Context, state []llms.MessageContent) (string, error) {
+		if len(toolCalls(state)) > 0 {
+			return ToolsNode, nil
+		}
+		return graph.END, nil
+	}, ToolsNode, graph.END)
+	g.AddEdge(ToolsNode, AgentNode)
+
+	return g.Compile()
+}
+
+// toolInput is the arguments of the tool calls of a ReAct agent.
+type toolInput struct {
+	Input string `json:"input"`
+}
+
+// toolDefinitions returns the definitions of the tools sent to the model.
+func toolDefinitions(tools []tools.Tool) []llms.Tool {
+	defs := make([]llms.Tool, len(tools))
+	for i, tool := range tools {
+		defs[i] = llms.Tool{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"input": map[string]any{"type": "string", "description": "The input of the tool."},
+					},
+					"required": []string{"input"},
+				},
+			},
+		}
+	}
+	return defs
+}
+
+// toolCalls returns the tool calls of the last message of the history, if it is an AI message.
+func toolCalls(state []llms.MessageContent) []llms.ToolCall {
+	if len(state) == 0 || state[len(state)-1].Role != llms.ChatMessageTypeAI {
+		return nil
+	}
+
+	var calls []llms.ToolCall
+	for _, part := range state[len(state)-1].Parts {
+		if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// callTool runs a tool call and returns its result. Only the errors of ctx are returned: other
+// failures are reported in the result.
+func callTool(ctx context.Context, tools []tools.Tool, call llms.ToolCall) (string, error) {
+	for _, tool := range tools {
+		if tool.Name() != call.FunctionCall.Name {
+			continue
+		}
+
+		// Fall back to the raw arguments for models ignoring the parameters schema.
+		input := toolInput{Input: call.FunctionCall.Arguments}
+		_ = json.Unmarshal([]byte(call.FunctionCall.Arguments), &input)
+
+		out, err := cachedCall(ctx, tool.Name(), input.Input, func() (string, error) {
+			stop := graph.ProfileSpan(ctx, graph.SpanTool, tool.Name())
+			defer stop()
+			if artifactTool, ok := tool.(ArtifactTool); ok {
+				result, err := artifactTool.CallWithArtifacts(ctx, input.Input)
+				if err != nil {
+					return "", err
+				}
+				return storeArtifacts(ctx, result)
+			}
+			return tool.Call(ctx, input.Input)
+		})
+		switch {
+		case ctx.Err() != nil:
+			return "", ctx.Err()
+		case err != nil:
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		return out, nil
+	}
+	return fmt.Sprintf("Error: %s is not a valid tool", call.FunctionCall.Name), nil
+}
@@ -0,0 +1,184 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+// scriptedModel returns its responses in order, and records the messages and options of every
+// call.
+type scriptedModel struct {
+	responses []*llms.ContentResponse
+	calls     [][]llms.MessageContent
+	options   []llms.CallOptions
+}
+
+func (m *scriptedModel) GenerateContent(_ context.Context, msgs []llms.MessageContent, opts ...llms.CallOption) (*llms.ContentResponse, error) {
+	var o llms.CallOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m.calls = append(m.calls, msgs)
+	m.options = append(m.options, o)
+	if len(m.responses) == 0 {
+		return nil, errors.New("no more responses")
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, opts ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, opts...)
+}
+
+// upper is a tool returning its input in uppercase, failing on empty input.
+type upper struct{}
+
+func (upper) Name() string        { return "upper" }
+func (upper) Description() string { return "Uppercases text." }
+
+func (upper) Call(_ context.Context, input string) (string, error) {
+	if input == "" {
+		return "", errors.New("empty input")
+	}
+	return strings.ToUpper(input), nil
+}
+
+func toolCall(id, name, args string) llms.ToolCall {
+	return llms.ToolCall{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: name, Arguments: args}}
+}
+
+func respond(content string, calls ...llms.ToolCall) *llms.ContentResponse {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: content, ToolCalls: calls}}}
+}
+
+func TestCreateReactAgent(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []*llms.ContentResponse{
+		respond("", toolCall("c1", "upper", `{"input":"hi"}`), toolCall("c2", "upper", `{"input":""}`)),
+		respond("", toolCall("c3", "lower", `{"input":"HI"}`), toolCall("c4", "upper", `raw`)),
+		respond("HI"),
+	}}
+	agent, err := prebuilt.CreateReactAgent(model, []tools.Tool{upper{}},
+		prebuilt.WithSystemPrompt("Be loud."),
+		prebuilt.WithCallOptions(llms.WithTemperature(0.5)),
+	)
+	require.NoError(t, err)
+
+	input := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "say hi")}
+	out, err := agent.Invoke(context.Background(), input)
+	require.NoError(t, err)
+
+	result := func(id, name, content string) llms.MessageContent {
+		return llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: id, Name: name, Content: content},
+		}}
+	}
+	assert.Equal(t, []llms.MessageContent{
+		input[0],
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			toolCall("c1", "upper", `{"input":"hi"}`), toolCall("c2", "upper", `{"input":""}`),
+		}},
+		result("c1", "upper", "HI"),
+		result("c2", "upper", "Error: empty input"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			toolCall("c3", "lower", `{"input":"HI"}`), toolCall("c4", "upper", `raw`),
+		}},
+		result("c3", "lower", "Error: lower is not a valid tool"),
+		result("c4", "upper", "RAW"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.TextPart("HI")}},
+	}, out)
+
+	require.Len(t, model.calls, 3)
+	for i, call := range model.calls {
+		assert.Equal(t, llms.TextParts(llms.ChatMessageTypeSystem, "Be loud."), call[0])
+		assert.Equal(t, out[:[]int{1, 4, 7}[i]], call[1:])
+		assert.InDelta(t, 0.5, model.options[i].Temperature, 1e-9)
+		require.Len(t, model.options[i].Tools, 1)
+		assert.Equal(t, "upper", model.options[i].Tools[0].Function.Name)
+	}
+}
+
+func TestCreateReactAgentErrors(t *testing.T) {
+	t.Parallel()
+
+	input := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "loop")}
+
+	testCases := []struct {
+		name      string
+		responses []*llms.ContentResponse
+		ctx       context.Context
+		expected  error
+	}{
+		{
+			name:      "no choices",
+			responses: []*llms.ContentResponse{{}},
+			expected:  prebuilt.ErrNoChoices,
+		},
+		{
+			name: "step limit",
+			responses: []*llms.ContentResponse{
+				respond("", toolCall("c1", "upper", `{"input":"a"}`)),
+				respond("", toolCall("c2", "upper", `{"input":"b"}`)),
+				respond("", toolCall("c3", "upper", `{"input":"c"}`)),
+			},
+			ctx:      graph.WithMaxSteps(context.Background(), 4),
+			expected: graph.ErrMaxStepsExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			agent, err := prebuilt.CreateReactAgent(&scriptedModel{responses: tc.responses}, []tools.Tool{upper{}})
+			require.NoError(t, err)
+
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			_, err = agent.Invoke(ctx, input)
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestCreateReactAgentActivity(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []*llms.ContentResponse{
+		respond("", toolCall("c1", "upper", `{"input":"hi"}`)),
+		respond("HI"),
+	}}
+	agent, err := prebuilt.CreateReactAgent(model, []tools.Tool{upper{}})
+	require.NoError(t, err)
+
+	input := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "say hi")}
+	events, err := agent.Stream(graph.WithActivity(context.Background()), input)
+	require.NoError(t, err)
+	var activities []string
+	for event := range events {
+		if event.Kind == graph.EventActivity {
+			activities = append(activities, event.Node+" "+string(event.Activity)+" "+event.Tool)
+		}
+	}
+	assert.Equal(t, []string{
+		prebuilt.AgentNode + " thinking ",
+		prebuilt.ToolsNode + " thinking ",
+		prebuilt.ToolsNode + " tool_running upper",
+		prebuilt.AgentNode + " thinking ",
+	}, activities)
+}
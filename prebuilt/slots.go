@@ -0,0 +1,297 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the values of SlotDate slots.
+const DateLayout = "2006-01-02"
+
+// SlotType is the type of the values of a slot, which Form.Set checks and Form.Value and
+// SlotValue convert them to.
+type SlotType string
+
+const (
+	// SlotText slots hold any non-empty text, as a string. It is the default.
+	SlotText SlotType = ""
+
+	// SlotInteger slots hold a decimal integer, as an int.
+	SlotInteger SlotType = "integer"
+
+	// SlotNumber slots hold a decimal number, as a float64.
+	SlotNumber SlotType = "number"
+
+	// SlotBoolean slots hold a boolean, as a bool: true, false, yes or no, in any case.
+	SlotBoolean SlotType = "boolean"
+
+	// SlotDate slots hold a date in the DateLayout layout, as a time.Time.
+	SlotDate SlotType = "date"
+
+	// SlotChoice slots hold one of the Choices of the slot, matched in any case, as a string.
+	SlotChoice SlotType = "choice"
+)
+
+var (
+	// ErrUnknownSlot is returned when a value is set for a slot that is not defined in the form.
+	ErrUnknownSlot = errors.New("unknown slot")
+
+	// ErrInvalidSlotValue is returned when a slot validator rejects a value.
+	ErrInvalidSlotValue = errors.New("invalid slot value")
+
+	// ErrSlotNotFilled is returned by Form.Value and SlotValue for slots without value.
+	ErrSlotNotFilled = errors.New("slot not filled")
+
+	// ErrInvalidSlotExtractionConfig is returned when a required callback is not set.
+	ErrInvalidSlotExtractionConfig = errors.New("invalid slot extraction config")
+)
+
+// Slot describes a single value a task-oriented dialog needs to collect.
+type Slot struct {
+	// Name is the unique identifier for the slot.
+	Name string
+
+	// Description explains the slot to the extractor and to the user.
+	Description string
+
+	// Required reports whether the dialog cannot complete without the slot.
+	Required bool
+
+	// Type is the type of the values of the slot; SlotText by default.
+	Type SlotType
+
+	// Choices are the values accepted by SlotChoice slots.
+	Choices []string
+
+	// Parse, if set, converts candidate values in place of Type, returning an error for values
+	// it rejects, e.g. to accept an amount with its currency. A string result replaces the
+	// value, so Parse can normalize it.
+	Parse func(value string) (any, error)
+
+	// Validate checks a candidate value, once converted. A nil Validate accepts any non-empty
+	// value.
+	Validate func(value string) error
+}
+
+// parse converts a candidate value of the slot with its Parse function or its type, and
+// returns the value to store, normalized, with the converted value.
+func (s Slot) parse(value string) (string, any, error) {
+	if s.Parse != nil {
+		parsed, err := s.Parse(value)
+		if normalized, ok := parsed.(string); ok && err == nil {
+			value = normalized
+		}
+		return value, parsed, err
+	}
+
+	if s.Type != SlotText {
+		value = strings.TrimSpace(value)
+	}
+	var parsed any
+	var err error
+	switch s.Type {
+	case SlotText:
+		parsed = value
+	case SlotInteger:
+		parsed, err = strconv.Atoi(value)
+	case SlotNumber:
+		parsed, err = strconv.ParseFloat(value, 64)
+	case SlotBoolean:
+		switch strings.ToLower(value) {
+		case "yes":
+			parsed = true
+		case "no":
+			parsed = false
+		default:
+			parsed, err = strconv.ParseBool(strings.ToLower(value))
+		}
+	case SlotDate:
+		parsed, err = time.Parse(DateLayout, value)
+	case SlotChoice:
+		err = fmt.Errorf("not one of %s", strings.Join(s.Choices, ", "))
+		for _, choice := range s.Choices {
+			if strings.EqualFold(choice, value) {
+				value, parsed, err = choice, choice, nil
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown slot type %q", s.Type)
+	}
+	return value, parsed, err
+}
+
+// Form holds slot definitions and the values collected so far.
+// Form methods never modify the receiver, so forms can be shared between steps.
+type Form struct {
+	// Slots are the slot definitions, in the order they should be asked for.
+	Slots []Slot
+
+	// Values maps slot names to their accepted values.
+	Values map[string]string
+
+	// Errors maps slot names to the reason the last candidate value was rejected.
+	Errors map[string]string
+}
+
+// NewForm creates an empty form for the given slots.
+func NewForm(slots ...Slot) Form {
+	return Form{
+		Slots:  slots,
+		Values: map[string]string{},
+		Errors: map[string]string{},
+	}
+}
+
+// Slot returns the definition of the named slot.
+func (f Form) Slot(name string) (Slot, bool) {
+	for _, s := range f.Slots {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Slot{}, false
+}
+
+// MissingSlots returns the names of the required slots that have no value yet.
+func (f Form) MissingSlots() []string {
+	var missing []string
+	for _, s := range f.Slots {
+		if s.Required && f.Values[s.Name] == "" {
+			missing = append(missing, s.Name)
+		}
+	}
+	return missing
+}
+
+// Complete reports whether all required slots are filled.
+func (f Form) Complete() bool {
+	return len(f.MissingSlots()) == 0
+}
+
+// Value returns the value of the slot converted according to its Type or its Parse function,
+// e.g. an int for SlotInteger slots. It returns ErrSlotNotFilled if the slot has no value.
+func (f Form) Value(name string) (any, error) {
+	s, ok := f.Slot(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSlot, name)
+	}
+	value := f.Values[name]
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrSlotNotFilled, name)
+	}
+	_, parsed, err := s.parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSlotValue, name, err)
+	}
+	return parsed, nil
+}
+
+// SlotValue returns the value of the slot of the form as a V, such as SlotValue[int] for
+// SlotInteger slots; see Form.Value.
+func SlotValue[V any](f Form, name string) (V, error) {
+	var zero V
+	value, err := f.Value(name)
+	if err != nil {
+		return zero, err
+	}
+	v, ok := value.(V)
+	if !ok {
+		return zero, fmt.Errorf("slot %s holds a %T, not a %T", name, value, zero)
+	}
+	return v, nil
+}
+
+// Set returns a copy of the form with the slot set to value.
+// The value is converted according to the Type or the Parse function of the slot and validated
+// first; rejected values are recorded in Errors of the returned form.
+func (f Form) Set(name, value string) (Form, error) {
+	s, ok := f.Slot(name)
+	if !ok {
+		return f, fmt.Errorf("%w: %s", ErrUnknownSlot, name)
+	}
+
+	next := Form{
+		Slots:  f.Slots,
+		Values: maps.Clone(f.Values),
+		Errors: maps.Clone(f.Errors),
+	}
+	if next.Values == nil {
+		next.Values = map[string]string{}
+	}
+	if next.Errors == nil {
+		next.Errors = map[string]string{}
+	}
+
+	var err error
+	if value == "" {
+		err = fmt.Errorf("%w: %s: empty value", ErrInvalidSlotValue, name)
+	} else if value, _, err = s.parse(value); err != nil {
+		err = fmt.Errorf("%w: %s: %w", ErrInvalidSlotValue, name, err)
+	} else if s.Validate != nil {
+		if verr := s.Validate(value); verr != nil {
+			err = fmt.Errorf("%w: %s: %w", ErrInvalidSlotValue, name, verr)
+		}
+	}
+	if err != nil {
+		next.Errors[name] = err.Error()
+		return next, err
+	}
+
+	next.Values[name] = value
+	delete(next.Errors, name)
+	return next, nil
+}
+
+// SlotExtractor extracts candidate slot values from a conversation turn.
+// It returns a map from slot name to candidate value; slots it could not find are omitted.
+type SlotExtractor func(ctx context.Context, slots []Slot, turn string) (map[string]string, error)
+
+// SlotExtractionConfig configures a slot extraction node.
+type SlotExtractionConfig[T any] struct {
+	// Extract extracts candidate values from the latest turn.
+	Extract SlotExtractor
+
+	// Turn returns the latest conversation turn from the state.
+	Turn func(state T) string
+
+	// Form returns the form stored in the state.
+	Form func(state T) Form
+
+	// SetForm returns the state with the form replaced.
+	SetForm func(state T, form Form) T
+}
+
+// NewSlotExtractionNode returns a node function that fills the state's form from the latest turn.
+// Candidate values rejected by a validator are recorded in Form.Errors and do not fail the node,
+// so routers can ask the user again. Candidates for unknown slots are ignored.
+func NewSlotExtractionNode[T any](cfg SlotExtractionConfig[T]) (func(ctx context.Context, state T) (T, error), error) {
+	if cfg.Extract == nil || cfg.Turn == nil || cfg.Form == nil || cfg.SetForm == nil {
+		return nil, fmt.Errorf("%w: Extract, Turn, Form and SetForm are required", ErrInvalidSlotExtractionConfig)
+	}
+
+	return func(ctx context.Context, state T) (T, error) {
+		form := cfg.Form(state)
+
+		candidates, err := cfg.Extract(ctx, form.Slots, cfg.Turn(state))
+		if err != nil {
+			return state, fmt.Errorf("extracting slots: %w", err)
+		}
+
+		for _, s := range form.Slots {
+			value, ok := candidates[s.Name]
+			if !ok {
+				continue
+			}
+			// Validation failures are kept in form.Errors.
+			form, _ = form.Set(s.Name, value)
+		}
+
+		return cfg.SetForm(state, form), nil
+	}, nil
+}
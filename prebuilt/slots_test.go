@@ -0,0 +1,171 @@
+package prebuilt_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/prebuilt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pizzaForm() prebuilt.Form {
+	return prebuilt.NewForm(
+		prebuilt.Slot{Name: "size", Required: true, Validate: func(v string) error {
+			if v != "small" && v != "large" {
+				return errors.New("must be small or large")
+			}
+			return nil
+		}},
+		prebuilt.Slot{Name: "topping", Required: true},
+		prebuilt.Slot{Name: "notes"},
+	)
+}
+
+func TestFormSet(t *testing.T) {
+	t.Parallel()
+
+	form := pizzaForm()
+	assert.Equal(t, []string{"size", "topping"}, form.MissingSlots())
+
+	filled, err := form.Set("size", "large")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"topping"}, filled.MissingSlots())
+	assert.Empty(t, form.Values, "Set must not modify the receiver")
+
+	rejected, err := filled.Set("size", "huge")
+	require.ErrorIs(t, err, prebuilt.ErrInvalidSlotValue)
+	assert.Equal(t, "large", rejected.Values["size"])
+	assert.Contains(t, rejected.Errors["size"], "must be small or large")
+
+	_, err = form.Set("crust", "thin")
+	require.ErrorIs(t, err, prebuilt.ErrUnknownSlot)
+
+	done, err := filled.Set("topping", "basil")
+	require.NoError(t, err)
+	assert.True(t, done.Complete())
+}
+
+func TestTypedSlots(t *testing.T) {
+	t.Parallel()
+
+	form := prebuilt.NewForm(
+		prebuilt.Slot{Name: "guests", Type: prebuilt.SlotInteger, Validate: func(v string) error {
+			if v == "0" {
+				return errors.New("at least one guest")
+			}
+			return nil
+		}},
+		prebuilt.Slot{Name: "budget", Type: prebuilt.SlotNumber},
+		prebuilt.Slot{Name: "outdoor", Type: prebuilt.SlotBoolean},
+		prebuilt.Slot{Name: "date", Type: prebuilt.SlotDate},
+		prebuilt.Slot{Name: "size", Type: prebuilt.SlotChoice, Choices: []string{"small", "large"}},
+		prebuilt.Slot{Name: "price", Parse: func(v string) (any, error) {
+			amount, ok := strings.CutSuffix(v, " EUR")
+			if !ok {
+				return nil, errors.New("missing currency")
+			}
+			return strconv.ParseFloat(amount, 64)
+		}},
+	)
+
+	testCases := []struct {
+		slot     string
+		value    string
+		stored   string
+		expected any
+		invalid  bool
+	}{
+		{slot: "guests", value: " 12 ", stored: "12", expected: 12},
+		{slot: "guests", value: "twelve", invalid: true},
+		{slot: "guests", value: "0", invalid: true},
+		{slot: "budget", value: "99.5", stored: "99.5", expected: 99.5},
+		{slot: "outdoor", value: "Yes", stored: "Yes", expected: true},
+		{slot: "outdoor", value: "false", stored: "false", expected: false},
+		{slot: "outdoor", value: "maybe", invalid: true},
+		{slot: "date", value: "2026-10-17", stored: "2026-10-17", expected: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{slot: "date", value: "tomorrow", invalid: true},
+		{slot: "size", value: "LARGE", stored: "large", expected: "large"},
+		{slot: "size", value: "huge", invalid: true},
+		{slot: "price", value: "12 EUR", stored: "12 EUR", expected: 12.0},
+		{slot: "price", value: "12", invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.slot+" "+tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			filled, err := form.Set(tc.slot, tc.value)
+			if tc.invalid {
+				require.ErrorIs(t, err, prebuilt.ErrInvalidSlotValue)
+				assert.NotEmpty(t, filled.Errors[tc.slot])
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.stored, filled.Values[tc.slot])
+
+			value, err := filled.Value(tc.slot)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	filled, err := form.Set("guests", "4")
+	require.NoError(t, err)
+	guests, err := prebuilt.SlotValue[int](filled, "guests")
+	require.NoError(t, err)
+	assert.Equal(t, 4, guests)
+	_, err = prebuilt.SlotValue[string](filled, "guests")
+	require.Error(t, err)
+	_, err = prebuilt.SlotValue[float64](filled, "budget")
+	require.ErrorIs(t, err, prebuilt.ErrSlotNotFilled)
+	_, err = filled.Value("missing")
+	require.ErrorIs(t, err, prebuilt.ErrUnknownSlot)
+}
+
+type order struct {
+	Turn string
+	Form prebuilt.Form
+}
+
+func TestSlotExtractionNode(t *testing.T) {
+	t.Parallel()
+
+	node, err := prebuilt.NewSlotExtractionNode(prebuilt.SlotExtractionConfig[order]{
+		Extract: func(_ context.Context, _ []prebuilt.Slot, turn string) (map[string]string, error) {
+			values := map[string]string{}
+			for _, field := range strings.Fields(turn) {
+				if k, v, ok := strings.Cut(field, "="); ok {
+					values[k] = v
+				}
+			}
+			return values, nil
+		},
+		Turn:    func(s order) string { return s.Turn },
+		Form:    func(s order) prebuilt.Form { return s.Form },
+		SetForm: func(s order, f prebuilt.Form) order { s.Form = f; return s },
+	})
+	require.NoError(t, err)
+
+	state, err := node(context.Background(), order{Turn: "size=huge topping=basil crust=thin", Form: pizzaForm()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"size"}, state.Form.MissingSlots())
+	assert.Contains(t, state.Form.Errors, "size")
+
+	state.Turn = "size=small"
+	state, err = node(context.Background(), state)
+	require.NoError(t, err)
+	assert.True(t, state.Form.Complete())
+	assert.Empty(t, state.Form.Errors)
+}
+
+func TestSlotExtractionConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := prebuilt.NewSlotExtractionNode(prebuilt.SlotExtractionConfig[order]{})
+	assert.ErrorIs(t, err, prebuilt.ErrInvalidSlotExtractionConfig)
+}
@@ -0,0 +1,179 @@
+package prebuilt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ToolCache caches the results of the tools of agents by thread, tool and normalized input, so
+// agents repeating the same lookup within a thread do not call the tool again. Failed calls are
+// not cached. It is safe for concurrent use.
+type ToolCache struct {
+	ttl         time.Duration
+	ttls        map[string]time.Duration
+	invalidates map[string][]string
+
+	mu      sync.Mutex
+	entries map[toolCacheKey]toolCacheEntry
+}
+
+type toolCacheKey struct {
+	threadID string
+	tool     string
+	input    string
+}
+
+type toolCacheEntry struct {
+	result  string
+	expires time.Time
+}
+
+// ToolCacheOption configures NewToolCache.
+type ToolCacheOption func(*ToolCache)
+
+// WithToolTTL sets the time the results of a tool are kept, instead of the TTL of the cache. A
+// TTL that is not positive disables caching for the tool, e.g. for tools with side effects.
+func WithToolTTL(tool string, ttl time.Duration) ToolCacheOption {
+	return func(c *ToolCache) { c.ttls[tool] = ttl }
+}
+
+// WithInvalidation makes every successful call of tool invalidate the results of the tools
+// invalidated in its thread, e.g. a tool updating a document invalidating the tool fetching it.
+func WithInvalidation(tool string, invalidated ...string) ToolCacheOption {
+	return func(c *ToolCache) { c.invalidates[tool] = append(c.invalidates[tool], invalidated...) }
+}
+
+// NewToolCache creates a cache keeping results for ttl, unless the tool has its own TTL.
+func NewToolCache(ttl time.Duration, opts ...ToolCacheOption) *ToolCache {
+	c := &ToolCache{
+		ttl:         ttl,
+		ttls:        make(map[string]time.Duration),
+		invalidates: make(map[string][]string),
+		entries:     make(map[toolCacheKey]toolCacheEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type toolCacheContextKey struct{}
+
+// WithToolCache returns a context making the tools nodes of agents cache the results of their
+// tools in c, scoped by the thread of the context; see graph.WithThreadID.
+func WithToolCache(ctx context.Context, c *ToolCache) context.Context {
+	return context.WithValue(ctx, toolCacheContextKey{}, c)
+}
+
+func toolCacheFromContext(ctx context.Context) *ToolCache {
+	c, _ := ctx.Value(toolCacheContextKey{}).(*ToolCache)
+	return c
+}
+
+// Get returns the result of the tool for the input cached in the thread, if any.
+func (c *ToolCache) Get(threadID, tool, input string) (string, bool) {
+	key := toolCacheKey{threadID: threadID, tool: tool, input: normalizeInput(input)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.result, true
+}
+
+// Put caches the result of the tool for the input in the thread, unless caching is disabled for
+// the tool, and invalidates the results of the tools it invalidates in the thread.
+func (c *ToolCache) Put(threadID, tool, input, result string) {
+	for _, invalidated := range c.invalidates[tool] {
+		c.Invalidate(threadID, invalidated)
+	}
+
+	ttl, ok := c.ttls[tool]
+	if !ok {
+		ttl = c.ttl
+	}
+	if ttl <= 0 {
+		return
+	}
+	key := toolCacheKey{threadID: threadID, tool: tool, input: normalizeInput(input)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = toolCacheEntry{result: result, expires: time.Now().Add(ttl)}
+}
+
+// Invalidate drops the results of the tool cached in the thread, or of all its tools when tool
+// is empty.
+func (c *ToolCache) Invalidate(threadID, tool string) {
+	c.invalidate(func(key toolCacheKey) bool {
+		return key.threadID == threadID && (tool == "" || key.tool == tool)
+	})
+}
+
+// InvalidateInput drops the result of the tool for the input cached in the thread.
+func (c *ToolCache) InvalidateInput(threadID, tool, input string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, toolCacheKey{threadID: threadID, tool: tool, input: normalizeInput(input)})
+}
+
+// InvalidateTool drops the results of the tool cached in every thread, e.g. when the data it
+// reads changed.
+func (c *ToolCache) InvalidateTool(tool string) {
+	c.invalidate(func(key toolCacheKey) bool { return key.tool == tool })
+}
+
+func (c *ToolCache) invalidate(match func(toolCacheKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if match(key) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// normalizeInput returns the input in canonical form: JSON inputs compacted with their object
+// keys sorted, other inputs trimmed.
+func normalizeInput(input string) string {
+	var v any
+	if err := json.Unmarshal([]byte(input), &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			return string(normalized)
+		}
+	}
+	return strings.TrimSpace(input)
+}
+
+// cachedCall returns the result of the tool for the input from the cache of the context, or
+// calls it and caches its result when it succeeds.
+func cachedCall(ctx context.Context, tool, input string, call func() (string, error)) (string, error) {
+	c := toolCacheFromContext(ctx)
+	if c == nil {
+		return call()
+	}
+	threadID := graph.ThreadID(ctx)
+	if result, ok := c.Get(threadID, tool, input); ok {
+		return result, nil
+	}
+	result, err := call()
+	if err == nil {
+		c.Put(threadID, tool, input, result)
+	}
+	return result, err
+}
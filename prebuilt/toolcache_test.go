@@ -0,0 +1,113 @@
+package prebuilt_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/prebuilt"
+)
+
+// counted counts its calls and returns the input of its calls with their number.
+type counted struct {
+	name  string
+	calls *atomic.Int32
+}
+
+func (c counted) Name() string        { return c.name }
+func (c counted) Description() string { return "Counts calls." }
+
+func (c counted) Call(_ context.Context, input string) (string, error) {
+	return input + "#" + string(rune('0'+c.calls.Add(1))), nil
+}
+
+func TestToolCache(t *testing.T) {
+	t.Parallel()
+
+	cache := prebuilt.NewToolCache(time.Minute,
+		prebuilt.WithToolTTL("send", 0),
+		prebuilt.WithToolTTL("stale", time.Nanosecond),
+		prebuilt.WithInvalidation("update", "fetch"),
+	)
+
+	cache.Put("t1", "fetch", `{"id": 1, "lang": "en"}`, "doc")
+	result, ok := cache.Get("t1", "fetch", `{"lang":"en","id":1}`)
+	assert.True(t, ok, "JSON inputs are normalized")
+	assert.Equal(t, "doc", result)
+	_, ok = cache.Get("t2", "fetch", `{"id":1,"lang":"en"}`)
+	assert.False(t, ok, "results are scoped by thread")
+
+	cache.Put("t1", "send", "hi", "sent")
+	_, ok = cache.Get("t1", "send", "hi")
+	assert.False(t, ok, "tools with a TTL of zero are not cached")
+
+	cache.Put("t1", "stale", "x", "y")
+	time.Sleep(time.Millisecond)
+	_, ok = cache.Get("t1", "stale", "x")
+	assert.False(t, ok, "results expire")
+
+	cache.Put("t1", "update", "doc", "updated")
+	_, ok = cache.Get("t1", "fetch", `{"id":1,"lang":"en"}`)
+	assert.False(t, ok, "update invalidates fetch")
+
+	cache.Put("t1", "fetch", " a ", "A")
+	cache.Put("t1", "fetch", "b", "B")
+	cache.Put("t2", "fetch", "a", "A")
+	cache.InvalidateInput("t1", "fetch", "a")
+	_, ok = cache.Get("t1", "fetch", "a")
+	assert.False(t, ok)
+	_, ok = cache.Get("t1", "fetch", "b")
+	assert.True(t, ok)
+
+	cache.Invalidate("t1", "")
+	_, ok = cache.Get("t1", "fetch", "b")
+	assert.False(t, ok)
+	_, ok = cache.Get("t2", "fetch", "a")
+	assert.True(t, ok)
+
+	cache.InvalidateTool("fetch")
+	_, ok = cache.Get("t2", "fetch", "a")
+	assert.False(t, ok)
+}
+
+func TestToolCacheAgent(t *testing.T) {
+	t.Parallel()
+
+	calls := new(atomic.Int32)
+	cache := prebuilt.NewToolCache(time.Minute)
+	run := func(threadID string) []llms.MessageContent {
+		model := &scriptedModel{responses: []*llms.ContentResponse{
+			respond("", toolCall("c1", "weather", `{"input":"paris"}`), toolCall("c2", "weather", `{"input": "paris"}`)),
+			respond("sunny"),
+		}}
+		agent, err := prebuilt.CreateReactAgent(model, []tools.Tool{counted{name: "weather", calls: calls}})
+		require.NoError(t, err)
+
+		ctx := graph.WithThreadID(prebuilt.WithToolCache(context.Background(), cache), threadID)
+		out, err := agent.Invoke(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "weather?")})
+		require.NoError(t, err)
+		return out
+	}
+
+	content := func(msg llms.MessageContent) string {
+		response, ok := msg.Parts[0].(llms.ToolCallResponse)
+		require.True(t, ok)
+		return response.Content
+	}
+
+	out := run("t1")
+	assert.Equal(t, "paris#1", content(out[2]))
+	assert.Equal(t, "paris#1", content(out[3]))
+	out = run("t1")
+	assert.Equal(t, "paris#1", content(out[2]))
+	out = run("t2")
+	assert.Equal(t, "paris#2", content(out[2]))
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,197 @@
+// Package privacy answers data-subject requests: it exports everything stored about a set of
+// threads as a portable archive and permanently deletes it from every backend holding it.
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// ArchiveVersion is the version of the archive layout written by Export.
+const ArchiveVersion = 1
+
+// Request identifies the data of a data subject.
+type Request struct {
+	// Subject identifies the person the data is about, e.g. a user ID. It is only recorded in
+	// the archive; the data is located through ThreadIDs.
+	Subject string `json:"subject"`
+
+	// ThreadIDs are the threads holding the data of the subject.
+	ThreadIDs []string `json:"thread_ids"`
+}
+
+// Source is a backend holding data about threads, such as a checkpointer or a store.
+type Source interface {
+	// Name identifies the source; its files are stored under this directory in archives.
+	Name() string
+
+	// Export returns the data of the request as files, by path relative to the source directory.
+	Export(ctx context.Context, req Request) (map[string][]byte, error)
+
+	// Delete permanently removes the data of the request.
+	Delete(ctx context.Context, req Request) error
+}
+
+// manifest is the manifest.json file of an archive.
+type manifest struct {
+	Version    int       `json:"version"`
+	Subject    string    `json:"subject"`
+	ThreadIDs  []string  `json:"thread_ids"`
+	Sources    []string  `json:"sources"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// Export writes a zip archive holding the data of the request from every source, with a
+// manifest.json describing it and one directory per source.
+func Export(ctx context.Context, w io.Writer, req Request, sources ...Source) error {
+	archive := zip.NewWriter(w)
+
+	m := manifest{
+		Version:    ArchiveVersion,
+		Subject:    req.Subject,
+		ThreadIDs:  req.ThreadIDs,
+		ExportedAt: time.Now().UTC(),
+	}
+	for _, source := range sources {
+		files, err := source.Export(ctx, req)
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", source.Name(), err)
+		}
+		m.Sources = append(m.Sources, source.Name())
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := writeFile(archive, path.Join(source.Name(), name), files[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := writeFile(archive, "manifest.json", data); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeFile(archive *zip.Writer, name string, data []byte) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete permanently removes the data of the request from every source. All the sources are
+// attempted even if some fail, and the failures are returned joined.
+func Delete(ctx context.Context, req Request, sources ...Source) error {
+	var errs []error
+	for _, source := range sources {
+		if err := source.Delete(ctx, req); err != nil {
+			errs = append(errs, fmt.Errorf("deleting from %s: %w", source.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkpoints is the Source of a checkpointer.
+type checkpoints struct {
+	name         string
+	checkpointer checkpoint.Checkpointer
+}
+
+// Checkpoints returns the Source of the threads stored in cp. Each thread is exported as
+// <thread>.json, with the thread ID path-escaped, holding its checkpoints with their states.
+// A checkpoint.History still writing to a deleted thread must be told with History.Delete.
+func Checkpoints(name string, cp checkpoint.Checkpointer) Source {
+	return &checkpoints{name: name, checkpointer: cp}
+}
+
+// Name returns the name of the source.
+func (c *checkpoints) Name() string {
+	return c.name
+}
+
+// exportedCheckpoint is a checkpoint as written to archives.
+type exportedCheckpoint struct {
+	ID        string            `json:"id"`
+	Step      int               `json:"step"`
+	Node      string            `json:"node"`
+	Kind      checkpoint.Kind   `json:"kind"`
+	Version   int               `json:"version"`
+	State     json.RawMessage   `json:"state"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Export returns one file per thread of the request holding checkpoints.
+func (c *checkpoints) Export(ctx context.Context, req Request) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, threadID := range req.ThreadIDs {
+		list, err := c.checkpointer.List(ctx, threadID)
+		if err != nil {
+			return nil, fmt.Errorf("listing thread %s: %w", threadID, err)
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		exported := make([]exportedCheckpoint, len(list))
+		for i, cp := range list {
+			if !json.Valid(cp.State) {
+				return nil, fmt.Errorf("thread %s: checkpoint %s: state is not JSON", threadID, cp.ID)
+			}
+			exported[i] = exportedCheckpoint{
+				ID:        cp.ID,
+				Step:      cp.Step,
+				Node:      cp.Node,
+				Kind:      cp.Kind,
+				Version:   cp.Version,
+				State:     json.RawMessage(cp.State),
+				Metadata:  cp.Metadata,
+				CreatedAt: cp.CreatedAt,
+			}
+		}
+
+		data, err := json.MarshalIndent(struct {
+			ThreadID    string               `json:"thread_id"`
+			Checkpoints []exportedCheckpoint `json:"checkpoints"`
+		}{threadID, exported}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("encoding thread %s: %w", threadID, err)
+		}
+		files[url.PathEscape(threadID)+".json"] = data
+	}
+	return files, nil
+}
+
+// Delete removes the checkpoints of the threads of the request.
+func (c *checkpoints) Delete(ctx context.Context, req Request) error {
+	var errs []error
+	for _, threadID := range req.ThreadIDs {
+		if err := c.checkpointer.Delete(ctx, threadID); err != nil {
+			errs = append(errs, fmt.Errorf("thread %s: %w", threadID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
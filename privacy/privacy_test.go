@@ -0,0 +1,126 @@
+package privacy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/privacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSource struct{}
+
+func (failingSource) Name() string { return "broken" }
+
+func (failingSource) Export(context.Context, privacy.Request) (map[string][]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingSource) Delete(context.Context, privacy.Request) error {
+	return errors.New("unavailable")
+}
+
+func seed(t *testing.T, cp checkpoint.Checkpointer, threads ...string) {
+	t.Helper()
+
+	history := checkpoint.NewHistory[string](cp, 10)
+	for _, thread := range threads {
+		_, err := history.Save(context.Background(), thread, 0, "chat", []string{"hello from " + thread})
+		require.NoError(t, err)
+		_, err = history.Save(context.Background(), thread, 1, "chat", []string{"hello from " + thread, "bye"})
+		require.NoError(t, err)
+	}
+}
+
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+	}
+	return files
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	seed(t, store, "ada/1", "ada-2", "bob")
+
+	req := privacy.Request{Subject: "ada", ThreadIDs: []string{"ada/1", "ada-2", "unknown"}}
+	var buf bytes.Buffer
+	require.NoError(t, privacy.Export(ctx, &buf, req, privacy.Checkpoints("checkpoints", store)))
+
+	files := readArchive(t, buf.Bytes())
+	assert.ElementsMatch(t, []string{"manifest.json", "checkpoints/ada%2F1.json", "checkpoints/ada-2.json"}, keys(files))
+
+	var m struct {
+		Version int      `json:"version"`
+		Subject string   `json:"subject"`
+		Sources []string `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &m))
+	assert.Equal(t, privacy.ArchiveVersion, m.Version)
+	assert.Equal(t, "ada", m.Subject)
+	assert.Equal(t, []string{"checkpoints"}, m.Sources)
+
+	var thread struct {
+		ThreadID    string `json:"thread_id"`
+		Checkpoints []struct {
+			Kind  string          `json:"kind"`
+			State json.RawMessage `json:"state"`
+		} `json:"checkpoints"`
+	}
+	require.NoError(t, json.Unmarshal(files["checkpoints/ada%2F1.json"], &thread))
+	assert.Equal(t, "ada/1", thread.ThreadID)
+	require.Len(t, thread.Checkpoints, 2)
+	assert.JSONEq(t, `["hello from ada/1"]`, string(thread.Checkpoints[0].State))
+	assert.Equal(t, "delta", thread.Checkpoints[1].Kind)
+
+	err := privacy.Export(ctx, io.Discard, req, failingSource{})
+	require.ErrorContains(t, err, "exporting broken")
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	seed(t, store, "ada", "bob")
+
+	req := privacy.Request{Subject: "ada", ThreadIDs: []string{"ada"}}
+	err := privacy.Delete(ctx, req, failingSource{}, privacy.Checkpoints("checkpoints", store))
+	require.ErrorContains(t, err, "deleting from broken")
+
+	remaining, err := store.List(ctx, "ada")
+	require.NoError(t, err)
+	assert.Empty(t, remaining, "later sources are deleted even if an earlier one fails")
+
+	remaining, err = store.List(ctx, "bob")
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+}
+
+func keys(m map[string][]byte) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
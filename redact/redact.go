@@ -0,0 +1,230 @@
+// Package redact masks secrets before state leaves the process, e.g. when it is written to a
+// checkpoint or a log, while letting the process that masked them restore the original values.
+package redact
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+)
+
+// DefaultMaxSecrets is the number of secrets a Redactor remembers unless set otherwise with
+// SetMaxSecrets.
+const DefaultMaxSecrets = 10000
+
+// tokenPattern matches the tokens replacing secrets.
+var tokenPattern = regexp.MustCompile(`\[REDACTED:[0-9a-f]{16}\]`)
+
+// Redactor replaces secrets with opaque tokens. A secret is either the value of a field marked
+// as secret, whatever its type, or a substring of a string matching a secret pattern.
+//
+// The same secret is always replaced with the same token within a process. The Redactor
+// remembers the secrets it masked most recently, up to DefaultMaxSecrets by default, so Restore
+// can put them back; tokens produced by another process, or whose secret was forgotten, are left
+// as they are. A Redactor is safe for concurrent use.
+type Redactor struct {
+	key      []byte
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+
+	mu         sync.Mutex
+	maxSecrets int
+	secrets    map[string]*list.Element
+
+	// recent holds the remembered secrets, most recently masked first.
+	recent *list.List
+}
+
+// secret is a secret remembered by a Redactor.
+type secret struct {
+	token string
+	value any
+}
+
+// New creates a Redactor masking the fields named fields, matched against JSON object keys,
+// and the substrings matching patterns.
+func New(fields []string, patterns ...*regexp.Regexp) *Redactor {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("redact: generating key: %v", err))
+	}
+
+	r := &Redactor{
+		key:        key,
+		fields:     make(map[string]bool, len(fields)),
+		patterns:   patterns,
+		maxSecrets: DefaultMaxSecrets,
+		secrets:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+	for _, f := range fields {
+		r.fields[f] = true
+	}
+	return r
+}
+
+// SetMaxSecrets sets the number of secrets the Redactor remembers to restore them, forgetting the
+// least recently masked ones beyond it. Values lower than 1 mean DefaultMaxSecrets.
+func (r *Redactor) SetMaxSecrets(n int) {
+	if n < 1 {
+		n = DefaultMaxSecrets
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxSecrets = n
+	r.evict()
+}
+
+// token returns the token of a value, remembering the value.
+func (r *Redactor) token(value any) string {
+	raw, _ := json.Marshal(value)
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write(raw)
+	token := "[REDACTED:" + hex.EncodeToString(mac.Sum(nil)[:8]) + "]"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.secrets[token]; ok {
+		r.recent.MoveToFront(e)
+		return token
+	}
+	r.secrets[token] = r.recent.PushFront(secret{token: token, value: value})
+	r.evict()
+	return token
+}
+
+// evict forgets the least recently masked secrets beyond the limit. The caller holds r.mu.
+func (r *Redactor) evict() {
+	for r.recent.Len() > r.maxSecrets {
+		e := r.recent.Back()
+		r.recent.Remove(e)
+		delete(r.secrets, e.Value.(secret).token)
+	}
+}
+
+// lookup returns the secret replaced with the token, if remembered.
+func (r *Redactor) lookup(token string) (any, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.secrets[token]
+	if !ok {
+		return nil, false
+	}
+	return e.Value.(secret).value, true
+}
+
+// String masks the substrings of s matching the secret patterns.
+func (r *Redactor) String(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllStringFunc(s, func(secret string) string {
+			return r.token(secret)
+		})
+	}
+	return s
+}
+
+// JSON masks the secrets of a JSON document.
+func (r *Redactor) JSON(data []byte) ([]byte, error) {
+	return r.rewrite(data, r.redact)
+}
+
+// Restore puts back the secrets masked by this Redactor in a JSON document.
+func (r *Redactor) Restore(data []byte) ([]byte, error) {
+	if !tokenPattern.Match(data) {
+		return data, nil
+	}
+	return r.rewrite(data, r.restore)
+}
+
+func (r *Redactor) rewrite(data []byte, walk func(v any) any) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding document: %w", err)
+	}
+
+	out, err := json.Marshal(walk(v))
+	if err != nil {
+		return nil, fmt.Errorf("encoding document: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Redactor) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if r.fields[k] && item != nil {
+				v[k] = r.token(item)
+			} else {
+				v[k] = r.redact(item)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.redact(item)
+		}
+	case string:
+		return r.String(v)
+	}
+	return v
+}
+
+func (r *Redactor) restore(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = r.restore(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.restore(item)
+		}
+	case string:
+		if value, ok := r.lookup(v); ok {
+			return value
+		}
+		return r.RestoreString(v)
+	}
+	return v
+}
+
+// RestoreString puts back the string secrets masked by this Redactor in s.
+func (r *Redactor) RestoreString(s string) string {
+	return tokenPattern.ReplaceAllStringFunc(s, func(token string) string {
+		if value, ok := r.lookup(token); ok {
+			if secret, ok := value.(string); ok {
+				return secret
+			}
+		}
+		return token
+	})
+}
+
+// ReplaceAttr masks the secret patterns in string attributes and the values of attributes whose
+// key is a secret field. It is meant to be used as slog.HandlerOptions.ReplaceAttr.
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if r.fields[a.Key] {
+		return slog.String(a.Key, r.token(a.Value.Resolve().Any()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.String(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,110 @@
+package redact_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var apiKey = regexp.MustCompile(`sk-[A-Za-z0-9]{8,}`)
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	r := redact.New([]string{"password", "card"}, apiKey)
+	doc := []byte(`{"user":"ada","password":"hunter2","card":{"number":4111,"cvc":"123"},` +
+		`"messages":["my key is sk-abcdefgh1234","hello"],"note":null}`)
+
+	masked, err := r.JSON(doc)
+	require.NoError(t, err)
+	for _, secret := range []string{"hunter2", "4111", "123", "sk-abcdefgh1234"} {
+		assert.NotContains(t, string(masked), secret)
+	}
+	assert.Contains(t, string(masked), `"user":"ada"`)
+	assert.Contains(t, string(masked), `"hello"`)
+	assert.Contains(t, string(masked), `my key is [REDACTED:`)
+
+	restored, err := r.Restore(masked)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(doc), string(restored))
+
+	again, err := r.JSON(doc)
+	require.NoError(t, err)
+	assert.Equal(t, masked, again, "the same secrets map to the same tokens")
+}
+
+func TestRestoreForeignTokens(t *testing.T) {
+	t.Parallel()
+
+	masked, err := redact.New([]string{"password"}).JSON([]byte(`{"password":"hunter2"}`))
+	require.NoError(t, err)
+
+	other := redact.New([]string{"password"})
+	restored, err := other.Restore(masked)
+	require.NoError(t, err)
+	assert.Equal(t, masked, restored, "another process cannot recover the secrets")
+
+	again, err := other.JSON([]byte(`{"password":"hunter2"}`))
+	require.NoError(t, err)
+	assert.NotEqual(t, masked, again, "tokens are keyed per Redactor")
+}
+
+func TestStrings(t *testing.T) {
+	t.Parallel()
+
+	r := redact.New(nil, apiKey)
+	masked := r.String("use sk-abcdefgh1234 twice: sk-abcdefgh1234")
+	assert.NotContains(t, masked, "sk-")
+	assert.Equal(t, "use sk-abcdefgh1234 twice: sk-abcdefgh1234", r.RestoreString(masked))
+
+	_, err := r.JSON([]byte(`{`))
+	require.Error(t, err)
+}
+
+func TestReplaceAttr(t *testing.T) {
+	t.Parallel()
+
+	r := redact.New([]string{"token"}, apiKey)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: r.ReplaceAttr}))
+
+	logger.Info("calling model with sk-abcdefgh1234",
+		"token", 42,
+		"prompt", "key sk-abcdefgh1234",
+		"error", errors.New("rejected sk-abcdefgh1234"),
+		"user", "ada",
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "sk-abcdefgh1234")
+	assert.NotContains(t, out, "token=42")
+	assert.Equal(t, 4, strings.Count(out, "[REDACTED:"))
+	assert.Contains(t, out, "user=ada")
+}
+
+func TestMaxSecrets(t *testing.T) {
+	t.Parallel()
+
+	r := redact.New(nil, apiKey)
+	r.SetMaxSecrets(2)
+
+	first := r.String("sk-aaaaaaaa1")
+	second := r.String("sk-bbbbbbbb2")
+	assert.Equal(t, first, r.String("sk-aaaaaaaa1"), "masking again keeps the secret remembered")
+	third := r.String("sk-cccccccc3")
+
+	assert.Equal(t, "sk-aaaaaaaa1", r.RestoreString(first))
+	assert.Equal(t, second, r.RestoreString(second), "the least recently masked secret is forgotten")
+	assert.Equal(t, "sk-cccccccc3", r.RestoreString(third))
+
+	r.SetMaxSecrets(1)
+	assert.Equal(t, first, r.RestoreString(first))
+	assert.Equal(t, "sk-cccccccc3", r.RestoreString(third))
+}
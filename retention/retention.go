@@ -0,0 +1,195 @@
+// Package retention deletes stored data once it outlives the retention configured for its
+// data class and tenant, so policies such as "traces 30 days, memories 2 years" are enforced
+// by a Janitor instead of custom cron jobs.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Class is a class of stored data with its own retention.
+type Class string
+
+const (
+	// ClassCheckpoints is the persisted state of threads.
+	ClassCheckpoints Class = "checkpoints"
+
+	// ClassTraces is execution traces and recorded model calls.
+	ClassTraces Class = "traces"
+
+	// ClassMemories is long-term memories.
+	ClassMemories Class = "memories"
+)
+
+// ErrUnsupportedTarget is returned when a target cannot be swept, e.g. a checkpointer that
+// cannot list its threads.
+var ErrUnsupportedTarget = errors.New("target cannot be swept")
+
+// Rules maps data classes to how long their data is kept. Classes without a positive duration
+// are kept forever.
+type Rules map[Class]time.Duration
+
+// Policy holds the retention rules of every tenant.
+type Policy struct {
+	// Default applies to data without a tenant and to the classes a tenant does not override.
+	Default Rules
+
+	// Tenants overrides the default rules per tenant.
+	Tenants map[string]Rules
+}
+
+// Retention returns how long data of the class is kept for the tenant, and false if it is kept
+// forever.
+func (p Policy) Retention(tenant string, class Class) (time.Duration, bool) {
+	if d, ok := p.Tenants[tenant][class]; ok {
+		return d, d > 0
+	}
+	d := p.Default[class]
+	return d, d > 0
+}
+
+// Target is a backend holding data of one class.
+type Target interface {
+	// Class returns the class of the data held by the target.
+	Class() Class
+
+	// Sweep deletes the data that expired at now, according to retention, which returns how long
+	// the data of a tenant is kept and false if it is kept forever. It returns how many items
+	// were deleted.
+	Sweep(ctx context.Context, now time.Time, retention func(tenant string) (time.Duration, bool)) (int, error)
+}
+
+// Janitor periodically enforces a Policy on a set of targets.
+type Janitor struct {
+	policy  Policy
+	targets []Target
+}
+
+// NewJanitor creates a Janitor enforcing policy on targets.
+func NewJanitor(policy Policy, targets ...Target) *Janitor {
+	return &Janitor{policy: policy, targets: targets}
+}
+
+// Report counts the items deleted by a sweep, per class.
+type Report map[Class]int
+
+// Sweep runs every target once. All the targets are swept even if some fail, and the failures
+// are returned joined.
+func (j *Janitor) Sweep(ctx context.Context) (Report, error) {
+	now := time.Now()
+	report := make(Report)
+
+	var errs []error
+	for _, target := range j.targets {
+		class := target.Class()
+		deleted, err := target.Sweep(ctx, now, func(tenant string) (time.Duration, bool) {
+			return j.policy.Retention(tenant, class)
+		})
+		report[class] += deleted
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sweeping %s: %w", class, err))
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// Run sweeps the targets every interval until ctx is done. Sweep failures are passed to
+// onError, if not nil, and do not stop the janitor.
+func (j *Janitor) Run(ctx context.Context, every time.Duration, onError func(error)) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := j.Sweep(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// checkpoints is the Target of a checkpointer.
+type checkpoints struct {
+	checkpointer checkpoint.Checkpointer
+}
+
+// Checkpoints returns the Target of the threads of cp, which must implement
+// checkpoint.ThreadLister. A thread is deleted as a whole once its latest checkpoint is older
+// than the retention of its tenant, read from the checkpoint.MetadataTenant metadata.
+func Checkpoints(cp checkpoint.Checkpointer) Target {
+	return &checkpoints{checkpointer: cp}
+}
+
+// Class returns ClassCheckpoints.
+func (c *checkpoints) Class() Class {
+	return ClassCheckpoints
+}
+
+// Sweep deletes the expired threads and returns how many were deleted.
+func (c *checkpoints) Sweep(ctx context.Context, now time.Time, retention func(tenant string) (time.Duration, bool)) (int, error) {
+	lister, ok := c.checkpointer.(checkpoint.ThreadLister)
+	if !ok {
+		return 0, fmt.Errorf("%w: %T does not list threads", ErrUnsupportedTarget, c.checkpointer)
+	}
+
+	threads, err := lister.Threads(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("listing threads: %w", err)
+	}
+
+	deleted := 0
+	for _, threadID := range threads {
+		latest, err := c.checkpointer.Latest(ctx, threadID)
+		if errors.Is(err, checkpoint.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("thread %s: %w", threadID, err)
+		}
+
+		keep, expires := retention(latest.Metadata[checkpoint.MetadataTenant])
+		if !expires || now.Sub(latest.CreatedAt) <= keep {
+			continue
+		}
+		if err := c.checkpointer.Delete(ctx, threadID); err != nil {
+			return deleted, fmt.Errorf("thread %s: %w", threadID, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// calls is the Target of a call recorder.
+type calls struct {
+	recorder *graph.CallRecorder
+}
+
+// Calls returns the Target of the model calls kept by r. Recorded calls have no tenant, so the
+// default traces retention applies.
+func Calls(r *graph.CallRecorder) Target {
+	return &calls{recorder: r}
+}
+
+// Class returns ClassTraces.
+func (c *calls) Class() Class {
+	return ClassTraces
+}
+
+// Sweep drops the expired calls and returns how many were dropped.
+func (c *calls) Sweep(_ context.Context, now time.Time, retention func(tenant string) (time.Duration, bool)) (int, error) {
+	keep, expires := retention("")
+	if !expires {
+		return 0, nil
+	}
+	return c.recorder.Prune(now.Add(-keep)), nil
+}
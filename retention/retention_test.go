@@ -0,0 +1,144 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const day = 24 * time.Hour
+
+func TestPolicyRetention(t *testing.T) {
+	t.Parallel()
+
+	p := retention.Policy{
+		Default: retention.Rules{retention.ClassTraces: 30 * day, retention.ClassMemories: 730 * day},
+		Tenants: map[string]retention.Rules{
+			"acme": {retention.ClassTraces: 7 * day, retention.ClassMemories: 0},
+		},
+	}
+
+	testCases := []struct {
+		tenant  string
+		class   retention.Class
+		keep    time.Duration
+		expires bool
+	}{
+		{"", retention.ClassTraces, 30 * day, true},
+		{"globex", retention.ClassMemories, 730 * day, true},
+		{"acme", retention.ClassTraces, 7 * day, true},
+		{"acme", retention.ClassMemories, 0, false},
+		{"acme", retention.ClassCheckpoints, 0, false},
+	}
+	for _, tc := range testCases {
+		keep, expires := p.Retention(tc.tenant, tc.class)
+		assert.Equal(t, tc.keep, keep, "%s/%s", tc.tenant, tc.class)
+		assert.Equal(t, tc.expires, expires, "%s/%s", tc.tenant, tc.class)
+	}
+}
+
+func putThread(t *testing.T, cp checkpoint.Checkpointer, threadID, tenant string, age time.Duration) {
+	t.Helper()
+
+	require.NoError(t, cp.Put(context.Background(), checkpoint.Checkpoint{
+		ThreadID:  threadID,
+		ID:        checkpoint.StepID(0),
+		Kind:      checkpoint.KindFull,
+		State:     []byte(`[]`),
+		Metadata:  map[string]string{checkpoint.MetadataTenant: tenant},
+		CreatedAt: time.Now().Add(-age),
+	}))
+}
+
+type failingTarget struct{}
+
+func (failingTarget) Class() retention.Class { return retention.ClassMemories }
+
+func (failingTarget) Sweep(context.Context, time.Time, func(string) (time.Duration, bool)) (int, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestJanitorSweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := checkpoint.NewMemory()
+	putThread(t, store, "old-default", "", 40*day)
+	putThread(t, store, "recent-default", "", 10*day)
+	putThread(t, store, "old-acme", "acme", 10*day)
+	putThread(t, store, "old-forever", "forever", 400*day)
+
+	recorder := graph.NewCallRecorder(graph.RecorderOptions{})
+	graph.RecordModelCall(graph.WithCallRecorder(ctx, recorder), "gpt", "prompt")("response", nil)
+
+	janitor := retention.NewJanitor(retention.Policy{
+		Default: retention.Rules{retention.ClassCheckpoints: 30 * day, retention.ClassTraces: time.Nanosecond},
+		Tenants: map[string]retention.Rules{
+			"acme":    {retention.ClassCheckpoints: 7 * day},
+			"forever": {retention.ClassCheckpoints: 0},
+		},
+	}, retention.Checkpoints(store), retention.Calls(recorder), failingTarget{})
+
+	time.Sleep(time.Millisecond)
+	report, err := janitor.Sweep(ctx)
+	require.ErrorContains(t, err, "sweeping memories: unavailable")
+	assert.Equal(t, retention.Report{
+		retention.ClassCheckpoints: 2,
+		retention.ClassTraces:      1,
+		retention.ClassMemories:    0,
+	}, report)
+
+	threads, err := store.Threads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-forever", "recent-default"}, threads)
+	assert.Empty(t, recorder.Calls())
+}
+
+type unlistable struct {
+	checkpoint.Checkpointer
+}
+
+func TestCheckpointsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	janitor := retention.NewJanitor(retention.Policy{}, retention.Checkpoints(unlistable{checkpoint.NewMemory()}))
+	_, err := janitor.Sweep(context.Background())
+	require.ErrorIs(t, err, retention.ErrUnsupportedTarget)
+}
+
+func TestJanitorRun(t *testing.T) {
+	t.Parallel()
+
+	store := checkpoint.NewMemory()
+	putThread(t, store, "old", "", 2*day)
+	janitor := retention.NewJanitor(retention.Policy{Default: retention.Rules{retention.ClassCheckpoints: day}},
+		retention.Checkpoints(store), failingTarget{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 100)
+	done := make(chan struct{})
+	go func() {
+		janitor.Run(ctx, time.Millisecond, func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+		close(done)
+	}()
+
+	require.Error(t, <-errs)
+	threads, err := store.Threads(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, threads)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,161 @@
+package semantic
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+)
+
+// ErrDimensionMismatch is returned when a vector does not have the dimension of the vectors of
+// its index.
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+// Hit is a vector found by a search, with its cosine similarity to the query, from -1 to 1.
+type Hit struct {
+	// ID identifies the vector in its index.
+	ID string
+
+	// Score is the cosine similarity of the vector to the query.
+	Score float64
+}
+
+// Index finds the vectors nearest to a query. Implementations must be safe for concurrent use.
+type Index interface {
+	// Add adds a vector, replacing any vector with the same ID.
+	Add(id string, vector []float32) error
+
+	// Remove removes the vector with the given ID, if any.
+	Remove(id string)
+
+	// Search returns the k vectors most similar to the query, by decreasing score, among those
+	// whose ID is accepted by keep, which may be nil to accept all of them.
+	Search(query []float32, k int, keep func(id string) bool) ([]Hit, error)
+}
+
+// FlatIndex is an Index comparing the query with every vector: searches are exact, and take a
+// time proportional to the number of vectors, which suits tests and small deployments of up to
+// tens of thousands of vectors. The zero value is ready to use and it is safe for concurrent
+// use.
+type FlatIndex struct {
+	mu        sync.RWMutex
+	dimension int
+	ids       []string
+	vectors   [][]float32
+	positions map[string]int
+}
+
+var _ Index = (*FlatIndex)(nil)
+
+// Add adds a vector, replacing any vector with the same ID. The first vector sets the dimension
+// of the index.
+func (x *FlatIndex) Add(id string, vector []float32) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.dimension == 0 {
+		x.dimension = len(vector)
+	}
+	if len(vector) != x.dimension {
+		return fmt.Errorf("%w: %d instead of %d", ErrDimensionMismatch, len(vector), x.dimension)
+	}
+	if x.positions == nil {
+		x.positions = make(map[string]int)
+	}
+
+	normalized := normalize(vector)
+	if i, ok := x.positions[id]; ok {
+		x.vectors[i] = normalized
+		return nil
+	}
+	x.positions[id] = len(x.ids)
+	x.ids = append(x.ids, id)
+	x.vectors = append(x.vectors, normalized)
+	return nil
+}
+
+// Remove removes the vector with the given ID, if any.
+func (x *FlatIndex) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	i, ok := x.positions[id]
+	if !ok {
+		return
+	}
+	last := len(x.ids) - 1
+	x.ids[i], x.vectors[i] = x.ids[last], x.vectors[last]
+	x.positions[x.ids[i]] = i
+	x.ids, x.vectors = x.ids[:last], x.vectors[:last]
+	delete(x.positions, id)
+}
+
+// Len returns the number of vectors of the index.
+func (x *FlatIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.ids)
+}
+
+// Search returns the k vectors most similar to the query, by decreasing score then ID, among
+// those whose ID is accepted by keep, which may be nil to accept all of them.
+func (x *FlatIndex) Search(query []float32, k int, keep func(id string) bool) ([]Hit, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if len(x.ids) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != x.dimension {
+		return nil, fmt.Errorf("%w: %d instead of %d", ErrDimensionMismatch, len(query), x.dimension)
+	}
+
+	query = normalize(query)
+	var hits []Hit
+	for i, id := range x.ids {
+		if keep == nil || keep(id) {
+			hits = append(hits, Hit{ID: id, Score: dot(query, x.vectors[i])})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+	return hits[:min(k, len(hits))], nil
+}
+
+// Cosine returns the cosine similarity of two vectors, from -1 to 1, or 0 if either is a zero
+// vector or their dimensions differ.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	return dot(normalize(a), normalize(b))
+}
+
+// normalize returns a copy of the vector scaled to unit length, or a zero vector.
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,98 @@
+package semantic_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/semantic"
+)
+
+func TestFlatIndex(t *testing.T) {
+	t.Parallel()
+
+	var index semantic.FlatIndex
+	require.NoError(t, index.Add("x", []float32{1, 0, 0}))
+	require.NoError(t, index.Add("xy", []float32{1, 1, 0}))
+	require.NoError(t, index.Add("y", []float32{0, 2, 0}))
+	require.NoError(t, index.Add("-x", []float32{-3, 0, 0}))
+
+	testCases := []struct {
+		name     string
+		query    []float32
+		k        int
+		keep     func(string) bool
+		expected []string
+	}{
+		{name: "nearest", query: []float32{2, 0.1, 0}, k: 2, expected: []string{"x", "xy"}},
+		{name: "all", query: []float32{0, 1, 0}, k: 10, expected: []string{"y", "xy", "-x", "x"}},
+		{name: "kept", query: []float32{1, 0, 0}, k: 2, keep: func(id string) bool { return id != "x" }, expected: []string{"xy", "y"}},
+		{name: "none", query: []float32{1, 0, 0}, k: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hits, err := index.Search(tc.query, tc.k, tc.keep)
+			require.NoError(t, err)
+			var ids []string
+			for _, hit := range hits {
+				ids = append(ids, hit.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestFlatIndexUpdate(t *testing.T) {
+	t.Parallel()
+
+	var index semantic.FlatIndex
+	require.NoError(t, index.Add("a", []float32{1, 0}))
+	require.NoError(t, index.Add("b", []float32{0, 1}))
+	require.NoError(t, index.Add("c", []float32{1, 1}))
+
+	// Replacing and removing vectors keeps the others searchable.
+	require.NoError(t, index.Add("a", []float32{0, 1}))
+	index.Remove("b")
+	index.Remove("missing")
+	assert.Equal(t, 2, index.Len())
+
+	hits, err := index.Search([]float32{0, 1}, 3, nil)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "a", hits[0].ID)
+	assert.InDelta(t, 1, hits[0].Score, 1e-6)
+	assert.Equal(t, "c", hits[1].ID)
+	assert.InDelta(t, 0.7071, hits[1].Score, 1e-4)
+
+	require.ErrorIs(t, index.Add("d", []float32{1, 2, 3}), semantic.ErrDimensionMismatch)
+	_, err = index.Search([]float32{1}, 1, nil)
+	require.ErrorIs(t, err, semantic.ErrDimensionMismatch)
+}
+
+func TestCosine(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		a, b     []float32
+		expected float64
+	}{
+		{name: "same direction", a: []float32{1, 2}, b: []float32{2, 4}, expected: 1},
+		{name: "opposite", a: []float32{1, 0}, b: []float32{-1, 0}, expected: -1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 3}, expected: 0},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 1}, expected: 0},
+		{name: "dimensions differ", a: []float32{1}, b: []float32{1, 1}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, tc.expected, semantic.Cosine(tc.a, tc.b), 1e-6)
+		})
+	}
+}
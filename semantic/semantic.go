@@ -0,0 +1,187 @@
+// Package semantic stores long-term memories of agents, such as facts learned about users, and
+// searches them by meaning: the texts of the memories are embedded as vectors, and searches
+// return the memories whose vectors are the most similar to the embedding of the query.
+//
+// Memory keeps the memories in process with a FlatIndex, so memory search works in tests and
+// small deployments without an external vector database.
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// ErrNotFound is returned when a memory does not exist.
+var ErrNotFound = errors.New("memory not found")
+
+// DefaultLimit is the number of memories returned by searches that set no limit.
+const DefaultLimit = 10
+
+// Item is a memory.
+type Item struct {
+	// ID identifies the memory in its store; Put sets it when empty.
+	ID string `json:"id"`
+
+	// Namespace groups the memories searched together, e.g. those of a user.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Text is the content of the memory, which is embedded.
+	Text string `json:"text"`
+
+	// Metadata holds application data about the memory, such as its source.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// CreatedAt is the time the memory was stored; Put sets it when zero.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Match is a memory found by a search.
+type Match struct {
+	Item
+
+	// Score is the cosine similarity of the memory to the query, from -1 to 1.
+	Score float64 `json:"score"`
+}
+
+// Query selects memories by similarity to a text.
+type Query struct {
+	// Text is the text the memories are compared with.
+	Text string
+
+	// Namespace selects the memories of a namespace; empty selects those without namespace.
+	Namespace string
+
+	// Limit is the maximum number of memories returned; DefaultLimit when zero.
+	Limit int
+
+	// MinScore excludes the memories less similar to the text; zero does not exclude any.
+	MinScore float64
+}
+
+// Store stores memories and searches them by meaning. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Put stores a memory and returns its ID, generated when empty.
+	Put(ctx context.Context, item Item) (string, error)
+
+	// Get returns the memory with the given ID.
+	Get(ctx context.Context, id string) (Item, error)
+
+	// Delete deletes the memory with the given ID, if any.
+	Delete(ctx context.Context, id string) error
+
+	// Search returns the memories most similar to the text of q, by decreasing score.
+	Search(ctx context.Context, q Query) ([]Match, error)
+}
+
+// Memory is an in-memory Store, embedding texts with an embedder and searching them with an
+// Index, for tests and single-process applications.
+type Memory struct {
+	embedder embeddings.Embedder
+	index    Index
+
+	mu    sync.RWMutex
+	next  int
+	items map[string]Item
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory creates a new in-memory store embedding texts with embedder. Its vectors are
+// searched with index, or a new FlatIndex when nil.
+func NewMemory(embedder embeddings.Embedder, index Index) *Memory {
+	if index == nil {
+		index = &FlatIndex{}
+	}
+	return &Memory{embedder: embedder, index: index, items: make(map[string]Item)}
+}
+
+// Put embeds the text of a memory and stores it, replacing any memory with the same ID.
+func (m *Memory) Put(ctx context.Context, item Item) (string, error) {
+	vectors, err := m.embedder.EmbedDocuments(ctx, []string{item.Text})
+	if err != nil {
+		return "", fmt.Errorf("embedding memory: %w", err)
+	}
+	if len(vectors) != 1 {
+		return "", fmt.Errorf("embedding memory: %d vectors for 1 text", len(vectors))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if item.ID == "" {
+		m.next++
+		item.ID = fmt.Sprintf("mem-%d", m.next)
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+	item.Metadata = maps.Clone(item.Metadata)
+	if err := m.index.Add(item.ID, vectors[0]); err != nil {
+		return "", fmt.Errorf("indexing memory %s: %w", item.ID, err)
+	}
+	m.items[item.ID] = item
+	return item.ID, nil
+}
+
+// Get returns the memory with the given ID.
+func (m *Memory) Get(_ context.Context, id string) (Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	item, ok := m.items[id]
+	if !ok {
+		return Item{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	item.Metadata = maps.Clone(item.Metadata)
+	return item, nil
+}
+
+// Delete deletes the memory with the given ID, if any.
+func (m *Memory) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.index.Remove(id)
+	delete(m.items, id)
+	return nil
+}
+
+// Search returns the memories of the namespace of q most similar to its text, by decreasing
+// score.
+func (m *Memory) Search(ctx context.Context, q Query) ([]Match, error) {
+	vector, err := m.embedder.EmbedQuery(ctx, q.Text)
+	if err != nil {
+		return nil, fmt.Errorf("embedding query: %w", err)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hits, err := m.index.Search(vector, limit, func(id string) bool {
+		return m.items[id].Namespace == q.Namespace
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching memories: %w", err)
+	}
+	matches := make([]Match, 0, len(hits))
+	for _, hit := range hits {
+		if q.MinScore != 0 && hit.Score < q.MinScore {
+			break
+		}
+		item := m.items[hit.ID]
+		item.Metadata = maps.Clone(item.Metadata)
+		matches = append(matches, Match{Item: item, Score: hit.Score})
+	}
+	return matches, nil
+}
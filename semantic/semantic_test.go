@@ -0,0 +1,145 @@
+package semantic_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/semantic"
+)
+
+// vocabulary are the dimensions of the embeddings of embedder.
+var vocabulary = []string{"coffee", "tea", "dog", "cat", "paris"}
+
+// embedder embeds texts as the counts of the words of vocabulary they contain.
+type embedder struct {
+	err error
+}
+
+func (e embedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		var err error
+		if vectors[i], err = e.EmbedQuery(ctx, text); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+func (e embedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	vector := make([]float32, len(vocabulary))
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		for i, v := range vocabulary {
+			if strings.HasPrefix(word, v) {
+				vector[i]++
+			}
+		}
+	}
+	return vector, nil
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := semantic.NewMemory(embedder{}, nil)
+
+	id, err := store.Put(ctx, semantic.Item{Namespace: "alice", Text: "Alice drinks coffee every morning"})
+	require.NoError(t, err)
+	assert.Equal(t, "mem-1", id)
+	for _, item := range []semantic.Item{
+		{ID: "pet", Namespace: "alice", Text: "Alice has a dog and a cat", Metadata: map[string]string{"source": "chat"}},
+		{Namespace: "alice", Text: "Alice lives in Paris"},
+		{Namespace: "bob", Text: "Bob drinks coffee and tea"},
+	} {
+		_, err := store.Put(ctx, item)
+		require.NoError(t, err)
+	}
+
+	item, err := store.Get(ctx, "pet")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"source": "chat"}, item.Metadata)
+	assert.WithinDuration(t, time.Now(), item.CreatedAt, time.Minute)
+
+	testCases := []struct {
+		name     string
+		query    semantic.Query
+		expected []string
+	}{
+		{
+			name:     "nearest first",
+			query:    semantic.Query{Namespace: "alice", Text: "coffee or cats?"},
+			expected: []string{"mem-1", "pet", "mem-2"},
+		},
+		{
+			name:     "limit",
+			query:    semantic.Query{Namespace: "alice", Text: "dogs", Limit: 1},
+			expected: []string{"pet"},
+		},
+		{
+			name:     "min score",
+			query:    semantic.Query{Namespace: "alice", Text: "coffee or cats?", MinScore: 0.4},
+			expected: []string{"mem-1", "pet"},
+		},
+		{
+			name:     "namespace",
+			query:    semantic.Query{Namespace: "bob", Text: "coffee"},
+			expected: []string{"mem-3"},
+		},
+		{
+			name:  "no namespace",
+			query: semantic.Query{Text: "coffee"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			matches, err := store.Search(ctx, tc.query)
+			require.NoError(t, err)
+			var ids []string
+			for _, match := range matches {
+				ids = append(ids, match.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := semantic.NewMemory(embedder{}, &semantic.FlatIndex{})
+	id, err := store.Put(ctx, semantic.Item{Text: "tea"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(ctx, id))
+	_, err = store.Get(ctx, id)
+	require.ErrorIs(t, err, semantic.ErrNotFound)
+	matches, err := store.Search(ctx, semantic.Query{Text: "tea"})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestMemoryEmbedderError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := semantic.NewMemory(embedder{err: errors.New("quota exceeded")}, nil)
+
+	_, err := store.Put(ctx, semantic.Item{Text: "tea"})
+	require.ErrorContains(t, err, "embedding memory: quota exceeded")
+	_, err = store.Search(ctx, semantic.Query{Text: "tea"})
+	require.ErrorContains(t, err, "embedding query: quota exceeded")
+}
@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"errors"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// Event is the JSON representation of a graph.StreamEvent.
+type Event[T any] struct {
+	// Kind classifies the event.
+	Kind graph.EventKind `json:"kind"`
+
+	// Step is the index of the step of node, route, chunk and merge events.
+	Step int `json:"step"`
+
+	// Node is the node of node, route, chunk and score events.
+	Node string `json:"node,omitempty"`
+
+	// Branch identifies the parallel branch that emitted the event.
+	Branch string `json:"branch,omitempty"`
+
+	// Branches are the branches joined by a merge event.
+	Branches []string `json:"branches,omitempty"`
+
+	// State is the state of node, merge and end events.
+	State *T `json:"state,omitempty"`
+
+	// Next are the nodes the edges taken lead to, for route events.
+	Next []string `json:"next,omitempty"`
+
+	// Score is the score of score events.
+	Score *graph.Score `json:"score,omitempty"`
+
+	// Chunk is the chunk of chunk events.
+	Chunk string `json:"chunk,omitempty"`
+
+	// Activity is the activity of activity events, such as graph.ActivityThinking.
+	Activity graph.Activity `json:"activity,omitempty"`
+
+	// Tool is the tool of graph.ActivityToolRunning events.
+	Tool string `json:"tool,omitempty"`
+
+	// Interrupt is the interrupt the run paused at, for end events.
+	Interrupt *graph.Interrupt `json:"interrupt,omitempty"`
+
+	// Error is the error that ended the run, for end events of failed runs.
+	Error string `json:"error,omitempty"`
+
+	// Reason is why the run ended, for end events.
+	Reason graph.Reason `json:"reason,omitempty"`
+}
+
+// NewEvent returns the JSON representation of e.
+func NewEvent[T any](e graph.StreamEvent[T]) Event[T] {
+	event := Event[T]{
+		Kind:     e.Kind,
+		Step:     e.Step,
+		Node:     e.Node,
+		Branch:   e.Branch,
+		Branches: e.Branches,
+		Score:    e.Score,
+		Chunk:    e.Chunk,
+		Activity: e.Activity,
+		Tool:     e.Tool,
+		Reason:   e.Reason,
+	}
+	switch e.Kind {
+	case graph.EventNode, graph.EventMerge, graph.EventEnd:
+		event.State = &e.State
+	case graph.EventRoute:
+		for _, edge := range e.Edges {
+			event.Next = append(event.Next, edge.To)
+		}
+	}
+	if e.Err != nil && !errors.As(e.Err, &event.Interrupt) {
+		event.Error = e.Err.Error()
+	}
+	return event
+}
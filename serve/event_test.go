@@ -0,0 +1,65 @@
+package serve_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve"
+)
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+
+	state := []string{"draft"}
+	interrupt := &graph.Interrupt{Node: "send", Next: []string{"send"}}
+	testCases := []struct {
+		name     string
+		event    graph.StreamEvent[[]string]
+		expected serve.Event[[]string]
+	}{
+		{
+			name:     "node",
+			event:    graph.StreamEvent[[]string]{Kind: graph.EventNode, Step: 1, Node: "draft", State: state},
+			expected: serve.Event[[]string]{Kind: graph.EventNode, Step: 1, Node: "draft", State: &state},
+		},
+		{
+			name: "route",
+			event: graph.StreamEvent[[]string]{Kind: graph.EventRoute, Node: "draft", Edges: []graph.Edge{
+				{From: "draft", To: "send"}, {From: "draft", To: "review"},
+			}},
+			expected: serve.Event[[]string]{Kind: graph.EventRoute, Node: "draft", Next: []string{"send", "review"}},
+		},
+		{
+			name:     "chunk",
+			event:    graph.StreamEvent[[]string]{Kind: graph.EventChunk, Node: "draft", Chunk: "dr", State: state},
+			expected: serve.Event[[]string]{Kind: graph.EventChunk, Node: "draft", Chunk: "dr"},
+		},
+		{
+			name:     "activity",
+			event:    graph.StreamEvent[[]string]{Kind: graph.EventActivity, Node: "search", Activity: graph.ActivityToolRunning, Tool: "web"},
+			expected: serve.Event[[]string]{Kind: graph.EventActivity, Node: "search", Activity: graph.ActivityToolRunning, Tool: "web"},
+		},
+		{
+			name:     "interrupted",
+			event:    graph.StreamEvent[[]string]{Kind: graph.EventEnd, State: state, Err: fmt.Errorf("paused: %w", interrupt), Reason: graph.ReasonInterrupted},
+			expected: serve.Event[[]string]{Kind: graph.EventEnd, State: &state, Interrupt: interrupt, Reason: graph.ReasonInterrupted},
+		},
+		{
+			name:     "failed",
+			event:    graph.StreamEvent[[]string]{Kind: graph.EventEnd, State: state, Err: errors.New("drafting failed"), Reason: graph.ReasonError},
+			expected: serve.Event[[]string]{Kind: graph.EventEnd, State: &state, Error: "drafting failed", Reason: graph.ReasonError},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, serve.NewEvent(tc.event))
+		})
+	}
+}
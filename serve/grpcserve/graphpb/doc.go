@@ -0,0 +1,4 @@
+// Package graphpb holds the protocol buffer messages and the gRPC service definition of graph
+// invocation, generated from graph.proto with protoc-gen-go and protoc-gen-go-grpc; see the
+// proto target of the Makefile. Clients in other languages generate theirs from graph.proto.
+package graphpb
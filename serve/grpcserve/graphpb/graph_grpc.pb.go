@@ -0,0 +1,270 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: graph.proto
+
+package graphpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Graph_Invoke_FullMethodName      = "/langgraphgo.graph.v1.Graph/Invoke"
+	Graph_Stream_FullMethodName      = "/langgraphgo.graph.v1.Graph/Stream"
+	Graph_GetState_FullMethodName    = "/langgraphgo.graph.v1.Graph/GetState"
+	Graph_UpdateState_FullMethodName = "/langgraphgo.graph.v1.Graph/UpdateState"
+)
+
+// GraphClient is the client API for Graph service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Graph invokes a compiled graph, streams its events and reads and updates the state of its
+// threads. States are JSON-encoded, as the state type of the graph is defined in Go.
+type GraphClient interface {
+	// Invoke runs the graph and returns the final state, or the state reached and the interrupt
+	// when the run paused. The status of failed runs has a google.rpc.ErrorInfo detail whose
+	// reason is why the run ended, as in Event.
+	Invoke(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (*InvokeResponse, error)
+	// Stream runs the graph and streams its events, ending with an event of kind "end".
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Graph_StreamClient, error)
+	// GetState returns the latest state of a thread.
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error)
+	// UpdateState patches the state of a thread between runs.
+	UpdateState(ctx context.Context, in *UpdateStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error)
+}
+
+type graphClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGraphClient(cc grpc.ClientConnInterface) GraphClient {
+	return &graphClient{cc}
+}
+
+func (c *graphClient) Invoke(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (*InvokeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvokeResponse)
+	err := c.cc.Invoke(ctx, Graph_Invoke_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Graph_StreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Graph_ServiceDesc.Streams[0], Graph_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &graphStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Graph_StreamClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type graphStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *graphStreamClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *graphClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateSnapshot)
+	err := c.cc.Invoke(ctx, Graph_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *graphClient) UpdateState(ctx context.Context, in *UpdateStateRequest, opts ...grpc.CallOption) (*StateSnapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateSnapshot)
+	err := c.cc.Invoke(ctx, Graph_UpdateState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GraphServer is the server API for Graph service.
+// All implementations must embed UnimplementedGraphServer
+// for forward compatibility
+//
+// Graph invokes a compiled graph, streams its events and reads and updates the state of its
+// threads. States are JSON-encoded, as the state type of the graph is defined in Go.
+type GraphServer interface {
+	// Invoke runs the graph and returns the final state, or the state reached and the interrupt
+	// when the run paused. The status of failed runs has a google.rpc.ErrorInfo detail whose
+	// reason is why the run ended, as in Event.
+	Invoke(context.Context, *InvokeRequest) (*InvokeResponse, error)
+	// Stream runs the graph and streams its events, ending with an event of kind "end".
+	Stream(*StreamRequest, Graph_StreamServer) error
+	// GetState returns the latest state of a thread.
+	GetState(context.Context, *GetStateRequest) (*StateSnapshot, error)
+	// UpdateState patches the state of a thread between runs.
+	UpdateState(context.Context, *UpdateStateRequest) (*StateSnapshot, error)
+	mustEmbedUnimplementedGraphServer()
+}
+
+// UnimplementedGraphServer must be embedded to have forward compatible implementations.
+type UnimplementedGraphServer struct {
+}
+
+func (UnimplementedGraphServer) Invoke(context.Context, *InvokeRequest) (*InvokeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Invoke not implemented")
+}
+func (UnimplementedGraphServer) Stream(*StreamRequest, Graph_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedGraphServer) GetState(context.Context, *GetStateRequest) (*StateSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedGraphServer) UpdateState(context.Context, *UpdateStateRequest) (*StateSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateState not implemented")
+}
+func (UnimplementedGraphServer) mustEmbedUnimplementedGraphServer() {}
+
+// UnsafeGraphServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GraphServer will
+// result in compilation errors.
+type UnsafeGraphServer interface {
+	mustEmbedUnimplementedGraphServer()
+}
+
+func RegisterGraphServer(s grpc.ServiceRegistrar, srv GraphServer) {
+	s.RegisterService(&Graph_ServiceDesc, srv)
+}
+
+func _Graph_Invoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).Invoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_Invoke_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).Invoke(ctx, req.(*InvokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Graph_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GraphServer).Stream(m, &graphStreamServer{ServerStream: stream})
+}
+
+type Graph_StreamServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type graphStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *graphStreamServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Graph_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Graph_UpdateState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GraphServer).UpdateState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Graph_UpdateState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GraphServer).UpdateState(ctx, req.(*UpdateStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Graph_ServiceDesc is the grpc.ServiceDesc for Graph service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Graph_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "langgraphgo.graph.v1.Graph",
+	HandlerType: (*GraphServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Invoke",
+			Handler:    _Graph_Invoke_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _Graph_GetState_Handler,
+		},
+		{
+			MethodName: "UpdateState",
+			Handler:    _Graph_UpdateState_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Graph_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "graph.proto",
+}
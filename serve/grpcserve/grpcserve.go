@@ -0,0 +1,270 @@
+// Package grpcserve exposes a compiled graph as the gRPC service Graph of graphpb, so graphs can
+// be called from other services and languages with typed contracts. States are JSON-encoded in
+// the messages, as the state type of the graph is defined in Go.
+//
+//	server := grpc.NewServer()
+//	graphpb.RegisterGraphServer(server, grpcserve.NewServer(runnable))
+//	err := server.Serve(listener)
+//
+// Errors are returned with gRPC status codes: InvalidArgument for states that cannot be
+// decoded, NotFound for threads without state, FailedPrecondition for GetState and UpdateState
+// without checkpointer, and Internal for failed runs. Runs paused at an interrupt succeed, with
+// the interrupt in the response. The status of failed runs has an errdetails.ErrorInfo detail
+// of ErrorDomain whose reason is the graph.Reason of the run.
+package grpcserve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve"
+	"github.com/cesto93/langgraphgo/serve/grpcserve/graphpb"
+)
+
+// ErrorDomain is the domain of the errdetails.ErrorInfo details of the status of failed runs.
+const ErrorDomain = "langgraphgo"
+
+// Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	strict bool
+}
+
+// WithStrict rejects the states with fields the state type does not declare or values of
+// another type, naming them, instead of silently dropping them; see checkpoint.DecodeStrict.
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// Server implements graphpb.GraphServer for a Runnable.
+type Server[T any] struct {
+	graphpb.UnimplementedGraphServer
+
+	runnable *graph.Runnable[T]
+	opts     options
+}
+
+var _ graphpb.GraphServer = (*Server[any])(nil)
+
+// NewServer returns the server of r.
+func NewServer[T any](r *graph.Runnable[T], opts ...Option) *Server[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Server[T]{runnable: r, opts: o}
+}
+
+// Invoke runs the graph on the state of the request, in its thread if any.
+func (s *Server[T]) Invoke(ctx context.Context, req *graphpb.InvokeRequest) (*graphpb.InvokeResponse, error) {
+	state, err := s.decode(req.GetState())
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.runnable.Invoke(runContext(ctx, req.GetThreadId()), state)
+	var interrupt *graph.Interrupt
+	if err != nil && !errors.As(err, &interrupt) {
+		return nil, runStatus(err)
+	}
+	reason := graph.ReasonOf(err)
+	encoded, err := encode(out)
+	if err != nil {
+		return nil, err
+	}
+	return &graphpb.InvokeResponse{State: encoded, Interrupt: interruptOf(interrupt), Reason: string(reason)}, nil
+}
+
+// Stream runs the graph on the state of the request, in its thread if any, and sends its
+// events.
+func (s *Server[T]) Stream(req *graphpb.StreamRequest, stream graphpb.Graph_StreamServer) error {
+	state, err := s.decode(req.GetState())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(runContext(stream.Context(), req.GetThreadId()))
+	defer cancel()
+	if req.GetActivity() {
+		ctx = graph.WithActivity(ctx)
+	}
+	events, err := s.runnable.Stream(ctx, state)
+	if err != nil {
+		return statusOf(err)
+	}
+
+	var sendErr error
+	for event := range events {
+		// Once sending failed, the run ends with the canceled context: drain its events.
+		if sendErr != nil {
+			continue
+		}
+		msg, err := eventOf(event)
+		if err == nil {
+			err = stream.Send(msg)
+		}
+		if err != nil {
+			sendErr = err
+			cancel()
+		}
+	}
+	return sendErr
+}
+
+// GetState returns the latest state of the thread of the request.
+func (s *Server[T]) GetState(ctx context.Context, req *graphpb.GetStateRequest) (*graphpb.StateSnapshot, error) {
+	snapshot, err := s.runnable.GetState(ctx, req.GetThreadId())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return snapshotOf(snapshot)
+}
+
+// UpdateState applies the update of the request to the state of its thread.
+func (s *Server[T]) UpdateState(ctx context.Context, req *graphpb.UpdateStateRequest) (*graphpb.StateSnapshot, error) {
+	update, err := s.decode(req.GetState())
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.runnable.UpdateState(ctx, req.GetThreadId(), update, req.GetAsNode())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return snapshotOf(snapshot)
+}
+
+// decode decodes a JSON-encoded state; an empty state is the zero state.
+func (s *Server[T]) decode(data []byte) (T, error) {
+	var state T
+	if len(data) == 0 {
+		return state, nil
+	}
+	if s.opts.strict {
+		state, err := checkpoint.DecodeStrict[T](data)
+		if err != nil {
+			return state, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return state, nil
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, status.Errorf(codes.InvalidArgument, "decoding state: %v", err)
+	}
+	return state, nil
+}
+
+func encode[T any](state T) ([]byte, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding state: %v", err)
+	}
+	return data, nil
+}
+
+// runContext returns the context of a run in the thread, if any.
+func runContext(ctx context.Context, threadID string) context.Context {
+	if threadID != "" {
+		ctx = graph.WithThreadID(ctx, threadID)
+	}
+	return ctx
+}
+
+// statusOf returns the status error of err.
+func statusOf(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, checkpoint.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, graph.ErrNoCheckpointer):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, graph.ErrNodeNotFound):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// runStatus returns the status error of a failed run, with its reason.
+func runStatus(err error) error {
+	st, detailed := status.Convert(statusOf(err)).WithDetails(&errdetails.ErrorInfo{
+		Reason: string(graph.ReasonOf(err)),
+		Domain: ErrorDomain,
+	})
+	if detailed != nil {
+		return statusOf(err)
+	}
+	return st.Err()
+}
+
+func interruptOf(interrupt *graph.Interrupt) *graphpb.Interrupt {
+	if interrupt == nil {
+		return nil
+	}
+	return &graphpb.Interrupt{
+		Node:     interrupt.Node,
+		After:    interrupt.After,
+		Step:     int32(interrupt.Step),
+		Next:     interrupt.Next,
+		ThreadId: interrupt.ThreadID,
+	}
+}
+
+func snapshotOf[T any](snapshot graph.StateSnapshot[T]) (*graphpb.StateSnapshot, error) {
+	encoded, err := encode(snapshot.State)
+	if err != nil {
+		return nil, err
+	}
+	return &graphpb.StateSnapshot{
+		State:        encoded,
+		Node:         snapshot.Node,
+		Next:         snapshot.Next,
+		Interrupt:    interruptOf(snapshot.Interrupt),
+		CheckpointId: snapshot.CheckpointID,
+		CreatedAt:    timestamppb.New(snapshot.CreatedAt),
+	}, nil
+}
+
+// eventOf returns the message of a stream event, built from its JSON representation.
+func eventOf[T any](e graph.StreamEvent[T]) (*graphpb.Event, error) {
+	event := serve.NewEvent(e)
+	msg := &graphpb.Event{
+		Kind:      string(event.Kind),
+		Step:      int32(event.Step),
+		Node:      event.Node,
+		Branch:    event.Branch,
+		Branches:  event.Branches,
+		Next:      event.Next,
+		Chunk:     event.Chunk,
+		Activity:  string(event.Activity),
+		Tool:      event.Tool,
+		Interrupt: interruptOf(event.Interrupt),
+		Error:     event.Error,
+		Reason:    string(event.Reason),
+	}
+	if event.State != nil {
+		encoded, err := encode(*event.State)
+		if err != nil {
+			return nil, err
+		}
+		msg.State = encoded
+	}
+	if score := event.Score; score != nil {
+		msg.Score = &graphpb.Score{
+			Scorer:   score.Scorer,
+			Node:     score.Node,
+			Value:    score.Value,
+			Rejected: score.Rejected,
+			Hard:     score.Hard,
+			Error:    score.Err,
+		}
+	}
+	return msg, nil
+}
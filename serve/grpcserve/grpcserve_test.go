@@ -0,0 +1,203 @@
+package grpcserve_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve/grpcserve"
+	"github.com/cesto93/langgraphgo/serve/grpcserve/graphpb"
+)
+
+type State struct {
+	Messages []string `json:"messages"`
+}
+
+// testClient returns the client of a server of a graph drafting and sending a message, pausing
+// before sending, and failing on a message "fail".
+func testClient(t *testing.T, checkpointed bool, opts ...grpcserve.Option) graphpb.GraphClient {
+	t.Helper()
+
+	g := graph.NewMessageGraph[State]("draft")
+	g.AddNode("draft", func(_ context.Context, state State) (State, error) {
+		if len(state.Messages) > 0 && state.Messages[0] == "fail" {
+			return state, errors.New("drafting failed")
+		}
+		return State{Messages: append(state.Messages, "draft")}, nil
+	})
+	g.AddNode("send", func(_ context.Context, state State) (State, error) {
+		return State{Messages: append(state.Messages, "send")}, nil
+	})
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	compileOpts := []graph.CompileOption{graph.WithInterruptBefore("send")}
+	if checkpointed {
+		compileOpts = append(compileOpts, graph.WithCheckpointer(checkpoint.NewMemory()))
+	}
+	runnable, err := g.Compile(compileOpts...)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	graphpb.RegisterGraphServer(server, grpcserve.NewServer(runnable, opts...))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return graphpb.NewGraphClient(conn)
+}
+
+func TestInvoke(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		req      *graphpb.InvokeRequest
+		opts     []grpcserve.Option
+		code     codes.Code
+		reason   string
+		expected *graphpb.InvokeResponse
+	}{
+		{
+			name: "interrupted",
+			req:  &graphpb.InvokeRequest{State: []byte(`{"messages": ["hi"]}`), ThreadId: "t1"},
+			expected: &graphpb.InvokeResponse{
+				State:     []byte(`{"messages":["hi","draft"]}`),
+				Interrupt: &graphpb.Interrupt{Node: "send", Step: 1, Next: []string{"send"}, ThreadId: "t1"},
+				Reason:    "interrupted",
+			},
+		},
+		{
+			name: "zero state",
+			req:  &graphpb.InvokeRequest{},
+			expected: &graphpb.InvokeResponse{
+				State:     []byte(`{"messages":["draft"]}`),
+				Interrupt: &graphpb.Interrupt{Node: "send", Step: 1, Next: []string{"send"}},
+				Reason:    "interrupted",
+			},
+		},
+		{
+			name:   "failed",
+			req:    &graphpb.InvokeRequest{State: []byte(`{"messages": ["fail"]}`)},
+			code:   codes.Internal,
+			reason: "node_error",
+		},
+		{
+			name: "invalid",
+			req:  &graphpb.InvokeRequest{State: []byte(`{"messages": `)},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "strict",
+			req:  &graphpb.InvokeRequest{State: []byte(`{"messages": ["hi"], "extra": 1}`)},
+			opts: []grpcserve.Option{grpcserve.WithStrict()},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := testClient(t, true, tc.opts...).Invoke(context.Background(), tc.req)
+			assert.Equal(t, tc.code, status.Code(err))
+			if tc.expected != nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected.GetState(), resp.GetState())
+				assert.Equal(t, tc.expected.GetInterrupt().String(), resp.GetInterrupt().String())
+				assert.Equal(t, tc.expected.GetReason(), resp.GetReason())
+			}
+			if tc.reason != "" {
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				info, ok := details[0].(*errdetails.ErrorInfo)
+				require.True(t, ok)
+				assert.Equal(t, tc.reason, info.GetReason())
+				assert.Equal(t, grpcserve.ErrorDomain, info.GetDomain())
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	stream, err := testClient(t, true).Stream(context.Background(), &graphpb.StreamRequest{
+		State:    []byte(`{"messages": ["hi"]}`),
+		ThreadId: "t1",
+		Activity: true,
+	})
+	require.NoError(t, err)
+
+	var events []*graphpb.Event
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+	var kinds []string
+	for _, event := range events {
+		kinds = append(kinds, event.GetKind()+" "+event.GetNode()+event.GetActivity())
+	}
+	assert.Equal(t, []string{"activity draftthinking", "node draft", "route draft", "activity sendawaiting_input", "end "}, kinds)
+	assert.Equal(t, []byte(`{"messages":["hi","draft"]}`), events[1].GetState())
+	assert.Equal(t, []string{"send"}, events[2].GetNext())
+	end := events[len(events)-1]
+	assert.Equal(t, []byte(`{"messages":["hi","draft"]}`), end.GetState())
+	assert.Equal(t, "send", end.GetInterrupt().GetNode())
+	assert.Empty(t, end.GetError())
+	assert.Equal(t, "interrupted", end.GetReason())
+}
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := testClient(t, true)
+	_, err := client.Invoke(ctx, &graphpb.InvokeRequest{State: []byte(`{"messages": ["hi"]}`), ThreadId: "t1"})
+	require.NoError(t, err)
+
+	snapshot, err := client.GetState(ctx, &graphpb.GetStateRequest{ThreadId: "t1"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"messages":["hi","draft"]}`), snapshot.GetState())
+	assert.Equal(t, []string{"send"}, snapshot.GetNext())
+	assert.Equal(t, "send", snapshot.GetInterrupt().GetNode())
+	assert.NotEmpty(t, snapshot.GetCheckpointId())
+	assert.False(t, snapshot.GetCreatedAt().AsTime().IsZero())
+
+	snapshot, err = client.UpdateState(ctx, &graphpb.UpdateStateRequest{
+		ThreadId: "t1",
+		State:    []byte(`{"messages": ["hi", "edited"]}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"messages":["hi","edited"]}`), snapshot.GetState())
+	assert.Equal(t, []string{"send"}, snapshot.GetNext())
+
+	_, err = client.GetState(ctx, &graphpb.GetStateRequest{ThreadId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.UpdateState(ctx, &graphpb.UpdateStateRequest{ThreadId: "t1", AsNode: "missing"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = testClient(t, false).GetState(ctx, &graphpb.GetStateRequest{ThreadId: "t1"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,65 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// NewPatchHandler returns the operator handler patching r at runtime during incidents, without
+// redeploying; see graph.Runnable.Patch:
+//
+//	GET    /               lists the patches as JSON
+//	PUT    /{kind}/{node}  applies the graph.Patch of the body, e.g. {"to": "escalate"} to
+//	                       /reroute/search, and responds with it
+//	DELETE /{kind}/{node}  reverts a patch
+//
+// Patches and their reversions are logged. Serve it separately from the handler of NewHandler,
+// behind the authentication of operators.
+func NewPatchHandler[T any](r *graph.Runnable[T]) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {
+		patches := r.Patches()
+		if patches == nil {
+			patches = []graph.Patch[T]{}
+		}
+		writeJSON(w, http.StatusOK, patches)
+	})
+	mux.HandleFunc("PUT /{kind}/{node}", func(w http.ResponseWriter, req *http.Request) {
+		var p graph.Patch[T]
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, DefaultMaxBodySize)).Decode(&p); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		p.Kind, p.Node = graph.PatchKind(req.PathValue("kind")), req.PathValue("node")
+		if err := r.Patch(p); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		slog.WarnContext(req.Context(), "graph patched", "kind", p.Kind, "node", p.Node, "to", p.To, "reason", p.Reason)
+		for _, applied := range r.Patches() {
+			if applied.Kind == p.Kind && applied.Node == p.Node {
+				p = applied
+			}
+		}
+		writeJSON(w, http.StatusOK, p)
+	})
+	mux.HandleFunc("DELETE /{kind}/{node}", func(w http.ResponseWriter, req *http.Request) {
+		kind, node := graph.PatchKind(req.PathValue("kind")), req.PathValue("node")
+		err := r.Unpatch(kind, node)
+		switch {
+		case errors.Is(err, graph.ErrPatchNotFound):
+			writeError(w, http.StatusNotFound, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		slog.WarnContext(req.Context(), "graph patch reverted", "kind", kind, "node", node)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
@@ -0,0 +1,84 @@
+package serve_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve"
+)
+
+func TestPatchHandler(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewMessageGraph[State]("search")
+	g.AddNode("search", func(_ context.Context, state State) (State, error) {
+		return state, errors.New("search API down")
+	})
+	g.AddNode("answer", func(_ context.Context, state State) (State, error) {
+		return State{Messages: append(state.Messages, "answer")}, nil
+	})
+	g.AddEdge("search", "answer")
+	g.SetFinishPoint("answer")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	server := httptest.NewServer(http.StripPrefix("/patches", serve.NewPatchHandler(runnable)))
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodPut, "/patches/disable/search", `{"fallback": {"messages": ["no results"]}, "reason": "INC-1"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var applied graph.Patch[State]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&applied))
+	assert.Equal(t, graph.PatchDisable, applied.Kind)
+	assert.Equal(t, "search", applied.Node)
+	assert.False(t, applied.AppliedAt.IsZero())
+
+	out, err := runnable.Invoke(context.Background(), State{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"no results", "answer"}, out.Messages)
+
+	resp = do(http.MethodGet, "/patches/", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var patches []graph.Patch[State]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&patches))
+	require.Len(t, patches, 1)
+	assert.Equal(t, "INC-1", patches[0].Reason)
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/patches/reroute/search", `{"to": "missing"}`, http.StatusBadRequest},
+		{http.MethodPut, "/patches/replace/search", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/patches/disable/search", `{`, http.StatusBadRequest},
+		{http.MethodDelete, "/patches/disable/search", "", http.StatusNoContent},
+		{http.MethodDelete, "/patches/disable/search", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, do(tt.method, tt.path, tt.body).StatusCode, "%s %s", tt.method, tt.path)
+	}
+
+	_, err = runnable.Invoke(context.Background(), State{})
+	require.ErrorContains(t, err, "search API down")
+	resp = do(http.MethodGet, "/patches/", "")
+	var none json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&none))
+	assert.JSONEq(t, `[]`, string(none))
+}
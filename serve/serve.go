@@ -0,0 +1,335 @@
+// Package serve exposes a compiled graph over HTTP, so a graph can be deployed as a service
+// without writing handlers:
+//
+//   - POST /invoke runs the graph on the JSON-encoded state of the body and responds with a
+//     Response;
+//   - POST /stream runs the graph the same way and streams its events as newline-delimited
+//     JSON, one Event per line, or as Server-Sent Events when the request accepts
+//     text/event-stream;
+//   - GET /stream streams the events of a run on the JSON-encoded state of the state query
+//     parameter as Server-Sent Events, for the EventSource of browsers;
+//   - GET /state/{thread} responds with the State of a thread;
+//   - GET /ws upgrades to a WebSocket for interactive human-in-the-loop runs: the client sends
+//     Commands as JSON messages, starting a run then approving, editing or rejecting the
+//     interrupts it pauses at, and the server pushes the events of the runs, one Event per
+//     message, the last one holding the interrupt the run paused at, if any.
+//
+// The thread_id query parameter of /invoke, /stream and /ws selects the thread of the run; see
+// graph.WithThreadID. Server-Sent Events are named after the kind of their Event, except the
+// last event of runs paused at an interrupt, named EventInterrupt, and hold the Event as JSON.
+// On a WebSocket, approving or editing without a run paused on the connection resumes the
+// interrupt its thread is paused at, so approval UIs can reconnect. Mount the handler under a
+// prefix with http.StripPrefix:
+//
+//	http.Handle("/support/", http.StripPrefix("/support", serve.NewHandler(runnable)))
+//
+// NewPatchHandler serves the operator API patching the graph at runtime during incidents.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/redact"
+)
+
+// DefaultMaxBodySize is the default size limit, in bytes, of request bodies.
+const DefaultMaxBodySize = 1 << 20
+
+// ContentTypeNDJSON is the content type of the newline-delimited JSON responses of /stream.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// Response is the response of /invoke.
+type Response[T any] struct {
+	// State is the final state, or the state reached when the run was interrupted.
+	State T `json:"state"`
+
+	// Interrupt is the interrupt the run paused at, if any.
+	Interrupt *graph.Interrupt `json:"interrupt,omitempty"`
+
+	// Reason is why the run ended: graph.ReasonCompleted or graph.ReasonInterrupted.
+	Reason graph.Reason `json:"reason"`
+}
+
+// State is the response of /state/{thread}: a graph.StateSnapshot.
+type State[T any] struct {
+	// State is the state of the thread.
+	State T `json:"state"`
+
+	// Node is the node that produced the state.
+	Node string `json:"node"`
+
+	// Next are the nodes to execute when resuming the thread; empty once it completed.
+	Next []string `json:"next,omitempty"`
+
+	// Interrupt is the interrupt the thread is paused at, if any.
+	Interrupt *graph.Interrupt `json:"interrupt,omitempty"`
+
+	// CheckpointID identifies the checkpoint the state was read from.
+	CheckpointID string `json:"checkpoint_id"`
+
+	// CreatedAt is the time the state was saved.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Error is the body of error responses.
+type Error struct {
+	// Error is the message of the error.
+	Error string `json:"error"`
+
+	// Reason is why the run ended, for the runs of /invoke that failed.
+	Reason graph.Reason `json:"reason,omitempty"`
+}
+
+// Option configures a handler.
+type Option func(*options)
+
+type options struct {
+	strict      bool
+	activity    bool
+	maxBodySize int64
+	redactor    *redact.Redactor
+}
+
+// WithStrict rejects the input states with fields the state type does not declare or values of
+// another type, naming them, instead of silently dropping them; see checkpoint.DecodeStrict.
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// WithActivity streams the activities of runs, such as nodes thinking, tools running and runs
+// awaiting human input, as activity events; see graph.WithActivity.
+func WithActivity() Option {
+	return func(o *options) { o.activity = true }
+}
+
+// WithMaxBodySize sets the size limit, in bytes, of request bodies; DefaultMaxBodySize by
+// default.
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) { o.maxBodySize = n }
+}
+
+// WithRedaction masks the secrets of the responses of the handler with r: the states, events
+// and errors of /invoke, /stream, /state and /ws, streamed as JSON or as Server-Sent Events.
+// The secrets r masked are restored in the states clients send, such as the states of responses
+// edited on a WebSocket, so runs see the original values.
+func WithRedaction(r *redact.Redactor) Option {
+	return func(o *options) { o.redactor = r }
+}
+
+// handler serves a Runnable.
+type handler[T any] struct {
+	runnable *graph.Runnable[T]
+	opts     options
+}
+
+// NewHandler returns the handler serving r, described by the package documentation.
+func NewHandler[T any](r *graph.Runnable[T], opts ...Option) http.Handler {
+	o := options{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := &handler[T]{runnable: r, opts: o}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /invoke", h.invoke)
+	mux.HandleFunc("POST /stream", h.stream)
+	mux.HandleFunc("GET /stream", h.stream)
+	mux.HandleFunc("GET /state/{thread}", h.state)
+	mux.Handle("GET /ws", websocket.Handler(h.ws))
+	return mux
+}
+
+func (h *handler[T]) invoke(w http.ResponseWriter, r *http.Request) {
+	state, err := h.decode(w, r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	out, err := h.runnable.Invoke(runContext(r), state)
+	var interrupt *graph.Interrupt
+	if err != nil && !errors.As(err, &interrupt) {
+		h.writeJSON(w, http.StatusInternalServerError, Error{Error: err.Error(), Reason: graph.ReasonOf(err)})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, Response[T]{State: out, Interrupt: interrupt, Reason: graph.ReasonOf(err)})
+}
+
+func (h *handler[T]) stream(w http.ResponseWriter, r *http.Request) {
+	state, err := h.decode(w, r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := runContext(r)
+	if h.opts.activity {
+		ctx = graph.WithActivity(ctx)
+	}
+	events, err := h.runnable.Stream(ctx, state)
+	if err != nil {
+		h.writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	var write func(id int, event Event[T]) error
+	if r.Method == http.MethodGet || acceptsEventStream(r) {
+		w.Header().Set("Content-Type", ContentTypeEventStream)
+		write = func(id int, event Event[T]) error { return writeSSE(w, id, event, h.opts.encode) }
+	} else {
+		w.Header().Set("Content-Type", ContentTypeNDJSON)
+		write = func(_ int, event Event[T]) error {
+			data, err := h.opts.encode(event)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(data, '\n'))
+			return err
+		}
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	failed := false
+	id := 0
+	for event := range events {
+		// Once the client is gone, the run ends with the request context: drain its events.
+		if failed {
+			continue
+		}
+		id++
+		if err := write(id, NewEvent(event)); err != nil {
+			failed = true
+			continue
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *handler[T]) state(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.runnable.GetState(r.Context(), r.PathValue("thread"))
+	switch {
+	case errors.Is(err, checkpoint.ErrNotFound):
+		h.writeError(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, graph.ErrNoCheckpointer):
+		h.writeError(w, http.StatusNotImplemented, err)
+		return
+	case err != nil:
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, State[T]{
+		State:        snapshot.State,
+		Node:         snapshot.Node,
+		Next:         snapshot.Next,
+		Interrupt:    snapshot.Interrupt,
+		CheckpointID: snapshot.CheckpointID,
+		CreatedAt:    snapshot.CreatedAt,
+	})
+}
+
+// decode decodes the state of the body of the request, or of its state query parameter for GET
+// requests; a GET request without state runs on the zero state.
+func (h *handler[T]) decode(w http.ResponseWriter, r *http.Request) (T, error) {
+	var state T
+	var body io.Reader = http.MaxBytesReader(w, r.Body, h.opts.maxBodySize)
+	if r.Method == http.MethodGet {
+		query := r.URL.Query().Get("state")
+		if query == "" {
+			return state, nil
+		}
+		body = strings.NewReader(query)
+	}
+	if !h.opts.strict && h.opts.redactor == nil {
+		if err := json.NewDecoder(body).Decode(&state); err != nil {
+			return state, fmt.Errorf("decoding state: %w", err)
+		}
+		return state, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return state, fmt.Errorf("reading state: %w", err)
+	}
+	return decodeState[T](h.opts, data)
+}
+
+// decodeState decodes a state sent by a client, with the secrets masked by the redactor set with
+// WithRedaction restored.
+func decodeState[T any](o options, data []byte) (T, error) {
+	var state T
+	if o.redactor != nil {
+		var err error
+		if data, err = o.redactor.Restore(data); err != nil {
+			return state, fmt.Errorf("decoding state: %w", err)
+		}
+	}
+	if o.strict {
+		return checkpoint.DecodeStrict[T](data)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("decoding state: %w", err)
+	}
+	return state, nil
+}
+
+// encode returns the JSON encoding of v, with its secrets masked by the redactor set with
+// WithRedaction, if any.
+func (o options) encode(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || o.redactor == nil {
+		return data, err
+	}
+	return o.redactor.JSON(data)
+}
+
+// writeJSON writes v as the JSON body of the response, with its secrets masked.
+func (h *handler[T]) writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := h.opts.encode(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// writeError writes err as the Error body of the response, with its secrets masked.
+func (h *handler[T]) writeError(w http.ResponseWriter, status int, err error) {
+	h.writeJSON(w, status, Error{Error: err.Error()})
+}
+
+// runContext returns the context of the run of the request, with its thread.
+func runContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if threadID := r.URL.Query().Get("thread_id"); threadID != "" {
+		ctx = graph.WithThreadID(ctx, threadID)
+	}
+	return ctx
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
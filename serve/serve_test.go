@@ -0,0 +1,268 @@
+package serve_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/redact"
+	"github.com/cesto93/langgraphgo/serve"
+)
+
+type State struct {
+	Messages []string `json:"messages"`
+}
+
+// testServer serves a graph drafting and sending a message, pausing before sending, and failing
+// on a message "fail".
+func testServer(t *testing.T, opts ...serve.Option) *httptest.Server {
+	t.Helper()
+
+	g := graph.NewMessageGraph[State]("draft")
+	g.AddNode("draft", func(ctx context.Context, state State) (State, error) {
+		if len(state.Messages) > 0 && state.Messages[0] == "fail" {
+			return state, errors.New("drafting failed")
+		}
+		if err := graph.StreamChunk(ctx, []byte("dr")); err != nil {
+			return state, err
+		}
+		return State{Messages: append(state.Messages, "draft")}, nil
+	})
+	g.AddNode("send", func(_ context.Context, state State) (State, error) {
+		return State{Messages: append(state.Messages, "send")}, nil
+	})
+	g.AddEdge("draft", "send")
+	g.SetFinishPoint("send")
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()), graph.WithInterruptBefore("send"))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(serve.NewHandler(runnable, opts...))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestInvoke(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		path     string
+		body     string
+		opts     []serve.Option
+		status   int
+		expected string
+	}{
+		{
+			name:     "interrupted",
+			path:     "/invoke?thread_id=t1",
+			body:     `{"messages": ["hi"]}`,
+			status:   http.StatusOK,
+			expected: `{"state":{"messages":["hi","draft"]},"interrupt":{"node":"send","step":1,"next":["send"],"from":["draft"],"thread_id":"t1"},"reason":"interrupted"}`,
+		},
+		{
+			name:     "without thread",
+			path:     "/invoke",
+			body:     `{"messages": ["hi"]}`,
+			status:   http.StatusOK,
+			expected: `{"state":{"messages":["hi","draft"]},"interrupt":{"node":"send","step":1,"next":["send"],"from":["draft"]},"reason":"interrupted"}`,
+		},
+		{
+			name:     "failed",
+			path:     "/invoke",
+			body:     `{"messages": ["fail"]}`,
+			status:   http.StatusInternalServerError,
+			expected: `{"error":"error in node draft: drafting failed","reason":"node_error"}`,
+		},
+		{
+			name:   "invalid",
+			path:   "/invoke",
+			body:   `{"messages": `,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "strict",
+			path:   "/invoke",
+			body:   `{"messages": ["hi"], "extra": 1}`,
+			opts:   []serve.Option{serve.WithStrict()},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "too large",
+			path:   "/invoke",
+			body:   `{"messages": ["hi"]}`,
+			opts:   []serve.Option{serve.WithMaxBodySize(4)},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := post(t, testServer(t, tc.opts...).URL+tc.path, tc.body)
+			require.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+			var body json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tc.expected != "" {
+				assert.JSONEq(t, tc.expected, string(body))
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	resp := post(t, testServer(t).URL+"/stream?thread_id=t1", `{"messages": ["hi"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, serve.ContentTypeNDJSON, resp.Header.Get("Content-Type"))
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, lines, 4)
+	assert.JSONEq(t, `{"kind":"chunk","step":0,"node":"draft","chunk":"dr"}`, lines[0])
+	assert.JSONEq(t, `{"kind":"node","step":0,"node":"draft","state":{"messages":["hi","draft"]}}`, lines[1])
+	assert.JSONEq(t, `{"kind":"route","step":0,"node":"draft","next":["send"]}`, lines[2])
+	assert.JSONEq(t, `{"kind":"end","step":0,"state":{"messages":["hi","draft"]},"interrupt":{"node":"send","step":1,"next":["send"],"from":["draft"],"thread_id":"t1"},"reason":"interrupted"}`, lines[3])
+}
+
+func TestStreamActivity(t *testing.T) {
+	t.Parallel()
+
+	resp := post(t, testServer(t, serve.WithActivity()).URL+"/stream?thread_id=t1", `{"messages": ["hi"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var activities []string
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		var event serve.Event[State]
+		require.NoError(t, dec.Decode(&event))
+		if event.Kind == graph.EventActivity {
+			activities = append(activities, event.Node+" "+string(event.Activity))
+		}
+	}
+	assert.Equal(t, []string{"draft thinking", "send awaiting_input"}, activities)
+}
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(t)
+	post(t, server.URL+"/invoke?thread_id=t1", `{"messages": ["hi"]}`)
+
+	resp, err := http.Get(server.URL + "/state/t1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var state serve.State[State]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.Equal(t, State{Messages: []string{"hi", "draft"}}, state.State)
+	assert.Equal(t, []string{"send"}, state.Next)
+	require.NotNil(t, state.Interrupt)
+	assert.Equal(t, "send", state.Interrupt.Node)
+
+	resp, err = http.Get(server.URL + "/state/missing")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/invoke")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestRedaction(t *testing.T) {
+	t.Parallel()
+
+	const secret = "sk-abcdefgh1234"
+	redactor := redact.New(nil, regexp.MustCompile(`sk-[a-z0-9]{8,}`))
+	server := testServer(t, serve.WithRedaction(redactor))
+	body := `{"messages": ["key ` + secret + `"]}`
+
+	for _, accept := range []string{"application/json", serve.ContentTypeEventStream} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/stream", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var out strings.Builder
+		_, err = io.Copy(&out, resp.Body)
+		require.NoError(t, err)
+		assert.NotContains(t, out.String(), secret, accept)
+		assert.Contains(t, out.String(), "key [REDACTED:", accept)
+	}
+
+	resp := post(t, server.URL+"/invoke?thread_id=t1", body)
+	var response serve.Response[State]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	masked := response.State.Messages[0]
+	assert.NotContains(t, masked, secret)
+
+	snapshot, err := http.Get(server.URL + "/state/t1")
+	require.NoError(t, err)
+	defer snapshot.Body.Close()
+	var state serve.State[State]
+	require.NoError(t, json.NewDecoder(snapshot.Body).Decode(&state))
+	assert.Equal(t, []string{masked, "draft"}, state.State.Messages)
+
+	// The masked states clients send back run on the secret.
+	var seen []string
+	g := graph.NewMessageGraph[State]("echo")
+	g.AddNode("echo", func(_ context.Context, state State) (State, error) {
+		seen = state.Messages
+		return state, nil
+	})
+	g.SetFinishPoint("echo")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	echo := httptest.NewServer(serve.NewHandler(runnable, serve.WithRedaction(redactor)))
+	t.Cleanup(echo.Close)
+
+	conn := dial(t, echo, "")
+	start, err := json.Marshal(map[string]any{"type": "start", "state": State{Messages: []string{masked}}})
+	require.NoError(t, err)
+	require.NoError(t, websocket.Message.Send(conn, string(start)))
+	for {
+		var message string
+		require.NoError(t, websocket.Message.Receive(conn, &message))
+		assert.NotContains(t, message, secret)
+		var event serve.Event[State]
+		require.NoError(t, json.Unmarshal([]byte(message), &event))
+		if event.Kind == graph.EventEnd {
+			assert.Equal(t, &State{Messages: []string{masked}}, event.State)
+			break
+		}
+	}
+	assert.Equal(t, []string{"key " + secret}, seen)
+}
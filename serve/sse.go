@@ -0,0 +1,50 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ContentTypeEventStream is the content type of the Server-Sent Events responses of /stream.
+const ContentTypeEventStream = "text/event-stream"
+
+// EventInterrupt is the name of the Server-Sent Event ending the streams of runs paused at an
+// interrupt, in place of graph.EventEnd.
+const EventInterrupt = "interrupt"
+
+// acceptsEventStream reports whether the client of the request asks for Server-Sent Events.
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if parsed, _, err := mime.ParseMediaType(mediaType); err == nil && parsed == ContentTypeEventStream {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sseName returns the name of the Server-Sent Event of e: its kind, or EventInterrupt for the
+// end of a run paused at an interrupt.
+func sseName[T any](e Event[T]) string {
+	if e.Kind == graph.EventEnd && e.Interrupt != nil {
+		return EventInterrupt
+	}
+	return string(e.Kind)
+}
+
+// writeSSE writes e as the Server-Sent Event with the ID, named after its kind and holding its
+// JSON representation, encoded with encode.
+func writeSSE[T any](w io.Writer, id int, e Event[T], encode func(v any) ([]byte, error)) error {
+	data, err := encode(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, sseName(e), data)
+	return err
+}
@@ -0,0 +1,109 @@
+package serve_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is a Server-Sent Event.
+type sseEvent struct {
+	id, name, data string
+}
+
+func readSSE(t *testing.T, resp *http.Response) []sseEvent {
+	t.Helper()
+
+	var events []sseEvent
+	var event sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		field, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.name = value
+		case "data":
+			event.data = value
+		case "":
+			events = append(events, event)
+			event = sseEvent{}
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestStreamEventStream(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(t)
+	testCases := []struct {
+		name     string
+		request  func() (*http.Request, error)
+		expected []sseEvent
+	}{
+		{
+			name: "accepted",
+			request: func() (*http.Request, error) {
+				req, err := http.NewRequest(http.MethodPost, server.URL+"/stream?thread_id=t1", strings.NewReader(`{"messages": ["hi"]}`))
+				if err == nil {
+					req.Header.Set("Accept", "text/html, text/event-stream;q=0.9")
+				}
+				return req, err
+			},
+			expected: []sseEvent{
+				{id: "1", name: "chunk", data: `{"kind":"chunk","step":0,"node":"draft","chunk":"dr"}`},
+				{id: "2", name: "node", data: `{"kind":"node","step":0,"node":"draft","state":{"messages":["hi","draft"]}}`},
+				{id: "3", name: "route", data: `{"kind":"route","step":0,"node":"draft","next":["send"]}`},
+				{id: "4", name: "interrupt", data: `{"kind":"end","step":0,"state":{"messages":["hi","draft"]},"interrupt":{"node":"send","step":1,"next":["send"],"from":["draft"],"thread_id":"t1"},"reason":"interrupted"}`},
+			},
+		},
+		{
+			name: "event source",
+			request: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, server.URL+"/stream?state="+url.QueryEscape(`{"messages": ["fail"]}`), nil)
+			},
+			expected: []sseEvent{
+				{id: "1", name: "end", data: `{"kind":"end","step":0,"state":{"messages":["fail"]},"error":"error in node draft: drafting failed","reason":"node_error"}`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := tc.request()
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+			events := readSSE(t, resp)
+			require.Len(t, events, len(tc.expected))
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected.id, events[i].id)
+				assert.Equal(t, expected.name, events[i].name)
+				assert.JSONEq(t, expected.data, events[i].data)
+			}
+		})
+	}
+}
+
+func TestStreamEventStreamInvalidState(t *testing.T) {
+	t.Parallel()
+
+	resp, err := http.Get(testServer(t).URL + "/stream?state=" + url.QueryEscape(`{"messages":`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,207 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+var (
+	// ErrNothingToResume is the error answering the commands resuming a run when neither the
+	// connection nor its thread is paused at an interrupt.
+	ErrNothingToResume = errors.New("no interrupt to resume")
+
+	// ErrRunInProgress is the error answering the commands sent while a run of the connection
+	// is in progress.
+	ErrRunInProgress = errors.New("run in progress")
+
+	// ErrUnknownCommand is the error answering commands of an unknown type.
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+const (
+	// EventRejected is the kind of the events acknowledging CommandReject, with the state of
+	// the interrupt rejected.
+	EventRejected graph.EventKind = "rejected"
+
+	// EventError is the kind of the events answering the commands that cannot be executed,
+	// with their error.
+	EventError graph.EventKind = "error"
+)
+
+// CommandType is the type of a Command.
+type CommandType string
+
+const (
+	// CommandStart runs the graph on the state of the command, or on the zero state.
+	CommandStart CommandType = "start"
+
+	// CommandApprove resumes the run paused at the interrupt with the state it paused with.
+	CommandApprove CommandType = "approve"
+
+	// CommandEdit resumes the run paused at the interrupt with the state of the command.
+	CommandEdit CommandType = "edit"
+
+	// CommandReject abandons the run paused at the interrupt, without executing its next
+	// nodes. The thread of the run, if any, stays paused at the interrupt.
+	CommandReject CommandType = "reject"
+)
+
+// Command is a message of the client of /ws.
+type Command struct {
+	// Type is the type of the command.
+	Type CommandType `json:"type"`
+
+	// State is the JSON-encoded input state of CommandStart, and the edited state of
+	// CommandEdit.
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// session is a WebSocket connection to /ws.
+type session[T any] struct {
+	h    *handler[T]
+	conn *websocket.Conn
+
+	// interrupt is the interrupt the last run of the connection paused at, with its state.
+	interrupt *graph.Interrupt
+	state     T
+}
+
+// ws serves the WebSocket connections of /ws: it executes the Commands the client sends, one
+// run at a time, and pushes the events of the runs to the client.
+func (h *handler[T]) ws(conn *websocket.Conn) {
+	defer conn.Close()
+	conn.MaxPayloadBytes = int(h.opts.maxBodySize)
+
+	// The runs of the connection end with it.
+	ctx, cancel := context.WithCancel(runContext(conn.Request()))
+	defer cancel()
+
+	commands := make(chan Command)
+	go func() {
+		defer cancel()
+		for {
+			var command Command
+			if err := websocket.JSON.Receive(conn, &command); err != nil {
+				return
+			}
+			select {
+			case commands <- command:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s := &session[T]{h: h, conn: conn}
+	for {
+		select {
+		case command := <-commands:
+			if err := s.execute(ctx, command, commands); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// execute executes the command, returning an error once the connection failed.
+func (s *session[T]) execute(ctx context.Context, command Command, commands <-chan Command) error {
+	if s.h.opts.activity {
+		ctx = graph.WithActivity(ctx)
+	}
+
+	var events <-chan graph.StreamEvent[T]
+	var err error
+	switch command.Type {
+	case CommandStart:
+		var state T
+		if len(command.State) > 0 {
+			state, err = s.decode(command.State)
+		}
+		if err == nil {
+			events, err = s.h.runnable.Stream(ctx, state)
+		}
+	case CommandApprove, CommandEdit:
+		var interrupt *graph.Interrupt
+		var state T
+		interrupt, state, err = s.pending(ctx)
+		if err == nil && command.Type == CommandEdit {
+			state, err = s.decode(command.State)
+		}
+		if err == nil {
+			events, err = s.h.runnable.ResumeStream(ctx, interrupt, state)
+		}
+	case CommandReject:
+		var state T
+		if _, state, err = s.pending(ctx); err == nil {
+			s.interrupt = nil
+			return s.send(Event[T]{Kind: EventRejected, State: &state})
+		}
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownCommand, command.Type)
+	}
+	if err != nil {
+		return s.send(Event[T]{Kind: EventError, Error: err.Error()})
+	}
+
+	s.interrupt = nil
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event.Kind == graph.EventEnd {
+				errors.As(event.Err, &s.interrupt)
+				s.state = event.State
+			}
+			if err := s.send(NewEvent(event)); err != nil {
+				return err
+			}
+		case command := <-commands:
+			if err := s.send(Event[T]{Kind: EventError, Error: fmt.Sprintf("%s: %s", command.Type, ErrRunInProgress)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pending returns the interrupt to resume, with its state: the interrupt the last run of the
+// connection paused at, or else the interrupt the thread of the connection is paused at.
+func (s *session[T]) pending(ctx context.Context) (*graph.Interrupt, T, error) {
+	if s.interrupt != nil {
+		return s.interrupt, s.state, nil
+	}
+	threadID := graph.ThreadID(ctx)
+	if threadID == "" {
+		var state T
+		return nil, state, ErrNothingToResume
+	}
+	interrupt, state, err := s.h.runnable.Pending(ctx, threadID)
+	if errors.Is(err, graph.ErrNotInterrupted) || errors.Is(err, graph.ErrNoCheckpointer) || errors.Is(err, checkpoint.ErrNotFound) {
+		return nil, state, fmt.Errorf("%w: %w", ErrNothingToResume, err)
+	}
+	return interrupt, state, err
+}
+
+// decode decodes the state of a command.
+func (s *session[T]) decode(data json.RawMessage) (T, error) {
+	return decodeState[T](s.h.opts, data)
+}
+
+// send pushes the event to the client, as a JSON text message.
+func (s *session[T]) send(event Event[T]) error {
+	data, err := s.h.opts.encode(event)
+	if err != nil {
+		return err
+	}
+	return websocket.Message.Send(s.conn, string(data))
+}
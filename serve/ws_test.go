@@ -0,0 +1,136 @@
+package serve_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/serve"
+)
+
+// dial connects to the /ws endpoint of the server, with the query.
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws"+query, "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// command sends the command and receives the events it produced, up to the last one.
+func command(t *testing.T, conn *websocket.Conn, c string) []serve.Event[State] {
+	t.Helper()
+
+	require.NoError(t, websocket.Message.Send(conn, c))
+	var events []serve.Event[State]
+	for {
+		var event serve.Event[State]
+		require.NoError(t, websocket.JSON.Receive(conn, &event))
+		events = append(events, event)
+		switch event.Kind {
+		case graph.EventEnd, serve.EventRejected, serve.EventError:
+			return events
+		}
+	}
+}
+
+// last returns the last of the events.
+func last(events []serve.Event[State]) serve.Event[State] {
+	return events[len(events)-1]
+}
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		resume   string
+		expected serve.Event[State]
+	}{
+		{
+			name:     "approve",
+			resume:   `{"type": "approve"}`,
+			expected: serve.Event[State]{Kind: graph.EventEnd, Step: 0, State: &State{Messages: []string{"hi", "draft", "send"}}, Reason: graph.ReasonCompleted},
+		},
+		{
+			name:     "edit",
+			resume:   `{"type": "edit", "state": {"messages": ["edited"]}}`,
+			expected: serve.Event[State]{Kind: graph.EventEnd, Step: 0, State: &State{Messages: []string{"edited", "send"}}, Reason: graph.ReasonCompleted},
+		},
+		{
+			name:     "reject",
+			resume:   `{"type": "reject"}`,
+			expected: serve.Event[State]{Kind: serve.EventRejected, State: &State{Messages: []string{"hi", "draft"}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := dial(t, testServer(t), "")
+			end := last(command(t, conn, `{"type": "start", "state": {"messages": ["hi"]}}`))
+			require.NotNil(t, end.Interrupt)
+			assert.Equal(t, "send", end.Interrupt.Node)
+			assert.Equal(t, graph.ReasonInterrupted, end.Reason)
+
+			assert.Equal(t, tc.expected, last(command(t, conn, tc.resume)))
+
+			// Once resumed or rejected, nothing is left to resume.
+			assert.Equal(t, serve.EventError, last(command(t, conn, `{"type": "approve"}`)).Kind)
+		})
+	}
+}
+
+func TestWebSocketReconnect(t *testing.T) {
+	t.Parallel()
+
+	server := testServer(t)
+	conn := dial(t, server, "?thread_id=t1")
+	end := last(command(t, conn, `{"type": "start", "state": {"messages": ["hi"]}}`))
+	require.NotNil(t, end.Interrupt)
+	require.NoError(t, conn.Close())
+
+	// The interrupt the thread is paused at is resumed from another connection.
+	conn = dial(t, server, "?thread_id=t1")
+	events := command(t, conn, `{"type": "approve"}`)
+	assert.Equal(t, "send", events[0].Node)
+	assert.Equal(t, &State{Messages: []string{"hi", "draft", "send"}}, last(events).State)
+
+	assert.Equal(t, serve.EventError, last(command(t, dial(t, server, "?thread_id=t2"), `{"type": "reject"}`)).Kind)
+}
+
+func TestWebSocketErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		command  string
+		opts     []serve.Option
+		expected string
+	}{
+		{name: "unknown", command: `{"type": "cancel"}`, expected: `unknown command: "cancel"`},
+		{name: "nothing to resume", command: `{"type": "edit", "state": {}}`, expected: "no interrupt to resume"},
+		{name: "invalid state", command: `{"type": "start", "state": {"messages": 1}}`, expected: "decoding state"},
+		{name: "strict", command: `{"type": "start", "state": {"extra": 1}}`, opts: []serve.Option{serve.WithStrict()}, expected: "extra"},
+		{name: "failed run", command: `{"type": "start", "state": {"messages": ["fail"]}}`, expected: "drafting failed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := dial(t, testServer(t, tc.opts...), "")
+			assert.Contains(t, last(command(t, conn, tc.command)).Error, tc.expected)
+
+			// The connection remains usable.
+			assert.NotNil(t, last(command(t, conn, `{"type": "start"}`)).Interrupt)
+		})
+	}
+}
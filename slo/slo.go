@@ -0,0 +1,390 @@
+// Package slo tracks service level objectives of graphs over their run events, such as "95% of
+// the runs of the support graph complete within 2s" or "interrupts are resolved within an hour",
+// and alerts when their error budget burns too fast.
+//
+// A Monitor is an events.Publisher: register it with an events.Emitter, or feed it the events
+// consumed from a message bus, and evaluate it periodically with Run:
+//
+//	monitor, err := slo.NewMonitor([]slo.Objective{{
+//		Name:      "support latency",
+//		Graph:     "support",
+//		Indicator: slo.IndicatorLatency,
+//		Threshold: 2 * time.Second,
+//		Target:    0.95,
+//	}}, slo.Options{Webhook: "https://alerts.example.com/hooks/slo"})
+//	go monitor.Run(ctx, time.Minute)
+//
+// Every objective is the share of good events in a rolling window that must reach a target.
+// The error budget is the remaining share, and the burn rate is the share of bad events
+// relative to the budget: at a burn rate of 1 the budget is exactly spent over the window. An
+// alert fires when the burn rate of an objective exceeds its maximum, and again, as resolved,
+// once it no longer does.
+package slo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/events"
+)
+
+// ErrInvalidObjective is returned by NewMonitor for objectives that cannot be evaluated.
+var ErrInvalidObjective = errors.New("invalid objective")
+
+// Default values of Objective and Options.
+const (
+	// DefaultWindow is the rolling window of objectives.
+	DefaultWindow = time.Hour
+
+	// DefaultMinEvents is the number of events in the window needed before an objective can
+	// be breached.
+	DefaultMinEvents = 20
+
+	// DefaultMaxBurnRate is the burn rate above which an objective is breached.
+	DefaultMaxBurnRate = 1.0
+
+	// WebhookTimeout bounds the delivery of the alert webhook.
+	WebhookTimeout = 10 * time.Second
+
+	// windowBuckets is the number of buckets counting the events of a window.
+	windowBuckets = 100
+)
+
+// Indicator is what an objective measures.
+type Indicator string
+
+const (
+	// IndicatorLatency counts as good the runs that ended within the threshold; failed runs
+	// are left to IndicatorErrors.
+	IndicatorLatency Indicator = "latency"
+
+	// IndicatorErrors counts as good the runs that did not fail.
+	IndicatorErrors Indicator = "errors"
+
+	// IndicatorInterruptResolution counts as good the interrupts resumed within the
+	// threshold, measured from the end of the interrupted run to the start of the run resuming
+	// its thread. Pending interrupts older than the threshold count as bad until they are
+	// resolved or older than the window.
+	IndicatorInterruptResolution Indicator = "interrupt_resolution"
+)
+
+// Objective is a service level objective.
+type Objective struct {
+	// Name identifies the objective in statuses and alerts.
+	Name string
+
+	// Graph selects the runs of a graph, from the events.MetadataGraph metadata of their
+	// events; empty selects every run.
+	Graph string
+
+	// Indicator is what the objective measures.
+	Indicator Indicator
+
+	// Threshold is the longest good duration of IndicatorLatency and
+	// IndicatorInterruptResolution.
+	Threshold time.Duration
+
+	// Target is the share of good events to reach, between 0 and 1 exclusive, e.g. 0.95.
+	Target float64
+
+	// Window is the rolling window of events; DefaultWindow is used when not positive.
+	Window time.Duration
+
+	// MinEvents is the number of events in the window needed before the objective can be
+	// breached; DefaultMinEvents is used when not positive.
+	MinEvents int
+
+	// MaxBurnRate is the burn rate above which the objective is breached;
+	// DefaultMaxBurnRate is used when not positive.
+	MaxBurnRate float64
+}
+
+// Options configures a Monitor.
+type Options struct {
+	// Webhook, if set, is the URL alerts are posted to as JSON.
+	Webhook string
+
+	// HTTPClient posts the webhook; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+
+	// OnAlert, if set, is called for every alert.
+	OnAlert func(Alert)
+
+	// Now returns the current time; time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Status is the state of an objective over its window.
+type Status struct {
+	// Objective is the name of the objective.
+	Objective string `json:"objective"`
+
+	// Graph is the graph of the objective.
+	Graph string `json:"graph,omitempty"`
+
+	// Indicator is what the objective measures.
+	Indicator Indicator `json:"indicator"`
+
+	// Events is the number of events in the window.
+	Events int `json:"events"`
+
+	// Good is the number of good events in the window.
+	Good int `json:"good"`
+
+	// SLI is the share of good events, 1 without events.
+	SLI float64 `json:"sli"`
+
+	// Target is the target of the objective.
+	Target float64 `json:"target"`
+
+	// BurnRate is the share of bad events relative to the error budget.
+	BurnRate float64 `json:"burn_rate"`
+
+	// Breached is set when the burn rate exceeds the maximum of the objective, with enough
+	// events.
+	Breached bool `json:"breached"`
+}
+
+// Alert reports that an objective was breached, or is no longer.
+type Alert struct {
+	Status
+
+	// Firing is set when the objective was breached, and unset when it recovered.
+	Firing bool `json:"firing"`
+
+	// At is the time of the alert.
+	At time.Time `json:"at"`
+}
+
+// Monitor evaluates objectives over run events. It is safe for concurrent use.
+type Monitor struct {
+	opts       Options
+	objectives []*objective
+}
+
+var _ events.Publisher = (*Monitor)(nil)
+
+// objective is the state of an objective.
+type objective struct {
+	Objective
+
+	mu      sync.Mutex
+	buckets []bucket
+	// interrupts holds the end of the interrupted runs by thread.
+	interrupts map[string]time.Time
+	firing     bool
+}
+
+// bucket counts the events of a slice of the window.
+type bucket struct {
+	start       time.Time
+	good, total int
+}
+
+// NewMonitor returns a monitor of the objectives.
+func NewMonitor(objectives []Objective, opts Options) (*Monitor, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	m := &Monitor{opts: opts}
+	var errs []error
+	for _, o := range objectives {
+		if o.Window <= 0 {
+			o.Window = DefaultWindow
+		}
+		if o.MinEvents <= 0 {
+			o.MinEvents = DefaultMinEvents
+		}
+		if o.MaxBurnRate <= 0 {
+			o.MaxBurnRate = DefaultMaxBurnRate
+		}
+		switch {
+		case o.Target <= 0 || o.Target >= 1:
+			errs = append(errs, fmt.Errorf("%w: %s: target %v not between 0 and 1", ErrInvalidObjective, o.Name, o.Target))
+		case o.Indicator != IndicatorLatency && o.Indicator != IndicatorErrors && o.Indicator != IndicatorInterruptResolution:
+			errs = append(errs, fmt.Errorf("%w: %s: unknown indicator %q", ErrInvalidObjective, o.Name, o.Indicator))
+		case o.Indicator != IndicatorErrors && o.Threshold <= 0:
+			errs = append(errs, fmt.Errorf("%w: %s: no threshold", ErrInvalidObjective, o.Name))
+		}
+		m.objectives = append(m.objectives, &objective{Objective: o, interrupts: make(map[string]time.Time)})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Publish records an event in the objectives of its graph. It never fails.
+func (m *Monitor) Publish(_ context.Context, e events.Event) error {
+	for _, o := range m.objectives {
+		if o.Graph == "" || o.Graph == e.Metadata[events.MetadataGraph] {
+			o.observe(e)
+		}
+	}
+	return nil
+}
+
+// observe records an event.
+func (o *objective) observe(e events.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch o.Indicator {
+	case IndicatorLatency:
+		if e.Kind == events.KindRunFinished && e.Status != events.StatusFailed {
+			o.record(e.Time, e.Duration <= o.Threshold)
+		}
+	case IndicatorErrors:
+		if e.Kind == events.KindRunFinished {
+			o.record(e.Time, e.Status != events.StatusFailed)
+		}
+	case IndicatorInterruptResolution:
+		if e.ThreadID == "" {
+			return
+		}
+		switch {
+		case e.Kind == events.KindRunFinished && e.Status == events.StatusInterrupted:
+			o.interrupts[e.ThreadID] = e.Time
+		case e.Kind == events.KindRunStarted:
+			if interrupted, ok := o.interrupts[e.ThreadID]; ok {
+				delete(o.interrupts, e.ThreadID)
+				o.record(e.Time, e.Time.Sub(interrupted) <= o.Threshold)
+			}
+		}
+	}
+}
+
+// record counts an event of the given time in its bucket. Events older than the last bucket
+// are counted in it.
+func (o *objective) record(at time.Time, good bool) {
+	width := o.Window / windowBuckets
+	start := at.Truncate(width)
+	if n := len(o.buckets); n == 0 || o.buckets[n-1].start.Before(start) {
+		o.buckets = append(o.buckets, bucket{start: start})
+	}
+	last := &o.buckets[len(o.buckets)-1]
+	last.total++
+	if good {
+		last.good++
+	}
+}
+
+// status returns the status of the objective at now, dropping the buckets out of the window.
+func (o *objective) status(now time.Time) Status {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	width := o.Window / windowBuckets
+	from := now.Add(-o.Window)
+	kept := 0
+	for kept < len(o.buckets) && !o.buckets[kept].start.Add(width).After(from) {
+		kept++
+	}
+	o.buckets = o.buckets[kept:]
+
+	s := Status{Objective: o.Name, Graph: o.Graph, Indicator: o.Indicator, Target: o.Target, SLI: 1}
+	for _, b := range o.buckets {
+		s.Events += b.total
+		s.Good += b.good
+	}
+	for thread, interrupted := range o.interrupts {
+		switch {
+		case interrupted.Before(from):
+			// Interrupts pending for longer than the window are forgotten, so abandoned threads
+			// do not hold memory and count as bad for one window only.
+			delete(o.interrupts, thread)
+		case now.Sub(interrupted) > o.Threshold:
+			s.Events++
+		}
+	}
+	if s.Events > 0 {
+		s.SLI = float64(s.Good) / float64(s.Events)
+	}
+	s.BurnRate = (1 - s.SLI) / (1 - o.Target)
+	s.Breached = s.Events >= o.MinEvents && s.BurnRate > o.MaxBurnRate
+	return s
+}
+
+// Evaluate returns the status of every objective, in order, and fires the alerts of the
+// objectives that were breached or recovered since the last evaluation. Alerts are delivered
+// before it returns; webhook failures are logged.
+func (m *Monitor) Evaluate(ctx context.Context) []Status {
+	now := m.opts.Now()
+	statuses := make([]Status, len(m.objectives))
+	for i, o := range m.objectives {
+		statuses[i] = o.status(now)
+
+		o.mu.Lock()
+		changed := o.firing != statuses[i].Breached
+		o.firing = statuses[i].Breached
+		o.mu.Unlock()
+		if changed {
+			m.alert(ctx, Alert{Status: statuses[i], Firing: statuses[i].Breached, At: now.UTC()})
+		}
+	}
+	return statuses
+}
+
+// Run evaluates the objectives every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Evaluate(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// alert delivers an alert. Webhook failures are logged, as there is no caller to report them
+// to.
+func (m *Monitor) alert(ctx context.Context, alert Alert) {
+	if m.opts.OnAlert != nil {
+		m.opts.OnAlert(alert)
+	}
+	if m.opts.Webhook != "" {
+		if err := m.post(ctx, alert); err != nil {
+			slog.ErrorContext(ctx, "slo alert webhook failed", "objective", alert.Objective, "error", err)
+		}
+	}
+}
+
+func (m *Monitor) post(ctx context.Context, alert Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package slo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/events"
+	"github.com/cesto93/langgraphgo/slo"
+)
+
+var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func finished(at time.Duration, status events.Status, duration time.Duration) events.Event {
+	return events.Event{
+		Kind: events.KindRunFinished, Status: status, Duration: duration, Time: start.Add(at),
+		Metadata: map[string]string{events.MetadataGraph: "support"},
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		objective slo.Objective
+		events    []events.Event
+		now       time.Duration
+		good      int
+		total     int
+		breached  bool
+	}{
+		{
+			name:      "latency",
+			objective: slo.Objective{Indicator: slo.IndicatorLatency, Threshold: time.Second, Target: 0.5, MinEvents: 2},
+			events: []events.Event{
+				finished(0, events.StatusCompleted, 500*time.Millisecond),
+				finished(0, events.StatusInterrupted, 2*time.Second),
+				finished(0, events.StatusCompleted, 3*time.Second),
+				finished(0, events.StatusFailed, 3*time.Second),
+			},
+			good:     1,
+			total:    3,
+			breached: true,
+		},
+		{
+			name:      "errors",
+			objective: slo.Objective{Indicator: slo.IndicatorErrors, Target: 0.5, MinEvents: 2},
+			events: []events.Event{
+				finished(0, events.StatusCompleted, time.Minute),
+				finished(0, events.StatusInterrupted, time.Minute),
+				finished(0, events.StatusFailed, 0),
+			},
+			good:  2,
+			total: 3,
+		},
+		{
+			name:      "other graph",
+			objective: slo.Objective{Graph: "billing", Indicator: slo.IndicatorErrors, Target: 0.5},
+			events:    []events.Event{finished(0, events.StatusFailed, 0)},
+		},
+		{
+			name:      "window",
+			objective: slo.Objective{Indicator: slo.IndicatorErrors, Target: 0.5, Window: time.Hour, MinEvents: 1},
+			events: []events.Event{
+				finished(0, events.StatusFailed, 0),
+				finished(30*time.Minute, events.StatusCompleted, 0),
+			},
+			now:   80 * time.Minute,
+			good:  1,
+			total: 1,
+		},
+		{
+			name:      "interrupt resolution",
+			objective: slo.Objective{Indicator: slo.IndicatorInterruptResolution, Threshold: 10 * time.Minute, Target: 0.9, MinEvents: 1},
+			events: []events.Event{
+				{Kind: events.KindRunFinished, Status: events.StatusInterrupted, ThreadID: "fast", Time: start},
+				{Kind: events.KindRunStarted, ThreadID: "fast", Time: start.Add(time.Minute)},
+				{Kind: events.KindRunFinished, Status: events.StatusInterrupted, ThreadID: "slow", Time: start},
+				{Kind: events.KindRunStarted, ThreadID: "slow", Time: start.Add(20 * time.Minute)},
+				{Kind: events.KindRunFinished, Status: events.StatusInterrupted, ThreadID: "pending", Time: start.Add(5 * time.Minute)},
+				{Kind: events.KindRunFinished, Status: events.StatusInterrupted, ThreadID: "recent", Time: start.Add(25 * time.Minute)},
+				{Kind: events.KindRunStarted, ThreadID: "never interrupted", Time: start},
+			},
+			now:      30 * time.Minute,
+			good:     1,
+			total:    3,
+			breached: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.objective.Name = tc.name
+			monitor, err := slo.NewMonitor([]slo.Objective{tc.objective}, slo.Options{
+				Now: func() time.Time { return start.Add(tc.now) },
+			})
+			require.NoError(t, err)
+			for _, e := range tc.events {
+				require.NoError(t, monitor.Publish(context.Background(), e))
+			}
+
+			statuses := monitor.Evaluate(context.Background())
+			require.Len(t, statuses, 1)
+			status := statuses[0]
+			assert.Equal(t, tc.name, status.Objective)
+			assert.Equal(t, tc.good, status.Good)
+			assert.Equal(t, tc.total, status.Events)
+			assert.Equal(t, tc.breached, status.Breached)
+			if tc.total > 0 {
+				assert.InDelta(t, float64(tc.good)/float64(tc.total), status.SLI, 1e-9)
+				assert.InDelta(t, (1-status.SLI)/(1-tc.objective.Target), status.BurnRate, 1e-9)
+			} else {
+				assert.Equal(t, 1.0, status.SLI)
+				assert.Zero(t, status.BurnRate)
+			}
+		})
+	}
+}
+
+func TestMonitorAlerts(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var posted []slo.Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert slo.Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		mu.Lock()
+		posted = append(posted, alert)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	now := start
+	var alerts []slo.Alert
+	monitor, err := slo.NewMonitor([]slo.Objective{{
+		Name: "errors", Graph: "support", Indicator: slo.IndicatorErrors, Target: 0.9, Window: time.Hour, MinEvents: 4,
+	}}, slo.Options{
+		Webhook: server.URL,
+		OnAlert: func(a slo.Alert) { alerts = append(alerts, a) },
+		Now:     func() time.Time { return now },
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Too few events to breach.
+	require.NoError(t, monitor.Publish(ctx, finished(0, events.StatusFailed, 0)))
+	assert.False(t, monitor.Evaluate(ctx)[0].Breached)
+	assert.Empty(t, alerts)
+
+	for range 3 {
+		require.NoError(t, monitor.Publish(ctx, finished(0, events.StatusCompleted, 0)))
+	}
+	assert.True(t, monitor.Evaluate(ctx)[0].Breached)
+	assert.True(t, monitor.Evaluate(ctx)[0].Breached)
+	require.Len(t, alerts, 1, "alerts fire once")
+	assert.True(t, alerts[0].Firing)
+	assert.Equal(t, "errors", alerts[0].Objective)
+	assert.InDelta(t, 2.5, alerts[0].BurnRate, 1e-9)
+
+	// The failure leaves the window.
+	now = start.Add(90 * time.Minute)
+	for range 4 {
+		require.NoError(t, monitor.Publish(ctx, finished(90*time.Minute, events.StatusCompleted, 0)))
+	}
+	assert.False(t, monitor.Evaluate(ctx)[0].Breached)
+	require.Len(t, alerts, 2)
+	assert.False(t, alerts[1].Firing)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, alerts, posted)
+}
+
+func TestNewMonitorErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := slo.NewMonitor([]slo.Objective{
+		{Name: "target", Indicator: slo.IndicatorErrors, Target: 1},
+		{Name: "indicator", Indicator: "cost", Target: 0.9},
+		{Name: "threshold", Indicator: slo.IndicatorLatency, Target: 0.9},
+		{Name: "valid", Indicator: slo.IndicatorErrors, Target: 0.9},
+	}, slo.Options{})
+	require.ErrorIs(t, err, slo.ErrInvalidObjective)
+	assert.EqualError(t, err, "invalid objective: target: target 1 not between 0 and 1\n"+
+		"invalid objective: indicator: unknown indicator \"cost\"\n"+
+		"invalid objective: threshold: no threshold")
+}
@@ -0,0 +1,116 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrUnknownNodeType is returned when a spec uses a node type that is not registered.
+var ErrUnknownNodeType = errors.New("unknown node type")
+
+// Factory builds the function of a node declared in a spec.
+type Factory[T any] func(node NodeSpec) (func(ctx context.Context, state T) (T, error), error)
+
+// Registry maps node types to the factories building them.
+type Registry[T any] struct {
+	factories map[string]Factory[T]
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry[T any]() *Registry[T] {
+	return &Registry[T]{
+		factories: make(map[string]Factory[T]),
+	}
+}
+
+// Register registers the factory of a node type, replacing any previous one.
+func (r *Registry[T]) Register(nodeType string, factory Factory[T]) {
+	r.factories[nodeType] = factory
+}
+
+// Types returns the registered node types in lexical order.
+func (r *Registry[T]) Types() []string {
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build builds the graph declared by the spec, using the registry to build node functions.
+// Factories receive the nodes with the policies they inherit set; see Spec.Policy.
+func Build[T any](s *Spec, r *Registry[T]) (*graph.MessageGraph[T], error) {
+	g := graph.NewMessageGraph[T](s.EntryPoint)
+
+	for _, node := range s.Nodes {
+		if _, ok := s.Groups[node.Group]; node.Group != "" && !ok {
+			return nil, fmt.Errorf("node %s: %w: %s", node.Name, ErrUnknownGroup, node.Group)
+		}
+		node = s.inherit(node)
+
+		factory, ok := r.factories[node.Type]
+		if !ok {
+			return nil, fmt.Errorf("node %s: %w: %s", node.Name, ErrUnknownNodeType, node.Type)
+		}
+
+		fn, err := factory(node)
+		if err != nil {
+			return nil, fmt.Errorf("building node %s: %w", node.Name, err)
+		}
+		var opts []graph.NodeOption
+		if r := node.Resources; r != nil {
+			opts = append(opts, graph.WithResources(graph.Resources{
+				Class:  graph.ResourceClass(r.Class),
+				API:    r.API,
+				Memory: int64(r.MemoryMB) << 20,
+			}))
+		}
+		if r := node.Retry; r != nil {
+			opts = append(opts, graph.WithRetry(r.MaxAttempts, time.Duration(r.Backoff)))
+		}
+		if node.Timeout > 0 {
+			opts = append(opts, graph.WithTimeout(time.Duration(node.Timeout)))
+		}
+		if node.SideEffects {
+			opts = append(opts, graph.WithSideEffects())
+		}
+		if node.Cache != nil {
+			fn = withCache(time.Duration(node.Cache.TTL), fn)
+		}
+		g.AddNodeWithOptions(node.Name, fn, opts...)
+	}
+
+	for _, edge := range s.Edges {
+		g.AddLabeledEdge(edge.From, edge.To, edge.Label, edge.Description)
+	}
+
+	return g, nil
+}
+
+// Load parses and validates a YAML spec against the registered node types, builds it and
+// compiles the resulting graph.
+func Load[T any](data []byte, r *Registry[T]) (*graph.Runnable[T], error) {
+	return LoadWithParams(data, r, nil)
+}
+
+// LoadWithParams is like Load but resolves the parameters of the spec with resolve,
+// so one spec can be instantiated per environment or per customer. The graph is compiled with
+// opts, e.g. graph.WithPlan.
+func LoadWithParams[T any](data []byte, r *Registry[T], resolve Resolver, opts ...graph.CompileOption) (*graph.Runnable[T], error) {
+	s, err := ParseWithParams(data, resolve, r.Types()...)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := Build(s, r)
+	if err != nil {
+		return nil, err
+	}
+	return g.Compile(opts...)
+}
@@ -0,0 +1,171 @@
+package spec_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry(failures *int) *spec.Registry[[]string] {
+	r := spec.NewRegistry[[]string]()
+	r.Register("append", func(node spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		text, _ := node.Config["text"].(string)
+		return func(_ context.Context, state []string) ([]string, error) {
+			return append(state, text), nil
+		}, nil
+	})
+	r.Register("flaky", func(node spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		return func(_ context.Context, state []string) ([]string, error) {
+			if *failures > 0 {
+				*failures--
+				return nil, errors.New("flaky")
+			}
+			return append(state, node.Name), nil
+		}, nil
+	})
+	r.Register("slow", func(spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		return func(ctx context.Context, state []string) ([]string, error) {
+			<-ctx.Done()
+			return state, ctx.Err()
+		}, nil
+	})
+	return r
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	failures := 2
+	runnable, err := spec.Load([]byte(`
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    config:
+      text: hello
+  - name: unreliable
+    type: flaky
+    retry:
+      max_attempts: 3
+      backoff: 1ms
+edges:
+  - from: greet
+    to: unreliable
+  - from: unreliable
+    to: END
+`), testRegistry(&failures))
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello", "unreliable"}, output)
+	assert.Equal(t, 0, failures)
+}
+
+func TestLoadTimeout(t *testing.T) {
+	t.Parallel()
+
+	runnable, err := spec.Load([]byte(`
+entry_point: wait
+nodes:
+  - name: wait
+    type: slow
+    timeout: 5ms
+edges:
+  - from: wait
+    to: END
+`), testRegistry(new(int)))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, err, graph.ErrNodeTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestLoadRejectsUnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, err := spec.Load([]byte(`
+entry_point: a
+nodes:
+  - name: a
+    type: llm
+`), testRegistry(new(int)))
+	require.ErrorIs(t, err, spec.ErrInvalidSpec)
+	assert.True(t, strings.Contains(err.Error(), `"llm" is not one of [append flaky slow]`), err.Error())
+}
+
+func TestBuildUnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, err := spec.Build(&spec.Spec{
+		EntryPoint: "a",
+		Nodes:      []spec.NodeSpec{{Name: "a", Type: "llm"}},
+	}, testRegistry(new(int)))
+	assert.ErrorIs(t, err, spec.ErrUnknownNodeType)
+}
+
+func TestBuildEdgeLabels(t *testing.T) {
+	t.Parallel()
+
+	s, err := spec.Parse([]byte(`
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+edges:
+  - from: greet
+    to: END
+    label: done
+    description: greeting ends the conversation
+`), "append")
+	require.NoError(t, err)
+
+	g, err := spec.Build(s, testRegistry(new(int)))
+	require.NoError(t, err)
+	assert.Equal(t, []graph.Edge{{From: "greet", To: graph.END, Label: "done", Description: "greeting ends the conversation"}}, g.Topology().Edges)
+}
+
+func TestBuildResources(t *testing.T) {
+	t.Parallel()
+
+	s, err := spec.Parse([]byte(`
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    resources:
+      class: external_api
+      api: openai
+      memory_mb: 64
+edges:
+  - from: greet
+    to: END
+`), "append")
+	require.NoError(t, err)
+
+	g, err := spec.Build(s, testRegistry(new(int)))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]graph.Resources{
+		"greet": {Class: graph.ResourceExternalAPI, API: "openai", Memory: 64 << 20},
+	}, g.Topology().Resources)
+
+	_, err = spec.Parse([]byte(`
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    resources:
+      class: tpu
+`), "append")
+	require.ErrorIs(t, err, spec.ErrInvalidSpec)
+}
@@ -0,0 +1,141 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParamSpec declares a parameter of a spec.
+type ParamSpec struct {
+	// Description explains the parameter.
+	Description string `yaml:"description"`
+
+	// Default is the value used when none is provided.
+	Default string `yaml:"default"`
+
+	// Required reports whether a value must be provided.
+	Required bool `yaml:"required"`
+}
+
+// Resolver returns the value of a parameter, or false if it has none.
+type Resolver func(name string) (string, bool)
+
+// Values returns a resolver reading parameters from a map.
+func Values(values map[string]string) Resolver {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+// Env returns a resolver reading parameter name from the environment variable prefix+NAME,
+// with the name upper-cased.
+func Env(prefix string) Resolver {
+	return func(name string) (string, bool) {
+		return os.LookupEnv(prefix + strings.ToUpper(name))
+	}
+}
+
+// Chain returns a resolver asking each resolver in turn, so earlier resolvers take precedence.
+func Chain(resolvers ...Resolver) Resolver {
+	return func(name string) (string, bool) {
+		for _, resolve := range resolvers {
+			if resolve == nil {
+				continue
+			}
+			if v, ok := resolve(name); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// paramReference matches ${name} references.
+var paramReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolate resolves the parameters declared in the params section of the document and
+// substitutes their references in every other scalar. A scalar consisting of a single reference
+// takes the type of the value, so "max_attempts: ${retries}" is an integer.
+func (v *validator) interpolate(doc *yaml.Node, s *schema, resolve Resolver) {
+	values := make(map[string]string)
+	declared := make(map[string]bool)
+
+	if params := field(doc, "params"); params != nil && params.ShortTag() != "!!null" {
+		paramsSchema, _ := s.property("params")
+		v.check(params, paramsSchema, "params")
+		if len(v.errs) > 0 {
+			return
+		}
+
+		var specs map[string]ParamSpec
+		if err := params.Decode(&specs); err != nil {
+			v.fail(params, "params", "%v", err)
+			return
+		}
+		for i := 0; i+1 < len(params.Content); i += 2 {
+			name := params.Content[i]
+			p := specs[name.Value]
+			declared[name.Value] = true
+
+			value, ok := "", false
+			if resolve != nil {
+				value, ok = resolve(name.Value)
+			}
+			switch {
+			case ok:
+				values[name.Value] = value
+			case p.Required:
+				v.fail(name, "params."+name.Value, "no value provided for required parameter")
+			default:
+				values[name.Value] = p.Default
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if key.Value != "params" {
+			v.substitute(value, key.Value, values, declared)
+		}
+	}
+}
+
+// substitute replaces parameter references in the scalars of n.
+func (v *validator) substitute(n *yaml.Node, path string, values map[string]string, declared map[string]bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.substitute(n.Content[i+1], joinPath(path, n.Content[i].Value), values, declared)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			v.substitute(item, fmt.Sprintf("%s[%d]", path, i), values, declared)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "${") {
+			return
+		}
+
+		whole := paramReference.FindStringIndex(n.Value)
+		single := whole != nil && whole[0] == 0 && whole[1] == len(n.Value)
+
+		n.Value = paramReference.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			value, ok := values[name]
+			if !ok && !declared[name] {
+				v.fail(n, path, "undeclared parameter %q", name)
+			}
+			return value
+		})
+
+		if single && n.Style == 0 {
+			// Let YAML resolve the type of the substituted value.
+			n.Tag = ""
+		}
+	}
+}
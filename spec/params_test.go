@@ -0,0 +1,138 @@
+package spec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const parameterizedSpec = `
+params:
+  model:
+    default: small
+    description: Model used to answer.
+  retries:
+    default: 2
+  customer:
+    required: true
+entry_point: answer
+nodes:
+  - name: answer
+    type: append
+    config:
+      text: "${customer} uses ${model}"
+      model: ${model}
+    retry:
+      max_attempts: ${retries}
+    timeout: ${timeout}
+edges:
+  - from: answer
+    to: END
+`
+
+func TestParseWithParams(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`
+params:
+  model:
+    default: small
+  retries:
+    default: 2
+  customer:
+    required: true
+  quoted:
+    default: "7"
+entry_point: answer
+nodes:
+  - name: answer
+    type: append
+    config:
+      text: "${customer} uses ${model}"
+      quoted: "${quoted}"
+      number: ${quoted}
+    retry:
+      max_attempts: ${retries}
+    timeout: 10s
+`)
+
+	s, err := spec.ParseWithParams(data, spec.Values(map[string]string{"customer": "acme", "retries": "5"}))
+	require.NoError(t, err)
+
+	node := s.Nodes[0]
+	assert.Equal(t, "acme uses small", node.Config["text"])
+	assert.Equal(t, "7", node.Config["quoted"], "quoted references stay strings")
+	assert.Equal(t, 7, node.Config["number"], "plain references take the type of the value")
+	assert.Equal(t, 5, node.Retry.MaxAttempts)
+	assert.Equal(t, spec.Duration(10*time.Second), node.Timeout)
+	assert.True(t, s.Params["customer"].Required)
+}
+
+func TestParseWithParamsErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := spec.Parse([]byte(parameterizedSpec))
+	require.ErrorIs(t, err, spec.ErrInvalidSpec)
+
+	var messages []string
+	for _, e := range spec.FieldErrors(err) {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		"8:3: params.customer: no value provided for required parameter",
+		`19:14: nodes[0].timeout: undeclared parameter "timeout"`,
+	}, messages)
+
+	_, err = spec.Parse([]byte("params:\n  model: [a]\nentry_point: a\nnodes: []\n"))
+	require.ErrorIs(t, err, spec.ErrInvalidSpec)
+	assert.Contains(t, err.Error(), "params.model: expected a mapping")
+}
+
+func TestResolvers(t *testing.T) {
+	t.Setenv("GRAPH_MODEL", "from-env")
+
+	resolve := spec.Chain(nil, spec.Values(map[string]string{"customer": "acme"}), spec.Env("GRAPH_"))
+
+	v, ok := resolve("customer")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", v)
+
+	v, ok = resolve("model")
+	assert.True(t, ok)
+	assert.Equal(t, "from-env", v)
+
+	_, ok = resolve("missing")
+	assert.False(t, ok)
+}
+
+func TestLoadWithParams(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`
+params:
+  greeting:
+    required: true
+entry_point: greet
+nodes:
+  - name: greet
+    type: append
+    config:
+      text: ${greeting}
+edges:
+  - from: greet
+    to: END
+`)
+
+	for customer, greeting := range map[string]string{"acme": "hello", "globex": "ciao"} {
+		runnable, err := spec.LoadWithParams(data, testRegistry(new(int)), spec.Values(map[string]string{"greeting": greeting}))
+		require.NoError(t, err, customer)
+
+		output, err := runnable.Invoke(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{greeting}, output)
+	}
+}
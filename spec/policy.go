@@ -0,0 +1,105 @@
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrUnknownGroup is returned when a node references a policy group the spec does not declare.
+var ErrUnknownGroup = errors.New("unknown policy group")
+
+// Policy returns the policies applying to a node: each of its retry, timeout and cache
+// policies is the one the node sets, or else the one its group sets, or else the default of
+// the spec. Setting a policy on a node overrides it entirely, e.g. a node retried by its group
+// with a backoff is not retried with max_attempts set to 1.
+func (s *Spec) Policy(node NodeSpec) PolicySpec {
+	policy := PolicySpec{Retry: node.Retry, Timeout: node.Timeout, Cache: node.Cache}
+	for _, inherited := range []PolicySpec{s.Groups[node.Group], s.Defaults} {
+		if policy.Retry == nil {
+			policy.Retry = inherited.Retry
+		}
+		if policy.Timeout == 0 {
+			policy.Timeout = inherited.Timeout
+		}
+		if policy.Cache == nil {
+			policy.Cache = inherited.Cache
+		}
+	}
+	return policy
+}
+
+// inherit returns the node with the policies it inherits set.
+func (s *Spec) inherit(node NodeSpec) NodeSpec {
+	policy := s.Policy(node)
+	node.Retry, node.Timeout, node.Cache = policy.Retry, policy.Timeout, policy.Cache
+	return node
+}
+
+// withCache wraps a node function so that the state it returns for an input state is reused
+// for the same input state until the TTL elapses. Errors are not cached, and states that
+// cannot be encoded to JSON, which keys the cache, are never cached.
+func withCache[T any](ttl time.Duration, fn func(ctx context.Context, state T) (T, error)) func(ctx context.Context, state T) (T, error) {
+	cache := &stateCache[T]{ttl: ttl, entries: make(map[string]cachedState[T])}
+	return func(ctx context.Context, state T) (T, error) {
+		key, err := json.Marshal(state)
+		if err != nil {
+			return fn(ctx, state)
+		}
+		if out, ok := cache.get(string(key)); ok {
+			return out, nil
+		}
+
+		out, err := fn(ctx, state)
+		if err == nil {
+			cache.put(string(key), out)
+		}
+		return out, err
+	}
+}
+
+// stateCache holds the states returned by a node function, by encoded input state.
+type stateCache[T any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedState[T]
+}
+
+// cachedState is a state held by a stateCache.
+type cachedState[T any] struct {
+	state   T
+	expires time.Time
+}
+
+// get returns the state cached for key, if it has not expired.
+func (c *stateCache[T]) get(key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		var zero T
+		return zero, false
+	}
+	// Keep appends by the next nodes from writing into the cached state.
+	return graph.Clip(entry.state), true
+}
+
+// put caches the state for key, dropping the expired states.
+func (c *stateCache[T]) put(key string, state T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedState[T]{state: graph.Clip(state), expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,146 @@
+package spec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/spec"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	s, err := spec.Parse([]byte(`
+entry_point: a
+defaults:
+  retry:
+    max_attempts: 2
+  timeout: 30s
+groups:
+  llm:
+    timeout: 1m
+    cache:
+      ttl: 1h
+nodes:
+  - name: a
+    type: append
+  - name: b
+    type: append
+    group: llm
+  - name: c
+    type: append
+    group: llm
+    retry:
+      max_attempts: 1
+    timeout: 5s
+edges:
+  - from: a
+    to: b
+  - from: b
+    to: c
+  - from: c
+    to: END
+`), "append")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		node     int
+		expected spec.PolicySpec
+	}{
+		{
+			node: 0,
+			expected: spec.PolicySpec{
+				Retry:   &spec.RetrySpec{MaxAttempts: 2},
+				Timeout: spec.Duration(30 * time.Second),
+			},
+		},
+		{
+			node: 1,
+			expected: spec.PolicySpec{
+				Retry:   &spec.RetrySpec{MaxAttempts: 2},
+				Timeout: spec.Duration(time.Minute),
+				Cache:   &spec.CacheSpec{TTL: spec.Duration(time.Hour)},
+			},
+		},
+		{
+			node: 2,
+			expected: spec.PolicySpec{
+				Retry:   &spec.RetrySpec{MaxAttempts: 1},
+				Timeout: spec.Duration(5 * time.Second),
+				Cache:   &spec.CacheSpec{TTL: spec.Duration(time.Hour)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(s.Nodes[tc.node].Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, s.Policy(s.Nodes[tc.node]))
+		})
+	}
+}
+
+func TestPolicyUnknownGroup(t *testing.T) {
+	t.Parallel()
+
+	_, err := spec.Parse([]byte(`
+entry_point: a
+nodes:
+  - name: a
+    type: append
+    group: llm
+edges:
+  - from: a
+    to: END
+`), "append")
+	require.ErrorIs(t, err, spec.ErrInvalidSpec)
+	assert.Contains(t, err.Error(), `6:12: nodes[0].group: unknown group "llm"`)
+
+	_, err = spec.Build(&spec.Spec{
+		EntryPoint: "a",
+		Nodes:      []spec.NodeSpec{{Name: "a", Type: "append", Group: "llm"}},
+	}, testRegistry(new(int)))
+	require.ErrorIs(t, err, spec.ErrUnknownGroup)
+}
+
+func TestLoadInheritedPolicies(t *testing.T) {
+	t.Parallel()
+
+	failures := 2
+	runnable, err := spec.Load([]byte(`
+entry_point: unreliable
+defaults:
+  retry:
+    max_attempts: 3
+    backoff: 1ms
+groups:
+  cached:
+    cache:
+      ttl: 1h
+nodes:
+  - name: unreliable
+    type: flaky
+    group: cached
+edges:
+  - from: unreliable
+    to: END
+`), testRegistry(&failures))
+	require.NoError(t, err)
+
+	output, err := runnable.Invoke(context.Background(), []string{"in"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"in", "unreliable"}, output)
+	assert.Equal(t, 0, failures)
+
+	// The cached state is returned without executing the node, which would fail again.
+	failures = 1
+	output, err = runnable.Invoke(context.Background(), []string{"in"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"in", "unreliable"}, output)
+	assert.Equal(t, 1, failures)
+}
@@ -0,0 +1,221 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// schema is a small subset of JSON Schema describing the spec format.
+// The same description is used to validate specs and to generate the JSON Schema document,
+// so the two never drift apart.
+type schema struct {
+	typ         string
+	description string
+	properties  []property
+	required    []string
+	items       *schema
+	enum        []string
+	minimum     *int
+	pattern     string
+
+	// open allows properties that are not declared.
+	open bool
+
+	// additional is the schema of the properties that are not declared.
+	additional *schema
+}
+
+type property struct {
+	name   string
+	schema *schema
+}
+
+// durationPattern matches Go duration strings such as "1m30s" or "500ms".
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+func intPtr(i int) *int { return &i }
+
+// specSchema returns the schema of a spec whose node types are restricted to nodeTypes,
+// or unrestricted when nodeTypes is empty.
+func specSchema(nodeTypes []string) *schema {
+	duration := func(description string) *schema {
+		return &schema{typ: "string", description: description, pattern: durationPattern}
+	}
+
+	nodeType := &schema{typ: "string", description: "Type of the node, selecting the factory that builds it."}
+	if len(nodeTypes) > 0 {
+		nodeType.enum = append([]string(nil), nodeTypes...)
+		sort.Strings(nodeType.enum)
+	}
+
+	retry := &schema{
+		typ:         "object",
+		description: "Retry policy of the node.",
+		required:    []string{"max_attempts"},
+		properties: []property{
+			{"max_attempts", &schema{typ: "integer", description: "Total number of executions, including the first one.", minimum: intPtr(1)}},
+			{"backoff", duration("Delay before the first retry, doubled on every further retry.")},
+		},
+	}
+	cache := &schema{
+		typ:         "object",
+		description: "Cache policy of the node, reusing the state it returned for the same input state.",
+		required:    []string{"ttl"},
+		properties: []property{
+			{"ttl", duration("How long a returned state is reused.")},
+		},
+	}
+	policy := func(description string) *schema {
+		return &schema{
+			typ:         "object",
+			description: description,
+			properties: []property{
+				{"retry", retry},
+				{"timeout", duration("Maximum duration of a single execution of the node.")},
+				{"cache", cache},
+			},
+		}
+	}
+
+	node := &schema{
+		typ:         "object",
+		description: "A node of the graph.",
+		required:    []string{"name", "type"},
+		properties: []property{
+			{"name", &schema{typ: "string", description: "Unique name of the node."}},
+			{"type", nodeType},
+			{"config", &schema{typ: "object", description: "Configuration passed to the node factory.", open: true}},
+			{"group", &schema{typ: "string", description: "Policy group the node inherits the policies it does not set from."}},
+			{"retry", retry},
+			{"timeout", duration("Maximum duration of a single execution of the node.")},
+			{"cache", cache},
+			{"side_effects", &schema{typ: "boolean", description: "Marks the node as side-effecting, such as sending an email: it is not executed again after it may have run without confirmation."}},
+			{"resources", &schema{
+				typ:         "object",
+				description: "Resource hints used by schedulers to place and throttle executions of the node.",
+				properties: []property{
+					{"class", &schema{
+						typ:         "string",
+						description: "What bounds the execution of the node.",
+						enum:        []string{string(graph.ResourceCPU), string(graph.ResourceExternalAPI), string(graph.ResourceGPU)},
+					}},
+					{"api", &schema{typ: "string", description: "Name of the external API of external_api nodes, limited separately."}},
+					{"memory_mb", &schema{typ: "integer", description: "Estimated peak memory of an execution, in MiB.", minimum: intPtr(0)}},
+				},
+			}},
+		},
+	}
+
+	edge := &schema{
+		typ:         "object",
+		description: "An edge of the graph.",
+		required:    []string{"from", "to"},
+		properties: []property{
+			{"from", &schema{typ: "string", description: "Name of the source node."}},
+			{"to", &schema{typ: "string", description: "Name of the target node, or END."}},
+			{"label", &schema{typ: "string", description: "Short name of the transition, shown in rendered graphs."}},
+			{"description", &schema{typ: "string", description: "Why the transition exists."}},
+		},
+	}
+
+	return &schema{
+		typ:         "object",
+		description: "A declarative graph definition.",
+		required:    []string{"entry_point", "nodes"},
+		properties: []property{
+			{"name", &schema{typ: "string", description: "Name of the graph."}},
+			{"params", &schema{
+				typ:         "object",
+				description: "Parameters referenced in the spec as ${name}, resolved at load time.",
+				additional: &schema{
+					typ:         "object",
+					description: "A parameter.",
+					properties: []property{
+						{"description", &schema{typ: "string", description: "Description of the parameter."}},
+						{"default", &schema{typ: "scalar", description: "Value used when none is provided."}},
+						{"required", &schema{typ: "boolean", description: "Whether a value must be provided."}},
+					},
+				},
+			}},
+			{"entry_point", &schema{typ: "string", description: "Name of the first node executed."}},
+			{"defaults", policy("Policies of the nodes that neither they nor their group set.")},
+			{"groups", &schema{
+				typ:         "object",
+				description: "Policy groups, overriding the defaults for the nodes referencing them.",
+				additional:  policy("A policy group."),
+			}},
+			{"nodes", &schema{typ: "array", description: "Nodes of the graph.", items: node}},
+			{"edges", &schema{typ: "array", description: "Edges of the graph.", items: edge}},
+		},
+	}
+}
+
+func (s *schema) property(name string) (*schema, bool) {
+	for _, p := range s.properties {
+		if p.name == name {
+			return p.schema, true
+		}
+	}
+	return nil, false
+}
+
+// jsonSchema converts the schema to a JSON Schema document.
+func (s *schema) jsonSchema() map[string]any {
+	out := map[string]any{}
+	if s.typ != "scalar" {
+		out["type"] = s.typ
+	} else {
+		out["type"] = []string{"string", "number", "boolean"}
+	}
+	if s.description != "" {
+		out["description"] = s.description
+	}
+	if len(s.enum) > 0 {
+		out["enum"] = s.enum
+	}
+	if s.minimum != nil {
+		out["minimum"] = *s.minimum
+	}
+	if s.pattern != "" {
+		out["pattern"] = s.pattern
+	}
+	if s.items != nil {
+		out["items"] = s.items.jsonSchema()
+	}
+	if s.typ == "object" {
+		switch {
+		case s.additional != nil:
+			out["additionalProperties"] = s.additional.jsonSchema()
+		case !s.open:
+			out["additionalProperties"] = false
+		}
+		if len(s.properties) > 0 {
+			props := make(map[string]any, len(s.properties))
+			for _, p := range s.properties {
+				props[p.name] = p.schema.jsonSchema()
+			}
+			out["properties"] = props
+		}
+		if len(s.required) > 0 {
+			out["required"] = s.required
+		}
+	}
+	return out
+}
+
+// Schema returns the JSON Schema of specs whose node types are restricted to nodeTypes,
+// for use by editors and CI tooling. An empty nodeTypes accepts any node type.
+func Schema(nodeTypes ...string) ([]byte, error) {
+	doc := specSchema(nodeTypes).jsonSchema()
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	doc["title"] = "langgraphgo graph spec"
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	return data, nil
+}
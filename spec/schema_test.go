@@ -0,0 +1,44 @@
+package spec_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	data, err := spec.Schema("tool", "llm")
+	require.NoError(t, err)
+
+	var doc struct {
+		Schema               string   `json:"$schema"`
+		Required             []string `json:"required"`
+		AdditionalProperties *bool    `json:"additionalProperties"`
+		Properties           struct {
+			Nodes struct {
+				Items struct {
+					Properties map[string]struct {
+						Enum    []string `json:"enum"`
+						Pattern string   `json:"pattern"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"nodes"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Contains(t, doc.Schema, "json-schema.org")
+	assert.Equal(t, []string{"entry_point", "nodes"}, doc.Required)
+	require.NotNil(t, doc.AdditionalProperties)
+	assert.False(t, *doc.AdditionalProperties)
+
+	nodeProps := doc.Properties.Nodes.Items.Properties
+	assert.Equal(t, []string{"llm", "tool"}, nodeProps["type"].Enum)
+	assert.NotEmpty(t, nodeProps["timeout"].Pattern)
+	assert.Contains(t, nodeProps, "retry")
+}
@@ -0,0 +1,205 @@
+// Package spec loads graphs declared in YAML.
+//
+// A spec lists the nodes of a graph, each with a type registered in a Registry, and the edges
+// between them:
+//
+//	name: support
+//	params:
+//	  model:
+//	    default: gpt-4o
+//	entry_point: classify
+//	defaults:
+//	  timeout: 30s
+//	groups:
+//	  llm:
+//	    retry:
+//	      max_attempts: 3
+//	      backoff: 1s
+//	nodes:
+//	  - name: classify
+//	    type: llm
+//	    group: llm
+//	    config:
+//	      model: ${model}
+//	      prompt: classify the request
+//	    timeout: 10s
+//	    resources:
+//	      class: external_api
+//	      api: openai
+//	  - name: answer
+//	    type: llm
+//	    group: llm
+//	    cache:
+//	      ttl: 1h
+//	edges:
+//	  - from: classify
+//	    to: answer
+//	    label: classified
+//	    description: every request is answered once classified
+//	  - from: answer
+//	    to: END
+//
+// The retry, timeout and cache policies of a node are inherited from its group, then from the
+// defaults of the spec, unless the node sets them itself; see Spec.Policy.
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidSpec is returned when a spec does not conform to the schema.
+var ErrInvalidSpec = errors.New("invalid graph spec")
+
+// Spec is a declarative graph definition.
+type Spec struct {
+	// Name identifies the graph.
+	Name string `yaml:"name"`
+
+	// Params declares the parameters referenced in the spec as ${name}.
+	Params map[string]ParamSpec `yaml:"params"`
+
+	// EntryPoint is the name of the first node executed.
+	EntryPoint string `yaml:"entry_point"`
+
+	// Defaults are the policies of the nodes that neither they nor their group set.
+	Defaults PolicySpec `yaml:"defaults"`
+
+	// Groups are policies shared by the nodes referencing them by name, overriding Defaults.
+	Groups map[string]PolicySpec `yaml:"groups"`
+
+	// Nodes are the nodes of the graph.
+	Nodes []NodeSpec `yaml:"nodes"`
+
+	// Edges are the edges of the graph.
+	Edges []EdgeSpec `yaml:"edges"`
+}
+
+// NodeSpec declares a node.
+type NodeSpec struct {
+	// Name is the unique identifier for the node.
+	Name string `yaml:"name"`
+
+	// Type selects the factory building the node function.
+	Type string `yaml:"type"`
+
+	// Config is passed to the factory.
+	Config map[string]any `yaml:"config"`
+
+	// Group names the policy group of the node in Spec.Groups.
+	Group string `yaml:"group"`
+
+	// Retry configures retries of the node function.
+	Retry *RetrySpec `yaml:"retry"`
+
+	// Timeout bounds a single execution of the node function.
+	Timeout Duration `yaml:"timeout"`
+
+	// Cache configures the caching of the states returned by the node function.
+	Cache *CacheSpec `yaml:"cache"`
+
+	// Resources declares the resource hints of the node.
+	Resources *ResourcesSpec `yaml:"resources"`
+
+	// SideEffects marks the node as side-effecting; see graph.WithSideEffects.
+	SideEffects bool `yaml:"side_effects"`
+}
+
+// ResourcesSpec declares the resource hints of a node; see graph.Resources.
+type ResourcesSpec struct {
+	// Class is cpu, gpu or external_api.
+	Class string `yaml:"class"`
+
+	// API names the external API of external_api nodes.
+	API string `yaml:"api"`
+
+	// MemoryMB is the estimated peak memory of an execution, in MiB.
+	MemoryMB int `yaml:"memory_mb"`
+}
+
+// RetrySpec declares the retry policy of a node.
+type RetrySpec struct {
+	// MaxAttempts is the total number of executions, including the first one.
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// Backoff is the delay before the first retry; it doubles on every further retry, with jitter as
+	// described by graph.WithRetry.
+	Backoff Duration `yaml:"backoff"`
+}
+
+// CacheSpec declares the cache policy of a node: the state it returns is reused for the same
+// input state instead of executing it again.
+type CacheSpec struct {
+	// TTL is how long a returned state is reused.
+	TTL Duration `yaml:"ttl"`
+}
+
+// PolicySpec declares policies inherited by nodes; see Spec.Policy.
+type PolicySpec struct {
+	// Retry configures retries of the node functions.
+	Retry *RetrySpec `yaml:"retry"`
+
+	// Timeout bounds a single execution of the node functions.
+	Timeout Duration `yaml:"timeout"`
+
+	// Cache configures the caching of the states returned by the node functions.
+	Cache *CacheSpec `yaml:"cache"`
+}
+
+// EdgeSpec declares an edge.
+type EdgeSpec struct {
+	// From is the name of the node from which the edge originates.
+	From string `yaml:"from"`
+
+	// To is the name of the node to which the edge points.
+	To string `yaml:"to"`
+
+	// Label is a short name of the transition, shown in rendered graphs.
+	Label string `yaml:"label"`
+
+	// Description explains why the transition exists.
+	Description string `yaml:"description"`
+}
+
+// Duration is a time.Duration written as a Go duration string, such as "1m30s".
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", value.Value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Parse validates a YAML spec and decodes it.
+// When nodeTypes are given, node types must be one of them.
+// All the problems found are reported together, each with its line and column.
+// Parameters are set to their default values; use ParseWithParams to provide values.
+func Parse(data []byte, nodeTypes ...string) (*Spec, error) {
+	return ParseWithParams(data, nil, nodeTypes...)
+}
+
+// ParseWithParams is like Parse but resolves the parameters of the spec with resolve
+// before validating it. Parameters resolve cannot provide fall back to their default value.
+func ParseWithParams(data []byte, resolve Resolver, nodeTypes ...string) (*Spec, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSpec, err)
+	}
+
+	if err := validate(&root, nodeTypes, resolve); err != nil {
+		return nil, fmt.Errorf("%w:\n%w", ErrInvalidSpec, err)
+	}
+
+	var s Spec
+	if err := root.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSpec, err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,140 @@
+package spec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const supportSpec = `
+name: support
+entry_point: classify
+nodes:
+  - name: classify
+    type: llm
+    config:
+      prompt: classify the request
+    retry:
+      max_attempts: 3
+      backoff: 1s
+    timeout: 30s
+  - name: answer
+    type: llm
+edges:
+  - from: classify
+    to: answer
+  - from: answer
+    to: END
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s, err := spec.Parse([]byte(supportSpec), "llm", "tool")
+	require.NoError(t, err)
+
+	assert.Equal(t, "support", s.Name)
+	assert.Equal(t, "classify", s.EntryPoint)
+	require.Len(t, s.Nodes, 2)
+	assert.Equal(t, "classify the request", s.Nodes[0].Config["prompt"])
+	assert.Equal(t, 3, s.Nodes[0].Retry.MaxAttempts)
+	assert.Equal(t, spec.Duration(time.Second), s.Nodes[0].Retry.Backoff)
+	assert.Equal(t, spec.Duration(30*time.Second), s.Nodes[0].Timeout)
+	assert.Equal(t, []spec.EdgeSpec{{From: "classify", To: "answer"}, {From: "answer", To: "END"}}, s.Edges)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Empty",
+			input:    ``,
+			expected: []string{"1:1: empty spec"},
+		},
+		{
+			name: "Schema violations",
+			input: `entry_point: a
+nodes:
+  - name: a
+    type: lmm
+    retry:
+      max_attempts: 0
+      backoff: soon
+    timeout: 5
+    colour: red
+  - type: llm
+edges: {}
+`,
+			expected: []string{
+				`4:11: nodes[0].type: "lmm" is not one of [llm tool]`,
+				`6:21: nodes[0].retry.max_attempts: must be at least 1`,
+				`7:16: nodes[0].retry.backoff: "soon" does not match`,
+				`8:14: nodes[0].timeout: expected a string`,
+				`9:5: nodes[0].colour: unknown field`,
+				`10:5: nodes[1].name: required field is missing`,
+				`11:8: edges: expected a sequence`,
+			},
+		},
+		{
+			name: "Broken references",
+			input: `entry_point: start
+nodes:
+  - name: a
+    type: llm
+  - name: a
+    type: llm
+  - name: END
+    type: llm
+edges:
+  - from: a
+    to: b
+  - from: a
+    to: END
+  - from: END
+    to: a
+`,
+			expected: []string{
+				`5:11: nodes[1].name: duplicate node "a", first declared at 3:11`,
+				`7:11: nodes[2].name: END is reserved`,
+				`1:14: entry_point: unknown node "start"`,
+				`11:9: edges[0].to: unknown node "b"`,
+				`12:11: edges[1].from: node "a" already has an outgoing edge at 10:11`,
+				`14:11: edges[2].from: unknown node "END"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := spec.Parse([]byte(tc.input), "llm", "tool")
+			require.ErrorIs(t, err, spec.ErrInvalidSpec)
+
+			var fieldErrs []string
+			for _, e := range spec.FieldErrors(err) {
+				fieldErrs = append(fieldErrs, e.Error())
+			}
+
+			require.Len(t, fieldErrs, len(tc.expected), "got %v", fieldErrs)
+			for i, expected := range tc.expected {
+				assert.Contains(t, fieldErrs[i], expected)
+			}
+		})
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	_, err := spec.Parse([]byte("nodes: [\n"))
+	assert.ErrorIs(t, err, spec.ErrInvalidSpec)
+}
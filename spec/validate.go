@@ -0,0 +1,264 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// FieldError is a problem found at a precise location of a spec.
+type FieldError struct {
+	// Line is the 1-based line of the offending value.
+	Line int
+
+	// Column is the 1-based column of the offending value.
+	Column int
+
+	// Path locates the value in the spec, e.g. "nodes[1].retry.max_attempts".
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// FieldErrors returns all the FieldErrors contained in err, in the order they were found.
+func FieldErrors(err error) []*FieldError {
+	var out []*FieldError
+	switch e := err.(type) { //nolint:errorlint // Walking the tree of joined errors.
+	case *FieldError:
+		out = append(out, e)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			out = append(out, FieldErrors(inner)...)
+		}
+	case interface{ Unwrap() error }:
+		out = FieldErrors(e.Unwrap())
+	}
+	return out
+}
+
+type validator struct {
+	errs     []error
+	patterns map[string]*regexp.Regexp
+}
+
+func (v *validator) fail(n *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{
+		Line:    n.Line,
+		Column:  n.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks the document against the schema and then checks the references between
+// nodes and edges. All the problems are returned joined.
+func validate(root *yaml.Node, nodeTypes []string, resolve Resolver) error {
+	v := &validator{patterns: make(map[string]*regexp.Regexp)}
+
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		v.errs = append(v.errs, &FieldError{Line: 1, Column: 1, Message: "empty spec"})
+		return errors.Join(v.errs...)
+	}
+	doc := root.Content[0]
+
+	s := specSchema(nodeTypes)
+	if doc.Kind == yaml.MappingNode {
+		v.interpolate(doc, s, resolve)
+		if len(v.errs) > 0 {
+			return errors.Join(v.errs...)
+		}
+	}
+
+	v.check(doc, s, "")
+	if len(v.errs) == 0 {
+		v.checkReferences(doc)
+	}
+	return errors.Join(v.errs...)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// check validates a YAML node against a schema.
+func (v *validator) check(n *yaml.Node, s *schema, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	switch s.typ {
+	case "object":
+		v.checkObject(n, s, path)
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			v.fail(n, path, "expected a sequence")
+			return
+		}
+		for i, item := range n.Content {
+			v.check(item, s.items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!str" {
+			v.fail(n, path, "expected a string")
+			return
+		}
+		if len(s.enum) > 0 && !slices.Contains(s.enum, n.Value) {
+			v.fail(n, path, "%q is not one of %v", n.Value, s.enum)
+		}
+		if s.pattern != "" && !v.pattern(s.pattern).MatchString(n.Value) {
+			v.fail(n, path, "%q does not match %s", n.Value, s.pattern)
+		}
+	case "boolean":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!bool" {
+			v.fail(n, path, "expected a boolean")
+		}
+	case "scalar":
+		if n.Kind != yaml.ScalarNode {
+			v.fail(n, path, "expected a scalar value")
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!int" {
+			v.fail(n, path, "expected an integer")
+			return
+		}
+		i, err := strconv.Atoi(n.Value)
+		if err != nil {
+			v.fail(n, path, "invalid integer %q", n.Value)
+			return
+		}
+		if s.minimum != nil && i < *s.minimum {
+			v.fail(n, path, "must be at least %d", *s.minimum)
+		}
+	}
+}
+
+func (v *validator) checkObject(n *yaml.Node, s *schema, path string) {
+	if n.Kind != yaml.MappingNode {
+		v.fail(n, path, "expected a mapping")
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		p := joinPath(path, key.Value)
+
+		if seen[key.Value] {
+			v.fail(key, p, "duplicate field")
+			continue
+		}
+		seen[key.Value] = true
+
+		prop, ok := s.property(key.Value)
+		if !ok {
+			switch {
+			case s.additional != nil:
+				prop = s.additional
+			case s.open:
+				continue
+			default:
+				v.fail(key, p, "unknown field")
+				continue
+			}
+		}
+		if value.ShortTag() == "!!null" && !slices.Contains(s.required, key.Value) {
+			continue
+		}
+		v.check(value, prop, p)
+	}
+
+	for _, name := range s.required {
+		if !seen[name] {
+			v.fail(n, joinPath(path, name), "required field is missing")
+		}
+	}
+}
+
+func (v *validator) pattern(expr string) *regexp.Regexp {
+	re, ok := v.patterns[expr]
+	if !ok {
+		re = regexp.MustCompile(expr)
+		v.patterns[expr] = re
+	}
+	return re
+}
+
+// field returns the value of a key of a mapping node, or nil.
+func field(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkReferences checks that node names are unique and that the entry point, edges and
+// policy groups reference declared nodes and groups. It assumes the document conforms to the
+// schema.
+func (v *validator) checkReferences(doc *yaml.Node) {
+	groups := field(doc, "groups")
+	declared := make(map[string]*yaml.Node)
+	if nodes := field(doc, "nodes"); nodes != nil {
+		for i, node := range nodes.Content {
+			if group := field(node, "group"); group != nil && (groups == nil || field(groups, group.Value) == nil) {
+				v.fail(group, fmt.Sprintf("nodes[%d].group", i), "unknown group %q", group.Value)
+			}
+
+			name := field(node, "name")
+			path := fmt.Sprintf("nodes[%d].name", i)
+			switch {
+			case name.Value == "":
+				v.fail(name, path, "node name must not be empty")
+			case name.Value == graph.END:
+				v.fail(name, path, "%s is reserved", graph.END)
+			case declared[name.Value] != nil:
+				first := declared[name.Value]
+				v.fail(name, path, "duplicate node %q, first declared at %d:%d", name.Value, first.Line, first.Column)
+			default:
+				declared[name.Value] = name
+			}
+		}
+	}
+
+	if entry := field(doc, "entry_point"); declared[entry.Value] == nil {
+		v.fail(entry, "entry_point", "unknown node %q", entry.Value)
+	}
+
+	edges := field(doc, "edges")
+	if edges == nil {
+		return
+	}
+	outgoing := make(map[string]*yaml.Node)
+	for i, edge := range edges.Content {
+		from, to := field(edge, "from"), field(edge, "to")
+		if declared[from.Value] == nil {
+			v.fail(from, fmt.Sprintf("edges[%d].from", i), "unknown node %q", from.Value)
+		} else if first := outgoing[from.Value]; first != nil {
+			v.fail(from, fmt.Sprintf("edges[%d].from", i), "node %q already has an outgoing edge at %d:%d", from.Value, first.Line, first.Column)
+		} else {
+			outgoing[from.Value] = from
+		}
+		if to.Value != graph.END && declared[to.Value] == nil {
+			v.fail(to, fmt.Sprintf("edges[%d].to", i), "unknown node %q", to.Value)
+		}
+	}
+}
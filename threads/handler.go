@@ -0,0 +1,42 @@
+package threads
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// NewHandler returns a handler serving the threads of the store to GET requests, as a JSON array
+// of Thread, the most recently updated first. The prefix query parameter selects the threads
+// whose ID starts with it and the limit query parameter bounds their number.
+func NewHandler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		params := r.URL.Query()
+		q := Query{Prefix: params.Get("prefix")}
+		if limit := params.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 0 {
+				http.Error(w, "limit: not a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			q.Limit = n
+		}
+
+		threads, err := store.List(r.Context(), q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if threads == nil {
+			threads = []Thread{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(threads)
+	})
+}
@@ -0,0 +1,57 @@
+package threads_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/threads"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		method   string
+		query    string
+		status   int
+		expected []string
+	}{
+		{name: "all", query: "", status: http.StatusOK, expected: []string{"alice/3", "alice/2", "bob/1", "alice/1"}},
+		{name: "prefix and limit", query: "?prefix=alice/&limit=1", status: http.StatusOK, expected: []string{"alice/3"}},
+		{name: "empty", query: "?prefix=carol/", status: http.StatusOK, expected: []string{}},
+		{name: "invalid limit", query: "?limit=-1", status: http.StatusBadRequest},
+		{name: "method", method: http.MethodPost, status: http.StatusMethodNotAllowed},
+	}
+
+	handler := threads.NewHandler(testStore(t))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(method, "/threads"+tc.query, nil))
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var list []threads.Thread
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+			ids := []string{}
+			for _, thread := range list {
+				ids = append(ids, thread.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package threads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrNoTranscript is returned by NewSummarizer when the options have no Transcript and the
+// state is not a list of messages.
+var ErrNoTranscript = errors.New("no transcript function for the state type")
+
+const (
+	// DefaultTitlePrompt are the instructions writing the titles of threads.
+	DefaultTitlePrompt = "Write a title of at most six words for the following conversation. Answer with the title only, without quotes."
+
+	// DefaultSummaryPrompt are the instructions updating the summaries of threads.
+	DefaultSummaryPrompt = "Update the summary of the following conversation with its latest messages, in at most three sentences, keeping what a user needs to recognize and resume it. Answer with the summary only."
+
+	// DefaultMaxTranscript is the default length, in bytes, of the end of the transcript sent to
+	// the model.
+	DefaultMaxTranscript = 8000
+)
+
+// Options configures a Summarizer.
+type Options[T any] struct {
+	// Transcript renders a state as the text of the conversation. It defaults to Transcript for
+	// states of type []llms.MessageContent and is required otherwise.
+	Transcript func(state T) string
+
+	// TitlePrompt are the instructions writing titles; DefaultTitlePrompt if empty.
+	TitlePrompt string
+
+	// SummaryPrompt are the instructions updating summaries; DefaultSummaryPrompt if empty.
+	SummaryPrompt string
+
+	// MaxTranscript is the length, in bytes, of the end of the transcript sent to the model;
+	// DefaultMaxTranscript if 0.
+	MaxTranscript int
+
+	// OnError is called when a thread cannot be updated. By default failures are logged: they
+	// never fail the run.
+	OnError func(ctx context.Context, threadID string, err error)
+}
+
+// Summarizer is graph.Callbacks writing the title and the rolling summary of the threads of the
+// runs it is notified of: at the end of every completed or interrupted run with a thread ID,
+// it asks the model for a title if the thread has none yet and for the summary of the thread
+// updated with the state reached, and stores them.
+//
+// Threads are updated in the background, so runs do not wait for the model: call Wait before
+// exiting. The updates of a thread are made one at a time, in the order the runs ended.
+type Summarizer[T any] struct {
+	model llms.Model
+	store Store
+	opts  Options[T]
+
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+	// last are the channels closed by the last updates queued, by thread.
+	last map[string]chan struct{}
+}
+
+var _ graph.Callbacks[any] = (*Summarizer[any])(nil)
+
+// NewSummarizer returns a Summarizer writing threads to store with model, typically a small
+// and cheap one. Register it with graph.WithCallbacks or graph.WithInvokeCallbacks.
+func NewSummarizer[T any](model llms.Model, store Store, opts Options[T]) (*Summarizer[T], error) {
+	if opts.Transcript == nil {
+		if _, ok := any(*new(T)).([]llms.MessageContent); !ok {
+			return nil, ErrNoTranscript
+		}
+		opts.Transcript = func(state T) string {
+			msgs, _ := any(state).([]llms.MessageContent)
+			return Transcript(msgs)
+		}
+	}
+	if opts.TitlePrompt == "" {
+		opts.TitlePrompt = DefaultTitlePrompt
+	}
+	if opts.SummaryPrompt == "" {
+		opts.SummaryPrompt = DefaultSummaryPrompt
+	}
+	if opts.MaxTranscript == 0 {
+		opts.MaxTranscript = DefaultMaxTranscript
+	}
+	if opts.OnError == nil {
+		opts.OnError = logError
+	}
+	return &Summarizer[T]{model: model, store: store, opts: opts, last: make(map[string]chan struct{})}, nil
+}
+
+// OnGraphStart does nothing.
+func (s *Summarizer[T]) OnGraphStart(context.Context, T) {}
+
+// OnNodeStart does nothing.
+func (s *Summarizer[T]) OnNodeStart(context.Context, string, T) {}
+
+// OnNodeEnd does nothing.
+func (s *Summarizer[T]) OnNodeEnd(context.Context, string, T) {}
+
+// OnNodeError does nothing.
+func (s *Summarizer[T]) OnNodeError(context.Context, string, error) {}
+
+// OnGraphEnd updates the thread of the run in the background, unless the run failed or has no
+// thread ID.
+func (s *Summarizer[T]) OnGraphEnd(ctx context.Context, state T, err error) {
+	threadID := graph.ThreadID(ctx)
+	if threadID == "" || (err != nil && !errors.Is(err, graph.ErrInterrupted)) {
+		return
+	}
+
+	// The thread is updated after the run, even if it ended because ctx is done.
+	ctx = context.WithoutCancel(ctx)
+	previous, done := s.queue(threadID)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.dequeue(threadID, done)
+		<-previous
+		if _, err := s.update(ctx, threadID, state); err != nil {
+			s.opts.OnError(ctx, threadID, err)
+		}
+	}()
+}
+
+// Wait waits for the updates in progress.
+func (s *Summarizer[T]) Wait() {
+	s.wg.Wait()
+}
+
+// Update updates the thread with the state synchronously, e.g. for the states of threads saved
+// outside of graph runs, and returns it.
+func (s *Summarizer[T]) Update(ctx context.Context, threadID string, state T) (Thread, error) {
+	previous, done := s.queue(threadID)
+	defer s.dequeue(threadID, done)
+	select {
+	case <-previous:
+	case <-ctx.Done():
+		return Thread{}, ctx.Err()
+	}
+	return s.update(ctx, threadID, state)
+}
+
+func (s *Summarizer[T]) update(ctx context.Context, threadID string, state T) (Thread, error) {
+	thread, err := s.store.Get(ctx, threadID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		thread = Thread{ID: threadID}
+	case err != nil:
+		return Thread{}, fmt.Errorf("reading thread %s: %w", threadID, err)
+	}
+
+	transcript := tail(s.opts.Transcript(state), s.opts.MaxTranscript)
+	if thread.Title == "" {
+		title, err := llms.GenerateFromSinglePrompt(ctx, s.model, s.opts.TitlePrompt+"\n\nConversation:\n"+transcript)
+		if err != nil {
+			return Thread{}, fmt.Errorf("writing title of thread %s: %w", threadID, err)
+		}
+		thread.Title = cleanTitle(title)
+	}
+
+	prompt := s.opts.SummaryPrompt
+	if thread.Summary != "" {
+		prompt += "\n\nSummary so far:\n" + thread.Summary
+	}
+	summary, err := llms.GenerateFromSinglePrompt(ctx, s.model, prompt+"\n\nConversation:\n"+transcript)
+	if err != nil {
+		return Thread{}, fmt.Errorf("writing summary of thread %s: %w", threadID, err)
+	}
+	thread.Summary = strings.TrimSpace(summary)
+	thread.Runs++
+	thread.UpdatedAt = time.Now()
+
+	if err := s.store.Put(ctx, thread); err != nil {
+		return Thread{}, fmt.Errorf("writing thread %s: %w", threadID, err)
+	}
+	return thread, nil
+}
+
+// queue queues an update of the thread. It returns the channel closed when the previous update
+// is done, and the channel to close with dequeue when this one is.
+func (s *Summarizer[T]) queue(threadID string) (previous <-chan struct{}, done chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous = s.last[threadID]
+	if previous == nil {
+		previous = closed
+	}
+	done = make(chan struct{})
+	s.last[threadID] = done
+	return previous, done
+}
+
+// dequeue ends an update of the thread.
+func (s *Summarizer[T]) dequeue(threadID string, done chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(done)
+	if s.last[threadID] == done {
+		delete(s.last, threadID)
+	}
+}
+
+// closed is a closed channel.
+var closed = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// Transcript renders the text of messages, one line per text part prefixed by the role of its
+// message. Other parts, such as tool calls and images, are left out.
+func Transcript(msgs []llms.MessageContent) string {
+	var b strings.Builder
+	for _, msg := range msgs {
+		for _, part := range msg.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				fmt.Fprintf(&b, "%s: %s\n", msg.Role, text.Text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// tail returns the last n bytes of s, starting at a rune.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[len(s)-n:]
+	for len(s) > 0 && !utf8.RuneStart(s[0]) {
+		s = s[1:]
+	}
+	return s
+}
+
+// cleanTitle returns the first line of title, without the quotes models add.
+func cleanTitle(title string) string {
+	title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+	return strings.Trim(strings.TrimSpace(title), `"'`)
+}
+
+func logError(ctx context.Context, threadID string, err error) {
+	slog.ErrorContext(ctx, "updating thread failed", "thread", threadID, "error", err)
+}
@@ -0,0 +1,154 @@
+package threads_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/graph"
+	"github.com/cesto93/langgraphgo/threads"
+)
+
+// model answers title prompts with a quoted title and summary prompts with the number of
+// summaries written, and records the prompts.
+type model struct {
+	mu      sync.Mutex
+	prompts []string
+	err     error
+}
+
+func (m *model) GenerateContent(_ context.Context, msgs []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+	prompt := msgs[0].Parts[0].(llms.TextContent).Text
+	m.prompts = append(m.prompts, prompt)
+	answer := "summary " + strings.Repeat("I", strings.Count(strings.Join(m.prompts, ""), threads.DefaultSummaryPrompt))
+	if strings.HasPrefix(prompt, threads.DefaultTitlePrompt) {
+		answer = "\"Greetings\"\nThis conversation is about greetings."
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: answer}}}, nil
+}
+
+func (m *model) Call(ctx context.Context, prompt string, opts ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, opts...)
+}
+
+func chatGraph(t *testing.T, summarizer *threads.Summarizer[[]llms.MessageContent]) *graph.Runnable[[]llms.MessageContent] {
+	t.Helper()
+
+	g := graph.NewMessageGraph[[]llms.MessageContent]("reply")
+	g.AddNode("reply", func(_ context.Context, state []llms.MessageContent) ([]llms.MessageContent, error) {
+		return append(state, llms.TextParts(llms.ChatMessageTypeAI, "hello")), nil
+	})
+	g.SetFinishPoint("reply")
+	runnable, err := g.Compile(graph.WithCheckpointer(checkpoint.NewMemory()), graph.WithCallbacks(summarizer))
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestSummarizer(t *testing.T) {
+	t.Parallel()
+
+	m := new(model)
+	store := new(threads.MemoryStore)
+	summarizer, err := threads.NewSummarizer(m, store, threads.Options[[]llms.MessageContent]{})
+	require.NoError(t, err)
+	runnable := chatGraph(t, summarizer)
+
+	ctx := graph.WithThreadID(context.Background(), "t1")
+	for _, text := range []string{"hi", "how are you?"} {
+		_, err := runnable.Invoke(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, text)})
+		require.NoError(t, err)
+	}
+	_, err = runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	summarizer.Wait()
+
+	thread, err := store.Get(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Greetings", thread.Title)
+	assert.Equal(t, "summary II", thread.Summary)
+	assert.Equal(t, 2, thread.Runs)
+	assert.False(t, thread.UpdatedAt.IsZero())
+
+	// The title is written once, and the summary is updated with the previous one.
+	require.Len(t, m.prompts, 3)
+	assert.Contains(t, m.prompts[0], "human: hi\nai: hello\n")
+	assert.Contains(t, m.prompts[2], "Summary so far:\nsummary I\n")
+	assert.Contains(t, m.prompts[2], "human: how are you?\nai: hello\n")
+
+	threadList, err := store.List(context.Background(), threads.Query{})
+	require.NoError(t, err)
+	assert.Len(t, threadList, 1)
+}
+
+func TestSummarizerErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := threads.NewSummarizer(new(model), new(threads.MemoryStore), threads.Options[map[string]string]{})
+	require.ErrorIs(t, err, threads.ErrNoTranscript)
+
+	failure := errors.New("model unavailable")
+	var mu sync.Mutex
+	var failed []string
+	summarizer, err := threads.NewSummarizer(&model{err: failure}, new(threads.MemoryStore), threads.Options[[]llms.MessageContent]{
+		OnError: func(_ context.Context, threadID string, err error) {
+			assert.ErrorIs(t, err, failure)
+			mu.Lock()
+			failed = append(failed, threadID)
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+	_, err = chatGraph(t, summarizer).Invoke(graph.WithThreadID(context.Background(), "t1"), nil)
+	require.NoError(t, err)
+	summarizer.Wait()
+	assert.Equal(t, []string{"t1"}, failed)
+}
+
+func TestSummarizerUpdate(t *testing.T) {
+	t.Parallel()
+
+	store := new(threads.MemoryStore)
+	summarizer, err := threads.NewSummarizer(new(model), store, threads.Options[string]{
+		Transcript:    func(state string) string { return state },
+		MaxTranscript: 4,
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := summarizer.Update(context.Background(), "t1", "a long conversation")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	thread, err := store.Get(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 5, thread.Runs)
+	assert.Equal(t, "Greetings", thread.Title)
+}
+
+func TestTranscript(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "human: hi\nai: hello\n", threads.Transcript([]llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: "1"}, llms.TextContent{Text: "hello"}}},
+	}))
+}
@@ -0,0 +1,125 @@
+// Package threads keeps the titles and rolling summaries of conversation threads, so chat UIs
+// can list meaningful conversations: a Summarizer registered as graph callbacks writes them with
+// a cheap model after the runs of a thread, into a Store that NewHandler lists as JSON.
+//
+//	store := new(threads.MemoryStore)
+//	summarizer, err := threads.NewSummarizer[[]llms.MessageContent](cheapModel, store, threads.Options[[]llms.MessageContent]{})
+//	if err != nil {
+//		return err
+//	}
+//	defer summarizer.Wait()
+//	runnable, err := g.Compile(graph.WithCheckpointer(cp), graph.WithCallbacks(summarizer))
+//	http.Handle("/threads", threads.NewHandler(store))
+package threads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Store.Get when the store knows nothing of the thread.
+var ErrNotFound = errors.New("thread not found")
+
+// Thread describes a conversation thread.
+type Thread struct {
+	// ID identifies the thread; see graph.WithThreadID.
+	ID string `json:"id"`
+
+	// Title is a short title of the conversation, written after its first run.
+	Title string `json:"title,omitempty"`
+
+	// Summary is a summary of the conversation, updated after every run.
+	Summary string `json:"summary,omitempty"`
+
+	// Runs is the number of runs summarized.
+	Runs int `json:"runs"`
+
+	// UpdatedAt is the time of the last update.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store persists Threads. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the thread with the ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (Thread, error)
+
+	// Put stores the thread, replacing the thread with the same ID.
+	Put(ctx context.Context, thread Thread) error
+
+	// List returns the threads matching q, the most recently updated first.
+	List(ctx context.Context, q Query) ([]Thread, error)
+}
+
+// Query selects threads.
+type Query struct {
+	// Prefix selects the threads whose ID starts with it, e.g. the threads of a user whose
+	// thread IDs are prefixed with the user ID; empty selects every thread.
+	Prefix string
+
+	// Limit is the maximum number of threads; 0 does not limit them.
+	Limit int
+}
+
+// MemoryStore is a Store in memory, for tests and single-process applications. The zero value
+// is ready to use and it is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	threads map[string]Thread
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// Get returns the thread with the ID, or ErrNotFound.
+func (m *MemoryStore) Get(_ context.Context, id string) (Thread, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	thread, ok := m.threads[id]
+	if !ok {
+		return Thread{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return thread, nil
+}
+
+// Put stores the thread, replacing the thread with the same ID.
+func (m *MemoryStore) Put(_ context.Context, thread Thread) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.threads == nil {
+		m.threads = make(map[string]Thread)
+	}
+	m.threads[thread.ID] = thread
+	return nil
+}
+
+// List returns the threads matching q, the most recently updated first.
+func (m *MemoryStore) List(_ context.Context, q Query) ([]Thread, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var threads []Thread
+	for id, thread := range m.threads {
+		if strings.HasPrefix(id, q.Prefix) {
+			threads = append(threads, thread)
+		}
+	}
+	slices.SortFunc(threads, Compare)
+	if q.Limit > 0 && len(threads) > q.Limit {
+		threads = threads[:q.Limit]
+	}
+	return threads, nil
+}
+
+// Compare orders threads the most recently updated first, then by ID.
+func Compare(a, b Thread) int {
+	if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
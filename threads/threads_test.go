@@ -0,0 +1,66 @@
+package threads_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/threads"
+)
+
+// testStore returns a store with the threads of two users, updated in order.
+func testStore(t *testing.T) *threads.MemoryStore {
+	t.Helper()
+
+	store := new(threads.MemoryStore)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"alice/1", "bob/1", "alice/2", "alice/3"} {
+		require.NoError(t, store.Put(context.Background(), threads.Thread{ID: id, Title: "title " + id, UpdatedAt: start.Add(time.Duration(i) * time.Hour)}))
+	}
+	return store
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		query    threads.Query
+		expected []string
+	}{
+		{name: "all", expected: []string{"alice/3", "alice/2", "bob/1", "alice/1"}},
+		{name: "prefix", query: threads.Query{Prefix: "alice/"}, expected: []string{"alice/3", "alice/2", "alice/1"}},
+		{name: "limit", query: threads.Query{Prefix: "alice/", Limit: 2}, expected: []string{"alice/3", "alice/2"}},
+		{name: "none", query: threads.Query{Prefix: "carol/"}},
+	}
+
+	store := testStore(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			list, err := store.List(context.Background(), tc.query)
+			require.NoError(t, err)
+			var ids []string
+			for _, thread := range list {
+				ids = append(ids, thread.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestMemoryStoreGet(t *testing.T) {
+	t.Parallel()
+
+	store := testStore(t)
+	thread, err := store.Get(context.Background(), "bob/1")
+	require.NoError(t, err)
+	assert.Equal(t, "title bob/1", thread.Title)
+
+	_, err = store.Get(context.Background(), "carol/1")
+	require.ErrorIs(t, err, threads.ErrNotFound)
+}
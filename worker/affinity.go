@@ -0,0 +1,284 @@
+// Package worker distributes graph invocations over a fleet of workers running the graphs of
+// an app package application.
+//
+// Invocations can be distributed through a Queue of runs, which a Worker on every machine
+// processes with retries, dead-lettering the runs that keep failing. Operators triage the
+// dead-lettered runs of the queues implementing Quarantine, e.g. through QuarantineHandler.
+// MemoryQueue is the in-process implementation; the natsqueue and kafkaqueue subpackages
+// distribute runs with NATS JetStream and Kafka.
+//
+// Invocations served over HTTP can go through a Router instead, which routes all the
+// invocations of a thread to the same worker when possible, so memory stores and checkpoints
+// cached by a worker are reused instead of reloaded. Threads are mapped to workers by
+// consistent hashing: adding or removing a worker only moves the threads of a fraction of the
+// fleet, and the threads of a failing worker fail over to the next worker of the ring.
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoWorker is returned when no worker could serve a request.
+var ErrNoWorker = errors.New("no worker available")
+
+// DefaultReplicas is the number of points of every worker on a ring when none is given.
+const DefaultReplicas = 128
+
+// DefaultCooldown is how long a failing worker is avoided when no cooldown is given.
+const DefaultCooldown = 10 * time.Second
+
+// Ring maps keys to workers by consistent hashing. It is safe for concurrent use.
+type Ring struct {
+	replicas int
+
+	mu      sync.RWMutex
+	points  []uint64
+	owners  map[uint64]string
+	workers map[string]struct{}
+}
+
+// NewRing returns a ring of the workers with the given number of points per worker;
+// DefaultReplicas is used when replicas is not positive. More points spread keys more evenly.
+func NewRing(replicas int, workers ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{
+		replicas: replicas,
+		owners:   make(map[uint64]string),
+		workers:  make(map[string]struct{}),
+	}
+	r.Add(workers...)
+	return r
+}
+
+// Add adds workers to the ring. Adding a worker already on the ring does nothing.
+func (r *Ring) Add(workers ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, worker := range workers {
+		if _, ok := r.workers[worker]; ok {
+			continue
+		}
+		r.workers[worker] = struct{}{}
+		for i := range r.replicas {
+			point := hash(worker + "#" + strconv.Itoa(i))
+			if _, taken := r.owners[point]; taken {
+				continue
+			}
+			r.owners[point] = worker
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Remove removes workers from the ring; their keys move to the next workers of the ring.
+func (r *Ring) Remove(workers ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, worker := range workers {
+		delete(r.workers, worker)
+	}
+	points := r.points[:0]
+	for _, point := range r.points {
+		if _, ok := r.workers[r.owners[point]]; ok {
+			points = append(points, point)
+		} else {
+			delete(r.owners, point)
+		}
+	}
+	r.points = points
+}
+
+// Workers returns the workers of the ring in lexical order.
+func (r *Ring) Workers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workers := make([]string, 0, len(r.workers))
+	for worker := range r.workers {
+		workers = append(workers, worker)
+	}
+	sort.Strings(workers)
+	return workers
+}
+
+// Lookup returns every worker of the ring in order of preference for the key: the owner of
+// the key first, then the workers its requests fail over to.
+func (r *Ring) Lookup(key string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return nil
+	}
+	h := hash(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+
+	workers := make([]string, 0, len(r.workers))
+	seen := make(map[string]struct{}, len(r.workers))
+	for i := 0; i < len(r.points) && len(workers) < len(r.workers); i++ {
+		worker := r.owners[r.points[(start+i)%len(r.points)]]
+		if _, ok := seen[worker]; !ok {
+			seen[worker] = struct{}{}
+			workers = append(workers, worker)
+		}
+	}
+	return workers
+}
+
+// hash returns a well-mixed 64-bit hash of s, stable across processes so every router
+// agrees on the owner of a key.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	// FNV spreads similar strings poorly; the splitmix64 finalizer fixes that.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// RouterOptions configures a Router.
+type RouterOptions struct {
+	// ThreadID returns the thread of a request; empty if it has none. By default it is the
+	// thread_id query parameter read by the app package handler.
+	ThreadID func(r *http.Request) string
+
+	// Client forwards the requests; http.DefaultClient is used when nil.
+	Client *http.Client
+
+	// Cooldown is how long a worker is avoided after failing; DefaultCooldown is used when
+	// zero. Its threads go back to it once the cooldown elapsed.
+	Cooldown time.Duration
+}
+
+// Router is an HTTP handler forwarding requests to the workers of a ring, which are base URLs
+// such as http://10.0.0.7:8080. Requests of a thread go to the owner of the thread on the
+// ring; requests without thread are spread over the workers in turn.
+//
+// A worker that cannot be reached or responds with 502, 503 or 504 is avoided for the
+// cooldown, and the request is retried on the next worker of the ring, so the threads of a
+// failing worker stick to the same fallback until it recovers. Request bodies are buffered
+// to be retried.
+type Router struct {
+	ring *Ring
+	opts RouterOptions
+
+	// next rotates the workers of requests without thread.
+	next atomic.Uint64
+
+	mu   sync.Mutex
+	down map[string]time.Time
+}
+
+// NewRouter returns a router forwarding requests to the workers of the ring, which may change
+// while the router runs.
+func NewRouter(ring *Ring, opts RouterOptions) *Router {
+	if opts.ThreadID == nil {
+		opts.ThreadID = func(r *http.Request) string { return r.URL.Query().Get("thread_id") }
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Cooldown == 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+	return &Router{ring: ring, opts: opts, down: make(map[string]time.Time)}
+}
+
+// Candidates returns the workers a request of the thread is tried on, in order: the available
+// workers in order of preference, then the workers in cooldown as a last resort.
+func (rt *Router) Candidates(threadID string) []string {
+	var workers []string
+	if threadID != "" {
+		workers = rt.ring.Lookup(threadID)
+	} else if all := rt.ring.Workers(); len(all) > 0 {
+		start := int(rt.next.Add(1) % uint64(len(all)))
+		workers = append(all[start:len(all):len(all)], all[:start]...)
+	}
+
+	now := time.Now()
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	available := make([]string, 0, len(workers))
+	var cooling []string
+	for _, worker := range workers {
+		if until, ok := rt.down[worker]; ok && now.Before(until) {
+			cooling = append(cooling, worker)
+		} else {
+			delete(rt.down, worker)
+			available = append(available, worker)
+		}
+	}
+	return append(available, cooling...)
+}
+
+// ServeHTTP forwards the request to the worker of its thread.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	lastErr := ErrNoWorker
+	for _, worker := range rt.Candidates(rt.opts.ThreadID(r)) {
+		resp, err := rt.forward(r, worker, body)
+		if err == nil && !unavailable(resp.StatusCode) {
+			defer resp.Body.Close()
+			for key, values := range resp.Header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(resp.StatusCode)
+			_, _ = io.Copy(w, resp.Body)
+			return
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("status %s", resp.Status)
+		}
+		lastErr = fmt.Errorf("%w: %s: %v", ErrNoWorker, worker, err)
+		rt.mu.Lock()
+		rt.down[worker] = time.Now().Add(rt.opts.Cooldown)
+		rt.mu.Unlock()
+	}
+	http.Error(w, lastErr.Error(), http.StatusBadGateway)
+}
+
+// forward sends a copy of the request to the worker.
+func (rt *Router) forward(r *http.Request, worker string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, worker+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	return rt.opts.Client.Do(req)
+}
+
+// unavailable reports whether a status tells the worker could not serve the request, which is
+// then retried on another worker.
+func unavailable(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,125 @@
+package worker_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	ring := worker.NewRing(0, "a", "b", "c", "a")
+	assert.Equal(t, []string{"a", "b", "c"}, ring.Workers())
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := range 3000 {
+		key := fmt.Sprintf("thread-%d", i)
+		workers := ring.Lookup(key)
+		require.Len(t, workers, 3)
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, workers)
+		owners[key] = workers[0]
+		counts[workers[0]]++
+	}
+	for worker, count := range counts {
+		assert.InDelta(t, 1000, count, 300, "keys of worker %s", worker)
+	}
+
+	// Removing a worker only moves its own keys, to their next worker.
+	ring.Remove("b")
+	for key, owner := range owners {
+		workers := ring.Lookup(key)
+		if owner != "b" {
+			assert.Equal(t, owner, workers[0])
+		}
+	}
+
+	ring.Add("b")
+	for key, owner := range owners {
+		assert.Equal(t, owner, ring.Lookup(key)[0])
+	}
+
+	assert.Nil(t, worker.NewRing(4).Lookup("thread"))
+}
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	served := make(map[string][]string)
+	servers := make(map[string]*httptest.Server)
+	var urls []string
+	for _, name := range []string{"w1", "w2", "w3"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Worker", name)
+			fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.RequestURI(), body)
+		}))
+		t.Cleanup(server.Close)
+		servers[server.URL] = server
+		urls = append(urls, server.URL)
+	}
+
+	ring := worker.NewRing(0, urls...)
+	router := httptest.NewServer(worker.NewRouter(ring, worker.RouterOptions{Cooldown: time.Hour}))
+	defer router.Close()
+
+	post := func(path string) (string, string) {
+		resp, err := http.Post(router.URL+path, "application/json", strings.NewReader(`{"q":1}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		return resp.Header.Get("Worker"), string(body)
+	}
+
+	first, body := post("/support?thread_id=t1")
+	assert.Equal(t, `POST /support?thread_id=t1 {"q":1}`, body)
+	for range 5 {
+		again, _ := post("/support?thread_id=t1")
+		assert.Equal(t, first, again)
+	}
+
+	// The owner fails: the thread moves to its next worker and stays there.
+	preference := ring.Lookup("t1")
+	servers[preference[0]].Close()
+	fallback, _ := post("/support?thread_id=t1")
+	assert.NotEqual(t, first, fallback)
+	for range 3 {
+		again, _ := post("/support?thread_id=t1")
+		assert.Equal(t, fallback, again)
+	}
+	assert.Equal(t, preference[0], router.Config.Handler.(*worker.Router).Candidates("t1")[2])
+
+	// Requests without thread are spread over the workers that are up.
+	for range 4 {
+		name, _ := post("/support")
+		served[name] = append(served[name], name)
+	}
+	assert.Len(t, served, 2)
+}
+
+func TestRouterNoWorker(t *testing.T) {
+	t.Parallel()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	for _, ring := range []*worker.Ring{worker.NewRing(0), worker.NewRing(0, down.URL)} {
+		rec := httptest.NewRecorder()
+		worker.NewRouter(ring, worker.RouterOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/support?thread_id=t1", nil))
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.Contains(t, rec.Body.String(), worker.ErrNoWorker.Error())
+	}
+}
@@ -0,0 +1,445 @@
+// Package kafkaqueue implements worker.Queue on Kafka.
+//
+// Runs are produced to a topic consumed by a consumer group formed by the workers: the
+// partitions of the topic are spread among the workers, and every run is delivered to the
+// worker owning its partition. Runs are keyed by thread, so the runs of a thread are delivered
+// in order.
+//
+// Deliveries are settled in Kafka transactions which commit the offset of the run together
+// with the records settling it: the retry of a nacked run, or the dead letter of a
+// dead-lettered one. A run is therefore handed off exactly once, even across crashes and
+// rebalances: either its settlement commits, or the run is delivered again from the last
+// committed offset. When the partition of a run in progress is revoked, the context of its
+// delivery (see worker.Leased) is cancelled, so the run stops before writing further
+// checkpoints and its settlement aborts; the new owner of the partition runs it again.
+//
+// Dead-lettered runs are produced as worker.DeadLetter JSON documents to a separate topic. The
+// queue does not implement worker.Quarantine: records cannot be removed from a topic.
+//
+// Topics are not created by the queue.
+package kafkaqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cesto93/langgraphgo/worker"
+)
+
+// ErrRevoked is returned when settling a delivery whose partition was revoked while it was
+// processed. The run is delivered again to the new owner of the partition.
+var ErrRevoked = errors.New("partition revoked")
+
+const (
+	// DefaultTopic is the topic of runs when none is configured.
+	DefaultTopic = "runs"
+
+	// DefaultGroup is the consumer group of the workers when none is configured.
+	DefaultGroup = "workers"
+
+	// headerAttempt holds the attempt of a retried run.
+	headerAttempt = "attempt"
+
+	// headerNotBefore holds the time, in Unix milliseconds, before which a retried run must not
+	// be delivered.
+	headerNotBefore = "not-before"
+)
+
+// Config configures a Queue.
+type Config struct {
+	// Brokers are the seed brokers of the cluster.
+	Brokers []string
+
+	// Topic is the topic of runs; DefaultTopic is used when empty.
+	Topic string
+
+	// Group is the consumer group shared by the workers; DefaultGroup is used when empty.
+	Group string
+
+	// DeadLetterTopic is the topic of dead-lettered runs; Topic followed by .dead is used when
+	// empty.
+	DeadLetterTopic string
+
+	// TransactionalID identifies the transactions of the queue and must be unique among the
+	// workers; the group followed by the host name and the process ID is used when empty.
+	TransactionalID string
+
+	// Options are additional client options, e.g. for TLS or SASL.
+	Options []kgo.Opt
+}
+
+// Queue is a worker.Queue on Kafka. A queue delivers one run at a time: Receive waits for the
+// delivery in progress to be settled. Run more workers, up to the number of partitions of the
+// topic, to process more runs at once.
+type Queue struct {
+	cfg      Config
+	session  *kgo.GroupTransactSession
+	producer *kgo.Client
+
+	// inflight holds a token while a delivery is received or processed.
+	inflight chan struct{}
+
+	mu sync.Mutex
+	// current is the delivery in progress.
+	current *delivery
+	// pending is a run received but not delivered yet, because it is not due.
+	pending *delivery
+	// stale is set when partitions were revoked since the last transaction ended.
+	stale bool
+}
+
+var _ worker.Queue = (*Queue)(nil)
+
+// New returns a queue joining the consumer group of the configuration.
+func New(cfg Config) (*Queue, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = DefaultTopic
+	}
+	if cfg.Group == "" {
+		cfg.Group = DefaultGroup
+	}
+	if cfg.DeadLetterTopic == "" {
+		cfg.DeadLetterTopic = cfg.Topic + ".dead"
+	}
+	if cfg.TransactionalID == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("naming transactions: %w", err)
+		}
+		cfg.TransactionalID = fmt.Sprintf("%s-%s-%d", cfg.Group, host, os.Getpid())
+	}
+
+	q := &Queue{cfg: cfg, inflight: make(chan struct{}, 1)}
+	opts := append([]kgo.Opt{kgo.SeedBrokers(cfg.Brokers...)}, cfg.Options...)
+
+	producer, err := kgo.NewClient(append(opts, kgo.DefaultProduceTopic(cfg.Topic))...)
+	if err != nil {
+		return nil, fmt.Errorf("creating producer: %w", err)
+	}
+	q.producer = producer
+
+	q.session, err = kgo.NewGroupTransactSession(append(opts,
+		kgo.ConsumerGroup(cfg.Group),
+		kgo.ConsumeTopics(cfg.Topic),
+		kgo.TransactionalID(cfg.TransactionalID),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.RequireStableFetchOffsets(),
+		kgo.OnPartitionsRevoked(q.revoke),
+		kgo.OnPartitionsLost(q.revoke),
+	)...)
+	if err != nil {
+		producer.Close()
+		return nil, fmt.Errorf("creating consumer: %w", err)
+	}
+	return q, nil
+}
+
+// Close leaves the consumer group and closes the clients of the queue.
+func (q *Queue) Close() {
+	q.session.Close()
+	q.producer.Close()
+}
+
+// Enqueue produces a run, keyed by its thread or, for runs without thread, by its ID. Runs
+// enqueued twice are delivered twice.
+func (q *Queue) Enqueue(ctx context.Context, run worker.Run) error {
+	if run.EnqueuedAt.IsZero() {
+		run.EnqueuedAt = time.Now()
+	}
+	record, err := q.record(q.cfg.Topic, run)
+	if err != nil {
+		return err
+	}
+	if err := q.producer.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("producing run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// record returns the record of a run.
+func (q *Queue) record(topic string, run worker.Run) (*kgo.Record, error) {
+	value, err := json.Marshal(run)
+	if err != nil {
+		return nil, fmt.Errorf("encoding run %s: %w", run.ID, err)
+	}
+	key := run.ThreadID
+	if key == "" {
+		key = run.ID
+	}
+	return &kgo.Record{Topic: topic, Key: []byte(key), Value: value}, nil
+}
+
+// Receive waits for the delivery in progress to be settled, then for the next run. Retried runs
+// are delivered once their delay elapsed, which holds back the runs behind them on their
+// partition. Records that are not runs are dead-lettered.
+func (q *Queue) Receive(ctx context.Context) (worker.Delivery, error) {
+	select {
+	case q.inflight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	d, err := q.receive(ctx)
+	if err != nil {
+		<-q.inflight
+		return nil, err
+	}
+	return d, nil
+}
+
+func (q *Queue) receive(ctx context.Context) (*delivery, error) {
+	for {
+		if err := q.refresh(ctx); err != nil {
+			return nil, err
+		}
+
+		q.mu.Lock()
+		d := q.pending
+		q.mu.Unlock()
+		if d == nil {
+			var err error
+			if d, err = q.poll(ctx); err != nil {
+				return nil, err
+			}
+			if d == nil {
+				continue
+			}
+		}
+
+		if wait := time.Until(d.notBefore); wait > 0 {
+			q.mu.Lock()
+			q.pending = d
+			q.mu.Unlock()
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+
+		q.mu.Lock()
+		if q.stale {
+			// The partition of the run was revoked while waiting.
+			q.mu.Unlock()
+			continue
+		}
+		q.pending = nil
+		q.current = d
+		q.mu.Unlock()
+		return d, nil
+	}
+}
+
+// refresh aborts the empty transaction left open by a rebalance since the last settlement, so
+// that the next settlement is not aborted for it. Aborting rewinds the consumer to the last
+// committed offsets, which drops the pending run.
+func (q *Queue) refresh(ctx context.Context) error {
+	// The session is not called with mu held: it calls revoke with its own lock held.
+	q.mu.Lock()
+	stale := q.stale
+	if stale {
+		q.stale = false
+		q.pending = nil
+	}
+	q.mu.Unlock()
+
+	if !stale {
+		return nil
+	}
+	if err := q.session.Begin(); err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	if _, err := q.session.End(ctx, kgo.TryAbort); err != nil {
+		return fmt.Errorf("aborting transaction: %w", err)
+	}
+	return nil
+}
+
+// poll fetches the next record and returns its delivery, or nil when the record was not a run
+// and was dead-lettered.
+func (q *Queue) poll(ctx context.Context) (*delivery, error) {
+	fetches := q.session.PollRecords(ctx, 1)
+	if fetches.IsClientClosed() {
+		return nil, worker.ErrQueueClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := fetches.Err(); err != nil {
+		return nil, fmt.Errorf("fetching run: %w", err)
+	}
+	records := fetches.Records()
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	d, err := q.delivery(records[0])
+	if err != nil {
+		q.mu.Lock()
+		q.current = d
+		q.mu.Unlock()
+		if err := d.DeadLetter(ctx, err); err != nil {
+			return nil, err
+		}
+		// Take the token back from the settlement.
+		q.inflight <- struct{}{}
+		return nil, nil
+	}
+	return d, nil
+}
+
+// delivery returns the delivery of a record. It returns an error along with a delivery of an
+// empty run if the record is not a run.
+func (q *Queue) delivery(record *kgo.Record) (*delivery, error) {
+	lease, cancel := context.WithCancelCause(context.Background())
+	d := &delivery{queue: q, record: record, attempt: 1, lease: lease, cancel: cancel}
+	if err := json.Unmarshal(record.Value, &d.run); err != nil {
+		return d, fmt.Errorf("decoding run: %w", err)
+	}
+	for _, h := range record.Headers {
+		switch h.Key {
+		case headerAttempt:
+			attempt, err := strconv.Atoi(string(h.Value))
+			if err != nil {
+				return d, fmt.Errorf("decoding attempt: %w", err)
+			}
+			d.attempt = attempt
+		case headerNotBefore:
+			ms, err := strconv.ParseInt(string(h.Value), 10, 64)
+			if err != nil {
+				return d, fmt.Errorf("decoding delay: %w", err)
+			}
+			d.notBefore = time.UnixMilli(ms)
+		}
+	}
+	return d, nil
+}
+
+// revoke cancels the lease of the delivery in progress when partitions are revoked from the
+// queue: the transaction settling it is aborted anyway.
+func (q *Queue) revoke(_ context.Context, _ *kgo.Client, partitions map[string][]int32) {
+	if len(partitions) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stale = true
+	q.pending = nil
+	if q.current != nil {
+		q.current.cancel(ErrRevoked)
+	}
+}
+
+type delivery struct {
+	queue     *Queue
+	record    *kgo.Record
+	run       worker.Run
+	attempt   int
+	notBefore time.Time
+
+	lease   context.Context
+	cancel  context.CancelCauseFunc
+	settled bool
+}
+
+var _ worker.Leased = (*delivery)(nil)
+
+func (d *delivery) Run() worker.Run { return d.run }
+func (d *delivery) Attempt() int    { return d.attempt }
+
+// Lease returns a context cancelled with ErrRevoked when partitions are revoked from the queue.
+func (d *delivery) Lease(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+	context.AfterFunc(d.lease, func() { cancel(context.Cause(d.lease)) })
+	return ctx
+}
+
+func (d *delivery) Ack(ctx context.Context) error {
+	return d.settle(ctx)
+}
+
+// Nack produces the run again with the next attempt. Nacking a run whose partition was revoked
+// succeeds without producing it: the new owner of the partition delivers it again.
+func (d *delivery) Nack(ctx context.Context, delay time.Duration) error {
+	if d.lease.Err() != nil {
+		if err := d.settle(ctx); !errors.Is(err, ErrRevoked) {
+			return err
+		}
+		return nil
+	}
+
+	record, err := d.queue.record(d.record.Topic, d.run)
+	if err != nil {
+		return err
+	}
+	record.Headers = []kgo.RecordHeader{{Key: headerAttempt, Value: []byte(strconv.Itoa(d.attempt + 1))}}
+	if delay > 0 {
+		notBefore := strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: headerNotBefore, Value: []byte(notBefore)})
+	}
+	if err := d.settle(ctx, record); err != nil && !errors.Is(err, ErrRevoked) {
+		return err
+	}
+	return nil
+}
+
+// DeadLetter produces a worker.DeadLetter JSON document to the dead-letter topic.
+func (d *delivery) DeadLetter(ctx context.Context, reason error) error {
+	value, err := json.Marshal(worker.NewDeadLetter(d.run, d.attempt, reason))
+	if err != nil {
+		return err
+	}
+	return d.settle(ctx, &kgo.Record{Topic: d.queue.cfg.DeadLetterTopic, Key: d.record.Key, Value: value})
+}
+
+// settle produces records and commits the offset of the run in a transaction, and ends the
+// delivery.
+func (d *delivery) settle(ctx context.Context, records ...*kgo.Record) error {
+	q := d.queue
+	q.mu.Lock()
+	if d.settled {
+		q.mu.Unlock()
+		return fmt.Errorf("run %s: delivery already settled", d.run.ID)
+	}
+	d.settled = true
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		q.current = nil
+		q.stale = false
+		q.mu.Unlock()
+		d.cancel(nil)
+		<-q.inflight
+	}()
+
+	if err := q.session.Begin(); err != nil {
+		return fmt.Errorf("run %s: beginning transaction: %w", d.run.ID, err)
+	}
+	if len(records) > 0 {
+		if err := q.session.ProduceSync(ctx, records...).FirstErr(); err != nil {
+			_, _ = q.session.End(ctx, kgo.TryAbort)
+			return fmt.Errorf("run %s: producing: %w", d.run.ID, err)
+		}
+	}
+	committed, err := q.session.End(ctx, kgo.TryCommit)
+	if err != nil {
+		return fmt.Errorf("run %s: committing: %w", d.run.ID, err)
+	}
+	if !committed {
+		return fmt.Errorf("%w: run %s", ErrRevoked, d.run.ID)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package kafkaqueue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/cesto93/langgraphgo/worker/kafkaqueue"
+)
+
+// TestQueue runs against the Kafka cluster at the comma-separated KAFKA_BROKERS, which must
+// create topics automatically, and is skipped when it is not set.
+func TestQueue(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Isolate the topics of every run of the test.
+	topic := "test-runs-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	seeds := strings.Split(brokers, ",")
+	q, err := kafkaqueue.New(kafkaqueue.Config{
+		Brokers: seeds,
+		Topic:   topic,
+		Group:   topic,
+		Options: []kgo.Opt{kgo.AllowAutoTopicCreation()},
+	})
+	require.NoError(t, err)
+	defer q.Close()
+
+	for _, id := range []string{"r1", "r2"} {
+		require.NoError(t, q.Enqueue(ctx, worker.Run{ID: id, Graph: "g", ThreadID: "t", Input: []byte(`[]`)}))
+	}
+
+	d, err := q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 1, d.Attempt())
+	require.NoError(t, d.Nack(ctx, 100*time.Millisecond))
+	require.Error(t, d.Ack(ctx))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", d.Run().ID)
+	require.NoError(t, d.DeadLetter(ctx, errors.New("poisoned")))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 2, d.Attempt())
+	require.NoError(t, d.Ack(ctx))
+
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(seeds...),
+		kgo.ConsumeTopics(topic+".dead"),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	)
+	require.NoError(t, err)
+	defer consumer.Close()
+	records := consumer.PollFetches(ctx).Records()
+	require.Len(t, records, 1)
+	var dead worker.DeadLetter
+	require.NoError(t, json.Unmarshal(records[0].Value, &dead))
+	assert.Equal(t, "r2", dead.Run.ID)
+	assert.Equal(t, "poisoned", dead.Error)
+}
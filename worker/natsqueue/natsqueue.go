@@ -0,0 +1,341 @@
+// Package natsqueue implements worker.Queue on NATS JetStream.
+//
+// Runs are published to a work-queue stream and consumed by a durable pull consumer shared by
+// all the workers, which form its consumer group: every run is delivered to one worker at a
+// time. Runs whose delivery is not acknowledged within the ack wait, e.g. because their worker
+// crashed, are redelivered. Dead-lettered runs are published as worker.DeadLetter JSON
+// documents to a separate stream, where operators can triage them through the
+// worker.Quarantine methods of the queue.
+package natsqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/cesto93/langgraphgo/worker"
+)
+
+const (
+	// DefaultStream is the name of the stream of runs when none is configured.
+	DefaultStream = "RUNS"
+
+	// DefaultConsumer is the name of the consumer group when none is configured.
+	DefaultConsumer = "workers"
+
+	// DefaultAckWait is how long a delivery may stay unsettled before the run is redelivered,
+	// when none is configured.
+	DefaultAckWait = 5 * time.Minute
+
+	// pollInterval bounds a single fetch, so Receive notices the end of its context.
+	pollInterval = 5 * time.Second
+)
+
+// Config configures a Queue.
+type Config struct {
+	// Stream is the stream of runs; DefaultStream is used when empty.
+	Stream string
+
+	// Subject is the subject runs are published to; the lowercase stream name is used when
+	// empty.
+	Subject string
+
+	// Consumer is the durable consumer shared by the workers; DefaultConsumer is used when
+	// empty.
+	Consumer string
+
+	// DeadLetterStream is the stream of dead-lettered runs; Stream followed by _DEAD is used
+	// when empty.
+	DeadLetterStream string
+
+	// DeadLetterSubject is the subject dead-lettered runs are published to; Subject followed
+	// by .dead is used when empty.
+	DeadLetterSubject string
+
+	// AckWait is how long a delivery may stay unsettled before the run is redelivered;
+	// DefaultAckWait is used when zero. It must exceed the duration of the longest run.
+	AckWait time.Duration
+
+	// Replicas is the number of replicas of the streams; one is used when zero.
+	Replicas int
+}
+
+// Queue is a worker.Queue on NATS JetStream.
+type Queue struct {
+	js       jetstream.JetStream
+	cfg      Config
+	consumer jetstream.Consumer
+	dead     jetstream.Stream
+}
+
+var (
+	_ worker.Queue      = (*Queue)(nil)
+	_ worker.Quarantine = (*Queue)(nil)
+)
+
+// New creates or updates the streams and the consumer of the configuration and returns a
+// queue using them.
+func New(ctx context.Context, js jetstream.JetStream, cfg Config) (*Queue, error) {
+	if cfg.Stream == "" {
+		cfg.Stream = DefaultStream
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = lower(cfg.Stream)
+	}
+	if cfg.Consumer == "" {
+		cfg.Consumer = DefaultConsumer
+	}
+	if cfg.DeadLetterStream == "" {
+		cfg.DeadLetterStream = cfg.Stream + "_DEAD"
+	}
+	if cfg.DeadLetterSubject == "" {
+		cfg.DeadLetterSubject = cfg.Subject + ".dead"
+	}
+	if cfg.AckWait == 0 {
+		cfg.AckWait = DefaultAckWait
+	}
+	cfg.Replicas = max(cfg.Replicas, 1)
+
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  []string{cfg.Subject},
+		Retention: jetstream.WorkQueuePolicy,
+		Replicas:  cfg.Replicas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating stream %s: %w", cfg.Stream, err)
+	}
+	dead, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.DeadLetterStream,
+		Subjects: []string{cfg.DeadLetterSubject},
+		Replicas: cfg.Replicas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating stream %s: %w", cfg.DeadLetterStream, err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:   cfg.Consumer,
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   cfg.AckWait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating consumer %s: %w", cfg.Consumer, err)
+	}
+	return &Queue{js: js, cfg: cfg, consumer: consumer, dead: dead}, nil
+}
+
+// Enqueue publishes a run. Runs published again with the same ID within the duplicate window
+// of the stream are dropped.
+func (q *Queue) Enqueue(ctx context.Context, run worker.Run) error {
+	if run.EnqueuedAt.IsZero() {
+		run.EnqueuedAt = time.Now()
+	}
+	return q.publish(ctx, run, run.ID)
+}
+
+// publish publishes a run with a message ID, for deduplication, unless empty.
+func (q *Queue) publish(ctx context.Context, run worker.Run, msgID string) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("encoding run %s: %w", run.ID, err)
+	}
+
+	var opts []jetstream.PublishOpt
+	if msgID != "" {
+		opts = append(opts, jetstream.WithMsgID(msgID))
+	}
+	if _, err := q.js.Publish(ctx, q.cfg.Subject, data, opts...); err != nil {
+		return fmt.Errorf("publishing run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// Receive waits for the next run. Messages that are not runs are dead-lettered.
+func (q *Queue) Receive(ctx context.Context) (worker.Delivery, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		batch, err := q.consumer.Fetch(1, jetstream.FetchMaxWait(pollInterval))
+		if err != nil {
+			return nil, fmt.Errorf("fetching run: %w", err)
+		}
+		for msg := range batch.Messages() {
+			d, err := q.delivery(msg)
+			if err != nil {
+				if err := q.deadLetter(ctx, msg, worker.Run{}, 1, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return d, nil
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+			return nil, fmt.Errorf("fetching run: %w", err)
+		}
+	}
+}
+
+func (q *Queue) delivery(msg jetstream.Msg) (*delivery, error) {
+	var run worker.Run
+	if err := json.Unmarshal(msg.Data(), &run); err != nil {
+		return nil, fmt.Errorf("decoding run: %w", err)
+	}
+	meta, err := msg.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	return &delivery{queue: q, msg: msg, run: run, attempt: int(meta.NumDelivered)}, nil
+}
+
+// deadLetter publishes the run to the dead-letter stream, then removes the message from the
+// stream of runs. A crash in between leaves a dead letter for a run that is redelivered.
+func (q *Queue) deadLetter(ctx context.Context, msg jetstream.Msg, run worker.Run, attempt int, reason error) error {
+	data, err := json.Marshal(worker.NewDeadLetter(run, attempt, reason))
+	if err != nil {
+		return err
+	}
+	if _, err := q.js.Publish(ctx, q.cfg.DeadLetterSubject, data); err != nil {
+		return fmt.Errorf("publishing dead letter of run %s: %w", run.ID, err)
+	}
+	return msg.TermWithReason(reason.Error())
+}
+
+// List returns the dead letters of the dead-letter stream.
+func (q *Queue) List(ctx context.Context) ([]worker.DeadLetter, error) {
+	stored, err := q.deadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dead := make([]worker.DeadLetter, len(stored))
+	for i, s := range stored {
+		dead[i] = s.DeadLetter
+	}
+	return dead, nil
+}
+
+// Requeue publishes a dead-lettered run again, then removes its dead letter.
+func (q *Queue) Requeue(ctx context.Context, runID string) error {
+	return q.Replay(ctx, runID, nil)
+}
+
+// Replay publishes the run returned by migrate for a dead-lettered run, then removes its dead
+// letter. A crash in between leaves the dead letter of a replayed run.
+func (q *Queue) Replay(ctx context.Context, runID string, migrate worker.Migration) error {
+	s, err := q.find(ctx, runID)
+	if err != nil {
+		return err
+	}
+	run, err := migrate.Apply(ctx, s.DeadLetter)
+	if err != nil {
+		return err
+	}
+	// The ID of the run may still be in the duplicate window of the stream.
+	if err := q.publish(ctx, run, fmt.Sprintf("%s/replay/%d", run.ID, s.seq)); err != nil {
+		return err
+	}
+	return q.discard(ctx, s)
+}
+
+// Discard removes the dead letter of a run.
+func (q *Queue) Discard(ctx context.Context, runID string) error {
+	s, err := q.find(ctx, runID)
+	if err != nil {
+		return err
+	}
+	return q.discard(ctx, s)
+}
+
+// storedDeadLetter is a dead letter along with its sequence in the dead-letter stream.
+type storedDeadLetter struct {
+	worker.DeadLetter
+	seq uint64
+}
+
+// deadLetters reads the dead-letter stream.
+func (q *Queue) deadLetters(ctx context.Context) ([]storedDeadLetter, error) {
+	info, err := q.dead.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading stream %s: %w", q.cfg.DeadLetterStream, err)
+	}
+
+	var stored []storedDeadLetter
+	for seq := info.State.FirstSeq; info.State.Msgs > 0 && seq <= info.State.LastSeq; seq++ {
+		msg, err := q.dead.GetMsg(ctx, seq)
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading dead letter %d: %w", seq, err)
+		}
+		s := storedDeadLetter{seq: seq}
+		if err := json.Unmarshal(msg.Data, &s.DeadLetter); err != nil {
+			return nil, fmt.Errorf("decoding dead letter %d: %w", seq, err)
+		}
+		stored = append(stored, s)
+	}
+	return stored, nil
+}
+
+// find returns the first dead letter of a run.
+func (q *Queue) find(ctx context.Context, runID string) (storedDeadLetter, error) {
+	stored, err := q.deadLetters(ctx)
+	if err != nil {
+		return storedDeadLetter{}, err
+	}
+	for _, s := range stored {
+		if s.Run.ID == runID {
+			return s, nil
+		}
+	}
+	return storedDeadLetter{}, fmt.Errorf("%w: %s", worker.ErrNotQuarantined, runID)
+}
+
+func (q *Queue) discard(ctx context.Context, s storedDeadLetter) error {
+	if err := q.dead.DeleteMsg(ctx, s.seq); err != nil {
+		return fmt.Errorf("removing dead letter of run %s: %w", s.Run.ID, err)
+	}
+	return nil
+}
+
+// lower returns s in lowercase, for the ASCII stream names.
+func lower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+type delivery struct {
+	queue   *Queue
+	msg     jetstream.Msg
+	run     worker.Run
+	attempt int
+}
+
+func (d *delivery) Run() worker.Run { return d.run }
+func (d *delivery) Attempt() int    { return d.attempt }
+
+// Ack acknowledges the message and waits for the server to confirm, so an acknowledged run is
+// never redelivered.
+func (d *delivery) Ack(ctx context.Context) error {
+	return d.msg.DoubleAck(ctx)
+}
+
+func (d *delivery) Nack(_ context.Context, delay time.Duration) error {
+	return d.msg.NakWithDelay(delay)
+}
+
+func (d *delivery) DeadLetter(ctx context.Context, reason error) error {
+	return d.queue.deadLetter(ctx, d.msg, d.run, d.attempt, reason)
+}
@@ -0,0 +1,93 @@
+package natsqueue_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/cesto93/langgraphgo/worker/natsqueue"
+)
+
+// TestQueue runs against the JetStream-enabled NATS server at NATS_URL, and is skipped when
+// it is not set.
+func TestQueue(t *testing.T) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		t.Skip("NATS_URL not set")
+	}
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Isolate the streams of every run of the test.
+	stream := "TEST_RUNS_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	q, err := natsqueue.New(ctx, js, natsqueue.Config{Stream: stream, AckWait: time.Second})
+	require.NoError(t, err)
+	defer func() {
+		_ = js.DeleteStream(context.Background(), stream)
+		_ = js.DeleteStream(context.Background(), stream+"_DEAD")
+	}()
+
+	for _, id := range []string{"r1", "r1", "r2"} {
+		require.NoError(t, q.Enqueue(ctx, worker.Run{ID: id, Graph: "g", Input: []byte(`[]`)}))
+	}
+
+	d, err := q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 1, d.Attempt())
+	require.NoError(t, d.Nack(ctx, 0))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", d.Run().ID)
+	require.NoError(t, d.DeadLetter(ctx, errors.New("poisoned")))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 2, d.Attempt())
+	require.NoError(t, d.Ack(ctx))
+
+	dead, err := q.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "r2", dead[0].Run.ID)
+	assert.Equal(t, "poisoned", dead[0].Error)
+
+	// Requeued runs are not dropped as duplicates, and start over.
+	require.NoError(t, q.Requeue(ctx, "r2"))
+	require.ErrorIs(t, q.Requeue(ctx, "r2"), worker.ErrNotQuarantined)
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", d.Run().ID)
+	assert.Equal(t, 1, d.Attempt())
+	require.NoError(t, d.DeadLetter(ctx, errors.New("poisoned again")))
+
+	require.NoError(t, q.Replay(ctx, "r2", worker.Retarget("g2")))
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", d.Run().ID)
+	assert.Equal(t, "g2", d.Run().Graph)
+	require.NoError(t, d.DeadLetter(ctx, errors.New("poisoned once more")))
+
+	require.NoError(t, q.Discard(ctx, "r2"))
+	dead, err = q.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+}
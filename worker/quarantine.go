@@ -0,0 +1,187 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+var (
+	// ErrNotQuarantined is returned by quarantines for runs without dead letter.
+	ErrNotQuarantined = errors.New("run not quarantined")
+
+	// ErrPoisoned is the reason of the runs dead-lettered without being processed because they
+	// were delivered more than the maximum number of attempts, which happens when they crash
+	// their workers before being settled.
+	ErrPoisoned = errors.New("run delivered too many times")
+)
+
+// RunError is the error of a run, along with the latest checkpoint of its thread when it
+// failed. Dead letters keep the checkpoint so that operators can inspect the state the run
+// failed from.
+type RunError struct {
+	// Err is the error of the run.
+	Err error
+
+	// Checkpoint is the latest checkpoint of the thread of the run.
+	Checkpoint checkpoint.Checkpoint
+}
+
+// Error returns the message of Err.
+func (e *RunError) Error() string { return e.Err.Error() }
+
+// Unwrap returns Err.
+func (e *RunError) Unwrap() error { return e.Err }
+
+// NewDeadLetter returns the dead letter of a run delivered attempts times and failing with
+// reason, which may wrap a RunError. Queues use it to dead-letter runs.
+func NewDeadLetter(run Run, attempts int, reason error) DeadLetter {
+	dead := DeadLetter{Run: run, Attempts: attempts, Error: reason.Error(), At: time.Now()}
+	if runErr := (*RunError)(nil); errors.As(reason, &runErr) {
+		dead.Checkpoint = &runErr.Checkpoint
+	}
+	return dead
+}
+
+// Migration adapts a dead-lettered run to a newer version of its graph before it is replayed.
+type Migration func(ctx context.Context, dead DeadLetter) (Run, error)
+
+// Apply returns the run replaying a dead letter: the run returned by m, or the run of the dead
+// letter if m is nil. The run keeps the ID of the dead-lettered run and is stamped with the
+// current time.
+func (m Migration) Apply(ctx context.Context, dead DeadLetter) (Run, error) {
+	run := dead.Run
+	if m != nil {
+		var err error
+		if run, err = m(ctx, dead); err != nil {
+			return run, fmt.Errorf("migrating run %s: %w", dead.Run.ID, err)
+		}
+	}
+	run.ID = dead.Run.ID
+	run.EnqueuedAt = time.Now()
+	return run, nil
+}
+
+// Retarget returns a migration replaying runs against the named graph, e.g. a newer version of
+// the graph that failed them deployed under another name.
+func Retarget(graph string) Migration {
+	return func(_ context.Context, dead DeadLetter) (Run, error) {
+		run := dead.Run
+		run.Graph = graph
+		return run, nil
+	}
+}
+
+// ConfirmSideEffects returns a migration replaying runs allowed to execute again the
+// side-effecting nodes their previous deliveries may have executed, once an operator checked
+// it is safe; see graph.WithSideEffects.
+func ConfirmSideEffects() Migration {
+	return func(_ context.Context, dead DeadLetter) (Run, error) {
+		run := dead.Run
+		run.ConfirmSideEffects = true
+		return run, nil
+	}
+}
+
+// MigrateInput returns a migration replaying runs against the named graph, with their input
+// converted from the state of their graph, From, to the state of the named graph, To.
+func MigrateInput[From, To any](graph string, convert func(From) (To, error)) Migration {
+	return func(_ context.Context, dead DeadLetter) (Run, error) {
+		var in From
+		if err := json.Unmarshal(dead.Run.Input, &in); err != nil {
+			return Run{}, fmt.Errorf("decoding input: %w", err)
+		}
+		out, err := convert(in)
+		if err != nil {
+			return Run{}, err
+		}
+		input, err := json.Marshal(out)
+		if err != nil {
+			return Run{}, fmt.Errorf("encoding input: %w", err)
+		}
+
+		run := dead.Run
+		run.Graph = graph
+		run.Input = input
+		return run, nil
+	}
+}
+
+// Quarantine is implemented by the queues able to triage their dead-lettered runs.
+// Dead letters are identified by the ID of their run.
+type Quarantine interface {
+	// List returns the dead-lettered runs, in the order they were dead-lettered.
+	List(ctx context.Context) ([]DeadLetter, error)
+
+	// Requeue removes the dead letter of a run and enqueues the run again, e.g. once the bug
+	// failing it was fixed. The run starts over from its first attempt.
+	Requeue(ctx context.Context, runID string) error
+
+	// Replay is like Requeue, but enqueues the run returned by migrate instead, e.g. to run it
+	// against the newer version of its graph fixing the bug. The dead letter is kept if
+	// migrate fails.
+	Replay(ctx context.Context, runID string, migrate Migration) error
+
+	// Discard removes the dead letter of a run for good.
+	Discard(ctx context.Context, runID string) error
+}
+
+// QuarantineHandler returns an HTTP API triaging the dead letters of a quarantine:
+//
+//	GET    /                     lists the dead letters as JSON
+//	POST   /{run}/requeue        requeues a run
+//	POST   /{run}/replay?graph=  replays a run against another graph, see Retarget
+//	POST   /{run}/confirm        replays a run confirming its side effects, see ConfirmSideEffects
+//	DELETE /{run}                discards a run
+//
+// Mount it with http.StripPrefix to serve it under a path.
+func QuarantineHandler(q Quarantine) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		dead, err := q.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if dead == nil {
+			dead = []DeadLetter{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(dead)
+	})
+	mux.HandleFunc("POST /{run}/requeue", func(w http.ResponseWriter, r *http.Request) {
+		triage(w, q.Requeue(r.Context(), r.PathValue("run")))
+	})
+	mux.HandleFunc("POST /{run}/replay", func(w http.ResponseWriter, r *http.Request) {
+		graph := r.URL.Query().Get("graph")
+		if graph == "" {
+			http.Error(w, "missing graph", http.StatusBadRequest)
+			return
+		}
+		triage(w, q.Replay(r.Context(), r.PathValue("run"), Retarget(graph)))
+	})
+	mux.HandleFunc("POST /{run}/confirm", func(w http.ResponseWriter, r *http.Request) {
+		triage(w, q.Replay(r.Context(), r.PathValue("run"), ConfirmSideEffects()))
+	})
+	mux.HandleFunc("DELETE /{run}", func(w http.ResponseWriter, r *http.Request) {
+		triage(w, q.Discard(r.Context(), r.PathValue("run")))
+	})
+	return mux
+}
+
+// triage writes the response of a requeue or a discard.
+func triage(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrNotQuarantined):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,173 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quarantined returns a memory queue holding the dead letters of the runs.
+func quarantined(t *testing.T, ids ...string) *worker.MemoryQueue {
+	t.Helper()
+
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	for _, id := range ids {
+		require.NoError(t, q.Enqueue(ctx, worker.Run{ID: id, Graph: "g"}))
+		d, err := q.Receive(ctx)
+		require.NoError(t, err)
+		require.NoError(t, d.DeadLetter(ctx, errors.New("failed")))
+	}
+	return q
+}
+
+func TestNewDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	run := worker.Run{ID: "r1", Graph: "g"}
+	dead := worker.NewDeadLetter(run, 2, errors.New("failed"))
+	assert.Equal(t, run, dead.Run)
+	assert.Equal(t, 2, dead.Attempts)
+	assert.Equal(t, "failed", dead.Error)
+	assert.Nil(t, dead.Checkpoint)
+
+	cp := checkpoint.Checkpoint{ThreadID: "t1", ID: checkpoint.StepID(3), Step: 3}
+	err := fmt.Errorf("invoking: %w", &worker.RunError{Err: errors.New("node failed"), Checkpoint: cp})
+	dead = worker.NewDeadLetter(run, 1, err)
+	assert.Equal(t, "invoking: node failed", dead.Error)
+	require.NotNil(t, dead.Checkpoint)
+	assert.Equal(t, cp, *dead.Checkpoint)
+}
+
+func TestMemoryQueueQuarantine(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := quarantined(t, "r1", "r2")
+
+	require.NoError(t, q.Requeue(ctx, "r1"))
+	require.ErrorIs(t, q.Requeue(ctx, "r1"), worker.ErrNotQuarantined)
+	d, err := q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 1, d.Attempt())
+
+	require.NoError(t, q.Discard(ctx, "r2"))
+	require.ErrorIs(t, q.Discard(ctx, "r2"), worker.ErrNotQuarantined)
+	dead, err := q.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestMemoryQueueReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "r1", Graph: "v1", ThreadID: "t1", Input: json.RawMessage(`"hi"`)}))
+	d, err := q.Receive(ctx)
+	require.NoError(t, err)
+	require.NoError(t, d.DeadLetter(ctx, errors.New("failed")))
+
+	// Failed migrations keep the dead letter.
+	broken := worker.MigrateInput("v2", func(string) ([]string, error) { return nil, errors.New("unsupported") })
+	require.ErrorContains(t, q.Replay(ctx, "r1", broken), "migrating run r1: unsupported")
+	require.Len(t, q.DeadLetters(), 1)
+	require.ErrorIs(t, q.Replay(ctx, "r2", broken), worker.ErrNotQuarantined)
+
+	// The version 2 of the graph takes a list of messages.
+	migrate := worker.MigrateInput("v2", func(msg string) ([]string, error) { return []string{msg}, nil })
+	require.NoError(t, q.Replay(ctx, "r1", migrate))
+	assert.Empty(t, q.DeadLetters())
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	run := d.Run()
+	assert.Equal(t, "r1", run.ID)
+	assert.Equal(t, "v2", run.Graph)
+	assert.Equal(t, "t1", run.ThreadID)
+	assert.JSONEq(t, `["hi"]`, string(run.Input))
+	assert.Equal(t, 1, d.Attempt())
+}
+
+func TestRetarget(t *testing.T) {
+	t.Parallel()
+
+	dead := worker.DeadLetter{Run: worker.Run{ID: "r1", Graph: "v1", Input: json.RawMessage(`[]`)}}
+	run, err := worker.Retarget("v2").Apply(context.Background(), dead)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", run.ID)
+	assert.Equal(t, "v2", run.Graph)
+	assert.Equal(t, dead.Run.Input, run.Input)
+	assert.False(t, run.EnqueuedAt.IsZero())
+
+	run, err = worker.Migration(nil).Apply(context.Background(), dead)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", run.Graph)
+}
+
+func TestConfirmSideEffects(t *testing.T) {
+	t.Parallel()
+
+	dead := worker.DeadLetter{Run: worker.Run{ID: "r1", Graph: "v1", Input: json.RawMessage(`[]`)}}
+	run, err := worker.ConfirmSideEffects().Apply(context.Background(), dead)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", run.ID)
+	assert.Equal(t, "v1", run.Graph)
+	assert.True(t, run.ConfirmSideEffects)
+}
+
+func TestQuarantineHandler(t *testing.T) {
+	t.Parallel()
+
+	q := quarantined(t, "r1", "r2", "r3")
+	server := httptest.NewServer(http.StripPrefix("/dead", worker.QuarantineHandler(q)))
+	defer server.Close()
+
+	do := func(method, path string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodGet, "/dead/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var dead []worker.DeadLetter
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dead))
+	require.Len(t, dead, 3)
+	assert.Equal(t, "r1", dead[0].Run.ID)
+	assert.Equal(t, "failed", dead[0].Error)
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/dead/r1/requeue", http.StatusNoContent},
+		{http.MethodPost, "/dead/r1/requeue", http.StatusNotFound},
+		{http.MethodPost, "/dead/r2/replay", http.StatusBadRequest},
+		{http.MethodPost, "/dead/r2/replay?graph=v2", http.StatusNoContent},
+		{http.MethodPost, "/dead/r9/replay?graph=v2", http.StatusNotFound},
+		{http.MethodPost, "/dead/r9/confirm", http.StatusNotFound},
+		{http.MethodDelete, "/dead/r3", http.StatusNoContent},
+		{http.MethodDelete, "/dead/r3", http.StatusNotFound},
+		{http.MethodPut, "/dead/r3", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, do(tt.method, tt.path).StatusCode, "%s %s", tt.method, tt.path)
+	}
+	assert.Equal(t, 2, q.Len())
+	assert.Empty(t, q.DeadLetters())
+}
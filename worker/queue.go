@@ -0,0 +1,334 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/checkpoint"
+)
+
+// ErrQueueClosed is returned by the queues once closed.
+var ErrQueueClosed = errors.New("queue closed")
+
+// Run is a graph invocation distributed to workers through a queue.
+type Run struct {
+	// ID identifies the run; queues use it to drop duplicate enqueues.
+	ID string `json:"id"`
+
+	// Graph is the name of the graph to invoke.
+	Graph string `json:"graph"`
+
+	// ThreadID is the thread the output is saved to; empty if none.
+	ThreadID string `json:"thread_id,omitempty"`
+
+	// Input is the JSON-encoded input state.
+	Input json.RawMessage `json:"input"`
+
+	// ConfirmSideEffects allows the run to execute again the side-effecting nodes a previous
+	// delivery may have executed. Handlers invoking graphs that checkpoint the thread of the
+	// run pass it on with graph.ConfirmSideEffects; see graph.WithSideEffects.
+	ConfirmSideEffects bool `json:"confirm_side_effects,omitempty"`
+
+	// EnqueuedAt is the time the run was enqueued.
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// Delivery is a run received from a queue. Exactly one of Ack, Nack and DeadLetter must be
+// called once the run was processed; a run whose delivery is never settled, e.g. because its
+// worker crashed, is redelivered by the queues that support it.
+type Delivery interface {
+	// Run returns the run delivered.
+	Run() Run
+
+	// Attempt is the number of times the run was delivered, including this one.
+	Attempt() int
+
+	// Ack removes the run from the queue.
+	Ack(ctx context.Context) error
+
+	// Nack makes the queue deliver the run again after delay.
+	Nack(ctx context.Context, delay time.Duration) error
+
+	// DeadLetter removes the run from the queue and quarantines it with the reason, so a
+	// poisoned run is not retried forever.
+	DeadLetter(ctx context.Context, reason error) error
+}
+
+// Leased is implemented by the deliveries that the queue can hand off to another worker
+// while they are processed, e.g. when the partition of a Kafka run is reassigned. Workers run
+// the handler with the context returned by Lease, which is cancelled with the delivery handed
+// off so the run stops before writing further checkpoints; the new owner runs it again.
+type Leased interface {
+	Delivery
+
+	// Lease returns a context derived from ctx, cancelled once the delivery is handed off.
+	Lease(ctx context.Context) context.Context
+}
+
+// Queue distributes runs to workers. Every run is delivered to one worker at a time.
+// Implementations must be safe for concurrent use.
+type Queue interface {
+	// Enqueue adds a run to the queue.
+	Enqueue(ctx context.Context, run Run) error
+
+	// Receive waits for the next run to process, until ctx is done.
+	Receive(ctx context.Context) (Delivery, error)
+}
+
+// DeadLetter is a run removed from a queue after failing.
+type DeadLetter struct {
+	// Run is the run.
+	Run Run `json:"run"`
+
+	// Attempts is the number of times the run was delivered.
+	Attempts int `json:"attempts"`
+
+	// Error is the reason the run was dead-lettered.
+	Error string `json:"error"`
+
+	// At is the time the run was dead-lettered.
+	At time.Time `json:"at"`
+
+	// Checkpoint is the latest checkpoint of the thread of the run when it failed, if the error
+	// of the run was a RunError.
+	Checkpoint *checkpoint.Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// MemoryQueue is an in-process Queue, for tests and single-process deployments.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	ready    []*memoryDelivery
+	seen     map[string]bool
+	dead     []DeadLetter
+	closed   bool
+	timers   map[*time.Timer]struct{}
+	notEmpty chan struct{}
+}
+
+var (
+	_ Queue      = (*MemoryQueue)(nil)
+	_ Quarantine = (*MemoryQueue)(nil)
+)
+
+// NewMemoryQueue returns an empty in-memory queue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		seen:     make(map[string]bool),
+		timers:   make(map[*time.Timer]struct{}),
+		notEmpty: make(chan struct{}),
+	}
+}
+
+// Enqueue adds a run to the queue. Runs whose ID was already enqueued are dropped.
+func (q *MemoryQueue) Enqueue(_ context.Context, run Run) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if run.ID != "" {
+		if q.seen[run.ID] {
+			return nil
+		}
+		q.seen[run.ID] = true
+	}
+	if run.EnqueuedAt.IsZero() {
+		run.EnqueuedAt = time.Now()
+	}
+	q.push(&memoryDelivery{queue: q, run: run, attempt: 1})
+	return nil
+}
+
+// push makes a delivery ready and wakes up the receivers. The caller holds mu.
+func (q *MemoryQueue) push(d *memoryDelivery) {
+	q.ready = append(q.ready, d)
+	close(q.notEmpty)
+	q.notEmpty = make(chan struct{})
+}
+
+// Receive waits for the next run.
+func (q *MemoryQueue) Receive(ctx context.Context) (Delivery, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+		if len(q.ready) > 0 {
+			d := q.ready[0]
+			q.ready = q.ready[1:]
+			q.mu.Unlock()
+			return d, nil
+		}
+		notEmpty := q.notEmpty
+		q.mu.Unlock()
+
+		select {
+		case <-notEmpty:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of runs ready to be received.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// DeadLetters returns the runs dead-lettered, in the order they were.
+func (q *MemoryQueue) DeadLetters() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]DeadLetter(nil), q.dead...)
+}
+
+// List returns the runs dead-lettered, in the order they were.
+func (q *MemoryQueue) List(context.Context) ([]DeadLetter, error) {
+	return q.DeadLetters(), nil
+}
+
+// Requeue removes the dead letter of a run and makes the run ready again.
+func (q *MemoryQueue) Requeue(ctx context.Context, runID string) error {
+	return q.Replay(ctx, runID, nil)
+}
+
+// Replay removes the dead letter of a run and makes the run returned by migrate ready.
+func (q *MemoryQueue) Replay(ctx context.Context, runID string, migrate Migration) error {
+	q.mu.Lock()
+	i := slices.IndexFunc(q.dead, func(dead DeadLetter) bool { return dead.Run.ID == runID })
+	if i < 0 {
+		q.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotQuarantined, runID)
+	}
+	dead := q.dead[i]
+	q.mu.Unlock()
+
+	run, err := migrate.Apply(ctx, dead)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if _, err := q.removeDeadLetter(runID); err != nil {
+		return err
+	}
+	q.push(&memoryDelivery{queue: q, run: run, attempt: 1})
+	return nil
+}
+
+// Discard removes the dead letter of a run.
+func (q *MemoryQueue) Discard(_ context.Context, runID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, err := q.removeDeadLetter(runID)
+	return err
+}
+
+// removeDeadLetter removes the first dead letter of a run. The caller holds mu.
+func (q *MemoryQueue) removeDeadLetter(runID string) (DeadLetter, error) {
+	for i, dead := range q.dead {
+		if dead.Run.ID == runID {
+			q.dead = slices.Delete(q.dead, i, i+1)
+			return dead, nil
+		}
+	}
+	return DeadLetter{}, fmt.Errorf("%w: %s", ErrNotQuarantined, runID)
+}
+
+// Close wakes up the receivers with ErrQueueClosed and drops the runs waiting to be
+// redelivered.
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	for timer := range q.timers {
+		timer.Stop()
+	}
+	close(q.notEmpty)
+	return nil
+}
+
+type memoryDelivery struct {
+	queue   *MemoryQueue
+	run     Run
+	attempt int
+
+	settled bool
+}
+
+func (d *memoryDelivery) Run() Run     { return d.run }
+func (d *memoryDelivery) Attempt() int { return d.attempt }
+
+// settle marks the delivery as settled. The caller holds the lock of the queue.
+func (d *memoryDelivery) settle() error {
+	if d.settled {
+		return fmt.Errorf("run %s: delivery already settled", d.run.ID)
+	}
+	d.settled = true
+	return nil
+}
+
+func (d *memoryDelivery) Ack(context.Context) error {
+	d.queue.mu.Lock()
+	defer d.queue.mu.Unlock()
+	return d.settle()
+}
+
+func (d *memoryDelivery) Nack(_ context.Context, delay time.Duration) error {
+	q := d.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := d.settle(); err != nil {
+		return err
+	}
+	if q.closed {
+		return nil
+	}
+	next := &memoryDelivery{queue: q, run: d.run, attempt: d.attempt + 1}
+	if delay <= 0 {
+		q.push(next)
+		return nil
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.timers, timer)
+		if !q.closed {
+			q.push(next)
+		}
+	})
+	q.timers[timer] = struct{}{}
+	return nil
+}
+
+func (d *memoryDelivery) DeadLetter(_ context.Context, reason error) error {
+	q := d.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := d.settle(); err != nil {
+		return err
+	}
+	q.dead = append(q.dead, NewDeadLetter(d.run, d.attempt, reason))
+	return nil
+}
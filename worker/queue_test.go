@@ -0,0 +1,58 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "r1", Graph: "g"}))
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "r1", Graph: "g"}))
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "r2", Graph: "g"}))
+	assert.Equal(t, 2, q.Len())
+
+	d, err := q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 1, d.Attempt())
+	assert.False(t, d.Run().EnqueuedAt.IsZero())
+	require.NoError(t, d.Nack(ctx, 10*time.Millisecond))
+	require.Error(t, d.Ack(ctx))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r2", d.Run().ID)
+	require.NoError(t, d.DeadLetter(ctx, errors.New("poisoned")))
+
+	d, err = q.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", d.Run().ID)
+	assert.Equal(t, 2, d.Attempt())
+	require.NoError(t, d.Ack(ctx))
+
+	dead := q.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, "r2", dead[0].Run.ID)
+	assert.Equal(t, 1, dead[0].Attempts)
+	assert.Equal(t, "poisoned", dead[0].Error)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = q.Receive(timeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, q.Close())
+	_, err = q.Receive(ctx)
+	require.ErrorIs(t, err, worker.ErrQueueClosed)
+	require.ErrorIs(t, q.Enqueue(ctx, worker.Run{ID: "r3"}), worker.ErrQueueClosed)
+}
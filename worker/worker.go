@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cesto93/langgraphgo/app"
+	"github.com/cesto93/langgraphgo/graph"
+)
+
+// ErrPermanent marks the errors of runs that fail the same way on every attempt, such as an
+// undecodable input. Workers dead-letter such runs without retrying them.
+var ErrPermanent = errors.New("permanent failure")
+
+const (
+	// DefaultMaxAttempts is the number of attempts of a run when a Worker sets none.
+	DefaultMaxAttempts = 3
+
+	// DefaultBackoff is the delay before the first retry of a run when a Worker sets none.
+	DefaultBackoff = time.Second
+)
+
+// Handler processes a run.
+type Handler func(ctx context.Context, run Run) error
+
+// AppHandler returns a handler invoking the graphs of an application: the input of a run is
+// decoded as the state of the graph, and the output is saved to the thread of the run if the
+// graph has a checkpointer. Runs of unknown graphs and undecodable inputs fail with
+// ErrPermanent. Failures of runs on threads with checkpoints are RunErrors carrying the latest
+// checkpoint of the thread. The graphs are invoked with the ID of the run as their run ID; see
+// graph.RunID.
+func AppHandler[T any](a *app.App[T]) Handler {
+	return func(ctx context.Context, run Run) error {
+		var state T
+		if err := json.Unmarshal(run.Input, &state); err != nil {
+			return fmt.Errorf("%w: decoding input: %v", ErrPermanent, err)
+		}
+		_, err := a.Invoke(graph.WithRunID(ctx, run.ID), run.Graph, run.ThreadID, state)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, app.ErrUnknownGraph):
+			return fmt.Errorf("%w: %w", ErrPermanent, err)
+		}
+
+		if cp, ok := a.GraphCheckpointer(run.Graph); ok && run.ThreadID != "" {
+			if latest, cpErr := cp.Latest(ctx, run.ThreadID); cpErr == nil {
+				return &RunError{Err: err, Checkpoint: latest}
+			}
+		}
+		return err
+	}
+}
+
+// Worker processes the runs of a queue.
+type Worker struct {
+	// Queue delivers the runs.
+	Queue Queue
+
+	// Handler processes every run.
+	Handler Handler
+
+	// Concurrency is the number of runs processed at once; one is used when not positive.
+	Concurrency int
+
+	// MaxAttempts is the number of attempts of a run before it is dead-lettered;
+	// DefaultMaxAttempts is used when not positive.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry of a run, doubled on every further retry;
+	// DefaultBackoff is used when zero.
+	Backoff time.Duration
+}
+
+// Run processes runs until ctx is done or the queue is closed, then waits for the runs in
+// progress. Failed runs are retried with backoff, and dead-lettered once they failed
+// MaxAttempts times or with ErrPermanent. Runs delivered more than MaxAttempts times, because
+// they crashed their workers, are dead-lettered with ErrPoisoned without being processed.
+// Errors settling deliveries are logged: the queue redelivers those runs.
+func (w *Worker) Run(ctx context.Context) error {
+	concurrency := max(w.Concurrency, 1)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, concurrency)
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		d, err := w.Queue.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrQueueClosed) {
+				return nil
+			}
+			return fmt.Errorf("receiving run: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			w.process(ctx, d)
+		}()
+	}
+}
+
+// process handles a delivery and settles it.
+func (w *Worker) process(ctx context.Context, d Delivery) {
+	run := d.Run()
+	// Settle deliveries even when ctx is done, so the queue does not wait for them to expire.
+	settleCtx := context.WithoutCancel(ctx)
+	maxAttempts := w.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	// Runs are settled before their attempts exceed the maximum, unless they crashed their
+	// workers: quarantine them rather than crash another one.
+	if d.Attempt() > maxAttempts {
+		err := fmt.Errorf("%w: %d attempts", ErrPoisoned, d.Attempt())
+		slog.WarnContext(ctx, "dead-lettering run", "run", run.ID, "graph", run.Graph, "attempt", d.Attempt(), "error", err)
+		if err := d.DeadLetter(settleCtx, err); err != nil {
+			slog.ErrorContext(ctx, "settling run", "run", run.ID, "graph", run.Graph, "error", err)
+		}
+		return
+	}
+
+	runCtx := ctx
+	if l, ok := d.(Leased); ok {
+		runCtx = l.Lease(ctx)
+	}
+	err := w.Handler(runCtx, run)
+	switch {
+	case err == nil:
+		err = d.Ack(settleCtx)
+	case runCtx.Err() != nil:
+		// The worker is stopping or the run was handed off: let another worker retry the run
+		// right away.
+		err = d.Nack(settleCtx, 0)
+	case errors.Is(err, ErrPermanent) || d.Attempt() >= maxAttempts:
+		slog.WarnContext(ctx, "dead-lettering run", "run", run.ID, "graph", run.Graph, "attempt", d.Attempt(), "error", err)
+		err = d.DeadLetter(settleCtx, err)
+	default:
+		backoff := w.Backoff
+		if backoff == 0 {
+			backoff = DefaultBackoff
+		}
+		err = d.Nack(settleCtx, backoff<<(d.Attempt()-1))
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "settling run", "run", run.ID, "graph", run.Graph, "error", err)
+	}
+}
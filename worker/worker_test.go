@@ -0,0 +1,227 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cesto93/langgraphgo/app"
+	"github.com/cesto93/langgraphgo/checkpoint"
+	"github.com/cesto93/langgraphgo/spec"
+	"github.com/cesto93/langgraphgo/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	for _, id := range []string{"ok", "flaky", "broken", "poisoned"} {
+		require.NoError(t, q.Enqueue(ctx, worker.Run{ID: id, Graph: "g"}))
+	}
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	w := worker.Worker{
+		Queue:       q,
+		Concurrency: 2,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Handler: func(_ context.Context, run worker.Run) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[run.ID]++
+			switch {
+			case run.ID == "flaky" && attempts[run.ID] < 2:
+				return errors.New("transient")
+			case run.ID == "broken":
+				return errors.New("always fails")
+			case run.ID == "poisoned":
+				return fmt.Errorf("%w: bad input", worker.ErrPermanent)
+			}
+			return nil
+		},
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(q.DeadLetters()) == 2 && q.Len() == 0 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, q.Close())
+	require.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"ok": 1, "flaky": 2, "broken": 3, "poisoned": 1}, attempts)
+
+	dead := q.DeadLetters()
+	reasons := map[string]string{dead[0].Run.ID: dead[0].Error, dead[1].Run.ID: dead[1].Error}
+	assert.Equal(t, map[string]string{"broken": "always fails", "poisoned": "permanent failure: bad input"}, reasons)
+}
+
+func TestWorkerPoisoned(t *testing.T) {
+	t.Parallel()
+
+	// A run delivered three times without being settled, as if it crashed two workers.
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "crasher", Graph: "g"}))
+	for range 2 {
+		d, err := q.Receive(ctx)
+		require.NoError(t, err)
+		require.NoError(t, d.Nack(ctx, 0))
+	}
+
+	var calls atomic.Int32
+	w := worker.Worker{
+		Queue:       q,
+		MaxAttempts: 2,
+		Handler: func(context.Context, worker.Run) error {
+			calls.Add(1)
+			return nil
+		},
+	}
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(q.DeadLetters()) == 1 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, q.Close())
+	require.NoError(t, <-done)
+
+	assert.Zero(t, calls.Load())
+	dead := q.DeadLetters()[0]
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, "run delivered too many times: 3 attempts", dead.Error)
+}
+
+func TestAppHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := spec.NewRegistry[[]string]()
+	registry.Register("append", func(node spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		return func(_ context.Context, state []string) ([]string, error) {
+			return append(state, node.Name), nil
+		}, nil
+	})
+	registry.Register("fail", func(node spec.NodeSpec) (func(context.Context, []string) ([]string, error), error) {
+		return func(context.Context, []string) ([]string, error) {
+			return nil, errors.New("node failed")
+		}, nil
+	})
+	m, err := app.ParseManifest([]byte(`
+checkpointers:
+  memory:
+    type: memory
+graphs:
+  greet:
+    spec: greet.yaml
+    checkpointer: memory
+  broken:
+    spec: broken.yaml
+    checkpointer: memory
+`))
+	require.NoError(t, err)
+	a, err := app.New(m, fstest.MapFS{
+		"greet.yaml": {Data: []byte(`
+entry_point: hello
+nodes:
+  - name: hello
+    type: append
+edges:
+  - from: hello
+    to: END
+`)},
+		"broken.yaml": {Data: []byte(`
+entry_point: crash
+nodes:
+  - name: crash
+    type: fail
+edges:
+  - from: crash
+    to: END
+`)},
+	}, registry)
+	require.NoError(t, err)
+
+	handler := worker.AppHandler(a)
+	ctx := context.Background()
+	require.NoError(t, handler(ctx, worker.Run{Graph: "greet", ThreadID: "t1", Input: json.RawMessage(`["hi"]`)}))
+
+	cp, _ := a.Checkpointer("memory")
+	latest, err := cp.Latest(ctx, "t1")
+	require.NoError(t, err)
+	state, err := checkpoint.Decode[[]string](latest.State)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hi", "hello"}, state)
+
+	// Failures carry the checkpoint the thread failed from.
+	err = handler(ctx, worker.Run{Graph: "broken", ThreadID: "t1", Input: json.RawMessage(`["again"]`)})
+	var runErr *worker.RunError
+	require.ErrorAs(t, err, &runErr)
+	assert.Equal(t, latest, runErr.Checkpoint)
+	err = handler(ctx, worker.Run{Graph: "broken", ThreadID: "t2", Input: json.RawMessage(`[]`)})
+	require.Error(t, err)
+	require.False(t, errors.As(err, &runErr))
+
+	require.ErrorIs(t, handler(ctx, worker.Run{Graph: "greet", Input: json.RawMessage(`{`)}), worker.ErrPermanent)
+	require.ErrorIs(t, handler(ctx, worker.Run{Graph: "missing", Input: json.RawMessage(`[]`)}), worker.ErrPermanent)
+}
+
+// leasedQueue hands off the deliveries of a MemoryQueue as soon as they are received.
+type leasedQueue struct {
+	*worker.MemoryQueue
+}
+
+func (q leasedQueue) Receive(ctx context.Context) (worker.Delivery, error) {
+	d, err := q.MemoryQueue.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handedOff{d}, nil
+}
+
+type handedOff struct {
+	worker.Delivery
+}
+
+// Attempt does not count the handoffs, as the new owner of a handed-off delivery would.
+func (handedOff) Attempt() int { return 1 }
+
+func (handedOff) Lease(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	return ctx
+}
+
+func TestWorkerLease(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := worker.NewMemoryQueue()
+	require.NoError(t, q.Enqueue(ctx, worker.Run{ID: "r1", Graph: "g"}))
+
+	var attempts atomic.Int32
+	w := worker.Worker{
+		Queue:       leasedQueue{q},
+		MaxAttempts: 1,
+		Handler: func(ctx context.Context, run worker.Run) error {
+			<-ctx.Done()
+			attempts.Add(1)
+			return ctx.Err()
+		},
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	// Handed-off runs are nacked rather than dead-lettered, whatever their attempt.
+	require.Eventually(t, func() bool { return attempts.Load() >= 2 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, q.Close())
+	require.NoError(t, <-done)
+	assert.Empty(t, q.DeadLetters())
+}